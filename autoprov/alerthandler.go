@@ -17,6 +17,7 @@ package main
 import (
 	"context"
 
+	"github.com/mobiledgex/edge-cloud-infra/autoprov/autorules"
 	"github.com/mobiledgex/edge-cloud/cloudcommon"
 	"github.com/mobiledgex/edge-cloud/edgeproto"
 	"github.com/mobiledgex/edge-cloud/log"
@@ -43,6 +44,8 @@ func alertChanged(ctx context.Context, old *edgeproto.Alert, new *edgeproto.Aler
 		clusterAutoScaleWorkers.NeedsWork(ctx, new.GetKeyVal())
 	case cloudcommon.AlertAutoUndeploy:
 		handler = autoUndeploy
+	case autorules.AlertAutoProvResume:
+		handler = autoProvResume
 	}
 
 	if handler == nil {
@@ -76,13 +79,14 @@ func autoUndeploy(ctx context.Context, name string, alert *edgeproto.Alert) erro
 		return nil
 	}
 	inst := edgeproto.AppInst{}
-	inst.Key.AppKey.Organization = alert.Labels[edgeproto.AppKeyTagOrganization]
-	inst.Key.AppKey.Name = alert.Labels[edgeproto.AppKeyTagName]
-	inst.Key.AppKey.Version = alert.Labels[edgeproto.AppKeyTagVersion]
-	inst.Key.ClusterInstKey.ClusterKey.Name = alert.Labels[edgeproto.ClusterKeyTagName]
-	inst.Key.ClusterInstKey.Organization = alert.Labels[edgeproto.ClusterInstKeyTagOrganization]
-	inst.Key.ClusterInstKey.CloudletKey.Name = alert.Labels[edgeproto.CloudletKeyTagName]
-	inst.Key.ClusterInstKey.CloudletKey.Organization = alert.Labels[edgeproto.CloudletKeyTagOrganization]
+	inst.Key = autorules.GetAlertAppInstKey(alert)
+
+	app := edgeproto.App{}
+	if cacheData.appCache.Get(&inst.Key.AppKey, &app) && autorules.IsAutoProvSuspendEnabled(&app) {
+		if suspended := autoProvSuspend(ctx, &app, &inst); suspended {
+			return nil
+		}
+	}
 
 	// we're already in a separate go thread so don't need another one here
 	goAppInstApi(ctx, &inst, cloudcommon.Delete, cloudcommon.AutoProvReasonDemand, "")
@@ -48,6 +48,7 @@ var vaultConfig *vault.Config
 var autoProvAggr *AutoProvAggr
 var minMaxChecker *MinMaxChecker
 var retryTracker *RetryTracker
+var maintTracker = newMaintenanceTracker()
 var settings edgeproto.Settings
 var nodeMgr node.NodeMgr
 
@@ -97,6 +98,7 @@ func start() error {
 	cacheData.alertCache.AddUpdatedCb(alertChanged)
 
 	autoProvAggr.Start()
+	maintTracker.Start()
 
 	addrs := strings.Split(*notifyAddrs, ",")
 	notifyClient = notify.NewClient(nodeMgr.Name(), addrs, dialOpts)
@@ -112,6 +114,7 @@ func stop() {
 	if autoProvAggr != nil {
 		autoProvAggr.Stop()
 	}
+	maintTracker.Stop()
 	if notifyClient != nil {
 		notifyClient.Stop()
 	}
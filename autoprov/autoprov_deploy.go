@@ -43,6 +43,10 @@ func goAppInstApi(ctx context.Context, inst *edgeproto.AppInst, action cloudcomm
 		log.SpanLog(ctx, log.DebugLevelApi, "invalid action", "action", action.String())
 		return fmt.Errorf("invalid action")
 	}
+	if maintTracker.underMaintenance(inst.Key.ClusterInstKey.CloudletKey) {
+		log.SpanLog(ctx, log.DebugLevelApi, "skip auto-prov deploy, cloudlet under maintenance", "cloudlet", inst.Key.ClusterInstKey.CloudletKey)
+		return fmt.Errorf("Cloudlet %s is under maintenance", inst.Key.ClusterInstKey.CloudletKey.GetKeyString())
+	}
 	eventStart := time.Now()
 	eventName := "AutoProv create AppInst"
 	if action == cloudcommon.Delete {
@@ -63,7 +67,7 @@ func goAppInstApi(ctx context.Context, inst *edgeproto.AppInst, action cloudcomm
 	return err
 }
 
-func runAppInstApi(ctx context.Context, inst *edgeproto.AppInst, action cloudcommon.Action, reason, policyName string) error {
+func dialCtrl() (*grpc.ClientConn, error) {
 	opts := []grpc.DialOption{}
 	if dialOpts != nil {
 		opts = append(opts, dialOpts)
@@ -74,17 +78,25 @@ func runAppInstApi(ctx context.Context, inst *edgeproto.AppInst, action cloudcom
 	opts = append(opts, grpc.WithBlock(),
 		grpc.WithUnaryInterceptor(log.UnaryClientTraceGrpc),
 		grpc.WithStreamInterceptor(log.StreamClientTraceGrpc))
-	conn, err := grpc.Dial(*ctrlAddr, opts...)
-	if err != nil {
-		return err
-	}
-	defer conn.Close()
+	return grpc.Dial(*ctrlAddr, opts...)
+}
 
+func autoProvOutgoingContext(ctx context.Context, reason, policyName string) context.Context {
 	kvPairs := []string{
 		cloudcommon.CallerAutoProv, "",
 		cloudcommon.AutoProvReason, reason,
 		cloudcommon.AutoProvPolicyName, policyName}
-	ctx = metadata.AppendToOutgoingContext(ctx, kvPairs...)
+	return metadata.AppendToOutgoingContext(ctx, kvPairs...)
+}
+
+func runAppInstApi(ctx context.Context, inst *edgeproto.AppInst, action cloudcommon.Action, reason, policyName string) error {
+	conn, err := dialCtrl()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx = autoProvOutgoingContext(ctx, reason, policyName)
 	client := edgeproto.NewAppInstApiClient(conn)
 	var stream edgeproto.AppInstApi_CreateAppInstClient
 	switch action {
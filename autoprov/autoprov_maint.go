@@ -0,0 +1,151 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mobiledgex/edge-cloud-infra/autoprov/maintwindow"
+	"github.com/mobiledgex/edge-cloud/edgeproto"
+	"github.com/mobiledgex/edge-cloud/log"
+)
+
+var MaintPollInterval = 1 * time.Minute
+
+// MaintenanceTracker tracks the cloudlets under an open maintenance
+// window, as published by the MC. While a cloudlet is under maintenance,
+// no AppInsts are deployed to or undeployed from it, and its ClusterInsts
+// are not auto-scaled.
+type MaintenanceTracker struct {
+	cloudlets map[edgeproto.CloudletKey]struct{}
+	mux       sync.Mutex
+	stop      chan struct{}
+	waitGroup sync.WaitGroup
+}
+
+func newMaintenanceTracker() *MaintenanceTracker {
+	s := MaintenanceTracker{}
+	s.cloudlets = make(map[edgeproto.CloudletKey]struct{})
+	return &s
+}
+
+func (s *MaintenanceTracker) Start() {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.stop != nil {
+		// already started
+		return
+	}
+	s.stop = make(chan struct{})
+	s.waitGroup.Add(1)
+	go s.Run()
+}
+
+func (s *MaintenanceTracker) Stop() {
+	s.mux.Lock()
+	if s.stop == nil {
+		// not started, i.e. stop after a startup error
+		s.mux.Unlock()
+		return
+	}
+	close(s.stop)
+	s.mux.Unlock()
+	s.waitGroup.Wait()
+	s.mux.Lock()
+	s.stop = nil
+	s.mux.Unlock()
+}
+
+func (s *MaintenanceTracker) Run() {
+	done := false
+	for !done {
+		span := log.StartSpan(log.DebugLevelMetrics, "maintenance-tracker")
+		ctx := log.ContextWithSpan(context.Background(), span)
+		data, err := maintwindow.GetMaintenanceCloudlets(vaultConfig, *region)
+		if err != nil {
+			// keep the current state until we can read it again
+			log.SpanLog(ctx, log.DebugLevelMetrics, "Failed to get cloudlets under maintenance", "err", err)
+		} else {
+			s.update(ctx, data.Cloudlets)
+		}
+		span.Finish()
+
+		select {
+		case <-time.After(MaintPollInterval):
+		case <-s.stop:
+			done = true
+		}
+	}
+	s.waitGroup.Done()
+}
+
+func (s *MaintenanceTracker) update(ctx context.Context, cloudlets []edgeproto.CloudletKey) {
+	newCloudlets := make(map[edgeproto.CloudletKey]struct{})
+	for _, key := range cloudlets {
+		newCloudlets[key] = struct{}{}
+	}
+
+	s.mux.Lock()
+	ended := []edgeproto.CloudletKey{}
+	for key := range s.cloudlets {
+		if _, found := newCloudlets[key]; !found {
+			ended = append(ended, key)
+		}
+	}
+	for key := range newCloudlets {
+		if _, found := s.cloudlets[key]; !found {
+			log.SpanLog(ctx, log.DebugLevelMetrics, "cloudlet maintenance started", "cloudlet", key)
+		}
+	}
+	s.cloudlets = newCloudlets
+	s.mux.Unlock()
+
+	for _, key := range ended {
+		log.SpanLog(ctx, log.DebugLevelMetrics, "cloudlet maintenance ended", "cloudlet", key)
+		s.maintenanceEnded(ctx, key)
+	}
+}
+
+// Re-run the actions held off during maintenance
+func (s *MaintenanceTracker) maintenanceEnded(ctx context.Context, key edgeproto.CloudletKey) {
+	if minMaxChecker != nil {
+		minMaxChecker.mux.Lock()
+		appsToCheck := minMaxChecker.cloudletNeedsCheck(key)
+		minMaxChecker.mux.Unlock()
+		for appKey, _ := range appsToCheck {
+			minMaxChecker.workers.NeedsWork(ctx, appKey)
+		}
+	}
+	alerts := []edgeproto.Alert{}
+	cacheData.alertCache.Show(&edgeproto.Alert{}, func(alert *edgeproto.Alert) error {
+		if alert.Labels[edgeproto.CloudletKeyTagName] == key.Name &&
+			alert.Labels[edgeproto.CloudletKeyTagOrganization] == key.Organization {
+			alerts = append(alerts, *alert)
+		}
+		return nil
+	})
+	for ii := range alerts {
+		alertChanged(ctx, nil, &alerts[ii])
+	}
+}
+
+func (s *MaintenanceTracker) underMaintenance(key edgeproto.CloudletKey) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	_, found := s.cloudlets[key]
+	return found
+}
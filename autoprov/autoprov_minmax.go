@@ -460,9 +460,14 @@ func (s *AppChecker) checkPolicy(ctx context.Context, app *edgeproto.App, pname
 	for _, apCloudlet := range policy.Cloudlets {
 		s.policyCloudlets[apCloudlet.Key] = struct{}{}
 
+		// Cloudlets under maintenance are expected to be offline for
+		// a while, so assume their AppInsts are still online to avoid
+		// failing them over, and do not deploy to or undeploy from them.
+		underMaintenance := maintTracker.underMaintenance(apCloudlet.Key)
+
 		insts, found := s.cloudletInsts[apCloudlet.Key]
 		if !found {
-			if !s.cloudletOnline(&apCloudlet.Key) {
+			if underMaintenance || !s.cloudletOnline(&apCloudlet.Key) {
 				continue
 			}
 			if retryTracker.hasFailure(ctx, app.Key, apCloudlet.Key) {
@@ -487,10 +492,10 @@ func (s *AppChecker) checkPolicy(ctx context.Context, app *edgeproto.App, pname
 				// potentially cause problems if AppInsts are
 				// stuck in a going-online transitional state,
 				// however.
-				if s.appInstOnlineOrGoingOnline(ctx, &appInstKey) {
+				if underMaintenance || s.appInstOnlineOrGoingOnline(ctx, &appInstKey) {
 					onlineCount++
 				}
-				if s.isAutoProvInst(&appInstKey) {
+				if !underMaintenance && s.isAutoProvInst(&appInstKey) {
 					potentialDelete = append(potentialDelete, appInstKey)
 				}
 			}
@@ -299,6 +299,24 @@ func TestAppChecker(t *testing.T) {
 	err = dc.waitForAppInsts(ctx, int(pt1.policy.MinActiveInstances))
 	require.Nil(t, err)
 
+	// AppInsts on cloudlets under maintenance are not failed over
+	maintInsts := pt1.getAppInsts(&app.Key)
+	maintCloudlet := maintInsts[0].Key.ClusterInstKey.CloudletKey
+	maintTracker.update(ctx, []edgeproto.CloudletKey{maintCloudlet})
+	require.True(t, maintTracker.underMaintenance(maintCloudlet))
+	maintInsts[0].HealthCheck = dme.HealthCheck_HEALTH_CHECK_SERVER_FAIL
+	dc.updateAppInst(ctx, &maintInsts[0])
+	minmax.CheckApp(ctx, app.Key)
+	err = dc.waitForAppInsts(ctx, int(pt1.policy.MinActiveInstances))
+	require.Nil(t, err)
+	// end maintenance with the AppInst healthy again
+	maintInsts[0].HealthCheck = dme.HealthCheck_HEALTH_CHECK_OK
+	dc.updateAppInst(ctx, &maintInsts[0])
+	maintTracker.update(ctx, []edgeproto.CloudletKey{})
+	require.False(t, maintTracker.underMaintenance(maintCloudlet))
+	// stop without start, i.e. after a startup error, does not panic
+	newMaintenanceTracker().Stop()
+
 	// simulate AppInst health check failure,
 	// this should create another inst
 	insts := pt1.getAppInsts(&app.Key)
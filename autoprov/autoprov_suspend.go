@@ -0,0 +1,172 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/mobiledgex/edge-cloud-infra/autoprov/autorules"
+	"github.com/mobiledgex/edge-cloud/cloudcommon"
+	"github.com/mobiledgex/edge-cloud/cloudcommon/node"
+	"github.com/mobiledgex/edge-cloud/edgeproto"
+	"github.com/mobiledgex/edge-cloud/log"
+	"github.com/opentracing/opentracing-go"
+)
+
+// Idle AppInsts of Apps with the autoprovsuspend annotation are
+// suspended instead of undeployed, so that they keep their DNS
+// and rootLB ports and can be resumed quickly. Kubernetes AppInsts
+// are scaled to zero replicas by shepherd, which raises the suspended
+// alert once done. VM AppInsts are powered off here, and powered back
+// on when shepherd raises the resume alert after seeing a new
+// connection on the rootLB proxy. If the suspend is not confirmed,
+// the AppInst is undeployed instead.
+
+// How long to wait for shepherd to confirm a kubernetes suspend
+var AutoProvSuspendConfirmTimeout = 3 * time.Minute
+var autoProvSuspendConfirmInterval = 5 * time.Second
+
+// Suspend the AppInst for the auto undeploy alert. Returns false
+// if the AppInst was not suspended and should be undeployed.
+func autoProvSuspend(ctx context.Context, app *edgeproto.App, inst *edgeproto.AppInst) bool {
+	switch app.Deployment {
+	case cloudcommon.DeploymentTypeKubernetes:
+		fallthrough
+	case cloudcommon.DeploymentTypeHelm:
+		return waitAutoProvSuspended(ctx, &inst.Key)
+	case cloudcommon.DeploymentTypeVM:
+		cur := edgeproto.AppInst{}
+		if !cacheData.appInstCache.Get(&inst.Key, &cur) {
+			log.SpanLog(ctx, log.DebugLevelApi, "AppInst to suspend not found", "AppInst", inst.Key)
+			return true
+		}
+		if cur.PowerState != edgeproto.PowerState_POWER_ON {
+			log.SpanLog(ctx, log.DebugLevelApi, "skip auto-undeploy, AppInst already suspended", "AppInst", inst.Key, "powerState", cur.PowerState)
+			return true
+		}
+		err := goAppInstPowerState(ctx, inst, edgeproto.PowerState_POWER_OFF, cloudcommon.AutoProvReasonDemand)
+		if err != nil {
+			log.SpanLog(ctx, log.DebugLevelApi, "auto-prov suspend failed, undeploying", "AppInst", inst.Key, "err", err)
+			return false
+		}
+		return true
+	}
+	// docker AppInsts cannot be suspended
+	return false
+}
+
+// Wait for shepherd to raise the suspended alert for the kubernetes
+// AppInst. Returns false if it does not within the timeout.
+func waitAutoProvSuspended(ctx context.Context, key *edgeproto.AppInstKey) bool {
+	start := time.Now()
+	for {
+		if isAutoProvSuspended(key) {
+			log.SpanLog(ctx, log.DebugLevelApi, "skip auto-undeploy, AppInst suspended by shepherd", "AppInst", key)
+			return true
+		}
+		if time.Since(start) >= AutoProvSuspendConfirmTimeout {
+			log.SpanLog(ctx, log.DebugLevelApi, "AppInst suspend not confirmed by shepherd, undeploying", "AppInst", key, "timeout", AutoProvSuspendConfirmTimeout)
+			return false
+		}
+		time.Sleep(autoProvSuspendConfirmInterval)
+	}
+}
+
+func isAutoProvSuspended(key *edgeproto.AppInstKey) bool {
+	found := false
+	cacheData.alertCache.Show(&edgeproto.Alert{}, func(alert *edgeproto.Alert) error {
+		if alert.Labels["alertname"] == autorules.AlertAutoProvSuspended && alert.State == "firing" && autorules.GetAlertAppInstKey(alert) == *key {
+			found = true
+		}
+		return nil
+	})
+	return found
+}
+
+func autoProvResume(ctx context.Context, name string, alert *edgeproto.Alert) error {
+	if alert.State != "firing" {
+		return nil
+	}
+	inst := edgeproto.AppInst{}
+	inst.Key = autorules.GetAlertAppInstKey(alert)
+	cur := edgeproto.AppInst{}
+	if !cacheData.appInstCache.Get(&inst.Key, &cur) {
+		log.SpanLog(ctx, log.DebugLevelApi, "AppInst to resume not found", "AppInst", inst.Key)
+		return nil
+	}
+	if cur.PowerState != edgeproto.PowerState_POWER_OFF {
+		log.SpanLog(ctx, log.DebugLevelApi, "skip auto-prov resume, AppInst not powered off", "AppInst", inst.Key, "powerState", cur.PowerState)
+		return nil
+	}
+	return goAppInstPowerState(ctx, &inst, edgeproto.PowerState_POWER_ON, cloudcommon.AutoProvReasonDemand)
+}
+
+func goAppInstPowerState(ctx context.Context, inst *edgeproto.AppInst, powerState edgeproto.PowerState, reason string) error {
+	span := log.StartSpan(log.DebugLevelApi, "auto-prov power state "+powerState.String(), opentracing.ChildOf(log.SpanFromContext(ctx).Context()))
+	log.SetTags(span, inst.Key.GetTags())
+	span.SetTag("reason", reason)
+	defer span.Finish()
+	ctx = log.ContextWithSpan(context.Background(), span)
+
+	log.SpanLog(ctx, log.DebugLevelApi, "auto-prov power state", "AppInst", inst.Key, "powerState", powerState, "reason", reason)
+	if maintTracker.underMaintenance(inst.Key.ClusterInstKey.CloudletKey) {
+		log.SpanLog(ctx, log.DebugLevelApi, "skip auto-prov power state, cloudlet under maintenance", "cloudlet", inst.Key.ClusterInstKey.CloudletKey)
+		return fmt.Errorf("Cloudlet %s is under maintenance", inst.Key.ClusterInstKey.CloudletKey.GetKeyString())
+	}
+	eventStart := time.Now()
+	eventName := "AutoProv suspend AppInst"
+	if powerState == edgeproto.PowerState_POWER_ON {
+		eventName = "AutoProv resume AppInst"
+	}
+
+	err := runAppInstPowerState(ctx, inst, powerState, reason)
+	log.SpanLog(ctx, log.DebugLevelApi, "auto-prov power state result", "err", err)
+	nodeMgr.TimedEvent(ctx, eventName, inst.Key.AppKey.Organization, node.EventType, inst.Key.GetTags(), err, eventStart, time.Now(), "reason", reason, "powerstate", powerState.String())
+	return err
+}
+
+func runAppInstPowerState(ctx context.Context, inst *edgeproto.AppInst, powerState edgeproto.PowerState, reason string) error {
+	conn, err := dialCtrl()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx = autoProvOutgoingContext(ctx, reason, "")
+	client := edgeproto.NewAppInstApiClient(conn)
+	update := edgeproto.AppInst{
+		Key:        inst.Key,
+		PowerState: powerState,
+		Fields:     []string{edgeproto.AppInstFieldPowerState},
+	}
+	stream, err := client.UpdateAppInst(ctx, &update)
+	if err != nil {
+		return err
+	}
+	for {
+		_, err = stream.Recv()
+		if err == io.EOF {
+			err = nil
+			break
+		}
+		if err != nil {
+			break
+		}
+	}
+	return err
+}
@@ -0,0 +1,98 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mobiledgex/edge-cloud-infra/autoprov/autorules"
+	"github.com/mobiledgex/edge-cloud/cloudcommon"
+	"github.com/mobiledgex/edge-cloud/edgeproto"
+	"github.com/mobiledgex/edge-cloud/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoProvSuspend(t *testing.T) {
+	log.SetDebugLevel(log.DebugLevelApi)
+	log.InitTracer(nil)
+	defer log.FinishTracer()
+	ctx := log.StartTestSpan(context.Background())
+
+	cacheData.init(nil)
+
+	app := edgeproto.App{}
+	app.Key.Name = "app"
+	app.Key.Organization = "devorg"
+	app.Key.Version = "1.0"
+	require.False(t, autorules.IsAutoProvSuspendEnabled(&app))
+	app.Annotations = "foo=bar," + autorules.AppAnnotationAutoProvSuspend + "=false"
+	require.False(t, autorules.IsAutoProvSuspendEnabled(&app))
+	app.Annotations = "foo=bar, " + autorules.AppAnnotationAutoProvSuspend + "=true"
+	require.True(t, autorules.IsAutoProvSuspendEnabled(&app))
+	require.Equal(t, map[string]string{
+		"foo":                                  "bar",
+		autorules.AppAnnotationAutoProvSuspend: "true",
+	}, autorules.ParseAppAnnotations(app.Annotations))
+
+	inst := edgeproto.AppInst{}
+	inst.Key.AppKey = app.Key
+	inst.Key.ClusterInstKey.CloudletKey.Name = "cloudlet"
+	inst.Key.ClusterInstKey.CloudletKey.Organization = "oper"
+
+	// kubernetes AppInsts are suspended by shepherd, and are
+	// undeployed if shepherd does not confirm the suspend
+	saveTimeout := AutoProvSuspendConfirmTimeout
+	saveInterval := autoProvSuspendConfirmInterval
+	AutoProvSuspendConfirmTimeout = 50 * time.Millisecond
+	autoProvSuspendConfirmInterval = 10 * time.Millisecond
+	defer func() {
+		AutoProvSuspendConfirmTimeout = saveTimeout
+		autoProvSuspendConfirmInterval = saveInterval
+	}()
+	app.Deployment = cloudcommon.DeploymentTypeKubernetes
+	require.False(t, autoProvSuspend(ctx, &app, &inst))
+	suspended := edgeproto.Alert{}
+	suspended.Labels = inst.Key.GetTags()
+	suspended.Labels["alertname"] = autorules.AlertAutoProvSuspended
+	suspended.State = "firing"
+	cacheData.alertCache.Update(ctx, &suspended, 0)
+	require.True(t, autoProvSuspend(ctx, &app, &inst))
+	app.Deployment = cloudcommon.DeploymentTypeHelm
+	require.True(t, autoProvSuspend(ctx, &app, &inst))
+	cacheData.alertCache.Delete(ctx, &suspended, 0)
+	require.False(t, autoProvSuspend(ctx, &app, &inst))
+
+	// docker AppInsts are undeployed
+	app.Deployment = cloudcommon.DeploymentTypeDocker
+	require.False(t, autoProvSuspend(ctx, &app, &inst))
+
+	// VM AppInsts that are already powered off are left alone
+	app.Deployment = cloudcommon.DeploymentTypeVM
+	inst.PowerState = edgeproto.PowerState_POWER_OFF
+	cacheData.appInstCache.Update(ctx, &inst, 0)
+	require.True(t, autoProvSuspend(ctx, &app, &inst))
+
+	// resume is only for powered off AppInsts
+	alert := edgeproto.Alert{}
+	alert.Labels = inst.Key.GetTags()
+	alert.Labels["alertname"] = autorules.AlertAutoProvResume
+	alert.State = "firing"
+	require.Equal(t, inst.Key, autorules.GetAlertAppInstKey(&alert))
+	inst.PowerState = edgeproto.PowerState_POWER_ON
+	cacheData.appInstCache.Update(ctx, &inst, 0)
+	require.Nil(t, autoProvResume(ctx, alert.Labels["alertname"], &alert))
+}
@@ -17,6 +17,8 @@ package autorules
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/mobiledgex/edge-cloud/cloud-resource-manager/prommgmt"
@@ -25,6 +27,58 @@ import (
 	"github.com/prometheus/common/model"
 )
 
+const (
+	// App annotation to suspend idle auto-provisioned AppInsts
+	// instead of undeploying them, i.e. autoprovsuspend=true
+	AppAnnotationAutoProvSuspend = "autoprovsuspend"
+	// Alert raised by shepherd when a suspended AppInst gets a
+	// new connection and needs to be resumed
+	AlertAutoProvResume = "AutoProvResume"
+	// Alert raised by shepherd while a kubernetes AppInst is
+	// suspended, confirms the suspend to autoprov
+	AlertAutoProvSuspended = "AutoProvSuspended"
+	// App annotation to scale the replicas of kubernetes AppInsts
+	// by an AutoScalePolicy, i.e. autoscalepolicy=mypolicy
+	AppAnnotationAutoScalePolicy = "autoscalepolicy"
+)
+
+// Parse App annotations of the form key1=val1,key2=val2
+func ParseAppAnnotations(annotations string) map[string]string {
+	vals := make(map[string]string)
+	for _, kv := range strings.Split(annotations, ",") {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		vals[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return vals
+}
+
+// Check if the App's AppInsts are suspended rather than undeployed
+// when the auto undeploy rule fires.
+func IsAutoProvSuspendEnabled(app *edgeproto.App) bool {
+	val, ok := ParseAppAnnotations(app.Annotations)[AppAnnotationAutoProvSuspend]
+	if !ok {
+		return false
+	}
+	enabled, err := strconv.ParseBool(val)
+	return err == nil && enabled
+}
+
+// Get the AppInst key from the labels of an AppInst alert
+func GetAlertAppInstKey(alert *edgeproto.Alert) edgeproto.AppInstKey {
+	key := edgeproto.AppInstKey{}
+	key.AppKey.Organization = alert.Labels[edgeproto.AppKeyTagOrganization]
+	key.AppKey.Name = alert.Labels[edgeproto.AppKeyTagName]
+	key.AppKey.Version = alert.Labels[edgeproto.AppKeyTagVersion]
+	key.ClusterInstKey.ClusterKey.Name = alert.Labels[edgeproto.ClusterKeyTagName]
+	key.ClusterInstKey.Organization = alert.Labels[edgeproto.ClusterInstKeyTagOrganization]
+	key.ClusterInstKey.CloudletKey.Name = alert.Labels[edgeproto.CloudletKeyTagName]
+	key.ClusterInstKey.CloudletKey.Organization = alert.Labels[edgeproto.CloudletKeyTagOrganization]
+	return key
+}
+
 func GetAutoUndeployRules(ctx context.Context, settings edgeproto.Settings, appKey *edgeproto.AppKey, policy *edgeproto.AutoProvPolicy) *prommgmt.RuleGroup {
 	if policy.UndeployClientCount == 0 {
 		return nil
@@ -58,6 +58,11 @@ func runAutoScale(ctx context.Context, k interface{}) {
 		log.SpanLog(ctx, log.DebugLevelApi, "Failed to set up ClusterInst for scaling from Alert", "err", err)
 		return
 	}
+	if maintTracker.underMaintenance(cinst.Key.CloudletKey) {
+		// alert is re-processed when the maintenance ends
+		log.SpanLog(ctx, log.DebugLevelApi, "Skip scaling ClusterInst, cloudlet under maintenance", "ClusterInst", cinst.Key)
+		return
+	}
 
 	log.SpanLog(ctx, log.DebugLevelApi, "auto scaling clusterinst", "alert", alert, "ClusterInst", cinst)
 	err = scaleClusterInst(ctx, name, &alert, cinst)
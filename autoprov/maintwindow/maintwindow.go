@@ -0,0 +1,52 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package maintwindow shares the set of cloudlets under an open maintenance
+// window between the MC, which schedules the windows, and the regional
+// autoprov service, which suspends deploy/undeploy actions on them.
+package maintwindow
+
+import (
+	"strings"
+
+	"github.com/mobiledgex/edge-cloud/edgeproto"
+	"github.com/mobiledgex/edge-cloud/vault"
+)
+
+// Cloudlets under maintenance in a region
+type MaintenanceCloudlets struct {
+	Cloudlets []edgeproto.CloudletKey `json:"cloudlets,omitempty"`
+}
+
+func GetVaultPath(region string) string {
+	return "/secret/data/" + region + "/maintenancewindows"
+}
+
+// Get the cloudlets under maintenance in the region. If the MC has never
+// published any data for the region, no cloudlets are under maintenance.
+func GetMaintenanceCloudlets(config *vault.Config, region string) (*MaintenanceCloudlets, error) {
+	data := MaintenanceCloudlets{}
+	err := vault.GetData(config, GetVaultPath(region), 0, &data)
+	if err != nil {
+		if strings.Contains(err.Error(), "no secrets") {
+			return &data, nil
+		}
+		return nil, err
+	}
+	return &data, nil
+}
+
+func PutMaintenanceCloudlets(config *vault.Config, region string, data *MaintenanceCloudlets) error {
+	return vault.PutData(config, GetVaultPath(region), data)
+}
@@ -260,7 +260,11 @@ func (k *K8sBareMetalPlatform) UpdateAppInst(ctx context.Context, clusterInst *e
 	if err != nil {
 		return err
 	}
-	return k8smgmt.UpdateAppInst(ctx, k.commonPf.PlatformConfig.AccessApi, client, names, app, appInst, appInstFlavor)
+	err = k8smgmt.UpdateAppInst(ctx, k.commonPf.PlatformConfig.AccessApi, client, names, app, appInst, appInstFlavor)
+	if err != nil {
+		return err
+	}
+	return infracommon.RestoreAppDeploymentReplicas(ctx, client, names, app)
 }
 
 func (k *K8sBareMetalPlatform) GetAppInstRuntime(ctx context.Context, clusterInst *edgeproto.ClusterInst, app *edgeproto.App, appInst *edgeproto.AppInst) (*edgeproto.AppInstRuntime, error) {
@@ -55,6 +55,63 @@ type swaggerRolePerm struct {
 	Body ormapi.RolePerm
 }
 
+// swagger:parameters CreateCustomRole UpdateCustomRole DeleteCustomRole ShowCustomRole
+type swaggerCustomRole struct {
+	// in: body
+	Body ormapi.CustomRole
+}
+
+// swagger:parameters CreateOrgInvitation ShowOrgInvitation RevokeOrgInvitation
+type swaggerOrgInvitation struct {
+	// in: body
+	Body ormapi.OrgInvitation
+}
+
+// swagger:parameters AcceptOrgInvitation
+type swaggerAcceptOrgInvitation struct {
+	// in: body
+	Body ormapi.Token
+}
+
+// swagger:parameters CreateIdentityProvider UpdateIdentityProvider DeleteIdentityProvider ShowIdentityProvider
+type swaggerIdentityProvider struct {
+	// in: body
+	Body ormapi.IdentityProvider
+}
+
+// swagger:parameters CreateOrgQuota UpdateOrgQuota DeleteOrgQuota ShowOrgQuota
+type swaggerOrgQuota struct {
+	// in: body
+	Body ormapi.OrgQuota
+}
+
+// swagger:parameters DiffData ApplyData PruneData
+type swaggerData struct {
+	// in: body
+	Body ormapi.AllData
+}
+
+// swagger:parameters SSOLogin
+type swaggerSSOLogin struct {
+	// Identity provider name
+	// in: query
+	// required: true
+	Idp string `json:"idp"`
+}
+
+// swagger:parameters SSOCallback
+type swaggerSSOCallback struct {
+	// Authorization code from the identity provider
+	// in: query
+	Code string `json:"code"`
+	// State passed through the identity provider
+	// in: query
+	State string `json:"state"`
+	// Error from the identity provider
+	// in: query
+	Error string `json:"error"`
+}
+
 // swagger:parameters PasswdReset
 type swaggerPasswdReset struct {
 	// in: body
@@ -127,6 +184,30 @@ type swaggerEvents struct {
 	Body node.EventSearch
 }
 
+// swagger:parameters ShowAudit ExportAudit
+type swaggerAudit struct {
+	// in: body
+	Body ormapi.AuditQuery
+}
+
+// swagger:parameters CreateEventWebhook UpdateEventWebhook DeleteEventWebhook ShowEventWebhook
+type swaggerEventWebhook struct {
+	// in: body
+	Body ormapi.EventWebhook
+}
+
+// swagger:parameters ShowEventWebhookDelivery RetryEventWebhookDelivery
+type swaggerEventWebhookDelivery struct {
+	// in: body
+	Body ormapi.EventWebhookDelivery
+}
+
+// swagger:parameters GraphQL
+type swaggerGraphQL struct {
+	// in: body
+	Body ormapi.GraphQLQuery
+}
+
 // swagger:parameters TermsEvents
 type swaggerTermsEvents struct {
 	// in: body
@@ -138,3 +219,21 @@ type swaggerAlertReceiver struct {
 	// in: body
 	Body ormapi.AlertReceiver
 }
+
+// swagger:parameters CreateAlertSilence DeleteAlertSilence ShowAlertSilence
+type swaggerAlertSilence struct {
+	// in: body
+	Body ormapi.AlertSilence
+}
+
+// swagger:parameters CreateMaintenanceWindow DeleteMaintenanceWindow ShowMaintenanceWindow
+type swaggerMaintenanceWindow struct {
+	// in: body
+	Body ormapi.MaintenanceWindow
+}
+
+// swagger:parameters UpdateNotificationPrefs
+type swaggerNotificationPreferences struct {
+	// in: body
+	Body ormapi.NotificationPreferences
+}
@@ -87,6 +87,20 @@ type swaggerListPerms struct {
 	Body []ormapi.RolePerm
 }
 
+// List of Organization Invitations
+// swagger:response listOrgInvitations
+type swaggerListOrgInvitations struct {
+	// in: body
+	Body []ormapi.OrgInvitation
+}
+
+// Organization Invitation created
+// swagger:response orgInvitationResponse
+type swaggerOrgInvitationResponse struct {
+	// in: body
+	Body ormapi.OrgInvitationResponse
+}
+
 // List of Orgs
 // swagger:response listOrgs
 type swaggerListOrgs struct {
@@ -94,6 +108,41 @@ type swaggerListOrgs struct {
 	Body []ormapi.Organization
 }
 
+// List of Data changes
+// swagger:response listDataChanges
+type swaggerListDataChanges struct {
+	// in: body
+	Body []ormapi.DataChange
+}
+
+// List of Audit logs
+// swagger:response listAudits
+type swaggerListAudits struct {
+	// in: body
+	Body []ormapi.AuditResponse
+}
+
+// List of Event Webhooks
+// swagger:response listEventWebhooks
+type swaggerListEventWebhooks struct {
+	// in: body
+	Body []ormapi.EventWebhook
+}
+
+// List of Event Webhook Deliveries
+// swagger:response listEventWebhookDeliveries
+type swaggerListEventWebhookDeliveries struct {
+	// in: body
+	Body []ormapi.EventWebhookDelivery
+}
+
+// GraphQL query result
+// swagger:response graphQLResponse
+type swaggerGraphQLResponse struct {
+	// in: body
+	Body ormapi.GraphQLResponse
+}
+
 // List of BillingOrgs
 // swagger:response listBillingOrgs
 type swaggerListBillingOrgs struct {
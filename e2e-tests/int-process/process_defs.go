@@ -25,6 +25,7 @@ type MC struct {
 	process.NodeCommon      `yaml:",inline"`
 	Addr                    string
 	FederationAddr          string
+	MetricsAddr             string
 	SqlAddr                 string
 	NotifyAddrs             string
 	RolesFile               string
@@ -66,6 +67,7 @@ type Shepherd struct {
 	AppDNSRoot         string
 	ChefServerPath     string
 	ThanosRecvAddr     string
+	RemoteWriteAddr    string
 }
 type AutoProv struct {
 	process.Common     `yaml:",inline"`
@@ -45,6 +45,10 @@ func (p *MC) StartLocal(logfile string, opts ...process.StartOp) error {
 		args = append(args, "--federationAddr")
 		args = append(args, p.FederationAddr)
 	}
+	if p.MetricsAddr != "" {
+		args = append(args, "--metricsAddr")
+		args = append(args, p.MetricsAddr)
+	}
 	if p.SqlAddr != "" {
 		args = append(args, "--sqlAddr")
 		args = append(args, p.SqlAddr)
@@ -334,6 +338,10 @@ func (p *Shepherd) GetArgs(opts ...process.StartOp) []string {
 		args = append(args, "--thanosRecvAddr")
 		args = append(args, p.ThanosRecvAddr)
 	}
+	if p.RemoteWriteAddr != "" {
+		args = append(args, "--remoteWriteAddr")
+		args = append(args, p.RemoteWriteAddr)
+	}
 	options := process.StartOptions{}
 	options.ApplyStartOptions(opts...)
 	if options.Debug != "" {
@@ -671,7 +679,8 @@ func (p *Alertmanager) StartLocal(logfile string, opts ...process.StartOp) error
 		"-p", fmt.Sprintf("%d:%d", p.Port, p.Port),
 		"-v", configFile+":/etc/prometheus/alertmanager.yml",
 		"-v", templateFile+":/etc/alertmanager/templates/alertmanager.tmpl",
-		"prom/alertmanager:v0.21.0",
+		// keep in sync with ansible/roles/alertmanager/vars/main.yml
+		"prom/alertmanager:v0.28.1",
 		"--web.listen-address", fmt.Sprintf(":%d", p.Port),
 		"--log.level", "debug",
 		"--config.file", "/etc/prometheus/alertmanager.yml",
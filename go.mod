@@ -23,11 +23,13 @@ require (
 	github.com/go-openapi/validate v0.19.11
 	github.com/gogo/googleapis v1.1.0
 	github.com/gogo/protobuf v1.3.1
+	github.com/golang/snappy v0.0.1
 	github.com/google/go-cmp v0.4.0
 	github.com/google/uuid v1.1.1
 	github.com/googleapis/gnostic v0.3.1 // indirect
 	github.com/gorilla/mux v1.7.4
 	github.com/gorilla/websocket v1.4.1
+	github.com/graphql-go/graphql v0.8.1
 	github.com/hashicorp/go-plugin v1.0.1 // indirect
 	github.com/imdario/mergo v0.3.7 // indirect
 	github.com/influxdata/influxdb v1.7.7
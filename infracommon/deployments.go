@@ -0,0 +1,134 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infracommon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/mobiledgex/edge-cloud-infra/autoprov/autorules"
+	"github.com/mobiledgex/edge-cloud/cloud-resource-manager/k8smgmt"
+	"github.com/mobiledgex/edge-cloud/cloudcommon"
+	"github.com/mobiledgex/edge-cloud/edgeproto"
+	"github.com/mobiledgex/edge-cloud/log"
+	"github.com/mobiledgex/edge-cloud/util"
+	ssh "github.com/mobiledgex/golang-ssh"
+)
+
+// Shepherd changes the replica count of AppInst deployments for replica
+// auto scaling and auto-provisioned AppInst suspend. The AppInst
+// manifest does not carry these counts, so shepherd records them as
+// deployment annotations, and the CRM restores them after it applies
+// the manifest.
+const (
+	AutoScaledReplicasAnnotation = "mobiledgex.com/autoscaled-replicas"
+	SuspendedReplicasAnnotation  = "mobiledgex.com/autoprov-suspended-replicas"
+)
+
+type AppDeployment struct {
+	Name        string
+	Namespace   string
+	Replicas    int
+	Annotations map[string]string
+}
+
+// Find the deployments of the AppInst by the pod labels that are
+// also used to identify the pods in the metrics.
+func GetAppDeployments(ctx context.Context, client ssh.Client, kubeNames *k8smgmt.KubeNames, appName, appVers string) ([]AppDeployment, error) {
+	cmd := fmt.Sprintf("%s kubectl get deployments -A -o json", kubeNames.KconfEnv)
+	out, err := client.Output(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deployments: %s, %v", out, err)
+	}
+	list := struct {
+		Items []struct {
+			Metadata struct {
+				Name        string
+				Namespace   string
+				Annotations map[string]string
+			}
+			Spec struct {
+				Replicas int
+				Template struct {
+					Metadata struct {
+						Labels map[string]string
+					}
+				}
+			}
+		}
+	}{}
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		return nil, fmt.Errorf("failed to parse deployments: %v", err)
+	}
+	deps := []AppDeployment{}
+	for _, item := range list.Items {
+		labels := item.Spec.Template.Metadata.Labels
+		if labels[cloudcommon.MexAppNameLabel] != appName || labels[cloudcommon.MexAppVersionLabel] != appVers {
+			continue
+		}
+		deps = append(deps, AppDeployment{
+			Name:        item.Metadata.Name,
+			Namespace:   item.Metadata.Namespace,
+			Replicas:    item.Spec.Replicas,
+			Annotations: item.Metadata.Annotations,
+		})
+	}
+	return deps, nil
+}
+
+func ScaleAppDeployment(ctx context.Context, client ssh.Client, kubeNames *k8smgmt.KubeNames, dep *AppDeployment, replicas int) error {
+	cmd := fmt.Sprintf("%s kubectl scale deployment %s -n %s --replicas=%d", kubeNames.KconfEnv, dep.Name, dep.Namespace, replicas)
+	log.SpanLog(ctx, log.DebugLevelInfra, "scaling deployment", "cmd", cmd)
+	out, err := client.Output(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to scale deployment %s: %s, %v", dep.Name, out, err)
+	}
+	return nil
+}
+
+// Restore the replica counts set by shepherd after the AppInst
+// manifest was applied, which resets them. Suspended deployments stay
+// at zero, and auto scaled deployments are set to the last auto scaled
+// count as long as the App still has replica auto scaling enabled.
+func RestoreAppDeploymentReplicas(ctx context.Context, client ssh.Client, kubeNames *k8smgmt.KubeNames, app *edgeproto.App) error {
+	appName := util.DNSSanitize(app.Key.Name)
+	appVers := util.DNSSanitize(app.Key.Version)
+	deps, err := GetAppDeployments(ctx, client, kubeNames, appName, appVers)
+	if err != nil {
+		return err
+	}
+	autoScaled := autorules.ParseAppAnnotations(app.Annotations)[autorules.AppAnnotationAutoScalePolicy] != ""
+	for ii := range deps {
+		dep := &deps[ii]
+		replicas := -1
+		if _, found := dep.Annotations[SuspendedReplicasAnnotation]; found {
+			replicas = 0
+		} else if val, found := dep.Annotations[AutoScaledReplicasAnnotation]; found && autoScaled {
+			if v, err := strconv.Atoi(val); err == nil && v > 0 {
+				replicas = v
+			}
+		}
+		if replicas < 0 || replicas == dep.Replicas {
+			continue
+		}
+		log.SpanLog(ctx, log.DebugLevelInfra, "restoring deployment replicas set by shepherd", "deployment", dep.Name, "replicas", dep.Replicas, "restore", replicas)
+		if err := ScaleAppDeployment(ctx, client, kubeNames, dep, replicas); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -153,6 +153,9 @@ func (m *ManagedK8sPlatform) UpdateAppInst(ctx context.Context, clusterInst *edg
 	}
 
 	err = k8smgmt.UpdateAppInst(ctx, m.CommonPf.PlatformConfig.AccessApi, client, names, app, appInst, flavor)
+	if err == nil {
+		err = infracommon.RestoreAppDeploymentReplicas(ctx, client, names, app)
+	}
 	if err == nil {
 		updateCallback(edgeproto.UpdateTask, "Waiting for AppInst to Start")
 		err = k8smgmt.WaitForAppInst(ctx, client, names, app, k8smgmt.WaitRunning)
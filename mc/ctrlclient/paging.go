@@ -0,0 +1,48 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctrlclient
+
+import (
+	"encoding/json"
+
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormutil"
+	"github.com/mobiledgex/edge-cloud/objstore"
+)
+
+type objKeyGetter interface {
+	GetObjKey() objstore.ObjKey
+}
+
+// NewShowStreamPager creates a pager for the results of a
+// controller show stream. The pager's Add func can be called
+// from the stream callback, and once the stream is done the
+// page is sent back in key order.
+func NewShowStreamPager(page *ormapi.ShowPage) (*ormutil.ShowPager, error) {
+	return ormutil.NewShowPager(page, StreamObjKey)
+}
+
+// StreamObjKey gets the key used to order streamed objects for
+// paging. Objects without a key are ordered by their data.
+func StreamObjKey(obj interface{}) (string, error) {
+	if keyObj, ok := obj.(objKeyGetter); ok {
+		return keyObj.GetObjKey().GetKeyString(), nil
+	}
+	dat, err := json.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	return string(dat), nil
+}
@@ -0,0 +1,336 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package graphql runs the MC's read-only GraphQL queries on top of
+// the graphql-go library. Object types are defined by ObjectDefs,
+// whose fields are the JSON fields of a Go struct plus the fields
+// the MC resolves from its other APIs, so the schema follows the API
+// objects without a separate schema definition. Only queries are
+// allowed, and queries are limited by MaxDepth, MaxFields and
+// MaxResolves, since a small query can select a large amount of data.
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	gql "github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// Maximum nesting of selected fields in a query
+var MaxDepth = 12
+
+// Maximum number of fields in a query, with fragments expanded. This
+// limits the breadth of a query, including aliases of the same field.
+var MaxFields = 500
+
+// Maximum number of fields resolved while executing a query. Fields
+// of each item of a list count separately, so this limits the cost of
+// selecting fields of large lists.
+var MaxResolves = 20000
+
+// Object is a value of an ObjectDef type. Resolve returns the value
+// of the named field, which may be a scalar, another Object, a generic
+// JSON value, or a slice of those.
+type Object interface {
+	Resolve(ctx context.Context, name string, args Args) (interface{}, error)
+}
+
+// Args are the field arguments, with variables substituted and
+// values coerced to the argument types.
+type Args map[string]interface{}
+
+// Check that only the given arguments were specified
+func (s Args) Check(names ...string) error {
+	for arg := range s {
+		found := false
+		for _, name := range names {
+			if arg == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("Unknown argument %s", arg)
+		}
+	}
+	return nil
+}
+
+func (s Args) String(name string) (string, error) {
+	val, found := s[name]
+	if !found || val == nil {
+		return "", nil
+	}
+	str, ok := val.(string)
+	if !ok {
+		return "", fmt.Errorf("Argument %s must be a string", name)
+	}
+	return str, nil
+}
+
+func (s Args) Int(name string) (int, error) {
+	val, found := s[name]
+	if !found || val == nil {
+		return 0, nil
+	}
+	switch v := val.(type) {
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case float64:
+		if v == math.Trunc(v) {
+			return int(v), nil
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return int(i), nil
+		}
+	}
+	return 0, fmt.Errorf("Argument %s must be an integer", name)
+}
+
+func (s Args) Bool(name string) (bool, error) {
+	val, found := s[name]
+	if !found || val == nil {
+		return false, nil
+	}
+	b, ok := val.(bool)
+	if !ok {
+		return false, fmt.Errorf("Argument %s must be a boolean", name)
+	}
+	return b, nil
+}
+
+// Error for a field, or for the whole query if there is no path
+type Error struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+type Result struct {
+	Data   interface{} `json:"data"`
+	Errors []*Error    `json:"errors,omitempty"`
+}
+
+// Execute the query against the root query object
+func (s *Schema) Execute(ctx context.Context, root Object, query, operationName string, variables map[string]interface{}) *Result {
+	doc, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return errorResult(gqlerrors.FormatErrors(err))
+	}
+	op, err := getOperation(doc, operationName)
+	if err != nil {
+		return errorResult(gqlerrors.FormatErrors(err))
+	}
+	if op.Operation != ast.OperationTypeQuery {
+		err := fmt.Errorf("Only queries are supported, %s operations are not allowed", op.Operation)
+		return errorResult(gqlerrors.FormatErrors(err))
+	}
+	// Check fragment cycles and limits before validation, which
+	// also expands fragments, so that huge queries are rejected up
+	// front. Validation does not terminate for fragment cycles.
+	limits := queryLimits{
+		fragments: make(map[string]*ast.FragmentDefinition),
+	}
+	for _, def := range doc.Definitions {
+		if frag, ok := def.(*ast.FragmentDefinition); ok && frag.Name != nil {
+			limits.fragments[frag.Name.Value] = frag
+		}
+	}
+	if err := limits.checkFragmentCycles(); err != nil {
+		return errorResult(gqlerrors.FormatErrors(err))
+	}
+	if err := limits.check(op.SelectionSet, 1); err != nil {
+		return errorResult(gqlerrors.FormatErrors(err))
+	}
+	validation := gql.ValidateDocument(&s.schema, doc, nil)
+	if !validation.IsValid {
+		return errorResult(validation.Errors)
+	}
+	res := gql.Execute(gql.ExecuteParams{
+		Schema:        s.schema,
+		Root:          root,
+		AST:           doc,
+		OperationName: operationName,
+		Args:          variables,
+		Context:       context.WithValue(ctx, execStateKey{}, &execState{}),
+	})
+	out := errorResult(res.Errors)
+	out.Data = res.Data
+	return out
+}
+
+func errorResult(errs []gqlerrors.FormattedError) *Result {
+	res := &Result{}
+	for _, err := range errs {
+		res.Errors = append(res.Errors, &Error{
+			Message: err.Message,
+			Path:    err.Path,
+		})
+	}
+	return res
+}
+
+func getOperation(doc *ast.Document, operationName string) (*ast.OperationDefinition, error) {
+	var op *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		o, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if operationName == "" || (o.Name != nil && o.Name.Value == operationName) {
+			if op != nil {
+				return nil, fmt.Errorf("Operation name must be specified for queries with multiple operations")
+			}
+			op = o
+		}
+	}
+	if op == nil {
+		if operationName == "" {
+			return nil, fmt.Errorf("No operations in query")
+		}
+		return nil, fmt.Errorf("Operation %s not found", operationName)
+	}
+	return op, nil
+}
+
+type queryLimits struct {
+	fragments map[string]*ast.FragmentDefinition
+	fields    int
+}
+
+// Check that no fragment spreads itself, directly or through other
+// fragments, including fragments the operation does not use.
+func (s *queryLimits) checkFragmentCycles() error {
+	done := make(map[string]bool)
+	for name := range s.fragments {
+		if err := s.visitFragment(name, make(map[string]bool), done); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *queryLimits) visitFragment(name string, visiting, done map[string]bool) error {
+	if done[name] {
+		return nil
+	}
+	if visiting[name] {
+		return fmt.Errorf("Cannot spread fragment %s within itself", name)
+	}
+	frag, found := s.fragments[name]
+	if !found {
+		// left for validation to report
+		return nil
+	}
+	visiting[name] = true
+	for _, spread := range getFragmentSpreads(frag.SelectionSet, nil) {
+		if err := s.visitFragment(spread, visiting, done); err != nil {
+			return err
+		}
+	}
+	delete(visiting, name)
+	done[name] = true
+	return nil
+}
+
+func getFragmentSpreads(set *ast.SelectionSet, names []string) []string {
+	if set == nil {
+		return names
+	}
+	for _, sel := range set.Selections {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			names = getFragmentSpreads(sel.SelectionSet, names)
+		case *ast.FragmentSpread:
+			if sel.Name != nil {
+				names = append(names, sel.Name.Value)
+			}
+		case *ast.InlineFragment:
+			names = getFragmentSpreads(sel.SelectionSet, names)
+		}
+	}
+	return names
+}
+
+// Check the depth and number of fields of the selections, expanding
+// fragments, which must not have cycles. Checking stops once a limit is exceeded, so fragments
+// that expand to huge queries are rejected without expanding them all.
+func (s *queryLimits) check(set *ast.SelectionSet, depth int) error {
+	if set == nil {
+		return nil
+	}
+	if depth > MaxDepth {
+		return fmt.Errorf("Query exceeds the maximum depth of %d", MaxDepth)
+	}
+	for _, sel := range set.Selections {
+		var err error
+		switch sel := sel.(type) {
+		case *ast.Field:
+			s.fields++
+			if s.fields > MaxFields {
+				return fmt.Errorf("Query exceeds the maximum of %d fields", MaxFields)
+			}
+			err = s.check(sel.SelectionSet, depth+1)
+		case *ast.FragmentSpread:
+			if sel.Name == nil {
+				continue
+			}
+			frag, found := s.fragments[sel.Name.Value]
+			if !found {
+				// left for validation to report
+				continue
+			}
+			err = s.check(frag.SelectionSet, depth)
+		case *ast.InlineFragment:
+			err = s.check(sel.SelectionSet, depth)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type execStateKey struct{}
+
+// State of a query execution. Fields are resolved one at a time,
+// so it does not need a lock.
+type execState struct {
+	resolves int
+}
+
+// Count the resolved fields of the query. Only the first field over
+// the limit reports an error, the rest are just null.
+func limitResolve(resolve gql.FieldResolveFn) gql.FieldResolveFn {
+	return func(p gql.ResolveParams) (interface{}, error) {
+		if state, ok := p.Context.Value(execStateKey{}).(*execState); ok {
+			state.resolves++
+			if state.resolves == MaxResolves+1 {
+				return nil, fmt.Errorf("Query exceeds the maximum of %d resolved fields", MaxResolves)
+			}
+			if state.resolves > MaxResolves {
+				return nil, nil
+			}
+		}
+		return resolve(p)
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testQuery struct{}
+
+func (s *testQuery) Resolve(ctx context.Context, name string, args Args) (interface{}, error) {
+	switch name {
+	case "orgs":
+		filter, err := args.String("name")
+		if err != nil {
+			return nil, err
+		}
+		limit, err := args.Int("limit")
+		if err != nil {
+			return nil, err
+		}
+		orgs := []*testOrg{}
+		for _, name := range []string{"devorg", "operorg"} {
+			if filter != "" && filter != name {
+				continue
+			}
+			orgs = append(orgs, &testOrg{
+				data: testOrgData{
+					Name: name,
+					Key: testKey{
+						Name:         name + "-app",
+						Organization: name,
+					},
+					Labels: map[string]string{"type": "test"},
+				},
+			})
+		}
+		if limit > 0 && limit < len(orgs) {
+			orgs = orgs[:limit]
+		}
+		return orgs, nil
+	case "broken":
+		return nil, fmt.Errorf("broken field")
+	}
+	return nil, fmt.Errorf("Unknown field %s", name)
+}
+
+type testKey struct {
+	Name         string `json:"name"`
+	Organization string `json:"organization"`
+}
+
+type testOrgData struct {
+	Name    string            `json:"name"`
+	Key     testKey           `json:"key"`
+	Apps    []testKey         `json:"apps"`
+	Labels  map[string]string `json:"labels"`
+	Created time.Time         `json:"created"`
+	Count   int64             `json:"count"`
+	Hidden  string            `json:"-"`
+	Invalid string            `json:"in-valid"`
+}
+
+type testOrg struct {
+	data testOrgData
+}
+
+func (s *testOrg) Resolve(ctx context.Context, name string, args Args) (interface{}, error) {
+	if name == "self" {
+		return s, nil
+	}
+	dat, err := json.Marshal(&s.data)
+	if err != nil {
+		return nil, err
+	}
+	data := make(map[string]interface{})
+	if err := json.Unmarshal(dat, &data); err != nil {
+		return nil, err
+	}
+	return data[name], nil
+}
+
+func testSchema(t *testing.T) *Schema {
+	schema, err := NewSchema(&ObjectDef{
+		Name: "Query",
+		Fields: []FieldDef{{
+			Name: "orgs",
+			Type: "[Org]",
+			Args: map[string]string{"name": "String", "limit": "Int"},
+		}, {
+			Name: "broken",
+			Type: "String",
+		}},
+	}, &ObjectDef{
+		Name: "Org",
+		Data: testOrgData{},
+		Fields: []FieldDef{{
+			Name: "self",
+			Type: "Org",
+		}},
+	})
+	require.Nil(t, err)
+	return schema
+}
+
+func testExec(t *testing.T, schema *Schema, query string, vars map[string]interface{}) (string, []*Error) {
+	res := schema.Execute(context.Background(), &testQuery{}, query, "", vars)
+	out, err := json.Marshal(res.Data)
+	require.Nil(t, err)
+	return string(out), res.Errors
+}
+
+func TestExecute(t *testing.T) {
+	schema := testSchema(t)
+
+	data, errs := testExec(t, schema, `{ orgs { name } }`, nil)
+	require.Nil(t, errs)
+	require.Equal(t, `{"orgs":[{"name":"devorg"},{"name":"operorg"}]}`, data)
+
+	// arguments, aliases, typename, comments and commas
+	data, errs = testExec(t, schema, `
+	# get one org
+	query GetOrgs {
+		dev: orgs(name: "devorg") { __typename, orgName: name }
+		first: orgs(limit: 1) { name }
+	}`, nil)
+	require.Nil(t, errs)
+	require.Equal(t, `{"dev":[{"__typename":"Org","orgName":"devorg"}],"first":[{"name":"devorg"}]}`, data)
+
+	// variables with defaults
+	query := `query($org: String = "operorg") { orgs(name: $org) { name } }`
+	data, errs = testExec(t, schema, query, nil)
+	require.Nil(t, errs)
+	require.Equal(t, `{"orgs":[{"name":"operorg"}]}`, data)
+	data, errs = testExec(t, schema, query, map[string]interface{}{"org": "devorg"})
+	require.Nil(t, errs)
+	require.Equal(t, `{"orgs":[{"name":"devorg"}]}`, data)
+	_, errs = testExec(t, schema, `query($org: String!) { orgs(name: $org) { name } }`, nil)
+	require.Equal(t, 1, len(errs))
+	require.Contains(t, errs[0].Message, `Variable "$org" of required type "String!" was not provided`)
+	_, errs = testExec(t, schema, `{ orgs(name: $org) { name } }`, nil)
+	require.Equal(t, 1, len(errs))
+	require.Contains(t, errs[0].Message, `Variable "$org" is not defined`)
+	// numbers from JSON variables
+	data, errs = testExec(t, schema, `query($limit: Int) { orgs(limit: $limit) { name } }`, map[string]interface{}{"limit": float64(1)})
+	require.Nil(t, errs)
+	require.Equal(t, `{"orgs":[{"name":"devorg"}]}`, data)
+
+	// fragments and directives, fields with the same key are merged
+	data, errs = testExec(t, schema, `
+	query($withKey: Boolean!) {
+		orgs(name: "devorg") {
+			...orgFields
+			self { name }
+			self { key @include(if: $withKey) { name } }
+			... on Org { labels @skip(if: true) }
+		}
+	}
+	fragment orgFields on Org { name }`, map[string]interface{}{"withKey": true})
+	require.Nil(t, errs)
+	require.Equal(t, `{"orgs":[{"name":"devorg","self":{"key":{"name":"devorg-app"},"name":"devorg"}}]}`, data)
+
+	// struct fields are objects, other types are JSON values
+	data, errs = testExec(t, schema, `{ orgs(name: "devorg") { key { organization } apps { name } labels created count } }`, nil)
+	require.Nil(t, errs)
+	require.Equal(t, `{"orgs":[{"apps":null,"count":0,"created":"0001-01-01T00:00:00Z","key":{"organization":"devorg"},"labels":{"type":"test"}}]}`, data)
+
+	// field errors null the field and report the path
+	data, errs = testExec(t, schema, `{ orgs(name: "devorg") { name } broken }`, nil)
+	require.Equal(t, `{"broken":null,"orgs":[{"name":"devorg"}]}`, data)
+	require.Equal(t, 1, len(errs))
+	require.Equal(t, "broken field", errs[0].Message)
+	require.Equal(t, []interface{}{"broken"}, errs[0].Path)
+
+	// invalid queries are rejected before running them
+	tests := []struct {
+		query  string
+		errStr string
+	}{
+		{`{ orgs { name }`, `Syntax Error GraphQL (1:16) Expected Name, found EOF`},
+		{`{ orgs { name foo } }`, `Cannot query field "foo" on type "Org".`},
+		{`{ orgs { Hidden } }`, `Cannot query field "Hidden" on type "Org".`},
+		{`{ orgs(foo: 1) { name } }`, `Unknown argument "foo" on field "orgs" of type "Query".`},
+		{`{ orgs(limit: "one") { name } }`, `Argument "limit" has invalid value "one".`},
+		{`{ orgs }`, `Field "orgs" of type "[Org]" must have a sub selection.`},
+		{`{ orgs { name { foo } } }`, `Field "name" of type "String" must not have a sub selection.`},
+		{`{ orgs { key } }`, `Field "key" of type "testKey" must have a sub selection.`},
+		{`{ orgs { ...missing } }`, `Unknown fragment "missing".`},
+		{`{ orgs { ...f } } fragment f on Org { ...f }`, `Cannot spread fragment f within itself`},
+		{`{ orgs { name } } fragment f on Org { self { ...g } } fragment g on Org { ...f }`, `within itself`},
+		{``, `No operations in query`},
+	}
+	for _, test := range tests {
+		_, errs = testExec(t, schema, test.query, nil)
+		require.NotEqual(t, 0, len(errs), test.query)
+		require.Contains(t, errs[0].Message, test.errStr, test.query)
+	}
+
+	// depth limit
+	_, errs = testExec(t, schema, `{ orgs(name: "devorg") { self { self { self { self { self { self { self { self { self { self { self { name } } } } } } } } } } } } }`, nil)
+	require.Equal(t, 1, len(errs))
+	require.Equal(t, "Query exceeds the maximum depth of 12", errs[0].Message)
+
+	// field limits, fields of fragments count each time they are spread
+	defer func(maxFields, maxResolves int) {
+		MaxFields = maxFields
+		MaxResolves = maxResolves
+	}(MaxFields, MaxResolves)
+	MaxFields = 6
+	_, errs = testExec(t, schema, `{ a: orgs { name } b: orgs { name } c: orgs { name } }`, nil)
+	require.Nil(t, errs)
+	_, errs = testExec(t, schema, `{ a: orgs { ...f } b: orgs { ...f } c: orgs { ...f } d: orgs { ...f } } fragment f on Org { name }`, nil)
+	require.Equal(t, 1, len(errs))
+	require.Equal(t, "Query exceeds the maximum of 6 fields", errs[0].Message)
+	MaxFields = 500
+	// resolve limit, each org in the list resolves its fields
+	MaxResolves = 4
+	data, errs = testExec(t, schema, `{ orgs { self { name } } }`, nil)
+	require.Equal(t, 1, len(errs))
+	require.Equal(t, "Query exceeds the maximum of 4 resolved fields", errs[0].Message)
+	require.Equal(t, `{"orgs":[{"self":{"name":"devorg"}},{"self":{"name":null}}]}`, data)
+	MaxResolves = 20000
+
+	// only queries are allowed
+	_, errs = testExec(t, schema, `mutation { deleteOrg(name: "devorg") { name } }`, nil)
+	require.Equal(t, "Only queries are supported, mutation operations are not allowed", errs[0].Message)
+	res := schema.Execute(context.Background(), &testQuery{}, `query A { orgs { name } } query B { orgs { name } }`, "", nil)
+	require.Equal(t, "Operation name must be specified for queries with multiple operations", res.Errors[0].Message)
+	res = schema.Execute(context.Background(), &testQuery{}, `query A { orgs { name } } query B { broken }`, "A", nil)
+	require.Nil(t, res.Errors)
+	res = schema.Execute(context.Background(), &testQuery{}, `query A { orgs { name } }`, "C", nil)
+	require.Equal(t, "Operation C not found", res.Errors[0].Message)
+
+	// introspection
+	data, errs = testExec(t, schema, `{ __type(name: "testKey") { fields { name } } }`, nil)
+	require.Nil(t, errs)
+	require.Equal(t, `{"__type":{"fields":[{"name":"name"},{"name":"organization"}]}}`, data)
+}
+
+func TestNewSchema(t *testing.T) {
+	_, err := NewSchema(&ObjectDef{
+		Name: "Query",
+		Fields: []FieldDef{{
+			Name: "orgs",
+			Type: "[Foo]",
+		}},
+	})
+	require.NotNil(t, err)
+	require.Equal(t, "Field orgs of type Query: unknown type Foo", err.Error())
+	_, err = NewSchema(&ObjectDef{
+		Name: "Query",
+		Fields: []FieldDef{{
+			Name: "orgs",
+			Type: "String",
+			Args: map[string]string{"limit": "Long"},
+		}},
+	})
+	require.NotNil(t, err)
+	require.Equal(t, "Argument limit of field orgs of type Query: unknown scalar type Long", err.Error())
+	_, err = NewSchema(&ObjectDef{Name: "Query"}, &ObjectDef{Name: "JSON"})
+	require.NotNil(t, err)
+	require.Equal(t, "Duplicate type JSON", err.Error())
+}
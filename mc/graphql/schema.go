@@ -0,0 +1,312 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	gql "github.com/graphql-go/graphql"
+)
+
+// JSON is the type of values without a fixed GraphQL type, like maps,
+// enums and metrics, which are returned as their JSON values.
+var JSON = gql.NewScalar(gql.ScalarConfig{
+	Name:        "JSON",
+	Description: "Any JSON value",
+	Serialize: func(value interface{}) interface{} {
+		return value
+	},
+})
+
+// ObjectDef defines an object type. Its fields are the JSON fields
+// of the Data struct, if any, plus the Fields. Values of the type
+// are Objects that resolve all of the fields.
+type ObjectDef struct {
+	Name   string
+	Data   interface{}
+	Fields []FieldDef
+}
+
+// FieldDef is a field with a type name, in brackets for a list, and
+// arguments as a map of argument names to their scalar type names.
+type FieldDef struct {
+	Name string
+	Type string
+	Args map[string]string
+}
+
+type Schema struct {
+	schema gql.Schema
+}
+
+var scalarTypes = map[string]*gql.Scalar{
+	"String":  gql.String,
+	"Int":     gql.Int,
+	"Float":   gql.Float,
+	"Boolean": gql.Boolean,
+	"JSON":    JSON,
+}
+
+var validName = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// NewSchema creates a schema with the query type and the other
+// object types it refers to.
+func NewSchema(query *ObjectDef, defs ...*ObjectDef) (*Schema, error) {
+	b := schemaBuilder{
+		objects: make(map[string]*gql.Object),
+		structs: make(map[reflect.Type]gql.Output),
+	}
+	for name := range scalarTypes {
+		b.objects[name] = nil
+	}
+	allDefs := append([]*ObjectDef{query}, defs...)
+	for _, def := range allDefs {
+		if _, found := b.objects[def.Name]; found {
+			return nil, fmt.Errorf("Duplicate type %s", def.Name)
+		}
+		b.objects[def.Name] = gql.NewObject(gql.ObjectConfig{
+			Name:   def.Name,
+			Fields: b.defFields(def),
+		})
+	}
+	// check type references up front, since fields are only
+	// created once the schema is built
+	for _, def := range allDefs {
+		for _, field := range def.Fields {
+			if _, err := b.typeRef(field.Type); err != nil {
+				return nil, fmt.Errorf("Field %s of type %s: %v", field.Name, def.Name, err)
+			}
+			for arg, argType := range field.Args {
+				if _, found := scalarTypes[argType]; !found {
+					return nil, fmt.Errorf("Argument %s of field %s of type %s: unknown scalar type %s", arg, field.Name, def.Name, argType)
+				}
+			}
+		}
+	}
+	schema, err := gql.NewSchema(gql.SchemaConfig{
+		Query: b.objects[query.Name],
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{schema: schema}, nil
+}
+
+type schemaBuilder struct {
+	objects map[string]*gql.Object
+	structs map[reflect.Type]gql.Output
+}
+
+// Fields of an ObjectDef type are resolved by its Objects
+func (b *schemaBuilder) defFields(def *ObjectDef) gql.FieldsThunk {
+	return func() gql.Fields {
+		fields := gql.Fields{}
+		if def.Data != nil {
+			for _, sf := range jsonFields(reflect.TypeOf(def.Data)) {
+				fields[sf.name] = &gql.Field{
+					Type:    b.outputType(sf.typ),
+					Resolve: limitResolve(resolveObjectField),
+				}
+			}
+		}
+		for _, field := range def.Fields {
+			typ, _ := b.typeRef(field.Type)
+			args := gql.FieldConfigArgument{}
+			for arg, argType := range field.Args {
+				args[arg] = &gql.ArgumentConfig{
+					Type: scalarTypes[argType],
+				}
+			}
+			fields[field.Name] = &gql.Field{
+				Type:    typ,
+				Args:    args,
+				Resolve: limitResolve(resolveObjectField),
+			}
+		}
+		return fields
+	}
+}
+
+func resolveObjectField(p gql.ResolveParams) (interface{}, error) {
+	obj, ok := p.Source.(Object)
+	if !ok {
+		return nil, fmt.Errorf("Unexpected value %T for field %s", p.Source, p.Info.FieldName)
+	}
+	return obj.Resolve(p.Context, p.Info.FieldName, Args(p.Args))
+}
+
+// Fields of struct types are resolved from the generic JSON value
+func resolveJSONField(p gql.ResolveParams) (interface{}, error) {
+	obj, ok := p.Source.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	return obj[p.Info.FieldName], nil
+}
+
+func (b *schemaBuilder) typeRef(name string) (gql.Output, error) {
+	if strings.HasPrefix(name, "[") && strings.HasSuffix(name, "]") {
+		typ, err := b.typeRef(name[1 : len(name)-1])
+		if err != nil {
+			return nil, err
+		}
+		return gql.NewList(typ), nil
+	}
+	if typ, found := scalarTypes[name]; found {
+		return typ, nil
+	}
+	if typ := b.objects[name]; typ != nil {
+		return typ, nil
+	}
+	return nil, fmt.Errorf("unknown type %s", name)
+}
+
+// Get the GraphQL type for the JSON value of the Go type. Types with
+// custom JSON encodings, named numeric types like enums, maps and
+// interfaces are generic JSON values. 64-bit integers are Floats,
+// since GraphQL Ints are 32-bit.
+func (b *schemaBuilder) outputType(t reflect.Type) gql.Output {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	for _, mt := range []reflect.Type{jsonMarshalerType, textMarshalerType} {
+		if t.Implements(mt) || reflect.PtrTo(t).Implements(mt) {
+			return JSON
+		}
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		return b.structType(t)
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			// base64 string
+			return gql.String
+		}
+		return gql.NewList(b.outputType(t.Elem()))
+	case reflect.String:
+		return gql.String
+	case reflect.Bool:
+		return gql.Boolean
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		if t.PkgPath() == "" {
+			return gql.Int
+		}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		if t.PkgPath() == "" {
+			return gql.Float
+		}
+	}
+	return JSON
+}
+
+func (b *schemaBuilder) structType(t reflect.Type) gql.Output {
+	if typ, found := b.structs[t]; found {
+		return typ
+	}
+	sfs := jsonFields(t)
+	if len(sfs) == 0 {
+		b.structs[t] = JSON
+		return JSON
+	}
+	baseName := t.Name()
+	if !validName.MatchString(baseName) {
+		baseName = "Object"
+	}
+	// Go types in different packages may have the same name
+	name := baseName
+	for ii := 2; ; ii++ {
+		if _, found := b.objects[name]; !found {
+			break
+		}
+		name = baseName + strconv.Itoa(ii)
+	}
+	obj := gql.NewObject(gql.ObjectConfig{
+		Name: name,
+		Fields: gql.FieldsThunk(func() gql.Fields {
+			fields := gql.Fields{}
+			for _, sf := range sfs {
+				fields[sf.name] = &gql.Field{
+					Type:    b.outputType(sf.typ),
+					Resolve: limitResolve(resolveJSONField),
+				}
+			}
+			return fields
+		}),
+	})
+	b.objects[name] = obj
+	b.structs[t] = obj
+	return obj
+}
+
+type jsonField struct {
+	name string
+	typ  reflect.Type
+}
+
+// Get the JSON fields of a struct that are valid GraphQL field names
+func jsonFields(t reflect.Type) []jsonField {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	fields := []jsonField{}
+	found := make(map[string]bool)
+	embedded := []jsonField{}
+	for ii := 0; ii < t.NumField(); ii++ {
+		sf := t.Field(ii)
+		if sf.PkgPath != "" {
+			// unexported
+			continue
+		}
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if sf.Anonymous && name == "" {
+			embedded = append(embedded, jsonFields(sf.Type)...)
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		if !validName.MatchString(name) || strings.HasPrefix(name, "__") {
+			continue
+		}
+		fields = append(fields, jsonField{name: name, typ: sf.Type})
+		found[name] = true
+	}
+	// fields of the struct hide those of embedded structs
+	for _, field := range embedded {
+		if !found[field.name] {
+			fields = append(fields, field)
+			found[field.name] = true
+		}
+	}
+	return fields
+}
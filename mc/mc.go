@@ -29,6 +29,7 @@ import (
 
 var addr = flag.String("addr", "127.0.0.1:9900", "REST listener address")
 var federationAddr = flag.String("federationAddr", "", "REST listener address for multi-operator platform federation")
+var metricsAddr = flag.String("metricsAddr", "", "Prometheus metrics listener address, metrics are served at /metrics")
 var sqlAddr = flag.String("sqlAddr", "127.0.0.1:5432", "Postgresql address")
 var localSql = flag.Bool("localSql", false, "Run local postgres db")
 var consoleProxyAddr = flag.String("consoleproxyaddr", "127.0.0.1:6080", "Console proxy address")
@@ -62,6 +63,7 @@ var controllerNotifyPort = flag.String("controllerNotifyPort", "50001", "Control
 var consoleAddr = flag.String("consoleAddr", "", "Address of the UI console using MC")
 var passwordResetConsolePath = flag.String("passwordResetConsolePath", "#/passwordreset", "Console URL path to perform password reset action by end-user")
 var verifyEmailConsolePath = flag.String("verifyEmailConsolePath", "#/verify", "Console URL path to perform email verification action by end-user")
+var inviteConsolePath = flag.String("inviteConsolePath", "#/invite", "Console URL path to accept an organization invitation by end-user")
 
 var sigChan chan os.Signal
 var nodeMgr node.NodeMgr
@@ -79,6 +81,7 @@ func main() {
 		SqlAddr:                  *sqlAddr,
 		VaultAddr:                nodeMgr.VaultAddr,
 		FederationAddr:           *federationAddr,
+		MetricsAddr:              *metricsAddr,
 		RunLocal:                 *localSql,
 		InitLocal:                *initSql,
 		LocalVault:               *localVault,
@@ -108,6 +111,7 @@ func main() {
 		ConsoleAddr:              *consoleAddr,
 		PasswordResetConsolePath: *passwordResetConsolePath,
 		VerifyEmailConsolePath:   *verifyEmailConsolePath,
+		InviteConsolePath:        *inviteConsolePath,
 	}
 	server, err := orm.RunServer(&config)
 	if err != nil {
@@ -127,6 +131,25 @@ func main() {
 	orm.InitReporter()
 	go orm.GenerateReports()
 
+	// start maintenance window thread
+	orm.InitMaintenanceWindows()
+	go orm.RunMaintenanceWindows()
+
+	// start notification digest thread
+	orm.InitNotificationDigests()
+	go orm.RunNotificationDigests()
+
+	// start event webhook delivery thread
+	orm.InitEventWebhooks()
+	go orm.RunEventWebhooks()
+
+	// start role expiry thread
+	orm.InitRoleExpirations()
+	go orm.RunRoleExpirations()
+
+	// start audit log retention thread
+	go orm.RunAuditLogRetention()
+
 	// wait until process is killed/interrupted
 	signal.Notify(sigChan, os.Interrupt)
 	<-sigChan
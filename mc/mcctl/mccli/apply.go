@@ -0,0 +1,45 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mccli
+
+import (
+	"github.com/mobiledgex/edge-cloud-infra/mc/mcctl/ormctl"
+	"github.com/mobiledgex/edge-cloud/cli"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Top level "mcctl apply -f <file>" command, which is shorthand for
+// "mcctl data apply --datafile <file>".
+func (s *RootCommand) getApplyCmd() *cobra.Command {
+	cliCmd := s.ConvertCmd(ormctl.MustGetCommand("ApplyData"))
+	// cli wrapper lookups should resolve to the data group command
+	delete(cliCmd.Annotations, LookupKey)
+
+	var filename string
+	cliCmd.AddFlagsFunc = func(flagSet *pflag.FlagSet) {
+		flagSet.StringVarP(&filename, "filename", "f", "", "file containing json/yaml formatted AllData manifest")
+	}
+	run := cliCmd.Run
+	cliCmd.Run = func(c *cli.Command, args []string) error {
+		if filename != "" {
+			if err := c.CobraCmd.Flags().Set("datafile", filename); err != nil {
+				return err
+			}
+		}
+		return run(c, args)
+	}
+	return cliCmd.GenCmd()
+}
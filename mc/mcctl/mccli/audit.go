@@ -0,0 +1,61 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mccli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mobiledgex/edge-cloud-infra/mc/mcctl/ormctl"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+	"github.com/mobiledgex/edge-cloud/cli"
+	"github.com/spf13/cobra"
+)
+
+func (s *RootCommand) getAuditCmdGroup() *cobra.Command {
+	apiGroup := ormctl.MustGetGroup(ormctl.AuditGroup)
+	cmds := []*cli.Command{}
+	for _, c := range apiGroup.Commands {
+		cliCmd := s.ConvertCmd(c)
+		switch c.Name {
+		case "ExportAudit":
+			cliCmd.Run = s.runExportAudit(c.Path)
+		}
+		cmds = append(cmds, cliCmd)
+	}
+	return cli.GenGroup(strings.ToLower(apiGroup.Name), apiGroup.Desc, cmds)
+}
+
+func (s *RootCommand) runExportAudit(path string) func(c *cli.Command, args []string) error {
+	return func(c *cli.Command, args []string) error {
+		c.CobraCmd.SilenceUsage = true
+		in, err := c.ParseInput(args)
+		if err != nil {
+			return err
+		}
+		s.client.Debug = cli.Debug
+
+		query, ok := c.ReqData.(*ormapi.AuditQuery)
+		if !ok {
+			return fmt.Errorf("unable to fetch audit args: %v", c.ReqData)
+		}
+		format := query.Format
+		if format == "" {
+			format = ormapi.ReportFormatJSON
+		}
+		st, err := s.sendReqAndDownloadReport(path, "audit."+format, in.Data)
+		return check(c, st, err, nil)
+	}
+}
@@ -32,7 +32,9 @@ import (
 
 var LookupKey = "lookupkey"
 
-func (s *RootCommand) runRest(path string) func(c *cli.Command, args []string) error {
+// Paged show commands fetch results pageSize objects at a time,
+// and the client follows the continuation tokens to get all pages.
+func (s *RootCommand) runRest(path string, paged bool) func(c *cli.Command, args []string) error {
 	return func(c *cli.Command, args []string) error {
 		if c.ReplyData == nil {
 			c.ReplyData = &ormapi.Result{}
@@ -52,6 +54,12 @@ func (s *RootCommand) runRest(path string) func(c *cli.Command, args []string) e
 			return err
 		}
 		in := mapData.Data
+		if paged && s.pageSize > 0 {
+			if in == nil {
+				in = make(map[string]interface{})
+			}
+			in[ormapi.ShowPageLimitKey] = s.pageSize
+		}
 		if s.client.PrintTransformations {
 			fmt.Printf("%s: transformed args to data %#v\n", log.GetLineno(0), in)
 		}
@@ -215,7 +223,7 @@ func (s *RootCommand) ConvertCmd(api *ormctl.ApiCommand) *cli.Command {
 		StreamOutIncremental: api.StreamOutIncremental,
 		DataFlagOnly:         api.DataFlagOnly,
 		Annotations:          annotations,
-		Run:                  s.runRest(api.Path),
+		Run:                  s.runRest(api.Path, api.IsPaged()),
 	}
 	return cmd
 }
@@ -63,7 +63,7 @@ func (s *RootCommand) runGenerateReport(path string) func(c *cli.Command, args [
 		}
 
 		filename := ormapi.GetReportFileName(report)
-		st, err := s.sendReqAndDownloadPDF(path, filename, in.Data)
+		st, err := s.sendReqAndDownloadReport(path, filename, in.Data)
 		return check(c, st, err, nil)
 	}
 }
@@ -86,12 +86,12 @@ func (s *RootCommand) runDownloadReport(path string) func(c *cli.Command, args [
 			return fmt.Errorf("unable to fetch report args: %v", c.ReqData)
 		}
 
-		st, err := s.sendReqAndDownloadPDF(path, report.Filename, in.Data)
+		st, err := s.sendReqAndDownloadReport(path, report.Filename, in.Data)
 		return check(c, st, err, nil)
 	}
 }
 
-func (s *RootCommand) sendReqAndDownloadPDF(path, filename string, reqData interface{}) (int, error) {
+func (s *RootCommand) sendReqAndDownloadReport(path, filename string, reqData interface{}) (int, error) {
 	uri := s.getUri() + path
 	resp, err := s.client.PostJsonSend(uri, s.token, reqData)
 	if err != nil {
@@ -124,6 +124,6 @@ func (s *RootCommand) sendReqAndDownloadPDF(path, filename string, reqData inter
 	if err != nil {
 		return http.StatusBadRequest, fmt.Errorf("failed to created file %s, %v", filename, err)
 	}
-	fmt.Printf("Saved PDF report to %s\n", filename)
+	fmt.Printf("Saved report to %s\n", filename)
 	return resp.StatusCode, nil
 }
@@ -31,6 +31,7 @@ type RootCommand struct {
 	addr       string
 	token      string
 	skipVerify bool
+	pageSize   int
 	client     ormclient.Client
 	CobraCmd   *cobra.Command
 	clearState bool
@@ -42,6 +43,7 @@ func (s *RootCommand) ClearState() {
 	s.addr = ""
 	s.token = ""
 	s.skipVerify = false
+	s.pageSize = 0
 	s.clearState = true
 }
 
@@ -60,6 +62,7 @@ func GetRootCommand() *RootCommand {
 		rc.getCmdGroup(ormctl.UserGroup),
 		rc.getCmdGroup(ormctl.RoleGroup),
 		rc.getCmdGroup(ormctl.OrgGroup),
+		rc.getCmdGroup(ormctl.OrgQuotaGroup),
 		rc.getCmdGroup(ormctl.BillingOrgGroup),
 	}
 	operatorCommands := []*cobra.Command{
@@ -73,6 +76,8 @@ func GetRootCommand() *RootCommand {
 		rc.getCmdGroup(ormctl.CloudletRefsGroup),
 		rc.getCmdGroup(ormctl.VMPoolGroup),
 		rc.getCmdGroup(ormctl.ReporterGroup),
+		rc.getCmdGroup(ormctl.MaintenanceWindowGroup),
+		rc.getCmdGroup(ormctl.IdentityProviderGroup),
 		rc.getCmdGroup(ormctl.GPUDriverGroup),
 		rc.getCmdGroup(ormctl.TrustPolicyExceptionGroup),
 		rc.getCmdGroup(ormctl.NetworkGroup),
@@ -112,6 +117,8 @@ func GetRootCommand() *RootCommand {
 		rc.getCmdGroup(ormctl.SpansGroup),
 		rc.getCmd("RestrictedUpdateUser"),
 		rc.getCmd("RestrictedUpdateOrg"),
+		rc.getCmd("SuspendOrg"),
+		rc.getCmd("ResumeOrg"),
 		rc.getCmdGroup(ormctl.RateLimitSettingsGroup),
 		rc.getCmdGroup(ormctl.RateLimitSettingsMcGroup),
 	}
@@ -119,9 +126,15 @@ func GetRootCommand() *RootCommand {
 		rc.getCmdGroup(ormctl.MetricsGroup),
 		rc.getCmdGroup(ormctl.BillingEventsGroup),
 		rc.getCmdGroup(ormctl.EventsGroup),
+		rc.getCmdGroup(ormctl.EventWebhookGroup),
+		rc.getCmdGroup(ormctl.GraphQLGroup),
+		rc.getAuditCmdGroup(),
 		rc.getCmdGroup(ormctl.UsageGroup),
 		rc.getCmdGroup(ormctl.AlertReceiverGroup),
+		rc.getCmdGroup(ormctl.AlertSilenceGroup),
 		rc.getCmdGroup(ormctl.AlertPolicyGroup),
+		rc.getCmdGroup(ormctl.DataGroup),
+		rc.getApplyCmd(),
 	}
 	otherCommands := []*cobra.Command{
 		GetVersionCmd(),
@@ -195,6 +208,7 @@ func GetRootCommand() *RootCommand {
 	cli.AddOutputFlags(rootCmd.PersistentFlags())
 	cli.AddDebugFlag(rootCmd.PersistentFlags())
 	rootCmd.PersistentFlags().BoolVar(&rc.skipVerify, "skipverify", false, "don't verify cert for TLS connections")
+	rootCmd.PersistentFlags().IntVar(&rc.pageSize, "pagesize", 0, "number of objects to fetch per request for show commands that support paging, 0 to fetch all at once")
 
 	cobra.EnableCommandSorting = false
 	return rc
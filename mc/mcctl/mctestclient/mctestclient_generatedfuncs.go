@@ -155,6 +155,46 @@ func (s *Client) ShowAlertReceiver(uri string, token string, in *ormapi.AlertRec
 	return out, rundata.RetStatus, rundata.RetError
 }
 
+// Generating group AlertSilence
+
+func (s *Client) CreateAlertSilence(uri string, token string, in *ormapi.AlertSilence) (int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+
+	apiCmd := ormctl.MustGetCommand("CreateAlertSilence")
+	s.ClientRun.Run(apiCmd, &rundata)
+	return rundata.RetStatus, rundata.RetError
+}
+
+func (s *Client) DeleteAlertSilence(uri string, token string, in *ormapi.AlertSilence) (int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+
+	apiCmd := ormctl.MustGetCommand("DeleteAlertSilence")
+	s.ClientRun.Run(apiCmd, &rundata)
+	return rundata.RetStatus, rundata.RetError
+}
+
+func (s *Client) ShowAlertSilence(uri string, token string, in *ormapi.AlertSilence) ([]ormapi.AlertSilence, int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+	var out []ormapi.AlertSilence
+	rundata.Out = &out
+
+	apiCmd := ormctl.MustGetCommand("ShowAlertSilence")
+	s.ClientRun.Run(apiCmd, &rundata)
+	if rundata.RetError != nil {
+		return nil, rundata.RetStatus, rundata.RetError
+	}
+	return out, rundata.RetStatus, rundata.RetError
+}
+
 // Generating group App
 
 func (s *Client) CreateApp(uri string, token string, in *ormapi.RegionApp) (*edgeproto.Result, int, error) {
@@ -445,6 +485,35 @@ func (s *Client) ShowAppInstRefs(uri string, token string, in *ormapi.RegionAppI
 	return out, rundata.RetStatus, rundata.RetError
 }
 
+// Generating group Audit
+
+func (s *Client) ShowAudit(uri string, token string, in *ormapi.AuditQuery) ([]ormapi.AuditResponse, int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+	var out []ormapi.AuditResponse
+	rundata.Out = &out
+
+	apiCmd := ormctl.MustGetCommand("ShowAudit")
+	s.ClientRun.Run(apiCmd, &rundata)
+	if rundata.RetError != nil {
+		return nil, rundata.RetStatus, rundata.RetError
+	}
+	return out, rundata.RetStatus, rundata.RetError
+}
+
+func (s *Client) ExportAudit(uri string, token string, in *ormapi.AuditQuery) (int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+
+	apiCmd := ormctl.MustGetCommand("ExportAudit")
+	s.ClientRun.Run(apiCmd, &rundata)
+	return rundata.RetStatus, rundata.RetError
+}
+
 // Generating group AutoProvPolicy
 
 func (s *Client) CreateAutoProvPolicy(uri string, token string, in *ormapi.RegionAutoProvPolicy) (*edgeproto.Result, int, error) {
@@ -1598,6 +1667,56 @@ func (s *Client) ShowController(uri string, token string, in *cli.MapData) ([]or
 	return out, rundata.RetStatus, rundata.RetError
 }
 
+// Generating group Data
+
+func (s *Client) DiffData(uri string, token string, in *ormapi.AllData) ([]ormapi.DataChange, int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+	var out []ormapi.DataChange
+	rundata.Out = &out
+
+	apiCmd := ormctl.MustGetCommand("DiffData")
+	s.ClientRun.Run(apiCmd, &rundata)
+	if rundata.RetError != nil {
+		return nil, rundata.RetStatus, rundata.RetError
+	}
+	return out, rundata.RetStatus, rundata.RetError
+}
+
+func (s *Client) ApplyData(uri string, token string, in *ormapi.AllData) ([]ormapi.DataChange, int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+	var out []ormapi.DataChange
+	rundata.Out = &out
+
+	apiCmd := ormctl.MustGetCommand("ApplyData")
+	s.ClientRun.Run(apiCmd, &rundata)
+	if rundata.RetError != nil {
+		return nil, rundata.RetStatus, rundata.RetError
+	}
+	return out, rundata.RetStatus, rundata.RetError
+}
+
+func (s *Client) PruneData(uri string, token string, in *ormapi.AllData) ([]ormapi.DataChange, int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+	var out []ormapi.DataChange
+	rundata.Out = &out
+
+	apiCmd := ormctl.MustGetCommand("PruneData")
+	s.ClientRun.Run(apiCmd, &rundata)
+	if rundata.RetError != nil {
+		return nil, rundata.RetStatus, rundata.RetError
+	}
+	return out, rundata.RetStatus, rundata.RetError
+}
+
 // Generating group Debug
 
 func (s *Client) EnableDebugLevels(uri string, token string, in *ormapi.RegionDebugRequest) ([]edgeproto.DebugReply, int, error) {
@@ -1730,6 +1849,84 @@ func (s *Client) ShowDeviceReport(uri string, token string, in *ormapi.RegionDev
 	return out, rundata.RetStatus, rundata.RetError
 }
 
+// Generating group EventWebhook
+
+func (s *Client) CreateEventWebhook(uri string, token string, in *ormapi.EventWebhook) (int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+
+	apiCmd := ormctl.MustGetCommand("CreateEventWebhook")
+	s.ClientRun.Run(apiCmd, &rundata)
+	return rundata.RetStatus, rundata.RetError
+}
+
+func (s *Client) UpdateEventWebhook(uri string, token string, in *cli.MapData) (int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+
+	apiCmd := ormctl.MustGetCommand("UpdateEventWebhook")
+	s.ClientRun.Run(apiCmd, &rundata)
+	return rundata.RetStatus, rundata.RetError
+}
+
+func (s *Client) DeleteEventWebhook(uri string, token string, in *ormapi.EventWebhook) (int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+
+	apiCmd := ormctl.MustGetCommand("DeleteEventWebhook")
+	s.ClientRun.Run(apiCmd, &rundata)
+	return rundata.RetStatus, rundata.RetError
+}
+
+func (s *Client) ShowEventWebhook(uri string, token string, in *cli.MapData) ([]ormapi.EventWebhook, int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+	var out []ormapi.EventWebhook
+	rundata.Out = &out
+
+	apiCmd := ormctl.MustGetCommand("ShowEventWebhook")
+	s.ClientRun.Run(apiCmd, &rundata)
+	if rundata.RetError != nil {
+		return nil, rundata.RetStatus, rundata.RetError
+	}
+	return out, rundata.RetStatus, rundata.RetError
+}
+
+func (s *Client) ShowEventWebhookDelivery(uri string, token string, in *cli.MapData) ([]ormapi.EventWebhookDelivery, int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+	var out []ormapi.EventWebhookDelivery
+	rundata.Out = &out
+
+	apiCmd := ormctl.MustGetCommand("ShowEventWebhookDelivery")
+	s.ClientRun.Run(apiCmd, &rundata)
+	if rundata.RetError != nil {
+		return nil, rundata.RetStatus, rundata.RetError
+	}
+	return out, rundata.RetStatus, rundata.RetError
+}
+
+func (s *Client) RetryEventWebhookDelivery(uri string, token string, in *ormapi.EventWebhookDelivery) (int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+
+	apiCmd := ormctl.MustGetCommand("RetryEventWebhookDelivery")
+	s.ClientRun.Run(apiCmd, &rundata)
+	return rundata.RetStatus, rundata.RetError
+}
+
 // Generating group Events
 
 func (s *Client) ShowEvents(uri string, token string, in *node.EventSearch) ([]node.EventData, int, error) {
@@ -2424,6 +2621,115 @@ func (s *Client) GetGPUDriverLicenseConfig(uri string, token string, in *ormapi.
 	return &out, rundata.RetStatus, rundata.RetError
 }
 
+// Generating group GraphQL
+
+func (s *Client) GraphQL(uri string, token string, in *ormapi.GraphQLQuery) (*ormapi.GraphQLResponse, int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+	var out ormapi.GraphQLResponse
+	rundata.Out = &out
+
+	apiCmd := ormctl.MustGetCommand("GraphQL")
+	s.ClientRun.Run(apiCmd, &rundata)
+	if rundata.RetError != nil {
+		return nil, rundata.RetStatus, rundata.RetError
+	}
+	return &out, rundata.RetStatus, rundata.RetError
+}
+
+// Generating group IdentityProvider
+
+func (s *Client) CreateIdentityProvider(uri string, token string, in *ormapi.IdentityProvider) (int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+
+	apiCmd := ormctl.MustGetCommand("CreateIdentityProvider")
+	s.ClientRun.Run(apiCmd, &rundata)
+	return rundata.RetStatus, rundata.RetError
+}
+
+func (s *Client) UpdateIdentityProvider(uri string, token string, in *cli.MapData) (int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+
+	apiCmd := ormctl.MustGetCommand("UpdateIdentityProvider")
+	s.ClientRun.Run(apiCmd, &rundata)
+	return rundata.RetStatus, rundata.RetError
+}
+
+func (s *Client) DeleteIdentityProvider(uri string, token string, in *ormapi.IdentityProvider) (int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+
+	apiCmd := ormctl.MustGetCommand("DeleteIdentityProvider")
+	s.ClientRun.Run(apiCmd, &rundata)
+	return rundata.RetStatus, rundata.RetError
+}
+
+func (s *Client) ShowIdentityProvider(uri string, token string, in *ormapi.IdentityProvider) ([]ormapi.IdentityProvider, int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+	var out []ormapi.IdentityProvider
+	rundata.Out = &out
+
+	apiCmd := ormctl.MustGetCommand("ShowIdentityProvider")
+	s.ClientRun.Run(apiCmd, &rundata)
+	if rundata.RetError != nil {
+		return nil, rundata.RetStatus, rundata.RetError
+	}
+	return out, rundata.RetStatus, rundata.RetError
+}
+
+// Generating group MaintenanceWindow
+
+func (s *Client) CreateMaintenanceWindow(uri string, token string, in *ormapi.MaintenanceWindow) (int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+
+	apiCmd := ormctl.MustGetCommand("CreateMaintenanceWindow")
+	s.ClientRun.Run(apiCmd, &rundata)
+	return rundata.RetStatus, rundata.RetError
+}
+
+func (s *Client) DeleteMaintenanceWindow(uri string, token string, in *ormapi.MaintenanceWindow) (int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+
+	apiCmd := ormctl.MustGetCommand("DeleteMaintenanceWindow")
+	s.ClientRun.Run(apiCmd, &rundata)
+	return rundata.RetStatus, rundata.RetError
+}
+
+func (s *Client) ShowMaintenanceWindow(uri string, token string, in *ormapi.MaintenanceWindow) ([]ormapi.MaintenanceWindow, int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+	var out []ormapi.MaintenanceWindow
+	rundata.Out = &out
+
+	apiCmd := ormctl.MustGetCommand("ShowMaintenanceWindow")
+	s.ClientRun.Run(apiCmd, &rundata)
+	if rundata.RetError != nil {
+		return nil, rundata.RetStatus, rundata.RetError
+	}
+	return out, rundata.RetStatus, rundata.RetError
+}
+
 // Generating group Metrics
 
 func (s *Client) ShowAppMetrics(uri string, token string, in *ormapi.RegionAppInstMetrics) (*ormapi.AllMetrics, int, error) {
@@ -2781,6 +3087,57 @@ func (s *Client) ShowOrgCloudletInfo(uri string, token string, in *ormapi.OrgClo
 	return out, rundata.RetStatus, rundata.RetError
 }
 
+// Generating group OrgQuota
+
+func (s *Client) CreateOrgQuota(uri string, token string, in *ormapi.OrgQuota) (int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+
+	apiCmd := ormctl.MustGetCommand("CreateOrgQuota")
+	s.ClientRun.Run(apiCmd, &rundata)
+	return rundata.RetStatus, rundata.RetError
+}
+
+func (s *Client) UpdateOrgQuota(uri string, token string, in *cli.MapData) (int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+
+	apiCmd := ormctl.MustGetCommand("UpdateOrgQuota")
+	s.ClientRun.Run(apiCmd, &rundata)
+	return rundata.RetStatus, rundata.RetError
+}
+
+func (s *Client) DeleteOrgQuota(uri string, token string, in *ormapi.OrgQuota) (int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+
+	apiCmd := ormctl.MustGetCommand("DeleteOrgQuota")
+	s.ClientRun.Run(apiCmd, &rundata)
+	return rundata.RetStatus, rundata.RetError
+}
+
+func (s *Client) ShowOrgQuota(uri string, token string, in *ormapi.OrgQuota) ([]ormapi.OrgQuotaUsage, int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+	var out []ormapi.OrgQuotaUsage
+	rundata.Out = &out
+
+	apiCmd := ormctl.MustGetCommand("ShowOrgQuota")
+	s.ClientRun.Run(apiCmd, &rundata)
+	if rundata.RetError != nil {
+		return nil, rundata.RetStatus, rundata.RetError
+	}
+	return out, rundata.RetStatus, rundata.RetError
+}
+
 // Generating group RateLimitSettings
 
 func (s *Client) ShowRateLimitSettings(uri string, token string, in *ormapi.RegionRateLimitSettings) ([]edgeproto.RateLimitSettings, int, error) {
@@ -3387,6 +3744,109 @@ func (s *Client) ShowRolePerm(uri string, token string, in *cli.MapData) ([]orma
 	return out, rundata.RetStatus, rundata.RetError
 }
 
+func (s *Client) CreateCustomRole(uri string, token string, in *ormapi.CustomRole) (int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+
+	apiCmd := ormctl.MustGetCommand("CreateCustomRole")
+	s.ClientRun.Run(apiCmd, &rundata)
+	return rundata.RetStatus, rundata.RetError
+}
+
+func (s *Client) UpdateCustomRole(uri string, token string, in *cli.MapData) (int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+
+	apiCmd := ormctl.MustGetCommand("UpdateCustomRole")
+	s.ClientRun.Run(apiCmd, &rundata)
+	return rundata.RetStatus, rundata.RetError
+}
+
+func (s *Client) DeleteCustomRole(uri string, token string, in *ormapi.CustomRole) (int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+
+	apiCmd := ormctl.MustGetCommand("DeleteCustomRole")
+	s.ClientRun.Run(apiCmd, &rundata)
+	return rundata.RetStatus, rundata.RetError
+}
+
+func (s *Client) ShowCustomRole(uri string, token string, in *ormapi.CustomRole) ([]ormapi.CustomRole, int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+	var out []ormapi.CustomRole
+	rundata.Out = &out
+
+	apiCmd := ormctl.MustGetCommand("ShowCustomRole")
+	s.ClientRun.Run(apiCmd, &rundata)
+	if rundata.RetError != nil {
+		return nil, rundata.RetStatus, rundata.RetError
+	}
+	return out, rundata.RetStatus, rundata.RetError
+}
+
+func (s *Client) CreateOrgInvitation(uri string, token string, in *ormapi.OrgInvitation) (*ormapi.OrgInvitationResponse, int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+	var out ormapi.OrgInvitationResponse
+	rundata.Out = &out
+
+	apiCmd := ormctl.MustGetCommand("CreateOrgInvitation")
+	s.ClientRun.Run(apiCmd, &rundata)
+	if rundata.RetError != nil {
+		return nil, rundata.RetStatus, rundata.RetError
+	}
+	return &out, rundata.RetStatus, rundata.RetError
+}
+
+func (s *Client) ShowOrgInvitation(uri string, token string, in *cli.MapData) ([]ormapi.OrgInvitation, int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+	var out []ormapi.OrgInvitation
+	rundata.Out = &out
+
+	apiCmd := ormctl.MustGetCommand("ShowOrgInvitation")
+	s.ClientRun.Run(apiCmd, &rundata)
+	if rundata.RetError != nil {
+		return nil, rundata.RetStatus, rundata.RetError
+	}
+	return out, rundata.RetStatus, rundata.RetError
+}
+
+func (s *Client) RevokeOrgInvitation(uri string, token string, in *ormapi.OrgInvitation) (int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+
+	apiCmd := ormctl.MustGetCommand("RevokeOrgInvitation")
+	s.ClientRun.Run(apiCmd, &rundata)
+	return rundata.RetStatus, rundata.RetError
+}
+
+func (s *Client) AcceptOrgInvitation(uri string, token string, in *ormapi.Token) (int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+
+	apiCmd := ormctl.MustGetCommand("AcceptOrgInvitation")
+	s.ClientRun.Run(apiCmd, &rundata)
+	return rundata.RetStatus, rundata.RetError
+}
+
 // Generating group Settings
 
 func (s *Client) UpdateSettings(uri string, token string, in *ormapi.RegionSettings) (*edgeproto.Result, int, error) {
@@ -3916,6 +4376,64 @@ func (s *Client) ShowUserApiKey(uri string, token string, in *ormapi.CreateUserA
 	return out, rundata.RetStatus, rundata.RetError
 }
 
+func (s *Client) RotateUserApiKey(uri string, token string, in *ormapi.CreateUserApiKey) (*ormapi.CreateUserApiKey, int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+	var out ormapi.CreateUserApiKey
+	rundata.Out = &out
+
+	apiCmd := ormctl.MustGetCommand("RotateUserApiKey")
+	s.ClientRun.Run(apiCmd, &rundata)
+	if rundata.RetError != nil {
+		return nil, rundata.RetStatus, rundata.RetError
+	}
+	return &out, rundata.RetStatus, rundata.RetError
+}
+
+func (s *Client) ShowStaleUserApiKey(uri string, token string, in *ormapi.StaleApiKeyFilter) ([]ormapi.CreateUserApiKey, int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+	var out []ormapi.CreateUserApiKey
+	rundata.Out = &out
+
+	apiCmd := ormctl.MustGetCommand("ShowStaleUserApiKey")
+	s.ClientRun.Run(apiCmd, &rundata)
+	if rundata.RetError != nil {
+		return nil, rundata.RetStatus, rundata.RetError
+	}
+	return out, rundata.RetStatus, rundata.RetError
+}
+
+func (s *Client) ShowNotificationPrefs(uri string, token string) (*ormapi.NotificationPreferences, int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	var out ormapi.NotificationPreferences
+	rundata.Out = &out
+
+	apiCmd := ormctl.MustGetCommand("ShowNotificationPrefs")
+	s.ClientRun.Run(apiCmd, &rundata)
+	if rundata.RetError != nil {
+		return nil, rundata.RetStatus, rundata.RetError
+	}
+	return &out, rundata.RetStatus, rundata.RetError
+}
+
+func (s *Client) UpdateNotificationPrefs(uri string, token string, in *cli.MapData) (int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+
+	apiCmd := ormctl.MustGetCommand("UpdateNotificationPrefs")
+	s.ClientRun.Run(apiCmd, &rundata)
+	return rundata.RetStatus, rundata.RetError
+}
+
 // Generating group VMPool
 
 func (s *Client) CreateVMPool(uri string, token string, in *ormapi.RegionVMPool) (*edgeproto.Result, int, error) {
@@ -4051,6 +4569,17 @@ func (s *Client) Login(uri string, in *ormapi.UserLogin) (map[string]interface{}
 	return out, rundata.RetStatus, rundata.RetError
 }
 
+func (s *Client) ResumeOrg(uri string, token string, in *ormapi.OrgSuspend) (int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+
+	apiCmd := ormctl.MustGetCommand("ResumeOrg")
+	s.ClientRun.Run(apiCmd, &rundata)
+	return rundata.RetStatus, rundata.RetError
+}
+
 func (s *Client) RestrictedUpdateOrg(uri string, token string, in *cli.MapData) (int, error) {
 	rundata := RunData{}
 	rundata.Uri = uri
@@ -4105,3 +4634,14 @@ func (s *Client) ShowLogsCli(uri string, token string, in *ormapi.RegionExecRequ
 	return out, rundata.RetStatus, rundata.RetError
 }
 
+func (s *Client) SuspendOrg(uri string, token string, in *ormapi.OrgSuspend) (int, error) {
+	rundata := RunData{}
+	rundata.Uri = uri
+	rundata.Token = token
+	rundata.In = in
+
+	apiCmd := ormctl.MustGetCommand("SuspendOrg")
+	s.ClientRun.Run(apiCmd, &rundata)
+	return rundata.RetStatus, rundata.RetError
+}
+
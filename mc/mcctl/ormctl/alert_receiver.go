@@ -26,6 +26,12 @@ var AlertReceiverAliasArgs = []string{
 	"slackapiurl=slackwebhook",
 	"pagerdutyintegrationkey=pagerdutyintegrationkey",
 	"pagerdutyapiversion=pagerdutyapiversion",
+	"webhookurl=webhookurl",
+	"webhookuser=webhookuser",
+	"webhookpassword=webhookpassword",
+	"webhookbearertoken=webhookbearertoken",
+	"webhookheaders=webhookheaders",
+	"teamswebhook=teamswebhook",
 	"apporg=appinst.appkey.organization",
 	"appname=appinst.appkey.name",
 	"appvers=appinst.appkey.version",
@@ -47,6 +53,7 @@ func init() {
 		RequiredArgs: strings.Join(AlertReceiverRequiredArgs, " "),
 		OptionalArgs: strings.Join(AlertReceiverOptionalArgs, " "),
 		AliasArgs:    strings.Join(AlertReceiverAliasArgs, " "),
+		SpecialArgs:  &AlertReceiverSpecialArgs,
 		Comments:     AlertReceiverArgsComments,
 		ReqData:      &ormapi.AlertReceiver{},
 		Path:         "/auth/alertreceiver/create",
@@ -70,6 +77,7 @@ func init() {
 		ReqData:      &ormapi.AlertReceiver{},
 		ReplyData:    &[]ormapi.AlertReceiver{},
 		Path:         "/auth/alertreceiver/show",
+		Paged:        true,
 	}}
 	AllApis.AddGroup(AlertReceiverGroup, "Manage alert receivers", cmds)
 }
@@ -88,6 +96,12 @@ var AlertReceiverOptionalArgs = []string{
 	"slackapiurl",
 	"pagerdutyintegrationkey",
 	"pagerdutyapiversion",
+	"webhookurl",
+	"webhookuser",
+	"webhookpassword",
+	"webhookbearertoken",
+	"webhookheaders",
+	"teamswebhook",
 	"appname",
 	"appvers",
 	"apporg",
@@ -103,13 +117,19 @@ var AlertReceiverArgsComments = map[string]string{
 	"region":                  "Region where alert originated",
 	"user":                    "User name, if not the same as the logged in user",
 	"name":                    "Unique name of this receiver",
-	"type":                    "Receiver type - email, slack or pagerduty",
+	"type":                    "Receiver type - email, slack, pagerduty, webhook or teams",
 	"severity":                "Alert severity level - one of " + cloudcommon.GetValidAlertSeverityString(),
 	"email":                   "Email address receiving the alert (by default email associated with the account)",
 	"slackchannel":            "Slack channel to be receiving the alert",
 	"slackapiurl":             "Slack webhook url",
 	"pagerdutyintegrationkey": "PagerDuty Integration key",
 	"pagerdutyapiversion":     "PagerDuty API version(\"v1\" or \"v2\"). By default \"v2\" is used",
+	"webhookurl":              "Generic webhook URL",
+	"webhookuser":             "Generic webhook basic auth username",
+	"webhookpassword":         "Generic webhook basic auth password, stored in Vault",
+	"webhookbearertoken":      "Generic webhook bearer token, stored in Vault",
+	"webhookheaders":          "Custom HTTP headers sent with each webhook request",
+	"teamswebhook":            "Microsoft Teams incoming webhook URL",
 	"apporg":                  "Organization or Company name of the App Instance",
 	"appname":                 "App Instance name",
 	"appvers":                 "App Instance version",
@@ -120,3 +140,7 @@ var AlertReceiverArgsComments = map[string]string{
 	"cloudletorg":             "Company or Organization name of the cloudlet",
 	"cloudlet":                "Name of the cloudlet",
 }
+
+var AlertReceiverSpecialArgs = map[string]string{
+	"webhookheaders": "StringToString",
+}
@@ -0,0 +1,116 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ormctl
+
+import (
+	"strings"
+
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+	"github.com/mobiledgex/edge-cloud/cloudcommon"
+)
+
+var AlertSilenceAliasArgs = []string{
+	"alertname=alertname",
+	"apporg=appinst.appkey.organization",
+	"appname=appinst.appkey.name",
+	"appvers=appinst.appkey.version",
+	"cluster=appinst.clusterinstkey.clusterkey.name",
+	"clusterorg=appinst.clusterinstkey.organization",
+	"appcloudletorg=appinst.clusterinstkey.cloudletkey.organization",
+	"appcloudlet=appinst.clusterinstkey.cloudletkey.name",
+	"cloudletorg=cloudlet.organization",
+	"cloudlet=cloudlet.name",
+	"startsat=startsat",
+	"endsat=endsat",
+	"createdby=createdby",
+}
+
+const AlertSilenceGroup = "AlertSilence"
+
+func init() {
+	cmds := []*ApiCommand{&ApiCommand{
+		Name:         "CreateAlertSilence",
+		Use:          "create",
+		Short:        "Create an alert silence",
+		RequiredArgs: strings.Join(AlertSilenceRequiredArgs, " "),
+		OptionalArgs: strings.Join(AlertSilenceOptionalArgs, " "),
+		AliasArgs:    strings.Join(AlertSilenceAliasArgs, " "),
+		Comments:     AlertSilenceArgsComments,
+		ReqData:      &ormapi.AlertSilence{},
+		Path:         "/auth/alertsilence/create",
+	}, &ApiCommand{
+		Name:         "DeleteAlertSilence",
+		Use:          "delete",
+		Short:        "Delete an alert silence",
+		RequiredArgs: "id",
+		AliasArgs:    strings.Join(AlertSilenceAliasArgs, " "),
+		Comments:     AlertSilenceArgsComments,
+		ReqData:      &ormapi.AlertSilence{},
+		Path:         "/auth/alertsilence/delete",
+	}, &ApiCommand{
+		Name:         "ShowAlertSilence",
+		Use:          "show",
+		Short:        "Show alert silences",
+		AliasArgs:    strings.Join(AlertSilenceAliasArgs, " "),
+		Comments:     AlertSilenceArgsComments,
+		OptionalArgs: "id state createdby " + strings.Join(AlertSilenceOptionalArgs, " ") + " " + strings.Join(AlertSilenceRequiredArgs, " "),
+		ReqData:      &ormapi.AlertSilence{},
+		ReplyData:    &[]ormapi.AlertSilence{},
+		Path:         "/auth/alertsilence/show",
+	}}
+	AllApis.AddGroup(AlertSilenceGroup, "Manage alert silences", cmds)
+}
+
+var AlertSilenceRequiredArgs = []string{
+	"endsat",
+}
+
+var AlertSilenceOptionalArgs = []string{
+	"region",
+	"alertname",
+	"severity",
+	"startsat",
+	"comment",
+	"appname",
+	"appvers",
+	"apporg",
+	"appcloudlet",
+	"appcloudletorg",
+	"cluster",
+	"clusterorg",
+	"cloudlet",
+	"cloudletorg",
+}
+
+var AlertSilenceArgsComments = map[string]string{
+	"id":             "Silence ID assigned by AlertManager",
+	"region":         "Region for the silenced alerts",
+	"alertname":      "Name of the alert to silence, all alerts if not specified",
+	"severity":       "Alert severity level to silence - one of " + cloudcommon.GetValidAlertSeverityString(),
+	"startsat":       "Absolute time (in RFC3339 format with intended timezone) when the silence starts (Default: now)",
+	"endsat":         "Absolute time (in RFC3339 format with intended timezone) when the silence ends",
+	"comment":        "Reason for silencing the alerts",
+	"createdby":      "User that created this silence",
+	"state":          "Silence state: active, pending or expired",
+	"apporg":         "Organization or Company name of the App Instance",
+	"appname":        "App Instance name",
+	"appvers":        "App Instance version",
+	"appcloudlet":    "Cloudlet name where app instance is deployed",
+	"appcloudletorg": "Company or Organization that owns the cloudlet",
+	"cluster":        "App Instance Cluster name",
+	"clusterorg":     "Company or Organization Name that a Cluster is owned by",
+	"cloudletorg":    "Company or Organization name of the cloudlet",
+	"cloudlet":       "Name of the cloudlet",
+}
@@ -18,6 +18,7 @@ import (
 	fmt "fmt"
 	"strings"
 
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
 	"github.com/mobiledgex/edge-cloud/util"
 )
 
@@ -51,6 +52,20 @@ type ApiCommand struct {
 	IsUpdate             bool
 	CliEmptyRequiredArgs string
 	ShowFilter           bool
+	Paged                bool
+}
+
+// IsPaged checks if the show command supports paging of results
+// via the ormapi.ShowPage keys.
+func (s *ApiCommand) IsPaged() bool {
+	if s.Paged {
+		return true
+	}
+	if !s.ProtobufApi || !s.StreamOut || !strings.HasPrefix(s.Name, "Show") {
+		return false
+	}
+	_, found := ormapi.UnpagedShowStreams[s.Name]
+	return !found
 }
 
 type ApiGroup struct {
@@ -0,0 +1,82 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ormctl
+
+import (
+	"strings"
+
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+)
+
+const AuditGroup = "Audit"
+
+func init() {
+	cmds := []*ApiCommand{&ApiCommand{
+		Name:         "ShowAudit",
+		Use:          "show",
+		Short:        "Show audit logs",
+		OptionalArgs: strings.Join(AuditOptionalArgs, " "),
+		AliasArgs:    strings.Join(AuditAliasArgs, " "),
+		Comments:     AuditComments,
+		SpecialArgs:  &AuditSpecialArgs,
+		ReqData:      &ormapi.AuditQuery{},
+		ReplyData:    &[]ormapi.AuditResponse{},
+		Path:         "/auth/audit/show",
+	}, &ApiCommand{
+		Name:         "ExportAudit",
+		Use:          "export",
+		Short:        "Export audit logs to a JSON or CSV file",
+		OptionalArgs: strings.Join(append(AuditOptionalArgs, "format"), " "),
+		AliasArgs:    strings.Join(AuditAliasArgs, " "),
+		Comments:     AuditComments,
+		SpecialArgs:  &AuditSpecialArgs,
+		ReqData:      &ormapi.AuditQuery{},
+		Path:         "/auth/audit/export",
+	}}
+	AllApis.AddGroup(AuditGroup, "Search and export audit logs", cmds)
+}
+
+var AuditOptionalArgs = []string{
+	"username",
+	"org",
+	"operation",
+	"status",
+	"clientip",
+	"tags",
+	"starttime",
+	"endtime",
+	"startage",
+	"endage",
+	"from",
+	"limit",
+}
+
+var AuditAliasArgs = []string{
+	"starttime=timerange.starttime",
+	"endtime=timerange.endtime",
+	"startage=timerange.startage",
+	"endage=timerange.endage",
+}
+
+var AuditComments = mergeMetricComments(ormapi.AuditQueryComments, map[string]string{
+	"starttime": "absolute time of search range start (RFC3339)",
+	"endtime":   "absolute time of search range end (RFC3339)",
+	"startage":  "relative age from now of search range start (default 48h)",
+	"endage":    "relative age from now of search range end (default 0)",
+})
+
+var AuditSpecialArgs = map[string]string{
+	"tags": "StringToString",
+}
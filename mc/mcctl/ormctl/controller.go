@@ -56,6 +56,7 @@ func init() {
 		ReqData:      &ormapi.Controller{},
 		ReplyData:    &[]ormapi.Controller{},
 		ShowFilter:   true,
+		Paged:        true,
 		Path:         "/auth/controller/show",
 	}}
 	AllApis.AddGroup(ControllerGroup, "Manage regional controllers", cmds)
@@ -0,0 +1,50 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ormctl
+
+import (
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+)
+
+const DataGroup = "Data"
+
+func init() {
+	cmds := []*ApiCommand{&ApiCommand{
+		Name:         "DiffData",
+		Use:          "diff",
+		Short:        "Show the changes needed to converge regional objects to an AllData manifest, specified via --datafile",
+		ReqData:      &ormapi.AllData{},
+		ReplyData:    &[]ormapi.DataChange{},
+		Path:         "/auth/data/diff",
+		DataFlagOnly: true,
+	}, &ApiCommand{
+		Name:         "ApplyData",
+		Use:          "apply",
+		Short:        "Create and update regional objects from an AllData manifest, specified via --datafile",
+		ReqData:      &ormapi.AllData{},
+		ReplyData:    &[]ormapi.DataChange{},
+		Path:         "/auth/data/apply",
+		DataFlagOnly: true,
+	}, &ApiCommand{
+		Name:         "PruneData",
+		Use:          "prune",
+		Short:        "Delete regional objects of the manifest's organizations that are not in an AllData manifest, specified via --datafile",
+		ReqData:      &ormapi.AllData{},
+		ReplyData:    &[]ormapi.DataChange{},
+		Path:         "/auth/data/prune",
+		DataFlagOnly: true,
+	}}
+	AllApis.AddGroup(DataGroup, "Declaratively manage regional data", cmds)
+}
@@ -0,0 +1,92 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ormctl
+
+import (
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+)
+
+const EventWebhookGroup = "EventWebhook"
+
+func init() {
+	cmds := []*ApiCommand{&ApiCommand{
+		Name:         "CreateEventWebhook",
+		Use:          "create",
+		Short:        "Subscribe a webhook to events of an organization",
+		RequiredArgs: "name org url secret",
+		OptionalArgs: "names types regions tags failed disabled",
+		SpecialArgs:  &EventWebhookSpecialArgs,
+		ReqData:      &ormapi.EventWebhook{},
+		Comments:     ormapi.EventWebhookComments,
+		Path:         "/auth/eventwebhook/create",
+	}, &ApiCommand{
+		Name:         "UpdateEventWebhook",
+		Use:          "update",
+		Short:        "Update an event webhook",
+		RequiredArgs: "name org",
+		OptionalArgs: "url secret names types regions tags failed disabled",
+		SpecialArgs:  &EventWebhookSpecialArgs,
+		ReqData:      &ormapi.EventWebhook{},
+		Comments:     ormapi.EventWebhookComments,
+		Path:         "/auth/eventwebhook/update",
+	}, &ApiCommand{
+		Name:         "DeleteEventWebhook",
+		Use:          "delete",
+		Short:        "Delete an event webhook and its delivery history",
+		RequiredArgs: "name org",
+		ReqData:      &ormapi.EventWebhook{},
+		Comments:     ormapi.EventWebhookComments,
+		Path:         "/auth/eventwebhook/delete",
+	}, &ApiCommand{
+		Name:         "ShowEventWebhook",
+		Use:          "show",
+		Short:        "Show event webhooks",
+		OptionalArgs: "name org url failed disabled",
+		ReqData:      &ormapi.EventWebhook{},
+		Comments:     ormapi.EventWebhookComments,
+		ReplyData:    &[]ormapi.EventWebhook{},
+		ShowFilter:   true,
+		Paged:        true,
+		Path:         "/auth/eventwebhook/show",
+	}, &ApiCommand{
+		Name:         "ShowEventWebhookDelivery",
+		Use:          "showdelivery",
+		Short:        "Show event webhook delivery history, failed deliveries have exhausted all retries",
+		OptionalArgs: "id webhook org eventname state",
+		ReqData:      &ormapi.EventWebhookDelivery{},
+		Comments:     ormapi.EventWebhookDeliveryComments,
+		ReplyData:    &[]ormapi.EventWebhookDelivery{},
+		ShowFilter:   true,
+		Paged:        true,
+		Path:         "/auth/eventwebhook/delivery/show",
+	}, &ApiCommand{
+		Name:         "RetryEventWebhookDelivery",
+		Use:          "retrydelivery",
+		Short:        "Retry failed event webhook deliveries",
+		RequiredArgs: "org",
+		OptionalArgs: "webhook id",
+		ReqData:      &ormapi.EventWebhookDelivery{},
+		Comments:     ormapi.EventWebhookDeliveryComments,
+		Path:         "/auth/eventwebhook/delivery/retry",
+	}}
+	AllApis.AddGroup(EventWebhookGroup, "Manage event webhook subscriptions", cmds)
+}
+
+var EventWebhookSpecialArgs = map[string]string{
+	"names":   "StringArray",
+	"types":   "StringArray",
+	"regions": "StringArray",
+	"tags":    "StringArray",
+}
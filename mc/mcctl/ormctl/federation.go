@@ -73,6 +73,7 @@ func init() {
 			ReplyData:    &[]ormapi.Federator{},
 			Path:         "/auth/federator/self/show",
 			ShowFilter:   true,
+			Paged:        true,
 		},
 		&ApiCommand{
 			Name:         "GenerateSelfFederatorAPIKey",
@@ -121,6 +122,7 @@ func init() {
 			ReplyData:    &[]ormapi.FederatorZone{},
 			Path:         "/auth/federator/self/zone/show",
 			ShowFilter:   true,
+			Paged:        true,
 		},
 		&ApiCommand{
 			Name:         "ShareSelfFederatorZone",
@@ -174,6 +176,7 @@ func init() {
 			ReplyData:    &[]ormapi.FederatedSelfZone{},
 			Path:         "/auth/federation/self/zone/show",
 			ShowFilter:   true,
+			Paged:        true,
 		},
 		&ApiCommand{
 			Name:         "ShowFederatedPartnerZone",
@@ -187,6 +190,7 @@ func init() {
 			ReplyData:    &[]ormapi.FederatedPartnerZone{},
 			Path:         "/auth/federation/partner/zone/show",
 			ShowFilter:   true,
+			Paged:        true,
 		},
 	}
 	AllApis.AddGroup(FederatorZoneGroup, "Federator Zone APIs", cmds)
@@ -261,6 +265,7 @@ func init() {
 			ReplyData:    &[]ormapi.Federation{},
 			Path:         "/auth/federation/show",
 			ShowFilter:   true,
+			Paged:        true,
 		},
 	}
 	AllApis.AddGroup(FederationGroup, "Federation APIs", cmds)
@@ -0,0 +1,36 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ormctl
+
+import (
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+)
+
+const GraphQLGroup = "GraphQL"
+
+func init() {
+	cmds := []*ApiCommand{&ApiCommand{
+		Name:         "GraphQL",
+		Use:          "query",
+		Short:        "Run a read-only GraphQL query over organizations, regional objects and metrics, specify variables via --datafile",
+		RequiredArgs: "query",
+		OptionalArgs: "operationname",
+		ReqData:      &ormapi.GraphQLQuery{},
+		ReplyData:    &ormapi.GraphQLResponse{},
+		Comments:     ormapi.GraphQLQueryComments,
+		Path:         "/auth/graphql",
+	}}
+	AllApis.AddGroup(GraphQLGroup, "Query MC and regional data with GraphQL", cmds)
+}
@@ -0,0 +1,61 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ormctl
+
+import (
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+)
+
+const IdentityProviderGroup = "IdentityProvider"
+
+func init() {
+	cmds := []*ApiCommand{&ApiCommand{
+		Name:         "CreateIdentityProvider",
+		Use:          "create",
+		Short:        "Create an OpenID Connect identity provider for single sign-on logins",
+		RequiredArgs: "name issuer clientid",
+		OptionalArgs: "clientsecret scopes usernameclaim groupsclaim createusers groupmappings:#.groupname groupmappings:#.org groupmappings:#.role",
+		ReqData:      &ormapi.IdentityProvider{},
+		Comments:     ormapi.IdentityProviderComments,
+		Path:         "/auth/idp/create",
+	}, &ApiCommand{
+		Name:         "UpdateIdentityProvider",
+		Use:          "update",
+		Short:        "Update an identity provider, group mappings if specified replace the existing group mappings",
+		RequiredArgs: "name",
+		OptionalArgs: "issuer clientid clientsecret scopes usernameclaim groupsclaim createusers groupmappings:#.groupname groupmappings:#.org groupmappings:#.role",
+		ReqData:      &ormapi.IdentityProvider{},
+		Comments:     ormapi.IdentityProviderComments,
+		Path:         "/auth/idp/update",
+	}, &ApiCommand{
+		Name:         "DeleteIdentityProvider",
+		Use:          "delete",
+		Short:        "Delete an identity provider",
+		RequiredArgs: "name",
+		ReqData:      &ormapi.IdentityProvider{},
+		Comments:     ormapi.IdentityProviderComments,
+		Path:         "/auth/idp/delete",
+	}, &ApiCommand{
+		Name:         "ShowIdentityProvider",
+		Use:          "show",
+		Short:        "Show identity providers",
+		OptionalArgs: "name issuer clientid",
+		ReqData:      &ormapi.IdentityProvider{},
+		Comments:     ormapi.IdentityProviderComments,
+		ReplyData:    &[]ormapi.IdentityProvider{},
+		Path:         "/auth/idp/show",
+	}}
+	AllApis.AddGroup(IdentityProviderGroup, "Manage single sign-on identity providers", cmds)
+}
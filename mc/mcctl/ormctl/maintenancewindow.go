@@ -0,0 +1,52 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ormctl
+
+import (
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+)
+
+const MaintenanceWindowGroup = "MaintenanceWindow"
+
+func init() {
+	cmds := []*ApiCommand{&ApiCommand{
+		Name:         "CreateMaintenanceWindow",
+		Use:          "create",
+		Short:        "Schedule a maintenance window for a cloudlet or cloudlet pool",
+		RequiredArgs: "name org region starttime endtime",
+		OptionalArgs: "cloudlet cloudletpool recurrence comment",
+		ReqData:      &ormapi.MaintenanceWindow{},
+		Comments:     ormapi.MaintenanceWindowComments,
+		Path:         "/auth/maintenancewindow/create",
+	}, &ApiCommand{
+		Name:         "DeleteMaintenanceWindow",
+		Use:          "delete",
+		Short:        "Delete a maintenance window, closing it if it is open",
+		RequiredArgs: "name org",
+		ReqData:      &ormapi.MaintenanceWindow{},
+		Comments:     ormapi.MaintenanceWindowComments,
+		Path:         "/auth/maintenancewindow/delete",
+	}, &ApiCommand{
+		Name:         "ShowMaintenanceWindow",
+		Use:          "show",
+		Short:        "Show maintenance windows",
+		OptionalArgs: "name org region cloudlet cloudletpool state",
+		ReqData:      &ormapi.MaintenanceWindow{},
+		Comments:     ormapi.MaintenanceWindowComments,
+		ReplyData:    &[]ormapi.MaintenanceWindow{},
+		Path:         "/auth/maintenancewindow/show",
+	}}
+	AllApis.AddGroup(MaintenanceWindowGroup, "Manage cloudlet maintenance windows", cmds)
+}
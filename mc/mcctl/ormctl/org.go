@@ -51,11 +51,12 @@ func init() {
 		Name:         "ShowOrg",
 		Use:          "show",
 		Short:        "Show organizations",
-		OptionalArgs: "name type address phone publicimages deleteinprogress edgeboxonly",
+		OptionalArgs: "name type address phone publicimages deleteinprogress edgeboxonly suspended",
 		Comments:     ormapi.OrganizationComments,
 		ReqData:      &ormapi.Organization{},
 		ReplyData:    &[]ormapi.Organization{},
 		ShowFilter:   true,
+		Paged:        true,
 		Path:         "/auth/org/show",
 	}}
 	AllApis.AddGroup(OrgGroup, "Manage organizations", cmds)
@@ -71,4 +72,26 @@ func init() {
 		IsUpdate:     true,
 	}
 	AllApis.AddCommand(cmd)
+
+	cmd = &ApiCommand{
+		Name:         "SuspendOrg",
+		Short:        "Admin-only suspend of an org, blocks changes to the org's controller resources",
+		RequiredArgs: "name",
+		OptionalArgs: "reason poweroffappinsts",
+		Comments:     ormapi.OrgSuspendComments,
+		ReqData:      &ormapi.OrgSuspend{},
+		Path:         "/auth/restricted/org/suspend",
+	}
+	AllApis.AddCommand(cmd)
+
+	cmd = &ApiCommand{
+		Name:         "ResumeOrg",
+		Short:        "Admin-only resume of a suspended org",
+		RequiredArgs: "name",
+		OptionalArgs: "poweronappinsts",
+		Comments:     ormapi.OrgSuspendComments,
+		ReqData:      &ormapi.OrgSuspend{},
+		Path:         "/auth/restricted/org/resume",
+	}
+	AllApis.AddCommand(cmd)
 }
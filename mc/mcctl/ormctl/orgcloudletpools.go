@@ -54,6 +54,7 @@ func init() {
 		ReqData:      &ormapi.OrgCloudletPool{},
 		ReplyData:    &[]ormapi.OrgCloudletPool{},
 		ShowFilter:   true,
+		Paged:        true,
 		Path:         "/auth/cloudletpoolaccessinvitation/show",
 	}}
 	AllApis.AddGroup(CloudletPoolInvitationGroup, "Manage CloudletPool invitations", cmds)
@@ -82,6 +83,7 @@ func init() {
 		ReqData:      &ormapi.OrgCloudletPool{},
 		ReplyData:    &[]ormapi.OrgCloudletPool{},
 		ShowFilter:   true,
+		Paged:        true,
 		Path:         "/auth/cloudletpoolaccessresponse/show",
 	}}
 	AllApis.AddGroup(CloudletPoolResponseGroup, "Manage CloudletPool responses to invitations", cmds)
@@ -95,6 +97,7 @@ func init() {
 		ReqData:      &ormapi.OrgCloudletPool{},
 		ReplyData:    &[]ormapi.OrgCloudletPool{},
 		ShowFilter:   true,
+		Paged:        true,
 		Path:         "/auth/cloudletpoolaccessgranted/show",
 	}, {
 		Name:         "ShowCloudletPoolAccessPending",
@@ -105,6 +108,7 @@ func init() {
 		ReqData:      &ormapi.OrgCloudletPool{},
 		ReplyData:    &[]ormapi.OrgCloudletPool{},
 		ShowFilter:   true,
+		Paged:        true,
 		Path:         "/auth/cloudletpoolaccesspending/show",
 	}}
 	AllApis.AddGroup(CloudletPoolAccessGroup, "View CloudletPool access", cmds)
@@ -0,0 +1,62 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ormctl
+
+import (
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+)
+
+const OrgQuotaGroup = "OrgQuota"
+
+func init() {
+	cmds := []*ApiCommand{&ApiCommand{
+		Name:         "CreateOrgQuota",
+		Use:          "create",
+		Short:        "Create a resource quota for a developer organization, for a region or blank region for all regions",
+		RequiredArgs: "org",
+		OptionalArgs: "region maxvcpus maxram maxgpus maxappinsts maxclusterinsts",
+		ReqData:      &ormapi.OrgQuota{},
+		Comments:     ormapi.OrgQuotaComments,
+		Path:         "/auth/org/quota/create",
+	}, &ApiCommand{
+		Name:         "UpdateOrgQuota",
+		Use:          "update",
+		Short:        "Update a resource quota, a limit of 0 removes the limit",
+		RequiredArgs: "org",
+		OptionalArgs: "region maxvcpus maxram maxgpus maxappinsts maxclusterinsts",
+		ReqData:      &ormapi.OrgQuota{},
+		Comments:     ormapi.OrgQuotaComments,
+		Path:         "/auth/org/quota/update",
+	}, &ApiCommand{
+		Name:         "DeleteOrgQuota",
+		Use:          "delete",
+		Short:        "Delete a resource quota",
+		RequiredArgs: "org",
+		OptionalArgs: "region",
+		ReqData:      &ormapi.OrgQuota{},
+		Comments:     ormapi.OrgQuotaComments,
+		Path:         "/auth/org/quota/delete",
+	}, &ApiCommand{
+		Name:         "ShowOrgQuota",
+		Use:          "show",
+		Short:        "Show resource quotas and current usage of organizations",
+		OptionalArgs: "org region",
+		ReqData:      &ormapi.OrgQuota{},
+		Comments:     ormapi.OrgQuotaComments,
+		ReplyData:    &[]ormapi.OrgQuotaUsage{},
+		Path:         "/auth/org/quota/show",
+	}}
+	AllApis.AddGroup(OrgQuotaGroup, "Manage organization resource quotas", cmds)
+}
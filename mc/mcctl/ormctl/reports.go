@@ -28,7 +28,7 @@ func init() {
 		Use:          "create",
 		Short:        "Create new reporter",
 		RequiredArgs: "name org",
-		OptionalArgs: "email schedule startscheduledate timezone",
+		OptionalArgs: "email schedule startscheduledate timezone format",
 		ReqData:      &ormapi.Reporter{},
 		Comments:     ormapi.ReporterComments,
 		Path:         "/auth/reporter/create",
@@ -37,7 +37,7 @@ func init() {
 		Use:          "update",
 		Short:        "Update reporter",
 		RequiredArgs: "name org",
-		OptionalArgs: "email schedule startscheduledate timezone",
+		OptionalArgs: "email schedule startscheduledate timezone format",
 		ReqData:      &ormapi.Reporter{},
 		Comments:     ormapi.ReporterComments,
 		Path:         "/auth/reporter/update",
@@ -66,7 +66,7 @@ func init() {
 		Use:          "generate",
 		Short:        "Generate new report for an org of all regions",
 		RequiredArgs: "org starttime endtime",
-		OptionalArgs: "timezone",
+		OptionalArgs: "timezone format",
 		ReqData:      &ormapi.GenerateReport{},
 		Comments:     ormapi.GenerateReportComments,
 		Path:         "/auth/report/generate",
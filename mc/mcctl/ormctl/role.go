@@ -32,6 +32,7 @@ func init() {
 		Use:          "add",
 		Short:        "Add a role for the organization to the user",
 		RequiredArgs: "org username role",
+		OptionalArgs: "expiresat",
 		Comments:     ormapi.RoleComments,
 		ReqData:      &ormapi.Role{},
 		Path:         "/auth/role/adduser",
@@ -73,6 +74,78 @@ func init() {
 		ReplyData:    &[]ormapi.RolePerm{},
 		ShowFilter:   true,
 		Path:         "/auth/role/perms/show",
+	}, &ApiCommand{
+		Name:         "CreateCustomRole",
+		Use:          "createcustom",
+		Short:        "Create a custom role from a subset of the built-in role permissions",
+		RequiredArgs: "name orgtype permissions:#.resource permissions:#.action",
+		OptionalArgs: "description",
+		Comments:     ormapi.CustomRoleComments,
+		ReqData:      &ormapi.CustomRole{},
+		Path:         "/auth/role/custom/create",
+	}, &ApiCommand{
+		Name:         "UpdateCustomRole",
+		Use:          "updatecustom",
+		Short:        "Update a custom role, specified permissions replace the existing permissions",
+		RequiredArgs: "name",
+		OptionalArgs: "description permissions:#.resource permissions:#.action",
+		Comments:     ormapi.CustomRoleComments,
+		ReqData:      &ormapi.CustomRole{},
+		Path:         "/auth/role/custom/update",
+	}, &ApiCommand{
+		Name:         "DeleteCustomRole",
+		Use:          "deletecustom",
+		Short:        "Delete a custom role",
+		RequiredArgs: "name",
+		Comments:     ormapi.CustomRoleComments,
+		ReqData:      &ormapi.CustomRole{},
+		Path:         "/auth/role/custom/delete",
+	}, &ApiCommand{
+		Name:         "ShowCustomRole",
+		Use:          "showcustom",
+		Short:        "Show custom roles and their permissions",
+		OptionalArgs: "name orgtype",
+		Comments:     ormapi.CustomRoleComments,
+		ReqData:      &ormapi.CustomRole{},
+		ReplyData:    &[]ormapi.CustomRole{},
+		Path:         "/auth/role/custom/show",
+	}, &ApiCommand{
+		Name:         "CreateOrgInvitation",
+		Use:          "invite",
+		Short:        "Invite a user by email address to join the organization with the role",
+		RequiredArgs: "org email role",
+		OptionalArgs: "expiresat",
+		Comments:     ormapi.OrgInvitationComments,
+		ReqData:      &ormapi.OrgInvitation{},
+		ReplyData:    &ormapi.OrgInvitationResponse{},
+		Path:         "/auth/role/invite/create",
+	}, &ApiCommand{
+		Name:         "ShowOrgInvitation",
+		Use:          "showinvite",
+		Short:        "Show pending invitations of organizations managed by the user",
+		OptionalArgs: "id org email role invitedby",
+		Comments:     ormapi.OrgInvitationComments,
+		ReqData:      &ormapi.OrgInvitation{},
+		ReplyData:    &[]ormapi.OrgInvitation{},
+		ShowFilter:   true,
+		Paged:        true,
+		Path:         "/auth/role/invite/show",
+	}, &ApiCommand{
+		Name:         "RevokeOrgInvitation",
+		Use:          "revokeinvite",
+		Short:        "Revoke a pending invitation",
+		RequiredArgs: "id",
+		Comments:     ormapi.OrgInvitationComments,
+		ReqData:      &ormapi.OrgInvitation{},
+		Path:         "/auth/role/invite/revoke",
+	}, &ApiCommand{
+		Name:         "AcceptOrgInvitation",
+		Use:          "acceptinvite",
+		Short:        "Accept an invitation to join an organization",
+		RequiredArgs: "token",
+		Comments:     ormapi.TokenComments,
+		ReqData:      &ormapi.Token{},
+		Path:         "/auth/role/invite/accept",
 	}}
 	AllApis.AddGroup(RoleGroup, "Manage user roles and permissions", cmds)
 }
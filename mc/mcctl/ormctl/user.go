@@ -30,7 +30,7 @@ func init() {
 		Use:            "create",
 		Short:          "Create a new user",
 		RequiredArgs:   "name email",
-		OptionalArgs:   "nickname familyname givenname enabletotp metadata",
+		OptionalArgs:   "nickname familyname givenname enabletotp metadata invitetoken",
 		AliasArgs:      strings.Join(CreateUserAliasArgs, " "),
 		Comments:       aliasedComments(ormapi.CreateUserComments, CreateUserAliasArgs),
 		PasswordArg:    "user.passhash",
@@ -66,6 +66,7 @@ func init() {
 		Comments:     aliasedComments(ormapi.ShowUserComments, EmbeddedUserAliasArgs),
 		ReplyData:    &[]ormapi.User{},
 		ShowFilter:   true,
+		Paged:        true,
 		Path:         "/auth/user/show",
 	}, &ApiCommand{
 		Name:      "CurrentUser",
@@ -118,7 +119,7 @@ func init() {
 		Name:         "CreateUserApiKey",
 		Short:        "Create an API key for reduced access, typically for automation",
 		RequiredArgs: "org description",
-		OptionalArgs: "permissions:#.action permissions:#.resource",
+		OptionalArgs: "permissions:#.action permissions:#.resource expiresat",
 		AliasArgs:    strings.Join(CreateUserApiKeyAliasArgs, " "),
 		Comments:     aliasedComments(ormapi.CreateUserApiKeyComments, CreateUserApiKeyAliasArgs),
 		ReqData:      &ormapi.CreateUserApiKey{},
@@ -141,6 +142,37 @@ func init() {
 		Comments:     aliasedComments(ormapi.CreateUserApiKeyComments, CreateUserApiKeyAliasArgs),
 		ReplyData:    &[]ormapi.CreateUserApiKey{},
 		Path:         "/auth/user/show/apikey",
+	}, &ApiCommand{
+		Name:         "RotateUserApiKey",
+		Short:        "Issue a new secret for an API key, the previous secret remains valid for the rotate grace period",
+		RequiredArgs: "apikeyid",
+		OptionalArgs: "expiresat",
+		AliasArgs:    strings.Join(CreateUserApiKeyAliasArgs, " "),
+		Comments:     aliasedComments(ormapi.CreateUserApiKeyComments, CreateUserApiKeyAliasArgs),
+		ReqData:      &ormapi.CreateUserApiKey{},
+		ReplyData:    &ormapi.CreateUserApiKey{},
+		Path:         "/auth/user/rotate/apikey",
+	}, &ApiCommand{
+		Name:         "ShowStaleUserApiKey",
+		Short:        "Admin report of API keys that have expired or have not been used recently",
+		OptionalArgs: "unusedduration username",
+		Comments:     ormapi.StaleApiKeyFilterComments,
+		ReqData:      &ormapi.StaleApiKeyFilter{},
+		ReplyData:    &[]ormapi.CreateUserApiKey{},
+		Path:         "/auth/user/show/apikey/stale",
+	}, &ApiCommand{
+		Name:      "ShowNotificationPrefs",
+		Short:     "Show notification preferences for the current user",
+		ReplyData: &ormapi.NotificationPreferences{},
+		Path:      "/auth/user/show/notificationprefs",
+	}, &ApiCommand{
+		Name:         "UpdateNotificationPrefs",
+		Short:        "Update notification preferences for the current user",
+		OptionalArgs: "categories delivery quiethoursstart quiethoursend digesthour timezone",
+		SpecialArgs:  &NotificationPrefsSpecialArgs,
+		Comments:     ormapi.NotificationPreferencesComments,
+		ReqData:      &ormapi.NotificationPreferences{},
+		Path:         "/auth/user/update/notificationprefs",
 	}}
 	AllApis.AddGroup(UserGroup, "Manage your account or other users", cmds)
 
@@ -159,7 +191,7 @@ func init() {
 	cmd = &ApiCommand{
 		Name:         "Login",
 		Short:        "Login using account credentials",
-		OptionalArgs: "name password totp apikeyid apikey",
+		OptionalArgs: "name password totp apikeyid apikey invitetoken",
 		AliasArgs:    "name=username",
 		Comments:     LoginComments,
 		ReqData:      &ormapi.UserLogin{},
@@ -170,11 +202,12 @@ func init() {
 }
 
 var LoginComments = map[string]string{
-	"name":     "User's name",
-	"password": "User's password",
-	"totp":     "Temporary one-time password, if 2-factor auth is enabled",
-	"apikeyid": "API key ID if authenticating via API key instead of user name",
-	"apikey":   "API key value if authenticating via API key instead of user name",
+	"name":        "User's name",
+	"password":    "User's password",
+	"totp":        "Temporary one-time password, if 2-factor auth is enabled",
+	"apikeyid":    "API key ID if authenticating via API key instead of user name",
+	"apikey":      "API key value if authenticating via API key instead of user name",
+	"invitetoken": "Organization invitation token to accept on login",
 }
 
 var EmbeddedUserAliasArgs = []string{
@@ -197,6 +230,7 @@ var CreateUserApiKeyAliasArgs = []string{
 	"org=userapikey.org",
 	"description=userapikey.description",
 	"apikeyid=userapikey.id",
+	"expiresat=userapikey.expiresat",
 }
 
 // convenience func - returns token, admin, error
@@ -223,3 +257,7 @@ func ParseLoginResp(resp map[string]interface{}, status int, err error) (string,
 	}
 	return token, admin, nil
 }
+
+var NotificationPrefsSpecialArgs = map[string]string{
+	"categories": "StringArray",
+}
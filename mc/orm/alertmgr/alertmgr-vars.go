@@ -48,11 +48,14 @@ receivers:
 	AlertReceiverTypeEmail     = "email"
 	AlertReceiverTypeSlack     = "slack"
 	AlertReceiverTypePagerDuty = "pagerduty"
+	AlertReceiverTypeWebhook   = "webhook"
+	AlertReceiverTypeTeams     = "teams"
 	AlertMgrDisplayHidden      = "<hidden>"
 
 	AlertApi               = "/api/v2/alerts"
 	ReceiverApi            = "/api/v2/receivers"
 	SilenceApi             = "/api/v2/silences"
+	SilenceIdApi           = "/api/v2/silence"
 	ReloadConfigApi        = "/-/reload"
 	mobiledgeXReceiversApi = "/api/v3/receivers"
 	mobiledgeXReceiverApi  = "/api/v3/receiver"
@@ -75,6 +78,10 @@ var (
 
 	alertmanagerConfigSlackIcon = "https://www.mobiledgex.com/img/logo.svg"
 
+	alertmanagerConfigTeamsTitle   = `{{ template "status.title" . }}`
+	alertmanagerConfigTeamsSummary = `{{ template "common.title" . }}`
+	alertmanagerConfigTeamsText    = `{{ template "slack.text" . }}`
+
 	alertmanagerConfigPagerDutyClient      = "MobiledgeX Monitoring"
 	alertmanagerConfigPagerDutyDescription = `{{ template "common.title" . }}`
 	alertmanagerConfigPagerDutyDetails     = map[string]string{
@@ -83,4 +90,13 @@ var (
 	}
 
 	PagerDutyIntegrationKeyLen = 32
+
+	// Headers set by alertmanager itself, or through the webhook auth settings
+	webhookReservedHeaders = []string{
+		"Authorization",
+		"Content-Type",
+		"Content-Length",
+		"Host",
+		"User-Agent",
+	}
 )
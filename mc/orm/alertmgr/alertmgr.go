@@ -23,17 +23,20 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
 	"sync"
 	"text/template"
 	"time"
 
 	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
 	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
 	"github.com/mobiledgex/edge-cloud/cloudcommon"
 	dme "github.com/mobiledgex/edge-cloud/d-match-engine/dme-proto"
 	"github.com/mobiledgex/edge-cloud/edgeproto"
 	"github.com/mobiledgex/edge-cloud/log"
+	"github.com/mobiledgex/edge-cloud/vault"
 	"github.com/mobiledgex/yaml/v2"
 
 	//"github.com/prometheus/alertmanager/api/v2/models"
@@ -43,6 +46,10 @@ import (
 	//	alertmanager_config "github.com/prometheus/alertmanager/config"
 	// TODO - below is to replace the above for right now - once we update go and modules we can use prometheus directly
 	alertmanager_config "github.com/mobiledgex/edge-cloud-infra/mc/orm/alertmgr/prometheus_structs/config"
+
+	//	commoncfg "github.com/prometheus/common/config"
+	// TODO - below is to replace the above for right now - once we update go and modules we can use prometheus directly
+	commoncfg "github.com/mobiledgex/edge-cloud-infra/mc/orm/alertmgr/prometheus_structs/commoncfg"
 )
 
 // Default alertmanager configuration
@@ -58,6 +65,7 @@ type AlertMgrServer struct {
 	AlertRefreshInterval  time.Duration
 	AlertCache            *edgeproto.AlertCache
 	TlsConfig             *tls.Config
+	VaultConfig           *vault.Config
 	waitGrp               sync.WaitGroup
 	stop                  chan struct{}
 }
@@ -75,7 +83,7 @@ func getAlertRefreshRate(resolveTimeout time.Duration) time.Duration {
 	return resolveTimeout / 3
 }
 
-func NewAlertMgrServer(alertMgrAddr string, tlsConfig *tls.Config,
+func NewAlertMgrServer(alertMgrAddr string, tlsConfig *tls.Config, vaultConfig *vault.Config,
 	alertCache *edgeproto.AlertCache, resolveTimeout time.Duration) (*AlertMgrServer, error) {
 	var err error
 	server := AlertMgrServer{
@@ -83,6 +91,7 @@ func NewAlertMgrServer(alertMgrAddr string, tlsConfig *tls.Config,
 		AlertCache:            alertCache,
 		AlertResolutionTimout: resolveTimeout,
 		TlsConfig:             tlsConfig,
+		VaultConfig:           vaultConfig,
 	}
 	span := log.StartSpan(log.DebugLevelApi|log.DebugLevelInfo, "AlertMgrServer")
 	defer span.Finish()
@@ -251,57 +260,65 @@ func getAlertmgrReceiverName(receiver *ormapi.AlertReceiver) string {
 }
 
 func getRouteMatchLabelsFromAlertReceiver(in *ormapi.AlertReceiver) map[string]string {
+	labels := getAlertKeyLabels(in.Region, &in.Cloudlet, &in.AppInst)
+	if _, found := labels[cloudcommon.AlertScopeTypeTag]; !found {
+		// Default to Platform scope when no org (from cloudlet/appkey/clusterinstkey) is specified
+		// Only admin can see platform scope alerts.
+		labels[cloudcommon.AlertScopeTypeTag] = cloudcommon.AlertScopePlatform
+	}
+	return labels
+}
+
+// Get a set of alert labels for the cloudlet, or the appInst/cluster keys.
+// Scope label is only set if one of the organizations is specified
+func getAlertKeyLabels(region string, cloudlet *edgeproto.CloudletKey, appInst *edgeproto.AppInstKey) map[string]string {
 	labels := map[string]string{}
 	// Add region label if one is specified
-	if in.Region != "" {
-		labels["region"] = in.Region
+	if region != "" {
+		labels["region"] = region
 	}
-	if in.Cloudlet.Organization != "" {
+	if cloudlet.Organization != "" {
 		// add labels for the cloudlet
 		labels[cloudcommon.AlertScopeTypeTag] = cloudcommon.AlertScopeCloudlet
-		labels[edgeproto.CloudletKeyTagOrganization] = in.Cloudlet.Organization
-		if in.Cloudlet.Name != "" {
-			labels[edgeproto.CloudletKeyTagName] = in.Cloudlet.Name
+		labels[edgeproto.CloudletKeyTagOrganization] = cloudlet.Organization
+		if cloudlet.Name != "" {
+			labels[edgeproto.CloudletKeyTagName] = cloudlet.Name
 		}
-	} else if in.AppInst.AppKey.Organization != "" {
+	} else if appInst.AppKey.Organization != "" {
 		// add labels for app instance
 		labels[cloudcommon.AlertScopeTypeTag] = cloudcommon.AlertScopeApp
-		labels[edgeproto.AppKeyTagOrganization] = in.AppInst.AppKey.Organization
-		if in.AppInst.AppKey.Name != "" {
-			labels[edgeproto.AppKeyTagName] = in.AppInst.AppKey.Name
+		labels[edgeproto.AppKeyTagOrganization] = appInst.AppKey.Organization
+		if appInst.AppKey.Name != "" {
+			labels[edgeproto.AppKeyTagName] = appInst.AppKey.Name
 		}
-		if in.AppInst.AppKey.Version != "" {
-			labels[edgeproto.AppKeyTagVersion] = in.AppInst.AppKey.Version
+		if appInst.AppKey.Version != "" {
+			labels[edgeproto.AppKeyTagVersion] = appInst.AppKey.Version
 		}
-		if in.AppInst.ClusterInstKey.CloudletKey.Name != "" {
-			labels[edgeproto.CloudletKeyTagName] = in.AppInst.ClusterInstKey.CloudletKey.Name
+		if appInst.ClusterInstKey.CloudletKey.Name != "" {
+			labels[edgeproto.CloudletKeyTagName] = appInst.ClusterInstKey.CloudletKey.Name
 		}
-		if in.AppInst.ClusterInstKey.CloudletKey.Organization != "" {
-			labels[edgeproto.CloudletKeyTagOrganization] = in.AppInst.ClusterInstKey.CloudletKey.Organization
+		if appInst.ClusterInstKey.CloudletKey.Organization != "" {
+			labels[edgeproto.CloudletKeyTagOrganization] = appInst.ClusterInstKey.CloudletKey.Organization
 		}
-		if in.AppInst.ClusterInstKey.ClusterKey.Name != "" {
-			labels[edgeproto.ClusterKeyTagName] = in.AppInst.ClusterInstKey.ClusterKey.Name
+		if appInst.ClusterInstKey.ClusterKey.Name != "" {
+			labels[edgeproto.ClusterKeyTagName] = appInst.ClusterInstKey.ClusterKey.Name
 		}
-		if in.AppInst.ClusterInstKey.Organization != "" {
-			labels[edgeproto.ClusterInstKeyTagOrganization] = in.AppInst.ClusterInstKey.Organization
+		if appInst.ClusterInstKey.Organization != "" {
+			labels[edgeproto.ClusterInstKeyTagOrganization] = appInst.ClusterInstKey.Organization
 		}
-	} else if in.AppInst.ClusterInstKey.Organization != "" {
+	} else if appInst.ClusterInstKey.Organization != "" {
 		// add labels for cluster instance
 		labels[cloudcommon.AlertScopeTypeTag] = cloudcommon.AlertScopeApp
-		labels[edgeproto.ClusterInstKeyTagOrganization] = in.AppInst.ClusterInstKey.Organization
-		if in.AppInst.ClusterInstKey.CloudletKey.Name != "" {
-			labels[edgeproto.CloudletKeyTagName] = in.AppInst.ClusterInstKey.CloudletKey.Name
+		labels[edgeproto.ClusterInstKeyTagOrganization] = appInst.ClusterInstKey.Organization
+		if appInst.ClusterInstKey.CloudletKey.Name != "" {
+			labels[edgeproto.CloudletKeyTagName] = appInst.ClusterInstKey.CloudletKey.Name
 		}
-		if in.AppInst.ClusterInstKey.CloudletKey.Organization != "" {
-			labels[edgeproto.CloudletKeyTagOrganization] = in.AppInst.ClusterInstKey.CloudletKey.Organization
+		if appInst.ClusterInstKey.CloudletKey.Organization != "" {
+			labels[edgeproto.CloudletKeyTagOrganization] = appInst.ClusterInstKey.CloudletKey.Organization
 		}
-		if in.AppInst.ClusterInstKey.ClusterKey.Name != "" {
-			labels[edgeproto.ClusterKeyTagName] = in.AppInst.ClusterInstKey.ClusterKey.Name
+		if appInst.ClusterInstKey.ClusterKey.Name != "" {
+			labels[edgeproto.ClusterKeyTagName] = appInst.ClusterInstKey.ClusterKey.Name
 		}
-	} else {
-		// Default to Platform scope when no org (from cloudlet/appkey/clusterinstkey) is specified
-		// Only admin can see platform scope alerts.
-		labels[cloudcommon.AlertScopeTypeTag] = cloudcommon.AlertScopePlatform
 	}
 	return labels
 }
@@ -341,6 +358,90 @@ func getAlertSeverityMatchRE(alertSeverity string) (alertmanager_config.MatchReg
 	return severityMatchRE, nil
 }
 
+// Webhook receiver credentials kept in vault
+type WebhookCreds struct {
+	User        string `json:"user,omitempty"`
+	Password    string `json:"password,omitempty"`
+	BearerToken string `json:"bearertoken,omitempty"`
+}
+
+func hasWebhookCreds(receiver *ormapi.AlertReceiver) bool {
+	return receiver.WebhookUser != "" || receiver.WebhookPassword != "" || receiver.WebhookBearerToken != ""
+}
+
+func getWebhookCredsVaultPath(receiver *ormapi.AlertReceiver) string {
+	return "/secret/data/accounts/alertreceivers/" + receiver.User + "/" + receiver.Name + "-" + receiver.Severity
+}
+
+// Store the webhook receiver credentials in vault, if any are specified
+func (s *AlertMgrServer) PutWebhookCreds(ctx context.Context, receiver *ormapi.AlertReceiver) error {
+	if !hasWebhookCreds(receiver) {
+		return nil
+	}
+	creds := WebhookCreds{
+		User:        receiver.WebhookUser,
+		Password:    receiver.WebhookPassword,
+		BearerToken: receiver.WebhookBearerToken,
+	}
+	log.SpanLog(ctx, log.DebugLevelApi, "Storing alert receiver webhook credentials in vault", "receiver", receiver.Name)
+	return vault.PutData(s.VaultConfig, getWebhookCredsVaultPath(receiver), &creds)
+}
+
+func (s *AlertMgrServer) getWebhookCreds(ctx context.Context, receiver *ormapi.AlertReceiver) (*WebhookCreds, error) {
+	creds := WebhookCreds{}
+	if !hasWebhookCreds(receiver) {
+		return &creds, nil
+	}
+	err := vault.GetData(s.VaultConfig, getWebhookCredsVaultPath(receiver), 0, &creds)
+	if err != nil {
+		log.SpanLog(ctx, log.DebugLevelInfo, "Failed to get alert receiver webhook credentials from vault", "receiver", receiver.Name, "err", err)
+		return nil, err
+	}
+	return &creds, nil
+}
+
+func (s *AlertMgrServer) DeleteWebhookCreds(ctx context.Context, receiver *ormapi.AlertReceiver) {
+	log.SpanLog(ctx, log.DebugLevelApi, "Deleting alert receiver webhook credentials from vault", "receiver", receiver.Name)
+	if err := vault.DeleteData(s.VaultConfig, getWebhookCredsVaultPath(receiver)); err != nil {
+		log.SpanLog(ctx, log.DebugLevelInfo, "Failed to delete alert receiver webhook credentials from vault", "receiver", receiver.Name, "err", err)
+	}
+}
+
+// helper function to build http client config for the webhook auth and custom headers.
+// The credentials are read from vault, Alertmanager gets them in its config file,
+// and they are hidden when receivers are shown.
+func getWebhookHTTPConfig(receiver *ormapi.AlertReceiver, creds *WebhookCreds) (*commoncfg.HTTPClientConfig, error) {
+	if creds.User == "" && creds.Password == "" &&
+		creds.BearerToken == "" && len(receiver.WebhookHeaders) == 0 {
+		return nil, nil
+	}
+	httpCfg := commoncfg.HTTPClientConfig{}
+	if creds.User != "" || creds.Password != "" {
+		httpCfg.BasicAuth = &commoncfg.BasicAuth{
+			Username: creds.User,
+			Password: commoncfg.Secret(creds.Password),
+		}
+	}
+	httpCfg.BearerToken = commoncfg.Secret(creds.BearerToken)
+	if err := httpCfg.Validate(); err != nil {
+		return nil, fmt.Errorf("Invalid webhook auth - %s", err.Error())
+	}
+	for name, value := range receiver.WebhookHeaders {
+		for _, reserved := range webhookReservedHeaders {
+			if http.CanonicalHeaderKey(name) == reserved {
+				return nil, fmt.Errorf("Webhook header %s cannot be customized", reserved)
+			}
+		}
+		if httpCfg.HTTPHeaders == nil {
+			httpCfg.HTTPHeaders = make(map[string]commoncfg.Header)
+		}
+		httpCfg.HTTPHeaders[name] = commoncfg.Header{
+			Values: []string{value},
+		}
+	}
+	return &httpCfg, nil
+}
+
 // Receiver includes a route and a receiver which will receive the alert
 // we create a route on the org tags for a given appInstance
 func (s *AlertMgrServer) CreateReceiver(ctx context.Context, receiver *ormapi.AlertReceiver) error {
@@ -422,6 +523,54 @@ func (s *AlertMgrServer) CreateReceiver(ctx context.Context, receiver *ormapi.Al
 			Name:             receiverName,
 			PagerdutyConfigs: []*alertmanager_config.PagerdutyConfig{&pagerDutyCfg},
 		}
+	case AlertReceiverTypeWebhook:
+		webhookUrl, err := url.Parse(receiver.WebhookUrl)
+		if err != nil || !strings.HasPrefix(webhookUrl.Scheme, "http") {
+			log.SpanLog(ctx, log.DebugLevelInfo, "Unable to parse webhook URL",
+				"url", receiver.WebhookUrl)
+			return fmt.Errorf("Invalid webhook URL")
+		}
+		creds, err := s.getWebhookCreds(ctx, receiver)
+		if err != nil {
+			return fmt.Errorf("Unable to get webhook credentials - %s", err.Error())
+		}
+		httpCfg, err := getWebhookHTTPConfig(receiver, creds)
+		if err != nil {
+			return err
+		}
+		webhookCfg := alertmanager_config.WebhookConfig{
+			NotifierConfig: notifierCfg,
+			HTTPConfig:     httpCfg,
+			URL: &alertmanager_config.URL{
+				URL: webhookUrl,
+			},
+		}
+		rec = alertmanager_config.Receiver{
+			// to make the name unique - construct it with all the fields and username
+			Name:           receiverName,
+			WebhookConfigs: []*alertmanager_config.WebhookConfig{&webhookCfg},
+		}
+	case AlertReceiverTypeTeams:
+		teamsUrl, err := url.Parse(receiver.TeamsWebhook)
+		if err != nil || !strings.HasPrefix(teamsUrl.Scheme, "http") {
+			log.SpanLog(ctx, log.DebugLevelInfo, "Unable to parse teams URL",
+				"url", receiver.TeamsWebhook)
+			return fmt.Errorf("Invalid Teams webhook URL")
+		}
+		teamsCfg := alertmanager_config.MSTeamsConfig{
+			NotifierConfig: notifierCfg,
+			WebhookURL: &alertmanager_config.URL{
+				URL: teamsUrl,
+			},
+			Title:   alertmanagerConfigTeamsTitle,
+			Summary: alertmanagerConfigTeamsSummary,
+			Text:    alertmanagerConfigTeamsText,
+		}
+		rec = alertmanager_config.Receiver{
+			// to make the name unique - construct it with all the fields and username
+			Name:           receiverName,
+			MSTeamsConfigs: []*alertmanager_config.MSTeamsConfig{&teamsCfg},
+		}
 	default:
 		log.SpanLog(ctx, log.DebugLevelInfo, "Unsupported receiver type", "type", receiver.Type,
 			"receiver", receiver)
@@ -563,6 +712,25 @@ func (s *AlertMgrServer) ShowReceivers(ctx context.Context, filter *ormapi.Alert
 				receiver.PagerDutyApiVersion = "v2"
 				receiver.PagerDutyIntegrationKey = AlertMgrDisplayHidden
 			}
+		case AlertReceiverTypeWebhook:
+			receiver.WebhookUrl = AlertMgrDisplayHidden
+			if httpCfg := rec.Receiver.WebhookConfigs[0].HTTPConfig; httpCfg != nil {
+				if httpCfg.BasicAuth != nil {
+					receiver.WebhookUser = httpCfg.BasicAuth.Username
+					receiver.WebhookPassword = AlertMgrDisplayHidden
+				}
+				if httpCfg.BearerToken != "" {
+					receiver.WebhookBearerToken = AlertMgrDisplayHidden
+				}
+				for name := range httpCfg.HTTPHeaders {
+					if receiver.WebhookHeaders == nil {
+						receiver.WebhookHeaders = make(map[string]string)
+					}
+					receiver.WebhookHeaders[name] = AlertMgrDisplayHidden
+				}
+			}
+		case AlertReceiverTypeTeams:
+			receiver.TeamsWebhook = AlertMgrDisplayHidden
 		default:
 			log.SpanLog(ctx, log.DebugLevelApi, "Unknown receiver type", "type", receiver.Type)
 		}
@@ -600,6 +768,217 @@ func (s *AlertMgrServer) ShowReceivers(ctx context.Context, filter *ormapi.Alert
 	return alertReceivers, nil
 }
 
+// Convert silence alert labels into alertmanager matchers
+func getSilenceMatchers(silence *ormapi.AlertSilence) models.Matchers {
+	labels := getAlertKeyLabels(silence.Region, &silence.Cloudlet, &silence.AppInst)
+	if silence.AlertName != "" {
+		labels["alertname"] = silence.AlertName
+	}
+	if silence.Severity != "" {
+		labels[cloudcommon.AlertSeverityLabel] = silence.Severity
+	}
+	// sort label names so the matchers are in a consistent order
+	names := []string{}
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	matchers := models.Matchers{}
+	for _, name := range names {
+		matchers = append(matchers, &models.Matcher{
+			Name:    swag.String(name),
+			Value:   swag.String(labels[name]),
+			IsRegex: swag.Bool(false),
+		})
+	}
+	return matchers
+}
+
+func getAlertSilenceFromGettableSilence(in *models.GettableSilence) *ormapi.AlertSilence {
+	silence := ormapi.AlertSilence{}
+	if in.ID != nil {
+		silence.Id = *in.ID
+	}
+	if in.Status != nil && in.Status.State != nil {
+		silence.State = *in.Status.State
+	}
+	if in.Comment != nil {
+		silence.Comment = *in.Comment
+	}
+	if in.CreatedBy != nil {
+		silence.CreatedBy = *in.CreatedBy
+	}
+	if in.StartsAt != nil {
+		silence.StartsAt = time.Time(*in.StartsAt)
+	}
+	if in.EndsAt != nil {
+		silence.EndsAt = time.Time(*in.EndsAt)
+	}
+	labels := map[string]string{}
+	for _, matcher := range in.Matchers {
+		if matcher == nil || matcher.Name == nil || matcher.Value == nil {
+			continue
+		}
+		labels[*matcher.Name] = *matcher.Value
+	}
+	silence.Region = labels["region"]
+	silence.AlertName = labels["alertname"]
+	silence.Severity = labels[cloudcommon.AlertSeverityLabel]
+	// Based on the scope it's either cloudlet, or appInst
+	if labels[cloudcommon.AlertScopeTypeTag] == cloudcommon.AlertScopeCloudlet {
+		silence.Cloudlet.Organization = labels[edgeproto.CloudletKeyTagOrganization]
+		silence.Cloudlet.Name = labels[edgeproto.CloudletKeyTagName]
+	} else {
+		silence.AppInst.AppKey.Organization = labels[edgeproto.AppKeyTagOrganization]
+		silence.AppInst.AppKey.Name = labels[edgeproto.AppKeyTagName]
+		silence.AppInst.AppKey.Version = labels[edgeproto.AppKeyTagVersion]
+		silence.AppInst.ClusterInstKey.ClusterKey.Name = labels[edgeproto.ClusterKeyTagName]
+		silence.AppInst.ClusterInstKey.Organization = labels[edgeproto.ClusterInstKeyTagOrganization]
+		silence.AppInst.ClusterInstKey.CloudletKey.Name = labels[edgeproto.CloudletKeyTagName]
+		silence.AppInst.ClusterInstKey.CloudletKey.Organization = labels[edgeproto.CloudletKeyTagOrganization]
+	}
+	return &silence
+}
+
+func alertSilenceMatchesFilter(silence *ormapi.AlertSilence, filter *ormapi.AlertSilence) bool {
+	if filter != nil {
+		if filter.Id != "" && filter.Id != silence.Id ||
+			filter.Region != "" && filter.Region != silence.Region ||
+			filter.AlertName != "" && filter.AlertName != silence.AlertName ||
+			filter.Severity != "" && filter.Severity != silence.Severity ||
+			filter.CreatedBy != "" && filter.CreatedBy != silence.CreatedBy ||
+			filter.State != "" && filter.State != silence.State ||
+			!silence.Cloudlet.Matches(&filter.Cloudlet, edgeproto.MatchFilter()) ||
+			!silence.AppInst.Matches(&filter.AppInst, edgeproto.MatchFilter()) {
+			return false
+		}
+	}
+	return true
+}
+
+// Create a new silence in alertmanager and return the ID of the silence
+func (s *AlertMgrServer) CreateSilence(ctx context.Context, silence *ormapi.AlertSilence) (string, error) {
+	matchers := getSilenceMatchers(silence)
+	if len(matchers) == 0 {
+		return "", fmt.Errorf("Silence must match at least one alert label")
+	}
+	return s.postSilence(ctx, silence, matchers)
+}
+
+// Create a silence for all the alerts on a cloudlet. Unlike a cloudlet-scoped
+// silence created with CreateSilence, this also silences the alerts of the
+// AppInsts deployed on the cloudlet.
+func (s *AlertMgrServer) CreateCloudletSilence(ctx context.Context, silence *ormapi.AlertSilence) (string, error) {
+	if silence.Cloudlet.Organization == "" || silence.Cloudlet.Name == "" {
+		return "", fmt.Errorf("Cloudlet must be specified")
+	}
+	labels := map[string]string{
+		edgeproto.CloudletKeyTagOrganization: silence.Cloudlet.Organization,
+		edgeproto.CloudletKeyTagName:         silence.Cloudlet.Name,
+	}
+	if silence.Region != "" {
+		labels["region"] = silence.Region
+	}
+	names := []string{}
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	matchers := models.Matchers{}
+	for _, name := range names {
+		matchers = append(matchers, &models.Matcher{
+			Name:    swag.String(name),
+			Value:   swag.String(labels[name]),
+			IsRegex: swag.Bool(false),
+		})
+	}
+	return s.postSilence(ctx, silence, matchers)
+}
+
+func (s *AlertMgrServer) postSilence(ctx context.Context, silence *ormapi.AlertSilence, matchers models.Matchers) (string, error) {
+	startsAt := strfmt.DateTime(silence.StartsAt)
+	endsAt := strfmt.DateTime(silence.EndsAt)
+	postableSilence := models.PostableSilence{
+		Silence: models.Silence{
+			Comment:   swag.String(silence.Comment),
+			CreatedBy: swag.String(silence.CreatedBy),
+			StartsAt:  &startsAt,
+			EndsAt:    &endsAt,
+			Matchers:  matchers,
+		},
+	}
+	data, err := json.Marshal(postableSilence)
+	if err != nil {
+		log.SpanLog(ctx, log.DebugLevelInfo, "Failed to marshal silence", "err", err, "silence", silence)
+		return "", err
+	}
+	res, err := alertMgrApi(ctx, s.AlertMrgAddr, "POST", SilenceApi, "", data, s.TlsConfig)
+	if err != nil {
+		log.SpanLog(ctx, log.DebugLevelInfo, "Failed to create silence", "err", err, "res", res)
+		return "", err
+	}
+	reply := struct {
+		SilenceID string `json:"silenceID"`
+	}{}
+	err = json.Unmarshal(res, &reply)
+	if err != nil {
+		log.SpanLog(ctx, log.DebugLevelInfo, "Unable to unmarshal silence reply", "err", err, "data", res)
+		return "", err
+	}
+	return reply.SilenceID, nil
+}
+
+// Get a single silence by its ID
+func (s *AlertMgrServer) GetSilence(ctx context.Context, id string) (*ormapi.AlertSilence, error) {
+	data, err := alertMgrApi(ctx, s.AlertMrgAddr, "GET", SilenceIdApi+"/"+url.PathEscape(id), "", nil, s.TlsConfig)
+	if err != nil {
+		log.SpanLog(ctx, log.DebugLevelInfo, "Unable to GET Silence", "err", err, "id", id)
+		return nil, err
+	}
+	gettableSilence := models.GettableSilence{}
+	err = json.Unmarshal(data, &gettableSilence)
+	if err != nil {
+		log.SpanLog(ctx, log.DebugLevelInfo, "Unable to unmarshal Silence", "err", err, "data", data)
+		return nil, err
+	}
+	return getAlertSilenceFromGettableSilence(&gettableSilence), nil
+}
+
+// Expire a silence. Alertmanager keeps expired silences around until their retention time expires
+func (s *AlertMgrServer) DeleteSilence(ctx context.Context, id string) error {
+	res, err := alertMgrApi(ctx, s.AlertMrgAddr, "DELETE", SilenceIdApi+"/"+url.PathEscape(id), "", nil, s.TlsConfig)
+	if err != nil {
+		log.SpanLog(ctx, log.DebugLevelInfo, "Failed to delete silence", "err", err, "res", res)
+		return err
+	}
+	return nil
+}
+
+func (s *AlertMgrServer) ShowSilences(ctx context.Context, filter *ormapi.AlertSilence) ([]ormapi.AlertSilence, error) {
+	data, err := alertMgrApi(ctx, s.AlertMrgAddr, "GET", SilenceApi, "", nil, s.TlsConfig)
+	if err != nil {
+		log.SpanLog(ctx, log.DebugLevelInfo, "Unable to GET Silences", "err", err, "filter", filter)
+		return nil, err
+	}
+	gettableSilences := models.GettableSilences{}
+	err = json.Unmarshal(data, &gettableSilences)
+	if err != nil {
+		log.SpanLog(ctx, log.DebugLevelInfo, "Unable to unmarshal Silences", "err", err, "data", data)
+		return nil, err
+	}
+	silences := []ormapi.AlertSilence{}
+	for _, gettableSilence := range gettableSilences {
+		if gettableSilence == nil {
+			continue
+		}
+		silence := getAlertSilenceFromGettableSilence(gettableSilence)
+		if alertSilenceMatchesFilter(silence, filter) {
+			silences = append(silences, *silence)
+		}
+	}
+	return silences, nil
+}
+
 // Common function to send an api call to alertmanager
 func alertMgrApi(ctx context.Context, addr, method, api, options string, payload []byte, tlsConfig *tls.Config) ([]byte, error) {
 	var client *http.Client
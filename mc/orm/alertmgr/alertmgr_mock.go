@@ -19,8 +19,11 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"path"
+	"regexp"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/go-openapi/strfmt"
 	"github.com/jarcoal/httpmock"
@@ -48,6 +51,7 @@ type AlertmanagerMock struct {
 	addr            string
 	configFile      string
 	alerts          map[string]model.Alert
+	silences        map[string]*open_api_models.GettableSilence
 	receivers       []*alertmanager_config.Receiver
 	route           *alertmanager_config.Route
 	AlertPosts      int
@@ -63,6 +67,7 @@ func NewAlertmanagerMock(addr string, cfg string) *AlertmanagerMock {
 	alertMgr := AlertmanagerMock{}
 	alertMgr.addr = addr
 	alertMgr.alerts = make(map[string]model.Alert)
+	alertMgr.silences = make(map[string]*open_api_models.GettableSilence)
 	alertMgr.configFile = cfg
 	if err := alertMgr.readConfig(); err != nil {
 		fmt.Printf("Error reading config file, %v\n", err)
@@ -188,18 +193,46 @@ func (s *AlertmanagerMock) registerGetAlerts() {
 func (s *AlertmanagerMock) registerCreateSilences() {
 	httpmock.RegisterResponder("POST", s.addr+SilenceApi,
 		func(req *http.Request) (*http.Response, error) {
-			// TODO
+			silence := open_api_models.PostableSilence{}
+			err := json.NewDecoder(req.Body).Decode(&silence)
+			if err != nil {
+				return httpmock.NewStringResponse(500, "Unable to decode JSON body"), nil
+			}
+			if len(silence.Matchers) == 0 {
+				return httpmock.NewStringResponse(400, "at least one matcher required"), nil
+			}
 			s.SilencesPosts++
-			return httpmock.NewStringResponse(200, "Success"), nil
+			id := fmt.Sprintf("silence-%d", s.SilencesPosts)
+			state := "active"
+			if silence.StartsAt != nil && time.Time(*silence.StartsAt).After(time.Now()) {
+				state = "pending"
+			}
+			updatedAt := strfmt.DateTime(time.Now())
+			s.silences[id] = &open_api_models.GettableSilence{
+				ID:        &id,
+				Status:    &open_api_models.SilenceStatus{State: &state},
+				UpdatedAt: &updatedAt,
+				Silence:   silence.Silence,
+			}
+			return httpmock.NewJsonResponse(200, map[string]string{"silenceID": id})
 		},
 	)
 }
 
 func (s *AlertmanagerMock) registerDeleteSilences() {
-	httpmock.RegisterResponder("DELETE", s.addr+SilenceApi,
+	httpmock.RegisterRegexpResponder("DELETE", regexp.MustCompile(regexp.QuoteMeta(s.addr+SilenceIdApi+"/")+".+"),
 		func(req *http.Request) (*http.Response, error) {
-			// TODO
 			s.SilencesDeletes++
+			id := path.Base(req.URL.Path)
+			silence, found := s.silences[id]
+			if !found {
+				return httpmock.NewStringResponse(404, "silence not found"), nil
+			}
+			if *silence.Status.State == "expired" {
+				return httpmock.NewStringResponse(500, "silence "+id+" already expired"), nil
+			}
+			expired := "expired"
+			silence.Status.State = &expired
 			return httpmock.NewStringResponse(200, "Success"), nil
 		},
 	)
@@ -208,9 +241,22 @@ func (s *AlertmanagerMock) registerDeleteSilences() {
 func (s *AlertmanagerMock) registerGetSilences() {
 	httpmock.RegisterResponder("GET", s.addr+SilenceApi,
 		func(req *http.Request) (*http.Response, error) {
-			// TODO
+			silences := open_api_models.GettableSilences{}
+			for _, silence := range s.silences {
+				silences = append(silences, silence)
+			}
 			s.SilencesGets++
-			return httpmock.NewStringResponse(200, "Success"), nil
+			return httpmock.NewJsonResponse(200, silences)
+		},
+	)
+	httpmock.RegisterRegexpResponder("GET", regexp.MustCompile(regexp.QuoteMeta(s.addr+SilenceIdApi+"/")+".+"),
+		func(req *http.Request) (*http.Response, error) {
+			s.SilencesGets++
+			silence, found := s.silences[path.Base(req.URL.Path)]
+			if !found {
+				return httpmock.NewStringResponse(404, "silence not found"), nil
+			}
+			return httpmock.NewJsonResponse(200, silence)
 		},
 	)
 }
@@ -272,6 +318,10 @@ func (s *AlertmanagerMock) verifyAlertPresent(t *testing.T, alert *edgeproto.Ale
 	require.True(t, found)
 }
 
+func (s *AlertmanagerMock) verifySilencesCnt(t *testing.T, cnt int) {
+	require.Equal(t, cnt, len(s.silences))
+}
+
 func (s *AlertmanagerMock) verifyReceiversCnt(t *testing.T, cnt int) {
 	require.Equal(t, cnt, len(s.receivers))
 }
@@ -26,6 +26,7 @@ import (
 	dme "github.com/mobiledgex/edge-cloud/d-match-engine/dme-proto"
 	"github.com/mobiledgex/edge-cloud/edgeproto"
 	"github.com/mobiledgex/edge-cloud/log"
+	"github.com/mobiledgex/edge-cloud/vault"
 
 	//	open_api_models "github.com/prometheus/alertmanager/api/v2/models"
 	// TODO - below is to replace the above for right now - once we update go and modules we can use prometheus directly
@@ -49,6 +50,8 @@ func TestAlertMgrServer(t *testing.T) {
 	defer httpmock.DeactivateAndReset()
 	// any requests that don't have a registered URL will be fetched normally
 	httpmock.RegisterNoResponder(httpmock.InitialTransport.RoundTrip)
+	vaultServer, vaultConfig := vault.DummyServer()
+	defer vaultServer.Close()
 
 	testAlertMgrAddr := "http://dummyalertmgr.mobiledgex.net:9093"
 	testAlertMgrConfig := "testAlertMgrConfig.yml"
@@ -74,12 +77,12 @@ func TestAlertMgrServer(t *testing.T) {
 	var testAlertCache edgeproto.AlertCache
 	edgeproto.InitAlertCache(&testAlertCache)
 	alertRefreshInterval = 100 * time.Millisecond
-	testAlertMgrServer, err := NewAlertMgrServer(sidecarServerAddr, nil, &testAlertCache, 2*time.Minute)
+	testAlertMgrServer, err := NewAlertMgrServer(sidecarServerAddr, nil, vaultConfig, &testAlertCache, 2*time.Minute)
 	require.Nil(t, err)
 	require.NotNil(t, testAlertMgrServer)
 	require.Equal(t, 1, fakeAlertmanager.ConfigReloads)
 	// start another test alertMgrServer to test multiple inits
-	testAlertMgrServer2, err := NewAlertMgrServer(sidecarServerAddr, nil, &testAlertCache, 2*time.Minute)
+	testAlertMgrServer2, err := NewAlertMgrServer(sidecarServerAddr, nil, vaultConfig, &testAlertCache, 2*time.Minute)
 	require.Nil(t, err)
 	require.NotNil(t, testAlertMgrServer2)
 	// config is already set up, don't need to reload
@@ -367,6 +370,190 @@ func TestAlertMgrServer(t *testing.T) {
 	// check the receiver and all fields
 	require.Equal(t, testAlertReceivers[4], receivers[0])
 
-	// TODO - test silencers
+	// Test webhook receivers
+	// Credentials must be stored in vault first
+	err = testAlertMgrServer.CreateReceiver(ctx, &testAlertReceivers[5])
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Unable to get webhook credentials")
+	err = testAlertMgrServer.PutWebhookCreds(ctx, &testAlertReceivers[5])
+	require.Nil(t, err)
+	// Reserved headers cannot be customized
+	invalidWebhook := testAlertReceivers[5]
+	invalidWebhook.WebhookHeaders = map[string]string{"authorization": "Bearer foo"}
+	err = testAlertMgrServer.CreateReceiver(ctx, &invalidWebhook)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Webhook header Authorization cannot be customized")
+	// Only one of basic auth, or bearer token
+	invalidWebhook = testAlertReceivers[5]
+	invalidWebhook.WebhookUser = "user"
+	err = testAlertMgrServer.PutWebhookCreds(ctx, &invalidWebhook)
+	require.Nil(t, err)
+	err = testAlertMgrServer.CreateReceiver(ctx, &invalidWebhook)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Invalid webhook auth")
+	err = testAlertMgrServer.PutWebhookCreds(ctx, &testAlertReceivers[5])
+	require.Nil(t, err)
+	invalidWebhook = testAlertReceivers[5]
+	invalidWebhook.WebhookUrl = "invalidURL"
+	err = testAlertMgrServer.CreateReceiver(ctx, &invalidWebhook)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Invalid webhook URL")
+	fakeAlertmanager.verifyReceiversCnt(t, 2)
+	err = testAlertMgrServer.CreateReceiver(ctx, &testAlertReceivers[5])
+	require.Nil(t, err)
+	fakeAlertmanager.verifyReceiversCnt(t, 3)
+	receiver = fakeAlertmanager.findReceiver(&testAlertReceivers[5])
+	require.NotNil(t, receiver)
+	require.Len(t, receiver.WebhookConfigs, 1)
+	require.Equal(t, testAlertReceivers[5].WebhookUrl, receiver.WebhookConfigs[0].URL.String())
+	require.NotNil(t, receiver.WebhookConfigs[0].HTTPConfig)
+	require.Nil(t, receiver.WebhookConfigs[0].HTTPConfig.BasicAuth)
+	require.Equal(t, testAlertReceivers[5].WebhookBearerToken, string(receiver.WebhookConfigs[0].HTTPConfig.BearerToken))
+	header, found := receiver.WebhookConfigs[0].HTTPConfig.HTTPHeaders["X-Incident-Team"]
+	require.True(t, found)
+	require.Equal(t, []string{"edge"}, header.Values)
+	// check route and labels
+	route = fakeAlertmanager.findRouteByReceiver(&testAlertReceivers[5])
+	require.NotNil(t, route)
+	routeLblVal, found = route.Match[edgeproto.CloudletKeyTagOrganization]
+	require.True(t, found)
+	require.Equal(t, routeLblVal, testAlertReceivers[5].Cloudlet.Organization)
+	// Verify ShowReceivers hides the webhook details
+	receivers, err = testAlertMgrServer.ShowReceivers(ctx, &ormapi.AlertReceiver{Type: AlertReceiverTypeWebhook})
+	require.Nil(t, err)
+	require.Len(t, receivers, 1)
+	require.Equal(t, AlertMgrDisplayHidden, receivers[0].WebhookUrl)
+	require.Equal(t, AlertMgrDisplayHidden, receivers[0].WebhookBearerToken)
+	require.Equal(t, map[string]string{"X-Incident-Team": AlertMgrDisplayHidden}, receivers[0].WebhookHeaders)
+	require.Empty(t, receivers[0].WebhookUser)
+	require.Empty(t, receivers[0].WebhookPassword)
+	receivers[0].WebhookUrl = testAlertReceivers[5].WebhookUrl
+	receivers[0].WebhookBearerToken = testAlertReceivers[5].WebhookBearerToken
+	receivers[0].WebhookHeaders = testAlertReceivers[5].WebhookHeaders
+	require.Equal(t, testAlertReceivers[5], receivers[0])
+	err = testAlertMgrServer.DeleteReceiver(ctx, &testAlertReceivers[5])
+	require.Nil(t, err)
+	fakeAlertmanager.verifyReceiversCnt(t, 2)
+	testAlertMgrServer.DeleteWebhookCreds(ctx, &testAlertReceivers[5])
+	_, err = testAlertMgrServer.getWebhookCreds(ctx, &testAlertReceivers[5])
+	require.NotNil(t, err)
+
+	// Test teams receivers
+	invalidTeams := testAlertReceivers[6]
+	invalidTeams.TeamsWebhook = "invalidURL"
+	err = testAlertMgrServer.CreateReceiver(ctx, &invalidTeams)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Invalid Teams webhook URL")
+	err = testAlertMgrServer.CreateReceiver(ctx, &testAlertReceivers[6])
+	require.Nil(t, err)
+	fakeAlertmanager.verifyReceiversCnt(t, 3)
+	receiver = fakeAlertmanager.findReceiver(&testAlertReceivers[6])
+	require.NotNil(t, receiver)
+	require.Len(t, receiver.MSTeamsConfigs, 1)
+	require.Equal(t, testAlertReceivers[6].TeamsWebhook, receiver.MSTeamsConfigs[0].WebhookURL.String())
+	require.True(t, receiver.MSTeamsConfigs[0].SendResolved())
+	receivers, err = testAlertMgrServer.ShowReceivers(ctx, &ormapi.AlertReceiver{Type: AlertReceiverTypeTeams})
+	require.Nil(t, err)
+	require.Len(t, receivers, 1)
+	require.Equal(t, AlertMgrDisplayHidden, receivers[0].TeamsWebhook)
+	receivers[0].TeamsWebhook = testAlertReceivers[6].TeamsWebhook
+	require.Equal(t, testAlertReceivers[6], receivers[0])
+	err = testAlertMgrServer.DeleteReceiver(ctx, &testAlertReceivers[6])
+	require.Nil(t, err)
+	fakeAlertmanager.verifyReceiversCnt(t, 2)
+
+	// Test silences
+	fakeAlertmanager.resetCounters()
+	// Silence with no matchers is rejected
+	_, err = testAlertMgrServer.CreateSilence(ctx, &ormapi.AlertSilence{})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Silence must match at least one alert label")
+	require.Equal(t, 0, fakeAlertmanager.SilencesPosts)
+	silenceIds := []string{}
+	for ii := range testAlertSilences {
+		id, err := testAlertMgrServer.CreateSilence(ctx, &testAlertSilences[ii])
+		require.Nil(t, err)
+		require.NotEmpty(t, id)
+		silenceIds = append(silenceIds, id)
+	}
+	require.Equal(t, len(testAlertSilences), fakeAlertmanager.SilencesPosts)
+	fakeAlertmanager.verifySilencesCnt(t, len(testAlertSilences))
+	// Verify matchers of the appInst silence
+	matchers := map[string]string{}
+	for _, matcher := range fakeAlertmanager.silences[silenceIds[0]].Matchers {
+		require.False(t, *matcher.IsRegex)
+		matchers[*matcher.Name] = *matcher.Value
+	}
+	require.Equal(t, cloudcommon.AlertAppInstDown, matchers["alertname"])
+	require.Equal(t, testRegions[0], matchers["region"])
+	require.Equal(t, cloudcommon.AlertScopeApp, matchers[cloudcommon.AlertScopeTypeTag])
+	require.Equal(t, "testorg", matchers[edgeproto.AppKeyTagOrganization])
+	require.Equal(t, "testapp", matchers[edgeproto.AppKeyTagName])
+	require.Equal(t, "1.0", matchers[edgeproto.AppKeyTagVersion])
+	// Verify ShowSilences
+	silences, err := testAlertMgrServer.ShowSilences(ctx, nil)
+	require.Nil(t, err)
+	require.Len(t, silences, len(testAlertSilences))
+	// Show with a filter
+	silences, err = testAlertMgrServer.ShowSilences(ctx, &ormapi.AlertSilence{
+		Cloudlet: edgeproto.CloudletKey{
+			Organization: "testCloudletOrg",
+		},
+	})
+	require.Nil(t, err)
+	require.Len(t, silences, 1)
+	require.Equal(t, silenceIds[1], silences[0].Id)
+	require.Equal(t, testAlertSilences[1].Cloudlet, silences[0].Cloudlet)
+	require.Equal(t, testAlertSilences[1].Severity, silences[0].Severity)
+	require.Equal(t, testAlertSilences[1].CreatedBy, silences[0].CreatedBy)
+	require.Equal(t, testAlertSilences[1].Comment, silences[0].Comment)
+	require.True(t, testAlertSilences[1].StartsAt.Equal(silences[0].StartsAt))
+	require.True(t, testAlertSilences[1].EndsAt.Equal(silences[0].EndsAt))
+	// silence starts in the future
+	require.Equal(t, "pending", silences[0].State)
+	// Get a single silence
+	silence, err := testAlertMgrServer.GetSilence(ctx, silenceIds[0])
+	require.Nil(t, err)
+	require.Equal(t, silenceIds[0], silence.Id)
+	require.Equal(t, testAlertSilences[0].AppInst, silence.AppInst)
+	require.Equal(t, testAlertSilences[0].AlertName, silence.AlertName)
+	require.Equal(t, testAlertSilences[0].Region, silence.Region)
+	// Non-existent silence
+	_, err = testAlertMgrServer.GetSilence(ctx, "invalidSilence")
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "silence not found")
+	// Delete silence - it should be expired
+	err = testAlertMgrServer.DeleteSilence(ctx, silenceIds[0])
+	require.Nil(t, err)
+	require.Equal(t, 1, fakeAlertmanager.SilencesDeletes)
+	silences, err = testAlertMgrServer.ShowSilences(ctx, &ormapi.AlertSilence{State: "expired"})
+	require.Nil(t, err)
+	require.Len(t, silences, 1)
+	require.Equal(t, silenceIds[0], silences[0].Id)
+	// Deleting it again fails
+	err = testAlertMgrServer.DeleteSilence(ctx, silenceIds[0])
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "already expired")
+	err = testAlertMgrServer.DeleteSilence(ctx, silenceIds[1])
+	require.Nil(t, err)
+	// Cloudlet silence requires a full cloudlet key
+	_, err = testAlertMgrServer.CreateCloudletSilence(ctx, &ormapi.AlertSilence{
+		Cloudlet: edgeproto.CloudletKey{Organization: "testCloudletOrg"},
+	})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Cloudlet must be specified")
+	// Cloudlet silence matches both cloudlet and appInst alerts
+	id, err := testAlertMgrServer.CreateCloudletSilence(ctx, &testAlertSilences[1])
+	require.Nil(t, err)
+	matchers = map[string]string{}
+	for _, matcher := range fakeAlertmanager.silences[id].Matchers {
+		matchers[*matcher.Name] = *matcher.Value
+	}
+	require.Len(t, matchers, 2)
+	require.Equal(t, "testCloudletOrg", matchers[edgeproto.CloudletKeyTagOrganization])
+	require.Equal(t, "testCloudlet", matchers[edgeproto.CloudletKeyTagName])
+	err = testAlertMgrServer.DeleteSilence(ctx, id)
+	require.Nil(t, err)
+
 	testAlertMgrServer.Stop()
 }
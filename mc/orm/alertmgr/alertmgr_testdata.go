@@ -15,6 +15,8 @@
 package alertmgr
 
 import (
+	"time"
+
 	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
 	"github.com/mobiledgex/edge-cloud/cloudcommon"
 	dme "github.com/mobiledgex/edge-cloud/d-match-engine/dme-proto"
@@ -171,4 +173,59 @@ var testAlertReceivers = []ormapi.AlertReceiver{
 			},
 		},
 	},
+	ormapi.AlertReceiver{
+		Name:               "testorgwebhookreceiver",
+		Type:               AlertReceiverTypeWebhook,
+		Severity:           cloudcommon.AlertSeverityError,
+		User:               testUsers[0],
+		WebhookUrl:         "https://incidents.testorg.net/alerts",
+		WebhookBearerToken: "testtoken",
+		WebhookHeaders: map[string]string{
+			"X-Incident-Team": "edge",
+		},
+		Cloudlet: edgeproto.CloudletKey{
+			Name:         "testCloudlet",
+			Organization: "testCloudletOrg",
+		},
+	},
+	ormapi.AlertReceiver{
+		Name:         "testorgteamsreceiver",
+		Type:         AlertReceiverTypeTeams,
+		Severity:     cloudcommon.AlertSeverityWarn,
+		User:         testUsers[1],
+		TeamsWebhook: "https://testorg.webhook.office.com/webhookb2/foo",
+		Cloudlet: edgeproto.CloudletKey{
+			Name:         "testCloudlet",
+			Organization: "testCloudletOrg",
+		},
+	},
+}
+
+var testAlertSilences = []ormapi.AlertSilence{
+	ormapi.AlertSilence{
+		Region:    testRegions[0],
+		AlertName: cloudcommon.AlertAppInstDown,
+		CreatedBy: testUsers[0],
+		Comment:   "app maintenance",
+		StartsAt:  time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC),
+		EndsAt:    time.Date(2030, time.January, 1, 2, 0, 0, 0, time.UTC),
+		AppInst: edgeproto.AppInstKey{
+			AppKey: edgeproto.AppKey{
+				Name:         "testapp",
+				Organization: "testorg",
+				Version:      "1.0",
+			},
+		},
+	},
+	ormapi.AlertSilence{
+		Severity:  cloudcommon.AlertSeverityWarn,
+		CreatedBy: testUsers[1],
+		Comment:   "cloudlet maintenance",
+		StartsAt:  time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC),
+		EndsAt:    time.Date(2030, time.January, 2, 0, 0, 0, 0, time.UTC),
+		Cloudlet: edgeproto.CloudletKey{
+			Name:         "testCloudlet",
+			Organization: "testCloudletOrg",
+		},
+	},
 }
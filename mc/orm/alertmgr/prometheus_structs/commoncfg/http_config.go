@@ -31,9 +31,11 @@ import (
 type Secret string
 
 // MarshalYAML implements the yaml.Marshaler interface for Secrets.
+// Note - local change. We write the config file from these structs, so
+// secrets should not be replaced with "<secret>" on marshaling.
 func (s Secret) MarshalYAML() (interface{}, error) {
 	if s != "" {
-		return "<secret>", nil
+		return string(s), nil
 	}
 	return nil, nil
 }
@@ -91,6 +93,16 @@ type HTTPClientConfig struct {
 	ProxyURL URL `yaml:"proxy_url,omitempty"`
 	// TLSConfig to use to connect to the targets.
 	TLSConfig TLSConfig `yaml:"tls_config,omitempty"`
+	// Custom HTTP headers to be sent along with each request.
+	// Note - local change. Backported from newer versions of prometheus common.
+	HTTPHeaders map[string]Header `yaml:"http_headers,omitempty"`
+}
+
+// Header represents the configuration for a single HTTP header.
+type Header struct {
+	Values  []string `yaml:"values,omitempty" json:"values,omitempty"`
+	Secrets []Secret `yaml:"secrets,omitempty" json:"secrets,omitempty"`
+	Files   []string `yaml:"files,omitempty" json:"files,omitempty"`
 }
 
 // Validate validates the HTTPClientConfig to check only one of BearerToken,
@@ -109,6 +109,7 @@ func (s *SidecarServer) Run() error {
 	rtrMux.HandleFunc(AlertApi, s.proxyHandler)
 	// http.HandleFunc(ReloadConfigApi, proxyHandler) - this should not be externally exposed
 	rtrMux.HandleFunc(SilenceApi, s.proxyHandler)
+	rtrMux.HandleFunc(SilenceIdApi+"/{silenceID}", s.proxyHandler)
 	rtrMux.HandleFunc(ReceiverApi, s.proxyHandler)
 	rtrMux.HandleFunc(mobiledgeXReceiversApi, s.alertReceiver).Methods("GET")
 	rtrMux.HandleFunc(mobiledgeXReceiverApi, s.alertReceiver)
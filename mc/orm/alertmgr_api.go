@@ -15,8 +15,10 @@
 package orm
 
 import (
+	"context"
 	fmt "fmt"
 	"strings"
+	"time"
 
 	"github.com/labstack/echo"
 	"github.com/mobiledgex/edge-cloud-infra/mc/orm/alertmgr"
@@ -124,13 +126,38 @@ func CreateAlertReceiver(c echo.Context) error {
 		if len(in.PagerDutyIntegrationKey) != alertmgr.PagerDutyIntegrationKeyLen {
 			return fmt.Errorf("PagerDuty Integration Key must contain %d characters", alertmgr.PagerDutyIntegrationKeyLen)
 		}
+	case alertmgr.AlertReceiverTypeWebhook:
+		if in.WebhookUrl == "" {
+			return fmt.Errorf("Webhook URL must be specified")
+		}
+		if in.WebhookPassword != "" && in.WebhookUser == "" {
+			return fmt.Errorf("Webhook user must be specified with the webhook password")
+		}
+		if in.WebhookBearerToken != "" && in.WebhookUser != "" {
+			return fmt.Errorf("Only one of webhook basic auth or bearer token can be specified")
+		}
+	case alertmgr.AlertReceiverTypeTeams:
+		if in.TeamsWebhook == "" {
+			return fmt.Errorf("Teams webhook URL must be specified")
+		}
 	default:
 		log.SpanLog(ctx, log.DebugLevelInfo, "type of a receiver is invalid")
 		return fmt.Errorf("Receiver type invalid")
 	}
+	webhookAuth := in.Type == alertmgr.AlertReceiverTypeWebhook &&
+		(in.WebhookUser != "" || in.WebhookBearerToken != "")
+	if webhookAuth {
+		if err := AlertManagerServer.PutWebhookCreds(ctx, &in); err != nil {
+			log.SpanLog(ctx, log.DebugLevelInfo, "Failed to store webhook credentials", "err", err)
+			return fmt.Errorf("Unable to store webhook credentials - %s", err.Error())
+		}
+	}
 	err = AlertManagerServer.CreateReceiver(ctx, &in)
 	if err != nil {
 		log.SpanLog(ctx, log.DebugLevelInfo, "Failed to create a receiver", "err", err)
+		if webhookAuth {
+			AlertManagerServer.DeleteWebhookCreds(ctx, &in)
+		}
 		return fmt.Errorf("Unable to create a receiver - %s", err.Error())
 	}
 	return ormutil.SetReply(c, ormutil.Msg("Alert receiver created successfully"))
@@ -200,6 +227,9 @@ func DeleteAlertReceiver(c echo.Context) error {
 		log.SpanLog(ctx, log.DebugLevelInfo, "Failed to delete a receiver", "err", err)
 		return fmt.Errorf("Unable to delete a receiver - %s", err.Error())
 	}
+	if in.Type == alertmgr.AlertReceiverTypeWebhook {
+		AlertManagerServer.DeleteWebhookCreds(ctx, &in)
+	}
 	return ormutil.SetReply(c, ormutil.Msg("Alert receiver deleted successfully"))
 }
 
@@ -213,16 +243,33 @@ func ShowAlertReceiver(c echo.Context) error {
 	ctx := ormutil.GetContext(c)
 	log.SpanLog(ctx, log.DebugLevelApi, "Show Alertmanager Receivers", "context", c, "claims", claims)
 
-	filter := ormapi.AlertReceiver{}
+	in := struct {
+		ormapi.AlertReceiver
+		ormapi.ShowPage
+	}{}
 	if c.Request().ContentLength > 0 {
-		if err := c.Bind(&filter); err != nil {
+		if err := c.Bind(&in); err != nil {
 			return ormutil.BindErr(err)
 		}
 	}
+	filter := in.AlertReceiver
+	if in.Limit != 0 || in.ContinuationToken != "" {
+		pager, err := ormutil.NewShowPager(&in.ShowPage, alertReceiverKey)
+		if err != nil {
+			return err
+		}
+		ormutil.SetShowPager(c, pager)
+	}
 
 	if filter.SlackWebhook != "" {
 		return fmt.Errorf("Slack URL is not specifiable as a filter")
 	}
+	if filter.WebhookUrl != "" || filter.WebhookPassword != "" || filter.WebhookBearerToken != "" {
+		return fmt.Errorf("Webhook URL and credentials are not specifiable as a filter")
+	}
+	if filter.TeamsWebhook != "" {
+		return fmt.Errorf("Teams URL is not specifiable as a filter")
+	}
 
 	allowedOrgs, err := enforcer.GetAuthorizedOrgs(ctx, claims.Username, ResourceAlert, ActionView)
 	isAdmin := false
@@ -264,3 +311,171 @@ func ShowAlertReceiver(c echo.Context) error {
 	}
 	return ormutil.SetReply(c, alertRecs)
 }
+
+// Key used to order alert receivers for paging
+func alertReceiverKey(obj interface{}) (string, error) {
+	receiver, ok := obj.(ormapi.AlertReceiver)
+	if !ok {
+		return "", fmt.Errorf("Unexpected alert receiver type %T", obj)
+	}
+	return receiver.Name + "::" + receiver.User + "::" + receiver.Severity + "::" + receiver.Type, nil
+}
+
+func getOrgForSilence(in *ormapi.AlertSilence) string {
+	if in.Cloudlet.Organization != "" {
+		return in.Cloudlet.Organization
+	}
+	return in.AppInst.AppKey.Organization
+}
+
+// Check that the user is allowed to manage the silence.
+// Developers can only silence alerts of their AppInsts, and operators
+// can only silence alerts of their cloudlets
+func authorizeAlertSilence(ctx context.Context, username string, in *ormapi.AlertSilence) error {
+	if in.Cloudlet.Organization != "" {
+		if !in.AppInst.Matches(&edgeproto.AppInstKey{}) {
+			return fmt.Errorf("AppInst details cannot be specified if this silence is for cloudlet alerts")
+		}
+		return authorized(ctx, username, in.Cloudlet.Organization, ResourceAlert, ActionManage,
+			withReferenceOrg(in.Cloudlet.Organization, "cloudlet", OrgTypeOperator))
+	}
+	if !in.Cloudlet.Matches(&edgeproto.CloudletKey{}) {
+		return fmt.Errorf("Cloudlet details cannot be specified if this silence is for appInst alerts")
+	}
+	if in.AppInst.AppKey.Organization != "" {
+		return authorized(ctx, username, in.AppInst.AppKey.Organization, ResourceAlert, ActionManage,
+			withReferenceOrg(in.AppInst.AppKey.Organization, "app", OrgTypeDeveloper))
+	}
+	// only admin can silence alerts across organizations
+	if in.AppInst.ClusterInstKey.Organization != "" || !isAdmin(ctx, username) {
+		return fmt.Errorf("Either cloudlet or app instance organization has to be specified")
+	}
+	return nil
+}
+
+// Create alert silence api handler
+func CreateAlertSilence(c echo.Context) error {
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	ctx := ormutil.GetContext(c)
+	log.SpanLog(ctx, log.DebugLevelInfo, "Create Alertmanager Silence", "context", c, "claims", claims)
+	in := ormapi.AlertSilence{}
+	_, err = ReadConn(c, &in)
+	if err != nil {
+		return err
+	}
+	if in.Id != "" {
+		return fmt.Errorf("Silence ID is not specifiable, it is assigned by the alertmanager")
+	}
+	if in.Severity != "" && !cloudcommon.IsAlertSeverityValid(in.Severity) {
+		return fmt.Errorf("Alert severity has to be one of %s", cloudcommon.GetValidAlertSeverityString())
+	}
+	if err := authorizeAlertSilence(ctx, claims.Username, &in); err != nil {
+		return err
+	}
+	// user is derived from the token
+	if in.CreatedBy != "" {
+		return fmt.Errorf("CreatedBy is not specifiable, current logged in user will be used")
+	}
+	in.CreatedBy = claims.Username
+	in.State = ""
+	if in.StartsAt.IsZero() {
+		in.StartsAt = time.Now()
+	}
+	if in.EndsAt.IsZero() {
+		return fmt.Errorf("Silence end time has to be specified")
+	}
+	if !in.EndsAt.After(in.StartsAt) {
+		return fmt.Errorf("Silence end time must be after the start time")
+	}
+	if !in.EndsAt.After(time.Now()) {
+		return fmt.Errorf("Silence end time must be in the future")
+	}
+	if in.Comment == "" {
+		in.Comment = "Silenced by " + claims.Username
+	}
+	id, err := AlertManagerServer.CreateSilence(ctx, &in)
+	if err != nil {
+		log.SpanLog(ctx, log.DebugLevelInfo, "Failed to create a silence", "err", err)
+		return fmt.Errorf("Unable to create a silence - %s", err.Error())
+	}
+	return ormutil.SetReply(c, ormutil.Msg("Alert silence "+id+" created successfully"))
+}
+
+// Delete alert silence api handler
+func DeleteAlertSilence(c echo.Context) error {
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	ctx := ormutil.GetContext(c)
+	log.SpanLog(ctx, log.DebugLevelInfo, "Delete Alertmanager Silence", "context", c, "claims", claims)
+	in := ormapi.AlertSilence{}
+	_, err = ReadConn(c, &in)
+	if err != nil {
+		return err
+	}
+	if in.Id == "" {
+		return fmt.Errorf("Silence ID has to be specified")
+	}
+	silence, err := AlertManagerServer.GetSilence(ctx, in.Id)
+	if err != nil {
+		log.SpanLog(ctx, log.DebugLevelInfo, "Failed to find a silence", "id", in.Id, "err", err)
+		// don't let users fish for silence IDs of other orgs
+		if !isAdmin(ctx, claims.Username) {
+			return echo.ErrForbidden
+		}
+		return fmt.Errorf("Unable to find a silence - %s", err.Error())
+	}
+	if err := authorizeAlertSilence(ctx, claims.Username, silence); err != nil {
+		log.SpanLog(ctx, log.DebugLevelInfo, "Not authorized to delete a silence", "silence", silence, "err", err)
+		return echo.ErrForbidden
+	}
+	err = AlertManagerServer.DeleteSilence(ctx, in.Id)
+	if err != nil {
+		log.SpanLog(ctx, log.DebugLevelInfo, "Failed to delete a silence", "err", err)
+		return fmt.Errorf("Unable to delete a silence - %s", err.Error())
+	}
+	return ormutil.SetReply(c, ormutil.Msg("Alert silence deleted successfully"))
+}
+
+// Show alert silences api handler
+func ShowAlertSilence(c echo.Context) error {
+	alertSilences := []ormapi.AlertSilence{}
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	ctx := ormutil.GetContext(c)
+	log.SpanLog(ctx, log.DebugLevelApi, "Show Alertmanager Silences", "context", c, "claims", claims)
+
+	filter := ormapi.AlertSilence{}
+	if c.Request().ContentLength > 0 {
+		if err := c.Bind(&filter); err != nil {
+			return ormutil.BindErr(err)
+		}
+	}
+
+	allowedOrgs, err := enforcer.GetAuthorizedOrgs(ctx, claims.Username, ResourceAlert, ActionView)
+	if err != nil {
+		return err
+	}
+	// check for a user with no orgs
+	if len(allowedOrgs) == 0 {
+		return echo.ErrForbidden
+	}
+	_, isAdmin := allowedOrgs[""]
+	silences, err := AlertManagerServer.ShowSilences(ctx, &filter)
+	if err != nil {
+		return err
+	}
+	for ii := range silences {
+		org := getOrgForSilence(&silences[ii])
+		if _, found := allowedOrgs[org]; found || isAdmin {
+			alertSilences = append(alertSilences, silences[ii])
+		}
+	}
+	return ormutil.SetReply(c, alertSilences)
+}
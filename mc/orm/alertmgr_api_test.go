@@ -19,11 +19,13 @@ import (
 	"net/http"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/mobiledgex/edge-cloud-infra/mc/mcctl/mctestclient"
 	"github.com/mobiledgex/edge-cloud-infra/mc/orm/alertmgr"
 	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
 	"github.com/mobiledgex/edge-cloud/edgeproto"
+	"github.com/mobiledgex/edge-cloud/vault"
 	"github.com/stretchr/testify/require"
 )
 
@@ -243,6 +245,51 @@ func goodPermTestAlertReceivers(t *testing.T, mcClient *mctestclient.Client, uri
 	status, err = testCreateAlertReceiver(mcClient, uri, devToken, region, devOrg, "testAlert", "email", "error", "", "xx.com", nil, nil)
 	require.NotNil(t, err)
 	require.Contains(t, err.Error(), "Receiver email is invalid")
+	// missing webhook and teams details
+	status, err = testCreateAlertReceiver(mcClient, uri, devToken, region, devOrg, "testAlert", "webhook", "error", "", "", nil, nil)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Webhook URL must be specified")
+	status, err = testCreateAlertReceiver(mcClient, uri, devToken, region, devOrg, "testAlert", "teams", "error", "", "", nil, nil)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Teams webhook URL must be specified")
+	// webhook basic auth and bearer token are mutually exclusive
+	webhookReceiver := &ormapi.AlertReceiver{
+		Name:               "testAlert",
+		Type:               "webhook",
+		Severity:           "error",
+		Region:             region,
+		WebhookUrl:         "https://incidents.example.com/alerts",
+		WebhookUser:        "user",
+		WebhookPassword:    "password",
+		WebhookBearerToken: "token",
+	}
+	webhookReceiver.AppInst.AppKey.Organization = devOrg
+	status, err = mcClient.CreateAlertReceiver(uri, devToken, webhookReceiver)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Only one of webhook basic auth or bearer token can be specified")
+	// webhook credentials are stored in vault
+	webhookReceiver.Name = "testWebhook"
+	webhookReceiver.WebhookUser = ""
+	webhookReceiver.WebhookPassword = ""
+	_, err = mcClient.CreateAlertReceiver(uri, devToken, webhookReceiver)
+	require.Nil(t, err)
+	receivers, _, err := testShowAlertReceiver(mcClient, uri, devToken, region, devOrg, "testWebhook", "")
+	require.Nil(t, err)
+	require.Len(t, receivers, 1)
+	require.Equal(t, alertmgr.AlertMgrDisplayHidden, receivers[0].WebhookBearerToken)
+	credsPath := "/secret/data/accounts/alertreceivers/" + receivers[0].User + "/testWebhook-error"
+	creds := alertmgr.WebhookCreds{}
+	err = vault.GetData(serverConfig.vaultConfig, credsPath, 0, &creds)
+	require.Nil(t, err)
+	require.Equal(t, "token", creds.BearerToken)
+	_, err = mcClient.DeleteAlertReceiver(uri, devToken, webhookReceiver)
+	require.Nil(t, err)
+	err = vault.GetData(serverConfig.vaultConfig, credsPath, 0, &creds)
+	require.NotNil(t, err)
+	// webhook URL cannot be used as a filter
+	_, status, err = mcClient.ShowAlertReceiver(uri, devToken, &ormapi.AlertReceiver{WebhookUrl: "https://incidents.example.com/alerts"})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Webhook URL and credentials are not specifiable as a filter")
 	// test combination of both appInst and cloudlet
 	appInst = edgeproto.AppInstKey{
 		AppKey: edgeproto.AppKey{
@@ -269,3 +316,126 @@ func goodPermTestAlertReceivers(t *testing.T, mcClient *mctestclient.Client, uri
 	require.Equal(t, http.StatusOK, status)
 	require.Equal(t, 0, len(list))
 }
+
+func testCreateAlertSilence(mcClient *mctestclient.Client, uri, token, region string, appInstKey *edgeproto.AppInstKey, cloudlet *edgeproto.CloudletKey, endsAt time.Time) (int, error) {
+	dat := &ormapi.AlertSilence{
+		Region: region,
+		EndsAt: endsAt,
+	}
+	if appInstKey != nil {
+		dat.AppInst = *appInstKey
+	}
+	if cloudlet != nil {
+		dat.Cloudlet = *cloudlet
+	}
+	status, err := mcClient.CreateAlertSilence(uri, token, dat)
+	return status, err
+}
+
+func testDeleteAlertSilence(mcClient *mctestclient.Client, uri, token, id string) (int, error) {
+	dat := &ormapi.AlertSilence{
+		Id: id,
+	}
+	status, err := mcClient.DeleteAlertSilence(uri, token, dat)
+	return status, err
+}
+
+func testShowAlertSilence(mcClient *mctestclient.Client, uri, token, state string) ([]ormapi.AlertSilence, int, error) {
+	dat := &ormapi.AlertSilence{
+		State: state,
+	}
+	silences, status, err := mcClient.ShowAlertSilence(uri, token, dat)
+	return silences, status, err
+}
+
+func badPermTestAlertSilences(t *testing.T, mcClient *mctestclient.Client, uri, token, region, org string) {
+	appInst := edgeproto.AppInstKey{
+		AppKey: edgeproto.AppKey{
+			Organization: org,
+		},
+	}
+	status, err := testCreateAlertSilence(mcClient, uri, token, region, &appInst, nil, time.Now().Add(time.Hour))
+	require.NotNil(t, err)
+	require.Equal(t, http.StatusForbidden, status)
+	cloudlet := edgeproto.CloudletKey{
+		Organization: org,
+	}
+	status, err = testCreateAlertSilence(mcClient, uri, token, region, nil, &cloudlet, time.Now().Add(time.Hour))
+	require.NotNil(t, err)
+	require.Equal(t, http.StatusForbidden, status)
+}
+
+func goodPermTestAlertSilences(t *testing.T, mcClient *mctestclient.Client, uri, devToken, operToken, region, devOrg, operOrg string) {
+	appInst := edgeproto.AppInstKey{
+		AppKey: edgeproto.AppKey{
+			Organization: devOrg,
+		},
+	}
+	cloudlet := edgeproto.CloudletKey{
+		Organization: operOrg,
+	}
+	endsAt := time.Now().Add(time.Hour)
+
+	// developer silences alerts for its AppInsts
+	status, err := testCreateAlertSilence(mcClient, uri, devToken, region, &appInst, nil, endsAt)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	// operator silences alerts for its cloudlets
+	status, err = testCreateAlertSilence(mcClient, uri, operToken, region, nil, &cloudlet, endsAt)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	// developer cannot silence cloudlet alerts and operator cannot silence AppInst alerts
+	status, err = testCreateAlertSilence(mcClient, uri, devToken, region, nil, &cloudlet, endsAt)
+	require.NotNil(t, err)
+	require.Equal(t, http.StatusForbidden, status)
+	status, err = testCreateAlertSilence(mcClient, uri, operToken, region, &appInst, nil, endsAt)
+	require.NotNil(t, err)
+	require.Equal(t, http.StatusForbidden, status)
+	// combination of both appInst and cloudlet
+	status, err = testCreateAlertSilence(mcClient, uri, operToken, region, &appInst, &cloudlet, endsAt)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "AppInst details cannot be specified if this silence is for cloudlet alerts")
+	// no organization
+	status, err = testCreateAlertSilence(mcClient, uri, devToken, region, nil, nil, endsAt)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Either cloudlet or app instance organization has to be specified")
+	// invalid time range
+	status, err = testCreateAlertSilence(mcClient, uri, devToken, region, &appInst, nil, time.Time{})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Silence end time has to be specified")
+	status, err = testCreateAlertSilence(mcClient, uri, devToken, region, &appInst, nil, time.Now().Add(-time.Hour))
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Silence end time must be after the start time")
+
+	// each user only sees the silences of its own organization
+	devList, status, err := testShowAlertSilence(mcClient, uri, devToken, "")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, 1, len(devList))
+	require.Equal(t, devOrg, devList[0].AppInst.AppKey.Organization)
+	operList, status, err := testShowAlertSilence(mcClient, uri, operToken, "")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, 1, len(operList))
+	require.Equal(t, operOrg, operList[0].Cloudlet.Organization)
+
+	// users cannot delete silences of other organizations
+	status, err = testDeleteAlertSilence(mcClient, uri, devToken, operList[0].Id)
+	require.NotNil(t, err)
+	require.Equal(t, http.StatusForbidden, status)
+	status, err = testDeleteAlertSilence(mcClient, uri, devToken, "invalid-silence-id")
+	require.NotNil(t, err)
+	require.Equal(t, http.StatusForbidden, status)
+
+	// clean up
+	status, err = testDeleteAlertSilence(mcClient, uri, devToken, devList[0].Id)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	status, err = testDeleteAlertSilence(mcClient, uri, operToken, operList[0].Id)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	devList, status, err = testShowAlertSilence(mcClient, uri, devToken, "active")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, 0, len(devList))
+}
@@ -59,6 +59,9 @@ func CreateAppInst(c echo.Context) error {
 			return err
 		}
 	}
+	if err := checkOrgQuotaCreateAppInst(ctx, rc, obj); err != nil {
+		return err
+	}
 	// Need access to database for federation handling
 	rc.Database = database
 
@@ -115,6 +115,12 @@ func (s *AppStoreSync) runThread(done chan struct{}) {
 			s.needsSync = false
 			s.syncObjects(ctx)
 			s.count++
+			result := metricResultSuccess
+			if s.needsSync {
+				// sync hit errors and will be retried
+				result = metricResultFailure
+			}
+			appStoreSyncRunsTotal.WithLabelValues(s.appStoreType, result).Inc()
 
 			span.Finish()
 		}
@@ -134,5 +140,6 @@ func (s *AppStoreSync) wakeup() {
 
 func (s *AppStoreSync) syncErr(ctx context.Context, err error) {
 	log.SpanLog(ctx, log.DebugLevelApi, "AppStore Sync failed", "AppStore", s.appStoreType, "err", err)
+	appStoreSyncErrorsTotal.WithLabelValues(s.appStoreType).Inc()
 	s.NeedsSync()
 }
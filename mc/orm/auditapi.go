@@ -0,0 +1,272 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormutil"
+	"github.com/mobiledgex/edge-cloud/cloudcommon/node"
+)
+
+// Max number of audit logs returned by a single query. This is the
+// default max result window for Elasticsearch.
+const AuditMaxResults = 10000
+
+// Span tags set by the logger middleware that are converted into
+// audit response fields.
+const (
+	auditTagUsername = "username"
+	auditTagClientIP = "remote-ip"
+	auditTagStatus   = "status"
+	auditTagDuration = "duration"
+	auditTagRequest  = "request"
+	auditTagResponse = "response"
+	auditTagTraceID  = "traceid"
+)
+
+var AuditExportHeader = []string{"StartTime", "Operation", "Username", "Org", "ClientIP", "Status", "Duration", "Request", "Response", "Error", "TraceID"}
+
+func ShowAudit(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	query := ormapi.AuditQuery{}
+	if err := c.Bind(&query); err != nil {
+		return ormutil.BindErr(err)
+	}
+	if query.Format != "" {
+		return fmt.Errorf("Format can only be specified for export")
+	}
+	audits, err := searchAudit(ctx, claims.Username, &query)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, audits)
+}
+
+func ExportAudit(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	query := ormapi.AuditQuery{}
+	if err := c.Bind(&query); err != nil {
+		return ormutil.BindErr(err)
+	}
+	if query.Format != "" && query.Format != ormapi.ReportFormatJSON && query.Format != ormapi.ReportFormatCSV {
+		return fmt.Errorf("Invalid export format %s, must be one of %s, %s", query.Format, ormapi.ReportFormatJSON, ormapi.ReportFormatCSV)
+	}
+	if query.Limit == 0 {
+		// export everything in the time range
+		query.Limit = AuditMaxResults
+	}
+	audits, err := searchAudit(ctx, claims.Username, &query)
+	if err != nil {
+		return err
+	}
+	out := bytes.Buffer{}
+	if query.Format == ormapi.ReportFormatCSV {
+		if err := writeAuditCSV(audits, &out); err != nil {
+			return err
+		}
+		c.Response().Header().Set(echo.HeaderContentDisposition, "attachment; filename=audit.csv")
+		return c.Blob(http.StatusOK, "text/csv", out.Bytes())
+	}
+	enc := json.NewEncoder(&out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(audits); err != nil {
+		return fmt.Errorf("failed to encode audit logs: %v", err)
+	}
+	c.Response().Header().Set(echo.HeaderContentDisposition, "attachment; filename=audit.json")
+	return c.Blob(http.StatusOK, echo.MIMEApplicationJSONCharsetUTF8, out.Bytes())
+}
+
+func searchAudit(ctx context.Context, username string, query *ormapi.AuditQuery) ([]ormapi.AuditResponse, error) {
+	if query.Limit < 0 || query.Limit > AuditMaxResults {
+		return nil, fmt.Errorf("Limit must be between 0 and %d", AuditMaxResults)
+	}
+	if query.From < 0 {
+		return nil, fmt.Errorf("From cannot be negative")
+	}
+	if query.From+query.Limit > AuditMaxResults {
+		return nil, fmt.Errorf("From plus limit cannot be more than %d, please narrow the time range instead", AuditMaxResults)
+	}
+	config, err := getConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	search, err := getAuditSearch(query, config.AuditLogRetention.TimeDuration(), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if search == nil {
+		// time range is entirely outside of the retention period
+		return []ormapi.AuditResponse{}, nil
+	}
+
+	// get all orgs user can view
+	allowedOrgs, err := enforcer.GetAuthorizedOrgs(ctx, username, ResourceUsers, ActionView)
+	if err != nil {
+		return nil, err
+	}
+	if len(allowedOrgs) == 0 {
+		return nil, echo.ErrForbidden
+	}
+	_, isAdmin := allowedOrgs[""]
+	if !isAdmin {
+		// non-admin, enforce allowed orgs in search
+		for k, _ := range allowedOrgs {
+			search.AllowedOrgs = append(search.AllowedOrgs, k)
+		}
+	}
+
+	events, err := nodeMgr.ShowEvents(ctx, search)
+	if err != nil {
+		return nil, ormutil.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if !isAdmin {
+		orgs, err := GetAllOrgs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		events = filterEvents(events, orgs)
+	}
+	audits := []ormapi.AuditResponse{}
+	for _, event := range events {
+		audits = append(audits, getAuditResponse(&event))
+	}
+	return audits, nil
+}
+
+// Convert the audit query into an event search. The time range is
+// limited by the retention period. Returns nil if there is nothing
+// to search for within the retention period.
+func getAuditSearch(query *ormapi.AuditQuery, retention time.Duration, now time.Time) (*node.EventSearch, error) {
+	search := node.EventSearch{
+		Match: node.EventMatch{
+			Types: []string{node.AuditType},
+			Tags:  make(map[string]string),
+		},
+		TimeRange: query.TimeRange,
+		From:      query.From,
+		Limit:     query.Limit,
+	}
+	if err := search.TimeRange.Resolve(48 * time.Hour); err != nil {
+		return nil, err
+	}
+	oldest := now.Add(-retention)
+	if search.TimeRange.EndTime.Before(oldest) {
+		return nil, nil
+	}
+	if search.TimeRange.StartTime.Before(oldest) {
+		search.TimeRange.StartTime = oldest
+	}
+	for k, v := range query.Tags {
+		search.Match.Tags[k] = v
+	}
+	if query.Username != "" {
+		search.Match.Tags[auditTagUsername] = query.Username
+	}
+	if query.ClientIP != "" {
+		search.Match.Tags[auditTagClientIP] = query.ClientIP
+	}
+	if query.Status != 0 {
+		search.Match.Tags[auditTagStatus] = strconv.Itoa(query.Status)
+	}
+	if query.Org != "" {
+		search.Match.Orgs = []string{query.Org}
+	}
+	if query.Operation != "" {
+		search.Match.Names = []string{query.Operation}
+	}
+	return &search, nil
+}
+
+func getAuditResponse(event *node.EventData) ormapi.AuditResponse {
+	audit := ormapi.AuditResponse{
+		OperationName: event.Name,
+		Error:         event.Error,
+		Tags:          make(map[string]string),
+	}
+	audit.StartTime.FromTime(event.Timestamp)
+	if len(event.Org) > 0 {
+		audit.Org = event.Org[0]
+	}
+	for k, v := range event.Mtags {
+		switch k {
+		case auditTagUsername:
+			audit.Username = v
+		case auditTagClientIP:
+			audit.ClientIP = v
+		case auditTagStatus:
+			audit.Status, _ = strconv.Atoi(v)
+		case auditTagDuration:
+			if dur, err := time.ParseDuration(v); err == nil {
+				audit.Duration = ormapi.DurationMicroseconds(dur.Microseconds())
+			}
+		case auditTagRequest:
+			audit.Request = v
+		case auditTagResponse:
+			audit.Response = v
+		case auditTagTraceID:
+			audit.TraceID = v
+		default:
+			audit.Tags[k] = v
+		}
+	}
+	return audit
+}
+
+func writeAuditCSV(audits []ormapi.AuditResponse, out *bytes.Buffer) error {
+	w := csv.NewWriter(out)
+	if err := w.Write(AuditExportHeader); err != nil {
+		return err
+	}
+	for _, audit := range audits {
+		sec := int64(audit.StartTime) / ormapi.Microsecond
+		nsec := (int64(audit.StartTime) % ormapi.Microsecond) * 1000
+		row := []string{
+			time.Unix(sec, nsec).UTC().Format(time.RFC3339Nano),
+			audit.OperationName,
+			audit.Username,
+			audit.Org,
+			audit.ClientIP,
+			strconv.Itoa(audit.Status),
+			time.Duration(audit.Duration * 1000).String(),
+			audit.Request,
+			audit.Response,
+			audit.Error,
+			audit.TraceID,
+		}
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
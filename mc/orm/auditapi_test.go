@@ -0,0 +1,130 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+	"github.com/mobiledgex/edge-cloud/cloudcommon/node"
+	edgeproto "github.com/mobiledgex/edge-cloud/edgeproto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAuditSearch(t *testing.T) {
+	now := time.Now()
+	retention := 24 * time.Hour
+
+	query := ormapi.AuditQuery{
+		Username:  "user1",
+		Org:       "org1",
+		Operation: "/api/v1/auth/ctrl/CreateApp",
+		Status:    400,
+		ClientIP:  "10.0.0.1",
+		Tags: map[string]string{
+			"region": "local",
+		},
+		From:  10,
+		Limit: 5,
+		TimeRange: edgeproto.TimeRange{
+			StartTime: now.Add(-2 * time.Hour),
+			EndTime:   now,
+		},
+	}
+	search, err := getAuditSearch(&query, retention, now)
+	require.Nil(t, err)
+	require.NotNil(t, search)
+	require.Equal(t, []string{node.AuditType}, search.Match.Types)
+	require.Equal(t, []string{"org1"}, search.Match.Orgs)
+	require.Equal(t, []string{"/api/v1/auth/ctrl/CreateApp"}, search.Match.Names)
+	require.Equal(t, map[string]string{
+		"region":    "local",
+		"username":  "user1",
+		"status":    "400",
+		"remote-ip": "10.0.0.1",
+	}, search.Match.Tags)
+	require.Equal(t, 10, search.From)
+	require.Equal(t, 5, search.Limit)
+	require.Equal(t, query.TimeRange.StartTime, search.TimeRange.StartTime)
+
+	// start time is limited by the retention
+	query.TimeRange.StartTime = now.Add(-48 * time.Hour)
+	search, err = getAuditSearch(&query, retention, now)
+	require.Nil(t, err)
+	require.NotNil(t, search)
+	require.Equal(t, now.Add(-retention), search.TimeRange.StartTime)
+	require.Equal(t, now, search.TimeRange.EndTime)
+
+	// nothing to search for outside of the retention
+	query.TimeRange.EndTime = now.Add(-36 * time.Hour)
+	search, err = getAuditSearch(&query, retention, now)
+	require.Nil(t, err)
+	require.Nil(t, search)
+}
+
+func TestGetAuditRetentionQuery(t *testing.T) {
+	oldest := time.Date(2022, 3, 4, 5, 6, 7, 0, time.FixedZone("PST", -8*60*60))
+	body, err := getAuditRetentionQuery(oldest)
+	require.Nil(t, err)
+	expected := `{"query":{"bool":{"filter":[{"term":{"type":"` + node.AuditType + `"}},{"range":{"timestamp":{"lt":"2022-03-04T13:06:07Z"}}}]}}}`
+	require.Equal(t, expected, string(body))
+}
+
+func TestAuditResponse(t *testing.T) {
+	ts := time.Date(2022, 3, 4, 5, 6, 7, 8000, time.UTC)
+	event := node.EventData{
+		Name:      "/api/v1/auth/ctrl/CreateApp",
+		Org:       []string{"org1"},
+		Type:      node.AuditType,
+		Timestamp: ts,
+		Error:     "App already exists",
+		Mtags: map[string]string{
+			"username":  "user1",
+			"remote-ip": "10.0.0.1",
+			"status":    "400",
+			"duration":  "1.5s",
+			"request":   `{"region":"local"}`,
+			"response":  `{"message":"App already exists"}`,
+			"traceid":   "abc123",
+			"region":    "local",
+		},
+	}
+	audit := getAuditResponse(&event)
+	require.Equal(t, event.Name, audit.OperationName)
+	require.Equal(t, "org1", audit.Org)
+	require.Equal(t, "user1", audit.Username)
+	require.Equal(t, "10.0.0.1", audit.ClientIP)
+	require.Equal(t, 400, audit.Status)
+	require.Equal(t, ormapi.DurationMicroseconds(1500000), audit.Duration)
+	require.Equal(t, `{"region":"local"}`, audit.Request)
+	require.Equal(t, `{"message":"App already exists"}`, audit.Response)
+	require.Equal(t, "App already exists", audit.Error)
+	require.Equal(t, "abc123", audit.TraceID)
+	require.Equal(t, map[string]string{"region": "local"}, audit.Tags)
+	expTime := ormapi.TimeMicroseconds(0)
+	expTime.FromTime(ts)
+	require.Equal(t, expTime, audit.StartTime)
+
+	out := bytes.Buffer{}
+	err := writeAuditCSV([]ormapi.AuditResponse{audit}, &out)
+	require.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Equal(t, 2, len(lines))
+	require.Equal(t, strings.Join(AuditExportHeader, ","), lines[0])
+	require.Equal(t, `2022-03-04T05:06:07.000008Z,/api/v1/auth/ctrl/CreateApp,user1,org1,10.0.0.1,400,1.5s,"{""region"":""local""}","{""message"":""App already exists""}",App already exists,abc123`, lines[1])
+}
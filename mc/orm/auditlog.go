@@ -18,7 +18,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"regexp"
 	"strings"
 	"time"
 
@@ -35,8 +34,6 @@ import (
 
 var AuditId uint64
 
-var TokenStringRegex = regexp.MustCompile(`"token":"(.*?)"`)
-
 func logger(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		eventStart := time.Now()
@@ -58,6 +55,7 @@ func logger(next echo.HandlerFunc) echo.HandlerFunc {
 			strings.Contains(req.RequestURI, "/auth/metrics/") ||
 			strings.Contains(req.RequestURI, "/ctrl/Stream") ||
 			strings.Contains(req.RequestURI, "/auth/audit/") ||
+			strings.Contains(req.RequestURI, "/auth/graphql") ||
 			strings.Contains(req.RequestURI, "/auth/events/") ||
 			strings.Contains(req.RequestURI, "/auth/report/generate") ||
 			strings.Contains(req.RequestURI, "/auth/report/download") {
@@ -112,6 +110,12 @@ func logger(next echo.HandlerFunc) echo.HandlerFunc {
 		nexterr := next(ec)
 
 		span.SetTag("status", res.Status)
+		respCode := res.Status
+		if nexterr != nil && respCode == http.StatusOK {
+			// streaming error after the header was written
+			respCode, _ = getErrorResult(nexterr)
+		}
+		recordApiRequest(c.Path(), req.Method, respCode, time.Since(eventStart))
 
 		if lvl == log.SuppressLvl && (nexterr != nil || res.Status != http.StatusOK) && (!isShow || res.Status != http.StatusForbidden) {
 			// log if there was a failure for shows.
@@ -133,98 +137,8 @@ func logger(next echo.HandlerFunc) echo.HandlerFunc {
 			}
 		}
 
-		// remove passwords from requests so they aren't logged
-		if strings.Contains(req.RequestURI, "login") {
-			login := ormapi.UserLogin{}
-			err := json.Unmarshal(reqBody, &login)
-			if err == nil {
-				login.Password = ""
-				login.TOTP = ""
-				login.ApiKey = ""
-				reqBody, err = json.Marshal(login)
-			}
-			if err != nil {
-				reqBody = []byte{}
-			}
-		} else if strings.Contains(req.RequestURI, "usercreate") {
-			user := ormapi.CreateUser{}
-			err := json.Unmarshal(reqBody, &user)
-			if err == nil {
-				user.Passhash = ""
-				reqBody, err = json.Marshal(user)
-			}
-			if err != nil {
-				reqBody = []byte{}
-			}
-		} else if strings.Contains(req.RequestURI, "passwordreset") {
-			reset := ormapi.PasswordReset{}
-			err := json.Unmarshal(reqBody, &reset)
-			if err == nil {
-				reset.Password = ""
-				reqBody, err = json.Marshal(reset)
-			}
-			if err != nil {
-				reqBody = []byte{}
-			}
-		} else if strings.Contains(req.RequestURI, "user/newpass") {
-			newpass := ormapi.NewPassword{}
-			err := json.Unmarshal(reqBody, &newpass)
-			if err == nil {
-				newpass.Password = ""
-				reqBody, err = json.Marshal(newpass)
-			}
-			if err != nil {
-				reqBody = []byte{}
-			}
-		} else if strings.Contains(req.RequestURI, "/auth/ctrl/CreateCloudlet") ||
-			strings.Contains(req.RequestURI, "/auth/ctrl/UpdateCloudlet") {
-			regionCloudlet := ormapi.RegionCloudlet{}
-			err := json.Unmarshal(reqBody, &regionCloudlet)
-			if err == nil {
-				regionCloudlet.Cloudlet.AccessVars = nil
-				reqBody, err = json.Marshal(regionCloudlet)
-			}
-			if err != nil {
-				reqBody = []byte{}
-			}
-		} else if strings.Contains(req.RequestURI, "/auth/ctrl/CreateGPUDriver") ||
-			strings.Contains(req.RequestURI, "/auth/ctrl/UpdateGPUDriver") {
-			regionGPUDriver := ormapi.RegionGPUDriver{}
-			err := json.Unmarshal(reqBody, &regionGPUDriver)
-			if err == nil {
-				regionGPUDriver.GPUDriver.LicenseConfig = ""
-				for ii, _ := range regionGPUDriver.GPUDriver.Builds {
-					regionGPUDriver.GPUDriver.Builds[ii].DriverPathCreds = ""
-				}
-				reqBody, err = json.Marshal(regionGPUDriver)
-			}
-			if err != nil {
-				reqBody = []byte{}
-			}
-		} else if strings.Contains(req.RequestURI, "/auth/ctrl/AddGPUDriverBuild") {
-			regionMember := ormapi.RegionGPUDriverBuildMember{}
-			err := json.Unmarshal(reqBody, &regionMember)
-			if err == nil {
-				regionMember.GPUDriverBuildMember.Build.DriverPathCreds = ""
-				reqBody, err = json.Marshal(regionMember)
-			}
-			if err != nil {
-				reqBody = []byte{}
-			}
-		} else if strings.Contains(req.RequestURI, "/auth/federation/create") ||
-			strings.Contains(req.RequestURI, "/auth/federation/partner/setapikey") {
-			fedReq := ormapi.Federation{}
-			err := json.Unmarshal(reqBody, &fedReq)
-			if err == nil {
-				// do not log partner federator's API key
-				fedReq.ApiKey = ""
-				fedReq.ApiKeyHash = ""
-				reqBody, err = json.Marshal(fedReq)
-			}
-			if err != nil {
-				reqBody = []byte{}
-			}
-		}
+		// remove secrets from requests so they aren't logged
+		reqBody = redactAuditData(reqBody)
 		span.SetTag("request", string(reqBody))
 		eventErr := nexterr
 		if nexterr != nil {
@@ -240,60 +154,10 @@ func logger(next echo.HandlerFunc) echo.HandlerFunc {
 			// and may also contain sensitive data.
 			response = ""
 		} else if len(resBody) > 0 {
-			// for all responses, if it has a jwt token
-			// remove it before logging
-			if strings.Contains(string(resBody), "token") {
-				response = string(TokenStringRegex.ReplaceAll(resBody, []byte(`"token":""`)))
-			} else if strings.Contains(string(resBody), "TOTP") {
-				resp := ormapi.UserResponse{}
-				err := json.Unmarshal(resBody, &resp)
-				if err == nil {
-					resp.TOTPSharedKey = ""
-					resp.TOTPQRImage = nil
-					updatedResp, err := json.Marshal(&resp)
-					if err == nil {
-						response = string(updatedResp)
-					} else {
-						response = string(resBody)
-					}
-				} else {
-					response = string(resBody)
-				}
-			} else if strings.Contains(string(resBody), "ApiKey") {
-				if strings.Contains(req.RequestURI, "/user/create/apikey") {
-					resp := ormapi.CreateUserApiKey{}
-					err := json.Unmarshal(resBody, &resp)
-					if err == nil {
-						resp.ApiKey = ""
-						updatedResp, err := json.Marshal(&resp)
-						if err == nil {
-							response = string(updatedResp)
-						} else {
-							response = string(resBody)
-						}
-					} else {
-						response = string(resBody)
-					}
-				} else if strings.Contains(req.RequestURI, "/federator/self/generateapikey") ||
-					strings.Contains(req.RequestURI, "/federator/create") {
-					resp := ormapi.Federation{}
-					err := json.Unmarshal(resBody, &resp)
-					if err == nil {
-						resp.ApiKey = ""
-						updatedResp, err := json.Marshal(&resp)
-						if err == nil {
-							response = string(updatedResp)
-						} else {
-							response = string(resBody)
-						}
-					} else {
-						response = string(resBody)
-					}
-				}
-
-			} else {
-				response = string(resBody)
-			}
+			// remove secrets from responses, i.e. jwt tokens
+			response = string(redactAuditData(resBody))
+		} else if response != "" {
+			response = string(redactAuditData([]byte(response)))
 		}
 		span.SetTag("response", response)
 		if logaudit {
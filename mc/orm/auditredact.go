@@ -0,0 +1,151 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+)
+
+// Lower case JSON keys whose values are redacted from audit logs.
+// JSON unmarshaling is case-insensitive, so keys are matched
+// case-insensitively as well.
+var auditRedactKeys = getAuditRedactKeys()
+
+// Quickly checks if data may contain any redacted keys, to avoid
+// parsing data that does not need to be redacted. This also matches
+// keys in non-JSON data, like form encoded data.
+var auditRedactKeysRegex = getAuditRedactKeysRegex(auditRedactKeys)
+
+func getAuditRedactKeys() map[string]struct{} {
+	keys := make(map[string]struct{})
+	visited := make(map[reflect.Type]struct{})
+	for _, obj := range ormapi.AuditRedactTypes {
+		addAuditRedactKeys(reflect.TypeOf(obj), keys, visited)
+	}
+	for typ, fields := range ormapi.AuditRedactExternalFields {
+		for _, name := range fields {
+			field, ok := typ.FieldByName(name)
+			if !ok {
+				panic("no field " + name + " in " + typ.String())
+			}
+			addAuditRedactKey(&field, keys)
+		}
+	}
+	return keys
+}
+
+func addAuditRedactKeys(typ reflect.Type, keys map[string]struct{}, visited map[reflect.Type]struct{}) {
+	switch typ.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		addAuditRedactKeys(typ.Elem(), keys, visited)
+		return
+	case reflect.Struct:
+	default:
+		return
+	}
+	if _, found := visited[typ]; found {
+		return
+	}
+	visited[typ] = struct{}{}
+	for ii := 0; ii < typ.NumField(); ii++ {
+		field := typ.Field(ii)
+		if field.Tag.Get(ormapi.AuditTag) == ormapi.AuditTagRedact {
+			addAuditRedactKey(&field, keys)
+			continue
+		}
+		addAuditRedactKeys(field.Type, keys, visited)
+	}
+}
+
+// Both the field name and the JSON name are redacted, since
+// the data may come from either structs or cli args.
+func addAuditRedactKey(field *reflect.StructField, keys map[string]struct{}) {
+	keys[strings.ToLower(field.Name)] = struct{}{}
+	jsonName := strings.Split(field.Tag.Get("json"), ",")[0]
+	if jsonName != "" && jsonName != "-" {
+		keys[strings.ToLower(jsonName)] = struct{}{}
+	}
+}
+
+func getAuditRedactKeysRegex(keys map[string]struct{}) *regexp.Regexp {
+	names := []string{}
+	for key, _ := range keys {
+		names = append(names, regexp.QuoteMeta(key))
+	}
+	sort.Strings(names)
+	return regexp.MustCompile(`(?i)(` + strings.Join(names, "|") + `)`)
+}
+
+// Redact secrets from request or response data before it is
+// written to the audit log. Data may be a single JSON object or a
+// stream of JSON objects. Values of redacted keys are blanked out.
+// Data that may contain secrets but cannot be parsed is dropped.
+func redactAuditData(data []byte) []byte {
+	if !auditRedactKeysRegex.Match(data) {
+		return data
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	out := [][]byte{}
+	for {
+		var val interface{}
+		err := dec.Decode(&val)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// not valid JSON, so secrets cannot be found
+			return []byte{}
+		}
+		redactAuditValue(val)
+		buf := bytes.Buffer{}
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(val); err != nil {
+			return []byte{}
+		}
+		out = append(out, bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	}
+	return bytes.Join(out, []byte("\n"))
+}
+
+func redactAuditValue(val interface{}) {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		for key, sub := range v {
+			if _, found := auditRedactKeys[strings.ToLower(key)]; found {
+				if _, ok := sub.(string); ok {
+					v[key] = ""
+				} else {
+					v[key] = nil
+				}
+				continue
+			}
+			redactAuditValue(sub)
+		}
+	case []interface{}:
+		for _, sub := range v {
+			redactAuditValue(sub)
+		}
+	}
+}
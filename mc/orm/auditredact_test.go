@@ -0,0 +1,168 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mobiledgex/edge-cloud-infra/mc/mcctl/mctestclient"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+	"github.com/mobiledgex/edge-cloud/cloudcommon/node"
+	"github.com/mobiledgex/edge-cloud/cloudcommon/nodetest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactAuditData(t *testing.T) {
+	tests := []struct {
+		desc string
+		in   string
+		exp  string
+	}{{
+		"no secrets",
+		`{"Region":"local","App":{"key":{"name":"app1"}}}`,
+		`{"Region":"local","App":{"key":{"name":"app1"}}}`,
+	}, {
+		"login",
+		`{"username":"user1","password":"secret1","totp":"123456","apikeyid":"id1","apikey":"secret2"}`,
+		`{"apikey":"","apikeyid":"id1","password":"","totp":"","username":"user1"}`,
+	}, {
+		"case insensitive keys",
+		`{"Username":"user1","PassWord":"secret1"}`,
+		`{"PassWord":"","Username":"user1"}`,
+	}, {
+		"create user",
+		`{"Name":"user1","Email":"user1@example.com","Passhash":"secret1","verify":{"email":"user1@example.com"}}`,
+		`{"Email":"user1@example.com","Name":"user1","Passhash":"","verify":{"email":"user1@example.com"}}`,
+	}, {
+		"login token response",
+		`{"token":"secret1"}`,
+		`{"token":""}`,
+	}, {
+		"nested external object",
+		`{"Region":"local","Cloudlet":{"key":{"name":"c1"},"access_vars":{"OS_PASSWORD":"secret1"}}}`,
+		`{"Cloudlet":{"access_vars":null,"key":{"name":"c1"}},"Region":"local"}`,
+	}, {
+		"nested list",
+		`{"Region":"local","GPUDriver":{"license_config":"secret1","builds":[{"name":"b1","driver_path_creds":"secret2"}]}}`,
+		`{"GPUDriver":{"builds":[{"driver_path_creds":"","name":"b1"}],"license_config":""},"Region":"local"}`,
+	}, {
+		"alert receiver",
+		`{"Name":"r1","Type":"pagerduty","PagerDutyIntegrationKey":"secret1","WebhookHeaders":{"X-Auth":"secret2"}}`,
+		`{"Name":"r1","PagerDutyIntegrationKey":"","Type":"pagerduty","WebhookHeaders":null}`,
+	}, {
+		"stream",
+		`{"data":{"message":"ok"}}` + "\n" + `{"data":{"ApiKey":"secret1","Id":"id1"}}` + "\n",
+		`{"data":{"message":"ok"}}` + "\n" + `{"data":{"ApiKey":"","Id":"id1"}}`,
+	}, {
+		"html characters are not escaped",
+		`{"token":"secret1","desc":"a<b&c"}`,
+		`{"desc":"a<b&c","token":""}`,
+	}, {
+		"form data with secrets is dropped",
+		`username=user1&password=secret1`,
+		``,
+	}, {
+		"invalid json with secrets is dropped",
+		`{"password":"secret1"`,
+		``,
+	}}
+	for _, test := range tests {
+		out := redactAuditData([]byte(test.in))
+		require.Equal(t, test.exp, string(out), test.desc)
+		require.NotContains(t, string(out), "secret", test.desc)
+	}
+}
+
+// Make sure all fields tagged for redaction are found, which
+// requires their types to be registered.
+func TestAuditRedactTags(t *testing.T) {
+	registered := make(map[string]struct{})
+	for _, obj := range ormapi.AuditRedactTypes {
+		registered[reflect.TypeOf(obj).Name()] = struct{}{}
+	}
+
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, "../ormapi", nil, 0)
+	require.Nil(t, err)
+	numTagged := 0
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			ast.Inspect(file, func(n ast.Node) bool {
+				spec, ok := n.(*ast.TypeSpec)
+				if !ok {
+					return true
+				}
+				st, ok := spec.Type.(*ast.StructType)
+				if !ok {
+					return true
+				}
+				for _, field := range st.Fields.List {
+					if field.Tag == nil {
+						continue
+					}
+					tagStr, err := strconv.Unquote(field.Tag.Value)
+					require.Nil(t, err)
+					tag := reflect.StructTag(tagStr)
+					if tag.Get(ormapi.AuditTag) != ormapi.AuditTagRedact {
+						continue
+					}
+					numTagged++
+					_, found := registered[spec.Name.Name]
+					require.True(t, found, "type %s with redacted fields must be added to AuditRedactTypes", spec.Name.Name)
+					for _, name := range field.Names {
+						_, found := auditRedactKeys[strings.ToLower(name.Name)]
+						require.True(t, found, "%s.%s is redacted", spec.Name.Name, name.Name)
+					}
+				}
+				return true
+			})
+		}
+	}
+	require.True(t, numTagged > 0)
+	for _, key := range []string{"access_vars", "accessvars", "license_config", "driver_path_creds"} {
+		_, found := auditRedactKeys[key]
+		require.True(t, found, key)
+	}
+}
+
+// Check that secrets sent to MC do not reach the audit log
+func testAuditRedact(t *testing.T, mcClient *mctestclient.Client, uri, token string, de *nodetest.DummyEventsES) {
+	user, tokenUser, password := testCreateUser(t, mcClient, uri, "redactuser")
+
+	matches := de.WaitLastEventMatches(func(event *node.EventData) bool {
+		if event.Name != "/api/v1/login" || event.Type != node.AuditType {
+			return false
+		}
+		found := false
+		for _, etag := range event.Tags {
+			if strings.Contains(etag.Value, password) || strings.Contains(etag.Value, tokenUser) {
+				return false
+			}
+			if etag.Key == "request" && strings.Contains(etag.Value, user.Name) {
+				found = true
+			}
+		}
+		return found
+	})
+	require.True(t, matches, "login audit event without secrets")
+
+	testDeleteUser(t, mcClient, uri, token, user.Name)
+}
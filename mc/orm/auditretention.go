@@ -0,0 +1,112 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mobiledgex/edge-cloud/cloudcommon/node"
+	"github.com/mobiledgex/edge-cloud/log"
+)
+
+// Audit logs are stored as events, in the same indices as all other
+// events, so they cannot be aged out by an index lifecycle policy.
+// Instead, audit logs older than the AuditLogRetention config are
+// periodically deleted by query.
+
+var AuditRetentionCheckInterval = 1 * time.Hour
+
+// Indices the events framework writes events to
+var auditEventsIndexPattern = "events-log-*"
+
+// Get the delete-by-query request body for audit logs older than
+// the oldest time.
+func getAuditRetentionQuery(oldest time.Time) ([]byte, error) {
+	query := map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"filter": []interface{}{
+					map[string]interface{}{
+						"term": map[string]interface{}{
+							"type": node.AuditType,
+						},
+					},
+					map[string]interface{}{
+						"range": map[string]interface{}{
+							"timestamp": map[string]interface{}{
+								"lt": oldest.UTC().Format(time.RFC3339Nano),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	return json.Marshal(query)
+}
+
+func deleteExpiredAuditLogs(ctx context.Context, now time.Time) error {
+	if nodeMgr.ESClient == nil {
+		// no events storage
+		return nil
+	}
+	config, err := getConfig(ctx)
+	if err != nil {
+		return err
+	}
+	oldest := now.Add(-config.AuditLogRetention.TimeDuration())
+	body, err := getAuditRetentionQuery(oldest)
+	if err != nil {
+		return err
+	}
+	es := nodeMgr.ESClient
+	res, err := es.DeleteByQuery([]string{auditEventsIndexPattern}, bytes.NewReader(body),
+		es.DeleteByQuery.WithContext(ctx),
+		es.DeleteByQuery.WithConflicts("proceed"),
+	)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return fmt.Errorf("Failed to delete audit logs older than %s: %s", oldest.Format(time.RFC3339), res.String())
+	}
+	resp := struct {
+		Deleted int `json:"deleted"`
+	}{}
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		log.SpanLog(ctx, log.DebugLevelApi, "failed to decode audit log delete response", "err", err)
+	}
+	log.SpanLog(ctx, log.DebugLevelApi, "deleted expired audit logs", "oldest", oldest, "deleted", resp.Deleted)
+	return nil
+}
+
+// Start audit log retention thread to delete expired audit logs
+func RunAuditLogRetention() {
+	for {
+		span := log.StartSpan(log.DebugLevelInfo, "Audit log retention thread")
+		ctx := log.ContextWithSpan(context.Background(), span)
+		err := deleteExpiredAuditLogs(ctx, time.Now())
+		if err != nil {
+			log.SpanLog(ctx, log.DebugLevelInfo, "Failed to delete expired audit logs", "err", err)
+		}
+		span.Finish()
+		time.Sleep(AuditRetentionCheckInterval)
+	}
+}
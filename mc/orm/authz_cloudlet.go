@@ -87,6 +87,9 @@ func (s *AuthzCloudlet) populate(ctx context.Context, region, username, orgfilte
 		// no access to any orgs for given resource/action
 		return echo.ErrForbidden
 	}
+	if err := checkOrgSuspended(ctx, orgfilter, resource, action, s.admin); err != nil {
+		return err
+	}
 
 	if opts.requiresOrg != "" {
 		// edgeboxOnly check is not required for Show command
@@ -86,7 +86,7 @@ func authzUpdateCloudletPool(ctx context.Context, region, username string, pool
 	filter["region"] = region
 	filter["cloudlet_pool"] = pool.Key.Name
 	filter["cloudlet_pool_org"] = pool.Key.Organization
-	orgPools, err := showCloudletPoolAccessObj(ctx, username, filter, accessTypeGranted)
+	orgPools, err := showCloudletPoolAccessObj(ctx, username, filter, accessTypeGranted, nil)
 	if err != nil {
 		return err
 	}
@@ -73,7 +73,9 @@ func CollectBillingUsage(collectInterval time.Duration) {
 			}
 			// get usage from every region
 			for region, _ := range regions {
-				recordRegionUsage(ctx, region, prevCollectTime, nextCollectTime)
+				if recordRegionUsage(ctx, region, prevCollectTime, nextCollectTime) {
+					billingUsageCollected.Set(region, nextCollectTime)
+				}
 			}
 			prevCollectTime = nextCollectTime
 			nextCollectTime = getNextCollectTime(nextCollectTime, collectInterval)
@@ -82,7 +84,8 @@ func CollectBillingUsage(collectInterval time.Duration) {
 	}
 }
 
-func recordRegionUsage(ctx context.Context, region string, start, end time.Time) {
+// Returns true if usage was collected for the region
+func recordRegionUsage(ctx context.Context, region string, start, end time.Time) bool {
 	poolMap := make(map[string]string)
 	err := ctrlclient.ShowCloudletPoolStream(ctx, &ormutil.RegionContext{SkipAuthz: true, Region: region, Database: database}, &edgeproto.CloudletPool{}, connCache, nil, func(pool *edgeproto.CloudletPool) error {
 		for _, clKey := range pool.Cloudlets {
@@ -92,7 +95,7 @@ func recordRegionUsage(ctx context.Context, region string, start, end time.Time)
 	})
 	if err != nil {
 		log.SpanLog(ctx, log.DebugLevelInfo, "Unable to get cloudletpool list", "region", region, "err", err)
-		return
+		return false
 	}
 	rc := InfluxDBContext{region: region}
 	appIn := ormapi.RegionAppInstUsage{
@@ -106,12 +109,12 @@ func recordRegionUsage(ctx context.Context, region string, start, end time.Time)
 	eventResp, checkResp, err := GetEventAndCheckpoint(ctx, &rc, eventCmd, checkpointCmd)
 	if err != nil {
 		log.SpanLog(ctx, log.DebugLevelInfo, "Error gathering app usage for billing", "region", region, "err", err)
-		return
+		return false
 	}
 	appUsage, err := GetAppUsage(eventResp, checkResp, appIn.StartTime, appIn.EndTime, appIn.Region)
 	if err != nil {
 		log.SpanLog(ctx, log.DebugLevelInfo, "Error parsing app usage for billing", "region", region, "err", err)
-		return
+		return false
 	}
 	recordAppUsages(ctx, appUsage, poolMap, region)
 
@@ -125,14 +128,15 @@ func recordRegionUsage(ctx context.Context, region string, start, end time.Time)
 	eventResp, checkResp, err = GetEventAndCheckpoint(ctx, &rc, eventCmd, checkpointCmd)
 	if err != nil {
 		log.SpanLog(ctx, log.DebugLevelInfo, "Error gathering cluster usage for billing", "region", region, "err", err)
-		return
+		return false
 	}
 	clusterUsage, err := GetClusterUsage(ctx, eventResp, checkResp, clusterIn.StartTime, clusterIn.EndTime, clusterIn.Region)
 	if err != nil {
 		log.SpanLog(ctx, log.DebugLevelInfo, "Error parsing cluster usage for billing", "region", region, "err", err)
-		return
+		return false
 	}
 	recordClusterUsages(ctx, clusterUsage, poolMap, region)
+	return true
 }
 
 func recordAppUsages(ctx context.Context, usage *ormapi.MetricData, cloudletPoolMap map[string]string, region string) {
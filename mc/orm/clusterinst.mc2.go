@@ -59,6 +59,9 @@ func CreateClusterInst(c echo.Context) error {
 			return err
 		}
 	}
+	if err := checkOrgQuotaCreateClusterInst(ctx, rc, obj); err != nil {
+		return err
+	}
 	// Need access to database for federation handling
 	rc.Database = database
 
@@ -157,6 +160,9 @@ func UpdateClusterInst(c echo.Context) error {
 			return err
 		}
 	}
+	if err := checkOrgQuotaUpdateClusterInst(ctx, rc, obj); err != nil {
+		return err
+	}
 	// Need access to database for federation handling
 	rc.Database = database
 
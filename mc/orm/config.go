@@ -47,6 +47,10 @@ var defaultConfig = ormapi.Config{
 	UserLoginTokenValidDuration:   edgeproto.Duration(24 * time.Hour),
 	ApiKeyLoginTokenValidDuration: edgeproto.Duration(4 * time.Hour),
 	WebsocketTokenValidDuration:   edgeproto.Duration(2 * time.Minute),
+	RoleExpiryWarningDuration:     edgeproto.Duration(72 * time.Hour),
+	ApiKeyRotateGracePeriod:       edgeproto.Duration(24 * time.Hour),
+	ApiKeyStaleDuration:           edgeproto.Duration(90 * 24 * time.Hour),
+	AuditLogRetention:             edgeproto.Duration(90 * 24 * time.Hour),
 }
 
 func InitConfig(ctx context.Context) error {
@@ -120,6 +124,22 @@ func InitConfig(ctx context.Context) error {
 		config.WebsocketTokenValidDuration = defaultConfig.WebsocketTokenValidDuration
 		save = true
 	}
+	if config.RoleExpiryWarningDuration == 0 {
+		config.RoleExpiryWarningDuration = defaultConfig.RoleExpiryWarningDuration
+		save = true
+	}
+	if config.ApiKeyRotateGracePeriod == 0 {
+		config.ApiKeyRotateGracePeriod = defaultConfig.ApiKeyRotateGracePeriod
+		save = true
+	}
+	if config.ApiKeyStaleDuration == 0 {
+		config.ApiKeyStaleDuration = defaultConfig.ApiKeyStaleDuration
+		save = true
+	}
+	if config.AuditLogRetention == 0 {
+		config.AuditLogRetention = defaultConfig.AuditLogRetention
+		save = true
+	}
 	if config.NotifyEmailAddress == "" {
 		config.NotifyEmailAddress = defaultConfig.NotifyEmailAddress
 		save = true
@@ -195,6 +215,18 @@ func UpdateConfig(c echo.Context) error {
 		// avoid setting duration so low that we can't log in and change it back
 		return fmt.Errorf("User login token valid duration cannot be less than 3 minutes")
 	}
+	if config.RoleExpiryWarningDuration < 0 {
+		return fmt.Errorf("Role expiry warning duration cannot be negative")
+	}
+	if config.ApiKeyRotateGracePeriod < 0 {
+		return fmt.Errorf("API key rotate grace period cannot be negative")
+	}
+	if config.ApiKeyStaleDuration < 0 {
+		return fmt.Errorf("API key stale duration cannot be negative")
+	}
+	if config.AuditLogRetention.TimeDuration() < time.Hour {
+		return fmt.Errorf("Audit log retention cannot be less than 1 hour")
+	}
 
 	// Update RateLimitMgr settings
 	if config.DisableRateLimit != oldConfig.DisableRateLimit {
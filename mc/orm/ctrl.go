@@ -31,6 +31,7 @@ import (
 	"github.com/mobiledgex/edge-cloud/tls"
 	"github.com/mobiledgex/edge-cloud/util"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 )
 
 type ConnCache struct {
@@ -60,6 +61,7 @@ func (s *ConnCache) GetRegionConn(ctx context.Context, region string) (*grpc.Cli
 	if !found {
 		conn, err = connectController(ctx, region)
 		if err != nil {
+			controllerConnectFailuresTotal.WithLabelValues(region).Inc()
 			return nil, err
 		}
 		s.cache[region] = conn
@@ -97,6 +99,17 @@ func (s *ConnCache) Cleanup() {
 	}
 }
 
+// Get the connection states of cached regional connections
+func (s *ConnCache) GetRegionStates() map[string]connectivity.State {
+	s.Lock()
+	defer s.Unlock()
+	states := make(map[string]connectivity.State)
+	for region, conn := range s.cache {
+		states[region] = conn.GetState()
+	}
+	return states
+}
+
 func (s *ConnCache) DeleteRegion(region string) {
 	s.Lock()
 	defer s.Unlock()
@@ -452,6 +452,7 @@ func testControllerClientRun(t *testing.T, ctx context.Context, clientRun mctest
 	badPermTestEvents(t, mcClient, uri, tokenDev3, ctrl.Region, org1)
 	badPermTestCustomMetrics(t, mcClient, uri, tokenDev3, ctrl.Region, org1)
 	badPermTestAlertReceivers(t, mcClient, uri, tokenDev3, ctrl.Region, org1)
+	badPermTestAlertSilences(t, mcClient, uri, tokenDev3, ctrl.Region, org1)
 	// add new users to orgs
 	testAddUserRole(t, mcClient, uri, tokenDev, org1, "DeveloperContributor", dev3.Name, Success)
 	testAddUserRole(t, mcClient, uri, tokenDev, org1, "DeveloperViewer", dev4.Name, Success)
@@ -855,6 +856,10 @@ func testControllerClientRun(t *testing.T, ctx context.Context, clientRun mctest
 	goodPermTestAlertReceivers(t, mcClient, uri, tokenDev3, tokenOper3, ctrl.Region, org1, org3)
 	// test ability of different users to delete/show other users's receivers
 	userPermTestAlertReceivers(t, mcClient, uri, dev.Name, tokenDev, dev3.Name, tokenDev3, ctrl.Region, org1, org3)
+	// test alert silence permissions and validations
+	goodPermTestAlertSilences(t, mcClient, uri, tokenDev3, tokenOper3, ctrl.Region, org1, org3)
+	// test maintenance window scheduling and permissions
+	testMaintenanceWindows(t, ctx, mcClient, uri, tokenDev3, tokenOper3, ctrl.Region, org1, tc3)
 
 	{
 		// developers can't create AppInsts on other developer's ClusterInsts
@@ -1194,16 +1199,20 @@ func testControllerClientRun(t *testing.T, ctx context.Context, clientRun mctest
 	goodPermTestShowAppInst(t, mcClient, uri, tokenOper, ctrl.Region, org1, 1)
 	goodPermTestShowClusterInst(t, mcClient, uri, tokenOper, ctrl.Region, org1, 1)
 	goodPermTestShowApp(t, mcClient, uri, tokenOper, ctrl.Region, org1, dcnt)
+	testGraphQLShowInsts(t, mcClient, uri, tokenOper, ctrl.Region, org1, 1)
 	// developer deletes appinst/clusterinst
 	goodPermDeleteAppInst(t, mcClient, uri, tokenDev, ctrl.Region, org1, tc3)
 	goodPermDeleteClusterInst(t, mcClient, uri, tokenDev, ctrl.Region, org1, tc3)
 	// operator should see empty appinsts/clusterinsts
 	goodPermTestShowAppInst(t, mcClient, uri, tokenOper, ctrl.Region, org1, 0)
 	goodPermTestShowClusterInst(t, mcClient, uri, tokenOper, ctrl.Region, org1, 0)
+	testGraphQLShowInsts(t, mcClient, uri, tokenOper, ctrl.Region, org1, 0)
 	// operator should not able able to access appinsts/clusterinsts of developer who has not confirmed invitation
 	badPermTestShowAppInst(t, mcClient, uri, tokenOper, ctrl.Region, org2)
 	badPermTestShowClusterInst(t, mcClient, uri, tokenOper, ctrl.Region, org2)
 	badPermTestShowApp(t, mcClient, uri, tokenOper, ctrl.Region, org2)
+	// GraphQL filters out objects the operator cannot see
+	testGraphQLShowInsts(t, mcClient, uri, tokenOper, ctrl.Region, org2, 0)
 
 	// Cloudlet Pool access related tests
 	operatorGoodPermCloudletPoolGroup(t, mcClient, uri, ctrl.Region, tokenOper, org1, pool.CloudletPool.Key.Name, tc3)
@@ -1401,6 +1410,25 @@ func testControllerClientRun(t *testing.T, ctx context.Context, clientRun mctest
 	// test user api keys
 	testUserApiKeys(t, ctx, ds, &ctrl, count, mcClient, uri, token)
 
+	// test org quotas
+	testOrgQuotas(t, ctx, ds, ctrl.Region, mcClient, uri, token)
+
+	// test org suspension
+	testOrgSuspend(t, mcClient, uri, token, ctrl.Region)
+
+	// test audit log redaction
+	testAuditRedact(t, mcClient, uri, token, de)
+
+	// test declarative data apply, the cli cannot marshal AllData to args
+	if _, ok := mcClient.ClientRun.(*ormclient.Client); ok {
+		testDataApply(t, ctx, ds, ctrl.Region, mcClient, uri, token)
+	}
+
+	// test show paging
+	if restClient, ok := mcClient.ClientRun.(*ormclient.Client); ok {
+		testShowPaging(t, ctx, ds, ctrl.Region, restClient, mcClient, uri, token)
+	}
+
 	// delete cloudlet pool
 	_, status, err = mcClient.DeleteCloudletPool(uri, tokenOper, &pool)
 	require.Nil(t, err)
@@ -2925,6 +2953,8 @@ func testUserApiKeys(t *testing.T, ctx context.Context, ds *testutil.DummyServer
 	delta := claims.ExpiresAt - claims.IssuedAt
 	require.Equal(t, delta, int64((4 * time.Hour).Seconds()), "match short expiration time")
 
+	testUserApiKeyLifecycle(t, ctx, mcClient, uri, token, token1, apiKeyLoginToken, user1, resp)
+
 	// user should not be able to create/delete/show apikey
 	userApiKeyObj.Permissions = []ormapi.RolePerm{
 		ormapi.RolePerm{
@@ -3017,6 +3047,13 @@ func testUserApiKeys(t *testing.T, ctx context.Context, ds *testutil.DummyServer
 	}
 	require.False(t, found, "policy doesn't exist")
 
+	// token of a deleted api key cannot be refreshed
+	if restClient, ok := mcClient.ClientRun.(*ormclient.Client); ok {
+		status, err = restClient.PostJson(uri+"/auth/refresh", apiKeyLoginToken, nil, nil)
+		require.NotNil(t, err, "refresh deleted api key token")
+		require.Equal(t, http.StatusUnauthorized, status)
+	}
+
 	// create max api keys allowed for user
 	userApiKeyObj.Permissions = []ormapi.RolePerm{
 		ormapi.RolePerm{
@@ -3087,6 +3124,130 @@ func testUserApiKeys(t *testing.T, ctx context.Context, ds *testutil.DummyServer
 	testDeleteUser(t, mcClient, uri, token2, "user2")
 }
 
+func testUserApiKeyLifecycle(t *testing.T, ctx context.Context, mcClient *mctestclient.Client, uri, token, token1, apiKeyLoginToken string, user1 *ormapi.User, resp *ormapi.CreateUserApiKey) {
+	db := loggedDB(ctx)
+	apiKeyObj := ormapi.UserApiKey{Id: resp.Id}
+	updateApiKey := func(updates map[string]interface{}) {
+		err := db.Model(&apiKeyObj).Updates(updates).Error
+		require.Nil(t, err, "update api key")
+	}
+	apiKeyFilter := &ormapi.CreateUserApiKey{UserApiKey: ormapi.UserApiKey{Id: resp.Id}}
+
+	// login via api key records last used
+	apiKeys, status, err := mcClient.ShowUserApiKey(uri, token1, apiKeyFilter)
+	require.Nil(t, err, "show apikey")
+	require.Equal(t, http.StatusOK, status, "show apikey")
+	require.Equal(t, 1, len(apiKeys), "match api key count")
+	require.False(t, apiKeys[0].LastUsed.IsZero(), "last used set")
+	require.Equal(t, "127.0.0.1", apiKeys[0].LastUsedIP, "last used ip set")
+	require.True(t, apiKeys[0].ExpiresAt.IsZero(), "no expiry")
+
+	// expiry must be in the future
+	badKey := ormapi.CreateUserApiKey{
+		UserApiKey: ormapi.UserApiKey{
+			Org:         resp.Org,
+			Description: "expired",
+			ExpiresAt:   time.Now().Add(-time.Hour),
+		},
+		Permissions: []ormapi.RolePerm{{Action: "view", Resource: "cloudlets"}},
+	}
+	_, _, err = mcClient.CreateUserApiKey(uri, token1, &badKey)
+	require.NotNil(t, err, "create expired apikey")
+	require.Contains(t, err.Error(), "must be in the future", "err matches")
+
+	// login token should not outlive the api key
+	updateApiKey(map[string]interface{}{"expires_at": time.Now().Add(time.Hour)})
+	keyToken, _, err := mcClient.DoLogin(uri, NoUserName, NoPassword, NoOTP, resp.Id, resp.ApiKey)
+	require.Nil(t, err, "login using api key")
+	claims := UserClaims{}
+	_, err = Jwks.VerifyCookie(keyToken, &claims)
+	require.Nil(t, err, "parse token")
+	require.LessOrEqual(t, claims.ExpiresAt-claims.IssuedAt, int64(time.Hour.Seconds()), "token capped at key expiry")
+
+	// refreshed token should not outlive the api key either
+	restClient, isRestClient := mcClient.ClientRun.(*ormclient.Client)
+	refreshToken := func(token string) (string, int, error) {
+		res := make(map[string]string)
+		status, err := restClient.PostJson(uri+"/auth/refresh", token, nil, &res)
+		return res["token"], status, err
+	}
+	if isRestClient {
+		refreshedToken, status, err := refreshToken(keyToken)
+		require.Nil(t, err, "refresh api key token")
+		require.Equal(t, http.StatusOK, status)
+		claims = UserClaims{}
+		_, err = Jwks.VerifyCookie(refreshedToken, &claims)
+		require.Nil(t, err, "parse token")
+		require.LessOrEqual(t, claims.ExpiresAt-claims.IssuedAt, int64(time.Hour.Seconds()), "refreshed token capped at key expiry")
+	}
+
+	// expired api key cannot log in
+	updateApiKey(map[string]interface{}{"expires_at": time.Now().Add(-time.Minute)})
+	if isRestClient {
+		_, status, err = refreshToken(keyToken)
+		require.NotNil(t, err, "refresh expired api key token")
+		require.Equal(t, http.StatusUnauthorized, status)
+		require.Contains(t, err.Error(), "ApiKey has expired", "err matches")
+	}
+	_, _, err = mcClient.DoLogin(uri, NoUserName, NoPassword, NoOTP, resp.Id, resp.ApiKey)
+	require.NotNil(t, err, "login using expired api key")
+	require.Contains(t, err.Error(), "ApiKey has expired", "err matches")
+	_, _, err = mcClient.RotateUserApiKey(uri, token1, apiKeyFilter)
+	require.NotNil(t, err, "rotate expired api key")
+	require.Contains(t, err.Error(), "has expired", "err matches")
+	updateApiKey(map[string]interface{}{"expires_at": time.Time{}})
+
+	// only the owner can rotate, and not via api key auth
+	_, status, err = mcClient.RotateUserApiKey(uri, apiKeyLoginToken, apiKeyFilter)
+	require.NotNil(t, err, "rotate via apikey auth")
+	require.Equal(t, http.StatusForbidden, status, "rotate forbidden")
+	_, status, err = mcClient.RotateUserApiKey(uri, token, apiKeyFilter)
+	require.NotNil(t, err, "rotate other user's key")
+	require.Equal(t, http.StatusForbidden, status, "rotate forbidden")
+
+	// rotate keeps the id, old key still works during grace period
+	rotated, status, err := mcClient.RotateUserApiKey(uri, token1, apiKeyFilter)
+	require.Nil(t, err, "rotate apikey")
+	require.Equal(t, http.StatusOK, status, "rotate apikey")
+	require.Equal(t, resp.Id, rotated.Id, "same api key id")
+	require.NotEmpty(t, rotated.ApiKey, "new api key")
+	require.NotEqual(t, resp.ApiKey, rotated.ApiKey, "new api key")
+	require.True(t, rotated.PrevExpiresAt.After(time.Now().Add(23*time.Hour)), "grace period")
+	_, _, err = mcClient.DoLogin(uri, NoUserName, NoPassword, NoOTP, resp.Id, rotated.ApiKey)
+	require.Nil(t, err, "login using rotated api key")
+	_, _, err = mcClient.DoLogin(uri, NoUserName, NoPassword, NoOTP, resp.Id, resp.ApiKey)
+	require.Nil(t, err, "login using previous api key during grace period")
+
+	// old key stops working after the grace period
+	updateApiKey(map[string]interface{}{"prev_expires_at": time.Now().Add(-time.Minute)})
+	_, _, err = mcClient.DoLogin(uri, NoUserName, NoPassword, NoOTP, resp.Id, resp.ApiKey)
+	require.NotNil(t, err, "login using previous api key after grace period")
+	require.Contains(t, err.Error(), "Invalid ApiKey or ApiKeyId", "err matches")
+	_, _, err = mcClient.DoLogin(uri, NoUserName, NoPassword, NoOTP, resp.Id, rotated.ApiKey)
+	require.Nil(t, err, "login using rotated api key")
+	resp.ApiKey = rotated.ApiKey
+
+	// stale api key report is admin only
+	staleFilter := &ormapi.StaleApiKeyFilter{Username: user1.Name}
+	_, status, err = mcClient.ShowStaleUserApiKey(uri, token1, staleFilter)
+	require.NotNil(t, err, "show stale apikeys")
+	require.Equal(t, http.StatusForbidden, status, "show stale apikeys forbidden")
+	stale, status, err := mcClient.ShowStaleUserApiKey(uri, token, staleFilter)
+	require.Nil(t, err, "show stale apikeys")
+	require.Equal(t, http.StatusOK, status, "show stale apikeys")
+	require.Equal(t, 0, len(stale), "no stale apikeys")
+	updateApiKey(map[string]interface{}{"last_used": time.Now().Add(-100 * 24 * time.Hour)})
+	stale, _, err = mcClient.ShowStaleUserApiKey(uri, token, staleFilter)
+	require.Nil(t, err, "show stale apikeys")
+	require.Equal(t, 1, len(stale), "stale apikey")
+	require.Equal(t, resp.Id, stale[0].Id, "stale apikey id")
+	require.Equal(t, user1.Name, stale[0].Username, "stale apikey user")
+	staleFilter.UnusedDuration = edgeproto.Duration(200 * 24 * time.Hour)
+	stale, _, err = mcClient.ShowStaleUserApiKey(uri, token, staleFilter)
+	require.Nil(t, err, "show stale apikeys")
+	require.Equal(t, 0, len(stale), "no stale apikeys for longer duration")
+}
+
 // This is the old version of OrgCloudletPool, before type got added
 type OrgCloudletPool struct {
 	// Developer Organization
@@ -0,0 +1,362 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormutil"
+)
+
+// Custom roles are composed from a subset of the permissions of the
+// built-in manager role for the org type they may be assigned for.
+var customRoleBaseRoles = map[string]string{
+	OrgTypeDeveloper: RoleDeveloperManager,
+	OrgTypeOperator:  RoleOperatorManager,
+}
+
+func isBuiltinRole(role string) bool {
+	return isAdminRole(role) ||
+		isDeveloperRole(role) ||
+		isOperatorRole(role) ||
+		role == RoleBillingManager
+}
+
+// Returns nil if the role is not a custom role
+func getCustomRole(ctx context.Context, name string) (*ormapi.CustomRole, error) {
+	customRole := ormapi.CustomRole{}
+	db := loggedDB(ctx)
+	res := db.Where(&ormapi.CustomRole{Name: name}).First(&customRole)
+	if res.RecordNotFound() {
+		return nil, nil
+	}
+	if res.Error != nil {
+		return nil, ormutil.DbErr(res.Error)
+	}
+	// SQL lookup by name is case-insensitive, but roles
+	// in the RBAC policies are case-sensitive.
+	if customRole.Name != name {
+		return nil, nil
+	}
+	return &customRole, nil
+}
+
+// Get permissions of the given role from the RBAC policies
+func getRolePerms(role string) ([]ormapi.RolePerm, error) {
+	policies, err := enforcer.GetPolicy()
+	if err != nil {
+		return nil, ormutil.DbErr(err)
+	}
+	perms := []ormapi.RolePerm{}
+	for _, policy := range policies {
+		if len(policy) < 3 || policy[0] != role {
+			continue
+		}
+		perms = append(perms, ormapi.RolePerm{
+			Role:     policy[0],
+			Resource: policy[1],
+			Action:   policy[2],
+		})
+	}
+	sort.Slice(perms, func(i, j int) bool {
+		if perms[i].Resource == perms[j].Resource {
+			return perms[i].Action < perms[j].Action
+		}
+		return perms[i].Resource < perms[j].Resource
+	})
+	return perms, nil
+}
+
+// Validate the custom role's permissions and return the full set of
+// permissions to apply. A custom role may not exceed the permissions of
+// the base role for its org type, nor the permissions of its creator.
+func getCustomRolePerms(ctx context.Context, username string, customRole *ormapi.CustomRole) ([]ormapi.RolePerm, error) {
+	if len(customRole.Permissions) == 0 {
+		return nil, fmt.Errorf("No permissions specified")
+	}
+	baseRole, ok := customRoleBaseRoles[customRole.OrgType]
+	if !ok {
+		return nil, fmt.Errorf("Invalid org type %q, must be one of %s, %s", customRole.OrgType, OrgTypeDeveloper, OrgTypeOperator)
+	}
+	basePerms, err := getRolePerms(baseRole)
+	if err != nil {
+		return nil, err
+	}
+	validPerms := make(map[ormapi.RolePerm]struct{})
+	validPermStrs := []string{}
+	for _, perm := range basePerms {
+		validPerms[ormapi.RolePerm{Resource: perm.Resource, Action: perm.Action}] = struct{}{}
+		validPermStrs = append(validPermStrs, perm.Resource+":"+perm.Action)
+	}
+	creatorPerms, err := enforcer.GetPermissions(ctx, username, "")
+	if err != nil {
+		return nil, ormutil.DbErr(err)
+	}
+
+	permsMap := make(map[ormapi.RolePerm]struct{})
+	for _, perm := range customRole.Permissions {
+		if perm.Action != ActionView && perm.Action != ActionManage {
+			return nil, fmt.Errorf("Invalid action %s, valid actions are %s, %s", perm.Action, ActionView, ActionManage)
+		}
+		lookup := ormapi.RolePerm{
+			Resource: perm.Resource,
+			Action:   perm.Action,
+		}
+		if _, ok := validPerms[lookup]; !ok {
+			return nil, fmt.Errorf("Invalid permission specified: [%s:%s], valid permissions (resource:action) for %s roles are %v", perm.Resource, perm.Action, customRole.OrgType, validPermStrs)
+		}
+		if _, ok := creatorPerms[lookup]; !ok {
+			return nil, fmt.Errorf("Cannot grant permission [%s:%s] which the current user does not have", perm.Resource, perm.Action)
+		}
+		permsMap[lookup] = struct{}{}
+		if perm.Action == ActionManage {
+			// manage implies view
+			permsMap[ormapi.RolePerm{Resource: perm.Resource, Action: ActionView}] = struct{}{}
+		}
+	}
+	perms := []ormapi.RolePerm{}
+	for perm, _ := range permsMap {
+		perm.Role = customRole.Name
+		perms = append(perms, perm)
+	}
+	return perms, nil
+}
+
+// Replace the RBAC policies of the custom role with the given permissions
+func setCustomRolePerms(ctx context.Context, name string, perms []ormapi.RolePerm) error {
+	err := enforcer.RemovePolicy(ctx, name)
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	for _, perm := range perms {
+		addPolicy(ctx, &err, name, perm.Resource, perm.Action)
+	}
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	return nil
+}
+
+// Check if the custom role is assigned to any user
+func customRoleInUse(name string) (bool, error) {
+	groupings, err := enforcer.GetGroupingPolicy()
+	if err != nil {
+		return false, ormutil.DbErr(err)
+	}
+	for ii, _ := range groupings {
+		role := parseRole(groupings[ii])
+		if role != nil && role.Role == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func CreateCustomRole(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	in := ormapi.CustomRole{}
+	if err := c.Bind(&in); err != nil {
+		return ormutil.BindErr(err)
+	}
+	if err := authorized(ctx, claims.Username, "", ResourceUsers, ActionManage); err != nil {
+		return err
+	}
+	if in.Name == "" {
+		return fmt.Errorf("Name not specified")
+	}
+	if err := ValidName(in.Name); err != nil {
+		return err
+	}
+	if isBuiltinRole(in.Name) || isApiKeyRole(in.Name) {
+		return fmt.Errorf("Role name %s is reserved", in.Name)
+	}
+	existingPerms, err := getRolePerms(in.Name)
+	if err != nil {
+		return err
+	}
+	if len(existingPerms) > 0 {
+		return fmt.Errorf("Role %s already exists", in.Name)
+	}
+	perms, err := getCustomRolePerms(ctx, claims.Username, &in)
+	if err != nil {
+		return err
+	}
+	in.CreatedBy = claims.Username
+	in.Permissions = nil
+
+	db := loggedDB(ctx)
+	if err := db.Create(&in).Error; err != nil {
+		if strings.Contains(err.Error(), "pq: duplicate key value violates unique constraint") {
+			return fmt.Errorf("Role %s already exists", in.Name)
+		}
+		return ormutil.DbErr(err)
+	}
+	if err := setCustomRolePerms(ctx, in.Name, perms); err != nil {
+		// clean up, ignore errors
+		enforcer.RemovePolicy(ctx, in.Name)
+		db.Delete(&in)
+		return err
+	}
+	return c.JSON(http.StatusOK, ormutil.Msg("Custom role created"))
+}
+
+func UpdateCustomRole(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	// Pull json directly so we can unmarshal twice.
+	// First time is to do lookup, second time is to apply
+	// modified fields.
+	body, err := ioutil.ReadAll(c.Request().Body)
+	in := ormapi.CustomRole{}
+	err = BindJson(body, &in)
+	if err != nil {
+		return ormutil.BindErr(err)
+	}
+	if err := authorized(ctx, claims.Username, "", ResourceUsers, ActionManage); err != nil {
+		return err
+	}
+	if in.Name == "" {
+		return fmt.Errorf("Name not specified")
+	}
+	customRole, err := getCustomRole(ctx, in.Name)
+	if err != nil {
+		return err
+	}
+	if customRole == nil {
+		return fmt.Errorf("Custom role %s not found", in.Name)
+	}
+	oldRole := *customRole
+	err = BindJson(body, customRole)
+	if err != nil {
+		return ormutil.BindErr(err)
+	}
+	if customRole.OrgType != oldRole.OrgType {
+		return fmt.Errorf("Cannot change org type")
+	}
+	if customRole.CreatedBy != oldRole.CreatedBy {
+		return fmt.Errorf("Cannot change created by")
+	}
+	applyUpdate := false
+	if customRole.Description != oldRole.Description {
+		applyUpdate = true
+	}
+	var perms []ormapi.RolePerm
+	if len(customRole.Permissions) > 0 {
+		perms, err = getCustomRolePerms(ctx, claims.Username, customRole)
+		if err != nil {
+			return err
+		}
+		applyUpdate = true
+	}
+	if !applyUpdate {
+		return fmt.Errorf("Nothing to update")
+	}
+	customRole.Permissions = nil
+	db := loggedDB(ctx)
+	if err := db.Save(customRole).Error; err != nil {
+		return ormutil.DbErr(err)
+	}
+	if perms != nil {
+		if err := setCustomRolePerms(ctx, customRole.Name, perms); err != nil {
+			return err
+		}
+	}
+	return c.JSON(http.StatusOK, ormutil.Msg("Custom role updated"))
+}
+
+func DeleteCustomRole(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	in := ormapi.CustomRole{}
+	if err := c.Bind(&in); err != nil {
+		return ormutil.BindErr(err)
+	}
+	if err := authorized(ctx, claims.Username, "", ResourceUsers, ActionManage); err != nil {
+		return err
+	}
+	if in.Name == "" {
+		return fmt.Errorf("Name not specified")
+	}
+	customRole, err := getCustomRole(ctx, in.Name)
+	if err != nil {
+		return err
+	}
+	if customRole == nil {
+		return fmt.Errorf("Custom role %s not found", in.Name)
+	}
+	inUse, err := customRoleInUse(customRole.Name)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return fmt.Errorf("Custom role %s is assigned to users, please remove the role from all users first", customRole.Name)
+	}
+	if err := enforcer.RemovePolicy(ctx, customRole.Name); err != nil {
+		return ormutil.DbErr(err)
+	}
+	db := loggedDB(ctx)
+	if err := db.Delete(customRole).Error; err != nil {
+		return ormutil.DbErr(err)
+	}
+	return c.JSON(http.StatusOK, ormutil.Msg("Custom role deleted"))
+}
+
+// Custom roles are visible to all users, the same as the
+// built-in roles shown by ShowRolePerms.
+func ShowCustomRole(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	_, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	filter := ormapi.CustomRole{}
+	if c.Request().ContentLength > 0 {
+		if err := c.Bind(&filter); err != nil {
+			return ormutil.BindErr(err)
+		}
+	}
+	filter.Permissions = nil
+	customRoles := []ormapi.CustomRole{}
+	db := loggedDB(ctx)
+	err = db.Where(&filter).Find(&customRoles).Error
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	for ii, _ := range customRoles {
+		perms, err := getRolePerms(customRoles[ii].Name)
+		if err != nil {
+			return err
+		}
+		customRoles[ii].Permissions = perms
+	}
+	return c.JSON(http.StatusOK, customRoles)
+}
@@ -0,0 +1,179 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/mobiledgex/edge-cloud-infra/mc/mcctl/mctestclient"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+	"github.com/mobiledgex/edge-cloud/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func testCustomRoles(t *testing.T, ctx context.Context, mcClient *mctestclient.Client, uri, token string) {
+	user1, token1, _ := testCreateUser(t, mcClient, uri, "customroleuser1")
+	user2, token2, _ := testCreateUser(t, mcClient, uri, "customroleuser2")
+	org := testCreateOrg(t, mcClient, uri, token1, OrgTypeDeveloper, "CustomRoleOrg")
+
+	roleName := "AppInstRunner"
+	customRole := ormapi.CustomRole{
+		Name:        roleName,
+		OrgType:     OrgTypeDeveloper,
+		Description: "Manage AppInsts and view their metrics",
+		Permissions: []ormapi.RolePerm{
+			{Resource: ResourceAppInsts, Action: ActionManage},
+			{Resource: ResourceAppAnalytics, Action: ActionView},
+		},
+	}
+
+	// only admins can manage custom roles
+	status, err := mcClient.CreateCustomRole(uri, token1, &customRole)
+	require.NotNil(t, err)
+	require.Equal(t, http.StatusForbidden, status)
+
+	// validation
+	badRole := customRole
+	badRole.Name = RoleDeveloperViewer
+	_, err = mcClient.CreateCustomRole(uri, token, &badRole)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "is reserved")
+	badRole = customRole
+	badRole.OrgType = "foo"
+	_, err = mcClient.CreateCustomRole(uri, token, &badRole)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Invalid org type")
+	badRole = customRole
+	badRole.Permissions = []ormapi.RolePerm{
+		{Resource: ResourceCloudlets, Action: ActionManage},
+	}
+	_, err = mcClient.CreateCustomRole(uri, token, &badRole)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Invalid permission specified: [cloudlets:manage]")
+	badRole = customRole
+	badRole.Permissions = nil
+	_, err = mcClient.CreateCustomRole(uri, token, &badRole)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "No permissions specified")
+	// custom role cannot exceed creator's permissions
+	_, err = getCustomRolePerms(ctx, user1.Name, &customRole)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "which the current user does not have")
+
+	status, err = mcClient.CreateCustomRole(uri, token, &customRole)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	_, err = mcClient.CreateCustomRole(uri, token, &customRole)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "already exists")
+
+	// manage implies view
+	roles, status, err := mcClient.ShowCustomRole(uri, token1, &ormapi.CustomRole{})
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, 1, len(roles))
+	require.Equal(t, roleName, roles[0].Name)
+	require.Equal(t, DefaultSuperuser, roles[0].CreatedBy)
+	require.Equal(t, []ormapi.RolePerm{
+		{Role: roleName, Resource: ResourceAppAnalytics, Action: ActionView},
+		{Role: roleName, Resource: ResourceAppInsts, Action: ActionManage},
+		{Role: roleName, Resource: ResourceAppInsts, Action: ActionView},
+	}, roles[0].Permissions)
+
+	// custom role perms are shown with built-in role perms
+	showRolePerm := &cli.MapData{
+		Namespace: cli.StructNamespace,
+		Data: map[string]interface{}{
+			"Role": roleName,
+		},
+	}
+	policies, status, err := mcClient.ShowRolePerm(uri, token2, showRolePerm)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, 3, len(policies))
+
+	// custom role can only be assigned for its org type
+	operOrg := testCreateOrg(t, mcClient, uri, token1, OrgTypeOperator, "CustomRoleOperOrg")
+	_, err = mcClient.AddUserRole(uri, token1, &ormapi.Role{
+		Username: user2.Name,
+		Org:      operOrg.Name,
+		Role:     roleName,
+	})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Can only assign operator roles")
+	testDeleteOrg(t, mcClient, uri, token1, operOrg.Name)
+
+	// assign custom role
+	testAddUserRole(t, mcClient, uri, token1, org.Name, roleName, user2.Name, Success)
+	assignments, status, err := mcClient.ShowRoleAssignment(uri, token2, ClientNoShowFilter)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, 1, len(assignments))
+	require.Equal(t, roleName, assignments[0].Role)
+	require.Equal(t, org.Name, assignments[0].Org)
+	require.Nil(t, authorized(ctx, user2.Name, org.Name, ResourceAppInsts, ActionManage))
+	require.NotNil(t, authorized(ctx, user2.Name, org.Name, ResourceApps, ActionManage))
+	require.NotNil(t, authorized(ctx, user2.Name, org.Name, ResourceUsers, ActionView))
+
+	// update replaces permissions
+	update := &cli.MapData{
+		Namespace: cli.StructNamespace,
+		Data: map[string]interface{}{
+			"Name":    roleName,
+			"OrgType": OrgTypeOperator,
+		},
+	}
+	_, err = mcClient.UpdateCustomRole(uri, token, update)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Cannot change org type")
+	update.Data = map[string]interface{}{
+		"Name":        roleName,
+		"Description": "Restart AppInsts",
+		"Permissions": []map[string]interface{}{
+			{"Resource": ResourceAppInsts, "Action": ActionManage},
+		},
+	}
+	status, err = mcClient.UpdateCustomRole(uri, token, update)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	roles, _, err = mcClient.ShowCustomRole(uri, token, &ormapi.CustomRole{Name: roleName})
+	require.Nil(t, err)
+	require.Equal(t, 1, len(roles))
+	require.Equal(t, "Restart AppInsts", roles[0].Description)
+	require.Equal(t, 2, len(roles[0].Permissions))
+	require.NotNil(t, authorized(ctx, user2.Name, org.Name, ResourceAppAnalytics, ActionView))
+
+	// cannot delete while assigned
+	_, err = mcClient.DeleteCustomRole(uri, token, &ormapi.CustomRole{Name: roleName})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "is assigned to users")
+	testRemoveUserRole(t, mcClient, uri, token1, org.Name, roleName, user2.Name, Success)
+	status, err = mcClient.DeleteCustomRole(uri, token, &ormapi.CustomRole{Name: roleName})
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	roles, _, err = mcClient.ShowCustomRole(uri, token, &ormapi.CustomRole{})
+	require.Nil(t, err)
+	require.Equal(t, 0, len(roles))
+	policies, _, err = mcClient.ShowRolePerm(uri, token, showRolePerm)
+	require.Nil(t, err)
+	require.Equal(t, 0, len(policies))
+
+	// cleanup
+	testDeleteOrg(t, mcClient, uri, token1, org.Name)
+	testDeleteUser(t, mcClient, uri, token1, user1.Name)
+	testDeleteUser(t, mcClient, uri, token2, user2.Name)
+}
@@ -0,0 +1,691 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ctrlclient"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormutil"
+	"github.com/mobiledgex/edge-cloud/cli"
+	edgeproto "github.com/mobiledgex/edge-cloud/edgeproto"
+	"github.com/mobiledgex/edge-cloud/log"
+	"google.golang.org/grpc/status"
+)
+
+// The data apis take a declarative AllData manifest and converge the
+// regional objects in it to the state described by the manifest.
+// Only Flavors, ClusterInsts, Apps and AppInsts are supported.
+// Creates and updates are done in dependency order, deletes in reverse.
+// Deletes are limited to objects of the organizations referenced in
+// the manifest for the region, and to Flavors only if the manifest
+// specifies Flavors for the region.
+
+const (
+	DataActionCreate = "create"
+	DataActionUpdate = "update"
+	DataActionDelete = "delete"
+
+	dataModeDiff  = "diff"
+	dataModeApply = "apply"
+	dataModePrune = "prune"
+
+	dataTypeFlavor      = "Flavor"
+	dataTypeClusterInst = "ClusterInst"
+	dataTypeApp         = "App"
+	dataTypeAppInst     = "AppInst"
+)
+
+// Supported regional AllData sections, by json name
+var dataSections = []string{"flavors", "clusterinsts", "apps", "appinstances"}
+
+type dataChange struct {
+	ormapi.DataChange
+	run func() error
+}
+
+// State for computing the changes for a single region
+type dataRegion struct {
+	rc      *ormutil.RegionContext
+	data    *edgeproto.AllData
+	dataMap map[string]interface{}
+	orgs    map[string]struct{}
+	changes []*dataChange
+}
+
+func DiffData(c echo.Context) error {
+	return runDataApi(c, dataModeDiff)
+}
+
+func ApplyData(c echo.Context) error {
+	return runDataApi(c, dataModeApply)
+}
+
+func PruneData(c echo.Context) error {
+	return runDataApi(c, dataModePrune)
+}
+
+func runDataApi(c echo.Context, mode string) error {
+	ctx := ormutil.GetContext(c)
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	// Pull json directly so we can unmarshal twice.
+	// First time is to get the objects, second time is to
+	// find which fields of each object were specified.
+	body, err := ioutil.ReadAll(c.Request().Body)
+	if err != nil {
+		return ormutil.BindErr(err)
+	}
+	in := ormapi.AllData{}
+	err = BindJson(body, &in)
+	if err != nil {
+		return ormutil.BindErr(err)
+	}
+	dataMap := make(map[string]interface{})
+	err = json.Unmarshal(body, &dataMap)
+	if err != nil {
+		return ormutil.BindErr(err)
+	}
+	if err := validateDataManifest(&in, dataMap); err != nil {
+		return err
+	}
+
+	changes := []*dataChange{}
+	rdMaps := getDataMapList(getDataMapValue(dataMap, "regiondata"))
+	for ii := range in.RegionData {
+		rd := &dataRegion{
+			rc: &ormutil.RegionContext{
+				Region:   in.RegionData[ii].Region,
+				Username: claims.Username,
+				Database: database,
+			},
+			data:    &in.RegionData[ii].AppData,
+			dataMap: getDataMapObj(getDataMapValue(getDataMapObj(getDataMapIndex(rdMaps, ii)), "appdata")),
+			orgs:    make(map[string]struct{}),
+		}
+		if err := rd.getChanges(ctx); err != nil {
+			return fmt.Errorf("Region %s: %v", rd.rc.Region, err)
+		}
+		changes = append(changes, rd.changes...)
+	}
+
+	out := []ormapi.DataChange{}
+	for _, change := range changes {
+		isDelete := change.Action == DataActionDelete
+		if (mode == dataModeApply && isDelete) || (mode == dataModePrune && !isDelete) {
+			continue
+		}
+		if mode != dataModeDiff {
+			err := change.run()
+			if err != nil {
+				if st, ok := status.FromError(err); ok {
+					err = fmt.Errorf("%s", st.Message())
+				}
+				log.SpanLog(ctx, log.DebugLevelApi, "data change failed", "region", change.Region, "type", change.Type, "key", change.Key, "action", change.Action, "err", err)
+				change.Error = err.Error()
+			} else {
+				change.Done = true
+			}
+		}
+		out = append(out, change.DataChange)
+	}
+	return c.JSON(http.StatusOK, out)
+}
+
+func validateDataManifest(data *ormapi.AllData, dataMap map[string]interface{}) error {
+	for key, val := range dataMap {
+		if strings.ToLower(key) != "regiondata" && !isEmptyDataValue(val) {
+			return fmt.Errorf("Only regiondata is supported, but %s was specified", key)
+		}
+	}
+	supported := make(map[string]struct{})
+	for _, section := range dataSections {
+		supported[section] = struct{}{}
+	}
+	rdMaps := getDataMapList(getDataMapValue(dataMap, "regiondata"))
+	regions := make(map[string]struct{})
+	for ii, rd := range data.RegionData {
+		if rd.Region == "" {
+			return fmt.Errorf("Region not specified for regiondata %d", ii)
+		}
+		if _, found := regions[rd.Region]; found {
+			return fmt.Errorf("Region %s specified more than once", rd.Region)
+		}
+		regions[rd.Region] = struct{}{}
+		appDataMap := getDataMapObj(getDataMapValue(getDataMapObj(getDataMapIndex(rdMaps, ii)), "appdata"))
+		for key, val := range appDataMap {
+			if _, found := supported[strings.ToLower(key)]; !found && !isEmptyDataValue(val) {
+				return fmt.Errorf("Region %s appdata %s is not supported, only %s are supported", rd.Region, key, strings.Join(dataSections, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+func isEmptyDataValue(val interface{}) bool {
+	switch v := val.(type) {
+	case nil:
+		return true
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// Json allows case insensitive matching, so do the same for the map
+func getDataMapValue(m map[string]interface{}, key string) interface{} {
+	if m == nil {
+		return nil
+	}
+	if val, found := m[key]; found {
+		return val
+	}
+	for k, val := range m {
+		if strings.ToLower(k) == key {
+			return val
+		}
+	}
+	return nil
+}
+
+func getDataMapObj(val interface{}) map[string]interface{} {
+	m, _ := val.(map[string]interface{})
+	return m
+}
+
+func getDataMapList(val interface{}) []interface{} {
+	list, _ := val.([]interface{})
+	return list
+}
+
+func getDataMapIndex(list []interface{}, ii int) interface{} {
+	if ii < len(list) {
+		return list[ii]
+	}
+	return nil
+}
+
+// Get the fields of the object that were specified in the manifest
+func (s *dataRegion) getSpecifiedFields(section string, ii int, obj interface{}) []string {
+	list := getDataMapList(getDataMapValue(s.dataMap, section))
+	objMap := getDataMapObj(getDataMapIndex(list, ii))
+	if objMap == nil {
+		return []string{}
+	}
+	md := &cli.MapData{
+		Namespace: cli.JsonNamespace,
+		Data:      objMap,
+	}
+	return cli.GetSpecifiedFields(md, obj)
+}
+
+// Only specified fields that differ from the current object are updated
+func getDataUpdateFields(specified []string, diff map[string]struct{}) []string {
+	fields := []string{}
+	for _, field := range specified {
+		if _, found := diff[field]; found {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func (s *dataRegion) addChange(typ, key, action string, fields []string, run func() error) {
+	change := dataChange{
+		DataChange: ormapi.DataChange{
+			Region: s.rc.Region,
+			Type:   typ,
+			Key:    key,
+			Action: action,
+			Fields: fields,
+		},
+		run: run,
+	}
+	s.changes = append(s.changes, &change)
+}
+
+func (s *dataRegion) hasOrg(org string) bool {
+	_, found := s.orgs[org]
+	return found
+}
+
+func (s *dataRegion) getChanges(ctx context.Context) error {
+	for _, obj := range s.data.ClusterInsts {
+		s.orgs[obj.Key.Organization] = struct{}{}
+	}
+	for _, obj := range s.data.Apps {
+		s.orgs[obj.Key.Organization] = struct{}{}
+	}
+	for _, obj := range s.data.AppInstances {
+		s.orgs[obj.Key.AppKey.Organization] = struct{}{}
+	}
+
+	pruneFlavors, err := s.getFlavorChanges(ctx)
+	if err != nil {
+		return err
+	}
+	pruneClusterInsts, err := s.getClusterInstChanges(ctx)
+	if err != nil {
+		return err
+	}
+	pruneApps, err := s.getAppChanges(ctx)
+	if err != nil {
+		return err
+	}
+	pruneAppInsts, err := s.getAppInstChanges(ctx)
+	if err != nil {
+		return err
+	}
+	// deletes are done in reverse dependency order
+	for _, prune := range []func(){pruneAppInsts, pruneApps, pruneClusterInsts, pruneFlavors} {
+		prune()
+	}
+	return nil
+}
+
+// Adds create and update changes for Flavors, and returns a func
+// to add delete changes.
+func (s *dataRegion) getFlavorChanges(ctx context.Context) (func(), error) {
+	cur := make(map[edgeproto.FlavorKey]*edgeproto.Flavor)
+	keys := []edgeproto.FlavorKey{}
+	if len(s.data.Flavors) > 0 {
+		err := ctrlclient.ShowFlavorStream(ctx, s.rc, &edgeproto.Flavor{}, connCache, func(obj *edgeproto.Flavor) error {
+			cur[obj.Key] = obj
+			keys = append(keys, obj.Key)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	want := make(map[edgeproto.FlavorKey]struct{})
+	for ii := range s.data.Flavors {
+		obj := s.data.Flavors[ii]
+		if _, found := want[obj.Key]; found {
+			return nil, fmt.Errorf("Flavor %s specified more than once", obj.Key.GetKeyString())
+		}
+		want[obj.Key] = struct{}{}
+		old, found := cur[obj.Key]
+		if !found {
+			s.addChange(dataTypeFlavor, obj.Key.GetKeyString(), DataActionCreate, nil, func() error {
+				return s.createFlavor(ctx, &obj)
+			})
+			continue
+		}
+		diff := make(map[string]struct{})
+		obj.DiffFields(old, diff)
+		fields := getDataUpdateFields(s.getSpecifiedFields("flavors", ii, &obj), diff)
+		if len(fields) == 0 {
+			continue
+		}
+		obj.Fields = fields
+		s.addChange(dataTypeFlavor, obj.Key.GetKeyString(), DataActionUpdate, fields, func() error {
+			return s.updateFlavor(ctx, &obj)
+		})
+	}
+	prune := func() {
+		for _, key := range keys {
+			if _, found := want[key]; found {
+				continue
+			}
+			obj := edgeproto.Flavor{Key: key}
+			s.addChange(dataTypeFlavor, key.GetKeyString(), DataActionDelete, nil, func() error {
+				return s.deleteFlavor(ctx, &obj)
+			})
+		}
+	}
+	return prune, nil
+}
+
+// Adds create and update changes for ClusterInsts, and returns a func
+// to add delete changes.
+func (s *dataRegion) getClusterInstChanges(ctx context.Context) (func(), error) {
+	cur := make(map[edgeproto.ClusterInstKey]*edgeproto.ClusterInst)
+	keys := []edgeproto.ClusterInstKey{}
+	if len(s.orgs) > 0 {
+		authz, err := newShowClusterInstAuthz(ctx, s.rc.Region, s.rc.Username, ResourceClusterInsts, ActionView)
+		if err != nil {
+			return nil, err
+		}
+		err = ctrlclient.ShowClusterInstStream(ctx, s.rc, &edgeproto.ClusterInst{}, connCache, authz, func(obj *edgeproto.ClusterInst) error {
+			if !s.hasOrg(obj.Key.Organization) {
+				return nil
+			}
+			cur[obj.Key] = obj
+			// automatically created ClusterInsts are never pruned
+			if !obj.Auto {
+				keys = append(keys, obj.Key)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	want := make(map[edgeproto.ClusterInstKey]struct{})
+	for ii := range s.data.ClusterInsts {
+		obj := s.data.ClusterInsts[ii]
+		if _, found := want[obj.Key]; found {
+			return nil, fmt.Errorf("ClusterInst %s specified more than once", obj.Key.GetKeyString())
+		}
+		want[obj.Key] = struct{}{}
+		old, found := cur[obj.Key]
+		if !found {
+			s.addChange(dataTypeClusterInst, obj.Key.GetKeyString(), DataActionCreate, nil, func() error {
+				return s.createClusterInst(ctx, &obj)
+			})
+			continue
+		}
+		diff := make(map[string]struct{})
+		obj.DiffFields(old, diff)
+		fields := getDataUpdateFields(s.getSpecifiedFields("clusterinsts", ii, &obj), diff)
+		if len(fields) == 0 {
+			continue
+		}
+		obj.Fields = fields
+		s.addChange(dataTypeClusterInst, obj.Key.GetKeyString(), DataActionUpdate, fields, func() error {
+			return s.updateClusterInst(ctx, &obj)
+		})
+	}
+	prune := func() {
+		for _, key := range keys {
+			if _, found := want[key]; found {
+				continue
+			}
+			obj := edgeproto.ClusterInst{Key: key}
+			s.addChange(dataTypeClusterInst, key.GetKeyString(), DataActionDelete, nil, func() error {
+				return s.deleteClusterInst(ctx, &obj)
+			})
+		}
+	}
+	return prune, nil
+}
+
+// Adds create and update changes for Apps, and returns a func
+// to add delete changes.
+func (s *dataRegion) getAppChanges(ctx context.Context) (func(), error) {
+	cur := make(map[edgeproto.AppKey]*edgeproto.App)
+	keys := []edgeproto.AppKey{}
+	if len(s.orgs) > 0 {
+		authz, err := newShowAppAuthz(ctx, s.rc.Region, s.rc.Username, ResourceApps, ActionView)
+		if err != nil {
+			return nil, err
+		}
+		err = ctrlclient.ShowAppStream(ctx, s.rc, &edgeproto.App{}, connCache, authz, func(obj *edgeproto.App) error {
+			if !s.hasOrg(obj.Key.Organization) {
+				return nil
+			}
+			cur[obj.Key] = obj
+			keys = append(keys, obj.Key)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	want := make(map[edgeproto.AppKey]struct{})
+	for ii := range s.data.Apps {
+		obj := s.data.Apps[ii]
+		if _, found := want[obj.Key]; found {
+			return nil, fmt.Errorf("App %s specified more than once", obj.Key.GetKeyString())
+		}
+		want[obj.Key] = struct{}{}
+		old, found := cur[obj.Key]
+		if !found {
+			s.addChange(dataTypeApp, obj.Key.GetKeyString(), DataActionCreate, nil, func() error {
+				return s.createApp(ctx, &obj)
+			})
+			continue
+		}
+		diff := make(map[string]struct{})
+		obj.DiffFields(old, diff)
+		fields := getDataUpdateFields(s.getSpecifiedFields("apps", ii, &obj), diff)
+		if len(fields) == 0 {
+			continue
+		}
+		obj.Fields = fields
+		s.addChange(dataTypeApp, obj.Key.GetKeyString(), DataActionUpdate, fields, func() error {
+			return s.updateApp(ctx, &obj)
+		})
+	}
+	prune := func() {
+		for _, key := range keys {
+			if _, found := want[key]; found {
+				continue
+			}
+			obj := edgeproto.App{Key: key}
+			s.addChange(dataTypeApp, key.GetKeyString(), DataActionDelete, nil, func() error {
+				return s.deleteApp(ctx, &obj)
+			})
+		}
+	}
+	return prune, nil
+}
+
+// Adds create and update changes for AppInsts, and returns a func
+// to add delete changes.
+func (s *dataRegion) getAppInstChanges(ctx context.Context) (func(), error) {
+	cur := make(map[edgeproto.AppInstKey]*edgeproto.AppInst)
+	keys := []edgeproto.AppInstKey{}
+	if len(s.orgs) > 0 {
+		authz, err := newShowAppInstAuthz(ctx, s.rc.Region, s.rc.Username, ResourceAppInsts, ActionView)
+		if err != nil {
+			return nil, err
+		}
+		err = ctrlclient.ShowAppInstStream(ctx, s.rc, &edgeproto.AppInst{}, connCache, authz, func(obj *edgeproto.AppInst) error {
+			if !s.hasOrg(obj.Key.AppKey.Organization) {
+				return nil
+			}
+			cur[obj.Key] = obj
+			keys = append(keys, obj.Key)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	want := make(map[edgeproto.AppInstKey]struct{})
+	for ii := range s.data.AppInstances {
+		obj := s.data.AppInstances[ii]
+		if _, found := want[obj.Key]; found {
+			return nil, fmt.Errorf("AppInst %s specified more than once", obj.Key.GetKeyString())
+		}
+		want[obj.Key] = struct{}{}
+		old, found := cur[obj.Key]
+		if !found {
+			s.addChange(dataTypeAppInst, obj.Key.GetKeyString(), DataActionCreate, nil, func() error {
+				return s.createAppInst(ctx, &obj)
+			})
+			continue
+		}
+		diff := make(map[string]struct{})
+		obj.DiffFields(old, diff)
+		fields := getDataUpdateFields(s.getSpecifiedFields("appinstances", ii, &obj), diff)
+		if len(fields) == 0 {
+			continue
+		}
+		obj.Fields = fields
+		s.addChange(dataTypeAppInst, obj.Key.GetKeyString(), DataActionUpdate, fields, func() error {
+			return s.updateAppInst(ctx, &obj)
+		})
+	}
+	prune := func() {
+		for _, key := range keys {
+			if _, found := want[key]; found {
+				continue
+			}
+			obj := edgeproto.AppInst{Key: key}
+			s.addChange(dataTypeAppInst, key.GetKeyString(), DataActionDelete, nil, func() error {
+				return s.deleteAppInst(ctx, &obj)
+			})
+		}
+	}
+	return prune, nil
+}
+
+// The following functions run the same checks as the corresponding
+// regional api handlers.
+
+func ignoreDataResult(res *edgeproto.Result) error {
+	return nil
+}
+
+func (s *dataRegion) createFlavor(ctx context.Context, obj *edgeproto.Flavor) error {
+	if err := obj.IsValidArgsForCreateFlavor(); err != nil {
+		return err
+	}
+	if err := authorized(ctx, s.rc.Username, "", ResourceFlavors, ActionManage); err != nil {
+		return err
+	}
+	_, err := ctrlclient.CreateFlavorObj(ctx, s.rc, obj, connCache)
+	return err
+}
+
+func (s *dataRegion) updateFlavor(ctx context.Context, obj *edgeproto.Flavor) error {
+	if err := obj.IsValidArgsForUpdateFlavor(); err != nil {
+		return err
+	}
+	if err := authorized(ctx, s.rc.Username, "", ResourceFlavors, ActionManage); err != nil {
+		return err
+	}
+	_, err := ctrlclient.UpdateFlavorObj(ctx, s.rc, obj, connCache)
+	return err
+}
+
+func (s *dataRegion) deleteFlavor(ctx context.Context, obj *edgeproto.Flavor) error {
+	if err := obj.IsValidArgsForDeleteFlavor(); err != nil {
+		return err
+	}
+	if err := authorized(ctx, s.rc.Username, "", ResourceFlavors, ActionManage); err != nil {
+		return err
+	}
+	_, err := ctrlclient.DeleteFlavorObj(ctx, s.rc, obj, connCache)
+	return err
+}
+
+func (s *dataRegion) createClusterInst(ctx context.Context, obj *edgeproto.ClusterInst) error {
+	if err := obj.IsValidArgsForCreateClusterInst(); err != nil {
+		return err
+	}
+	if err := authzCreateClusterInst(ctx, s.rc.Region, s.rc.Username, obj, ResourceClusterInsts, ActionManage); err != nil {
+		return err
+	}
+	if err := checkOrgQuotaCreateClusterInst(ctx, s.rc, obj); err != nil {
+		return err
+	}
+	return ctrlclient.CreateClusterInstStream(ctx, s.rc, obj, connCache, ignoreDataResult)
+}
+
+func (s *dataRegion) updateClusterInst(ctx context.Context, obj *edgeproto.ClusterInst) error {
+	if err := obj.IsValidArgsForUpdateClusterInst(); err != nil {
+		return err
+	}
+	if err := authorized(ctx, s.rc.Username, obj.Key.Organization, ResourceClusterInsts, ActionManage); err != nil {
+		return err
+	}
+	if err := checkOrgQuotaUpdateClusterInst(ctx, s.rc, obj); err != nil {
+		return err
+	}
+	return ctrlclient.UpdateClusterInstStream(ctx, s.rc, obj, connCache, ignoreDataResult)
+}
+
+func (s *dataRegion) deleteClusterInst(ctx context.Context, obj *edgeproto.ClusterInst) error {
+	if err := obj.IsValidArgsForDeleteClusterInst(); err != nil {
+		return err
+	}
+	if err := authorized(ctx, s.rc.Username, obj.Key.Organization, ResourceClusterInsts, ActionManage); err != nil {
+		return err
+	}
+	return ctrlclient.DeleteClusterInstStream(ctx, s.rc, obj, connCache, ignoreDataResult)
+}
+
+func (s *dataRegion) createApp(ctx context.Context, obj *edgeproto.App) error {
+	if err := obj.IsValidArgsForCreateApp(); err != nil {
+		return err
+	}
+	if err := authzCreateApp(ctx, s.rc.Region, s.rc.Username, obj, ResourceApps, ActionManage); err != nil {
+		return err
+	}
+	_, err := ctrlclient.CreateAppObj(ctx, s.rc, obj, connCache)
+	return err
+}
+
+func (s *dataRegion) updateApp(ctx context.Context, obj *edgeproto.App) error {
+	if err := obj.IsValidArgsForUpdateApp(); err != nil {
+		return err
+	}
+	if err := authzUpdateApp(ctx, s.rc.Region, s.rc.Username, obj, ResourceApps, ActionManage); err != nil {
+		return err
+	}
+	_, err := ctrlclient.UpdateAppObj(ctx, s.rc, obj, connCache)
+	return err
+}
+
+func (s *dataRegion) deleteApp(ctx context.Context, obj *edgeproto.App) error {
+	if err := obj.IsValidArgsForDeleteApp(); err != nil {
+		return err
+	}
+	if err := authorized(ctx, s.rc.Username, obj.Key.Organization, ResourceApps, ActionManage); err != nil {
+		return err
+	}
+	_, err := ctrlclient.DeleteAppObj(ctx, s.rc, obj, connCache)
+	return err
+}
+
+func (s *dataRegion) createAppInst(ctx context.Context, obj *edgeproto.AppInst) error {
+	if err := obj.IsValidArgsForCreateAppInst(); err != nil {
+		return err
+	}
+	if err := authzCreateAppInst(ctx, s.rc.Region, s.rc.Username, obj, ResourceAppInsts, ActionManage); err != nil {
+		return err
+	}
+	if err := checkOrgQuotaCreateAppInst(ctx, s.rc, obj); err != nil {
+		return err
+	}
+	return ctrlclient.CreateAppInstStream(ctx, s.rc, obj, connCache, ignoreDataResult)
+}
+
+func (s *dataRegion) updateAppInst(ctx context.Context, obj *edgeproto.AppInst) error {
+	if err := obj.IsValidArgsForUpdateAppInst(); err != nil {
+		return err
+	}
+	if err := authorized(ctx, s.rc.Username, obj.Key.AppKey.Organization, ResourceAppInsts, ActionManage); err != nil {
+		return err
+	}
+	return ctrlclient.UpdateAppInstStream(ctx, s.rc, obj, connCache, ignoreDataResult)
+}
+
+func (s *dataRegion) deleteAppInst(ctx context.Context, obj *edgeproto.AppInst) error {
+	if err := obj.IsValidArgsForDeleteAppInst(); err != nil {
+		return err
+	}
+	if err := authorized(ctx, s.rc.Username, obj.Key.AppKey.Organization, ResourceAppInsts, ActionManage); err != nil {
+		return err
+	}
+	return ctrlclient.DeleteAppInstStream(ctx, s.rc, obj, connCache, ignoreDataResult)
+}
@@ -0,0 +1,253 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/mobiledgex/edge-cloud-infra/mc/mcctl/mctestclient"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+	"github.com/mobiledgex/edge-cloud/edgeproto"
+	"github.com/mobiledgex/edge-cloud/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateDataManifest(t *testing.T) {
+	validate := func(js string) error {
+		data := ormapi.AllData{}
+		err := json.Unmarshal([]byte(js), &data)
+		require.Nil(t, err)
+		dataMap := make(map[string]interface{})
+		err = json.Unmarshal([]byte(js), &dataMap)
+		require.Nil(t, err)
+		return validateDataManifest(&data, dataMap)
+	}
+	err := validate(`{"regiondata":[{"region":"local","appdata":{"flavors":[{"key":{"name":"x1.small"}}],"apps":[]}}]}`)
+	require.Nil(t, err)
+	err = validate(`{"RegionData":[{"region":"local","AppData":{"AppInstances":[{"key":{}}]}}],"orgs":[]}`)
+	require.Nil(t, err)
+
+	err = validate(`{"orgs":[{"name":"org1"}]}`)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Only regiondata is supported, but orgs was specified")
+	err = validate(`{"regiondata":[{"appdata":{}}]}`)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Region not specified for regiondata 0")
+	err = validate(`{"regiondata":[{"region":"local"},{"region":"local"}]}`)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Region local specified more than once")
+	err = validate(`{"regiondata":[{"region":"local","appdata":{"cloudlets":[{"key":{"name":"c1"}}]}}]}`)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Region local appdata cloudlets is not supported")
+
+	diff := map[string]struct{}{
+		edgeproto.AppFieldImagePath:   struct{}{},
+		edgeproto.AppFieldAccessPorts: struct{}{},
+	}
+	fields := getDataUpdateFields([]string{edgeproto.AppFieldImagePath, edgeproto.AppFieldDeployment}, diff)
+	require.Equal(t, []string{edgeproto.AppFieldImagePath}, fields)
+}
+
+func findDataChange(changes []ormapi.DataChange, typ, key, action string) *ormapi.DataChange {
+	for ii, change := range changes {
+		if change.Type == typ && change.Key == key && change.Action == action {
+			return &changes[ii]
+		}
+	}
+	return nil
+}
+
+func testDataApply(t *testing.T, ctx context.Context, ds *testutil.DummyServer, region string, mcClient *mctestclient.Client, uri, token string) {
+	user, tokenDev, _ := testCreateUser(t, mcClient, uri, "datauser")
+	org := testCreateOrg(t, mcClient, uri, tokenDev, OrgTypeDeveloper, "DataOrg")
+	operOrg := testCreateOrg(t, mcClient, uri, tokenDev, OrgTypeOperator, "DataOperOrg")
+
+	// current state
+	flavorA := edgeproto.Flavor{
+		Key:   edgeproto.FlavorKey{Name: "data-flavorA"},
+		Vcpus: 1,
+		Ram:   1024,
+		Disk:  10,
+	}
+	appA := edgeproto.App{
+		Key: edgeproto.AppKey{
+			Organization: org.Name,
+			Name:         "data-appA",
+			Version:      "1.0",
+		},
+		ImagePath: "docker.example.com/dataorg/appa:1.0",
+	}
+	appOld := edgeproto.App{
+		Key: edgeproto.AppKey{
+			Organization: org.Name,
+			Name:         "data-appOld",
+			Version:      "1.0",
+		},
+	}
+	cloudletKey := edgeproto.CloudletKey{
+		Organization: operOrg.Name,
+		Name:         "data-cloudlet",
+	}
+	autoCluster := edgeproto.ClusterInst{
+		Key: edgeproto.ClusterInstKey{
+			ClusterKey:   edgeproto.ClusterKey{Name: "autoclusterdata"},
+			CloudletKey:  cloudletKey,
+			Organization: org.Name,
+		},
+		Auto: true,
+	}
+	ds.FlavorCache.Update(ctx, &flavorA, 0)
+	ds.AppCache.Update(ctx, &appA, 0)
+	ds.AppCache.Update(ctx, &appOld, 0)
+	ds.ClusterInstCache.Update(ctx, &autoCluster, 0)
+
+	// desired state
+	flavorB := edgeproto.Flavor{
+		Key:   edgeproto.FlavorKey{Name: "data-flavorB"},
+		Vcpus: 2,
+		Ram:   2048,
+		Disk:  20,
+	}
+	clusterA := edgeproto.ClusterInst{
+		Key: edgeproto.ClusterInstKey{
+			ClusterKey:   edgeproto.ClusterKey{Name: "data-clusterA"},
+			CloudletKey:  cloudletKey,
+			Organization: org.Name,
+		},
+		Flavor:   flavorB.Key,
+		NumNodes: 1,
+	}
+	newAppA := appA
+	newAppA.ImagePath = "docker.example.com/dataorg/appa:2.0"
+	appInstA := edgeproto.AppInst{
+		Key: edgeproto.AppInstKey{
+			AppKey: appA.Key,
+			ClusterInstKey: edgeproto.VirtualClusterInstKey{
+				ClusterKey:   clusterA.Key.ClusterKey,
+				CloudletKey:  cloudletKey,
+				Organization: org.Name,
+			},
+		},
+	}
+	manifest := ormapi.AllData{
+		RegionData: []ormapi.RegionData{{
+			Region: region,
+			AppData: edgeproto.AllData{
+				Flavors:      []edgeproto.Flavor{flavorA, flavorB},
+				ClusterInsts: []edgeproto.ClusterInst{clusterA},
+				Apps:         []edgeproto.App{newAppA},
+				AppInstances: []edgeproto.AppInst{appInstA},
+			},
+		}},
+	}
+	expApply := []struct {
+		typ    string
+		key    string
+		action string
+	}{
+		{dataTypeFlavor, flavorB.Key.GetKeyString(), DataActionCreate},
+		{dataTypeClusterInst, clusterA.Key.GetKeyString(), DataActionCreate},
+		{dataTypeApp, appA.Key.GetKeyString(), DataActionUpdate},
+		{dataTypeAppInst, appInstA.Key.GetKeyString(), DataActionCreate},
+	}
+
+	// invalid manifests are rejected
+	badManifest := manifest
+	badManifest.Orgs = []ormapi.Organization{*org}
+	_, status, err := mcClient.DiffData(uri, token, &badManifest)
+	require.NotNil(t, err)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Contains(t, err.Error(), "Only regiondata is supported")
+
+	// diff shows all changes without running them
+	changes, status, err := mcClient.DiffData(uri, token, &manifest)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	for _, exp := range expApply {
+		change := findDataChange(changes, exp.typ, exp.key, exp.action)
+		require.NotNil(t, change, exp)
+		require.False(t, change.Done)
+	}
+	require.Nil(t, findDataChange(changes, dataTypeFlavor, flavorA.Key.GetKeyString(), DataActionUpdate))
+	update := findDataChange(changes, dataTypeApp, appA.Key.GetKeyString(), DataActionUpdate)
+	require.Equal(t, []string{edgeproto.AppFieldImagePath}, update.Fields)
+	require.NotNil(t, findDataChange(changes, dataTypeApp, appOld.Key.GetKeyString(), DataActionDelete))
+	require.Nil(t, findDataChange(changes, dataTypeClusterInst, autoCluster.Key.GetKeyString(), DataActionDelete))
+
+	// apply runs creates and updates in dependency order
+	changes, status, err = mcClient.ApplyData(uri, token, &manifest)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, len(expApply), len(changes))
+	for ii, exp := range expApply {
+		require.Equal(t, exp.typ, changes[ii].Type)
+		require.Equal(t, exp.key, changes[ii].Key)
+		require.Equal(t, exp.action, changes[ii].Action)
+		require.True(t, changes[ii].Done, changes[ii])
+		require.Equal(t, "", changes[ii].Error)
+	}
+
+	// prune only runs deletes
+	changes, status, err = mcClient.PruneData(uri, token, &manifest)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	for _, change := range changes {
+		require.Equal(t, DataActionDelete, change.Action)
+	}
+	change := findDataChange(changes, dataTypeApp, appOld.Key.GetKeyString(), DataActionDelete)
+	require.NotNil(t, change)
+	require.True(t, change.Done)
+
+	// once converged, applying again changes nothing
+	ds.FlavorCache.Update(ctx, &flavorB, 0)
+	ds.ClusterInstCache.Update(ctx, &clusterA, 0)
+	ds.AppCache.Update(ctx, &newAppA, 0)
+	ds.AppInstCache.Update(ctx, &appInstA, 0)
+	ds.AppCache.Delete(ctx, &appOld, 0)
+	changes, status, err = mcClient.ApplyData(uri, token, &manifest)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, 0, len(changes))
+
+	// changes are authorized per object
+	flavorC := edgeproto.Flavor{
+		Key:   edgeproto.FlavorKey{Name: "data-flavorC"},
+		Vcpus: 1,
+		Ram:   512,
+		Disk:  5,
+	}
+	manifest.RegionData[0].AppData.Flavors = append(manifest.RegionData[0].AppData.Flavors, flavorC)
+	changes, status, err = mcClient.ApplyData(uri, tokenDev, &manifest)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, 1, len(changes))
+	require.Equal(t, flavorC.Key.GetKeyString(), changes[0].Key)
+	require.False(t, changes[0].Done)
+	require.Contains(t, changes[0].Error, "Forbidden")
+
+	// cleanup
+	ds.AppInstCache.Delete(ctx, &appInstA, 0)
+	ds.AppCache.Delete(ctx, &newAppA, 0)
+	ds.ClusterInstCache.Delete(ctx, &clusterA, 0)
+	ds.ClusterInstCache.Delete(ctx, &autoCluster, 0)
+	ds.FlavorCache.Delete(ctx, &flavorB, 0)
+	ds.FlavorCache.Delete(ctx, &flavorA, 0)
+	testDeleteOrg(t, mcClient, uri, tokenDev, operOrg.Name)
+	testDeleteOrg(t, mcClient, uri, tokenDev, org.Name)
+	testDeleteUser(t, mcClient, uri, tokenDev, user.Name)
+}
@@ -0,0 +1,349 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	influxdb "github.com/influxdata/influxdb/client/v2"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ctrlclient"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormutil"
+	"github.com/mobiledgex/edge-cloud/cloudcommon"
+	dme "github.com/mobiledgex/edge-cloud/d-match-engine/dme-proto"
+	"github.com/mobiledgex/edge-cloud/edgeproto"
+	"github.com/mobiledgex/edge-cloud/log"
+)
+
+// Usage data columns shown in the developer report, in header order
+var (
+	appInstUsageReportColumns     = []string{"app", "version", "cluster", "cloudlet", "cloudletorg", "flavor", "startime", "endtime", "duration", "note"}
+	clusterInstUsageReportColumns = []string{"cluster", "cloudlet", "cloudletorg", "flavor", "numnodes", "startime", "endtime", "duration", "note"}
+)
+
+// App metrics for which peak values are reported, in header order
+var appMetricPeakColumns = []string{"cpu", "mem", "disk", "sendBytes", "recvBytes"}
+
+// Convert usage records to report rows with the given columns
+func getUsageReportRows(usage *ormapi.MetricData, columns []string, loc *time.Location) [][]string {
+	rows := [][]string{}
+	if usage == nil || len(usage.Series) == 0 {
+		return rows
+	}
+	series := usage.Series[0]
+	colIndex := make(map[string]int)
+	for ii, col := range series.Columns {
+		colIndex[col] = ii
+	}
+	for _, val := range series.Values {
+		row := []string{}
+		for _, col := range columns {
+			ii, ok := colIndex[col]
+			if !ok || ii >= len(val) || val[ii] == nil {
+				row = append(row, "")
+				continue
+			}
+			switch v := val[ii].(type) {
+			case time.Time:
+				row = append(row, v.In(loc).Format(ormapi.TimeFormatFullDateTime))
+			case time.Duration:
+				row = append(row, v.Round(time.Second).String())
+			default:
+				row = append(row, fmt.Sprintf("%v", v))
+			}
+		}
+		rows = append(rows, row)
+	}
+	// sort rows for consistent output
+	sort.SliceStable(rows, func(i, j int) bool {
+		ikey := strings.Join(rows[i], "|")
+		jkey := strings.Join(rows[j], "|")
+		return ikey < jkey
+	})
+	return rows
+}
+
+func GetDeveloperAppInstUsage(ctx context.Context, username string, report *ormapi.GenerateReport) ([][]string, error) {
+	in := ormapi.RegionAppInstUsage{
+		Region: report.Region,
+		AppInst: edgeproto.AppInstKey{
+			AppKey: edgeproto.AppKey{
+				Organization: report.Org,
+			},
+		},
+		StartTime: report.StartTime,
+		EndTime:   report.EndTime,
+	}
+	usage, err := GetAppInstUsageData(ctx, &UserClaims{Username: username}, &in)
+	if err != nil {
+		return nil, err
+	}
+	return getUsageReportRows(usage, appInstUsageReportColumns, report.StartTime.Location()), nil
+}
+
+func GetDeveloperClusterInstUsage(ctx context.Context, username string, report *ormapi.GenerateReport) ([][]string, error) {
+	in := ormapi.RegionClusterInstUsage{
+		Region: report.Region,
+		ClusterInst: edgeproto.ClusterInstKey{
+			Organization: report.Org,
+		},
+		StartTime: report.StartTime,
+		EndTime:   report.EndTime,
+	}
+	usage, err := GetClusterInstUsageData(ctx, &UserClaims{Username: username}, &in)
+	if err != nil {
+		return nil, err
+	}
+	return getUsageReportRows(usage, clusterInstUsageReportColumns, report.StartTime.Location()), nil
+}
+
+// Get peak cpu, memory, disk and network usage per AppInst
+func GetDeveloperAppMetricPeaks(ctx context.Context, username string, report *ormapi.GenerateReport) ([][]string, error) {
+	rc := &InfluxDBContext{}
+	dbNames := []string{cloudcommon.DeveloperMetricsDbName}
+	in := ormapi.RegionAppInstMetrics{
+		Region: report.Region,
+		AppInst: edgeproto.AppInstKey{
+			AppKey: edgeproto.AppKey{
+				Organization: report.Org,
+			},
+		},
+		Selector: "cpu,mem,disk,network",
+		MetricsCommon: ormapi.MetricsCommon{
+			TimeRange: edgeproto.TimeRange{
+				StartTime: report.StartTime,
+				EndTime:   report.EndTime,
+			},
+		},
+	}
+	rc.region = in.Region
+	cloudletList, err := checkPermissionsAndGetCloudletList(ctx, username, in.Region, []string{in.AppInst.AppKey.Organization},
+		ResourceAppAnalytics, []edgeproto.CloudletKey{in.AppInst.ClusterInstKey.CloudletKey})
+	if err != nil {
+		return nil, err
+	}
+	cmd := AppInstMetricsQuery(&in, cloudletList)
+
+	// AppInst identifier columns, in row order
+	keyColumns := []string{"app", "ver", "cluster", "cloudlet", "cloudletorg"}
+	peaks := make(map[string][]float64)
+	keys := make(map[string][]string)
+	err = influxStream(ctx, rc, dbNames, cmd, func(res interface{}) error {
+		results, ok := res.([]influxdb.Result)
+		if !ok {
+			return fmt.Errorf("result not expected type")
+		}
+		for _, result := range results {
+			for _, row := range result.Series {
+				colIndex := make(map[string]int)
+				for ii, col := range row.Columns {
+					colIndex[col] = ii
+				}
+				for _, val := range row.Values {
+					key := []string{}
+					for _, col := range keyColumns {
+						str := ""
+						if ii, ok := colIndex[col]; ok && ii < len(val) && val[ii] != nil {
+							str = fmt.Sprintf("%v", val[ii])
+						}
+						key = append(key, str)
+					}
+					id := strings.Join(key, "|")
+					if _, ok := peaks[id]; !ok {
+						peaks[id] = make([]float64, len(appMetricPeakColumns))
+						keys[id] = key
+					}
+					for jj, col := range appMetricPeakColumns {
+						ii, ok := colIndex[col]
+						if !ok || ii >= len(val) || val[ii] == nil {
+							continue
+						}
+						num, ok := val[ii].(json.Number)
+						if !ok {
+							continue
+						}
+						metricVal, err := num.Float64()
+						if err != nil {
+							log.SpanLog(ctx, log.DebugLevelInfo, "failed to parse metric value", "metric", col, "value", val[ii])
+							continue
+						}
+						if metricVal > peaks[id][jj] {
+							peaks[id][jj] = metricVal
+						}
+					}
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	for id, _ := range peaks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	rows := [][]string{}
+	for _, id := range ids {
+		row := append([]string{}, keys[id]...)
+		for jj, peak := range peaks[id] {
+			if appMetricPeakColumns[jj] == "cpu" {
+				row = append(row, strconv.FormatFloat(peak, 'f', 2, 64))
+			} else {
+				row = append(row, strconv.FormatFloat(peak, 'f', 0, 64))
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func GetDeveloperAppAlerts(ctx context.Context, username string, report *ormapi.GenerateReport) ([][]string, error) {
+	alertsData := [][]string{}
+	rc := &ormutil.RegionContext{
+		Region:   report.Region,
+		Username: username,
+		Database: database,
+	}
+	obj := &edgeproto.Alert{
+		Labels: map[string]string{
+			edgeproto.AppKeyTagOrganization: report.Org,
+			cloudcommon.AlertScopeTypeTag:   cloudcommon.AlertScopeApp,
+		},
+	}
+	err := ctrlclient.ShowAlertStream(ctx, rc, obj, connCache, nil, func(alert *edgeproto.Alert) error {
+		alertTime := dme.TimestampToTime(alert.ActiveAt).In(report.StartTime.Location())
+		if !inTimeSpan(report.StartTime, report.EndTime, alertTime) {
+			return nil
+		}
+		desc, ok := alert.Annotations[cloudcommon.AlertAnnotationDescription]
+		if !ok {
+			log.SpanLog(ctx, log.DebugLevelInfo, "missing description in alert annotations", "annotations", alert.Annotations)
+		}
+		entry := []string{
+			alertTime.Format(ormapi.TimeFormatDayDateTime),
+			alert.Labels[edgeproto.AppKeyTagName] + " | " + alert.Labels[edgeproto.AppKeyTagVersion],
+			alert.Labels[edgeproto.CloudletKeyTagName] + " | " + alert.Labels[edgeproto.CloudletKeyTagOrganization],
+			desc,
+			alert.State,
+		}
+		alertsData = append(alertsData, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(alertsData, func(i, j int) bool {
+		return alertsData[i][0] < alertsData[j][0]
+	})
+	return alertsData, nil
+}
+
+// Generate usage report for a developer org. Unlike the cloudlet report,
+// developer data is summarized per region rather than per cloudlet.
+func GenerateDeveloperReport(ctx context.Context, username string, regions []string, report *ormapi.GenerateReport, pdfOut *bytes.Buffer, dataOnly bool) (map[string]map[string]interface{}, error) {
+	// fetch logo path
+	logoPath := serverConfig.StaticDir + "/MobiledgeX_Logo.png"
+	if _, err := os.Stat(logoPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("Missing logo")
+	}
+	pdfReport, err := NewReport(report, "Developer")
+	if err != nil {
+		return nil, err
+	}
+	reportData := make(map[string]map[string]interface{})
+	for _, region := range regions {
+		report.Region = region
+
+		// Step-1: Gather all data
+		// -------------------------
+		appInstUsage, err := GetDeveloperAppInstUsage(ctx, username, report)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get app usage data: %v", err)
+		}
+		clusterInstUsage, err := GetDeveloperClusterInstUsage(ctx, username, report)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get cluster usage data: %v", err)
+		}
+		if len(appInstUsage) == 0 && len(clusterInstUsage) == 0 {
+			// Skip as developer has no deployments in this region
+			continue
+		}
+		metricPeaks, err := GetDeveloperAppMetricPeaks(ctx, username, report)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get app metrics data: %v", err)
+		}
+		alertsData, err := GetDeveloperAppAlerts(ctx, username, report)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get app alerts: %v", err)
+		}
+		reportData[region] = map[string]interface{}{
+			"appinstusage":     appInstUsage,
+			"clusterinstusage": clusterInstUsage,
+			"appmetricpeaks":   metricPeaks,
+			"appalerts":        alertsData,
+		}
+
+		log.SpanLog(ctx, log.DebugLevelInfo, "Generate developer report for region", "region", region)
+		// Step-2: Render data
+		// -------------------------
+		// start new page for every region
+		pdfReport.ResetHeader()
+		pdfReport.AddPage()
+
+		pdfReport.AddReportTitle(DeveloperReportTitle, logoPath)
+		pdfReport.AddHeader(report, logoPath, NoCloudlet)
+		pdfReport.AddFooter()
+		pdfReport.AddOrgInfo(report)
+		pdfReport.AddHorizontalLine()
+
+		if len(appInstUsage) > 0 {
+			columnsWidth := []float64{20, 12, 20, 20, 18, 20, 22, 22, 16, 14}
+			pdfReport.AddTable("App Instance Usage", AppInstUsageReportHeader, appInstUsage, columnsWidth)
+		}
+		if len(clusterInstUsage) > 0 {
+			columnsWidth := []float64{24, 24, 22, 22, 12, 24, 24, 18, 16}
+			pdfReport.AddTable("Cluster Instance Usage", ClusterInstUsageReportHeader, clusterInstUsage, columnsWidth)
+		}
+		if len(metricPeaks) > 0 {
+			columnsWidth := []float64{20, 12, 20, 20, 18, 14, 20, 20, 20, 20}
+			pdfReport.AddTable("App Instance Metric Peaks", AppMetricPeaksReportHeader, metricPeaks, columnsWidth)
+		}
+		if len(alertsData) > 0 {
+			columnsWidth := []float64{35, 35, 35, 60, 25}
+			pdfReport.AddTable("App Alerts", AppAlertsReportHeader, alertsData, columnsWidth)
+		}
+	}
+	if dataOnly {
+		return reportData, nil
+	}
+	if err = pdfReport.Err(); err != nil {
+		return nil, fmt.Errorf("failed to create PDF report: %s\n", err.Error())
+	}
+	err = pdfReport.Output(pdfOut)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get PDF output: %v", err)
+	}
+	return nil, nil
+}
@@ -44,8 +44,10 @@ var passwordResetNoneTmpl *template.Template
 var notifyTmpl *template.Template
 var welcomeTmpl *template.Template
 var addedTmpl *template.Template
+var inviteTmpl *template.Template
 var otpTmpl *template.Template
-var operatorReportTmpl *template.Template
+var reportTmpl *template.Template
+var digestTmpl *template.Template
 
 func init() {
 	passwordResetTmpl = template.Must(template.New("pwdreset").Parse(passwordResetT))
@@ -53,8 +55,10 @@ func init() {
 	notifyTmpl = template.Must(template.New("notify").Parse(notifyT))
 	welcomeTmpl = template.Must(template.New("welcome").Parse(welcomeT))
 	addedTmpl = template.Must(template.New("added").Parse(addedT))
+	inviteTmpl = template.Must(template.New("invite").Parse(inviteT))
 	otpTmpl = template.Must(template.New("otp").Parse(otpT))
-	operatorReportTmpl = template.Must(template.New("operatorreport").Parse(operatorReportT))
+	reportTmpl = template.Must(template.New("report").Parse(reportT))
+	digestTmpl = template.Must(template.New("digest").Parse(digestT))
 }
 
 type emailTmplArg struct {
@@ -370,6 +374,112 @@ func sendAddedEmail(ctx context.Context, admin, name, email, org, role string) e
 	return sendMailFunc(noreply, email, &buf)
 }
 
+// Claims of the token sent to users invited to join an organization.
+// The invitation itself is stored in the database so it can be revoked.
+// Claim names differ from EmailClaims so that invitation tokens cannot
+// be used to verify emails or reset passwords.
+type OrgInviteClaims struct {
+	jwt.StandardClaims
+	InviteId string `json:"inviteid"`
+	Org      string `json:"inviteorg"`
+	Email    string `json:"inviteemail"`
+	Role     string `json:"inviterole"`
+	Kid      int    `json:"kid"`
+}
+
+func (s *OrgInviteClaims) GetKid() (int, error) {
+	return s.Kid, nil
+}
+
+func (s *OrgInviteClaims) SetKid(kid int) {
+	s.Kid = kid
+}
+
+type inviteTmplArg struct {
+	From      string
+	Admin     string
+	Email     string
+	Org       string
+	Role      string
+	ExpiresAt string
+	Token     string
+	URL       string
+	MCAddr    string
+}
+
+var inviteT = `From: {{.From}}
+To: {{.Email}}
+Subject: Invitation to join {{.Org}}
+
+Hi,
+
+User {{.Admin}} has invited you ({{.Email}}) to join Organization {{.Org}} with role {{.Role}}. This invitation is valid until {{.ExpiresAt}}.
+
+{{ if .URL}}
+Click to accept: {{.URL}}
+{{ else}}
+If you do not have a MobiledgeX account yet, create one with this email address and the invitation token below. If you already have an account, copy and paste to accept the invitation:
+
+{{ if .MCAddr}}
+mcctl --addr {{.MCAddr}} role acceptinvite token={{.Token}}
+{{ else}}
+mcctl role acceptinvite token={{.Token}}
+{{- end}}
+{{- end}}
+
+If you are not expecting this invitation, please ignore this email.
+
+Thanks!
+MobiledgeX Team
+`
+
+// Email the invitation token to the invitee. If emails are not sent
+// because email verification is skipped, the token is returned instead
+// so that it can be passed to the invitee some other way.
+func sendInviteEmail(ctx context.Context, invite *ormapi.OrgInvitation) (string, error) {
+	claims := OrgInviteClaims{
+		StandardClaims: jwt.StandardClaims{
+			IssuedAt:  invite.CreatedAt.Unix(),
+			ExpiresAt: invite.ExpiresAt.Unix(),
+		},
+		InviteId: invite.Id,
+		Org:      invite.Org,
+		Email:    invite.Email,
+		Role:     invite.Role,
+	}
+	cookie, err := Jwks.GenerateCookie(&claims)
+	if err != nil {
+		return "", err
+	}
+	if getSkipVerifyEmail(ctx, nil) {
+		return cookie, nil
+	}
+	noreply, err := getNoreply(ctx)
+	if err != nil {
+		return "", err
+	}
+	arg := inviteTmplArg{
+		From:      noreply.Email,
+		Admin:     invite.InvitedBy,
+		Email:     invite.Email,
+		Org:       invite.Org,
+		Role:      invite.Role,
+		ExpiresAt: invite.ExpiresAt.UTC().Format(time.RFC1123),
+		Token:     cookie,
+		MCAddr:    serverConfig.PublicAddr,
+	}
+	if serverConfig.ConsoleAddr != "" && serverConfig.InviteConsolePath != "" {
+		arg.URL = serverConfig.ConsoleAddr + serverConfig.InviteConsolePath + "?token=" + cookie
+	}
+	buf := bytes.Buffer{}
+	if err := inviteTmpl.Execute(&buf, &arg); err != nil {
+		return "", err
+	}
+	log.SpanLog(ctx, log.DebugLevelApi, "send invite email",
+		"from", noreply.Email, "to", invite.Email)
+	return "", sendMailFunc(noreply, invite.Email, &buf)
+}
+
 func getSkipVerifyEmail(ctx context.Context, config *ormapi.Config) bool {
 	if serverConfig.SkipVerifyEmail {
 		return true
@@ -432,11 +542,13 @@ func sendOTPEmail(ctx context.Context, username, email, totp, totpExpTime string
 	return sendMailFunc(noreply, email, &buf)
 }
 
-type operatorReportTmplArg struct {
+type reportTmplArg struct {
 	From         string
 	To           string
 	Name         string
 	ReporterName string
+	ReportTitle  string
+	ReportScope  string
 	Org          string
 	StartDate    string
 	EndDate      string
@@ -446,11 +558,11 @@ type operatorReportTmplArg struct {
 	Timezone     string
 }
 
-var operatorReportT = `Content-Type: multipart/mixed; boundary="{{.Boundary}}"
+var reportT = `Content-Type: multipart/mixed; boundary="{{.Boundary}}"
 MIME-Version: 1.0
 From: {{.From}}
 To: {{.To}}
-Subject: [{{.ReporterName}}] Cloudlet Usage Report for {{.Org}} for the period {{.StartDate}} to {{.EndDate}} (Timezone: {{.Timezone}})
+Subject: [{{.ReporterName}}] {{.ReportTitle}} for {{.Org}} for the period {{.StartDate}} to {{.EndDate}} (Timezone: {{.Timezone}})
 
 --{{.Boundary}}
 Content-Type: text/plain; charset="utf-8"
@@ -459,7 +571,7 @@ Content-Transfer-Encoding: 7bit
 
 Hi {{.Name}},
 
-Please find the attached report generated for cloudlets part of {{.Org}} organization for the period {{.StartDate}} to {{.EndDate}}
+Please find the attached report generated for {{.ReportScope}} part of {{.Org}} organization for the period {{.StartDate}} to {{.EndDate}}
 
 This report was automatically generated by the configured reporter: {{.ReporterName}}
 If you did not request this report, please contact MobiledgeX support for assistance.
@@ -477,7 +589,7 @@ Content-Disposition: attachment; filename={{.FileName}}
 --{{.Boundary}}--
 `
 
-func sendOperatorReportEmail(ctx context.Context, username, email, reporterName string, report *ormapi.GenerateReport, pdfFileName string, pdfFileBytes []byte) error {
+func sendReportEmail(ctx context.Context, username, email, reporterName, orgType string, report *ormapi.GenerateReport, reportFileName string, reportFileBytes []byte) error {
 	if getSkipVerifyEmail(ctx, nil) {
 		return nil
 	}
@@ -488,28 +600,82 @@ func sendOperatorReportEmail(ctx context.Context, username, email, reporterName
 	writer := multipart.NewWriter(nil)
 	boundary := writer.Boundary()
 
-	attachment := base64.StdEncoding.EncodeToString(pdfFileBytes)
-	pdfFileName = strings.ReplaceAll(pdfFileName, "/", "_")
+	attachment := base64.StdEncoding.EncodeToString(reportFileBytes)
+	reportFileName = strings.ReplaceAll(reportFileName, "/", "_")
 
-	arg := operatorReportTmplArg{
+	reportScope := "cloudlets"
+	if orgType == OrgTypeDeveloper {
+		reportScope = "App and Cluster instances"
+	}
+	arg := reportTmplArg{
 		From:         noreply.Email,
 		To:           email,
 		Name:         username,
 		ReporterName: reporterName,
+		ReportTitle:  getReportTitle(orgType),
+		ReportScope:  reportScope,
 		Org:          report.Org,
 		StartDate:    report.StartTime.Format(ormapi.TimeFormatDate),
 		EndDate:      report.EndTime.Format(ormapi.TimeFormatDate),
 		Boundary:     boundary,
-		FileName:     pdfFileName,
+		FileName:     reportFileName,
 		Attachment:   attachment,
 		Timezone:     report.Timezone,
 	}
 	buf := bytes.Buffer{}
-	if err := operatorReportTmpl.Execute(&buf, &arg); err != nil {
+	if err := reportTmpl.Execute(&buf, &arg); err != nil {
 		return err
 	}
 
-	log.SpanLog(ctx, log.DebugLevelApi, "send operator report email",
-		"from", noreply.Email, "to", email, "report file", pdfFileName)
+	log.SpanLog(ctx, log.DebugLevelApi, "send report email",
+		"from", noreply.Email, "to", email, "report file", reportFileName)
 	return sendMailFunc(noreply, email, &buf)
 }
+
+type digestTmplArg struct {
+	From    string
+	To      string
+	Name    string
+	Subject string
+	Items   []ormapi.PendingNotification
+}
+
+var digestT = `From: {{.From}}
+To: {{.To}}
+Subject: {{.Subject}}
+
+Hi {{.Name}},
+
+Here is a summary of recent activity in your organizations:
+{{range .Items}}
+- [{{.Org}}] {{.Message}}
+{{- end}}
+
+You can change which notifications you receive and how they are delivered in your notification preferences.
+
+MobiledgeX Team
+`
+
+func sendDigestEmail(ctx context.Context, user *ormapi.User, subject string, items []ormapi.PendingNotification) error {
+	if getSkipVerifyEmail(ctx, nil) {
+		return nil
+	}
+	noreply, err := getNoreply(ctx)
+	if err != nil {
+		return err
+	}
+	arg := digestTmplArg{
+		From:    noreply.Email,
+		To:      user.Email,
+		Name:    user.Name,
+		Subject: subject,
+		Items:   items,
+	}
+	buf := bytes.Buffer{}
+	if err := digestTmpl.Execute(&buf, &arg); err != nil {
+		return err
+	}
+	log.SpanLog(ctx, log.DebugLevelApi, "send digest email",
+		"from", noreply.Email, "to", user.Email, "items", len(items))
+	return sendMailFunc(noreply, user.Email, &buf)
+}
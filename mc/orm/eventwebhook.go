@@ -0,0 +1,890 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormutil"
+	"github.com/mobiledgex/edge-cloud/cloudcommon/node"
+	"github.com/mobiledgex/edge-cloud/edgeproto"
+	"github.com/mobiledgex/edge-cloud/log"
+	"github.com/mobiledgex/edge-cloud/vault"
+)
+
+const (
+	EventWebhookDeliveryPending   = "Pending"
+	EventWebhookDeliveryDelivered = "Delivered"
+	EventWebhookDeliveryFailed    = "Failed"
+
+	EventWebhookSignatureHeader = "X-MC-Signature"
+	EventWebhookDeliveryHeader  = "X-MC-Delivery"
+	EventWebhookSignaturePrefix = "sha256="
+)
+
+var (
+	eventWebhookTrigger chan bool
+
+	EventWebhookCheckInterval = 30 * time.Second
+	// Events show up in search some time after they are written
+	EventWebhookIndexDelay = 10 * time.Second
+	// Max time span of events searched for a webhook in one pass
+	EventWebhookMaxSearchSpan = time.Hour
+	EventWebhookSearchLimit   = 500
+	EventWebhookMaxAttempts   = 8
+	EventWebhookRetryDelay    = 30 * time.Second
+	EventWebhookMaxRetryDelay = time.Hour
+	// How long delivery history is kept
+	EventWebhookHistoryRetention = 7 * 24 * time.Hour
+	// Max deliveries claimed by one MC in one pass, and how many
+	// are sent in parallel
+	EventWebhookDeliveryBatchSize   = 100
+	EventWebhookDeliveryConcurrency = 10
+	// Claimed deliveries are not retried by other MCs until the
+	// lease expires
+	EventWebhookDeliveryLease = 5 * time.Minute
+)
+
+// Allows webhooks to local addresses, for unit tests only
+var EventWebhookAllowLocalAddrs = false
+
+// Webhooks may not reach loopback, private, link-local (which includes
+// cloud metadata services), or other non-public addresses.
+var eventWebhookBlockedNets = func() []*net.IPNet {
+	cidrs := []string{
+		"0.0.0.0/8",
+		"10.0.0.0/8",
+		"100.64.0.0/10",
+		"127.0.0.0/8",
+		"169.254.0.0/16",
+		"172.16.0.0/12",
+		"192.0.0.0/24",
+		"192.168.0.0/16",
+		"198.18.0.0/15",
+		"224.0.0.0/4",
+		"240.0.0.0/4",
+		"::/128",
+		"::1/128",
+		"fc00::/7",
+		"fe80::/10",
+		"ff00::/8",
+	}
+	nets := []*net.IPNet{}
+	for _, cidr := range cidrs {
+		_, ipnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, ipnet)
+	}
+	return nets
+}()
+
+func checkEventWebhookIP(ip net.IP) error {
+	if EventWebhookAllowLocalAddrs {
+		return nil
+	}
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	for _, ipnet := range eventWebhookBlockedNets {
+		if ipnet.Contains(ip) {
+			return fmt.Errorf("Webhook address %s is not a public address", ip.String())
+		}
+	}
+	return nil
+}
+
+// Check the resolved address of each connection, so that DNS changes
+// or redirects after validation cannot reach internal addresses.
+func eventWebhookDialControl(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("Invalid webhook address %s", host)
+	}
+	return checkEventWebhookIP(ip)
+}
+
+var eventWebhookHttpClient = &http.Client{
+	Timeout: 15 * time.Second,
+	Transport: &http.Transport{
+		// no proxy, so that the dial check applies to the webhook itself
+		Proxy: nil,
+		DialContext: (&net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+			Control:   eventWebhookDialControl,
+		}).DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 15 * time.Second,
+		MaxIdleConnsPerHost:   2,
+		IdleConnTimeout:       90 * time.Second,
+	},
+}
+
+// Event posted to a webhook
+type eventWebhookPayload struct {
+	DeliveryId string         `json:"deliveryid"`
+	Webhook    string         `json:"webhook"`
+	Org        string         `json:"org"`
+	Event      node.EventData `json:"event"`
+}
+
+type eventWebhookCreds struct {
+	Secret string `json:"secret"`
+}
+
+func getEventWebhookVaultPath(org, name string) string {
+	return "/secret/data/accounts/eventwebhooks/" + org + "/" + name
+}
+
+func putEventWebhookSecretToVault(ctx context.Context, hook *ormapi.EventWebhook) error {
+	creds := eventWebhookCreds{
+		Secret: hook.Secret,
+	}
+	log.SpanLog(ctx, log.DebugLevelApi, "Storing event webhook secret in vault", "webhook", hook.Name, "org", hook.Org)
+	return vault.PutData(serverConfig.vaultConfig, getEventWebhookVaultPath(hook.Org, hook.Name), &creds)
+}
+
+func getEventWebhookSecretFromVault(hook *ormapi.EventWebhook) (string, error) {
+	creds := eventWebhookCreds{}
+	err := vault.GetData(serverConfig.vaultConfig, getEventWebhookVaultPath(hook.Org, hook.Name), 0, &creds)
+	if err != nil {
+		return "", err
+	}
+	return creds.Secret, nil
+}
+
+func deleteEventWebhookSecretFromVault(ctx context.Context, hook *ormapi.EventWebhook) {
+	if err := vault.DeleteData(serverConfig.vaultConfig, getEventWebhookVaultPath(hook.Org, hook.Name)); err != nil {
+		log.SpanLog(ctx, log.DebugLevelInfo, "Failed to delete event webhook secret from vault", "webhook", hook.Name, "org", hook.Org, "err", err)
+	}
+}
+
+// Signature of the payload sent in the signature header, so that
+// receivers can verify the payload came from the MC.
+func getEventWebhookSignature(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return EventWebhookSignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Exponential backoff after the given number of failed attempts
+func getEventWebhookRetryDelay(attempts int) time.Duration {
+	delay := EventWebhookRetryDelay
+	for ii := 1; ii < attempts; ii++ {
+		delay *= 2
+		if delay >= EventWebhookMaxRetryDelay {
+			return EventWebhookMaxRetryDelay
+		}
+	}
+	return delay
+}
+
+func parseEventWebhookTags(tags []string) (map[string]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	tagMap := make(map[string]string)
+	for _, tag := range tags {
+		kv := strings.SplitN(tag, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("Invalid tag %q, must be key=value", tag)
+		}
+		tagMap[kv[0]] = kv[1]
+	}
+	return tagMap, nil
+}
+
+func validateEventWebhook(hook *ormapi.EventWebhook) error {
+	if hook.Url == "" {
+		return fmt.Errorf("Webhook URL not specified")
+	}
+	u, err := url.Parse(hook.Url)
+	if err != nil {
+		return fmt.Errorf("Invalid webhook URL %s, %v", hook.Url, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("Invalid webhook URL %s, must be an http or https URL", hook.Url)
+	}
+	var ips []net.IP
+	if ip := net.ParseIP(u.Hostname()); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		ips, err = net.LookupIP(u.Hostname())
+		if err != nil {
+			return fmt.Errorf("Unable to resolve webhook host %s, %v", u.Hostname(), err)
+		}
+	}
+	for _, ip := range ips {
+		if err := checkEventWebhookIP(ip); err != nil {
+			return err
+		}
+	}
+	for _, typ := range hook.Types {
+		if typ != node.EventType && typ != node.AuditType {
+			return fmt.Errorf("Invalid event type %s, must be one of %s, %s", typ, node.EventType, node.AuditType)
+		}
+	}
+	if _, err := parseEventWebhookTags(hook.Tags); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Search for the webhook's events in the given time range
+func getEventWebhookSearch(hook *ormapi.EventWebhook, start, end time.Time) *node.EventSearch {
+	// tags are validated on create and update
+	tags, _ := parseEventWebhookTags(hook.Tags)
+	return &node.EventSearch{
+		Match: node.EventMatch{
+			Names:   hook.Names,
+			Types:   hook.Types,
+			Regions: hook.Regions,
+			Tags:    tags,
+			Failed:  hook.Failed,
+		},
+		AllowedOrgs: []string{hook.Org},
+		TimeRange: edgeproto.TimeRange{
+			StartTime: start,
+			EndTime:   end,
+		},
+		Limit: EventWebhookSearchLimit,
+	}
+}
+
+// Queue deliveries for the webhook's events since the last pass
+func queueEventWebhookEvents(ctx context.Context, hook *ormapi.EventWebhook, now time.Time) error {
+	start := hook.LastEventTime
+	// postgres saves time in microseconds, truncate so that the
+	// saved end time matches the range searched.
+	end := now.Add(-EventWebhookIndexDelay).Truncate(time.Microsecond)
+	if end.Sub(start) > EventWebhookMaxSearchSpan {
+		end = start.Add(EventWebhookMaxSearchSpan)
+	}
+	if !end.After(start) {
+		return nil
+	}
+	var events []node.EventData
+	for {
+		var err error
+		events, err = nodeMgr.ShowEvents(ctx, getEventWebhookSearch(hook, start, end))
+		if err != nil {
+			return err
+		}
+		if len(events) < EventWebhookSearchLimit || end.Sub(start) <= time.Second {
+			break
+		}
+		// too many events to get at once, narrow down the time range
+		end = start.Add(end.Sub(start) / 2).Truncate(time.Microsecond)
+	}
+	if len(events) >= EventWebhookSearchLimit {
+		log.SpanLog(ctx, log.DebugLevelInfo, "Event webhook search limit reached, some events may not be delivered", "webhook", hook.Name, "org", hook.Org, "start", start, "end", end)
+	}
+
+	deliveries := []ormapi.EventWebhookDelivery{}
+	for _, event := range events {
+		// start time is inclusive, but those events were
+		// already queued by the previous pass
+		if !event.Timestamp.After(start) || event.Timestamp.After(end) {
+			continue
+		}
+		payload := eventWebhookPayload{
+			DeliveryId: uuid.New().String(),
+			Webhook:    hook.Name,
+			Org:        hook.Org,
+			Event:      event,
+		}
+		dat, err := json.Marshal(&payload)
+		if err != nil {
+			return err
+		}
+		deliveries = append(deliveries, ormapi.EventWebhookDelivery{
+			Id:          payload.DeliveryId,
+			Webhook:     hook.Name,
+			Org:         hook.Org,
+			EventName:   event.Name,
+			EventTime:   event.Timestamp,
+			State:       EventWebhookDeliveryPending,
+			NextAttempt: now,
+			Payload:     string(dat),
+		})
+	}
+
+	db := loggedDB(ctx)
+	tx := db.BeginTx(ctx, nil)
+	defer tx.RollbackUnlessCommitted()
+
+	lookup := ormapi.EventWebhook{
+		Name: hook.Name,
+		Org:  hook.Org,
+	}
+	cur := ormapi.EventWebhook{}
+	// Lock the webhook so that only one MC queues the events, the
+	// others will see the updated LastEventTime once unblocked.
+	res := tx.Set("gorm:query_option", "FOR UPDATE").Where(&lookup).First(&cur)
+	if res.RecordNotFound() {
+		// webhook got deleted in the meantime
+		return nil
+	}
+	if res.Error != nil {
+		return ormutil.DbErr(res.Error)
+	}
+	if !cur.LastEventTime.Equal(start) || cur.Disabled {
+		// webhook got updated in the meantime
+		return nil
+	}
+	for ii := range deliveries {
+		if err := tx.Create(&deliveries[ii]).Error; err != nil {
+			return ormutil.DbErr(err)
+		}
+	}
+	err := tx.Model(&cur).Update("last_event_time", end).Error
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	err = tx.Commit().Error
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	hook.LastEventTime = end
+	if len(deliveries) > 0 {
+		log.SpanLog(ctx, log.DebugLevelInfo, "Queued event webhook deliveries", "webhook", hook.Name, "org", hook.Org, "count", len(deliveries))
+	}
+	return nil
+}
+
+// Post the delivery payload to the webhook. Returns the HTTP status
+// code if a response was received.
+func sendEventWebhookDelivery(ctx context.Context, hook *ormapi.EventWebhook, secret string, delivery *ormapi.EventWebhookDelivery) (int, error) {
+	payload := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.Url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventWebhookDeliveryHeader, delivery.Id)
+	req.Header.Set(EventWebhookSignatureHeader, getEventWebhookSignature(secret, payload))
+	resp, err := eventWebhookHttpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// the response body is never stored, as it is shown to
+		// org users with the delivery
+		return resp.StatusCode, fmt.Errorf("Webhook returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Update the delivery with the result of a delivery attempt
+func setEventWebhookDeliveryResult(delivery *ormapi.EventWebhookDelivery, status int, err error, now time.Time) {
+	delivery.Attempts++
+	delivery.LastStatus = status
+	if err == nil {
+		delivery.State = EventWebhookDeliveryDelivered
+		delivery.LastError = ""
+		delivery.DeliveredAt = now
+		return
+	}
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= EventWebhookMaxAttempts {
+		// move to dead letter list
+		delivery.State = EventWebhookDeliveryFailed
+		return
+	}
+	delivery.NextAttempt = now.Add(getEventWebhookRetryDelay(delivery.Attempts))
+}
+
+// Claim due deliveries for this MC. Rows locked by other MCs are
+// skipped, and the claimed rows have their next attempt pushed out
+// by the lease time so that other MCs do not pick them up while
+// they are being sent. If this MC goes away before saving the
+// results, the deliveries are retried after the lease expires.
+func claimEventWebhookDeliveries(ctx context.Context, now time.Time) ([]ormapi.EventWebhookDelivery, error) {
+	db := loggedDB(ctx)
+	tx := db.BeginTx(ctx, nil)
+	defer tx.RollbackUnlessCommitted()
+
+	deliveries := []ormapi.EventWebhookDelivery{}
+	err := tx.Set("gorm:query_option", "FOR UPDATE SKIP LOCKED").Where(&ormapi.EventWebhookDelivery{State: EventWebhookDeliveryPending}).Where("next_attempt <= ?", now).Order("event_time").Limit(EventWebhookDeliveryBatchSize).Find(&deliveries).Error
+	if err != nil {
+		return nil, ormutil.DbErr(err)
+	}
+	if len(deliveries) == 0 {
+		return deliveries, nil
+	}
+	ids := []string{}
+	for _, delivery := range deliveries {
+		ids = append(ids, delivery.Id)
+	}
+	err = tx.Model(&ormapi.EventWebhookDelivery{}).Where("id IN (?)", ids).Update("next_attempt", now.Add(EventWebhookDeliveryLease)).Error
+	if err != nil {
+		return nil, ormutil.DbErr(err)
+	}
+	err = tx.Commit().Error
+	if err != nil {
+		return nil, ormutil.DbErr(err)
+	}
+	return deliveries, nil
+}
+
+func sendEventWebhookDeliveries(ctx context.Context, hooks map[string]*ormapi.EventWebhook, now time.Time) error {
+	deliveries, err := claimEventWebhookDeliveries(ctx, now)
+	if err != nil {
+		return err
+	}
+	db := loggedDB(ctx)
+	secrets := make(map[string]string)
+	sem := make(chan struct{}, EventWebhookDeliveryConcurrency)
+	var wg sync.WaitGroup
+	for ii := range deliveries {
+		delivery := &deliveries[ii]
+		hookKey := delivery.Org + "/" + delivery.Webhook
+		hook, found := hooks[hookKey]
+		if !found || hook.Disabled {
+			// release the claim
+			delivery.NextAttempt = now
+			if err := db.Save(delivery).Error; err != nil {
+				log.SpanLog(ctx, log.DebugLevelInfo, "Failed to update event webhook delivery", "delivery", delivery.Id, "err", err)
+			}
+			continue
+		}
+		secret, found := secrets[hookKey]
+		if !found {
+			secret, err = getEventWebhookSecretFromVault(hook)
+			if err != nil {
+				log.SpanLog(ctx, log.DebugLevelInfo, "Failed to get event webhook secret", "webhook", hook.Name, "org", hook.Org, "err", err)
+				continue
+			}
+			secrets[hookKey] = secret
+		}
+		// each send may block for the http client timeout,
+		// so send in parallel
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(hook *ormapi.EventWebhook, secret string, delivery *ormapi.EventWebhookDelivery) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			status, err := sendEventWebhookDelivery(ctx, hook, secret, delivery)
+			if err != nil {
+				log.SpanLog(ctx, log.DebugLevelInfo, "Failed to deliver event to webhook", "webhook", hook.Name, "org", hook.Org, "delivery", delivery.Id, "err", err)
+			}
+			setEventWebhookDeliveryResult(delivery, status, err, time.Now())
+			err = db.Save(delivery).Error
+			if err != nil {
+				log.SpanLog(ctx, log.DebugLevelInfo, "Failed to update event webhook delivery", "delivery", delivery.Id, "err", err)
+			}
+		}(hook, secret, delivery)
+	}
+	wg.Wait()
+	return nil
+}
+
+// Queue new events, deliver them, and clean up old delivery history
+func runEventWebhooks(ctx context.Context, now time.Time) error {
+	db := loggedDB(ctx)
+	hookList := []ormapi.EventWebhook{}
+	err := db.Find(&hookList).Error
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	hooks := make(map[string]*ormapi.EventWebhook)
+	for ii := range hookList {
+		hook := &hookList[ii]
+		hooks[hook.Org+"/"+hook.Name] = hook
+		if hook.Disabled {
+			continue
+		}
+		err := queueEventWebhookEvents(ctx, hook, now)
+		if err != nil {
+			log.SpanLog(ctx, log.DebugLevelInfo, "Failed to queue event webhook events", "webhook", hook.Name, "org", hook.Org, "err", err)
+		}
+	}
+	err = sendEventWebhookDeliveries(ctx, hooks, now)
+	if err != nil {
+		return err
+	}
+	err = db.Where("state <> ? AND created_at < ?", EventWebhookDeliveryPending, now.Add(-EventWebhookHistoryRetention)).Delete(&ormapi.EventWebhookDelivery{}).Error
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	return nil
+}
+
+// Start event webhook thread to deliver events to subscribed webhooks
+func RunEventWebhooks() {
+	for {
+		select {
+		case <-time.After(EventWebhookCheckInterval):
+		case <-eventWebhookTrigger:
+		}
+		span := log.StartSpan(log.DebugLevelInfo, "Event webhook thread")
+		ctx := log.ContextWithSpan(context.Background(), span)
+		err := runEventWebhooks(ctx, time.Now())
+		if err != nil {
+			log.SpanLog(ctx, log.DebugLevelInfo, "Failed to run event webhooks", "err", err)
+		}
+		span.Finish()
+	}
+}
+
+func InitEventWebhooks() {
+	eventWebhookTrigger = make(chan bool, 10)
+}
+
+func triggerEventWebhooks() {
+	select {
+	case eventWebhookTrigger <- true:
+	default:
+	}
+}
+
+func CreateEventWebhook(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	hook := ormapi.EventWebhook{}
+	if err := c.Bind(&hook); err != nil {
+		return ormutil.BindErr(err)
+	}
+	if hook.Name == "" {
+		return fmt.Errorf("Name not specified")
+	}
+	err = ValidNameNoUnderscore(hook.Name)
+	if err != nil {
+		return err
+	}
+	if hook.Org == "" {
+		return fmt.Errorf("Org name has to be specified")
+	}
+	if _, err := orgExists(ctx, hook.Org); err != nil {
+		return err
+	}
+	// check if user is authorized to manage the org
+	if err := authorized(ctx, claims.Username, hook.Org, ResourceUsers, ActionManage); err != nil {
+		return err
+	}
+	if err := validateEventWebhook(&hook); err != nil {
+		return err
+	}
+	if hook.Secret == "" {
+		return fmt.Errorf("Secret has to be specified to sign deliveries")
+	}
+	if hook.Username != "" || !hook.LastEventTime.IsZero() {
+		return fmt.Errorf("Username and last event time are for internal-use only")
+	}
+	hook.Username = claims.Username
+	// only deliver events from now on
+	hook.LastEventTime = time.Now().Truncate(time.Microsecond)
+
+	db := loggedDB(ctx)
+	tx := db.BeginTx(ctx, nil)
+	defer tx.RollbackUnlessCommitted()
+
+	err = tx.Create(&hook).Error
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key value violates unique constraint \"event_webhooks_pkey") {
+			return fmt.Errorf("Event webhook for org %s with name %s already exists", hook.Org, hook.Name)
+		}
+		return ormutil.DbErr(err)
+	}
+	if err := putEventWebhookSecretToVault(ctx, &hook); err != nil {
+		return err
+	}
+	err = tx.Commit().Error
+	if err != nil {
+		deleteEventWebhookSecretFromVault(ctx, &hook)
+		return ormutil.DbErr(err)
+	}
+	return ormutil.SetReply(c, ormutil.Msg("Event webhook created"))
+}
+
+func UpdateEventWebhook(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	// Pull json directly so we can unmarshal twice.
+	// First time is to do lookup, second time is to apply
+	// modified fields.
+	body, err := ioutil.ReadAll(c.Request().Body)
+	in := ormapi.EventWebhook{}
+	err = BindJson(body, &in)
+	if err != nil {
+		return ormutil.BindErr(err)
+	}
+	if in.Name == "" {
+		return fmt.Errorf("Event webhook name not specified")
+	}
+	if in.Org == "" {
+		return fmt.Errorf("Event webhook org not specified")
+	}
+	lookup := ormapi.EventWebhook{
+		Name: in.Name,
+		Org:  in.Org,
+	}
+
+	db := loggedDB(ctx)
+	tx := db.BeginTx(ctx, nil)
+	defer tx.RollbackUnlessCommitted()
+
+	hook := ormapi.EventWebhook{}
+	res := tx.Where(&lookup).First(&hook)
+	if res.RecordNotFound() {
+		return fmt.Errorf("Event webhook not found")
+	}
+	if res.Error != nil {
+		return ormutil.NewHTTPError(http.StatusInternalServerError, ormutil.DbErr(res.Error).Error())
+	}
+	if err := authorized(ctx, claims.Username, hook.Org, ResourceUsers, ActionManage); err != nil {
+		return err
+	}
+
+	oldHook := hook
+	// apply specified fields
+	err = BindJson(body, &hook)
+	if err != nil {
+		return ormutil.BindErr(err)
+	}
+	if hook.Username != oldHook.Username {
+		return fmt.Errorf("Cannot change username")
+	}
+	if !hook.LastEventTime.Equal(oldHook.LastEventTime) {
+		return fmt.Errorf("Cannot change last event time")
+	}
+	if err := validateEventWebhook(&hook); err != nil {
+		return err
+	}
+	if oldHook.Disabled && !hook.Disabled {
+		// do not deliver events from while it was disabled
+		hook.LastEventTime = time.Now().Truncate(time.Microsecond)
+	}
+	err = tx.Save(&hook).Error
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	if hook.Secret != "" {
+		if err := putEventWebhookSecretToVault(ctx, &hook); err != nil {
+			return err
+		}
+	}
+	err = tx.Commit().Error
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	return ormutil.SetReply(c, ormutil.Msg("Event webhook updated"))
+}
+
+func DeleteEventWebhook(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	hook := ormapi.EventWebhook{}
+	if err := c.Bind(&hook); err != nil {
+		return ormutil.BindErr(err)
+	}
+	if hook.Name == "" {
+		return fmt.Errorf("Event webhook name not specified")
+	}
+	if hook.Org == "" {
+		return fmt.Errorf("Event webhook org not specified")
+	}
+
+	db := loggedDB(ctx)
+	tx := db.BeginTx(ctx, nil)
+	defer tx.RollbackUnlessCommitted()
+
+	lookup := ormapi.EventWebhook{
+		Name: hook.Name,
+		Org:  hook.Org,
+	}
+	res := tx.Where(&lookup).First(&hook)
+	if res.RecordNotFound() {
+		return fmt.Errorf("Event webhook not found")
+	}
+	if res.Error != nil {
+		return ormutil.NewHTTPError(http.StatusInternalServerError, ormutil.DbErr(res.Error).Error())
+	}
+	if err := authorized(ctx, claims.Username, hook.Org, ResourceUsers, ActionManage); err != nil {
+		return err
+	}
+	err = tx.Delete(&hook).Error
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	// delivery history goes away with the webhook
+	err = tx.Where(&ormapi.EventWebhookDelivery{Webhook: hook.Name, Org: hook.Org}).Delete(&ormapi.EventWebhookDelivery{}).Error
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	err = tx.Commit().Error
+	if err != nil {
+		return ormutil.NewHTTPError(http.StatusInternalServerError, ormutil.DbErr(err).Error())
+	}
+	deleteEventWebhookSecretFromVault(ctx, &hook)
+	return ormutil.SetReply(c, ormutil.Msg("Event webhook deleted"))
+}
+
+// Get the orgs the user can view webhooks of, and check that the
+// user can view the org in the filter, if specified.
+func getEventWebhookAuthOrgs(ctx context.Context, username string, filter map[string]interface{}) (map[string]struct{}, bool, error) {
+	authOrgs, err := enforcer.GetAuthorizedOrgs(ctx, username, ResourceUsers, ActionView)
+	if err != nil {
+		return nil, false, ormutil.DbErr(err)
+	}
+	if len(authOrgs) == 0 {
+		return nil, false, echo.ErrForbidden
+	}
+	_, admin := authOrgs[""]
+	if org, ok := filter["org"].(string); ok && org != "" && !admin {
+		if _, found := authOrgs[org]; !found {
+			// no perms for specified org
+			return nil, false, echo.ErrForbidden
+		}
+	}
+	return authOrgs, admin, nil
+}
+
+func ShowEventWebhook(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	filter, err := bindDbFilter(c, &ormapi.EventWebhook{})
+	if err != nil {
+		return err
+	}
+	authOrgs, admin, err := getEventWebhookAuthOrgs(ctx, claims.Username, filter)
+	if err != nil {
+		return err
+	}
+	db := loggedDB(ctx)
+	hooks := []ormapi.EventWebhook{}
+	err = findShowPage(db.Where(filter), ormutil.GetShowPager(c), &hooks).Error
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	showOutput := []ormapi.EventWebhook{}
+	for _, hook := range hooks {
+		if _, found := authOrgs[hook.Org]; !found && !admin {
+			continue
+		}
+		showOutput = append(showOutput, hook)
+	}
+	return ormutil.SetReply(c, showOutput)
+}
+
+func ShowEventWebhookDelivery(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	filter, err := bindDbFilter(c, &ormapi.EventWebhookDelivery{})
+	if err != nil {
+		return err
+	}
+	authOrgs, admin, err := getEventWebhookAuthOrgs(ctx, claims.Username, filter)
+	if err != nil {
+		return err
+	}
+	db := loggedDB(ctx)
+	deliveries := []ormapi.EventWebhookDelivery{}
+	err = findShowPage(db.Where(filter).Order("event_time"), ormutil.GetShowPager(c), &deliveries).Error
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	showOutput := []ormapi.EventWebhookDelivery{}
+	for _, delivery := range deliveries {
+		if _, found := authOrgs[delivery.Org]; !found && !admin {
+			continue
+		}
+		showOutput = append(showOutput, delivery)
+	}
+	return ormutil.SetReply(c, showOutput)
+}
+
+// Retry failed deliveries from the dead letter list
+func RetryEventWebhookDelivery(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	in := ormapi.EventWebhookDelivery{}
+	if err := c.Bind(&in); err != nil {
+		return ormutil.BindErr(err)
+	}
+	if in.Org == "" {
+		return fmt.Errorf("Event webhook org not specified")
+	}
+	if err := authorized(ctx, claims.Username, in.Org, ResourceUsers, ActionManage); err != nil {
+		return err
+	}
+	if in.State != "" && in.State != EventWebhookDeliveryFailed {
+		return fmt.Errorf("Only %s deliveries can be retried", EventWebhookDeliveryFailed)
+	}
+	lookup := ormapi.EventWebhookDelivery{
+		Id:      in.Id,
+		Webhook: in.Webhook,
+		Org:     in.Org,
+		State:   EventWebhookDeliveryFailed,
+	}
+	db := loggedDB(ctx)
+	res := db.Model(&ormapi.EventWebhookDelivery{}).Where(&lookup).Updates(map[string]interface{}{
+		"state":        EventWebhookDeliveryPending,
+		"attempts":     0,
+		"next_attempt": time.Now(),
+	})
+	if res.Error != nil {
+		return ormutil.DbErr(res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("No failed deliveries found")
+	}
+	triggerEventWebhooks()
+	return ormutil.SetReply(c, ormutil.Msg(fmt.Sprintf("Retrying %d deliveries", res.RowsAffected)))
+}
@@ -0,0 +1,337 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mobiledgex/edge-cloud-infra/mc/mcctl/mctestclient"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+	"github.com/mobiledgex/edge-cloud/cli"
+	"github.com/mobiledgex/edge-cloud/cloudcommon/node"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventWebhookDelivery(t *testing.T) {
+	// signature is hex encoded HMAC-SHA256 of the payload
+	sig := getEventWebhookSignature("key", []byte("The quick brown fox jumps over the lazy dog"))
+	require.Equal(t, "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8", sig)
+
+	// exponential backoff, capped
+	require.Equal(t, EventWebhookRetryDelay, getEventWebhookRetryDelay(1))
+	require.Equal(t, 2*EventWebhookRetryDelay, getEventWebhookRetryDelay(2))
+	require.Equal(t, 8*EventWebhookRetryDelay, getEventWebhookRetryDelay(4))
+	require.Equal(t, EventWebhookMaxRetryDelay, getEventWebhookRetryDelay(20))
+
+	// tags
+	tags, err := parseEventWebhookTags([]string{"cloudlet=foo", "app=a=b"})
+	require.Nil(t, err)
+	require.Equal(t, map[string]string{"cloudlet": "foo", "app": "a=b"}, tags)
+	_, err = parseEventWebhookTags([]string{"cloudlet"})
+	require.NotNil(t, err)
+	_, err = parseEventWebhookTags([]string{"=foo"})
+	require.NotNil(t, err)
+
+	// validation
+	hook := ormapi.EventWebhook{
+		Url:   "https://203.0.113.10/hook",
+		Types: []string{node.EventType},
+		Tags:  []string{"cloudlet=foo"},
+	}
+	require.Nil(t, validateEventWebhook(&hook))
+	bad := hook
+	bad.Url = "ftp://example.com"
+	require.NotNil(t, validateEventWebhook(&bad))
+	bad = hook
+	bad.Types = []string{"foo"}
+	require.NotNil(t, validateEventWebhook(&bad))
+	// internal addresses are not allowed
+	for _, addr := range []string{"127.0.0.1", "10.1.2.3", "192.168.1.1", "169.254.169.254", "[::1]", "[fd00:ec2::254]", "localhost"} {
+		bad = hook
+		bad.Url = "http://" + addr + ":8200/v1/secret"
+		err = validateEventWebhook(&bad)
+		require.NotNil(t, err, addr)
+		if addr != "localhost" {
+			require.Contains(t, err.Error(), "not a public address")
+		}
+	}
+	require.NotNil(t, eventWebhookDialControl("tcp", "169.254.169.254:80", nil))
+	require.Nil(t, eventWebhookDialControl("tcp", "203.0.113.10:443", nil))
+
+	// search
+	hook.Org = "devorg"
+	hook.Failed = true
+	start := time.Now()
+	search := getEventWebhookSearch(&hook, start, start.Add(time.Minute))
+	require.Equal(t, []string{"devorg"}, search.AllowedOrgs)
+	require.Equal(t, []string{node.EventType}, search.Match.Types)
+	require.Equal(t, map[string]string{"cloudlet": "foo"}, search.Match.Tags)
+	require.True(t, search.Match.Failed)
+	require.Equal(t, start, search.TimeRange.StartTime)
+
+	// retries until max attempts, then dead letter
+	now := time.Now()
+	delivery := ormapi.EventWebhookDelivery{
+		State: EventWebhookDeliveryPending,
+	}
+	for ii := 1; ii < EventWebhookMaxAttempts; ii++ {
+		setEventWebhookDeliveryResult(&delivery, http.StatusServiceUnavailable, fmt.Errorf("unavailable"), now)
+		require.Equal(t, EventWebhookDeliveryPending, delivery.State)
+		require.Equal(t, ii, delivery.Attempts)
+		require.Equal(t, now.Add(getEventWebhookRetryDelay(ii)), delivery.NextAttempt)
+	}
+	setEventWebhookDeliveryResult(&delivery, http.StatusServiceUnavailable, fmt.Errorf("unavailable"), now)
+	require.Equal(t, EventWebhookDeliveryFailed, delivery.State)
+	require.Equal(t, "unavailable", delivery.LastError)
+
+	delivery = ormapi.EventWebhookDelivery{
+		State:     EventWebhookDeliveryPending,
+		LastError: "unavailable",
+	}
+	setEventWebhookDeliveryResult(&delivery, http.StatusOK, nil, now)
+	require.Equal(t, EventWebhookDeliveryDelivered, delivery.State)
+	require.Equal(t, "", delivery.LastError)
+	require.Equal(t, now, delivery.DeliveredAt)
+}
+
+func testEventWebhooks(t *testing.T, ctx context.Context, mcClient *mctestclient.Client, uri string) {
+	secret := "webhooksecret"
+	var mux sync.Mutex
+	received := []string{}
+	fail := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.Nil(t, err)
+		require.Equal(t, getEventWebhookSignature(secret, body), r.Header.Get(EventWebhookSignatureHeader))
+		mux.Lock()
+		defer mux.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("internal details"))
+			return
+		}
+		received = append(received, r.Header.Get(EventWebhookDeliveryHeader))
+	}))
+	defer server.Close()
+
+	user1, token1, _ := testCreateUser(t, mcClient, uri, "webhookuser1")
+	user2, token2, _ := testCreateUser(t, mcClient, uri, "webhookuser2")
+	org := testCreateOrg(t, mcClient, uri, token1, OrgTypeDeveloper, "WebhookOrg")
+
+	hook := ormapi.EventWebhook{
+		Name:   "hook1",
+		Org:    org.Name,
+		Url:    server.URL,
+		Secret: secret,
+		Tags:   []string{"app=myapp"},
+	}
+	// test server is on a local address, which is not allowed
+	_, err := mcClient.CreateEventWebhook(uri, token1, &hook)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "not a public address")
+	EventWebhookAllowLocalAddrs = true
+	defer func() {
+		EventWebhookAllowLocalAddrs = false
+	}()
+
+	// user2 is not part of the org
+	status, err := mcClient.CreateEventWebhook(uri, token2, &hook)
+	require.NotNil(t, err)
+	require.Equal(t, http.StatusForbidden, status)
+	// secret is required
+	badHook := hook
+	badHook.Secret = ""
+	_, err = mcClient.CreateEventWebhook(uri, token1, &badHook)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Secret has to be specified")
+	badHook = hook
+	badHook.Tags = []string{"app"}
+	_, err = mcClient.CreateEventWebhook(uri, token1, &badHook)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "must be key=value")
+
+	status, err = mcClient.CreateEventWebhook(uri, token1, &hook)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	_, err = mcClient.CreateEventWebhook(uri, token1, &hook)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "already exists")
+
+	// secret is never shown
+	hooks, status, err := mcClient.ShowEventWebhook(uri, token1, ClientNoShowFilter)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, 1, len(hooks))
+	require.Equal(t, "", hooks[0].Secret)
+	require.Equal(t, user1.Name, hooks[0].Username)
+	require.Equal(t, []string{"app=myapp"}, []string(hooks[0].Tags))
+	hooks, status, err = mcClient.ShowEventWebhook(uri, token2, ClientNoShowFilter)
+	require.NotNil(t, err)
+	require.Equal(t, http.StatusForbidden, status)
+
+	// queue deliveries as if the events had been found
+	dbHook := ormapi.EventWebhook{}
+	err = loggedDB(ctx).Where(&ormapi.EventWebhook{Name: hook.Name, Org: hook.Org}).First(&dbHook).Error
+	require.Nil(t, err)
+	hooksMap := map[string]*ormapi.EventWebhook{
+		dbHook.Org + "/" + dbHook.Name: &dbHook,
+	}
+	numDeliveries := 3
+	for ii := 0; ii < numDeliveries; ii++ {
+		payload := eventWebhookPayload{
+			DeliveryId: uuid.New().String(),
+			Webhook:    hook.Name,
+			Org:        hook.Org,
+			Event: node.EventData{
+				Name:      "App created",
+				Org:       []string{hook.Org},
+				Timestamp: time.Now(),
+			},
+		}
+		dat, err := json.Marshal(&payload)
+		require.Nil(t, err)
+		delivery := ormapi.EventWebhookDelivery{
+			Id:          payload.DeliveryId,
+			Webhook:     hook.Name,
+			Org:         hook.Org,
+			EventName:   payload.Event.Name,
+			EventTime:   payload.Event.Timestamp,
+			State:       EventWebhookDeliveryPending,
+			NextAttempt: time.Now(),
+			Payload:     string(dat),
+		}
+		err = loggedDB(ctx).Create(&delivery).Error
+		require.Nil(t, err)
+	}
+
+	failedFilter := &cli.MapData{
+		Namespace: cli.StructNamespace,
+		Data: map[string]interface{}{
+			"State": EventWebhookDeliveryFailed,
+		},
+	}
+	// failed attempts are retried until they are dead-lettered
+	saveMaxAttempts := EventWebhookMaxAttempts
+	EventWebhookMaxAttempts = 2
+	defer func() {
+		EventWebhookMaxAttempts = saveMaxAttempts
+	}()
+	// claimed deliveries are skipped by other MCs until the lease expires
+	claimed, err := claimEventWebhookDeliveries(ctx, time.Now())
+	require.Nil(t, err)
+	require.Equal(t, numDeliveries, len(claimed))
+	claimed, err = claimEventWebhookDeliveries(ctx, time.Now())
+	require.Nil(t, err)
+	require.Equal(t, 0, len(claimed))
+	err = sendEventWebhookDeliveries(ctx, hooksMap, time.Now().Add(EventWebhookDeliveryLease))
+	require.Nil(t, err)
+	deliveries, status, err := mcClient.ShowEventWebhookDelivery(uri, token1, ClientNoShowFilter)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, numDeliveries, len(deliveries))
+	for _, delivery := range deliveries {
+		require.Equal(t, EventWebhookDeliveryPending, delivery.State)
+		require.Equal(t, 1, delivery.Attempts)
+		require.Equal(t, http.StatusServiceUnavailable, delivery.LastStatus)
+		// response body is never stored
+		require.Equal(t, "Webhook returned status 503", delivery.LastError)
+	}
+	// not due yet
+	err = sendEventWebhookDeliveries(ctx, hooksMap, time.Now())
+	require.Nil(t, err)
+	deliveries, _, err = mcClient.ShowEventWebhookDelivery(uri, token1, failedFilter)
+	require.Nil(t, err)
+	require.Equal(t, 0, len(deliveries))
+	err = sendEventWebhookDeliveries(ctx, hooksMap, time.Now().Add(EventWebhookRetryDelay))
+	require.Nil(t, err)
+	deliveries, _, err = mcClient.ShowEventWebhookDelivery(uri, token1, failedFilter)
+	require.Nil(t, err)
+	require.Equal(t, numDeliveries, len(deliveries))
+
+	// retry the dead letters
+	_, status, err = mcClient.ShowEventWebhookDelivery(uri, token2, failedFilter)
+	require.NotNil(t, err)
+	require.Equal(t, http.StatusForbidden, status)
+	retry := ormapi.EventWebhookDelivery{
+		Org:     hook.Org,
+		Webhook: hook.Name,
+	}
+	status, err = mcClient.RetryEventWebhookDelivery(uri, token2, &retry)
+	require.NotNil(t, err)
+	require.Equal(t, http.StatusForbidden, status)
+	mux.Lock()
+	fail = false
+	mux.Unlock()
+	status, err = mcClient.RetryEventWebhookDelivery(uri, token1, &retry)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	_, err = mcClient.RetryEventWebhookDelivery(uri, token1, &retry)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "No failed deliveries found")
+	err = sendEventWebhookDeliveries(ctx, hooksMap, time.Now())
+	require.Nil(t, err)
+	deliveries, _, err = mcClient.ShowEventWebhookDelivery(uri, token1, ClientNoShowFilter)
+	require.Nil(t, err)
+	require.Equal(t, numDeliveries, len(deliveries))
+	for _, delivery := range deliveries {
+		require.Equal(t, EventWebhookDeliveryDelivered, delivery.State)
+		require.Contains(t, received, delivery.Id)
+	}
+
+	// disabling the webhook
+	update := &cli.MapData{
+		Namespace: cli.StructNamespace,
+		Data: map[string]interface{}{
+			"Name":     hook.Name,
+			"Org":      hook.Org,
+			"Disabled": true,
+		},
+	}
+	status, err = mcClient.UpdateEventWebhook(uri, token1, update)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	update.Data["Url"] = "not a url"
+	_, err = mcClient.UpdateEventWebhook(uri, token1, update)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Invalid webhook URL")
+	hooks, _, err = mcClient.ShowEventWebhook(uri, token1, ClientNoShowFilter)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(hooks))
+	require.True(t, hooks[0].Disabled)
+	require.Equal(t, server.URL, hooks[0].Url)
+
+	// delivery history is deleted with the webhook
+	status, err = mcClient.DeleteEventWebhook(uri, token1, &hook)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	deliveryCount := 0
+	err = loggedDB(ctx).Model(&ormapi.EventWebhookDelivery{}).Count(&deliveryCount).Error
+	require.Nil(t, err)
+	require.Equal(t, 0, deliveryCount)
+
+	// cleanup
+	testDeleteOrg(t, mcClient, uri, token1, org.Name)
+	testDeleteUser(t, mcClient, uri, token1, user1.Name)
+	testDeleteUser(t, mcClient, uri, token2, user2.Name)
+}
@@ -461,7 +461,7 @@ func ShowSelfFederator(c echo.Context) error {
 
 	db := loggedDB(ctx)
 	feds := []ormapi.Federator{}
-	res := db.Where(filter).Find(&feds)
+	res := findShowPage(db.Where(filter), ormutil.GetShowPager(c), &feds)
 	if !res.RecordNotFound() && res.Error != nil {
 		return ormutil.DbErr(res.Error)
 	}
@@ -475,7 +475,7 @@ func ShowSelfFederator(c echo.Context) error {
 		fed.Iter = 0
 		out = append(out, fed)
 	}
-	return c.JSON(http.StatusOK, out)
+	return ormutil.SetReply(c, out)
 }
 
 func orgInUseByFederatorCheck(ctx context.Context, orgName string) error {
@@ -903,7 +903,7 @@ func ShowSelfFederatorZone(c echo.Context) error {
 	}
 	db := loggedDB(ctx)
 	opZones := []ormapi.FederatorZone{}
-	res := db.Where(filter).Find(&opZones)
+	res := findShowPage(db.Where(filter), ormutil.GetShowPager(c), &opZones)
 	if !res.RecordNotFound() && res.Error != nil {
 		return ormutil.DbErr(res.Error)
 	}
@@ -915,7 +915,7 @@ func ShowSelfFederatorZone(c echo.Context) error {
 		out = append(out, opZone)
 	}
 
-	return c.JSON(http.StatusOK, out)
+	return ormutil.SetReply(c, out)
 }
 
 func ShowFederatedSelfZone(c echo.Context) error {
@@ -934,7 +934,7 @@ func ShowFederatedSelfZone(c echo.Context) error {
 	}
 	db := loggedDB(ctx)
 	opZones := []ormapi.FederatedSelfZone{}
-	res := db.Where(filter).Find(&opZones)
+	res := findShowPage(db.Where(filter), ormutil.GetShowPager(c), &opZones)
 	if !res.RecordNotFound() && res.Error != nil {
 		return ormutil.DbErr(res.Error)
 	}
@@ -946,7 +946,7 @@ func ShowFederatedSelfZone(c echo.Context) error {
 		out = append(out, zone)
 	}
 
-	return c.JSON(http.StatusOK, out)
+	return ormutil.SetReply(c, out)
 }
 
 func ShowFederatedPartnerZone(c echo.Context) error {
@@ -969,7 +969,7 @@ func ShowFederatedPartnerZone(c echo.Context) error {
 	}
 	db := loggedDB(ctx)
 	opZones := []ormapi.FederatedPartnerZone{}
-	res := db.Where(filter).Find(&opZones)
+	res := findShowPage(db.Where(filter), ormutil.GetShowPager(c), &opZones)
 	if !res.RecordNotFound() && res.Error != nil {
 		return ormutil.DbErr(res.Error)
 	}
@@ -981,7 +981,7 @@ func ShowFederatedPartnerZone(c echo.Context) error {
 		out = append(out, zone)
 	}
 
-	return c.JSON(http.StatusOK, out)
+	return ormutil.SetReply(c, out)
 }
 
 func ShareSelfFederatorZone(c echo.Context) error {
@@ -1623,7 +1623,7 @@ func ShowFederation(c echo.Context) error {
 	}
 	db := loggedDB(ctx)
 	outFeds := []ormapi.Federation{}
-	res := db.Where(filter).Find(&outFeds)
+	res := findShowPage(db.Where(filter), ormutil.GetShowPager(c), &outFeds)
 	if !res.RecordNotFound() && res.Error != nil {
 		return ormutil.DbErr(res.Error)
 	}
@@ -1637,5 +1637,5 @@ func ShowFederation(c echo.Context) error {
 		fed.Iter = 0
 		out = append(out, fed)
 	}
-	return c.JSON(http.StatusOK, out)
+	return ormutil.SetReply(c, out)
 }
@@ -0,0 +1,1010 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	influxdb "github.com/influxdata/influxdb/client/v2"
+	"github.com/influxdata/influxdb/models"
+	"github.com/labstack/echo"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ctrlclient"
+	"github.com/mobiledgex/edge-cloud-infra/mc/graphql"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormutil"
+	"github.com/mobiledgex/edge-cloud/edgeproto"
+	"github.com/mobiledgex/edge-cloud/log"
+	"github.com/mobiledgex/edge-cloud/util"
+)
+
+// The GraphQL API is a read-only view over MC organizations and
+// regional objects, resolved as
+//   Org -> CloudletPool -> Cloudlet -> ClusterInst -> AppInst -> metrics
+// Regional objects are looked up with the same show authz as the
+// regional show APIs, and are fetched once per region and type for
+// each query, so nested fields are filtered in memory. Metrics of the
+// objects in a list are fetched with one metrics query per region.
+
+var (
+	gqlSchema     *graphql.Schema
+	gqlSchemaErr  error
+	gqlSchemaOnce sync.Once
+)
+
+var gqlKeyArgs = map[string]string{
+	"region": "String",
+	"org":    "String",
+	"name":   "String",
+}
+
+var gqlRegionArgs = map[string]string{
+	"region": "String",
+}
+
+var gqlMetricsField = graphql.FieldDef{
+	Name: "metrics",
+	Type: "JSON",
+	Args: map[string]string{
+		"selector":   "String",
+		"limit":      "Int",
+		"numSamples": "Int",
+		"startTime":  "String",
+		"endTime":    "String",
+	},
+}
+
+var gqlRegionField = graphql.FieldDef{
+	Name: "region",
+	Type: "String",
+}
+
+// Schema of the object types, whose other fields are the JSON fields
+// of their API objects.
+func getGqlSchema() (*graphql.Schema, error) {
+	gqlSchemaOnce.Do(func() {
+		gqlSchema, gqlSchemaErr = graphql.NewSchema(&graphql.ObjectDef{
+			Name: "Query",
+			Fields: []graphql.FieldDef{
+				{Name: "regions", Type: "[String]"},
+				{Name: "orgs", Type: "[Org]", Args: map[string]string{"name": "String", "type": "String"}},
+				{Name: "cloudletPools", Type: "[CloudletPool]", Args: gqlKeyArgs},
+				{Name: "cloudlets", Type: "[Cloudlet]", Args: gqlKeyArgs},
+				{Name: "clusterInsts", Type: "[ClusterInst]", Args: gqlKeyArgs},
+				{Name: "appInsts", Type: "[AppInst]", Args: gqlKeyArgs},
+			},
+		}, &graphql.ObjectDef{
+			Name: "Org",
+			Data: ormapi.Organization{},
+			Fields: []graphql.FieldDef{
+				{Name: "cloudletPools", Type: "[CloudletPool]", Args: gqlRegionArgs},
+				{Name: "cloudlets", Type: "[Cloudlet]", Args: gqlRegionArgs},
+				{Name: "clusterInsts", Type: "[ClusterInst]", Args: gqlRegionArgs},
+				{Name: "appInsts", Type: "[AppInst]", Args: gqlRegionArgs},
+			},
+		}, &graphql.ObjectDef{
+			Name: "CloudletPool",
+			Data: edgeproto.CloudletPool{},
+			Fields: []graphql.FieldDef{
+				gqlRegionField,
+				{Name: "cloudlets", Type: "[Cloudlet]"},
+			},
+		}, &graphql.ObjectDef{
+			Name: "Cloudlet",
+			Data: edgeproto.Cloudlet{},
+			Fields: []graphql.FieldDef{
+				gqlRegionField,
+				{Name: "cloudletPools", Type: "[CloudletPool]"},
+				{Name: "clusterInsts", Type: "[ClusterInst]"},
+				{Name: "appInsts", Type: "[AppInst]"},
+				gqlMetricsField,
+			},
+		}, &graphql.ObjectDef{
+			Name: "ClusterInst",
+			Data: edgeproto.ClusterInst{},
+			Fields: []graphql.FieldDef{
+				gqlRegionField,
+				{Name: "cloudlet", Type: "Cloudlet"},
+				{Name: "appInsts", Type: "[AppInst]"},
+				gqlMetricsField,
+			},
+		}, &graphql.ObjectDef{
+			Name: "AppInst",
+			Data: edgeproto.AppInst{},
+			Fields: []graphql.FieldDef{
+				gqlRegionField,
+				{Name: "cloudlet", Type: "Cloudlet"},
+				{Name: "clusterInst", Type: "ClusterInst"},
+				gqlMetricsField,
+			},
+		})
+	})
+	return gqlSchema, gqlSchemaErr
+}
+
+func GraphQL(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	in := ormapi.GraphQLQuery{}
+	if err := c.Bind(&in); err != nil {
+		return ormutil.BindErr(err)
+	}
+	if strings.TrimSpace(in.Query) == "" {
+		return fmt.Errorf("Query not specified")
+	}
+	span := log.SpanFromContext(ctx)
+	if in.OperationName != "" {
+		span.SetTag("operation", in.OperationName)
+	}
+
+	schema, err := getGqlSchema()
+	if err != nil {
+		return err
+	}
+	query := &gqlQuery{
+		claims: claims,
+		cache:  make(map[string]interface{}),
+	}
+	res := schema.Execute(ctx, query, in.Query, in.OperationName, in.Variables)
+	resp := ormapi.GraphQLResponse{
+		Data: res.Data,
+	}
+	for _, e := range res.Errors {
+		resp.Errors = append(resp.Errors, ormapi.GraphQLError{
+			Message: e.Message,
+			Path:    e.Path,
+		})
+	}
+	return c.JSON(http.StatusOK, &resp)
+}
+
+// Root query type, which also holds the per query cache
+type gqlQuery struct {
+	claims  *UserClaims
+	regions []string
+	cache   map[string]interface{}
+}
+
+func (s *gqlQuery) Resolve(ctx context.Context, name string, args graphql.Args) (interface{}, error) {
+	switch name {
+	case "regions":
+		if err := args.Check(); err != nil {
+			return nil, err
+		}
+		return s.getRegions(ctx)
+	case "orgs":
+		if err := args.Check("name", "type"); err != nil {
+			return nil, err
+		}
+		filter := make(map[string]interface{})
+		for _, arg := range []string{"name", "type"} {
+			val, err := args.String(arg)
+			if err != nil {
+				return nil, err
+			}
+			if val != "" {
+				filter[arg] = val
+			}
+		}
+		orgs, err := ShowOrgObj(ctx, s.claims, filter)
+		if err != nil {
+			return nil, err
+		}
+		out := []*gqlOrg{}
+		for ii := range orgs {
+			out = append(out, &gqlOrg{
+				gqlFields: newGqlFields("Org", &orgs[ii]),
+				query:     s,
+				org:       &orgs[ii],
+			})
+		}
+		return out, nil
+	case "cloudletPools":
+		if err := args.Check("region", "org", "name"); err != nil {
+			return nil, err
+		}
+		org, name, err := getGqlKeyArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		return s.listCloudletPools(ctx, args, func(pool *edgeproto.CloudletPool) bool {
+			return matchGqlArg(org, pool.Key.Organization) && matchGqlArg(name, pool.Key.Name)
+		})
+	case "cloudlets":
+		if err := args.Check("region", "org", "name"); err != nil {
+			return nil, err
+		}
+		org, name, err := getGqlKeyArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		return s.listCloudlets(ctx, args, func(cloudlet *edgeproto.Cloudlet) bool {
+			return matchGqlArg(org, cloudlet.Key.Organization) && matchGqlArg(name, cloudlet.Key.Name)
+		})
+	case "clusterInsts":
+		if err := args.Check("region", "org", "name"); err != nil {
+			return nil, err
+		}
+		org, name, err := getGqlKeyArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		return s.listClusterInsts(ctx, args, func(ci *edgeproto.ClusterInst) bool {
+			return matchGqlArg(org, ci.Key.Organization) && matchGqlArg(name, ci.Key.ClusterKey.Name)
+		})
+	case "appInsts":
+		if err := args.Check("region", "org", "name"); err != nil {
+			return nil, err
+		}
+		org, name, err := getGqlKeyArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		return s.listAppInsts(ctx, args, func(ai *edgeproto.AppInst) bool {
+			return matchGqlArg(org, ai.Key.AppKey.Organization) && matchGqlArg(name, ai.Key.AppKey.Name)
+		})
+	}
+	return nil, fmt.Errorf("Unknown field %s on type Query", name)
+}
+
+func getGqlKeyArgs(args graphql.Args) (string, string, error) {
+	org, err := args.String("org")
+	if err != nil {
+		return "", "", err
+	}
+	name, err := args.String("name")
+	if err != nil {
+		return "", "", err
+	}
+	return org, name, nil
+}
+
+func matchGqlArg(arg, val string) bool {
+	return arg == "" || arg == val
+}
+
+func (s *gqlQuery) getRegions(ctx context.Context) ([]string, error) {
+	if s.regions == nil {
+		regions, err := getAllRegions(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.regions = regions
+	}
+	return s.regions, nil
+}
+
+// Get the regions to search, either the region argument or all regions
+func (s *gqlQuery) getArgRegions(ctx context.Context, args graphql.Args) ([]string, error) {
+	region, err := args.String("region")
+	if err != nil {
+		return nil, err
+	}
+	regions, err := s.getRegions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if region == "" {
+		return regions, nil
+	}
+	for _, r := range regions {
+		if r == region {
+			return []string{region}, nil
+		}
+	}
+	return nil, fmt.Errorf("Region %s not found", region)
+}
+
+func (s *gqlQuery) regionContext(region string) *ormutil.RegionContext {
+	return &ormutil.RegionContext{
+		Region:   region,
+		Username: s.claims.Username,
+		Database: database,
+	}
+}
+
+// Users with no access to any objects of the type in a region
+// just see no objects, rather than an error.
+func gqlShowAuthzErr(err error) error {
+	if err == echo.ErrForbidden {
+		return nil
+	}
+	return err
+}
+
+func (s *gqlQuery) getCloudletPools(ctx context.Context, region string) ([]*edgeproto.CloudletPool, error) {
+	key := region + "/cloudletpools"
+	if objs, found := s.cache[key]; found {
+		return objs.([]*edgeproto.CloudletPool), nil
+	}
+	objs := []*edgeproto.CloudletPool{}
+	authz, err := newShowAuthz(ctx, region, s.claims.Username, ResourceCloudletPools, ActionView)
+	if err == nil {
+		err = ctrlclient.ShowCloudletPoolStream(ctx, s.regionContext(region), &edgeproto.CloudletPool{}, connCache, authz, func(obj *edgeproto.CloudletPool) error {
+			objs = append(objs, obj)
+			return nil
+		})
+	}
+	if err = gqlShowAuthzErr(err); err != nil {
+		return nil, err
+	}
+	s.cache[key] = objs
+	return objs, nil
+}
+
+func (s *gqlQuery) getCloudlets(ctx context.Context, region string) ([]*edgeproto.Cloudlet, error) {
+	key := region + "/cloudlets"
+	if objs, found := s.cache[key]; found {
+		return objs.([]*edgeproto.Cloudlet), nil
+	}
+	objs := []*edgeproto.Cloudlet{}
+	authz, err := newShowCloudletAuthz(ctx, region, s.claims.Username, ResourceCloudlets, ActionView)
+	if err == nil {
+		err = ctrlclient.ShowCloudletStream(ctx, s.regionContext(region), &edgeproto.Cloudlet{}, connCache, authz, func(obj *edgeproto.Cloudlet) error {
+			objs = append(objs, obj)
+			return nil
+		})
+	}
+	if err = gqlShowAuthzErr(err); err != nil {
+		return nil, err
+	}
+	s.cache[key] = objs
+	return objs, nil
+}
+
+func (s *gqlQuery) getClusterInsts(ctx context.Context, region string) ([]*edgeproto.ClusterInst, error) {
+	key := region + "/clusterinsts"
+	if objs, found := s.cache[key]; found {
+		return objs.([]*edgeproto.ClusterInst), nil
+	}
+	objs := []*edgeproto.ClusterInst{}
+	authz, err := newShowClusterInstAuthz(ctx, region, s.claims.Username, ResourceClusterInsts, ActionView)
+	if err == nil {
+		err = ctrlclient.ShowClusterInstStream(ctx, s.regionContext(region), &edgeproto.ClusterInst{}, connCache, authz, func(obj *edgeproto.ClusterInst) error {
+			objs = append(objs, obj)
+			return nil
+		})
+	}
+	if err = gqlShowAuthzErr(err); err != nil {
+		return nil, err
+	}
+	s.cache[key] = objs
+	return objs, nil
+}
+
+func (s *gqlQuery) getAppInsts(ctx context.Context, region string) ([]*edgeproto.AppInst, error) {
+	key := region + "/appinsts"
+	if objs, found := s.cache[key]; found {
+		return objs.([]*edgeproto.AppInst), nil
+	}
+	objs := []*edgeproto.AppInst{}
+	authz, err := newShowAppInstAuthz(ctx, region, s.claims.Username, ResourceAppInsts, ActionView)
+	if err == nil {
+		err = ctrlclient.ShowAppInstStream(ctx, s.regionContext(region), &edgeproto.AppInst{}, connCache, authz, func(obj *edgeproto.AppInst) error {
+			objs = append(objs, obj)
+			return nil
+		})
+	}
+	if err = gqlShowAuthzErr(err); err != nil {
+		return nil, err
+	}
+	s.cache[key] = objs
+	return objs, nil
+}
+
+func (s *gqlQuery) listCloudletPools(ctx context.Context, args graphql.Args, match func(pool *edgeproto.CloudletPool) bool) ([]*gqlCloudletPool, error) {
+	regions, err := s.getArgRegions(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	out := []*gqlCloudletPool{}
+	for _, region := range regions {
+		pools, err := s.getCloudletPools(ctx, region)
+		if err != nil {
+			return nil, err
+		}
+		for _, pool := range pools {
+			if match(pool) {
+				out = append(out, s.newCloudletPool(region, pool))
+			}
+		}
+	}
+	return out, nil
+}
+
+func (s *gqlQuery) listCloudlets(ctx context.Context, args graphql.Args, match func(cloudlet *edgeproto.Cloudlet) bool) ([]*gqlCloudlet, error) {
+	regions, err := s.getArgRegions(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	out := []*gqlCloudlet{}
+	for _, region := range regions {
+		cloudlets, err := s.getCloudlets(ctx, region)
+		if err != nil {
+			return nil, err
+		}
+		batch := newGqlMetricsBatch()
+		for _, cloudlet := range cloudlets {
+			if match(cloudlet) {
+				batch.cloudlets = append(batch.cloudlets, cloudlet.Key)
+				out = append(out, s.newCloudlet(region, cloudlet, batch))
+			}
+		}
+	}
+	return out, nil
+}
+
+func (s *gqlQuery) listClusterInsts(ctx context.Context, args graphql.Args, match func(ci *edgeproto.ClusterInst) bool) ([]*gqlClusterInst, error) {
+	regions, err := s.getArgRegions(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	out := []*gqlClusterInst{}
+	for _, region := range regions {
+		cis, err := s.getClusterInsts(ctx, region)
+		if err != nil {
+			return nil, err
+		}
+		batch := newGqlMetricsBatch()
+		for _, ci := range cis {
+			if match(ci) {
+				batch.clusterInsts = append(batch.clusterInsts, ci.Key)
+				out = append(out, s.newClusterInst(region, ci, batch))
+			}
+		}
+	}
+	return out, nil
+}
+
+func (s *gqlQuery) listAppInsts(ctx context.Context, args graphql.Args, match func(ai *edgeproto.AppInst) bool) ([]*gqlAppInst, error) {
+	regions, err := s.getArgRegions(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	out := []*gqlAppInst{}
+	for _, region := range regions {
+		ais, err := s.getAppInsts(ctx, region)
+		if err != nil {
+			return nil, err
+		}
+		batch := newGqlMetricsBatch()
+		for _, ai := range ais {
+			if match(ai) {
+				batch.appInsts = append(batch.appInsts, ai.Key)
+				out = append(out, s.newAppInst(region, ai, batch))
+			}
+		}
+	}
+	return out, nil
+}
+
+// Convert to generic JSON values so that nested objects
+// can be selected by their JSON field names.
+func toGqlValue(obj interface{}) (interface{}, error) {
+	dat, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var val interface{}
+	if err := json.Unmarshal(dat, &val); err != nil {
+		return nil, err
+	}
+	return val, nil
+}
+
+// JSON fields of an object. Fields omitted from the JSON because
+// they are empty resolve to null, other unknown fields are errors.
+type gqlFields struct {
+	typeName string
+	obj      interface{}
+	data     map[string]interface{}
+}
+
+func newGqlFields(typeName string, obj interface{}) gqlFields {
+	return gqlFields{
+		typeName: typeName,
+		obj:      obj,
+	}
+}
+
+func (s *gqlFields) field(name string, args graphql.Args) (interface{}, error) {
+	if s.data == nil {
+		val, err := toGqlValue(s.obj)
+		if err != nil {
+			return nil, err
+		}
+		data, ok := val.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Type %s is not an object", s.typeName)
+		}
+		s.data = data
+	}
+	val, found := s.data[name]
+	if !found && !hasJSONField(reflect.TypeOf(s.obj), name) {
+		return nil, fmt.Errorf("Unknown field %s on type %s", name, s.typeName)
+	}
+	if len(args) > 0 {
+		return nil, fmt.Errorf("Field %s does not take arguments", name)
+	}
+	return val, nil
+}
+
+func hasJSONField(t reflect.Type, name string) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for ii := 0; ii < t.NumField(); ii++ {
+		sf := t.Field(ii)
+		if sf.PkgPath != "" {
+			// unexported
+			continue
+		}
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		tagName := strings.Split(tag, ",")[0]
+		if sf.Anonymous && tagName == "" {
+			if hasJSONField(sf.Type, name) {
+				return true
+			}
+			continue
+		}
+		if tagName == "" {
+			tagName = sf.Name
+		}
+		if tagName == name {
+			return true
+		}
+	}
+	return false
+}
+
+type gqlOrg struct {
+	gqlFields
+	query *gqlQuery
+	org   *ormapi.Organization
+}
+
+func (s *gqlOrg) Resolve(ctx context.Context, name string, args graphql.Args) (interface{}, error) {
+	switch name {
+	case "cloudletPools":
+		if err := args.Check("region"); err != nil {
+			return nil, err
+		}
+		return s.query.listCloudletPools(ctx, args, func(pool *edgeproto.CloudletPool) bool {
+			return pool.Key.Organization == s.org.Name
+		})
+	case "cloudlets":
+		if err := args.Check("region"); err != nil {
+			return nil, err
+		}
+		return s.query.listCloudlets(ctx, args, func(cloudlet *edgeproto.Cloudlet) bool {
+			return cloudlet.Key.Organization == s.org.Name
+		})
+	case "clusterInsts":
+		if err := args.Check("region"); err != nil {
+			return nil, err
+		}
+		return s.query.listClusterInsts(ctx, args, func(ci *edgeproto.ClusterInst) bool {
+			return ci.Key.Organization == s.org.Name
+		})
+	case "appInsts":
+		if err := args.Check("region"); err != nil {
+			return nil, err
+		}
+		return s.query.listAppInsts(ctx, args, func(ai *edgeproto.AppInst) bool {
+			return ai.Key.AppKey.Organization == s.org.Name
+		})
+	}
+	return s.field(name, args)
+}
+
+type gqlCloudletPool struct {
+	gqlFields
+	query  *gqlQuery
+	region string
+	pool   *edgeproto.CloudletPool
+}
+
+func (s *gqlQuery) newCloudletPool(region string, pool *edgeproto.CloudletPool) *gqlCloudletPool {
+	return &gqlCloudletPool{
+		gqlFields: newGqlFields("CloudletPool", pool),
+		query:     s,
+		region:    region,
+		pool:      pool,
+	}
+}
+
+func (s *gqlCloudletPool) Resolve(ctx context.Context, name string, args graphql.Args) (interface{}, error) {
+	switch name {
+	case "region":
+		return s.region, args.Check()
+	case "cloudlets":
+		// member cloudlets that the user can see
+		if err := args.Check(); err != nil {
+			return nil, err
+		}
+		members := make(map[edgeproto.CloudletKey]struct{})
+		for _, key := range s.pool.Cloudlets {
+			members[key] = struct{}{}
+		}
+		return s.query.listCloudlets(ctx, graphql.Args{"region": s.region}, func(cloudlet *edgeproto.Cloudlet) bool {
+			_, found := members[cloudlet.Key]
+			return found
+		})
+	}
+	return s.field(name, args)
+}
+
+type gqlCloudlet struct {
+	gqlFields
+	query    *gqlQuery
+	region   string
+	cloudlet *edgeproto.Cloudlet
+	batch    *gqlMetricsBatch
+}
+
+func (s *gqlQuery) newCloudlet(region string, cloudlet *edgeproto.Cloudlet, batch *gqlMetricsBatch) *gqlCloudlet {
+	return &gqlCloudlet{
+		gqlFields: newGqlFields("Cloudlet", cloudlet),
+		query:     s,
+		region:    region,
+		cloudlet:  cloudlet,
+		batch:     batch,
+	}
+}
+
+func (s *gqlCloudlet) Resolve(ctx context.Context, name string, args graphql.Args) (interface{}, error) {
+	regionArgs := graphql.Args{"region": s.region}
+	switch name {
+	case "region":
+		return s.region, args.Check()
+	case "cloudletPools":
+		if err := args.Check(); err != nil {
+			return nil, err
+		}
+		return s.query.listCloudletPools(ctx, regionArgs, func(pool *edgeproto.CloudletPool) bool {
+			for _, key := range pool.Cloudlets {
+				if key == s.cloudlet.Key {
+					return true
+				}
+			}
+			return false
+		})
+	case "clusterInsts":
+		if err := args.Check(); err != nil {
+			return nil, err
+		}
+		return s.query.listClusterInsts(ctx, regionArgs, func(ci *edgeproto.ClusterInst) bool {
+			return ci.Key.CloudletKey == s.cloudlet.Key
+		})
+	case "appInsts":
+		if err := args.Check(); err != nil {
+			return nil, err
+		}
+		return s.query.listAppInsts(ctx, regionArgs, func(ai *edgeproto.AppInst) bool {
+			return ai.Key.ClusterInstKey.CloudletKey == s.cloudlet.Key
+		})
+	case "metrics":
+		selector, common, err := getGqlMetricsArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		newObj := func(keys []edgeproto.CloudletKey) MetricsObject {
+			return &cloudletMetrics{
+				RegionCloudletMetrics: &ormapi.RegionCloudletMetrics{
+					Region:        s.region,
+					Cloudlets:     keys,
+					Selector:      selector,
+					MetricsCommon: common,
+				},
+			}
+		}
+		tags := map[string]string{
+			"cloudlet":    s.cloudlet.Key.Name,
+			"cloudletorg": s.cloudlet.Key.Organization,
+		}
+		return s.query.getBatchMetrics(ctx, s.batch, newObj(s.batch.cloudlets), newObj([]edgeproto.CloudletKey{s.cloudlet.Key}), tags)
+	}
+	return s.field(name, args)
+}
+
+type gqlClusterInst struct {
+	gqlFields
+	query  *gqlQuery
+	region string
+	ci     *edgeproto.ClusterInst
+	batch  *gqlMetricsBatch
+}
+
+func (s *gqlQuery) newClusterInst(region string, ci *edgeproto.ClusterInst, batch *gqlMetricsBatch) *gqlClusterInst {
+	return &gqlClusterInst{
+		gqlFields: newGqlFields("ClusterInst", ci),
+		query:     s,
+		region:    region,
+		ci:        ci,
+		batch:     batch,
+	}
+}
+
+func (s *gqlClusterInst) Resolve(ctx context.Context, name string, args graphql.Args) (interface{}, error) {
+	regionArgs := graphql.Args{"region": s.region}
+	switch name {
+	case "region":
+		return s.region, args.Check()
+	case "cloudlet":
+		if err := args.Check(); err != nil {
+			return nil, err
+		}
+		cloudlets, err := s.query.listCloudlets(ctx, regionArgs, func(cloudlet *edgeproto.Cloudlet) bool {
+			return cloudlet.Key == s.ci.Key.CloudletKey
+		})
+		if err != nil || len(cloudlets) == 0 {
+			return nil, err
+		}
+		return cloudlets[0], nil
+	case "appInsts":
+		if err := args.Check(); err != nil {
+			return nil, err
+		}
+		return s.query.listAppInsts(ctx, regionArgs, func(ai *edgeproto.AppInst) bool {
+			return ai.Key.ClusterInstKey.ClusterKey == s.ci.Key.ClusterKey &&
+				ai.Key.ClusterInstKey.CloudletKey == s.ci.Key.CloudletKey &&
+				ai.Key.ClusterInstKey.Organization == s.ci.Key.Organization
+		})
+	case "metrics":
+		selector, common, err := getGqlMetricsArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		newObj := func(keys []edgeproto.ClusterInstKey) MetricsObject {
+			return &clusterInstMetrics{
+				RegionClusterInstMetrics: &ormapi.RegionClusterInstMetrics{
+					Region:        s.region,
+					ClusterInsts:  keys,
+					Selector:      selector,
+					MetricsCommon: common,
+				},
+			}
+		}
+		tags := map[string]string{
+			"cluster":     s.ci.Key.ClusterKey.Name,
+			"clusterorg":  s.ci.Key.Organization,
+			"cloudlet":    s.ci.Key.CloudletKey.Name,
+			"cloudletorg": s.ci.Key.CloudletKey.Organization,
+		}
+		return s.query.getBatchMetrics(ctx, s.batch, newObj(s.batch.clusterInsts), newObj([]edgeproto.ClusterInstKey{s.ci.Key}), tags)
+	}
+	return s.field(name, args)
+}
+
+type gqlAppInst struct {
+	gqlFields
+	query  *gqlQuery
+	region string
+	ai     *edgeproto.AppInst
+	batch  *gqlMetricsBatch
+}
+
+func (s *gqlQuery) newAppInst(region string, ai *edgeproto.AppInst, batch *gqlMetricsBatch) *gqlAppInst {
+	return &gqlAppInst{
+		gqlFields: newGqlFields("AppInst", ai),
+		query:     s,
+		region:    region,
+		ai:        ai,
+		batch:     batch,
+	}
+}
+
+func (s *gqlAppInst) Resolve(ctx context.Context, name string, args graphql.Args) (interface{}, error) {
+	regionArgs := graphql.Args{"region": s.region}
+	switch name {
+	case "region":
+		return s.region, args.Check()
+	case "cloudlet":
+		if err := args.Check(); err != nil {
+			return nil, err
+		}
+		cloudlets, err := s.query.listCloudlets(ctx, regionArgs, func(cloudlet *edgeproto.Cloudlet) bool {
+			return cloudlet.Key == s.ai.Key.ClusterInstKey.CloudletKey
+		})
+		if err != nil || len(cloudlets) == 0 {
+			return nil, err
+		}
+		return cloudlets[0], nil
+	case "clusterInst":
+		if err := args.Check(); err != nil {
+			return nil, err
+		}
+		cis, err := s.query.listClusterInsts(ctx, regionArgs, func(ci *edgeproto.ClusterInst) bool {
+			return ci.Key.ClusterKey == s.ai.Key.ClusterInstKey.ClusterKey &&
+				ci.Key.CloudletKey == s.ai.Key.ClusterInstKey.CloudletKey &&
+				ci.Key.Organization == s.ai.Key.ClusterInstKey.Organization
+		})
+		if err != nil || len(cis) == 0 {
+			return nil, err
+		}
+		return cis[0], nil
+	case "metrics":
+		selector, common, err := getGqlMetricsArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		newObj := func(keys []edgeproto.AppInstKey) MetricsObject {
+			return &appInstMetrics{
+				RegionAppInstMetrics: &ormapi.RegionAppInstMetrics{
+					Region:        s.region,
+					AppInsts:      keys,
+					Selector:      selector,
+					MetricsCommon: common,
+				},
+			}
+		}
+		key := &s.ai.Key
+		tags := map[string]string{
+			"app":         util.DNSSanitize(key.AppKey.Name),
+			"ver":         util.DNSSanitize(key.AppKey.Version),
+			"apporg":      key.AppKey.Organization,
+			"cluster":     key.ClusterInstKey.ClusterKey.Name,
+			"clusterorg":  key.ClusterInstKey.Organization,
+			"cloudlet":    key.ClusterInstKey.CloudletKey.Name,
+			"cloudletorg": key.ClusterInstKey.CloudletKey.Organization,
+		}
+		return s.query.getBatchMetrics(ctx, s.batch, newObj(s.batch.appInsts), newObj([]edgeproto.AppInstKey{*key}), tags)
+	}
+	return s.field(name, args)
+}
+
+// Arguments for metrics fields, times are in RFC3339 format
+func getGqlMetricsArgs(args graphql.Args) (string, ormapi.MetricsCommon, error) {
+	common := ormapi.MetricsCommon{}
+	if err := args.Check("selector", "limit", "numSamples", "startTime", "endTime"); err != nil {
+		return "", common, err
+	}
+	selector, err := args.String("selector")
+	if err != nil {
+		return "", common, err
+	}
+	if selector == "" {
+		selector = "*"
+	}
+	if common.Limit, err = args.Int("limit"); err != nil {
+		return "", common, err
+	}
+	if common.NumSamples, err = args.Int("numSamples"); err != nil {
+		return "", common, err
+	}
+	for _, arg := range []string{"startTime", "endTime"} {
+		str, err := args.String(arg)
+		if err != nil {
+			return "", common, err
+		}
+		if str == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, str)
+		if err != nil {
+			return "", common, fmt.Errorf("Invalid %s %q, must be in RFC3339 format", arg, str)
+		}
+		if arg == "startTime" {
+			common.StartTime = t
+		} else {
+			common.EndTime = t
+		}
+	}
+	return selector, common, nil
+}
+
+func (s *gqlQuery) getMetrics(ctx context.Context, obj MetricsObject) (interface{}, error) {
+	var out interface{}
+	err := queryMetricsCommon(ctx, s.claims, obj, func(res interface{}) error {
+		val, err := toGqlValue(res)
+		if err != nil {
+			return err
+		}
+		out = val
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Metrics of the objects in a list are fetched with a single query
+// for all of them, the first time one of them is asked for metrics
+// with a given set of arguments. The metrics queries group by the
+// object key tags, so the results are split up by the series tags.
+type gqlMetricsBatch struct {
+	cloudlets    []edgeproto.CloudletKey
+	clusterInsts []edgeproto.ClusterInstKey
+	appInsts     []edgeproto.AppInstKey
+	results      map[string][]influxdb.Result
+}
+
+func newGqlMetricsBatch() *gqlMetricsBatch {
+	return &gqlMetricsBatch{
+		results: make(map[string][]influxdb.Result),
+	}
+}
+
+func (s *gqlQuery) getBatchMetrics(ctx context.Context, batch *gqlMetricsBatch, batchObj, obj MetricsObject, tags map[string]string) (interface{}, error) {
+	if batchObj.GetObjCount() <= 1 {
+		return s.getMetrics(ctx, obj)
+	}
+	argsDat, err := json.Marshal(batchObj.GetMetricsCommon())
+	if err != nil {
+		return nil, err
+	}
+	argsKey := strings.Join(batchObj.GetSelectors(), ",") + string(argsDat)
+	results, found := batch.results[argsKey]
+	if !found {
+		err := queryMetricsCommon(ctx, s.claims, batchObj, func(res interface{}) error {
+			list, ok := res.([]influxdb.Result)
+			if !ok {
+				return fmt.Errorf("Unexpected metrics result type %T", res)
+			}
+			results = list
+			return nil
+		})
+		if err != nil {
+			// query each object instead, so that errors like
+			// permission errors only apply to their objects
+			log.SpanLog(ctx, log.DebugLevelApi, "GraphQL batch metrics query failed", "type", batchObj.GetType(), "count", batchObj.GetObjCount(), "err", err)
+			results = nil
+		}
+		batch.results[argsKey] = results
+	}
+	if results == nil {
+		return s.getMetrics(ctx, obj)
+	}
+	return toGqlValue(filterMetricsSeries(results, tags))
+}
+
+// Get the series of the results whose tags match the given tags
+func filterMetricsSeries(results []influxdb.Result, tags map[string]string) []influxdb.Result {
+	out := []influxdb.Result{}
+	for _, res := range results {
+		filtered := res
+		filtered.Series = []models.Row{}
+		for _, row := range res.Series {
+			match := true
+			for k, v := range tags {
+				if row.Tags[k] != v {
+					match = false
+					break
+				}
+			}
+			if match {
+				filtered.Series = append(filtered.Series, row)
+			}
+		}
+		out = append(out, filtered)
+	}
+	return out
+}
@@ -0,0 +1,137 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	influxdb "github.com/influxdata/influxdb/client/v2"
+	"github.com/influxdata/influxdb/models"
+	"github.com/mobiledgex/edge-cloud-infra/mc/graphql"
+	"github.com/mobiledgex/edge-cloud-infra/mc/mcctl/mctestclient"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraphQLFields(t *testing.T) {
+	// schema types are built from the API objects
+	_, err := getGqlSchema()
+	require.Nil(t, err)
+
+	org := &ormapi.Organization{
+		Name: "devorg",
+		Type: OrgTypeDeveloper,
+	}
+	fields := newGqlFields("Org", org)
+	val, err := fields.field("Name", nil)
+	require.Nil(t, err)
+	require.Equal(t, "devorg", val)
+	// omitted empty field
+	val, err = fields.field("Address", nil)
+	require.Nil(t, err)
+	require.Nil(t, val)
+	_, err = fields.field("Foo", nil)
+	require.NotNil(t, err)
+	require.Equal(t, "Unknown field Foo on type Org", err.Error())
+	_, err = fields.field("Name", graphql.Args{"foo": "bar"})
+	require.NotNil(t, err)
+
+	selector, common, err := getGqlMetricsArgs(graphql.Args{
+		"limit":     int64(10),
+		"startTime": "2022-01-01T00:00:00Z",
+	})
+	require.Nil(t, err)
+	require.Equal(t, "*", selector)
+	require.Equal(t, 10, common.Limit)
+	require.Equal(t, time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), common.StartTime)
+	require.True(t, common.EndTime.IsZero())
+	_, _, err = getGqlMetricsArgs(graphql.Args{"endTime": "yesterday"})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "must be in RFC3339 format")
+	_, _, err = getGqlMetricsArgs(graphql.Args{"foo": "bar"})
+	require.NotNil(t, err)
+
+	// batched metrics are split up by the series tags
+	results := []influxdb.Result{{
+		Series: []models.Row{{
+			Name: "cloudlet-utilization",
+			Tags: map[string]string{"cloudlet": "c1", "cloudletorg": "oper"},
+		}, {
+			Name: "cloudlet-utilization",
+			Tags: map[string]string{"cloudlet": "c2", "cloudletorg": "oper"},
+		}},
+	}}
+	filtered := filterMetricsSeries(results, map[string]string{"cloudlet": "c2", "cloudletorg": "oper"})
+	require.Equal(t, 1, len(filtered))
+	require.Equal(t, 1, len(filtered[0].Series))
+	require.Equal(t, "c2", filtered[0].Series[0].Tags["cloudlet"])
+	require.Equal(t, 2, len(results[0].Series))
+	filtered = filterMetricsSeries(results, map[string]string{"cloudlet": "c3", "cloudletorg": "oper"})
+	require.Equal(t, 0, len(filtered[0].Series))
+}
+
+// Check that the GraphQL API shows the same cluster and app instances
+// as the show APIs for the user.
+func testGraphQLShowInsts(t *testing.T, mcClient *mctestclient.Client, uri, token, region, org string, count int) {
+	query := ormapi.GraphQLQuery{
+		Query: fmt.Sprintf(`{
+			clusterInsts(region: %q, org: %q) { region key { organization } }
+			appInsts(region: %q, org: %q) { region clusterInst { key { organization } } }
+		}`, region, org, region, org),
+	}
+	resp, status, err := mcClient.GraphQL(uri, token, &query)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Nil(t, resp.Errors)
+	data, ok := resp.Data.(map[string]interface{})
+	require.True(t, ok)
+	for _, field := range []string{"clusterInsts", "appInsts"} {
+		list, ok := data[field].([]interface{})
+		require.True(t, ok, field)
+		require.Equal(t, count, len(list), field)
+		for _, obj := range list {
+			require.Equal(t, region, obj.(map[string]interface{})["region"])
+		}
+	}
+
+	// unknown regions are reported as field errors
+	query.Query = `{ clusterInsts(region: "nosuchregion") { region } }`
+	resp, status, err = mcClient.GraphQL(uri, token, &query)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, 1, len(resp.Errors))
+	require.Equal(t, "Region nosuchregion not found", resp.Errors[0].Message)
+	require.Equal(t, []interface{}{"clusterInsts"}, resp.Errors[0].Path)
+
+	// unknown fields fail validation of the whole query
+	query.Query = `{ clusterInsts { region } orgs { foo } }`
+	resp, status, err = mcClient.GraphQL(uri, token, &query)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Nil(t, resp.Data)
+	require.Equal(t, 1, len(resp.Errors))
+	require.Contains(t, resp.Errors[0].Message, `Cannot query field "foo" on type "Org"`)
+
+	// only queries are allowed
+	query.Query = `mutation { deleteOrg(name: "foo") { name } }`
+	resp, status, err = mcClient.GraphQL(uri, token, &query)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Nil(t, resp.Data)
+	require.Equal(t, 1, len(resp.Errors))
+}
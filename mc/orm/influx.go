@@ -266,6 +266,12 @@ func (c *InfluxDbConnCache) DeleteClient(region string) {
 	delete(c.clients, region)
 }
 
+func (c *InfluxDbConnCache) Count() int {
+	c.RLock()
+	defer c.RUnlock()
+	return len(c.clients)
+}
+
 func (c *InfluxDbConnCache) CloseIdleConnections(region string) {
 	c.Lock()
 	defer c.Unlock()
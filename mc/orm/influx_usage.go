@@ -709,16 +709,79 @@ func GetEventAndCheckpoint(ctx context.Context, rc *InfluxDBContext, eventCmd, c
 	}
 }
 
+// Get AppInst usage records for the given filter, limited to
+// the cloudlets the user has access to
+func GetAppInstUsageData(ctx context.Context, claims *UserClaims, in *ormapi.RegionAppInstUsage) (*ormapi.MetricData, error) {
+	// validate all the passed in arguments
+	if err := util.ValidateNames(in.AppInst.GetTags()); err != nil {
+		return nil, err
+	}
+
+	// start and end times must be specified
+	if in.StartTime.IsZero() || in.EndTime.IsZero() {
+		return nil, fmt.Errorf("Both start and end times must be specified")
+	}
+
+	cloudletList, err := checkPermissionsAndGetCloudletList(ctx, claims.Username, in.Region, []string{in.AppInst.AppKey.Organization},
+		ResourceAppAnalytics, []edgeproto.CloudletKey{in.AppInst.ClusterInstKey.CloudletKey})
+	if err != nil {
+		return nil, err
+	}
+
+	rc := &InfluxDBContext{
+		claims: claims,
+		region: in.Region,
+	}
+	eventCmd := AppInstUsageEventsQuery(in, cloudletList)
+	checkpointCmd := AppInstCheckpointsQuery(in, cloudletList)
+
+	eventResp, checkResp, err := GetEventAndCheckpoint(ctx, rc, eventCmd, checkpointCmd)
+	if err != nil {
+		return nil, err
+	}
+	return GetAppUsage(eventResp, checkResp, in.StartTime, in.EndTime, in.Region)
+}
+
+// Get ClusterInst usage records for the given filter, limited to
+// the cloudlets the user has access to
+func GetClusterInstUsageData(ctx context.Context, claims *UserClaims, in *ormapi.RegionClusterInstUsage) (*ormapi.MetricData, error) {
+	// validate all the passed in arguments
+	if err := util.ValidateNames(in.ClusterInst.GetTags()); err != nil {
+		return nil, err
+	}
+
+	// start and end times must be specified
+	if in.StartTime.IsZero() || in.EndTime.IsZero() {
+		return nil, fmt.Errorf("Both start and end times must be specified")
+	}
+
+	cloudletList, err := checkPermissionsAndGetCloudletList(ctx, claims.Username, in.Region, []string{in.ClusterInst.Organization},
+		ResourceClusterAnalytics, []edgeproto.CloudletKey{in.ClusterInst.CloudletKey})
+	if err != nil {
+		return nil, err
+	}
+
+	rc := &InfluxDBContext{
+		claims: claims,
+		region: in.Region,
+	}
+	eventCmd := ClusterUsageEventsQuery(in, cloudletList)
+	checkpointCmd := ClusterCheckpointsQuery(in, cloudletList)
+
+	eventResp, checkResp, err := GetEventAndCheckpoint(ctx, rc, eventCmd, checkpointCmd)
+	if err != nil {
+		return nil, err
+	}
+	return GetClusterUsage(ctx, eventResp, checkResp, in.StartTime, in.EndTime, in.Region)
+}
+
 // Common method to handle both app and cluster metrics
 func GetUsageCommon(c echo.Context) error {
-	var checkpointCmd, eventCmd string
 	var usage *ormapi.MetricData
-	rc := &InfluxDBContext{}
 	claims, err := getClaims(c)
 	if err != nil {
 		return err
 	}
-	rc.claims = claims
 	ctx := ormutil.GetContext(c)
 
 	if strings.HasSuffix(c.Path(), "usage/app") {
@@ -727,32 +790,7 @@ func GetUsageCommon(c echo.Context) error {
 		if err != nil {
 			return err
 		}
-		// validate all the passed in arguments
-		if err = util.ValidateNames(in.AppInst.GetTags()); err != nil {
-			return err
-		}
-
-		// start and end times must be specified
-		if in.StartTime.IsZero() || in.EndTime.IsZero() {
-			return fmt.Errorf("Both start and end times must be specified")
-		}
-
-		cloudletList, err := checkPermissionsAndGetCloudletList(ctx, claims.Username, in.Region, []string{in.AppInst.AppKey.Organization},
-			ResourceAppAnalytics, []edgeproto.CloudletKey{in.AppInst.ClusterInstKey.CloudletKey})
-		if err != nil {
-			return err
-		}
-
-		rc.region = in.Region
-
-		eventCmd = AppInstUsageEventsQuery(&in, cloudletList)
-		checkpointCmd = AppInstCheckpointsQuery(&in, cloudletList)
-
-		eventResp, checkResp, err := GetEventAndCheckpoint(ctx, rc, eventCmd, checkpointCmd)
-		if err != nil {
-			return err
-		}
-		usage, err = GetAppUsage(eventResp, checkResp, in.StartTime, in.EndTime, in.Region)
+		usage, err = GetAppInstUsageData(ctx, claims, &in)
 		if err != nil {
 			return err
 		}
@@ -762,32 +800,7 @@ func GetUsageCommon(c echo.Context) error {
 		if err != nil {
 			return err
 		}
-		// validate all the passed in arguments
-		if err = util.ValidateNames(in.ClusterInst.GetTags()); err != nil {
-			return err
-		}
-
-		// start and end times must be specified
-		if in.StartTime.IsZero() || in.EndTime.IsZero() {
-			return fmt.Errorf("Both start and end times must be specified")
-		}
-
-		cloudletList, err := checkPermissionsAndGetCloudletList(ctx, claims.Username, in.Region, []string{in.ClusterInst.Organization},
-			ResourceClusterAnalytics, []edgeproto.CloudletKey{in.ClusterInst.CloudletKey})
-		if err != nil {
-			return err
-		}
-
-		rc.region = in.Region
-
-		eventCmd = ClusterUsageEventsQuery(&in, cloudletList)
-		checkpointCmd = ClusterCheckpointsQuery(&in, cloudletList)
-
-		eventResp, checkResp, err := GetEventAndCheckpoint(ctx, rc, eventCmd, checkpointCmd)
-		if err != nil {
-			return err
-		}
-		usage, err = GetClusterUsage(ctx, eventResp, checkResp, in.StartTime, in.EndTime, in.Region)
+		usage, err = GetClusterInstUsageData(ctx, claims, &in)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,581 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/mobiledgex/edge-cloud-infra/autoprov/maintwindow"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ctrlclient"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormutil"
+	"github.com/mobiledgex/edge-cloud/cloudcommon/node"
+	"github.com/mobiledgex/edge-cloud/edgeproto"
+	"github.com/mobiledgex/edge-cloud/log"
+)
+
+const (
+	MaintWindowRecurrenceNone    = "None"
+	MaintWindowRecurrenceDaily   = "Daily"
+	MaintWindowRecurrenceWeekly  = "Weekly"
+	MaintWindowRecurrenceMonthly = "Monthly"
+
+	MaintWindowStateScheduled = "Scheduled"
+	MaintWindowStateOpen      = "Open"
+	MaintWindowStateClosed    = "Closed"
+)
+
+var (
+	maintWindowTrigger chan bool
+
+	MaintWindowCheckInterval = 1 * time.Minute
+
+	// Cloudlets last published to Vault per region, only accessed
+	// by the maintenance window thread
+	maintWindowPublished = make(map[string]string)
+)
+
+// Get the next occurrence of the recurring window
+func getNextMaintWindowTime(t time.Time, recurrence string) time.Time {
+	switch recurrence {
+	case MaintWindowRecurrenceDaily:
+		return t.AddDate(0, 0, 1)
+	case MaintWindowRecurrenceWeekly:
+		return t.AddDate(0, 0, 7)
+	case MaintWindowRecurrenceMonthly:
+		return t.AddDate(0, 1, 0)
+	}
+	return t
+}
+
+func validMaintWindowRecurrence(recurrence string) error {
+	switch recurrence {
+	case MaintWindowRecurrenceNone:
+	case MaintWindowRecurrenceDaily:
+	case MaintWindowRecurrenceWeekly:
+	case MaintWindowRecurrenceMonthly:
+	default:
+		return fmt.Errorf("Invalid recurrence %s, must be one of %s, %s, %s, %s", recurrence,
+			MaintWindowRecurrenceNone, MaintWindowRecurrenceDaily,
+			MaintWindowRecurrenceWeekly, MaintWindowRecurrenceMonthly)
+	}
+	return nil
+}
+
+// Get the cloudlets covered by the maintenance window
+func getMaintWindowCloudlets(ctx context.Context, window *ormapi.MaintenanceWindow) ([]edgeproto.CloudletKey, error) {
+	rc := ormutil.RegionContext{
+		Region:    window.Region,
+		SkipAuthz: true,
+		Database:  loggedDB(ctx),
+	}
+	cloudlets := []edgeproto.CloudletKey{}
+	if window.Cloudlet != "" {
+		filter := edgeproto.Cloudlet{
+			Key: edgeproto.CloudletKey{
+				Name:         window.Cloudlet,
+				Organization: window.Org,
+			},
+		}
+		err := ctrlclient.ShowCloudletStream(ctx, &rc, &filter, connCache, nil, func(cloudlet *edgeproto.Cloudlet) error {
+			cloudlets = append(cloudlets, cloudlet.Key)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(cloudlets) == 0 {
+			return nil, fmt.Errorf("Cloudlet %s not found in region %s", window.Cloudlet, window.Region)
+		}
+		return cloudlets, nil
+	}
+	filter := edgeproto.CloudletPool{
+		Key: edgeproto.CloudletPoolKey{
+			Name:         window.CloudletPool,
+			Organization: window.Org,
+		},
+	}
+	found := false
+	err := ctrlclient.ShowCloudletPoolStream(ctx, &rc, &filter, connCache, nil, func(pool *edgeproto.CloudletPool) error {
+		found = true
+		cloudlets = append(cloudlets, pool.Cloudlets...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("Cloudlet pool %s not found in region %s", window.CloudletPool, window.Region)
+	}
+	return cloudlets, nil
+}
+
+func getMaintWindowEventTags(window *ormapi.MaintenanceWindow) map[string]string {
+	tags := map[string]string{
+		"maintenancewindow": window.Name,
+		"region":            window.Region,
+		"cloudletorg":       window.Org,
+	}
+	if window.Cloudlet != "" {
+		tags["cloudlet"] = window.Cloudlet
+	}
+	if window.CloudletPool != "" {
+		tags["cloudletpool"] = window.CloudletPool
+	}
+	return tags
+}
+
+// Silence the alerts of all cloudlets covered by the window
+func openMaintWindow(ctx context.Context, window *ormapi.MaintenanceWindow) error {
+	cloudlets, err := getMaintWindowCloudlets(ctx, window)
+	if err != nil {
+		return err
+	}
+	window.SilenceIds = []string{}
+	if AlertManagerServer != nil {
+		for _, cloudlet := range cloudlets {
+			silence := ormapi.AlertSilence{
+				Region:    window.Region,
+				Cloudlet:  cloudlet,
+				StartsAt:  window.StartTime,
+				EndsAt:    window.EndTime,
+				CreatedBy: window.Username,
+				Comment:   fmt.Sprintf("Maintenance window %s: %s", window.Name, window.Comment),
+			}
+			id, err := AlertManagerServer.CreateCloudletSilence(ctx, &silence)
+			if err != nil {
+				// undo any silences created so far
+				closeMaintWindowSilences(ctx, window)
+				return err
+			}
+			window.SilenceIds = append(window.SilenceIds, id)
+		}
+	}
+	window.State = MaintWindowStateOpen
+	nodeMgr.TimedEvent(ctx, "Maintenance window opened", window.Org, node.AuditType, getMaintWindowEventTags(window), nil, window.StartTime, time.Now(), "username", window.Username, "endtime", window.EndTime.String())
+	return nil
+}
+
+func closeMaintWindowSilences(ctx context.Context, window *ormapi.MaintenanceWindow) {
+	if AlertManagerServer == nil {
+		return
+	}
+	for _, id := range window.SilenceIds {
+		err := AlertManagerServer.DeleteSilence(ctx, id)
+		if err != nil {
+			// silence expires at the end of the window anyway
+			log.SpanLog(ctx, log.DebugLevelInfo, "Failed to delete maintenance window silence", "window", window.Name, "id", id, "err", err)
+		}
+	}
+	window.SilenceIds = []string{}
+}
+
+func closeMaintWindow(ctx context.Context, window *ormapi.MaintenanceWindow, now time.Time) {
+	closeMaintWindowSilences(ctx, window)
+	nodeMgr.TimedEvent(ctx, "Maintenance window closed", window.Org, node.AuditType, getMaintWindowEventTags(window), nil, window.StartTime, now, "username", window.Username)
+}
+
+// Move the window to its next occurrence, or close it for good
+// if it does not recur.
+func advanceMaintWindow(window *ormapi.MaintenanceWindow, now time.Time) {
+	if window.Recurrence == MaintWindowRecurrenceNone {
+		window.State = MaintWindowStateClosed
+		return
+	}
+	for !now.Before(window.EndTime) {
+		window.StartTime = getNextMaintWindowTime(window.StartTime, window.Recurrence)
+		window.EndTime = getNextMaintWindowTime(window.EndTime, window.Recurrence)
+	}
+	window.State = MaintWindowStateScheduled
+}
+
+// Save the schedule state of the window, as changed from prev.
+// Returns false if the window was deleted or its schedule was
+// updated in the meantime, in which case nothing is saved.
+func updateMaintWindowData(ctx context.Context, prev, window *ormapi.MaintenanceWindow) (bool, error) {
+	lookup := ormapi.MaintenanceWindow{
+		Name: window.Name,
+		Org:  window.Org,
+	}
+
+	db := loggedDB(ctx)
+	tx := db.BeginTx(ctx, nil)
+	defer tx.RollbackUnlessCommitted()
+
+	cur := ormapi.MaintenanceWindow{}
+	// Lock the window so that it cannot be deleted or updated
+	// until the schedule state is saved.
+	res := tx.Set("gorm:query_option", "FOR UPDATE").Where(&lookup).First(&cur)
+	if res.RecordNotFound() {
+		// window got deleted in the meantime
+		return false, nil
+	}
+	if res.Error != nil {
+		return false, ormutil.DbErr(res.Error)
+	}
+	if cur.State != prev.State || !cur.StartTime.Equal(prev.StartTime) || !cur.EndTime.Equal(prev.EndTime) {
+		// window got updated in the meantime
+		return false, nil
+	}
+	// Only update the schedule state, an update never inserts
+	// the window again, and leaves other fields as they are.
+	err := tx.Model(&cur).Updates(map[string]interface{}{
+		"state":       window.State,
+		"start_time":  window.StartTime,
+		"end_time":    window.EndTime,
+		"silence_ids": window.SilenceIds,
+	}).Error
+	if err != nil {
+		return false, ormutil.DbErr(err)
+	}
+	err = tx.Commit().Error
+	if err != nil {
+		return false, ormutil.DbErr(err)
+	}
+	return true, nil
+}
+
+// Open and close maintenance windows as per their schedule, and publish
+// the cloudlets under maintenance for the regional autoprov services.
+func updateMaintWindows(ctx context.Context, now time.Time) error {
+	db := loggedDB(ctx)
+	windows := []ormapi.MaintenanceWindow{}
+	err := db.Find(&windows).Error
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	for ii := range windows {
+		window := &windows[ii]
+		prev := *window
+		changed := false
+		switch window.State {
+		case MaintWindowStateOpen:
+			if now.Before(window.EndTime) {
+				break
+			}
+			closeMaintWindow(ctx, window, now)
+			advanceMaintWindow(window, now)
+			changed = true
+		case MaintWindowStateScheduled:
+			if !now.Before(window.EndTime) {
+				// window was missed entirely, i.e. MC was down
+				log.SpanLog(ctx, log.DebugLevelInfo, "Skipping missed maintenance window", "window", window.Name, "org", window.Org, "endtime", window.EndTime)
+				advanceMaintWindow(window, now)
+				changed = true
+				break
+			}
+			if now.Before(window.StartTime) {
+				break
+			}
+			err := openMaintWindow(ctx, window)
+			if err != nil {
+				log.SpanLog(ctx, log.DebugLevelInfo, "Failed to open maintenance window, will retry", "window", window.Name, "org", window.Org, "err", err)
+				break
+			}
+			changed = true
+		}
+		if !changed {
+			continue
+		}
+		found, err := updateMaintWindowData(ctx, &prev, window)
+		if err != nil {
+			log.SpanLog(ctx, log.DebugLevelInfo, "Failed to update maintenance window", "window", window.Name, "org", window.Org, "err", err)
+		} else if !found && window.State == MaintWindowStateOpen {
+			// window was deleted or rescheduled while we were
+			// opening it, it is re-evaluated on the next run
+			closeMaintWindowSilences(ctx, window)
+			window.State = MaintWindowStateClosed
+		}
+	}
+	return publishMaintWindowCloudlets(ctx, windows)
+}
+
+func publishMaintWindowCloudlets(ctx context.Context, windows []ormapi.MaintenanceWindow) error {
+	regions, err := getAllRegions(ctx)
+	if err != nil {
+		return err
+	}
+	regionCloudlets := make(map[string]map[edgeproto.CloudletKey]struct{})
+	for _, region := range regions {
+		regionCloudlets[region] = make(map[edgeproto.CloudletKey]struct{})
+	}
+	for ii := range windows {
+		window := &windows[ii]
+		if window.State != MaintWindowStateOpen {
+			continue
+		}
+		// cloudlet pool membership may change while the window is open
+		cloudlets, err := getMaintWindowCloudlets(ctx, window)
+		if err != nil {
+			// don't risk lifting the hold on the cloudlets of the region
+			log.SpanLog(ctx, log.DebugLevelInfo, "Failed to get maintenance window cloudlets", "window", window.Name, "org", window.Org, "err", err)
+			delete(regionCloudlets, window.Region)
+			continue
+		}
+		if _, found := regionCloudlets[window.Region]; !found {
+			continue
+		}
+		for _, cloudlet := range cloudlets {
+			regionCloudlets[window.Region][cloudlet] = struct{}{}
+		}
+	}
+	for region, cloudletsMap := range regionCloudlets {
+		data := maintwindow.MaintenanceCloudlets{}
+		names := []string{}
+		for cloudlet := range cloudletsMap {
+			data.Cloudlets = append(data.Cloudlets, cloudlet)
+			names = append(names, cloudlet.GetKeyString())
+		}
+		sort.Strings(names)
+		published := strings.Join(names, ",")
+		if cur, found := maintWindowPublished[region]; found && cur == published {
+			continue
+		}
+		err := maintwindow.PutMaintenanceCloudlets(serverConfig.vaultConfig, region, &data)
+		if err != nil {
+			log.SpanLog(ctx, log.DebugLevelInfo, "Failed to publish cloudlets under maintenance", "region", region, "err", err)
+			continue
+		}
+		log.SpanLog(ctx, log.DebugLevelInfo, "Published cloudlets under maintenance", "region", region, "cloudlets", names)
+		maintWindowPublished[region] = published
+	}
+	return nil
+}
+
+// Start maintenance window thread to open and close windows as scheduled
+func RunMaintenanceWindows() {
+	for {
+		select {
+		case <-time.After(MaintWindowCheckInterval):
+		case <-maintWindowTrigger:
+		}
+		span := log.StartSpan(log.DebugLevelInfo, "Maintenance window thread")
+		ctx := log.ContextWithSpan(context.Background(), span)
+		err := updateMaintWindows(ctx, time.Now())
+		if err != nil {
+			log.SpanLog(ctx, log.DebugLevelInfo, "Failed to update maintenance windows", "err", err)
+		}
+		span.Finish()
+	}
+}
+
+func InitMaintenanceWindows() {
+	maintWindowTrigger = make(chan bool, 10)
+}
+
+func triggerMaintenanceWindows() {
+	select {
+	case maintWindowTrigger <- true:
+	default:
+	}
+}
+
+// Schedule a maintenance window for a cloudlet or cloudlet pool
+func CreateMaintenanceWindow(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	window := ormapi.MaintenanceWindow{}
+	if err := c.Bind(&window); err != nil {
+		return ormutil.BindErr(err)
+	}
+	// sanity check
+	if window.Name == "" {
+		return fmt.Errorf("Name not specified")
+	}
+	err = ValidNameNoUnderscore(window.Name)
+	if err != nil {
+		return err
+	}
+	if window.Org == "" {
+		return fmt.Errorf("Org name has to be specified")
+	}
+	if window.Region == "" {
+		return fmt.Errorf("Region has to be specified")
+	}
+	// get org details
+	orgCheck, err := orgExists(ctx, window.Org)
+	if err != nil {
+		return err
+	}
+	if orgCheck.Type != OrgTypeOperator {
+		return fmt.Errorf("Maintenance window can only be created for Operator org")
+	}
+
+	// check if user is authorized to manage the org's cloudlets
+	if err := authorized(ctx, claims.Username, window.Org, ResourceCloudlets, ActionManage); err != nil {
+		return err
+	}
+
+	if window.Cloudlet == "" && window.CloudletPool == "" {
+		return fmt.Errorf("Either cloudlet or cloudlet pool has to be specified")
+	}
+	if window.Cloudlet != "" && window.CloudletPool != "" {
+		return fmt.Errorf("Only one of cloudlet or cloudlet pool can be specified")
+	}
+	if window.StartTime.IsZero() {
+		return fmt.Errorf("Start time has to be specified")
+	}
+	if window.EndTime.IsZero() {
+		return fmt.Errorf("End time has to be specified")
+	}
+	if !window.EndTime.After(window.StartTime) {
+		return fmt.Errorf("End time must be after the start time")
+	}
+	if window.Recurrence == "" {
+		window.Recurrence = MaintWindowRecurrenceNone
+	}
+	if err := validMaintWindowRecurrence(window.Recurrence); err != nil {
+		return err
+	}
+	if window.Recurrence == MaintWindowRecurrenceNone {
+		if !window.EndTime.After(time.Now()) {
+			return fmt.Errorf("End time must not be in the past")
+		}
+	} else if !getNextMaintWindowTime(window.StartTime, window.Recurrence).After(window.EndTime) {
+		return fmt.Errorf("Window duration must be shorter than the recurrence interval")
+	}
+	if window.State != "" || len(window.SilenceIds) > 0 {
+		return fmt.Errorf("State and silence IDs are for internal-use only")
+	}
+	// make sure the cloudlet or cloudlet pool exists
+	if _, err := getMaintWindowCloudlets(ctx, &window); err != nil {
+		return err
+	}
+	window.Username = claims.Username
+	window.State = MaintWindowStateScheduled
+
+	// store in db
+	db := loggedDB(ctx)
+	err = db.Create(&window).Error
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key value violates unique constraint \"maintenance_windows_pkey") {
+			return fmt.Errorf("Maintenance window for org %s with name %s already exists", window.Org, window.Name)
+		}
+		return ormutil.DbErr(err)
+	}
+	// window may already be open
+	triggerMaintenanceWindows()
+	return c.JSON(http.StatusOK, ormutil.Msg("Maintenance window created"))
+}
+
+func DeleteMaintenanceWindow(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	window := ormapi.MaintenanceWindow{}
+	if err := c.Bind(&window); err != nil {
+		return ormutil.BindErr(err)
+	}
+	if window.Name == "" {
+		return fmt.Errorf("Maintenance window name not specified")
+	}
+	if window.Org == "" {
+		return fmt.Errorf("Maintenance window org not specified")
+	}
+
+	db := loggedDB(ctx)
+	tx := db.BeginTx(ctx, nil)
+	defer tx.RollbackUnlessCommitted()
+
+	lookup := ormapi.MaintenanceWindow{
+		Name: window.Name,
+		Org:  window.Org,
+	}
+	// Lock the window so that the silences of a window being
+	// opened are seen here and closed.
+	res := tx.Set("gorm:query_option", "FOR UPDATE").Where(&lookup).First(&window)
+	if res.RecordNotFound() {
+		return fmt.Errorf("Maintenance window not found")
+	}
+	if res.Error != nil {
+		return ormutil.NewHTTPError(http.StatusInternalServerError, ormutil.DbErr(res.Error).Error())
+	}
+	// check if user is authorized to delete maintenance window
+	if err := authorized(ctx, claims.Username, window.Org, ResourceCloudlets, ActionManage); err != nil {
+		return err
+	}
+	err = tx.Delete(&window).Error
+	if err != nil {
+		return err
+	}
+	err = tx.Commit().Error
+	if err != nil {
+		return ormutil.NewHTTPError(http.StatusInternalServerError, ormutil.DbErr(err).Error())
+	}
+	if window.State == MaintWindowStateOpen {
+		closeMaintWindow(ctx, &window, time.Now())
+		// lift the hold on autoprov actions for the cloudlets
+		triggerMaintenanceWindows()
+	}
+	return c.JSON(http.StatusOK, ormutil.Msg("Maintenance window deleted"))
+}
+
+func ShowMaintenanceWindow(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	db := loggedDB(ctx)
+
+	filter := ormapi.MaintenanceWindow{}
+	if c.Request().ContentLength > 0 {
+		if err := c.Bind(&filter); err != nil {
+			return ormutil.BindErr(err)
+		}
+	}
+	authOrgs, err := enforcer.GetAuthorizedOrgs(ctx, claims.Username, ResourceCloudlets, ActionView)
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	if len(authOrgs) == 0 {
+		return echo.ErrForbidden
+	}
+	_, admin := authOrgs[""]
+	_, orgFound := authOrgs[filter.Org]
+	if filter.Org != "" && !admin && !orgFound {
+		// no perms for specified org
+		return echo.ErrForbidden
+	}
+
+	windows := []ormapi.MaintenanceWindow{}
+	err = db.Where(&filter).Find(&windows).Error
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	showOutput := []ormapi.MaintenanceWindow{}
+	if admin {
+		showOutput = windows
+	} else {
+		for _, window := range windows {
+			if _, found := authOrgs[window.Org]; !found {
+				continue
+			}
+			showOutput = append(showOutput, window)
+		}
+	}
+	return c.JSON(http.StatusOK, showOutput)
+}
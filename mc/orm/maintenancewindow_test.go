@@ -0,0 +1,225 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mobiledgex/edge-cloud-infra/autoprov/maintwindow"
+	"github.com/mobiledgex/edge-cloud-infra/mc/mcctl/mctestclient"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+	"github.com/mobiledgex/edge-cloud/edgeproto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextMaintWindowTime(t *testing.T) {
+	start := time.Date(2030, time.January, 31, 22, 0, 0, 0, time.UTC)
+	require.Equal(t, start, getNextMaintWindowTime(start, MaintWindowRecurrenceNone))
+	require.Equal(t, start.Add(24*time.Hour), getNextMaintWindowTime(start, MaintWindowRecurrenceDaily))
+	require.Equal(t, start.Add(7*24*time.Hour), getNextMaintWindowTime(start, MaintWindowRecurrenceWeekly))
+	require.Equal(t, time.Date(2030, time.March, 3, 22, 0, 0, 0, time.UTC), getNextMaintWindowTime(start, MaintWindowRecurrenceMonthly))
+
+	// missed occurrences are skipped
+	window := ormapi.MaintenanceWindow{
+		StartTime:  start,
+		EndTime:    start.Add(time.Hour),
+		Recurrence: MaintWindowRecurrenceDaily,
+		State:      MaintWindowStateOpen,
+	}
+	advanceMaintWindow(&window, start.Add(50*time.Hour))
+	require.Equal(t, MaintWindowStateScheduled, window.State)
+	require.Equal(t, start.Add(72*time.Hour), window.StartTime)
+	require.Equal(t, start.Add(73*time.Hour), window.EndTime)
+
+	window.Recurrence = MaintWindowRecurrenceNone
+	advanceMaintWindow(&window, window.EndTime)
+	require.Equal(t, MaintWindowStateClosed, window.State)
+}
+
+func getMaintCloudlets(t *testing.T, region string) []edgeproto.CloudletKey {
+	data, err := maintwindow.GetMaintenanceCloudlets(serverConfig.vaultConfig, region)
+	require.Nil(t, err)
+	return data.Cloudlets
+}
+
+func testMaintenanceWindows(t *testing.T, ctx context.Context, mcClient *mctestclient.Client, uri, devToken, operToken, region, devOrg string, cloudlet *edgeproto.CloudletKey) {
+	start := time.Now().Add(time.Hour).Truncate(time.Second)
+	window := ormapi.MaintenanceWindow{
+		Name:       "upgrade",
+		Org:        cloudlet.Organization,
+		Region:     region,
+		Cloudlet:   cloudlet.Name,
+		StartTime:  start,
+		EndTime:    start.Add(2 * time.Hour),
+		Recurrence: MaintWindowRecurrenceDaily,
+		Comment:    "cloudlet upgrade",
+	}
+
+	// developer cannot schedule maintenance for operator cloudlets
+	status, err := mcClient.CreateMaintenanceWindow(uri, devToken, &window)
+	require.NotNil(t, err)
+	require.Equal(t, http.StatusForbidden, status)
+	// developer org is not an operator org
+	badWindow := window
+	badWindow.Org = devOrg
+	_, err = mcClient.CreateMaintenanceWindow(uri, devToken, &badWindow)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Maintenance window can only be created for Operator org")
+
+	// validation
+	badWindow = window
+	badWindow.Cloudlet = ""
+	_, err = mcClient.CreateMaintenanceWindow(uri, operToken, &badWindow)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Either cloudlet or cloudlet pool has to be specified")
+	badWindow.Cloudlet = "nosuchcloudlet"
+	_, err = mcClient.CreateMaintenanceWindow(uri, operToken, &badWindow)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Cloudlet nosuchcloudlet not found in region "+region)
+	badWindow = window
+	badWindow.EndTime = window.StartTime
+	_, err = mcClient.CreateMaintenanceWindow(uri, operToken, &badWindow)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "End time must be after the start time")
+	badWindow = window
+	badWindow.Recurrence = "Yearly"
+	_, err = mcClient.CreateMaintenanceWindow(uri, operToken, &badWindow)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Invalid recurrence Yearly")
+	badWindow = window
+	badWindow.EndTime = window.StartTime.Add(25 * time.Hour)
+	_, err = mcClient.CreateMaintenanceWindow(uri, operToken, &badWindow)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Window duration must be shorter than the recurrence interval")
+	badWindow = window
+	badWindow.Recurrence = MaintWindowRecurrenceNone
+	badWindow.StartTime = start.Add(-3 * time.Hour)
+	badWindow.EndTime = start.Add(-2 * time.Hour)
+	_, err = mcClient.CreateMaintenanceWindow(uri, operToken, &badWindow)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "End time must not be in the past")
+
+	// operator schedules maintenance
+	status, err = mcClient.CreateMaintenanceWindow(uri, operToken, &window)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	_, err = mcClient.CreateMaintenanceWindow(uri, operToken, &window)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "already exists")
+
+	// developer does not see operator windows
+	list, status, err := mcClient.ShowMaintenanceWindow(uri, devToken, &ormapi.MaintenanceWindow{})
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, 0, len(list))
+	list, status, err = mcClient.ShowMaintenanceWindow(uri, operToken, &ormapi.MaintenanceWindow{})
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, 1, len(list))
+	require.Equal(t, MaintWindowStateScheduled, list[0].State)
+	require.Equal(t, 0, len(getMaintCloudlets(t, region)))
+
+	// window opens, alerts are silenced and cloudlet is under maintenance
+	err = updateMaintWindows(ctx, start.Add(time.Minute))
+	require.Nil(t, err)
+	list, _, err = mcClient.ShowMaintenanceWindow(uri, operToken, &ormapi.MaintenanceWindow{})
+	require.Nil(t, err)
+	require.Equal(t, 1, len(list))
+	require.Equal(t, MaintWindowStateOpen, list[0].State)
+	require.Equal(t, 1, len(list[0].SilenceIds))
+	silenceId := list[0].SilenceIds[0]
+	silence, err := AlertManagerServer.GetSilence(ctx, silenceId)
+	require.Nil(t, err)
+	require.Equal(t, *cloudlet, silence.AppInst.ClusterInstKey.CloudletKey)
+	require.Equal(t, []edgeproto.CloudletKey{*cloudlet}, getMaintCloudlets(t, region))
+
+	// window closes and is rescheduled for the next day
+	err = updateMaintWindows(ctx, start.Add(2*time.Hour))
+	require.Nil(t, err)
+	list, _, err = mcClient.ShowMaintenanceWindow(uri, operToken, &ormapi.MaintenanceWindow{})
+	require.Nil(t, err)
+	require.Equal(t, 1, len(list))
+	require.Equal(t, MaintWindowStateScheduled, list[0].State)
+	require.Equal(t, 0, len(list[0].SilenceIds))
+	require.True(t, start.AddDate(0, 0, 1).Equal(list[0].StartTime))
+	silence, err = AlertManagerServer.GetSilence(ctx, silenceId)
+	require.Nil(t, err)
+	require.Equal(t, "expired", silence.State)
+	require.Equal(t, 0, len(getMaintCloudlets(t, region)))
+
+	// deleting an open window lifts the maintenance
+	openWindow := window
+	openWindow.Name = "hotfix"
+	openWindow.Recurrence = ""
+	openWindow.StartTime = time.Now().Add(-time.Minute).Truncate(time.Second)
+	openWindow.EndTime = openWindow.StartTime.Add(time.Hour)
+	status, err = mcClient.CreateMaintenanceWindow(uri, operToken, &openWindow)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	err = updateMaintWindows(ctx, time.Now())
+	require.Nil(t, err)
+	require.Equal(t, 1, len(getMaintCloudlets(t, region)))
+	list, _, err = mcClient.ShowMaintenanceWindow(uri, operToken, &ormapi.MaintenanceWindow{Name: openWindow.Name})
+	require.Nil(t, err)
+	require.Equal(t, 1, len(list))
+	require.Equal(t, MaintWindowStateOpen, list[0].State)
+	require.Equal(t, MaintWindowRecurrenceNone, list[0].Recurrence)
+	status, err = mcClient.DeleteMaintenanceWindow(uri, devToken, &openWindow)
+	require.NotNil(t, err)
+	require.Equal(t, http.StatusForbidden, status)
+	status, err = mcClient.DeleteMaintenanceWindow(uri, operToken, &openWindow)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	silence, err = AlertManagerServer.GetSilence(ctx, list[0].SilenceIds[0])
+	require.Nil(t, err)
+	require.Equal(t, "expired", silence.State)
+	err = updateMaintWindows(ctx, time.Now())
+	require.Nil(t, err)
+	require.Equal(t, 0, len(getMaintCloudlets(t, region)))
+
+	// schedule state is not saved if the window was rescheduled
+	list, _, err = mcClient.ShowMaintenanceWindow(uri, operToken, &ormapi.MaintenanceWindow{Name: window.Name})
+	require.Nil(t, err)
+	require.Equal(t, 1, len(list))
+	prev := list[0]
+	updated := list[0]
+	updated.State = MaintWindowStateOpen
+	rescheduled := prev
+	rescheduled.StartTime = prev.StartTime.Add(-time.Hour)
+	found, err := updateMaintWindowData(ctx, &rescheduled, &updated)
+	require.Nil(t, err)
+	require.False(t, found)
+	list, _, err = mcClient.ShowMaintenanceWindow(uri, operToken, &ormapi.MaintenanceWindow{Name: window.Name})
+	require.Nil(t, err)
+	require.Equal(t, MaintWindowStateScheduled, list[0].State)
+
+	// clean up
+	status, err = mcClient.DeleteMaintenanceWindow(uri, operToken, &window)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	_, err = mcClient.DeleteMaintenanceWindow(uri, operToken, &window)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Maintenance window not found")
+	// a deleted window is not inserted again
+	found, err = updateMaintWindowData(ctx, &prev, &updated)
+	require.Nil(t, err)
+	require.False(t, found)
+	list, _, err = mcClient.ShowMaintenanceWindow(uri, operToken, &ormapi.MaintenanceWindow{})
+	require.Nil(t, err)
+	require.Equal(t, 0, len(list))
+}
@@ -0,0 +1,179 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc/connectivity"
+)
+
+// Prometheus metrics about the MC itself, served on the
+// metrics listener address at /metrics.
+
+const (
+	rateLimiterMC         = "mc"
+	rateLimiterFederation = "federation"
+
+	metricResultSuccess = "success"
+	metricResultFailure = "failure"
+	metricResultPartial = "partial"
+	metricResultTimeout = "timeout"
+)
+
+var (
+	mcMetricsRegistry = prometheus.NewRegistry()
+
+	apiRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "mc_api_requests_total",
+		Help: "Number of API requests by route path, method and status code",
+	}, []string{"path", "method", "status"})
+	apiRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "mc_api_request_duration_seconds",
+		Help:    "Latency of API requests by route path and status code",
+		Buckets: []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"path", "status"})
+	rateLimitRejectionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "mc_ratelimit_rejections_total",
+		Help: "Number of API requests rejected by rate limiting, by limiter and route path",
+	}, []string{"limiter", "path"})
+	controllerConnectFailuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "mc_controller_connect_failures_total",
+		Help: "Number of failures to connect to a regional controller",
+	}, []string{"region"})
+	appStoreSyncErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "mc_appstore_sync_errors_total",
+		Help: "Number of errors syncing users and orgs to an app store",
+	}, []string{"appstore"})
+	appStoreSyncRunsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "mc_appstore_sync_runs_total",
+		Help: "Number of app store sync runs by result",
+	}, []string{"appstore", "result"})
+	reporterRunsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "mc_reporter_runs_total",
+		Help: "Number of report generation runs by result",
+	}, []string{"result"})
+	reportsGeneratedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "mc_reports_generated_total",
+		Help: "Number of scheduled reports by result, partial means the report was generated but upload or email failed",
+	}, []string{"result"})
+
+	controllerConnectedDesc = prometheus.NewDesc(
+		"mc_controller_connected",
+		"Whether the cached connection to the regional controller is ready, regions without recent API calls have no cached connection",
+		[]string{"region", "state"}, nil)
+	influxDbClientsDesc = prometheus.NewDesc(
+		"mc_influxdb_clients",
+		"Number of cached regional InfluxDB clients",
+		nil, nil)
+	billingUsageCollectedDesc = prometheus.NewDesc(
+		"mc_billing_usage_collected_timestamp_seconds",
+		"End time of the last successful billing usage collection for the region",
+		[]string{"region"}, nil)
+	billingUsageLagDesc = prometheus.NewDesc(
+		"mc_billing_usage_collection_lag_seconds",
+		"Time since the end of the last successful billing usage collection for the region",
+		[]string{"region"}, nil)
+
+	billingUsageCollected = billingUsageCollectedTimes{
+		times: make(map[string]time.Time),
+	}
+)
+
+func init() {
+	mcMetricsRegistry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		apiRequestsTotal,
+		apiRequestDuration,
+		rateLimitRejectionsTotal,
+		controllerConnectFailuresTotal,
+		appStoreSyncErrorsTotal,
+		appStoreSyncRunsTotal,
+		reporterRunsTotal,
+		reportsGeneratedTotal,
+		&mcStateCollector{},
+	)
+}
+
+func getMetricsHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(mcMetricsRegistry, promhttp.HandlerOpts{}))
+	return mux
+}
+
+func recordApiRequest(path, method string, code int, dur time.Duration) {
+	if path == "" {
+		// no route matched, avoid unbounded request URIs
+		path = "unknown"
+	}
+	status := strconv.Itoa(code)
+	apiRequestsTotal.WithLabelValues(path, method, status).Inc()
+	apiRequestDuration.WithLabelValues(path, status).Observe(dur.Seconds())
+}
+
+type billingUsageCollectedTimes struct {
+	sync.Mutex
+	times map[string]time.Time
+}
+
+func (s *billingUsageCollectedTimes) Set(region string, end time.Time) {
+	s.Lock()
+	defer s.Unlock()
+	s.times[region] = end
+}
+
+func (s *billingUsageCollectedTimes) Get() map[string]time.Time {
+	s.Lock()
+	defer s.Unlock()
+	times := make(map[string]time.Time)
+	for region, t := range s.times {
+		times[region] = t
+	}
+	return times
+}
+
+// Collects metrics from the MC's current state at scrape time
+type mcStateCollector struct{}
+
+func (s *mcStateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- controllerConnectedDesc
+	ch <- influxDbClientsDesc
+	ch <- billingUsageCollectedDesc
+	ch <- billingUsageLagDesc
+}
+
+func (s *mcStateCollector) Collect(ch chan<- prometheus.Metric) {
+	if connCache != nil {
+		for region, state := range connCache.GetRegionStates() {
+			connected := 0.0
+			if state == connectivity.Ready {
+				connected = 1
+			}
+			ch <- prometheus.MustNewConstMetric(controllerConnectedDesc, prometheus.GaugeValue, connected, region, state.String())
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(influxDbClientsDesc, prometheus.GaugeValue, float64(influxDbConnCache.Count()))
+	now := time.Now()
+	for region, end := range billingUsageCollected.Get() {
+		ch <- prometheus.MustNewConstMetric(billingUsageCollectedDesc, prometheus.GaugeValue, float64(end.Unix()), region)
+		ch <- prometheus.MustNewConstMetric(billingUsageLagDesc, prometheus.GaugeValue, now.Sub(end).Seconds(), region)
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMcMetricsRecording(t *testing.T) {
+	recordApiRequest("/api/v1/auth/user/show", http.MethodPost, http.StatusOK, 20*time.Millisecond)
+	recordApiRequest("/api/v1/auth/user/show", http.MethodPost, http.StatusOK, 30*time.Millisecond)
+	recordApiRequest("", http.MethodGet, http.StatusNotFound, time.Millisecond)
+	require.Equal(t, 2.0, testutil.ToFloat64(apiRequestsTotal.WithLabelValues("/api/v1/auth/user/show", http.MethodPost, "200")))
+	require.Equal(t, 1.0, testutil.ToFloat64(apiRequestsTotal.WithLabelValues("unknown", http.MethodGet, "404")))
+
+	// billing usage lag is computed at scrape time
+	billingUsageCollected.Set("testregion", time.Now().Add(-time.Hour))
+	defer func() {
+		billingUsageCollected.Lock()
+		delete(billingUsageCollected.times, "testregion")
+		billingUsageCollected.Unlock()
+	}()
+
+	rec := httptest.NewRecorder()
+	getMetricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	require.Contains(t, body, `mc_api_requests_total{method="POST",path="/api/v1/auth/user/show",status="200"} 2`)
+	require.Contains(t, body, `mc_api_request_duration_seconds_count{path="/api/v1/auth/user/show",status="200"} 2`)
+	require.Contains(t, body, `mc_billing_usage_collection_lag_seconds{region="testregion"} 3600`)
+	require.Contains(t, body, "mc_influxdb_clients ")
+	require.Contains(t, body, "go_goroutines ")
+}
+
+func testMcMetrics(t *testing.T, metricsAddr string) {
+	resp, err := http.Get("http://" + metricsAddr + "/metrics")
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	dat, err := ioutil.ReadAll(resp.Body)
+	require.Nil(t, err)
+	body := string(dat)
+	// the server test has logged in and run APIs
+	require.Contains(t, body, `api/v1/login",status="200"}`)
+	require.Contains(t, body, `mc_api_request_duration_seconds_bucket{path=`)
+}
@@ -487,13 +487,23 @@ func init() {
 }
 
 func ShowMetricsCommon(c echo.Context, in MetricsObject) error {
-	rc := &InfluxDBContext{}
 	claims, err := getClaims(c)
 	if err != nil {
 		return err
 	}
-	rc.claims = claims
 	ctx := ormutil.GetContext(c)
+	return queryMetricsCommon(ctx, claims, in, func(res interface{}) error {
+		payload := ormapi.StreamPayload{}
+		payload.Data = res
+		return WriteStream(c, &payload)
+	})
+}
+
+// Check permissions for and query the metrics of the given object,
+// results are passed to the callback.
+func queryMetricsCommon(ctx context.Context, claims *UserClaims, in MetricsObject, cb func(res interface{}) error) error {
+	rc := &InfluxDBContext{}
+	rc.claims = claims
 	// Get the current config
 	config, err := getConfig(ctx)
 	if err == nil {
@@ -522,11 +532,7 @@ func ShowMetricsCommon(c echo.Context, in MetricsObject) error {
 		return err
 	}
 	cmd := in.GetGroupQuery(cloudletList)
-	err = influxStream(ctx, rc, in.GetDbNames(), cmd, func(res interface{}) error {
-		payload := ormapi.StreamPayload{}
-		payload.Data = res
-		return WriteStream(c, &payload)
-	})
+	err = influxStream(ctx, rc, in.GetDbNames(), cmd, cb)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,437 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormutil"
+	"github.com/mobiledgex/edge-cloud/cloudcommon/node"
+	"github.com/mobiledgex/edge-cloud/edgeproto"
+	"github.com/mobiledgex/edge-cloud/log"
+)
+
+const (
+	NotifyCategoryRoles           = "roles"
+	NotifyCategoryCloudletPools   = "cloudletpools"
+	NotifyCategoryAppInstFailures = "appinstfailures"
+)
+
+const (
+	NotifyDeliveryImmediate = "immediate"
+	NotifyDeliveryDigest    = "digest"
+	NotifyDeliveryNone      = "none"
+)
+
+const DefaultDigestHour = 8
+
+// Audit event generated by MC for AppInst creates
+const appInstCreateEventName = "/api/v1/auth/ctrl/CreateAppInst"
+
+var notifyDigestTrigger chan bool
+
+var NotifyDigestCheckInterval = 15 * time.Minute
+
+func getDefaultNotificationPrefs(username string) *ormapi.NotificationPreferences {
+	return &ormapi.NotificationPreferences{
+		Username:   username,
+		Categories: []string{NotifyCategoryRoles, NotifyCategoryCloudletPools},
+		Delivery:   NotifyDeliveryImmediate,
+		DigestHour: DefaultDigestHour,
+		Timezone:   "UTC",
+	}
+}
+
+func getNotificationPrefs(ctx context.Context, username string) (*ormapi.NotificationPreferences, error) {
+	prefs := ormapi.NotificationPreferences{
+		Username: username,
+	}
+	db := loggedDB(ctx)
+	res := db.Where(&prefs).First(&prefs)
+	if res.RecordNotFound() {
+		return getDefaultNotificationPrefs(username), nil
+	}
+	if res.Error != nil {
+		return nil, ormutil.DbErr(res.Error)
+	}
+	return &prefs, nil
+}
+
+func validateNotificationPrefs(prefs *ormapi.NotificationPreferences) error {
+	for _, category := range prefs.Categories {
+		switch category {
+		case NotifyCategoryRoles:
+		case NotifyCategoryCloudletPools:
+		case NotifyCategoryAppInstFailures:
+		default:
+			return fmt.Errorf("Invalid category %q, must be one of %s, %s, %s", category, NotifyCategoryRoles, NotifyCategoryCloudletPools, NotifyCategoryAppInstFailures)
+		}
+	}
+	switch prefs.Delivery {
+	case NotifyDeliveryImmediate:
+	case NotifyDeliveryDigest:
+	case NotifyDeliveryNone:
+	default:
+		return fmt.Errorf("Invalid delivery %q, must be one of %s, %s, %s", prefs.Delivery, NotifyDeliveryImmediate, NotifyDeliveryDigest, NotifyDeliveryNone)
+	}
+	if prefs.QuietHoursStart < 0 || prefs.QuietHoursStart > 23 {
+		return fmt.Errorf("Quiet hours start must be between 0 and 23")
+	}
+	if prefs.QuietHoursEnd < 0 || prefs.QuietHoursEnd > 23 {
+		return fmt.Errorf("Quiet hours end must be between 0 and 23")
+	}
+	if prefs.DigestHour < 0 || prefs.DigestHour > 23 {
+		return fmt.Errorf("Digest hour must be between 0 and 23")
+	}
+	if _, err := time.LoadLocation(prefs.Timezone); err != nil {
+		return fmt.Errorf("Invalid timezone %s, %v", prefs.Timezone, err)
+	}
+	return nil
+}
+
+func notifyCategoryEnabled(prefs *ormapi.NotificationPreferences, category string) bool {
+	for _, c := range prefs.Categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+func inQuietHours(prefs *ormapi.NotificationPreferences, now time.Time) (bool, error) {
+	if prefs.QuietHoursStart == prefs.QuietHoursEnd {
+		return false, nil
+	}
+	loc, err := time.LoadLocation(prefs.Timezone)
+	if err != nil {
+		return false, err
+	}
+	hour := now.In(loc).Hour()
+	if prefs.QuietHoursStart < prefs.QuietHoursEnd {
+		return hour >= prefs.QuietHoursStart && hour < prefs.QuietHoursEnd, nil
+	}
+	// quiet hours span midnight
+	return hour >= prefs.QuietHoursStart || hour < prefs.QuietHoursEnd, nil
+}
+
+// Get the most recent scheduled digest time at or before now
+func getDigestDueTime(prefs *ormapi.NotificationPreferences, now time.Time) (time.Time, error) {
+	loc, err := time.LoadLocation(prefs.Timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+	local := now.In(loc)
+	due := time.Date(local.Year(), local.Month(), local.Day(), prefs.DigestHour, 0, 0, 0, loc)
+	if due.After(local) {
+		due = due.AddDate(0, 0, -1)
+	}
+	return due, nil
+}
+
+// Notify the user of an event according to their notification
+// preferences. If the notification is sent right away, sendNow is
+// used if specified, otherwise a plain notification email is sent.
+// Otherwise the message is held for the next digest email.
+func notifyUser(ctx context.Context, user *ormapi.User, category, org, subject, message string, sendNow func() error) error {
+	prefs, err := getNotificationPrefs(ctx, user.Name)
+	if err != nil {
+		return err
+	}
+	if prefs.Delivery == NotifyDeliveryNone || !notifyCategoryEnabled(prefs, category) {
+		log.SpanLog(ctx, log.DebugLevelApi, "notification disabled by user preferences", "user", user.Name, "category", category)
+		return nil
+	}
+	if prefs.Delivery == NotifyDeliveryImmediate {
+		quiet, err := inQuietHours(prefs, time.Now())
+		if err != nil {
+			log.SpanLog(ctx, log.DebugLevelApi, "unable to check quiet hours", "user", user.Name, "err", err)
+		}
+		if !quiet {
+			if sendNow != nil {
+				return sendNow()
+			}
+			return sendNotify(ctx, user.Email, subject, message)
+		}
+	}
+	pending := ormapi.PendingNotification{
+		Id:       uuid.New().String(),
+		Username: user.Name,
+		Category: category,
+		Org:      org,
+		Message:  message,
+	}
+	db := loggedDB(ctx)
+	if err := db.Create(&pending).Error; err != nil {
+		return ormutil.DbErr(err)
+	}
+	return nil
+}
+
+// Notify all users with the given role in the org
+func notifyOrgUsers(ctx context.Context, org, role, category, subject, message string) {
+	groupings, err := enforcer.GetGroupingPolicy()
+	if err != nil {
+		log.SpanLog(ctx, log.DebugLevelApi, "failed to get roles for notification", "org", org, "err", err)
+		return
+	}
+	db := loggedDB(ctx)
+	for ii, _ := range groupings {
+		r := parseRole(groupings[ii])
+		if r == nil || r.Org != org || r.Role != role {
+			continue
+		}
+		user := ormapi.User{Name: r.Username}
+		res := db.Where(&user).First(&user)
+		if res.Error != nil {
+			log.SpanLog(ctx, log.DebugLevelApi, "failed to look up user for notification", "user", r.Username, "err", res.Error)
+			continue
+		}
+		err := notifyUser(ctx, &user, category, org, subject, message, nil)
+		if err != nil {
+			log.SpanLog(ctx, log.DebugLevelApi, "failed to notify user", "user", user.Name, "err", err)
+		}
+	}
+}
+
+// Get failed AppInst creates for the orgs the user can view
+func getAppInstCreateFailures(ctx context.Context, username string, start, end time.Time) ([]ormapi.PendingNotification, error) {
+	authOrgs, err := enforcer.GetAuthorizedOrgs(ctx, username, ResourceAppInsts, ActionView)
+	if err != nil {
+		return nil, err
+	}
+	orgs := []string{}
+	for org, _ := range authOrgs {
+		// admins are not notified about every org
+		if org == "" {
+			continue
+		}
+		orgs = append(orgs, org)
+	}
+	if len(orgs) == 0 {
+		return nil, nil
+	}
+	search := node.EventSearch{
+		Match: node.EventMatch{
+			Names: []string{appInstCreateEventName},
+			Orgs:  orgs,
+			Types: []string{node.AuditType},
+		},
+		TimeRange: edgeproto.TimeRange{
+			StartTime: start,
+			EndTime:   end,
+		},
+	}
+	events, err := nodeMgr.ShowEvents(ctx, &search)
+	if err != nil {
+		return nil, err
+	}
+	failures := []ormapi.PendingNotification{}
+	for _, event := range events {
+		if event.Error == "" {
+			continue
+		}
+		failure := ormapi.PendingNotification{
+			Username:  username,
+			Category:  NotifyCategoryAppInstFailures,
+			CreatedAt: event.Timestamp,
+		}
+		if len(event.Org) > 0 {
+			failure.Org = event.Org[0]
+		}
+		app := event.Mtags["app"]
+		region := event.Mtags["region"]
+		failure.Message = fmt.Sprintf("Failed to create AppInst for App %s in region %s: %s", app, region, event.Error)
+		failures = append(failures, failure)
+	}
+	return failures, nil
+}
+
+// Send held notifications that are due for the user
+func sendUserDigest(ctx context.Context, prefs *ormapi.NotificationPreferences, now time.Time) error {
+	subject := ""
+	switch prefs.Delivery {
+	case NotifyDeliveryDigest:
+		due, err := getDigestDueTime(prefs, now)
+		if err != nil {
+			return err
+		}
+		if !prefs.LastDigest.Before(due) {
+			return nil
+		}
+		subject = "Daily notification digest"
+	case NotifyDeliveryImmediate:
+		// flush notifications held during quiet hours
+		quiet, err := inQuietHours(prefs, now)
+		if err != nil {
+			return err
+		}
+		if quiet {
+			return nil
+		}
+		subject = "Notifications held during quiet hours"
+	}
+
+	db := loggedDB(ctx)
+	pending := []ormapi.PendingNotification{}
+	err := db.Where(&ormapi.PendingNotification{Username: prefs.Username}).Order("created_at").Find(&pending).Error
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	items := pending
+	if prefs.Delivery == NotifyDeliveryDigest && notifyCategoryEnabled(prefs, NotifyCategoryAppInstFailures) {
+		failures, err := getAppInstCreateFailures(ctx, prefs.Username, prefs.LastDigest, now)
+		if err != nil {
+			log.SpanLog(ctx, log.DebugLevelInfo, "failed to get AppInst create failures for digest", "user", prefs.Username, "err", err)
+		}
+		items = append(items, failures...)
+	}
+	if len(items) > 0 && prefs.Delivery != NotifyDeliveryNone {
+		user := ormapi.User{Name: prefs.Username}
+		res := db.Where(&user).First(&user)
+		if res.Error != nil {
+			return ormutil.DbErr(res.Error)
+		}
+		// keep held notifications on failure so that they are retried
+		if err := sendDigestEmail(ctx, &user, subject, items); err != nil {
+			return err
+		}
+	}
+	if len(pending) > 0 {
+		ids := []string{}
+		for _, p := range pending {
+			ids = append(ids, p.Id)
+		}
+		err = db.Where("id IN (?)", ids).Delete(&ormapi.PendingNotification{}).Error
+		if err != nil {
+			return ormutil.DbErr(err)
+		}
+	}
+	if prefs.Delivery == NotifyDeliveryDigest {
+		err = db.Model(prefs).Update("last_digest", now).Error
+		if err != nil {
+			return ormutil.DbErr(err)
+		}
+	}
+	return nil
+}
+
+func sendNotificationDigests(ctx context.Context, now time.Time) error {
+	db := loggedDB(ctx)
+	allPrefs := []ormapi.NotificationPreferences{}
+	err := db.Find(&allPrefs).Error
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	for ii, _ := range allPrefs {
+		prefs := &allPrefs[ii]
+		err := sendUserDigest(ctx, prefs, now)
+		if err != nil {
+			log.SpanLog(ctx, log.DebugLevelInfo, "failed to send notification digest", "user", prefs.Username, "err", err)
+		}
+	}
+	return nil
+}
+
+// Start digest thread to send held notifications
+func RunNotificationDigests() {
+	for {
+		select {
+		case <-time.After(NotifyDigestCheckInterval):
+		case <-notifyDigestTrigger:
+		}
+		span := log.StartSpan(log.DebugLevelInfo, "Notification digest thread")
+		ctx := log.ContextWithSpan(context.Background(), span)
+		err := sendNotificationDigests(ctx, time.Now())
+		if err != nil {
+			log.SpanLog(ctx, log.DebugLevelInfo, "Failed to send notification digests", "err", err)
+		}
+		span.Finish()
+	}
+}
+
+func InitNotificationDigests() {
+	notifyDigestTrigger = make(chan bool, 10)
+}
+
+func ShowNotificationPrefs(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	prefs, err := getNotificationPrefs(ctx, claims.Username)
+	if err != nil {
+		return err
+	}
+	return ormutil.SetReply(c, prefs)
+}
+
+func UpdateNotificationPrefs(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	prefs, err := getNotificationPrefs(ctx, claims.Username)
+	if err != nil {
+		return err
+	}
+	old := *prefs
+
+	// read args onto existing data, will overwrite only specified fields
+	if err := c.Bind(prefs); err != nil {
+		return ormutil.BindErr(err)
+	}
+	if prefs.Username != old.Username {
+		return fmt.Errorf("Cannot change username")
+	}
+	if !prefs.LastDigest.Equal(old.LastDigest) {
+		return fmt.Errorf("Cannot change lastdigest")
+	}
+	if prefs.Timezone == "" {
+		prefs.Timezone = "UTC"
+	}
+	if err := validateNotificationPrefs(prefs); err != nil {
+		return err
+	}
+	if prefs.Delivery == NotifyDeliveryDigest && old.Delivery != NotifyDeliveryDigest {
+		// digest covers events from now on
+		prefs.LastDigest = time.Now()
+	}
+	db := loggedDB(ctx)
+	err = db.Save(prefs).Error
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	return ormutil.SetReply(c, ormutil.Msg("Notification preferences updated"))
+}
+
+func deleteUserNotifications(ctx context.Context, username string) error {
+	db := loggedDB(ctx)
+	err := db.Where(&ormapi.PendingNotification{Username: username}).Delete(&ormapi.PendingNotification{}).Error
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	err = db.Delete(&ormapi.NotificationPreferences{Username: username}).Error
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	return nil
+}
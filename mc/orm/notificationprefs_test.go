@@ -0,0 +1,190 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mobiledgex/edge-cloud-infra/mc/mcctl/mctestclient"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+	"github.com/mobiledgex/edge-cloud/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationPrefsSchedule(t *testing.T) {
+	prefs := getDefaultNotificationPrefs("user1")
+	require.Nil(t, validateNotificationPrefs(prefs))
+	require.True(t, notifyCategoryEnabled(prefs, NotifyCategoryRoles))
+	require.False(t, notifyCategoryEnabled(prefs, NotifyCategoryAppInstFailures))
+
+	// validation
+	bad := *prefs
+	bad.Categories = []string{"foo"}
+	require.NotNil(t, validateNotificationPrefs(&bad))
+	bad = *prefs
+	bad.Delivery = "weekly"
+	require.NotNil(t, validateNotificationPrefs(&bad))
+	bad = *prefs
+	bad.QuietHoursEnd = 24
+	require.NotNil(t, validateNotificationPrefs(&bad))
+	bad = *prefs
+	bad.Timezone = "Not/AZone"
+	require.NotNil(t, validateNotificationPrefs(&bad))
+
+	// quiet hours disabled by default
+	now := time.Date(2030, time.January, 1, 23, 30, 0, 0, time.UTC)
+	quiet, err := inQuietHours(prefs, now)
+	require.Nil(t, err)
+	require.False(t, quiet)
+	// quiet hours spanning midnight
+	prefs.QuietHoursStart = 22
+	prefs.QuietHoursEnd = 6
+	quiet, err = inQuietHours(prefs, now)
+	require.Nil(t, err)
+	require.True(t, quiet)
+	quiet, err = inQuietHours(prefs, now.Add(3*time.Hour))
+	require.Nil(t, err)
+	require.True(t, quiet)
+	quiet, err = inQuietHours(prefs, now.Add(7*time.Hour))
+	require.Nil(t, err)
+	require.False(t, quiet)
+	// quiet hours in the user's timezone, 23:30 UTC is 18:30 EST
+	prefs.Timezone = "America/New_York"
+	quiet, err = inQuietHours(prefs, now)
+	require.Nil(t, err)
+	require.False(t, quiet)
+
+	// digest due time
+	prefs.Timezone = "UTC"
+	due, err := getDigestDueTime(prefs, now)
+	require.Nil(t, err)
+	require.Equal(t, time.Date(2030, time.January, 1, 8, 0, 0, 0, time.UTC), due)
+	due, err = getDigestDueTime(prefs, time.Date(2030, time.January, 1, 7, 0, 0, 0, time.UTC))
+	require.Nil(t, err)
+	require.Equal(t, time.Date(2029, time.December, 31, 8, 0, 0, 0, time.UTC), due)
+}
+
+func testNotificationPrefs(t *testing.T, ctx context.Context, mcClient *mctestclient.Client, uri string) {
+	mockMail := MockSendMail{}
+
+	user1, token1, _ := testCreateUser(t, mcClient, uri, "notifyuser1")
+	user2, token2, _ := testCreateUser(t, mcClient, uri, "notifyuser2")
+	org := testCreateOrg(t, mcClient, uri, token1, OrgTypeDeveloper, "NotifyOrg")
+
+	// defaults
+	prefs, status, err := mcClient.ShowNotificationPrefs(uri, token2)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, NotifyDeliveryImmediate, prefs.Delivery)
+	require.Equal(t, DefaultDigestHour, prefs.DigestHour)
+	require.Equal(t, []string{NotifyCategoryRoles, NotifyCategoryCloudletPools}, []string(prefs.Categories))
+
+	update := &cli.MapData{
+		Namespace: cli.StructNamespace,
+		Data: map[string]interface{}{
+			"Delivery": "weekly",
+		},
+	}
+	status, err = mcClient.UpdateNotificationPrefs(uri, token2, update)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Invalid delivery")
+	update.Data = map[string]interface{}{
+		"DigestHour": 30,
+	}
+	status, err = mcClient.UpdateNotificationPrefs(uri, token2, update)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Digest hour must be between 0 and 23")
+
+	pendingCount := func() int {
+		pending := []ormapi.PendingNotification{}
+		err := loggedDB(ctx).Where(&ormapi.PendingNotification{Username: user2.Name}).Find(&pending).Error
+		require.Nil(t, err)
+		return len(pending)
+	}
+
+	mockMail.Start()
+	defer mockMail.Stop()
+
+	// immediate delivery
+	testAddUserRole(t, mcClient, uri, token1, org.Name, RoleDeveloperViewer, user2.Name, Success)
+	require.Equal(t, user2.Email, mockMail.To)
+	require.Contains(t, mockMail.Message, "Added to NotifyOrg")
+	mockMail.Reset()
+
+	// digest delivery holds notifications until the digest is due
+	update.Data = map[string]interface{}{
+		"Delivery": NotifyDeliveryDigest,
+	}
+	status, err = mcClient.UpdateNotificationPrefs(uri, token2, update)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	testRemoveUserRole(t, mcClient, uri, token1, org.Name, RoleDeveloperViewer, user2.Name, Success)
+	require.Equal(t, "", mockMail.Message)
+	require.Equal(t, 1, pendingCount())
+	err = sendNotificationDigests(ctx, time.Now())
+	require.Nil(t, err)
+	require.Equal(t, "", mockMail.Message)
+	err = sendNotificationDigests(ctx, time.Now().Add(24*time.Hour))
+	require.Nil(t, err)
+	require.Equal(t, user2.Email, mockMail.To)
+	require.Contains(t, mockMail.Message, "Daily notification digest")
+	require.Contains(t, mockMail.Message, "removed your role DeveloperViewer from Organization NotifyOrg")
+	require.Equal(t, 0, pendingCount())
+	mockMail.Reset()
+
+	// quiet hours hold immediate notifications until quiet hours end
+	hour := time.Now().UTC().Hour()
+	update.Data = map[string]interface{}{
+		"Delivery":        NotifyDeliveryImmediate,
+		"QuietHoursStart": hour,
+		"QuietHoursEnd":   (hour + 2) % 24,
+	}
+	status, err = mcClient.UpdateNotificationPrefs(uri, token2, update)
+	require.Nil(t, err)
+	testAddUserRole(t, mcClient, uri, token1, org.Name, RoleDeveloperViewer, user2.Name, Success)
+	require.Equal(t, "", mockMail.Message)
+	require.Equal(t, 1, pendingCount())
+	err = sendNotificationDigests(ctx, time.Now())
+	require.Nil(t, err)
+	require.Equal(t, "", mockMail.Message)
+	err = sendNotificationDigests(ctx, time.Now().Add(3*time.Hour))
+	require.Nil(t, err)
+	require.Contains(t, mockMail.Message, "Notifications held during quiet hours")
+	require.Contains(t, mockMail.Message, "added you to Organization NotifyOrg with role DeveloperViewer")
+	require.Equal(t, 0, pendingCount())
+	mockMail.Reset()
+
+	// no notifications
+	update.Data = map[string]interface{}{
+		"Delivery": NotifyDeliveryNone,
+	}
+	status, err = mcClient.UpdateNotificationPrefs(uri, token2, update)
+	require.Nil(t, err)
+	testRemoveUserRole(t, mcClient, uri, token1, org.Name, RoleDeveloperViewer, user2.Name, Success)
+	require.Equal(t, "", mockMail.Message)
+	require.Equal(t, 0, pendingCount())
+
+	// cleanup
+	testDeleteOrg(t, mcClient, uri, token1, org.Name)
+	testDeleteUser(t, mcClient, uri, token1, user1.Name)
+	testDeleteUser(t, mcClient, uri, token2, user2.Name)
+	prefsCount := 0
+	err = loggedDB(ctx).Model(&ormapi.NotificationPreferences{}).Count(&prefsCount).Error
+	require.Nil(t, err)
+	require.Equal(t, 0, prefsCount)
+}
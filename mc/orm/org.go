@@ -99,6 +99,9 @@ func CreateOrgObj(ctx context.Context, claims *UserClaims, org *ormapi.Organizat
 	}
 	// set the billingOrg parent to none
 	org.Parent = ""
+	// orgs can only be suspended after creation
+	org.Suspended = false
+	org.SuspendReason = ""
 
 	db := loggedDB(ctx)
 
@@ -221,6 +224,15 @@ func DeleteOrgObj(ctx context.Context, claims *UserClaims, org *ormapi.Organizat
 		if strings.Contains(err.Error(), "violates foreign key constraint \"org_cloudlet_pools_org_fkey\"") {
 			return fmt.Errorf("Cannot delete organization because it is referenced by some cloudletpool invitation or response")
 		}
+		if strings.Contains(err.Error(), "violates foreign key constraint \"maintenance_windows_org_fkey\"") {
+			return fmt.Errorf("Cannot delete organization because it is referenced by some maintenance window")
+		}
+		if strings.Contains(err.Error(), "violates foreign key constraint \"org_invitations_org_fkey\"") {
+			return fmt.Errorf("Cannot delete organization because it has pending user invitations, please revoke them first")
+		}
+		if strings.Contains(err.Error(), "violates foreign key constraint \"event_webhooks_org_fkey\"") {
+			return fmt.Errorf("Cannot delete organization because it is referenced by some event webhook")
+		}
 		return ormutil.DbErr(err)
 	}
 
@@ -242,6 +254,12 @@ func DeleteOrgObj(ctx context.Context, claims *UserClaims, org *ormapi.Organizat
 		}
 	}
 
+	// delete org quotas
+	err = db.Where("org = ?", org.Name).Delete(&ormapi.OrgQuota{}).Error
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+
 	gitlabDeleteGroup(ctx, org)
 	artifactoryDeleteGroupObjects(ctx, org.Name, "")
 	return nil
@@ -316,6 +334,9 @@ func updateOrg(c echo.Context, updateType UpdateType) error {
 	if org.CreatedAt != old.CreatedAt {
 		return fmt.Errorf("Cannot update created at")
 	}
+	if org.Suspended != old.Suspended || org.SuspendReason != old.SuspendReason {
+		return fmt.Errorf("Cannot update suspended state, please use the suspend and resume APIs")
+	}
 	if updateType != AdminUpdate {
 		if org.EdgeboxOnly != old.EdgeboxOnly {
 			return fmt.Errorf("Cannot update edgeboxonly field for Organization")
@@ -343,7 +364,7 @@ func ShowOrg(c echo.Context) error {
 	if err != nil {
 		return err
 	}
-	orgs, err := ShowOrgObj(ctx, claims, filter)
+	orgs, err := showOrgObj(ctx, claims, filter, ormutil.GetShowPager(c))
 	if err != nil {
 		return err
 	}
@@ -351,9 +372,13 @@ func ShowOrg(c echo.Context) error {
 }
 
 func ShowOrgObj(ctx context.Context, claims *UserClaims, filter map[string]interface{}) ([]ormapi.Organization, error) {
+	return showOrgObj(ctx, claims, filter, nil)
+}
+
+func showOrgObj(ctx context.Context, claims *UserClaims, filter map[string]interface{}, pager *ormutil.ShowPager) ([]ormapi.Organization, error) {
 	orgs := []ormapi.Organization{}
 	db := loggedDB(ctx)
-	err := db.Where(filter).Find(&orgs).Error
+	err := findShowPage(db.Where(filter), pager, &orgs).Error
 	if err != nil {
 		return nil, ormutil.DbErr(err)
 	}
@@ -592,10 +592,37 @@ func createDeleteCloudletPoolAccess(c echo.Context, action cloudcommon.Action, t
 	if err != nil {
 		return err
 	}
-	// TODO: trigger email or slack to notify other party
+	notifyCloudletPoolAccess(ctx, &in, typ, action)
 	return ormutil.SetReply(c, ormutil.Msg(msg))
 }
 
+// Notify the managers of the other party of the cloudlet pool access change
+func notifyCloudletPoolAccess(ctx context.Context, in *ormapi.OrgCloudletPool, typ string, action cloudcommon.Action) {
+	var org, role, subject, msg string
+	if typ == ormapi.CloudletPoolAccessInvitation {
+		org = in.Org
+		role = RoleDeveloperManager
+		if action == cloudcommon.Create {
+			subject = fmt.Sprintf("Invitation to CloudletPool %s", in.CloudletPool)
+			msg = fmt.Sprintf("Operator %s invited Organization %s to use CloudletPool %s in region %s", in.CloudletPoolOrg, in.Org, in.CloudletPool, in.Region)
+		} else {
+			subject = fmt.Sprintf("Invitation to CloudletPool %s withdrawn", in.CloudletPool)
+			msg = fmt.Sprintf("Operator %s withdrew the invitation for Organization %s to use CloudletPool %s in region %s", in.CloudletPoolOrg, in.Org, in.CloudletPool, in.Region)
+		}
+	} else {
+		org = in.CloudletPoolOrg
+		role = RoleOperatorManager
+		if action == cloudcommon.Create {
+			subject = fmt.Sprintf("Response to CloudletPool %s invitation", in.CloudletPool)
+			msg = fmt.Sprintf("Organization %s responded to the invitation to use CloudletPool %s in region %s with decision %s", in.Org, in.CloudletPool, in.Region, in.Decision)
+		} else {
+			subject = fmt.Sprintf("Response to CloudletPool %s invitation withdrawn", in.CloudletPool)
+			msg = fmt.Sprintf("Organization %s withdrew its response to the invitation to use CloudletPool %s in region %s", in.Org, in.CloudletPool, in.Region)
+		}
+	}
+	notifyOrgUsers(ctx, org, role, NotifyCategoryCloudletPools, subject, msg)
+}
+
 func showCloudletPoolAccess(c echo.Context, typ string) error {
 	claims, err := getClaims(c)
 	if err != nil {
@@ -607,14 +634,14 @@ func showCloudletPoolAccess(c echo.Context, typ string) error {
 	if err != nil {
 		return err
 	}
-	out, err := showCloudletPoolAccessObj(ctx, claims.Username, filter, typ)
+	out, err := showCloudletPoolAccessObj(ctx, claims.Username, filter, typ, ormutil.GetShowPager(c))
 	if err != nil {
 		return err
 	}
 	return ormutil.SetReply(c, out)
 }
 
-func showCloudletPoolAccessObj(ctx context.Context, username string, filter map[string]interface{}, typ string) ([]ormapi.OrgCloudletPool, error) {
+func showCloudletPoolAccessObj(ctx context.Context, username string, filter map[string]interface{}, typ string, pager *ormutil.ShowPager) ([]ormapi.OrgCloudletPool, error) {
 	// granted and pending are not types in the database,
 	// they're just used here for special cases.
 	if typ != accessTypeGranted && typ != accessTypePending {
@@ -633,9 +660,14 @@ func showCloudletPoolAccessObj(ctx context.Context, username string, filter map[
 		return nil, err
 	}
 
+	if typ == accessTypeGranted || typ == accessTypePending {
+		// invitations and responses are paired up after the
+		// query, so the page is taken from the paired results
+		pager = nil
+	}
 	ops := []ormapi.OrgCloudletPool{}
 	db := loggedDB(ctx)
-	err = db.Where(filter).Find(&ops).Error
+	err = findShowPage(db.Where(filter), pager, &ops).Error
 	if err != nil {
 		return nil, ormutil.DbErr(err)
 	}
@@ -0,0 +1,289 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormutil"
+	"github.com/mobiledgex/edge-cloud/log"
+	"github.com/mobiledgex/edge-cloud/util"
+)
+
+var (
+	OrgInviteValidDuration    = 7 * 24 * time.Hour
+	OrgInviteMaxValidDuration = 30 * 24 * time.Hour
+)
+
+func CreateOrgInvitation(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	invite := ormapi.OrgInvitation{}
+	if err := c.Bind(&invite); err != nil {
+		return ormutil.BindErr(err)
+	}
+	if invite.Org == "" {
+		return fmt.Errorf("Org name has to be specified")
+	}
+	if invite.Role == "" {
+		return fmt.Errorf("Role not specified")
+	}
+	if !util.ValidEmail(invite.Email) {
+		return fmt.Errorf("Invalid email address")
+	}
+	span := log.SpanFromContext(ctx)
+	span.SetTag("org", invite.Org)
+
+	// make sure caller has perms to modify users of target org
+	if err := authorized(ctx, claims.Username, invite.Org, ResourceUsers, ActionManage); err != nil {
+		return err
+	}
+	if _, err := checkRoleForOrg(ctx, invite.Role, invite.Org); err != nil {
+		return err
+	}
+	now := time.Now().Truncate(time.Microsecond)
+	if invite.ExpiresAt.IsZero() {
+		invite.ExpiresAt = now.Add(OrgInviteValidDuration)
+	}
+	if !invite.ExpiresAt.After(now) {
+		return fmt.Errorf("Expires at time must be in the future")
+	}
+	if invite.ExpiresAt.After(now.Add(OrgInviteMaxValidDuration)) {
+		return fmt.Errorf("Expires at time cannot be more than %d days in the future", int(OrgInviteMaxValidDuration.Hours()/24))
+	}
+	invite.Id = uuid.New().String()
+	invite.InvitedBy = claims.Username
+	invite.CreatedAt = now
+
+	db := loggedDB(ctx)
+	tx := db.BeginTx(ctx, nil)
+	defer tx.RollbackUnlessCommitted()
+
+	// clean up expired invitations for the org
+	err = tx.Where("org = ? AND expires_at < ?", invite.Org, now).Delete(&ormapi.OrgInvitation{}).Error
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	lookup := ormapi.OrgInvitation{
+		Org:   invite.Org,
+		Email: invite.Email,
+	}
+	existing := ormapi.OrgInvitation{}
+	res := tx.Where(&lookup).First(&existing)
+	if res.Error == nil {
+		return fmt.Errorf("Pending invitation for %s to org %s already exists, please revoke it first", existing.Email, existing.Org)
+	}
+	if !res.RecordNotFound() {
+		return ormutil.DbErr(res.Error)
+	}
+	err = tx.Create(&invite).Error
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	// commit before sending the email, so the invitee cannot get a
+	// token for an invitation that failed to be saved
+	err = tx.Commit().Error
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	token, err := sendInviteEmail(ctx, &invite)
+	if err != nil {
+		// remove the invitation so that it can be sent again
+		if delErr := db.Delete(&invite).Error; delErr != nil {
+			log.SpanLog(ctx, log.DebugLevelApi, "Failed to delete invitation after email failure", "id", invite.Id, "err", delErr)
+		}
+		return err
+	}
+	msg := "Invitation sent"
+	if token != "" {
+		msg = "Invitation created, email not sent"
+	}
+	return ormutil.SetReply(c, &ormapi.OrgInvitationResponse{
+		Message: msg,
+		Id:      invite.Id,
+		Token:   token,
+	})
+}
+
+func ShowOrgInvitation(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	filter, err := bindDbFilter(c, &ormapi.OrgInvitation{})
+	if err != nil {
+		return err
+	}
+	// only managers of the org may see its invitations
+	authOrgs, err := enforcer.GetAuthorizedOrgs(ctx, claims.Username, ResourceUsers, ActionManage)
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	if len(authOrgs) == 0 {
+		return echo.ErrForbidden
+	}
+	_, admin := authOrgs[""]
+	if org, ok := filter["org"].(string); ok && org != "" && !admin {
+		if _, found := authOrgs[org]; !found {
+			// no perms for specified org
+			return echo.ErrForbidden
+		}
+	}
+	db := loggedDB(ctx)
+	invites := []ormapi.OrgInvitation{}
+	err = findShowPage(db.Where(filter), ormutil.GetShowPager(c), &invites).Error
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	showOutput := []ormapi.OrgInvitation{}
+	for _, invite := range invites {
+		if _, found := authOrgs[invite.Org]; !found && !admin {
+			continue
+		}
+		showOutput = append(showOutput, invite)
+	}
+	return ormutil.SetReply(c, showOutput)
+}
+
+func RevokeOrgInvitation(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	in := ormapi.OrgInvitation{}
+	if err := c.Bind(&in); err != nil {
+		return ormutil.BindErr(err)
+	}
+	if in.Id == "" {
+		return fmt.Errorf("Invitation ID not specified")
+	}
+	db := loggedDB(ctx)
+	invite := ormapi.OrgInvitation{}
+	res := db.Where(&ormapi.OrgInvitation{Id: in.Id}).First(&invite)
+	if res.RecordNotFound() {
+		return fmt.Errorf("Invitation not found")
+	}
+	if res.Error != nil {
+		return ormutil.DbErr(res.Error)
+	}
+	span := log.SpanFromContext(ctx)
+	span.SetTag("org", invite.Org)
+	if err := authorized(ctx, claims.Username, invite.Org, ResourceUsers, ActionManage); err != nil {
+		return err
+	}
+	err = db.Delete(&invite).Error
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	return ormutil.SetReply(c, ormutil.Msg("Invitation revoked"))
+}
+
+func AcceptOrgInvitation(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	if claims.AuthType == ApiKeyAuth {
+		return ormutil.NewHTTPError(http.StatusForbidden, "ApiKey auth not allowed to accept invitations, please log in with user account")
+	}
+	tok := ormapi.Token{}
+	if err := c.Bind(&tok); err != nil {
+		return ormutil.BindErr(err)
+	}
+	user := ormapi.User{}
+	db := loggedDB(ctx)
+	err = db.Where(&ormapi.User{Name: claims.Username}).First(&user).Error
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	invite, err := acceptOrgInvitation(ctx, &user, tok.Token)
+	if err != nil {
+		return err
+	}
+	return ormutil.SetReply(c, ormutil.Msg(fmt.Sprintf("Joined organization %s with role %s", invite.Org, invite.Role)))
+}
+
+// Look up the invitation for the token, and check that it can be
+// accepted by a user with the given email address.
+func getOrgInvitation(ctx context.Context, token, email string) (*ormapi.OrgInvitation, error) {
+	claims := OrgInviteClaims{}
+	tok, err := Jwks.VerifyCookie(token, &claims)
+	if err != nil || !tok.Valid || claims.InviteId == "" {
+		return nil, &echo.HTTPError{
+			Code:     http.StatusUnauthorized,
+			Message:  "invalid or expired invitation token",
+			Internal: err,
+		}
+	}
+	invite := ormapi.OrgInvitation{}
+	db := loggedDB(ctx)
+	res := db.Where(&ormapi.OrgInvitation{Id: claims.InviteId}).First(&invite)
+	if res.RecordNotFound() {
+		return nil, fmt.Errorf("Invitation not found, it may have been revoked or already accepted")
+	}
+	if res.Error != nil {
+		return nil, ormutil.DbErr(res.Error)
+	}
+	if !time.Now().Before(invite.ExpiresAt) {
+		return nil, fmt.Errorf("Invitation has expired")
+	}
+	if !strings.EqualFold(invite.Email, email) {
+		return nil, fmt.Errorf("Invitation was sent to a different email address")
+	}
+	return &invite, nil
+}
+
+// Give the user the invited role in the org, and remove the invitation.
+// The role is added on behalf of the user who sent the invitation,
+// so the invitation is only valid while they can still manage the org.
+func acceptOrgInvitation(ctx context.Context, user *ormapi.User, token string) (*ormapi.OrgInvitation, error) {
+	invite, err := getOrgInvitation(ctx, token, user.Email)
+	if err != nil {
+		return nil, err
+	}
+	inviter := UserClaims{
+		Username: invite.InvitedBy,
+	}
+	role := ormapi.Role{
+		Org:      invite.Org,
+		Username: user.Name,
+		Role:     invite.Role,
+	}
+	if err := AddUserRoleObj(ctx, &inviter, &role); err != nil {
+		if err == echo.ErrForbidden {
+			return nil, fmt.Errorf("Invitation is no longer valid, user %s can no longer manage users of org %s", invite.InvitedBy, invite.Org)
+		}
+		return nil, err
+	}
+	db := loggedDB(ctx)
+	if err := db.Delete(invite).Error; err != nil {
+		log.SpanLog(ctx, log.DebugLevelApi, "failed to delete accepted invitation", "invite", invite.Id, "err", err)
+	}
+	log.SpanLog(ctx, log.DebugLevelApi, "accepted org invitation", "invite", invite.Id, "org", invite.Org, "user", user.Name, "role", invite.Role)
+	return invite, nil
+}
@@ -0,0 +1,240 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mobiledgex/edge-cloud-infra/mc/mcctl/mctestclient"
+	"github.com/mobiledgex/edge-cloud-infra/mc/mcctl/ormctl"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+	"github.com/mobiledgex/edge-cloud/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func testOrgInvitations(t *testing.T, ctx context.Context, mcClient *mctestclient.Client, uri string) {
+	user1, token1, _ := testCreateUser(t, mcClient, uri, "inviteuser1")
+	user2, token2, _ := testCreateUser(t, mcClient, uri, "inviteuser2")
+	user3, token3, _ := testCreateUser(t, mcClient, uri, "inviteuser3")
+	org := testCreateOrg(t, mcClient, uri, token1, OrgTypeDeveloper, "InviteOrg")
+	org2 := testCreateOrg(t, mcClient, uri, token1, OrgTypeDeveloper, "InviteOrg2")
+
+	mockMail := MockSendMail{}
+	mockMail.Start()
+	defer mockMail.Stop()
+
+	// invitation tokens are only sent by email
+	getInviteToken := func() string {
+		inviteToken, err := getVerificationTokenFromEmail(mockMail.Message)
+		require.Nil(t, err)
+		return inviteToken
+	}
+
+	getUserRoles := func(username string) []ormapi.Role {
+		filter := &cli.MapData{
+			Namespace: cli.StructNamespace,
+			Data: map[string]interface{}{
+				"Username": username,
+			},
+		}
+		roles, status, err := mcClient.ShowUserRole(uri, token1, filter)
+		require.Nil(t, err)
+		require.Equal(t, http.StatusOK, status)
+		return roles
+	}
+
+	// validation
+	invite := ormapi.OrgInvitation{
+		Org:   org.Name,
+		Email: "notanemail",
+		Role:  RoleDeveloperViewer,
+	}
+	_, _, err := mcClient.CreateOrgInvitation(uri, token1, &invite)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Invalid email address")
+	invite.Email = user2.Email
+	invite.Role = RoleOperatorViewer
+	_, _, err = mcClient.CreateOrgInvitation(uri, token1, &invite)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Can only assign developer roles")
+	invite.Role = RoleDeveloperViewer
+	invite.ExpiresAt = time.Now().Add(-time.Hour)
+	_, _, err = mcClient.CreateOrgInvitation(uri, token1, &invite)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "must be in the future")
+	invite.ExpiresAt = time.Now().Add(OrgInviteMaxValidDuration + time.Hour)
+	_, _, err = mcClient.CreateOrgInvitation(uri, token1, &invite)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "cannot be more than 30 days")
+	invite.ExpiresAt = time.Time{}
+
+	// only managers can invite
+	_, status, err := mcClient.CreateOrgInvitation(uri, token2, &invite)
+	require.NotNil(t, err)
+	require.Equal(t, http.StatusForbidden, status)
+
+	// invite existing user2, token is emailed to user2
+	mockMail.Reset()
+	resp, status, err := mcClient.CreateOrgInvitation(uri, token1, &invite)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.NotEmpty(t, resp.Id)
+	require.Empty(t, resp.Token)
+	require.Equal(t, "Invitation sent", resp.Message)
+	require.Equal(t, user2.Email, mockMail.To)
+	require.Contains(t, mockMail.Message, "role acceptinvite token=")
+	invite2Token := getInviteToken()
+
+	// duplicate invitation
+	_, _, err = mcClient.CreateOrgInvitation(uri, token1, &invite)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "already exists")
+
+	// show is restricted to managers
+	invites, status, err := mcClient.ShowOrgInvitation(uri, token1, ClientNoShowFilter)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, 1, len(invites))
+	require.Equal(t, resp.Id, invites[0].Id)
+	require.Equal(t, user1.Name, invites[0].InvitedBy)
+	require.Equal(t, user2.Email, invites[0].Email)
+	require.True(t, invites[0].ExpiresAt.After(time.Now().Add(OrgInviteValidDuration-time.Hour)))
+	_, status, err = mcClient.ShowOrgInvitation(uri, token2, ClientNoShowFilter)
+	require.NotNil(t, err)
+	require.Equal(t, http.StatusForbidden, status)
+
+	// invitation tokens cannot be used for other email tokens
+	_, err = mcClient.PasswordReset(uri, &ormapi.PasswordReset{
+		Token:    invite2Token,
+		Password: "invite-password-super-long-crazy-hard-difficult",
+	})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "invalid or expired token")
+
+	// only the invited email address can accept
+	_, err = mcClient.AcceptOrgInvitation(uri, token3, &ormapi.Token{Token: invite2Token})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "different email address")
+	status, err = mcClient.AcceptOrgInvitation(uri, token2, &ormapi.Token{Token: "bad-token"})
+	require.NotNil(t, err)
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	status, err = mcClient.AcceptOrgInvitation(uri, token2, &ormapi.Token{Token: invite2Token})
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	roles := getUserRoles(user2.Name)
+	require.Equal(t, 1, len(roles))
+	require.Equal(t, org.Name, roles[0].Org)
+	require.Equal(t, RoleDeveloperViewer, roles[0].Role)
+	// accepted invitation is removed
+	_, err = mcClient.AcceptOrgInvitation(uri, token2, &ormapi.Token{Token: invite2Token})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Invitation not found")
+	invites, _, err = mcClient.ShowOrgInvitation(uri, token1, ClientNoShowFilter)
+	require.Nil(t, err)
+	require.Equal(t, 0, len(invites))
+
+	// expired invitation cannot be accepted
+	invite.Email = user3.Email
+	resp, _, err = mcClient.CreateOrgInvitation(uri, token1, &invite)
+	require.Nil(t, err)
+	err = loggedDB(ctx).Model(&ormapi.OrgInvitation{Id: resp.Id}).Update("expires_at", time.Now().Add(-time.Minute)).Error
+	require.Nil(t, err)
+	_, err = mcClient.AcceptOrgInvitation(uri, token3, &ormapi.Token{Token: getInviteToken()})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "expired")
+	status, err = mcClient.RevokeOrgInvitation(uri, token1, &ormapi.OrgInvitation{Id: resp.Id})
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+
+	// revoked invitation cannot be used to sign up
+	newUser := ormapi.User{
+		Name:     "inviteuser4",
+		Email:    "inviteuser4@gmail.com",
+		Passhash: "inviteuser4-password-super-long-crazy-hard-difficult",
+	}
+	invite.Email = newUser.Email
+	invite.Role = RoleDeveloperContributor
+	resp, _, err = mcClient.CreateOrgInvitation(uri, token1, &invite)
+	require.Nil(t, err)
+	// only managers can revoke
+	status, err = mcClient.RevokeOrgInvitation(uri, token2, &ormapi.OrgInvitation{Id: resp.Id})
+	require.NotNil(t, err)
+	require.Equal(t, http.StatusForbidden, status)
+	status, err = mcClient.RevokeOrgInvitation(uri, token1, &ormapi.OrgInvitation{Id: resp.Id})
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	createUser := ormapi.CreateUser{
+		User:        newUser,
+		InviteToken: getInviteToken(),
+	}
+	_, _, status, err = mcClientCreateUserWithMockMail(mcClient, uri, &createUser)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "revoked")
+	mockMail.Start()
+
+	// accept on sign up
+	resp, _, err = mcClient.CreateOrgInvitation(uri, token1, &invite)
+	require.Nil(t, err)
+	createUser.InviteToken = getInviteToken()
+	userResp, mailMsg, status, err := mcClientCreateUserWithMockMail(mcClient, uri, &createUser)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, userResp.Message, "joined organization "+org.Name)
+	// last email sent is the notification of being added to the org
+	require.Contains(t, mailMsg, "Added to "+org.Name)
+	mockMail.Start()
+	err = loggedDB(ctx).Model(&ormapi.User{Name: newUser.Name}).Update("email_verified", true).Error
+	require.Nil(t, err)
+	roles = getUserRoles(newUser.Name)
+	require.Equal(t, 1, len(roles))
+	require.Equal(t, RoleDeveloperContributor, roles[0].Role)
+
+	// accept on login
+	invite.Org = org2.Name
+	resp, _, err = mcClient.CreateOrgInvitation(uri, token1, &invite)
+	require.Nil(t, err)
+	login := ormapi.UserLogin{
+		Username:    newUser.Name,
+		Password:    newUser.Passhash,
+		InviteToken: getInviteToken(),
+	}
+	token4, _, err := ormctl.ParseLoginResp(mcClient.Login(uri, &login))
+	require.Nil(t, err)
+	roles = getUserRoles(newUser.Name)
+	require.Equal(t, 2, len(roles))
+
+	// pending invitations block org delete
+	invite.Email = "inviteuser5@gmail.com"
+	resp, _, err = mcClient.CreateOrgInvitation(uri, token1, &invite)
+	require.Nil(t, err)
+	_, err = mcClient.DeleteOrg(uri, token1, org2)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "pending user invitations")
+	status, err = mcClient.RevokeOrgInvitation(uri, token1, &ormapi.OrgInvitation{Id: resp.Id})
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+
+	// cleanup
+	testDeleteOrg(t, mcClient, uri, token1, org.Name)
+	testDeleteOrg(t, mcClient, uri, token1, org2.Name)
+	testDeleteUser(t, mcClient, uri, token1, user1.Name)
+	testDeleteUser(t, mcClient, uri, token2, user2.Name)
+	testDeleteUser(t, mcClient, uri, token3, user3.Name)
+	testDeleteUser(t, mcClient, uri, token4, newUser.Name)
+}
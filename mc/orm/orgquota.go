@@ -0,0 +1,656 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ctrlclient"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormutil"
+	"github.com/mobiledgex/edge-cloud/cloudcommon"
+	"github.com/mobiledgex/edge-cloud/edgeproto"
+	"github.com/mobiledgex/edge-cloud/log"
+)
+
+// Org quotas limit the total resources used by a developer org's
+// ClusterInsts and AppInsts, either per region or across all regions.
+// Quotas are checked by the MC before create/update requests are
+// forwarded to the controllers, so concurrent requests may still
+// briefly exceed the quota. Quotas across all regions fail closed,
+// i.e. creates are rejected while any region cannot be reached, as its
+// usage is unknown.
+
+const OptResGpu = "gpu"
+
+func getOrgQuotas(ctx context.Context, org string) ([]ormapi.OrgQuota, error) {
+	quotas := []ormapi.OrgQuota{}
+	db := loggedDB(ctx)
+	err := db.Where(&ormapi.OrgQuota{Org: org}).Order("region").Find(&quotas).Error
+	if err != nil {
+		return nil, ormutil.DbErr(err)
+	}
+	return quotas, nil
+}
+
+// Get quotas that apply to the org in the given region
+func getRegionOrgQuotas(ctx context.Context, org, region string) ([]ormapi.OrgQuota, error) {
+	quotas, err := getOrgQuotas(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+	regionQuotas := []ormapi.OrgQuota{}
+	for _, quota := range quotas {
+		if quota.Region == "" || quota.Region == region {
+			regionQuotas = append(regionQuotas, quota)
+		}
+	}
+	return regionQuotas, nil
+}
+
+// Get the number of GPUs from the flavor's optional resources,
+// i.e. "gpu": "pci:2" or "gpu": "vgpu:nvidia-63:1"
+func getFlavorGpus(flavor *edgeproto.Flavor) uint64 {
+	val, found := flavor.OptResMap[OptResGpu]
+	if !found {
+		return 0
+	}
+	parts := strings.Split(val, ":")
+	count, err := strconv.ParseUint(parts[len(parts)-1], 10, 64)
+	if err != nil {
+		return 1
+	}
+	return count
+}
+
+func addFlavorUsage(usage *ormapi.OrgResourceUsage, flavor *edgeproto.Flavor, count uint64) {
+	if flavor == nil {
+		return
+	}
+	usage.Vcpus += flavor.Vcpus * count
+	usage.Ram += flavor.Ram * count
+	usage.Gpus += getFlavorGpus(flavor) * count
+}
+
+func addUsage(usage *ormapi.OrgResourceUsage, add *ormapi.OrgResourceUsage) {
+	usage.Vcpus += add.Vcpus
+	usage.Ram += add.Ram
+	usage.Gpus += add.Gpus
+	usage.AppInsts += add.AppInsts
+	usage.ClusterInsts += add.ClusterInsts
+}
+
+// Docker and single node kubernetes clusters have no worker nodes
+// but still run on one node.
+func clusterNodeCount(numNodes uint32) uint64 {
+	if numNodes == 0 {
+		return 1
+	}
+	return uint64(numNodes)
+}
+
+func getClusterInstKey(key *edgeproto.VirtualClusterInstKey) edgeproto.ClusterInstKey {
+	return edgeproto.ClusterInstKey{
+		ClusterKey:   key.ClusterKey,
+		CloudletKey:  key.CloudletKey,
+		Organization: key.Organization,
+	}
+}
+
+func getQuotaRegionContext(region string) *ormutil.RegionContext {
+	return &ormutil.RegionContext{
+		Region:    region,
+		SkipAuthz: true,
+		Database:  database,
+	}
+}
+
+func getRegionFlavors(ctx context.Context, region string) (map[string]*edgeproto.Flavor, error) {
+	flavors := make(map[string]*edgeproto.Flavor)
+	err := ctrlclient.ShowFlavorStream(ctx, getQuotaRegionContext(region), &edgeproto.Flavor{}, connCache, func(flavor *edgeproto.Flavor) error {
+		flavors[flavor.Key.Name] = flavor
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return flavors, nil
+}
+
+func getRegionFlavor(ctx context.Context, region, name string) (*edgeproto.Flavor, error) {
+	if name == "" {
+		return nil, nil
+	}
+	var flavor *edgeproto.Flavor
+	filter := edgeproto.Flavor{
+		Key: edgeproto.FlavorKey{Name: name},
+	}
+	err := ctrlclient.ShowFlavorStream(ctx, getQuotaRegionContext(region), &filter, connCache, func(res *edgeproto.Flavor) error {
+		flavor = res
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return flavor, nil
+}
+
+func getRegionClusterInst(ctx context.Context, region string, key *edgeproto.ClusterInstKey) (*edgeproto.ClusterInst, error) {
+	var clusterInst *edgeproto.ClusterInst
+	filter := edgeproto.ClusterInst{
+		Key: *key,
+	}
+	err := ctrlclient.ShowClusterInstStream(ctx, getQuotaRegionContext(region), &filter, connCache, nil, func(res *edgeproto.ClusterInst) error {
+		clusterInst = res
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return clusterInst, nil
+}
+
+// Get the resources used by the org in the region. ClusterInsts owned
+// by the org count their flavor per node. AppInsts count their flavor
+// only if they are not deployed on one of the org's ClusterInsts,
+// i.e. VM AppInsts or AppInsts on reservable ClusterInsts.
+func getOrgRegionUsage(ctx context.Context, region, org string) (*ormapi.OrgResourceUsage, error) {
+	rc := getQuotaRegionContext(region)
+	usage := ormapi.OrgResourceUsage{}
+	flavors, err := getRegionFlavors(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+
+	orgClusters := make(map[edgeproto.ClusterInstKey]struct{})
+	clusterFilter := edgeproto.ClusterInst{
+		Key: edgeproto.ClusterInstKey{
+			Organization: org,
+		},
+	}
+	err = ctrlclient.ShowClusterInstStream(ctx, rc, &clusterFilter, connCache, nil, func(clusterInst *edgeproto.ClusterInst) error {
+		usage.ClusterInsts++
+		addFlavorUsage(&usage, flavors[clusterInst.Flavor.Name], clusterNodeCount(clusterInst.NumNodes))
+		orgClusters[clusterInst.Key] = struct{}{}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	appInstFilter := edgeproto.AppInst{
+		Key: edgeproto.AppInstKey{
+			AppKey: edgeproto.AppKey{
+				Organization: org,
+			},
+		},
+	}
+	err = ctrlclient.ShowAppInstStream(ctx, rc, &appInstFilter, connCache, nil, func(appInst *edgeproto.AppInst) error {
+		usage.AppInsts++
+		if _, found := orgClusters[getClusterInstKey(&appInst.Key.ClusterInstKey)]; found {
+			return nil
+		}
+		addFlavorUsage(&usage, flavors[appInst.Flavor.Name], 1)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
+
+// Get the resources used by the org in each region, in parallel.
+// Fails if any region cannot be reached.
+func getOrgRegionUsages(ctx context.Context, org string) (map[string]*ormapi.OrgResourceUsage, error) {
+	ctrls, err := ShowControllerObj(ctx, NoUserClaims, NoShowFilter)
+	if err != nil {
+		return nil, err
+	}
+	usages := make(map[string]*ormapi.OrgResourceUsage)
+	errs := make([]string, 0)
+	var mux sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, ctrl := range ctrls {
+		wg.Add(1)
+		go func(c ormapi.Controller) {
+			usage, err := getOrgRegionUsage(ctx, c.Region, org)
+			mux.Lock()
+			if err != nil {
+				log.SpanLog(ctx, log.DebugLevelApi, "Failed to get org resource usage", "org", org, "region", c.Region, "err", err)
+				errs = append(errs, fmt.Sprintf("region %s unreachable: %v", c.Region, err))
+			} else {
+				usages[c.Region] = usage
+			}
+			mux.Unlock()
+			wg.Done()
+		}(ctrl)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return nil, fmt.Errorf("Failed to get resource usage for Organization %s across all regions, %s", org, strings.Join(errs, "; "))
+	}
+	return usages, nil
+}
+
+// Check that the additional resources do not exceed the quota
+func checkQuotaLimits(quota *ormapi.OrgQuota, usage, add *ormapi.OrgResourceUsage) error {
+	limits := []struct {
+		desc  string
+		limit uint64
+		used  uint64
+		add   uint64
+	}{
+		{"vCPUs", quota.MaxVcpus, usage.Vcpus, add.Vcpus},
+		{"RAM (MB)", quota.MaxRam, usage.Ram, add.Ram},
+		{"GPUs", quota.MaxGpus, usage.Gpus, add.Gpus},
+		{"AppInsts", quota.MaxAppInsts, usage.AppInsts, add.AppInsts},
+		{"ClusterInsts", quota.MaxClusterInsts, usage.ClusterInsts, add.ClusterInsts},
+	}
+	regionDesc := "all regions"
+	if quota.Region != "" {
+		regionDesc = "region " + quota.Region
+	}
+	for _, l := range limits {
+		if l.limit == 0 || l.add == 0 {
+			continue
+		}
+		if l.used+l.add > l.limit {
+			return fmt.Errorf("Organization %s quota for %s exceeded in %s, %d in use, %d requested, limit is %d", quota.Org, l.desc, regionDesc, l.used, l.add, l.limit)
+		}
+	}
+	return nil
+}
+
+func checkOrgQuotas(ctx context.Context, region string, quotas []ormapi.OrgQuota, add *ormapi.OrgResourceUsage) error {
+	if len(quotas) == 0 {
+		return nil
+	}
+	org := quotas[0].Org
+	allRegions := false
+	for _, quota := range quotas {
+		if quota.Region == "" {
+			allRegions = true
+		}
+	}
+	usages := make(map[string]*ormapi.OrgResourceUsage)
+	if allRegions {
+		var err error
+		usages, err = getOrgRegionUsages(ctx, org)
+		if err != nil {
+			return err
+		}
+	} else {
+		usage, err := getOrgRegionUsage(ctx, region, org)
+		if err != nil {
+			return err
+		}
+		usages[region] = usage
+	}
+	total := ormapi.OrgResourceUsage{}
+	for _, usage := range usages {
+		addUsage(&total, usage)
+	}
+	for ii, quota := range quotas {
+		usage := &total
+		if quota.Region != "" {
+			usage = usages[quota.Region]
+		}
+		if usage == nil {
+			usage = &ormapi.OrgResourceUsage{}
+		}
+		if err := checkQuotaLimits(&quotas[ii], usage, add); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkOrgQuotaCreateClusterInst(ctx context.Context, rc *ormutil.RegionContext, obj *edgeproto.ClusterInst) error {
+	quotas, err := getRegionOrgQuotas(ctx, obj.Key.Organization, rc.Region)
+	if err != nil || len(quotas) == 0 {
+		return err
+	}
+	add := ormapi.OrgResourceUsage{
+		ClusterInsts: 1,
+	}
+	flavor, err := getRegionFlavor(ctx, rc.Region, obj.Flavor.Name)
+	if err != nil {
+		return err
+	}
+	addFlavorUsage(&add, flavor, clusterNodeCount(obj.NumNodes))
+	return checkOrgQuotas(ctx, rc.Region, quotas, &add)
+}
+
+func checkOrgQuotaUpdateClusterInst(ctx context.Context, rc *ormutil.RegionContext, obj *edgeproto.ClusterInst) error {
+	updateNodes := false
+	for _, field := range obj.Fields {
+		if field == edgeproto.ClusterInstFieldNumNodes {
+			updateNodes = true
+		}
+	}
+	if !updateNodes {
+		return nil
+	}
+	quotas, err := getRegionOrgQuotas(ctx, obj.Key.Organization, rc.Region)
+	if err != nil || len(quotas) == 0 {
+		return err
+	}
+	cur, err := getRegionClusterInst(ctx, rc.Region, &obj.Key)
+	if err != nil || cur == nil {
+		// let the controller report missing ClusterInsts
+		return err
+	}
+	curNodes := clusterNodeCount(cur.NumNodes)
+	newNodes := clusterNodeCount(obj.NumNodes)
+	if newNodes <= curNodes {
+		return nil
+	}
+	flavor, err := getRegionFlavor(ctx, rc.Region, cur.Flavor.Name)
+	if err != nil {
+		return err
+	}
+	add := ormapi.OrgResourceUsage{}
+	addFlavorUsage(&add, flavor, newNodes-curNodes)
+	return checkOrgQuotas(ctx, rc.Region, quotas, &add)
+}
+
+func checkOrgQuotaCreateAppInst(ctx context.Context, rc *ormutil.RegionContext, obj *edgeproto.AppInst) error {
+	org := obj.Key.AppKey.Organization
+	quotas, err := getRegionOrgQuotas(ctx, org, rc.Region)
+	if err != nil || len(quotas) == 0 {
+		return err
+	}
+	add := ormapi.OrgResourceUsage{
+		AppInsts: 1,
+	}
+	if obj.Key.ClusterInstKey.Organization == org {
+		clusterKey := getClusterInstKey(&obj.Key.ClusterInstKey)
+		clusterInst, err := getRegionClusterInst(ctx, rc.Region, &clusterKey)
+		if err != nil {
+			return err
+		}
+		if clusterInst != nil {
+			// resources already counted by the ClusterInst
+			return checkOrgQuotas(ctx, rc.Region, quotas, &add)
+		}
+		if strings.HasPrefix(clusterKey.ClusterKey.Name, cloudcommon.AutoClusterPrefix) {
+			// the controller creates the org's autocluster for the
+			// AppInst, sized by the AppInst's flavor
+			add.ClusterInsts = 1
+		}
+	}
+	flavorName := obj.Flavor.Name
+	if flavorName == "" {
+		appFilter := edgeproto.App{
+			Key: obj.Key.AppKey,
+		}
+		err := ctrlclient.ShowAppStream(ctx, getQuotaRegionContext(rc.Region), &appFilter, connCache, nil, func(app *edgeproto.App) error {
+			flavorName = app.DefaultFlavor.Name
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	flavor, err := getRegionFlavor(ctx, rc.Region, flavorName)
+	if err != nil {
+		return err
+	}
+	addFlavorUsage(&add, flavor, 1)
+	return checkOrgQuotas(ctx, rc.Region, quotas, &add)
+}
+
+func validateOrgQuota(ctx context.Context, quota *ormapi.OrgQuota) error {
+	if quota.Org == "" {
+		return fmt.Errorf("Org not specified")
+	}
+	org, err := orgExists(ctx, quota.Org)
+	if err != nil {
+		return err
+	}
+	if org.Type != OrgTypeDeveloper {
+		return fmt.Errorf("Quotas can only be set for developer organizations")
+	}
+	if quota.Region != "" {
+		ctrl := ormapi.Controller{}
+		db := loggedDB(ctx)
+		res := db.Where(&ormapi.Controller{Region: quota.Region}).First(&ctrl)
+		if res.RecordNotFound() {
+			return fmt.Errorf("Region %s not found", quota.Region)
+		}
+		if res.Error != nil {
+			return ormutil.DbErr(res.Error)
+		}
+	}
+	return nil
+}
+
+func getOrgQuota(ctx context.Context, org, region string) (*ormapi.OrgQuota, error) {
+	quota := ormapi.OrgQuota{}
+	db := loggedDB(ctx)
+	// Region may be blank, so cannot use struct based lookup
+	res := db.Where("org = ? AND region = ?", org, region).First(&quota)
+	if res.RecordNotFound() {
+		return nil, nil
+	}
+	if res.Error != nil {
+		return nil, ormutil.DbErr(res.Error)
+	}
+	return &quota, nil
+}
+
+func quotaDesc(quota *ormapi.OrgQuota) string {
+	if quota.Region == "" {
+		return fmt.Sprintf("Organization %s", quota.Org)
+	}
+	return fmt.Sprintf("Organization %s region %s", quota.Org, quota.Region)
+}
+
+func CreateOrgQuota(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	in := ormapi.OrgQuota{}
+	if err := c.Bind(&in); err != nil {
+		return ormutil.BindErr(err)
+	}
+	if err := authorized(ctx, claims.Username, "", ResourceUsers, ActionManage); err != nil {
+		return err
+	}
+	if err := validateOrgQuota(ctx, &in); err != nil {
+		return err
+	}
+	existing, err := getOrgQuota(ctx, in.Org, in.Region)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("Quota for %s already exists", quotaDesc(&in))
+	}
+	db := loggedDB(ctx)
+	if err := db.Create(&in).Error; err != nil {
+		return ormutil.DbErr(err)
+	}
+	return c.JSON(http.StatusOK, ormutil.Msg("Quota created"))
+}
+
+func UpdateOrgQuota(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	// Pull json directly so we can unmarshal twice.
+	// First time is to do lookup, second time is to apply
+	// modified fields.
+	body, err := ioutil.ReadAll(c.Request().Body)
+	in := ormapi.OrgQuota{}
+	err = BindJson(body, &in)
+	if err != nil {
+		return ormutil.BindErr(err)
+	}
+	if err := authorized(ctx, claims.Username, "", ResourceUsers, ActionManage); err != nil {
+		return err
+	}
+	quota, err := getOrgQuota(ctx, in.Org, in.Region)
+	if err != nil {
+		return err
+	}
+	if quota == nil {
+		return fmt.Errorf("Quota for %s not found", quotaDesc(&in))
+	}
+	oldQuota := *quota
+	err = BindJson(body, quota)
+	if err != nil {
+		return ormutil.BindErr(err)
+	}
+	if quota.Org != oldQuota.Org || quota.Region != oldQuota.Region {
+		return fmt.Errorf("Cannot change org or region")
+	}
+	db := loggedDB(ctx)
+	if err := db.Save(quota).Error; err != nil {
+		return ormutil.DbErr(err)
+	}
+	return c.JSON(http.StatusOK, ormutil.Msg("Quota updated"))
+}
+
+func DeleteOrgQuota(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	in := ormapi.OrgQuota{}
+	if err := c.Bind(&in); err != nil {
+		return ormutil.BindErr(err)
+	}
+	if err := authorized(ctx, claims.Username, "", ResourceUsers, ActionManage); err != nil {
+		return err
+	}
+	quota, err := getOrgQuota(ctx, in.Org, in.Region)
+	if err != nil {
+		return err
+	}
+	if quota == nil {
+		return fmt.Errorf("Quota for %s not found", quotaDesc(&in))
+	}
+	db := loggedDB(ctx)
+	if err := db.Delete(quota).Error; err != nil {
+		return ormutil.DbErr(err)
+	}
+	return c.JSON(http.StatusOK, ormutil.Msg("Quota deleted"))
+}
+
+// Show quotas and current usage for the org, both the total across
+// all regions and per region.
+func getOrgQuotaUsage(ctx context.Context, org, region string) ([]ormapi.OrgQuotaUsage, error) {
+	quotas, err := getOrgQuotas(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+	quotaMap := make(map[string]*ormapi.OrgQuota)
+	for ii, quota := range quotas {
+		quotaMap[quota.Region] = &quotas[ii]
+	}
+	usages, err := getOrgRegionUsages(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+	regions := []string{}
+	total := ormapi.OrgResourceUsage{}
+	for r, usage := range usages {
+		regions = append(regions, r)
+		addUsage(&total, usage)
+	}
+	sort.Strings(regions)
+
+	out := []ormapi.OrgQuotaUsage{}
+	if region == "" {
+		out = append(out, ormapi.OrgQuotaUsage{
+			Org:   org,
+			Quota: quotaMap[""],
+			Usage: total,
+		})
+	}
+	for _, r := range regions {
+		if region != "" && r != region {
+			continue
+		}
+		out = append(out, ormapi.OrgQuotaUsage{
+			Org:    org,
+			Region: r,
+			Quota:  quotaMap[r],
+			Usage:  *usages[r],
+		})
+	}
+	return out, nil
+}
+
+func ShowOrgQuota(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	filter := ormapi.OrgQuota{}
+	if c.Request().ContentLength > 0 {
+		if err := c.Bind(&filter); err != nil {
+			return ormutil.BindErr(err)
+		}
+	}
+	orgs := []string{}
+	if filter.Org == "" {
+		// admins can see all orgs with quotas
+		if err := authorized(ctx, claims.Username, "", ResourceUsers, ActionView); err != nil {
+			return fmt.Errorf("Org not specified")
+		}
+		quotas := []ormapi.OrgQuota{}
+		db := loggedDB(ctx)
+		if err := db.Order("org").Find(&quotas).Error; err != nil {
+			return ormutil.DbErr(err)
+		}
+		for _, quota := range quotas {
+			if len(orgs) == 0 || orgs[len(orgs)-1] != quota.Org {
+				orgs = append(orgs, quota.Org)
+			}
+		}
+	} else {
+		if err := authorized(ctx, claims.Username, filter.Org, ResourceUsers, ActionView); err != nil {
+			return err
+		}
+		orgs = append(orgs, filter.Org)
+	}
+	out := []ormapi.OrgQuotaUsage{}
+	for _, org := range orgs {
+		usages, err := getOrgQuotaUsage(ctx, org, filter.Region)
+		if err != nil {
+			return err
+		}
+		out = append(out, usages...)
+	}
+	return c.JSON(http.StatusOK, out)
+}
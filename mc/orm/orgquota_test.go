@@ -0,0 +1,329 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/mobiledgex/edge-cloud-infra/mc/mcctl/mctestclient"
+	ormtestutil "github.com/mobiledgex/edge-cloud-infra/mc/orm/testutil"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+	"github.com/mobiledgex/edge-cloud/cli"
+	"github.com/mobiledgex/edge-cloud/edgeproto"
+	"github.com/mobiledgex/edge-cloud/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrgQuotaLimits(t *testing.T) {
+	flavor := edgeproto.Flavor{
+		Vcpus: 2,
+		Ram:   1024,
+	}
+	require.Equal(t, uint64(0), getFlavorGpus(&flavor))
+	flavor.OptResMap = map[string]string{"gpu": "pci:2"}
+	require.Equal(t, uint64(2), getFlavorGpus(&flavor))
+	flavor.OptResMap = map[string]string{"gpu": "vgpu:nvidia-63:1"}
+	require.Equal(t, uint64(1), getFlavorGpus(&flavor))
+	flavor.OptResMap = map[string]string{"gpu": "gpu"}
+	require.Equal(t, uint64(1), getFlavorGpus(&flavor))
+
+	usage := ormapi.OrgResourceUsage{}
+	addFlavorUsage(&usage, &flavor, 3)
+	addFlavorUsage(&usage, nil, 1)
+	require.Equal(t, ormapi.OrgResourceUsage{Vcpus: 6, Ram: 3072, Gpus: 3}, usage)
+
+	quota := ormapi.OrgQuota{
+		Org:         "org1",
+		Region:      "local",
+		MaxVcpus:    8,
+		MaxAppInsts: 2,
+	}
+	// within limits
+	err := checkQuotaLimits(&quota, &usage, &ormapi.OrgResourceUsage{Vcpus: 2, AppInsts: 2})
+	require.Nil(t, err)
+	// no limit
+	err = checkQuotaLimits(&quota, &usage, &ormapi.OrgResourceUsage{Ram: 100000, ClusterInsts: 5})
+	require.Nil(t, err)
+	// over limit
+	err = checkQuotaLimits(&quota, &usage, &ormapi.OrgResourceUsage{Vcpus: 4})
+	require.NotNil(t, err)
+	require.Equal(t, "Organization org1 quota for vCPUs exceeded in region local, 6 in use, 4 requested, limit is 8", err.Error())
+	// already over limit but not adding any more
+	usage.AppInsts = 5
+	err = checkQuotaLimits(&quota, &usage, &ormapi.OrgResourceUsage{Vcpus: 1})
+	require.Nil(t, err)
+	quota.Region = ""
+	err = checkQuotaLimits(&quota, &usage, &ormapi.OrgResourceUsage{AppInsts: 1})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "quota for AppInsts exceeded in all regions")
+}
+
+func testOrgQuotas(t *testing.T, ctx context.Context, ds *testutil.DummyServer, region string, mcClient *mctestclient.Client, uri, token string) {
+	user, tokenDev, _ := testCreateUser(t, mcClient, uri, "quotauser")
+	org := testCreateOrg(t, mcClient, uri, tokenDev, OrgTypeDeveloper, "QuotaOrg")
+	operOrg := testCreateOrg(t, mcClient, uri, tokenDev, OrgTypeOperator, "QuotaOperOrg")
+
+	// current usage: a 2 node cluster with an AppInst on it,
+	// and a VM AppInst.
+	flavor := edgeproto.Flavor{
+		Key:       edgeproto.FlavorKey{Name: "quota-flavor"},
+		Vcpus:     2,
+		Ram:       4096,
+		Disk:      40,
+		OptResMap: map[string]string{"gpu": "pci:1"},
+	}
+	cloudletKey := edgeproto.CloudletKey{
+		Organization: operOrg.Name,
+		Name:         "quota-cloudlet",
+	}
+	clusterInst := edgeproto.ClusterInst{
+		Key: edgeproto.ClusterInstKey{
+			ClusterKey:   edgeproto.ClusterKey{Name: "quota-cluster"},
+			CloudletKey:  cloudletKey,
+			Organization: org.Name,
+		},
+		Flavor:   flavor.Key,
+		NumNodes: 2,
+	}
+	appKey := edgeproto.AppKey{
+		Organization: org.Name,
+		Name:         "quota-app",
+		Version:      "1.0",
+	}
+	appInst := edgeproto.AppInst{
+		Key: edgeproto.AppInstKey{
+			AppKey: appKey,
+			ClusterInstKey: edgeproto.VirtualClusterInstKey{
+				ClusterKey:   clusterInst.Key.ClusterKey,
+				CloudletKey:  cloudletKey,
+				Organization: org.Name,
+			},
+		},
+		Flavor: flavor.Key,
+	}
+	vmAppInst := edgeproto.AppInst{
+		Key: edgeproto.AppInstKey{
+			AppKey: edgeproto.AppKey{
+				Organization: org.Name,
+				Name:         "quota-vmapp",
+				Version:      "1.0",
+			},
+			ClusterInstKey: edgeproto.VirtualClusterInstKey{
+				ClusterKey:  edgeproto.ClusterKey{Name: "quota-vmcluster"},
+				CloudletKey: cloudletKey,
+			},
+		},
+		Flavor: flavor.Key,
+	}
+	ds.FlavorCache.Update(ctx, &flavor, 0)
+	ds.ClusterInstCache.Update(ctx, &clusterInst, 0)
+	ds.AppInstCache.Update(ctx, &appInst, 0)
+	ds.AppInstCache.Update(ctx, &vmAppInst, 0)
+	expectedUsage := ormapi.OrgResourceUsage{
+		Vcpus:        6,
+		Ram:          12288,
+		Gpus:         3,
+		AppInsts:     2,
+		ClusterInsts: 1,
+	}
+
+	regionQuota := ormapi.OrgQuota{
+		Org:             org.Name,
+		Region:          region,
+		MaxClusterInsts: 1,
+	}
+	totalQuota := ormapi.OrgQuota{
+		Org:      org.Name,
+		MaxVcpus: 8,
+	}
+
+	// only admins can manage quotas
+	status, err := mcClient.CreateOrgQuota(uri, tokenDev, &regionQuota)
+	require.NotNil(t, err)
+	require.Equal(t, http.StatusForbidden, status)
+
+	// validation
+	badQuota := regionQuota
+	badQuota.Org = operOrg.Name
+	_, err = mcClient.CreateOrgQuota(uri, token, &badQuota)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Quotas can only be set for developer organizations")
+	badQuota = regionQuota
+	badQuota.Region = "nosuchregion"
+	_, err = mcClient.CreateOrgQuota(uri, token, &badQuota)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Region nosuchregion not found")
+
+	status, err = mcClient.CreateOrgQuota(uri, token, &regionQuota)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	status, err = mcClient.CreateOrgQuota(uri, token, &totalQuota)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	_, err = mcClient.CreateOrgQuota(uri, token, &totalQuota)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "already exists")
+
+	// org members can see quotas and usage
+	usages, status, err := mcClient.ShowOrgQuota(uri, tokenDev, &ormapi.OrgQuota{Org: org.Name})
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, 2, len(usages))
+	require.Equal(t, "", usages[0].Region)
+	require.NotNil(t, usages[0].Quota)
+	require.Equal(t, uint64(8), usages[0].Quota.MaxVcpus)
+	require.Equal(t, expectedUsage, usages[0].Usage)
+	require.Equal(t, region, usages[1].Region)
+	require.NotNil(t, usages[1].Quota)
+	require.Equal(t, uint64(1), usages[1].Quota.MaxClusterInsts)
+	require.Equal(t, expectedUsage, usages[1].Usage)
+	_, err = mcClient.ShowOrgQuota(uri, tokenDev, &ormapi.OrgQuota{})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Org not specified")
+	usages, _, err = mcClient.ShowOrgQuota(uri, token, &ormapi.OrgQuota{})
+	require.Nil(t, err)
+	require.Equal(t, 2, len(usages))
+	require.Equal(t, org.Name, usages[0].Org)
+
+	// quotas are enforced before forwarding to the controller
+	newCluster := clusterInst
+	newCluster.Key.ClusterKey.Name = "quota-cluster2"
+	newCluster.NumNodes = 1
+	_, _, err = ormtestutil.TestCreateClusterInst(mcClient, uri, tokenDev, region, &newCluster)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "quota for ClusterInsts exceeded in region "+region+", 1 in use, 1 requested, limit is 1")
+
+	update := &cli.MapData{
+		Namespace: cli.StructNamespace,
+		Data: map[string]interface{}{
+			"Org":             org.Name,
+			"Region":          region,
+			"MaxClusterInsts": 2,
+		},
+	}
+	status, err = mcClient.UpdateOrgQuota(uri, token, update)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	newCluster.NumNodes = 2
+	_, _, err = ormtestutil.TestCreateClusterInst(mcClient, uri, tokenDev, region, &newCluster)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "quota for vCPUs exceeded in all regions, 6 in use, 4 requested, limit is 8")
+	newCluster.NumNodes = 1
+	_, status, err = ormtestutil.TestCreateClusterInst(mcClient, uri, tokenDev, region, &newCluster)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+
+	// adding nodes counts against the quota
+	updateCluster := clusterInst
+	updateCluster.NumNodes = 4
+	_, _, err = ormtestutil.TestUpdateClusterInst(mcClient, uri, tokenDev, region, &updateCluster)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "quota for vCPUs exceeded in all regions, 6 in use, 4 requested, limit is 8")
+	updateCluster.NumNodes = 3
+	_, status, err = ormtestutil.TestUpdateClusterInst(mcClient, uri, tokenDev, region, &updateCluster)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+
+	// AppInsts on the org's ClusterInsts only count as AppInsts
+	update.Data = map[string]interface{}{
+		"Org":         org.Name,
+		"Region":      region,
+		"MaxAppInsts": 2,
+	}
+	_, err = mcClient.UpdateOrgQuota(uri, token, update)
+	require.Nil(t, err)
+	newAppInst := appInst
+	newAppInst.Key.AppKey.Name = "quota-app2"
+	_, _, err = ormtestutil.TestCreateAppInst(mcClient, uri, tokenDev, region, &newAppInst)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "quota for AppInsts exceeded")
+	update.Data["MaxAppInsts"] = 0
+	_, err = mcClient.UpdateOrgQuota(uri, token, update)
+	require.Nil(t, err)
+	_, status, err = ormtestutil.TestCreateAppInst(mcClient, uri, tokenDev, region, &newAppInst)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+
+	// other AppInsts count their flavor
+	update.Data = map[string]interface{}{
+		"Org":      org.Name,
+		"MaxVcpus": 7,
+	}
+	_, err = mcClient.UpdateOrgQuota(uri, token, update)
+	require.Nil(t, err)
+	newVMAppInst := vmAppInst
+	newVMAppInst.Key.AppKey.Name = "quota-vmapp2"
+	_, _, err = ormtestutil.TestCreateAppInst(mcClient, uri, tokenDev, region, &newVMAppInst)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "quota for vCPUs exceeded in all regions, 6 in use, 2 requested, limit is 7")
+
+	// AppInsts on a new autocluster also count as a ClusterInst
+	update.Data = map[string]interface{}{
+		"Org":      org.Name,
+		"MaxVcpus": 0,
+	}
+	_, err = mcClient.UpdateOrgQuota(uri, token, update)
+	require.Nil(t, err)
+	update.Data = map[string]interface{}{
+		"Org":             org.Name,
+		"Region":          region,
+		"MaxClusterInsts": 1,
+	}
+	_, err = mcClient.UpdateOrgQuota(uri, token, update)
+	require.Nil(t, err)
+	autoAppInst := appInst
+	autoAppInst.Key.AppKey.Name = "quota-app3"
+	autoAppInst.Key.ClusterInstKey.ClusterKey.Name = "autoclusterquota"
+	_, _, err = ormtestutil.TestCreateAppInst(mcClient, uri, tokenDev, region, &autoAppInst)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "quota for ClusterInsts exceeded in region "+region)
+
+	// quotas across all regions fail closed if a region is unreachable
+	badCtrl := ormapi.Controller{
+		Region:  "quota-unreachable",
+		Address: "127.0.0.1:1",
+	}
+	_, err = mcClient.CreateController(uri, token, &badCtrl)
+	require.Nil(t, err)
+	_, _, err = ormtestutil.TestCreateAppInst(mcClient, uri, tokenDev, region, &autoAppInst)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "across all regions, region quota-unreachable unreachable")
+	// per-region quotas only need their own region
+	status, err = mcClient.DeleteOrgQuota(uri, token, &totalQuota)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	_, _, err = ormtestutil.TestCreateAppInst(mcClient, uri, tokenDev, region, &autoAppInst)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "quota for ClusterInsts exceeded in region "+region)
+	_, err = mcClient.DeleteController(uri, token, &badCtrl)
+	require.Nil(t, err)
+
+	// cleanup
+	status, err = mcClient.DeleteOrgQuota(uri, token, &regionQuota)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	_, err = mcClient.DeleteOrgQuota(uri, token, &totalQuota)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "not found")
+	ds.AppInstCache.Delete(ctx, &vmAppInst, 0)
+	ds.AppInstCache.Delete(ctx, &appInst, 0)
+	ds.ClusterInstCache.Delete(ctx, &clusterInst, 0)
+	ds.FlavorCache.Delete(ctx, &flavor, 0)
+	testDeleteOrg(t, mcClient, uri, tokenDev, operOrg.Name)
+	testDeleteOrg(t, mcClient, uri, tokenDev, org.Name)
+	testDeleteUser(t, mcClient, uri, tokenDev, user.Name)
+}
@@ -0,0 +1,237 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ctrlclient"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormutil"
+	"github.com/mobiledgex/edge-cloud/cloudcommon/node"
+	edgeproto "github.com/mobiledgex/edge-cloud/edgeproto"
+	"github.com/mobiledgex/edge-cloud/log"
+)
+
+// Returns an error if the org is suspended and the action would
+// modify controller resources for it. Admins are not blocked so they
+// can clean up after a suspended org. Missing orgs are ignored here,
+// they are reported by the requires/reference org checks.
+func checkOrgSuspended(ctx context.Context, org, resource, action string, admin bool) error {
+	if admin || org == "" || action != ActionManage {
+		return nil
+	}
+	_, devRes := DeveloperResourcesMap[resource]
+	_, operRes := OperatorResourcesMap[resource]
+	if !devRes && !operRes {
+		return nil
+	}
+	lookup := ormapi.Organization{}
+	res := loggedDB(ctx).Where(&ormapi.Organization{Name: org}).First(&lookup)
+	if res.RecordNotFound() {
+		return nil
+	}
+	if res.Error != nil {
+		// fail closed, the org may be suspended
+		return ormutil.DbErr(res.Error)
+	}
+	if lookup.Suspended {
+		return ormutil.NewHTTPError(http.StatusForbidden, fmt.Sprintf("Organization %s is suspended", org))
+	}
+	return nil
+}
+
+func SuspendOrg(c echo.Context) error {
+	return setOrgSuspended(c, true)
+}
+
+func ResumeOrg(c echo.Context) error {
+	return setOrgSuspended(c, false)
+}
+
+func setOrgSuspended(c echo.Context, suspend bool) error {
+	ctx := ormutil.GetContext(c)
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	in := ormapi.OrgSuspend{}
+	if err := c.Bind(&in); err != nil {
+		return ormutil.BindErr(err)
+	}
+	if in.Name == "" {
+		return fmt.Errorf("Organization name not specified")
+	}
+	// Only admin user allowed to suspend or resume orgs.
+	if err := authorized(ctx, claims.Username, "", ResourceUsers, ActionManage); err != nil {
+		return err
+	}
+	if suspend && in.PowerOnAppInsts {
+		return fmt.Errorf("Cannot power on AppInsts when suspending an Organization")
+	}
+	if !suspend && in.PowerOffAppInsts {
+		return fmt.Errorf("Cannot power off AppInsts when resuming an Organization")
+	}
+	if !suspend && in.Reason != "" {
+		return fmt.Errorf("Reason can only be specified when suspending an Organization")
+	}
+
+	org := ormapi.Organization{}
+	db := loggedDB(ctx)
+	res := db.Where(&ormapi.Organization{Name: in.Name}).First(&org)
+	if res.RecordNotFound() {
+		return fmt.Errorf("Organization not found")
+	}
+	if res.Error != nil {
+		return ormutil.NewHTTPError(http.StatusInternalServerError, ormutil.DbErr(res.Error).Error())
+	}
+	if org.DeleteInProgress {
+		return fmt.Errorf("Operation not allowed for org %s with delete in progress", org.Name)
+	}
+	if suspend && org.Suspended {
+		return fmt.Errorf("Organization %s is already suspended", org.Name)
+	}
+	if !suspend && !org.Suspended {
+		return fmt.Errorf("Organization %s is not suspended", org.Name)
+	}
+	setPower := in.PowerOffAppInsts || in.PowerOnAppInsts
+	if setPower && org.Type != OrgTypeDeveloper {
+		return fmt.Errorf("Organization %s is not a developer organization and has no AppInsts", org.Name)
+	}
+
+	org.Suspended = suspend
+	org.SuspendReason = in.Reason
+	err = db.Save(&org).Error
+	if err != nil {
+		return ormutil.NewHTTPError(http.StatusInternalServerError, ormutil.DbErr(err).Error())
+	}
+
+	eventName := "Organization resumed"
+	msg := "Organization resumed"
+	powerState := edgeproto.PowerState_POWER_ON
+	if suspend {
+		eventName = "Organization suspended"
+		msg = "Organization suspended"
+		powerState = edgeproto.PowerState_POWER_OFF
+	}
+	tags := map[string]string{
+		"username": claims.Username,
+	}
+	if in.Reason != "" {
+		tags["reason"] = in.Reason
+	}
+	nodeMgr.TimedEvent(ctx, eventName, org.Name, node.AuditType, tags, nil, ormutil.GetEventStart(c), time.Now())
+
+	if setPower {
+		// Changing the power state may take a while for each AppInst,
+		// so run it in the background.
+		go setOrgAppInstsPowerState(org.Name, powerState, tags)
+		if suspend {
+			msg += ", powering off AppInsts"
+		} else {
+			msg += ", powering on AppInsts"
+		}
+	}
+	return c.JSON(http.StatusOK, ormutil.Msg(msg))
+}
+
+func setOrgAppInstsPowerState(org string, powerState edgeproto.PowerState, tags map[string]string) {
+	span := log.StartSpan(log.DebugLevelApi, "set org AppInsts power state")
+	defer span.Finish()
+	ctx := log.ContextWithSpan(context.Background(), span)
+	start := time.Now()
+
+	err := updateOrgAppInstsPowerState(ctx, org, powerState)
+	if err != nil {
+		log.SpanLog(ctx, log.DebugLevelApi, "failed to set org AppInsts power state", "org", org, "powerState", powerState, "err", err)
+	}
+	eventName := "Organization AppInsts powered on"
+	if powerState == edgeproto.PowerState_POWER_OFF {
+		eventName = "Organization AppInsts powered off"
+	}
+	nodeMgr.TimedEvent(ctx, eventName, org, node.AuditType, tags, err, start, time.Now())
+}
+
+// Set the power state of all of the org's AppInsts in all regions.
+// Failures for individual AppInsts do not stop the others from
+// being updated, all failures are returned together.
+func updateOrgAppInstsPowerState(ctx context.Context, org string, powerState edgeproto.PowerState) error {
+	ctrls, err := ShowControllerObj(ctx, NoUserClaims, NoShowFilter)
+	if err != nil {
+		return err
+	}
+	errs := []string{}
+	for _, ctrl := range ctrls {
+		rc := &ormutil.RegionContext{
+			Region:    ctrl.Region,
+			SkipAuthz: true,
+			Database:  database,
+		}
+		filter := edgeproto.AppInst{
+			Key: edgeproto.AppInstKey{
+				AppKey: edgeproto.AppKey{
+					Organization: org,
+				},
+			},
+		}
+		appInsts := []edgeproto.AppInst{}
+		err := ctrlclient.ShowAppInstStream(ctx, rc, &filter, connCache, nil, func(appInst *edgeproto.AppInst) error {
+			if appInstNeedsPowerState(appInst.PowerState, powerState) {
+				appInsts = append(appInsts, *appInst)
+			}
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("region %s: %v", ctrl.Region, err))
+			continue
+		}
+		for _, appInst := range appInsts {
+			update := edgeproto.AppInst{
+				Key:        appInst.Key,
+				PowerState: powerState,
+				Fields:     []string{edgeproto.AppInstFieldPowerState},
+			}
+			err := ctrlclient.UpdateAppInstStream(ctx, rc, &update, connCache, func(res *edgeproto.Result) error {
+				return nil
+			})
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("region %s AppInst %s: %v", ctrl.Region, appInst.Key.GetKeyString(), err))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// AppInsts already in (or transitioning to) the target state, or
+// in an unknown state, are skipped.
+func appInstNeedsPowerState(cur, target edgeproto.PowerState) bool {
+	switch target {
+	case edgeproto.PowerState_POWER_OFF:
+		return cur == edgeproto.PowerState_POWER_ON
+	case edgeproto.PowerState_POWER_ON:
+		return cur == edgeproto.PowerState_POWER_OFF
+	}
+	return false
+}
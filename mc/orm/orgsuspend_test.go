@@ -0,0 +1,173 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mobiledgex/edge-cloud-infra/mc/mcctl/mctestclient"
+	ormtestutil "github.com/mobiledgex/edge-cloud-infra/mc/orm/testutil"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+	"github.com/mobiledgex/edge-cloud/cli"
+	"github.com/mobiledgex/edge-cloud/edgeproto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppInstNeedsPowerState(t *testing.T) {
+	require.True(t, appInstNeedsPowerState(edgeproto.PowerState_POWER_ON, edgeproto.PowerState_POWER_OFF))
+	require.False(t, appInstNeedsPowerState(edgeproto.PowerState_POWER_OFF, edgeproto.PowerState_POWER_OFF))
+	require.False(t, appInstNeedsPowerState(edgeproto.PowerState_POWER_OFF_REQUESTED, edgeproto.PowerState_POWER_OFF))
+	require.False(t, appInstNeedsPowerState(edgeproto.PowerState_POWER_STATE_UNKNOWN, edgeproto.PowerState_POWER_OFF))
+	require.True(t, appInstNeedsPowerState(edgeproto.PowerState_POWER_OFF, edgeproto.PowerState_POWER_ON))
+	require.False(t, appInstNeedsPowerState(edgeproto.PowerState_POWER_ON, edgeproto.PowerState_POWER_ON))
+}
+
+func getTestOrg(t *testing.T, mcClient *mctestclient.Client, uri, token, name string) ormapi.Organization {
+	orgs, status, err := mcClient.ShowOrg(uri, token, ClientNoShowFilter)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	for _, org := range orgs {
+		if org.Name == name {
+			return org
+		}
+	}
+	require.Fail(t, "org not found", name)
+	return ormapi.Organization{}
+}
+
+func testOrgSuspend(t *testing.T, mcClient *mctestclient.Client, uri, token, region string) {
+	user, tokenDev, _ := testCreateUser(t, mcClient, uri, "suspenduser")
+	org := testCreateOrg(t, mcClient, uri, tokenDev, OrgTypeDeveloper, "SuspendOrg")
+	operOrg := testCreateOrg(t, mcClient, uri, tokenDev, OrgTypeOperator, "SuspendOperOrg")
+
+	clusterInst := edgeproto.ClusterInst{
+		Key: edgeproto.ClusterInstKey{
+			ClusterKey: edgeproto.ClusterKey{Name: "suspend-cluster"},
+			CloudletKey: edgeproto.CloudletKey{
+				Organization: operOrg.Name,
+				Name:         "suspend-cloudlet",
+			},
+			Organization: org.Name,
+		},
+	}
+	appInst := edgeproto.AppInst{
+		Key: edgeproto.AppInstKey{
+			AppKey: edgeproto.AppKey{
+				Organization: org.Name,
+				Name:         "suspend-app",
+				Version:      "1.0",
+			},
+			ClusterInstKey: edgeproto.VirtualClusterInstKey{
+				ClusterKey:   clusterInst.Key.ClusterKey,
+				CloudletKey:  clusterInst.Key.CloudletKey,
+				Organization: org.Name,
+			},
+		},
+	}
+	suspend := ormapi.OrgSuspend{
+		Name:   org.Name,
+		Reason: "billing overdue",
+	}
+
+	// only admins can suspend orgs
+	status, err := mcClient.SuspendOrg(uri, tokenDev, &suspend)
+	require.NotNil(t, err)
+	require.Equal(t, http.StatusForbidden, status)
+
+	// validation
+	_, err = mcClient.SuspendOrg(uri, token, &ormapi.OrgSuspend{Name: "nosuchorg"})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Organization not found")
+	_, err = mcClient.SuspendOrg(uri, token, &ormapi.OrgSuspend{Name: operOrg.Name, PowerOffAppInsts: true})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "is not a developer organization")
+	_, err = mcClient.ResumeOrg(uri, token, &ormapi.OrgSuspend{Name: org.Name})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "is not suspended")
+
+	status, err = mcClient.SuspendOrg(uri, token, &suspend)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	_, err = mcClient.SuspendOrg(uri, token, &suspend)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "is already suspended")
+
+	check := getTestOrg(t, mcClient, uri, tokenDev, org.Name)
+	require.True(t, check.Suspended)
+	require.Equal(t, suspend.Reason, check.SuspendReason)
+
+	// suspended state cannot be changed by a normal update
+	update := &cli.MapData{
+		Namespace: cli.StructNamespace,
+		Data: map[string]interface{}{
+			"Name":      org.Name,
+			"Suspended": false,
+		},
+	}
+	_, err = mcClient.UpdateOrg(uri, tokenDev, update)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Cannot update suspended state")
+	_, err = mcClient.RestrictedUpdateOrg(uri, token, update)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Cannot update suspended state")
+
+	// org members cannot change controller resources
+	_, status, err = ormtestutil.TestCreateClusterInst(mcClient, uri, tokenDev, region, &clusterInst)
+	require.NotNil(t, err)
+	require.Equal(t, http.StatusForbidden, status)
+	require.Contains(t, err.Error(), "Organization "+org.Name+" is suspended")
+	_, status, err = ormtestutil.TestCreateAppInst(mcClient, uri, tokenDev, region, &appInst)
+	require.NotNil(t, err)
+	require.Equal(t, http.StatusForbidden, status)
+	require.Contains(t, err.Error(), "is suspended")
+	_, status, err = ormtestutil.TestDeleteAppInst(mcClient, uri, tokenDev, region, &appInst)
+	require.NotNil(t, err)
+	require.Equal(t, http.StatusForbidden, status)
+	require.Contains(t, err.Error(), "is suspended")
+
+	// but can still view them
+	_, status, err = ormtestutil.TestShowAppInst(mcClient, uri, tokenDev, region, &appInst)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+
+	// admins are not blocked
+	_, status, err = ormtestutil.TestDeleteAppInst(mcClient, uri, token, region, &appInst)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+
+	// resume
+	_, err = mcClient.ResumeOrg(uri, token, &ormapi.OrgSuspend{Name: org.Name, Reason: "paid"})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Reason can only be specified when suspending")
+	status, err = mcClient.ResumeOrg(uri, tokenDev, &ormapi.OrgSuspend{Name: org.Name})
+	require.NotNil(t, err)
+	require.Equal(t, http.StatusForbidden, status)
+	status, err = mcClient.ResumeOrg(uri, token, &ormapi.OrgSuspend{Name: org.Name})
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	check = getTestOrg(t, mcClient, uri, tokenDev, org.Name)
+	require.False(t, check.Suspended)
+	require.Equal(t, "", check.SuspendReason)
+
+	_, status, err = ormtestutil.TestCreateClusterInst(mcClient, uri, tokenDev, region, &clusterInst)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+
+	// cleanup
+	testDeleteOrg(t, mcClient, uri, tokenDev, operOrg.Name)
+	testDeleteOrg(t, mcClient, uri, tokenDev, org.Name)
+	testDeleteUser(t, mcClient, uri, tokenDev, user.Name)
+}
@@ -70,23 +70,26 @@ type CellInfo struct {
 type PDFReport struct {
 	timezone *time.Location
 	pdf      *gofpdf.Fpdf
+	// Org type label shown in the report, i.e. Operator or Developer
+	orgLabel string
 }
 
-func NewReport(report *ormapi.GenerateReport) (*PDFReport, error) {
+func NewReport(report *ormapi.GenerateReport, orgLabel string) (*PDFReport, error) {
 	pdf := gofpdf.New(gofpdf.OrientationPortrait, "mm", "A4", "")
 	pdf.SetFont(FontName, "B", DefaultFontSize)
 	pdf.AliasNbPages("")
 	pdfReport := &PDFReport{
 		pdf:      pdf,
 		timezone: report.StartTime.Location(),
+		orgLabel: orgLabel,
 	}
 	return pdfReport, nil
 }
 
-func (r *PDFReport) AddReportTitle(logoPath string) {
+func (r *PDFReport) AddReportTitle(title, logoPath string) {
 	r.pdf.SetFont(FontName, "B", ReportTitleFontSize)
 	_, topMargin, rightMargin, _ := r.pdf.GetMargins()
-	r.pdf.Cell(100, 10, "Cloudlet Usage Report")
+	r.pdf.Cell(100, 10, title)
 	pageW, _ := r.pdf.GetPageSize()
 	// Logo aspect ratio is 6:1
 	r.pdf.ImageOptions(logoPath, pageW-rightMargin-60, topMargin, TitleLogoSize, TitleLogoSize/6, false, gofpdf.ImageOptions{ImageType: "PNG", ReadDpi: true}, 0, "")
@@ -108,9 +111,9 @@ func (r *PDFReport) AddHeader(report *ormapi.GenerateReport, logoPath, cloudlet
 		r.pdf.SetFont(FontName, "I", HeaderFontSize)
 		headerStr := ""
 		if cloudlet == "" {
-			headerStr = fmt.Sprintf("Operator: %s | Region: %s", report.Org, report.Region)
+			headerStr = fmt.Sprintf("%s: %s | Region: %s", r.orgLabel, report.Org, report.Region)
 		} else {
-			headerStr = fmt.Sprintf("Operator: %s | Region: %s | Cloudlet: %s", report.Org, report.Region, cloudlet)
+			headerStr = fmt.Sprintf("%s: %s | Region: %s | Cloudlet: %s", r.orgLabel, report.Org, report.Region, cloudlet)
 		}
 		_, topMargin, rightMargin, _ := r.pdf.GetMargins()
 		r.pdf.CellFormat(0, 0, headerStr, "", 0, "L", false, 0, "")
@@ -131,9 +134,9 @@ func (r *PDFReport) AddFooter() {
 	})
 }
 
-func (r *PDFReport) AddOperatorInfo(report *ormapi.GenerateReport) {
+func (r *PDFReport) AddOrgInfo(report *ormapi.GenerateReport) {
 	r.pdf.SetFont(FontName, "B", HeaderFontSize)
-	r.pdf.Cell(40, 10, fmt.Sprintf("Operator: %s", report.Org))
+	r.pdf.Cell(40, 10, fmt.Sprintf("%s: %s", r.orgLabel, report.Org))
 	r.pdf.Ln(5)
 	r.pdf.Cell(40, 10, fmt.Sprintf("Region: %s", report.Region))
 	r.pdf.Ln(5)
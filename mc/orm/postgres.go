@@ -98,7 +98,19 @@ func InitData(ctx context.Context, superuser, superpass string, pingInterval tim
 			&ormapi.AccountInfo{},
 			&ormapi.BillingOrganization{},
 			&ormapi.UserApiKey{},
+			&ormapi.CustomRole{},
+			&ormapi.RoleExpiration{},
+			&ormapi.IdentityProvider{},
+			&ormapi.IdpGroupMapping{},
+			&ormapi.SSOLoginState{},
+			&ormapi.OrgQuota{},
 			&ormapi.Reporter{},
+			&ormapi.MaintenanceWindow{},
+			&ormapi.NotificationPreferences{},
+			&ormapi.PendingNotification{},
+			&ormapi.OrgInvitation{},
+			&ormapi.EventWebhook{},
+			&ormapi.EventWebhookDelivery{},
 			&ormapi.McRateLimitFlowSettings{},
 			&ormapi.McRateLimitMaxReqsSettings{},
 			// Federation GORM Objects
@@ -494,5 +506,44 @@ func upgradeCustom(ctx context.Context, db *gorm.DB) error {
 			return res.Error
 		}
 	}
+
+	// move identity provider client secrets to vault
+	if err := upgradeIdpClientSecrets(ctx, db); err != nil {
+		return err
+	}
 	return nil
 }
+
+func upgradeIdpClientSecrets(ctx context.Context, db *gorm.DB) error {
+	cmd := `SELECT column_name FROM information_schema.columns WHERE table_name = 'identity_providers' AND column_name = 'client_secret'`
+	rows, err := db.Raw(cmd).Rows()
+	if err != nil {
+		return err
+	}
+	hasColumn := rows.Next()
+	rows.Close()
+	if !hasColumn {
+		return nil
+	}
+	rows, err = db.Raw(`SELECT "name", "client_secret" FROM "identity_providers" WHERE "client_secret" <> ''`).Rows()
+	if err != nil {
+		return err
+	}
+	idps := []ormapi.IdentityProvider{}
+	for rows.Next() {
+		idp := ormapi.IdentityProvider{}
+		if err := rows.Scan(&idp.Name, &idp.ClientSecret); err != nil {
+			rows.Close()
+			return err
+		}
+		idps = append(idps, idp)
+	}
+	rows.Close()
+	for ii := range idps {
+		if err := putIdpClientSecretToVault(ctx, &idps[ii]); err != nil {
+			return err
+		}
+	}
+	res := db.Exec(`ALTER TABLE "identity_providers" DROP COLUMN "client_secret"`)
+	return res.Error
+}
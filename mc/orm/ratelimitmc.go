@@ -205,6 +205,7 @@ func RateLimit(next echo.HandlerFunc) echo.HandlerFunc {
 
 		// Rate limit
 		if err = rateLimitMgr.Limit(ctx, callerInfo); err != nil {
+			rateLimitRejectionsTotal.WithLabelValues(rateLimiterMC, c.Path()).Inc()
 			errMsg := fmt.Sprintf("%s is rejected, please retry later.", c.Path())
 			if err != nil {
 				errMsg += fmt.Sprintf(" Error is: %s.", err.Error())
@@ -261,6 +262,7 @@ func FederationRateLimit(next echo.HandlerFunc) echo.HandlerFunc {
 
 		// Rate limit
 		if err = rateLimitMgr.Limit(ctx, callerInfo); err != nil {
+			rateLimitRejectionsTotal.WithLabelValues(rateLimiterFederation, c.Path()).Inc()
 			errMsg := fmt.Sprintf("%s is rejected, please retry later.", c.Path())
 			if err != nil {
 				errMsg += fmt.Sprintf(" Error is: %s.", err.Error())
@@ -0,0 +1,271 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+)
+
+// Table headers shared by the PDF and CSV reports
+var (
+	CloudletsReportHeader      = []string{"Name", "Platform Type", "Last Known State"}
+	CloudletPoolsReportHeader  = []string{"Name", "Associated Cloudlets", "Accepted Developers", "Pending Developers"}
+	CloudletEventsReportHeader = []string{"Timestamp", "Description"}
+	CloudletAlertsReportHeader = []string{"Timestamp", "Description", "State"}
+	AppEventsReportHeader      = []string{"Timestamp", "App Info", "Developer", "Cluster Info", "State"}
+
+	AppInstUsageReportHeader     = []string{"App", "Version", "Cluster", "Cloudlet", "Operator", "Flavor", "Start Time", "End Time", "Duration", "Status"}
+	ClusterInstUsageReportHeader = []string{"Cluster", "Cloudlet", "Operator", "Flavor", "Nodes", "Start Time", "End Time", "Duration", "Status"}
+	AppMetricPeaksReportHeader   = []string{"App", "Version", "Cluster", "Cloudlet", "Operator", "Peak CPU (%)", "Peak Memory (Bytes)", "Peak Disk (Bytes)", "Peak Sent (Bytes)", "Peak Received (Bytes)"}
+	AppAlertsReportHeader        = []string{"Timestamp", "App", "Cloudlet", "Description", "State"}
+)
+
+// Structured JSON report document
+type ReportDocument struct {
+	Org       string
+	StartTime time.Time
+	EndTime   time.Time
+	Timezone  string
+	// Report data per region
+	Regions map[string]map[string]interface{}
+}
+
+func validReportFormat(format string) error {
+	switch format {
+	case "":
+	case ormapi.ReportFormatPDF:
+	case ormapi.ReportFormatCSV:
+	case ormapi.ReportFormatJSON:
+	default:
+		return fmt.Errorf("Invalid report format %s, must be one of %s, %s, %s", format, ormapi.ReportFormatPDF, ormapi.ReportFormatCSV, ormapi.ReportFormatJSON)
+	}
+	return nil
+}
+
+// Generate the report for the org type in the report's output format
+func GenerateReportOutput(ctx context.Context, username, orgType string, regions []string, report *ormapi.GenerateReport, out *bytes.Buffer) error {
+	if report.Format == "" || report.Format == ormapi.ReportFormatPDF {
+		_, err := GenerateOrgReport(ctx, username, orgType, regions, report, out, OutputPDF)
+		return err
+	}
+	data, err := GenerateOrgReport(ctx, username, orgType, regions, report, nil, OutputDataNoPDF)
+	if err != nil {
+		return err
+	}
+	switch report.Format {
+	case ormapi.ReportFormatJSON:
+		return writeReportJSON(report, data, out)
+	case ormapi.ReportFormatCSV:
+		return writeReportCSV(report, data, out)
+	}
+	return validReportFormat(report.Format)
+}
+
+func writeReportJSON(report *ormapi.GenerateReport, data map[string]map[string]interface{}, out *bytes.Buffer) error {
+	doc := ReportDocument{
+		Org:       report.Org,
+		StartTime: report.StartTime,
+		EndTime:   report.EndTime,
+		Timezone:  report.Timezone,
+		Regions:   data,
+	}
+	if doc.Regions == nil {
+		doc.Regions = make(map[string]map[string]interface{})
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(&doc); err != nil {
+		return fmt.Errorf("failed to encode JSON report: %v", err)
+	}
+	return nil
+}
+
+// Write the report as a zip bundle with one CSV file per
+// region and report section.
+func writeReportCSV(report *ormapi.GenerateReport, data map[string]map[string]interface{}, out *bytes.Buffer) error {
+	loc, err := time.LoadLocation(report.Timezone)
+	if err != nil {
+		return err
+	}
+	regions := []string{}
+	for region, _ := range data {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+
+	zw := zip.NewWriter(out)
+	for _, region := range regions {
+		files := getReportCSVFiles(data[region], loc)
+		for _, file := range files {
+			if len(file.rows) == 0 {
+				continue
+			}
+			w, err := zw.Create(region + "/" + file.name)
+			if err != nil {
+				return fmt.Errorf("failed to create CSV report file: %v", err)
+			}
+			cw := csv.NewWriter(w)
+			if err := cw.Write(file.header); err != nil {
+				return err
+			}
+			if err := cw.WriteAll(file.rows); err != nil {
+				return err
+			}
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to create CSV report bundle: %v", err)
+	}
+	return nil
+}
+
+type reportCSVFile struct {
+	name   string
+	header []string
+	rows   [][]string
+}
+
+func getReportCSVFiles(regionData map[string]interface{}, loc *time.Location) []reportCSVFile {
+	files := []reportCSVFile{}
+	if rows, ok := regionData["cloudlets"].([][]string); ok {
+		files = append(files, reportCSVFile{"cloudlets.csv", CloudletsReportHeader, rows})
+	}
+	if rows, ok := regionData["cloudletpools"].([][]string); ok {
+		files = append(files, reportCSVFile{"cloudletpools.csv", CloudletPoolsReportHeader, rows})
+	}
+	if charts, ok := regionData["resourcesused"].(map[string]TimeChartDataMap); ok {
+		rows := [][]string{}
+		for _, cloudlet := range sortedKeys(charts) {
+			for _, resName := range sortedKeys(charts[cloudlet]) {
+				for _, series := range charts[cloudlet][resName] {
+					for ii, ts := range series.XValues {
+						if ii >= len(series.YValues) {
+							break
+						}
+						rows = append(rows, []string{
+							cloudlet,
+							resName,
+							ts.In(loc).Format(time.RFC3339),
+							strconv.FormatFloat(series.YValues[ii], 'f', -1, 64),
+						})
+					}
+				}
+			}
+		}
+		files = append(files, reportCSVFile{"resourceusage.csv", []string{"Cloudlet", "Resource", "Timestamp", "Value"}, rows})
+	}
+	if pies, ok := regionData["flavorsused"].(map[string]PieChartDataMap); ok {
+		files = append(files, reportCSVFile{"flavorusage.csv", []string{"Cloudlet", "Flavor", "Count"}, getPieChartCSVRows(pies)})
+	}
+	if tables, ok := regionData["cloudletevents"].(map[string][][]string); ok {
+		files = append(files, reportCSVFile{"cloudletevents.csv", append([]string{"Cloudlet"}, CloudletEventsReportHeader...), getTableCSVRows(tables)})
+	}
+	if tables, ok := regionData["cloudletalerts"].(map[string][][]string); ok {
+		files = append(files, reportCSVFile{"cloudletalerts.csv", append([]string{"Cloudlet"}, CloudletAlertsReportHeader...), getTableCSVRows(tables)})
+	}
+	if pies, ok := regionData["appcounts"].(map[string]PieChartDataMap); ok {
+		files = append(files, reportCSVFile{"appcounts.csv", []string{"Cloudlet", "Developer", "Count"}, getPieChartCSVRows(pies)})
+	}
+	if tables, ok := regionData["appevents"].(map[string][][]string); ok {
+		files = append(files, reportCSVFile{"appevents.csv", append([]string{"Cloudlet"}, AppEventsReportHeader...), getTableCSVRows(tables)})
+	}
+	if rows, ok := regionData["appinstusage"].([][]string); ok {
+		files = append(files, reportCSVFile{"appinstusage.csv", AppInstUsageReportHeader, rows})
+	}
+	if rows, ok := regionData["clusterinstusage"].([][]string); ok {
+		files = append(files, reportCSVFile{"clusterinstusage.csv", ClusterInstUsageReportHeader, rows})
+	}
+	if rows, ok := regionData["appmetricpeaks"].([][]string); ok {
+		files = append(files, reportCSVFile{"appmetricpeaks.csv", AppMetricPeaksReportHeader, rows})
+	}
+	if rows, ok := regionData["appalerts"].([][]string); ok {
+		files = append(files, reportCSVFile{"appalerts.csv", AppAlertsReportHeader, rows})
+	}
+	return files
+}
+
+func getPieChartCSVRows(pies map[string]PieChartDataMap) [][]string {
+	rows := [][]string{}
+	for _, cloudlet := range sortedKeys(pies) {
+		for _, name := range sortedKeys(pies[cloudlet]) {
+			count := strconv.FormatFloat(pies[cloudlet][name], 'f', -1, 64)
+			rows = append(rows, []string{cloudlet, name, count})
+		}
+	}
+	return rows
+}
+
+// Flatten per-cloudlet tables, prefixing each row with the cloudlet name
+func getTableCSVRows(tables map[string][][]string) [][]string {
+	rows := [][]string{}
+	for _, cloudlet := range sortedKeys(tables) {
+		for _, row := range tables[cloudlet] {
+			rows = append(rows, append([]string{cloudlet}, row...))
+		}
+	}
+	return rows
+}
+
+func sortedKeys(m interface{}) []string {
+	keys := []string{}
+	switch v := m.(type) {
+	case map[string]TimeChartDataMap:
+		for k, _ := range v {
+			keys = append(keys, k)
+		}
+	case TimeChartDataMap:
+		for k, _ := range v {
+			keys = append(keys, k)
+		}
+	case map[string]PieChartDataMap:
+		for k, _ := range v {
+			keys = append(keys, k)
+		}
+	case PieChartDataMap:
+		for k, _ := range v {
+			keys = append(keys, k)
+		}
+	case map[string][][]string:
+		for k, _ := range v {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Reply with report file contents, based on the file type
+func reportBlob(c echo.Context, filename string, data []byte) error {
+	switch filepath.Ext(filename) {
+	case ".json":
+		return c.Blob(http.StatusOK, echo.MIMEApplicationJSONCharsetUTF8, data)
+	case ".zip":
+		return c.Blob(http.StatusOK, "application/zip", data)
+	}
+	return c.HTMLBlob(http.StatusOK, data)
+}
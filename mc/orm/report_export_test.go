@@ -0,0 +1,181 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+	"github.com/stretchr/testify/require"
+)
+
+func getTestReportData() map[string]map[string]interface{} {
+	ts := time.Date(2021, time.April, 20, 10, 0, 0, 0, time.UTC)
+	return map[string]map[string]interface{}{
+		"local": map[string]interface{}{
+			"cloudlets": [][]string{
+				[]string{"cloudlet1", "Openstack", "Ready"},
+			},
+			"cloudletpools": [][]string{},
+			"resourcesused": map[string]TimeChartDataMap{
+				"cloudlet1": TimeChartDataMap{
+					"RAM Used": []TimeChartData{{
+						XValues: []time.Time{ts, ts.Add(time.Hour)},
+						YValues: []float64{1024, 2048.5},
+					}},
+				},
+			},
+			"flavorsused": map[string]PieChartDataMap{
+				"cloudlet1": PieChartDataMap{"m4.small": 2},
+			},
+			"cloudletevents": map[string][][]string{
+				"cloudlet1": [][]string{
+					[]string{"Tue, 20 Apr 2021 10:00:00", "Cloudlet online"},
+				},
+			},
+			"cloudletalerts": map[string][][]string{},
+			"appcounts": map[string]PieChartDataMap{
+				"cloudlet1": PieChartDataMap{"devorg": 3},
+			},
+			"appevents": map[string][][]string{},
+		},
+	}
+}
+
+func TestReportExport(t *testing.T) {
+	require.Nil(t, validReportFormat(""))
+	require.Nil(t, validReportFormat(ormapi.ReportFormatCSV))
+	require.NotNil(t, validReportFormat("xls"))
+
+	report := ormapi.GenerateReport{
+		Org:       "testoper",
+		StartTime: time.Date(2021, time.April, 20, 0, 0, 0, 0, time.UTC),
+		EndTime:   time.Date(2021, time.May, 3, 0, 0, 0, 0, time.UTC),
+		Timezone:  "UTC",
+	}
+	data := getTestReportData()
+
+	// JSON document
+	out := bytes.Buffer{}
+	err := writeReportJSON(&report, data, &out)
+	require.Nil(t, err)
+	doc := map[string]interface{}{}
+	err = json.Unmarshal(out.Bytes(), &doc)
+	require.Nil(t, err)
+	require.Equal(t, "testoper", doc["Org"])
+	regions, ok := doc["Regions"].(map[string]interface{})
+	require.True(t, ok)
+	require.Contains(t, regions, "local")
+
+	// CSV bundle
+	out.Reset()
+	err = writeReportCSV(&report, data, &out)
+	require.Nil(t, err)
+	zr, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	require.Nil(t, err)
+	files := make(map[string][][]string)
+	for _, f := range zr.File {
+		rd, err := f.Open()
+		require.Nil(t, err)
+		rows, err := csv.NewReader(rd).ReadAll()
+		require.Nil(t, err)
+		rd.Close()
+		files[f.Name] = rows
+	}
+	// empty sections are skipped
+	require.Equal(t, 5, len(files))
+	require.Equal(t, [][]string{
+		CloudletsReportHeader,
+		[]string{"cloudlet1", "Openstack", "Ready"},
+	}, files["local/cloudlets.csv"])
+	require.Equal(t, [][]string{
+		[]string{"Cloudlet", "Resource", "Timestamp", "Value"},
+		[]string{"cloudlet1", "RAM Used", "2021-04-20T10:00:00Z", "1024"},
+		[]string{"cloudlet1", "RAM Used", "2021-04-20T11:00:00Z", "2048.5"},
+	}, files["local/resourceusage.csv"])
+	require.Equal(t, [][]string{
+		[]string{"Cloudlet", "Flavor", "Count"},
+		[]string{"cloudlet1", "m4.small", "2"},
+	}, files["local/flavorusage.csv"])
+	require.Equal(t, [][]string{
+		[]string{"Cloudlet", "Timestamp", "Description"},
+		[]string{"cloudlet1", "Tue, 20 Apr 2021 10:00:00", "Cloudlet online"},
+	}, files["local/cloudletevents.csv"])
+	require.Equal(t, [][]string{
+		[]string{"Cloudlet", "Developer", "Count"},
+		[]string{"cloudlet1", "devorg", "3"},
+	}, files["local/appcounts.csv"])
+}
+
+func TestDeveloperReportExport(t *testing.T) {
+	start := time.Date(2021, time.April, 20, 10, 0, 0, 0, time.UTC)
+	end := start.Add(90 * time.Minute)
+	usage := &ormapi.MetricData{
+		Series: []ormapi.MetricSeries{{
+			Columns: appInstDataColumns,
+			Values: [][]interface{}{
+				[]interface{}{"local", "app2", "devorg", "1.0", "cluster1", "devorg", "cloudlet1", "operorg", "m4.small", "kubernetes", start, end, end.Sub(start), "Running"},
+				[]interface{}{"local", "app1", "devorg", "1.0", "cluster1", "devorg", "cloudlet1", "operorg", "m4.small", "kubernetes", start, end, end.Sub(start), "DELETED"},
+			},
+		}},
+	}
+	appUsage := getUsageReportRows(usage, appInstUsageReportColumns, time.UTC)
+	require.Equal(t, [][]string{
+		[]string{"app1", "1.0", "cluster1", "cloudlet1", "operorg", "m4.small", "2021/04/20 10:00:00", "2021/04/20 11:30:00", "1h30m0s", "DELETED"},
+		[]string{"app2", "1.0", "cluster1", "cloudlet1", "operorg", "m4.small", "2021/04/20 10:00:00", "2021/04/20 11:30:00", "1h30m0s", "Running"},
+	}, appUsage)
+	require.Equal(t, len(AppInstUsageReportHeader), len(appUsage[0]))
+
+	report := ormapi.GenerateReport{
+		Org:       "devorg",
+		StartTime: start,
+		EndTime:   start.AddDate(0, 0, 7),
+		Timezone:  "UTC",
+	}
+	data := map[string]map[string]interface{}{
+		"local": map[string]interface{}{
+			"appinstusage":     appUsage,
+			"clusterinstusage": [][]string{},
+			"appmetricpeaks": [][]string{
+				[]string{"app1", "1.0", "cluster1", "cloudlet1", "operorg", "12.50", "1024", "2048", "10", "20"},
+			},
+			"appalerts": [][]string{},
+		},
+	}
+	out := bytes.Buffer{}
+	err := writeReportCSV(&report, data, &out)
+	require.Nil(t, err)
+	zr, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	require.Nil(t, err)
+	files := make(map[string][][]string)
+	for _, f := range zr.File {
+		rd, err := f.Open()
+		require.Nil(t, err)
+		rows, err := csv.NewReader(rd).ReadAll()
+		require.Nil(t, err)
+		rd.Close()
+		files[f.Name] = rows
+	}
+	// empty sections are skipped
+	require.Equal(t, 2, len(files))
+	require.Equal(t, append([][]string{AppInstUsageReportHeader}, appUsage...), files["local/appinstusage.csv"])
+	require.Equal(t, AppMetricPeaksReportHeader, files["local/appmetricpeaks.csv"][0])
+	require.Equal(t, 2, len(files["local/appmetricpeaks.csv"]))
+}
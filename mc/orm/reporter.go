@@ -51,8 +51,64 @@ var (
 
 	OutputDataNoPDF = true
 	OutputPDF       = false
+
+	CloudletReportTitle  = "Cloudlet Usage Report"
+	DeveloperReportTitle = "Developer Usage Report"
 )
 
+// Reporter permissions depend on the org type. Operator reports cover
+// the org's cloudlets, developer reports cover its App and Cluster
+// instances. Returns the resources to manage reporters and to view
+// reports.
+func getReporterResources(orgType string) (string, string, error) {
+	switch orgType {
+	case OrgTypeOperator:
+		return ResourceCloudlets, ResourceCloudletAnalytics, nil
+	case OrgTypeDeveloper:
+		return ResourceAppInsts, ResourceAppAnalytics, nil
+	}
+	return "", "", fmt.Errorf("Reports are only supported for Operator and Developer orgs")
+}
+
+// Check if user is authorized to manage reporters or view reports for the org
+func authorizedReporterOrg(ctx context.Context, username, orgName, action string) (*ormapi.Organization, error) {
+	org, err := orgExists(ctx, orgName)
+	if err != nil {
+		return nil, err
+	}
+	manageResource, viewResource, err := getReporterResources(org.Type)
+	if err != nil {
+		return nil, err
+	}
+	resource := viewResource
+	if action == ActionManage {
+		resource = manageResource
+	}
+	if err := authorized(ctx, username, orgName, resource, action); err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+func getReportTitle(orgType string) string {
+	if orgType == OrgTypeDeveloper {
+		return DeveloperReportTitle
+	}
+	return CloudletReportTitle
+}
+
+// Generate report for the org type, and render it as PDF
+// unless dataOnly is set
+func GenerateOrgReport(ctx context.Context, username, orgType string, regions []string, report *ormapi.GenerateReport, pdfOut *bytes.Buffer, dataOnly bool) (map[string]map[string]interface{}, error) {
+	switch orgType {
+	case OrgTypeOperator:
+		return GenerateCloudletReport(ctx, username, regions, report, pdfOut, dataOnly)
+	case OrgTypeDeveloper:
+		return GenerateDeveloperReport(ctx, username, regions, report, pdfOut, dataOnly)
+	}
+	return nil, fmt.Errorf("Reports are only supported for Operator and Developer orgs")
+}
+
 func getOperatorReportsBucketName(deploymentTag string) string {
 	return fmt.Sprintf("mobiledgex-%s-operator-reports", deploymentTag)
 }
@@ -158,7 +214,7 @@ func GenerateReports() {
 		case <-time.After(reportTime.Sub(time.Now().UTC())):
 		case <-reportTrigger:
 		}
-		span := log.StartSpan(log.DebugLevelInfo, "Operator report generation thread")
+		span := log.StartSpan(log.DebugLevelInfo, "Report generation thread")
 		ctx := log.ContextWithSpan(context.Background(), span)
 		// get list of all reporters
 		db := loggedDB(ctx)
@@ -167,6 +223,7 @@ func GenerateReports() {
 		if err != nil {
 			log.SpanLog(ctx, log.DebugLevelInfo, "Unable to get list of reporters", "err", err)
 			// retry again in few minutes
+			reporterRunsTotal.WithLabelValues(metricResultFailure).Inc()
 			reportTime = getNextReportTimeUTC(&retryCount)
 			span.Finish()
 			continue
@@ -175,6 +232,16 @@ func GenerateReports() {
 		if err != nil {
 			log.SpanLog(ctx, log.DebugLevelInfo, "Unable to get regions", "err", err)
 			// retry again in few minutes
+			reporterRunsTotal.WithLabelValues(metricResultFailure).Inc()
+			reportTime = getNextReportTimeUTC(&retryCount)
+			span.Finish()
+			continue
+		}
+		allOrgs, err := GetAllOrgs(ctx)
+		if err != nil {
+			log.SpanLog(ctx, log.DebugLevelInfo, "Unable to get orgs", "err", err)
+			// retry again in few minutes
+			reporterRunsTotal.WithLabelValues(metricResultFailure).Inc()
 			reportTime = getNextReportTimeUTC(&retryCount)
 			span.Finish()
 			continue
@@ -184,6 +251,7 @@ func GenerateReports() {
 		if err != nil {
 			log.SpanLog(ctx, log.DebugLevelInfo, "Unable to setup GCS storage client", "err", err)
 			// retry again in few minutes
+			reporterRunsTotal.WithLabelValues(metricResultFailure).Inc()
 			reportTime = getNextReportTimeUTC(&retryCount)
 			span.Finish()
 			continue
@@ -221,17 +289,25 @@ func GenerateReports() {
 				StartTime: StartTime,
 				EndTime:   EndTime,
 				Timezone:  reporter.Timezone,
+				Format:    reporter.Format,
 			}
+			orgType := getOrgType(reporter.Org, allOrgs)
 			wg.Add(1)
-			go func(inReporter ormapi.Reporter, genReport ormapi.GenerateReport, wg *sync.WaitGroup) {
-				log.SpanLog(ctx, log.DebugLevelInfo, "Generate operator report", "reporter", inReporter.Name, "args", genReport)
+			go func(inReporter ormapi.Reporter, genReport ormapi.GenerateReport, orgType string, wg *sync.WaitGroup) {
+				log.SpanLog(ctx, log.DebugLevelInfo, "Generate report", "reporter", inReporter.Name, "orgtype", orgType, "args", genReport)
+				eventPrefix := "Cloudlet report"
 				tags := map[string]string{"cloudletorg": genReport.Org}
+				if orgType == OrgTypeDeveloper {
+					eventPrefix = "Developer report"
+					tags = map[string]string{"org": genReport.Org}
+				}
 				defer wg.Done()
 				var output bytes.Buffer
-				_, err = GenerateCloudletReport(ctx, inReporter.Username, regions, &genReport, &output, OutputPDF)
+				err := GenerateReportOutput(ctx, inReporter.Username, orgType, regions, &genReport, &output)
 				if err != nil {
-					log.SpanLog(ctx, log.DebugLevelInfo, "failed to generate cloudlet report", "org", genReport.Org, "err", err)
-					nodeMgr.Event(ctx, "Cloudlet report generation failure", genReport.Org, tags, err)
+					log.SpanLog(ctx, log.DebugLevelInfo, "failed to generate report", "org", genReport.Org, "err", err)
+					reportsGeneratedTotal.WithLabelValues(metricResultFailure).Inc()
+					nodeMgr.Event(ctx, eventPrefix+" generation failure", genReport.Org, tags, err)
 					updateReporterData(
 						ctx, inReporter.Name,
 						genReport.Org, time.Time{},
@@ -240,27 +316,32 @@ func GenerateReports() {
 					return
 				}
 				errStrs := []string{}
-				// Upload PDF report to cloudlet
+				// Upload report to cloudlet
 				filename := ormapi.GetReporterFileName(inReporter.Name, &genReport)
 				err = storageClient.UploadObject(ctx, filename, "", &output)
 				if err != nil {
-					nodeMgr.Event(ctx, "Cloudlet report upload failure", genReport.Org, tags, err)
-					log.SpanLog(ctx, log.DebugLevelInfo, "failed to upload cloudlet report to cloudlet", "org", genReport.Org, "err", err)
+					nodeMgr.Event(ctx, eventPrefix+" upload failure", genReport.Org, tags, err)
+					log.SpanLog(ctx, log.DebugLevelInfo, "failed to upload report to cloud storage", "org", genReport.Org, "err", err)
 					// if file upload failed, continue
 					errStrs = append(errStrs, fmt.Sprintf("Failed to upload report to cloudlet: %v", err))
 				}
 				// Trigger email
-				err = sendOperatorReportEmail(ctx, inReporter.Username, inReporter.Email, inReporter.Name, &genReport, filename, output.Bytes())
+				err = sendReportEmail(ctx, inReporter.Username, inReporter.Email, inReporter.Name, orgType, &genReport, filename, output.Bytes())
 				if err != nil {
-					nodeMgr.Event(ctx, "Send Cloudlet report email", genReport.Org, tags, err)
-					log.SpanLog(ctx, log.DebugLevelInfo, "failed to send cloudlet report email", "org", genReport.Org, "email", inReporter.Email, "err", err)
+					nodeMgr.Event(ctx, "Send "+eventPrefix+" email", genReport.Org, tags, err)
+					log.SpanLog(ctx, log.DebugLevelInfo, "failed to send report email", "org", genReport.Org, "email", inReporter.Email, "err", err)
 					// if send email failed, continue
 					errStrs = append(errStrs, fmt.Sprintf("Failed to send report to configured email: %v", err))
 				}
+				if len(errStrs) > 0 {
+					reportsGeneratedTotal.WithLabelValues(metricResultPartial).Inc()
+				} else {
+					reportsGeneratedTotal.WithLabelValues(metricResultSuccess).Inc()
+				}
 				// Update next schedule date
 				newDate := ormapi.StripTime(genReport.EndTime.AddDate(0, monthCount, dayCount))
 				updateReporterData(ctx, inReporter.Name, genReport.Org, newDate, errStrs)
-			}(reporter, genReport, &wg)
+			}(reporter, genReport, orgType, &wg)
 		}
 		go func() {
 			wg.Wait()
@@ -269,13 +350,15 @@ func GenerateReports() {
 		// wait for all threads to exit
 		select {
 		case <-wgDone:
-			log.SpanLog(ctx, log.DebugLevelInfo, "Done Generating operator reports")
+			log.SpanLog(ctx, log.DebugLevelInfo, "Done Generating reports")
+			reporterRunsTotal.WithLabelValues(metricResultSuccess).Inc()
 		case <-time.After(ReportTimeout):
-			log.SpanLog(ctx, log.DebugLevelInfo, "Timed out generating operator reports")
+			log.SpanLog(ctx, log.DebugLevelInfo, "Timed out generating reports")
+			reporterRunsTotal.WithLabelValues(metricResultTimeout).Inc()
 		}
 		storageClient.Close()
 		reportTime = getNextReportTimeUTC(nil)
-		log.SpanLog(ctx, log.DebugLevelInfo, "Next operator report generation run info", "date", reportTime)
+		log.SpanLog(ctx, log.DebugLevelInfo, "Next report generation run info", "date", reportTime)
 		span.Finish()
 	}
 }
@@ -340,17 +423,8 @@ func CreateReporter(c echo.Context) error {
 	if reporter.Org == "" {
 		return fmt.Errorf("Org name has to be specified")
 	}
-	// get org details
-	orgCheck, err := orgExists(ctx, reporter.Org)
-	if err != nil {
-		return err
-	}
-	if orgCheck.Type != OrgTypeOperator {
-		return fmt.Errorf("Reporter can only be created for Operator org")
-	}
-
 	// check if user is authorized to create reporter
-	if err := authorized(ctx, claims.Username, reporter.Org, ResourceCloudlets, ActionManage); err != nil {
+	if _, err := authorizedReporterOrg(ctx, claims.Username, reporter.Org, ActionManage); err != nil {
 		return err
 	}
 
@@ -367,6 +441,9 @@ func CreateReporter(c echo.Context) error {
 	if _, ok := edgeproto.ReportSchedule_name[int32(reporter.Schedule)]; !ok {
 		return fmt.Errorf("Invalid reporter schedule")
 	}
+	if err := validReportFormat(reporter.Format); err != nil {
+		return err
+	}
 	if reporter.Timezone == "" {
 		reporter.Timezone = "UTC"
 	}
@@ -459,7 +536,7 @@ func UpdateReporter(c echo.Context) error {
 	}
 
 	// check if user is authorized to update reporter
-	if err := authorized(ctx, claims.Username, reporter.Org, ResourceCloudlets, ActionManage); err != nil {
+	if _, err := authorizedReporterOrg(ctx, claims.Username, reporter.Org, ActionManage); err != nil {
 		return err
 	}
 
@@ -485,6 +562,12 @@ func UpdateReporter(c echo.Context) error {
 	if reporter.Username != oldReporter.Username {
 		return fmt.Errorf("Cannot change username")
 	}
+	if reporter.Format != oldReporter.Format {
+		if err := validReportFormat(reporter.Format); err != nil {
+			return err
+		}
+		applyUpdate = true
+	}
 	if reporter.Timezone == "" {
 		reporter.Timezone = "UTC"
 	}
@@ -591,7 +674,7 @@ func DeleteReporter(c echo.Context) error {
 		return ormutil.NewHTTPError(http.StatusInternalServerError, ormutil.DbErr(res.Error).Error())
 	}
 	// check if user is authorized to delete reporter
-	if err := authorized(ctx, claims.Username, reporter.Org, ResourceCloudlets, ActionManage); err != nil {
+	if _, err := authorizedReporterOrg(ctx, claims.Username, reporter.Org, ActionManage); err != nil {
 		return err
 	}
 	err = tx.Delete(&reporter).Error
@@ -619,10 +702,18 @@ func ShowReporter(c echo.Context) error {
 			return ormutil.BindErr(err)
 		}
 	}
+	// reporters may belong to operator or developer orgs
 	authOrgs, err := enforcer.GetAuthorizedOrgs(ctx, claims.Username, ResourceCloudletAnalytics, ActionView)
 	if err != nil {
 		return ormutil.DbErr(err)
 	}
+	devAuthOrgs, err := enforcer.GetAuthorizedOrgs(ctx, claims.Username, ResourceAppAnalytics, ActionView)
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	for org, _ := range devAuthOrgs {
+		authOrgs[org] = struct{}{}
+	}
 	if len(authOrgs) == 0 {
 		return echo.ErrForbidden
 	}
@@ -675,20 +766,11 @@ func GenerateReportObj(c echo.Context, dataOnly bool) error {
 	if org == "" {
 		return fmt.Errorf("org not specified")
 	}
-	// auth for Operator access only
-	// get org details
-	orgCheck, err := orgExists(ctx, org)
+	// check if user is authorized to generate reports for the org
+	orgCheck, err := authorizedReporterOrg(ctx, claims.Username, org, ActionView)
 	if err != nil {
 		return err
 	}
-	if orgCheck.Type != OrgTypeOperator {
-		return fmt.Errorf("report can only be generated for Operator org")
-	}
-
-	// check if user is authorized to generate cloudlet reports
-	if err := authorized(ctx, claims.Username, report.Org, ResourceCloudletAnalytics, ActionView); err != nil {
-		return err
-	}
 
 	_, startTimeZoneOffset := report.StartTime.Zone()
 	_, endTimeZoneOffset := report.EndTime.Zone()
@@ -699,6 +781,9 @@ func GenerateReportObj(c echo.Context, dataOnly bool) error {
 	if report.Timezone == "" {
 		report.Timezone = "UTC"
 	}
+	if err := validReportFormat(report.Format); err != nil {
+		return err
+	}
 	match, err := tzMatch(report.Timezone, report.StartTime)
 	if err != nil {
 		return err
@@ -728,20 +813,23 @@ func GenerateReportObj(c echo.Context, dataOnly bool) error {
 		return err
 	}
 
-	var output bytes.Buffer
-	data, err := GenerateCloudletReport(ctx, claims.Username, regions, &report, &output, dataOnly)
-	if err != nil {
-		return err
-	}
-
 	if dataOnly {
+		data, err := GenerateOrgReport(ctx, claims.Username, orgCheck.Type, regions, &report, nil, dataOnly)
+		if err != nil {
+			return err
+		}
 		if data == nil {
 			return fmt.Errorf("No report data")
 		}
 		return c.JSON(http.StatusOK, data)
 	}
 
-	return c.HTMLBlob(http.StatusOK, output.Bytes())
+	var output bytes.Buffer
+	err = GenerateReportOutput(ctx, claims.Username, orgCheck.Type, regions, &report, &output)
+	if err != nil {
+		return err
+	}
+	return reportBlob(c, ormapi.GetReportFileName(&report), output.Bytes())
 }
 
 func GetCloudletSummaryData(ctx context.Context, username string, report *ormapi.GenerateReport) ([][]string, error) {
@@ -786,11 +874,11 @@ func GetCloudletPoolSummaryData(ctx context.Context, username string, report *or
 	}
 	poolAcceptedDevelopers := make(map[string][]string)
 	poolPendingDevelopers := make(map[string][]string)
-	acceptedOps, err := showCloudletPoolAccessObj(ctx, username, filter, accessTypeGranted)
+	acceptedOps, err := showCloudletPoolAccessObj(ctx, username, filter, accessTypeGranted, nil)
 	if err != nil {
 		return nil, err
 	}
-	pendingOps, err := showCloudletPoolAccessObj(ctx, username, filter, accessTypePending)
+	pendingOps, err := showCloudletPoolAccessObj(ctx, username, filter, accessTypePending, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1435,7 +1523,7 @@ func GenerateCloudletReport(ctx context.Context, username string, regions []stri
 	if _, err := os.Stat(logoPath); os.IsNotExist(err) {
 		return nil, fmt.Errorf("Missing logo")
 	}
-	pdfReport, err := NewReport(report)
+	pdfReport, err := NewReport(report, "Operator")
 	if err != nil {
 		return nil, err
 	}
@@ -1459,10 +1547,10 @@ func GenerateCloudletReport(ctx context.Context, username string, regions []stri
 		pdfReport.ResetHeader()
 		pdfReport.AddPage()
 
-		pdfReport.AddReportTitle(logoPath)
+		pdfReport.AddReportTitle(CloudletReportTitle, logoPath)
 		pdfReport.AddHeader(report, logoPath, NoCloudlet)
 		pdfReport.AddFooter()
-		pdfReport.AddOperatorInfo(report)
+		pdfReport.AddOrgInfo(report)
 		pdfReport.AddHorizontalLine()
 
 		// Step-1: Gather all data
@@ -1548,15 +1636,13 @@ func GenerateCloudletReport(ctx context.Context, username string, regions []stri
 		// Step-2: Render data
 		// -------------------------
 		// Get list of cloudlets
-		header := []string{"Name", "Platform Type", "Last Known State"}
 		columnsWidth := []float64{60, 30, 35}
-		pdfReport.AddTable("Cloudlets", header, cloudlets_summary, columnsWidth)
+		pdfReport.AddTable("Cloudlets", CloudletsReportHeader, cloudlets_summary, columnsWidth)
 
 		// Get list of cloudletpools
 		if len(cloudletpools) > 0 {
-			header = []string{"Name", "Associated Cloudlets", "Accepted Developers", "Pending Developers"}
 			columnsWidth = []float64{30, 60, 50, 50}
-			pdfReport.AddTable("CloudletPools - Last Known Details", header, cloudletpools, columnsWidth)
+			pdfReport.AddTable("CloudletPools - Last Known Details", CloudletPoolsReportHeader, cloudletpools, columnsWidth)
 		}
 
 		// Sort cloudlet by name
@@ -1591,16 +1677,14 @@ func GenerateCloudletReport(ctx context.Context, username string, regions []stri
 			}
 			// Get cloudlet events
 			if data, ok := eventsData[cloudletName]; ok {
-				header = []string{"Timestamp", "Description"}
 				columnsWidth = []float64{50, 120}
-				pdfReport.AddTable("Cloudlet Events", header, data, columnsWidth)
+				pdfReport.AddTable("Cloudlet Events", CloudletEventsReportHeader, data, columnsWidth)
 			}
 
 			// Get cloudlet alerts
 			if data, ok := alertsData[cloudletName]; ok {
-				header = []string{"Timestamp", "Description", "State"}
 				columnsWidth = []float64{40, 100, 30}
-				pdfReport.AddTable("Cloudlet Alerts", header, data, columnsWidth)
+				pdfReport.AddTable("Cloudlet Alerts", CloudletAlertsReportHeader, data, columnsWidth)
 			}
 
 			// Get app count by developer on cloudlet
@@ -1613,9 +1697,8 @@ func GenerateCloudletReport(ctx context.Context, username string, regions []stri
 
 			// Get app state events
 			if data, ok := appEventsData[cloudletName]; ok {
-				header = []string{"Timestamp", "App Info", "Developer", "Cluster Info", "State"}
 				columnsWidth = []float64{30, 40, 40, 40, 30}
-				pdfReport.AddTable("Developer App State", header, data, columnsWidth)
+				pdfReport.AddTable("Developer App State", AppEventsReportHeader, data, columnsWidth)
 			}
 		}
 	}
@@ -1662,7 +1745,7 @@ func ShowReport(c echo.Context) error {
 		return fmt.Errorf("Org name has to be specified")
 	}
 	// check if user is authorized to view reports
-	if err := authorized(ctx, claims.Username, reportQuery.Org, ResourceCloudletAnalytics, ActionView); err != nil {
+	if _, err := authorizedReporterOrg(ctx, claims.Username, reportQuery.Org, ActionView); err != nil {
 		return err
 	}
 
@@ -1721,7 +1804,7 @@ func DownloadReport(c echo.Context) error {
 		return fmt.Errorf("Only org %s related reports can be accessed", reportQuery.Org)
 	}
 	// check if user is authorized to view reports
-	if err := authorized(ctx, claims.Username, reportQuery.Org, ResourceCloudletAnalytics, ActionView); err != nil {
+	if _, err := authorizedReporterOrg(ctx, claims.Username, reportQuery.Org, ActionView); err != nil {
 		return err
 	}
 
@@ -1755,5 +1838,5 @@ func DownloadReport(c echo.Context) error {
 		return fmt.Errorf("Failed to read from downloaded report %s: %v", outFilePath, err)
 	}
 
-	return c.HTMLBlob(http.StatusOK, data)
+	return reportBlob(c, reportQuery.Filename, data)
 }
@@ -20,6 +20,7 @@ import (
 	"net/http"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/labstack/echo"
 	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
@@ -252,6 +253,10 @@ func ShowRoleAssignment(c echo.Context) error {
 	if err != nil {
 		return ormutil.DbErr(err)
 	}
+	expirations, err := getRoleExpirations(ctx)
+	if err != nil {
+		return err
+	}
 	ret := []*ormapi.Role{}
 	for ii, _ := range groupings {
 		role := parseRole(groupings[ii])
@@ -264,6 +269,7 @@ func ShowRoleAssignment(c echo.Context) error {
 		if !roleMatchesFilter(role, filter) {
 			continue
 		}
+		setRoleExpiresAt(role, expirations)
 		ret = append(ret, role)
 	}
 	return c.JSON(http.StatusOK, ret)
@@ -386,6 +392,64 @@ func AddUserRole(c echo.Context) error {
 	return ormutil.SetReply(c, ormutil.Msg("Role added to user"))
 }
 
+// Check that the role exists and may be assigned for the org.
+// Returns the org type, which is blank for the empty org.
+func checkRoleForOrg(ctx context.Context, roleName, orgName string) (string, error) {
+	policies, err := enforcer.GetPolicy()
+	if err != nil {
+		return "", ormutil.DbErr(err)
+	}
+	roleFound := false
+	for _, policy := range policies {
+		if len(policy) < 1 {
+			continue
+		}
+		if policy[0] == roleName {
+			roleFound = true
+			break
+		}
+	}
+	if !roleFound {
+		return "", fmt.Errorf("Role not found")
+	}
+	orgType := ""
+	if orgName != "" {
+		org := ormapi.Organization{}
+		db := loggedDB(ctx)
+		res := db.Where(&ormapi.Organization{Name: orgName}).First(&org)
+		if res.RecordNotFound() {
+			return "", fmt.Errorf("Organization not found")
+		}
+		if res.Error != nil {
+			return "", ormutil.DbErr(res.Error)
+		}
+		// Restricting role types to match org types isn't strictly
+		// necessary. For example, giving role AdminManager for
+		// org foobar won't allow that user to modify controllers
+		// or flavors or clusterflavors, because those perms are
+		// tied to the blank org, "". But it does probably confuse
+		// the user, so disallow it to prevent confusion.
+		// Custom roles are restricted to the org type they were
+		// created for.
+		customRole, err := getCustomRole(ctx, roleName)
+		if err != nil {
+			return "", err
+		}
+		customRoleType := ""
+		if customRole != nil {
+			customRoleType = customRole.OrgType
+		}
+		if org.Type == OrgTypeDeveloper && !isDeveloperRole(roleName) && customRoleType != OrgTypeDeveloper {
+			return "", fmt.Errorf("Can only assign developer roles for developer organization")
+		}
+		if org.Type == OrgTypeOperator && !isOperatorRole(roleName) && customRoleType != OrgTypeOperator {
+			return "", fmt.Errorf("Can only assign operator roles for operator organization")
+		}
+		orgType = org.Type
+	}
+	return orgType, nil
+}
+
 func AddUserRoleObj(ctx context.Context, claims *UserClaims, role *ormapi.Role) error {
 	if role.Username == "" {
 		return fmt.Errorf("Username not specified")
@@ -400,6 +464,9 @@ func AddUserRoleObj(ctx context.Context, claims *UserClaims, role *ormapi.Role)
 		span := log.SpanFromContext(ctx)
 		span.SetTag("org", role.Org)
 	}
+	if !role.ExpiresAt.IsZero() && !role.ExpiresAt.After(time.Now()) {
+		return fmt.Errorf("Expires at time must be in the future")
+	}
 	// Special case Admin roles and the empty org (which implies all orgs).
 	// AdminRoles may only be associated to the empty org, and the
 	// empty org may only be associated with Admin roles.
@@ -435,47 +502,11 @@ func AddUserRoleObj(ctx context.Context, claims *UserClaims, role *ormapi.Role)
 			return err
 		}
 	}
-	policies, err := enforcer.GetPolicy()
+	orgType, err := checkRoleForOrg(ctx, role.Role, role.Org)
 	if err != nil {
-		return ormutil.DbErr(err)
-	}
-	roleFound := false
-	for _, policy := range policies {
-		if len(policy) < 1 {
-			continue
-		}
-		if policy[0] == role.Role {
-			roleFound = true
-			break
-		}
-	}
-	if !roleFound {
-		return fmt.Errorf("Role not found")
+		return err
 	}
-	orgType := ""
 	if role.Org != "" {
-		org := ormapi.Organization{}
-		res = db.Where(&ormapi.Organization{Name: role.Org}).First(&org)
-		if res.RecordNotFound() {
-			return fmt.Errorf("Organization not found")
-		}
-		if res.Error != nil {
-			return ormutil.DbErr(res.Error)
-		}
-		// Restricting role types to match org types isn't strictly
-		// necessary. For example, giving role AdminManager for
-		// org foobar won't allow that user to modify controllers
-		// or flavors or clusterflavors, because those perms are
-		// tied to the blank org, "". But it does probably confuse
-		// the user, so disallow it to prevent confusion.
-		if org.Type == OrgTypeDeveloper && !isDeveloperRole(role.Role) {
-			return fmt.Errorf("Can only assign developer roles for developer organization")
-		}
-		if org.Type == OrgTypeOperator && !isOperatorRole(role.Role) {
-			return fmt.Errorf("Can only assign operator roles for operator organization")
-		}
-		orgType = org.Type
-
 		groupings, err := enforcer.GetGroupingPolicy()
 		if err != nil {
 			return ormutil.DbErr(err)
@@ -507,8 +538,27 @@ func AddUserRoleObj(ctx context.Context, claims *UserClaims, role *ormapi.Role)
 	if err != nil {
 		return ormutil.DbErr(err)
 	}
+	// always update the expiration, to clear out any stale
+	// expiration left behind by a previous assignment
+	if role.ExpiresAt.IsZero() {
+		err = deleteRoleExpiration(ctx, role)
+	} else {
+		err = setRoleExpiration(ctx, role)
+	}
+	if err != nil {
+		// undo, ignore errors
+		enforcer.RemoveGroupingPolicy(ctx, psub, role.Role)
+		return err
+	}
 	// notify recipient that they were added. don't fail on error
-	senderr := sendAddedEmail(ctx, claims.Username, targetUser.Name, targetUser.Email, role.Org, role.Role)
+	msg := fmt.Sprintf("User %s added you to Organization %s with role %s", claims.Username, role.Org, role.Role)
+	if !role.ExpiresAt.IsZero() {
+		msg += fmt.Sprintf(" until %s", role.ExpiresAt.UTC().Format(time.RFC1123))
+		triggerRoleExpirations()
+	}
+	senderr := notifyUser(ctx, &targetUser, NotifyCategoryRoles, role.Org, "", msg, func() error {
+		return sendAddedEmail(ctx, claims.Username, targetUser.Name, targetUser.Email, role.Org, role.Role)
+	})
 	if senderr != nil {
 		log.SpanLog(ctx, log.DebugLevelApi, "failed to send role added email", "err", senderr)
 	}
@@ -590,12 +640,28 @@ func RemoveUserRoleObj(ctx context.Context, claims *UserClaims, role *ormapi.Rol
 	if err != nil {
 		return ormutil.DbErr(err)
 	}
+	if err := deleteRoleExpiration(ctx, role); err != nil {
+		// stale expiration will be cleaned up by the role expiry thread
+		log.SpanLog(ctx, log.DebugLevelApi, "failed to delete role expiration", "role", role, "err", err)
+	}
 
 	org := ormapi.Organization{}
 	// ignore any error
 	db := loggedDB(ctx)
 	db.Where(&ormapi.Organization{Name: role.Org}).First(&org)
 
+	// notify user that they were removed. don't fail on error
+	targetUser := ormapi.User{}
+	res := db.Where(&ormapi.User{Name: role.Username}).First(&targetUser)
+	if res.Error == nil {
+		subject := fmt.Sprintf("Removed from %s", role.Org)
+		msg := fmt.Sprintf("User %s removed your role %s from Organization %s", claims.Username, role.Role, role.Org)
+		senderr := notifyUser(ctx, &targetUser, NotifyCategoryRoles, role.Org, subject, msg, nil)
+		if senderr != nil {
+			log.SpanLog(ctx, log.DebugLevelApi, "failed to send role removed email", "err", senderr)
+		}
+	}
+
 	gitlabRemoveGroupMember(ctx, role, org.Type)
 	artifactoryRemoveUserFromGroup(ctx, role, org.Type)
 
@@ -634,6 +700,10 @@ func ShowUserRoleObj(ctx context.Context, username string, filter map[string]int
 	if err != nil {
 		return nil, err
 	}
+	expirations, err := getRoleExpirations(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	for ii, _ := range groupings {
 		role := parseRole(groupings[ii])
@@ -650,6 +720,7 @@ func ShowUserRoleObj(ctx context.Context, username string, filter map[string]int
 		if !roleMatchesFilter(role, filter) {
 			continue
 		}
+		setRoleExpiresAt(role, expirations)
 		roles = append(roles, *role)
 	}
 	return roles, nil
@@ -0,0 +1,281 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormutil"
+	"github.com/mobiledgex/edge-cloud-infra/mc/rbac"
+	"github.com/mobiledgex/edge-cloud/cloudcommon/node"
+	"github.com/mobiledgex/edge-cloud/log"
+)
+
+var (
+	roleExpiryTrigger chan bool
+
+	RoleExpiryCheckInterval = 1 * time.Minute
+)
+
+type roleExpirationKey struct {
+	org      string
+	username string
+	role     string
+}
+
+// Role assignment expirations are stored separately from the
+// RBAC grouping policies, which have no place for extra data.
+func setRoleExpiration(ctx context.Context, role *ormapi.Role) error {
+	exp := ormapi.RoleExpiration{
+		Org:       role.Org,
+		Username:  role.Username,
+		Role:      role.Role,
+		ExpiresAt: role.ExpiresAt,
+	}
+	db := loggedDB(ctx)
+	if err := db.Save(&exp).Error; err != nil {
+		return ormutil.DbErr(err)
+	}
+	return nil
+}
+
+func deleteRoleExpiration(ctx context.Context, role *ormapi.Role) error {
+	exp := ormapi.RoleExpiration{
+		Org:      role.Org,
+		Username: role.Username,
+		Role:     role.Role,
+	}
+	db := loggedDB(ctx)
+	if err := db.Delete(&exp).Error; err != nil {
+		return ormutil.DbErr(err)
+	}
+	return nil
+}
+
+func getRoleExpirations(ctx context.Context) (map[roleExpirationKey]time.Time, error) {
+	exps := []ormapi.RoleExpiration{}
+	db := loggedDB(ctx)
+	if err := db.Find(&exps).Error; err != nil {
+		return nil, ormutil.DbErr(err)
+	}
+	expirations := make(map[roleExpirationKey]time.Time)
+	for _, exp := range exps {
+		key := roleExpirationKey{
+			org:      exp.Org,
+			username: exp.Username,
+			role:     exp.Role,
+		}
+		expirations[key] = exp.ExpiresAt
+	}
+	return expirations, nil
+}
+
+func setRoleExpiresAt(role *ormapi.Role, expirations map[roleExpirationKey]time.Time) {
+	key := roleExpirationKey{
+		org:      role.Org,
+		username: role.Username,
+		role:     role.Role,
+	}
+	if expiresAt, found := expirations[key]; found {
+		role.ExpiresAt = expiresAt
+	}
+}
+
+// Get the manager role for the org, which for the empty org
+// is the admin manager.
+func getOrgManagerRole(ctx context.Context, orgName string) (string, error) {
+	if orgName == "" {
+		return RoleAdminManager, nil
+	}
+	org := ormapi.Organization{}
+	db := loggedDB(ctx)
+	res := db.Where(&ormapi.Organization{Name: orgName}).First(&org)
+	if res.Error != nil {
+		return "", ormutil.DbErr(res.Error)
+	}
+	if org.Type == OrgTypeOperator {
+		return RoleOperatorManager, nil
+	}
+	return RoleDeveloperManager, nil
+}
+
+// Warn the user and the org managers that the role assignment will expire
+func sendRoleExpiryWarning(ctx context.Context, exp *ormapi.RoleExpiration) error {
+	managerRole, err := getOrgManagerRole(ctx, exp.Org)
+	if err != nil {
+		return err
+	}
+	groupings, err := enforcer.GetGroupingPolicy()
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	usernames := []string{exp.Username}
+	for ii, _ := range groupings {
+		r := parseRole(groupings[ii])
+		if r == nil || r.Org != exp.Org || r.Role != managerRole || r.Username == exp.Username {
+			continue
+		}
+		usernames = append(usernames, r.Username)
+	}
+
+	orgDesc := "Organization " + exp.Org
+	if exp.Org == "" {
+		orgDesc = "all Organizations"
+	}
+	subject := fmt.Sprintf("Role %s expiring", exp.Role)
+	msg := fmt.Sprintf("Role %s of user %s for %s expires at %s", exp.Role, exp.Username, orgDesc, exp.ExpiresAt.UTC().Format(time.RFC1123))
+	db := loggedDB(ctx)
+	for _, username := range usernames {
+		user := ormapi.User{Name: username}
+		res := db.Where(&user).First(&user)
+		if res.Error != nil {
+			log.SpanLog(ctx, log.DebugLevelApi, "failed to look up user for role expiry warning", "user", username, "err", res.Error)
+			continue
+		}
+		err := notifyUser(ctx, &user, NotifyCategoryRoles, exp.Org, subject, msg, nil)
+		if err != nil {
+			log.SpanLog(ctx, log.DebugLevelApi, "failed to send role expiry warning", "user", username, "err", err)
+		}
+	}
+	return nil
+}
+
+// Remove the expired role assignment
+func expireUserRole(ctx context.Context, exp *ormapi.RoleExpiration, now time.Time) error {
+	role := ormapi.Role{
+		Org:      exp.Org,
+		Username: exp.Username,
+		Role:     exp.Role,
+	}
+	psub := rbac.GetCasbinGroup(role.Org, role.Username)
+	err := enforcer.RemoveGroupingPolicy(ctx, psub, role.Role)
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	if err := deleteRoleExpiration(ctx, &role); err != nil {
+		return err
+	}
+	tags := map[string]string{
+		"username": role.Username,
+		"role":     role.Role,
+	}
+	nodeMgr.TimedEvent(ctx, "Role assignment expired", role.Org, node.AuditType, tags, nil, exp.ExpiresAt, now)
+
+	org := ormapi.Organization{}
+	db := loggedDB(ctx)
+	// ignore any error
+	db.Where(&ormapi.Organization{Name: role.Org}).First(&org)
+
+	// notify user that the role was removed. don't fail on error
+	targetUser := ormapi.User{}
+	res := db.Where(&ormapi.User{Name: role.Username}).First(&targetUser)
+	if res.Error == nil {
+		subject := fmt.Sprintf("Role %s expired", role.Role)
+		msg := fmt.Sprintf("Your role %s for Organization %s has expired", role.Role, role.Org)
+		senderr := notifyUser(ctx, &targetUser, NotifyCategoryRoles, role.Org, subject, msg, nil)
+		if senderr != nil {
+			log.SpanLog(ctx, log.DebugLevelApi, "failed to send role expired email", "err", senderr)
+		}
+	}
+
+	gitlabRemoveGroupMember(ctx, &role, org.Type)
+	artifactoryRemoveUserFromGroup(ctx, &role, org.Type)
+	return nil
+}
+
+func checkRoleExpirations(ctx context.Context, now time.Time) error {
+	config, err := getConfig(ctx)
+	if err != nil {
+		return err
+	}
+	warnDuration := config.RoleExpiryWarningDuration.TimeDuration()
+
+	exps := []ormapi.RoleExpiration{}
+	db := loggedDB(ctx)
+	if err := db.Find(&exps).Error; err != nil {
+		return ormutil.DbErr(err)
+	}
+	for ii, _ := range exps {
+		exp := &exps[ii]
+		psub := rbac.GetCasbinGroup(exp.Org, exp.Username)
+		found, err := enforcer.HasGroupingPolicy(psub, exp.Role)
+		if err != nil {
+			return ormutil.DbErr(err)
+		}
+		if !found {
+			// role was removed some other way, i.e. by
+			// deleting the org or user.
+			role := ormapi.Role{
+				Org:      exp.Org,
+				Username: exp.Username,
+				Role:     exp.Role,
+			}
+			if err := deleteRoleExpiration(ctx, &role); err != nil {
+				log.SpanLog(ctx, log.DebugLevelInfo, "failed to delete stale role expiration", "role", role, "err", err)
+			}
+			continue
+		}
+		if !now.Before(exp.ExpiresAt) {
+			err := expireUserRole(ctx, exp, now)
+			if err != nil {
+				log.SpanLog(ctx, log.DebugLevelInfo, "failed to remove expired role", "expiration", exp, "err", err)
+			}
+			continue
+		}
+		if !exp.WarningSent && !now.Before(exp.ExpiresAt.Add(-warnDuration)) {
+			err := sendRoleExpiryWarning(ctx, exp)
+			if err != nil {
+				log.SpanLog(ctx, log.DebugLevelInfo, "failed to send role expiry warning", "expiration", exp, "err", err)
+				continue
+			}
+			err = db.Model(exp).Update("warning_sent", true).Error
+			if err != nil {
+				return ormutil.DbErr(err)
+			}
+		}
+	}
+	return nil
+}
+
+// Start role expiry thread to warn about and remove expiring role assignments
+func RunRoleExpirations() {
+	for {
+		select {
+		case <-time.After(RoleExpiryCheckInterval):
+		case <-roleExpiryTrigger:
+		}
+		span := log.StartSpan(log.DebugLevelInfo, "Role expiry thread")
+		ctx := log.ContextWithSpan(context.Background(), span)
+		err := checkRoleExpirations(ctx, time.Now())
+		if err != nil {
+			log.SpanLog(ctx, log.DebugLevelInfo, "Failed to check role expirations", "err", err)
+		}
+		span.Finish()
+	}
+}
+
+func InitRoleExpirations() {
+	roleExpiryTrigger = make(chan bool, 10)
+}
+
+func triggerRoleExpirations() {
+	select {
+	case roleExpiryTrigger <- true:
+	default:
+	}
+}
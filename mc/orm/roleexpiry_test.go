@@ -0,0 +1,132 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mobiledgex/edge-cloud-infra/mc/mcctl/mctestclient"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+	"github.com/mobiledgex/edge-cloud/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func testRoleExpiry(t *testing.T, ctx context.Context, mcClient *mctestclient.Client, uri string) {
+	mockMail := MockSendMail{}
+
+	user1, token1, _ := testCreateUser(t, mcClient, uri, "expiryuser1")
+	user2, token2, _ := testCreateUser(t, mcClient, uri, "expiryuser2")
+	org := testCreateOrg(t, mcClient, uri, token1, OrgTypeDeveloper, "ExpiryOrg")
+
+	mockMail.Start()
+	defer mockMail.Stop()
+
+	expirationCount := func() int {
+		exps := []ormapi.RoleExpiration{}
+		err := loggedDB(ctx).Find(&exps).Error
+		require.Nil(t, err)
+		return len(exps)
+	}
+
+	// expiry must be in the future
+	role := ormapi.Role{
+		Org:       org.Name,
+		Username:  user2.Name,
+		Role:      RoleDeveloperContributor,
+		ExpiresAt: time.Now().Add(-time.Hour),
+	}
+	_, err := mcClient.AddUserRole(uri, token1, &role)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "must be in the future")
+
+	// beyond the default warning duration of 72h
+	expiresAt := time.Now().Add(96 * time.Hour).Truncate(time.Second)
+	role.ExpiresAt = expiresAt
+	status, err := mcClient.AddUserRole(uri, token1, &role)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, 1, expirationCount())
+
+	assignments, status, err := mcClient.ShowRoleAssignment(uri, token2, ClientNoShowFilter)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, 1, len(assignments))
+	require.True(t, expiresAt.Equal(assignments[0].ExpiresAt))
+	filter := &cli.MapData{
+		Namespace: cli.StructNamespace,
+		Data: map[string]interface{}{
+			"Username": user2.Name,
+		},
+	}
+	roles, status, err := mcClient.ShowUserRole(uri, token1, filter)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, 1, len(roles))
+	require.True(t, expiresAt.Equal(roles[0].ExpiresAt))
+
+	// no warning yet
+	mockMail.Reset()
+	err = checkRoleExpirations(ctx, time.Now())
+	require.Nil(t, err)
+	require.Equal(t, "", mockMail.To)
+
+	// warning goes to the user and the org managers, only once
+	err = checkRoleExpirations(ctx, expiresAt.Add(-time.Hour))
+	require.Nil(t, err)
+	require.Equal(t, user1.Email, mockMail.To)
+	require.Contains(t, mockMail.Message, "Role DeveloperContributor of user expiryuser2 for Organization ExpiryOrg expires at")
+	exp := ormapi.RoleExpiration{}
+	err = loggedDB(ctx).Where(&ormapi.RoleExpiration{Username: user2.Name}).First(&exp).Error
+	require.Nil(t, err)
+	require.True(t, exp.WarningSent)
+	mockMail.Reset()
+	err = checkRoleExpirations(ctx, expiresAt.Add(-time.Minute))
+	require.Nil(t, err)
+	require.Equal(t, "", mockMail.To)
+
+	// role is removed on expiry
+	err = checkRoleExpirations(ctx, expiresAt)
+	require.Nil(t, err)
+	require.Equal(t, user2.Email, mockMail.To)
+	require.Contains(t, mockMail.Message, "has expired")
+	assignments, _, err = mcClient.ShowRoleAssignment(uri, token2, ClientNoShowFilter)
+	require.Nil(t, err)
+	require.Equal(t, 0, len(assignments))
+	require.Equal(t, 0, expirationCount())
+
+	// removing the role removes the expiration
+	role.ExpiresAt = time.Now().Add(time.Hour)
+	_, err = mcClient.AddUserRole(uri, token1, &role)
+	require.Nil(t, err)
+	require.Equal(t, 1, expirationCount())
+	testRemoveUserRole(t, mcClient, uri, token1, org.Name, role.Role, user2.Name, Success)
+	require.Equal(t, 0, expirationCount())
+
+	// stale expirations are cleaned up after the org is deleted
+	_, err = mcClient.AddUserRole(uri, token1, &role)
+	require.Nil(t, err)
+	testDeleteOrg(t, mcClient, uri, token1, org.Name)
+	require.Equal(t, 1, expirationCount())
+	err = checkRoleExpirations(ctx, time.Now())
+	require.Nil(t, err)
+	require.Equal(t, 0, expirationCount())
+
+	// cleanup
+	testDeleteUser(t, mcClient, uri, token1, user1.Name)
+	testDeleteUser(t, mcClient, uri, token2, user2.Name)
+}
@@ -72,6 +72,7 @@ type Server struct {
 	done            chan struct{}
 	alertMgrStarted bool
 	federationEcho  *echo.Echo
+	metricsServer   *http.Server
 }
 
 type ServerConfig struct {
@@ -79,6 +80,7 @@ type ServerConfig struct {
 	SqlAddr                  string
 	VaultAddr                string
 	FederationAddr           string
+	MetricsAddr              string
 	PublicAddr               string
 	RunLocal                 bool
 	InitLocal                bool
@@ -114,6 +116,7 @@ type ServerConfig struct {
 	ConsoleAddr              string
 	PasswordResetConsolePath string
 	VerifyEmailConsolePath   string
+	InviteConsolePath        string
 }
 
 var DefaultDBUser = "mcuser"
@@ -206,6 +209,9 @@ func RunServer(config *ServerConfig) (retserver *Server, reterr error) {
 	if config.VerifyEmailConsolePath != "" {
 		config.VerifyEmailConsolePath = strings.TrimPrefix(config.VerifyEmailConsolePath, "/")
 	}
+	if config.InviteConsolePath != "" {
+		config.InviteConsolePath = strings.TrimPrefix(config.InviteConsolePath, "/")
+	}
 
 	ops := []node.NodeOp{
 		node.WithName(config.Hostname),
@@ -342,7 +348,7 @@ func RunServer(config *ServerConfig) (retserver *Server, reterr error) {
 		if err != nil {
 			return nil, fmt.Errorf("Unable to get a client tls config, %s", err.Error())
 		}
-		AlertManagerServer, err = alertmgr.NewAlertMgrServer(config.AlertMgrAddr, tlsConfig,
+		AlertManagerServer, err = alertmgr.NewAlertMgrServer(config.AlertMgrAddr, tlsConfig, config.vaultConfig,
 			config.AlertCache, config.AlertmgrResolveTimout)
 		if err != nil {
 			// TODO - this needs to be a fatal failure when we add alertmanager deployment to the ansible scripts
@@ -405,8 +411,22 @@ func RunServer(config *ServerConfig) (retserver *Server, reterr error) {
 	e.POST(root+"/passwordreset", PasswordReset)
 	e.POST(root+"/verifyemail", VerifyEmail)
 	e.POST(root+"/resendverify", ResendVerify)
+	// swagger:route GET /sso/login Security SSOLogin
+	// Single Sign-On Login.
+	// Redirects to the identity provider to log in to MC.
+	// responses:
+	//   302: success
+	//   400: badRequest
+	e.GET(root+"/sso/login", SSOLogin)
+	// swagger:route GET /sso/callback Security SSOCallback
+	// Single Sign-On Callback.
+	// Completes the single sign-on login after the identity provider redirects back to MC.
+	// responses:
+	//   200: authToken
+	//   400: loginBadRequest
+	e.GET(root+"/sso/callback", SSOCallback)
 	// authenticated routes - jwt middleware
-	auth := e.Group(root + "/auth")
+	auth := e.Group(root+"/auth", ShowStreamPaging)
 	// refresh auth cookie
 	auth.POST("/refresh", RefreshAuthCookie)
 
@@ -448,6 +468,32 @@ func RunServer(config *ServerConfig) (retserver *Server, reterr error) {
 	auth.POST("/user/create/apikey", CreateUserApiKey)
 	auth.POST("/user/delete/apikey", DeleteUserApiKey)
 	auth.POST("/user/show/apikey", ShowUserApiKey)
+	auth.POST("/user/rotate/apikey", RotateUserApiKey)
+	auth.POST("/user/show/apikey/stale", ShowStaleUserApiKey)
+	// swagger:route POST /auth/user/show/notificationprefs User ShowNotificationPrefs
+	// Show Notification Preferences.
+	// Shows the notification preferences of the current user.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: success
+	//   400: badRequest
+	//   403: forbidden
+	//   404: notFound
+	auth.POST("/user/show/notificationprefs", ShowNotificationPrefs)
+	// swagger:route POST /auth/user/update/notificationprefs User UpdateNotificationPrefs
+	// Update Notification Preferences.
+	// Updates the notification preferences of the current user, which control
+	// the event categories, immediate or daily digest delivery, and quiet hours
+	// for notification emails.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: success
+	//   400: badRequest
+	//   403: forbidden
+	//   404: notFound
+	auth.POST("/user/update/notificationprefs", UpdateNotificationPrefs)
 	// swagger:route POST /auth/role/assignment/show Role ShowRoleAssignment
 	// Show Role Assignment.
 	// Show roles for the current user.
@@ -514,6 +560,138 @@ func RunServer(config *ServerConfig) (retserver *Server, reterr error) {
 	//   403: forbidden
 	//   404: notFound
 	auth.POST("/role/showuser", ShowUserRole)
+	// swagger:route POST /auth/role/custom/create Role CreateCustomRole
+	// Create Custom Role.
+	// Create a role composed of a subset of the permissions of the
+	// built-in manager role for the specified organization type.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: success
+	//   400: badRequest
+	//   403: forbidden
+	//   404: notFound
+	auth.POST("/role/custom/create", CreateCustomRole)
+	// swagger:route POST /auth/role/custom/update Role UpdateCustomRole
+	// Update Custom Role.
+	// Update the description or replace the permissions of a custom role.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: success
+	//   400: badRequest
+	//   403: forbidden
+	//   404: notFound
+	auth.POST("/role/custom/update", UpdateCustomRole)
+	// swagger:route POST /auth/role/custom/delete Role DeleteCustomRole
+	// Delete Custom Role.
+	// Delete a custom role that is not assigned to any user.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: success
+	//   400: badRequest
+	//   403: forbidden
+	//   404: notFound
+	auth.POST("/role/custom/delete", DeleteCustomRole)
+	// swagger:route POST /auth/role/custom/show Role ShowCustomRole
+	// Show Custom Roles.
+	// Show custom roles and their permissions.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: success
+	//   400: badRequest
+	//   403: forbidden
+	//   404: notFound
+	auth.POST("/role/custom/show", ShowCustomRole)
+	// swagger:route POST /auth/role/invite/create Role CreateOrgInvitation
+	// Create Organization Invitation.
+	// Invite a user by email address to join the organization with
+	// the given role. The invitation token is emailed to the invitee.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: orgInvitationResponse
+	//   400: badRequest
+	//   403: forbidden
+	//   404: notFound
+	auth.POST("/role/invite/create", CreateOrgInvitation)
+	// swagger:route POST /auth/role/invite/show Role ShowOrgInvitation
+	// Show Organization Invitations.
+	// Show pending invitations of organizations managed by the user.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: listOrgInvitations
+	//   400: badRequest
+	//   403: forbidden
+	//   404: notFound
+	auth.POST("/role/invite/show", ShowOrgInvitation)
+	// swagger:route POST /auth/role/invite/revoke Role RevokeOrgInvitation
+	// Revoke Organization Invitation.
+	// Revoke a pending invitation so that it can no longer be accepted.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: success
+	//   400: badRequest
+	//   403: forbidden
+	//   404: notFound
+	auth.POST("/role/invite/revoke", RevokeOrgInvitation)
+	// swagger:route POST /auth/role/invite/accept Role AcceptOrgInvitation
+	// Accept Organization Invitation.
+	// Accept an invitation sent to the user's email address, and
+	// join the organization with the invited role.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: success
+	//   400: badRequest
+	//   403: forbidden
+	//   404: notFound
+	auth.POST("/role/invite/accept", AcceptOrgInvitation)
+	// swagger:route POST /auth/idp/create IdentityProvider CreateIdentityProvider
+	// Create Identity Provider.
+	// Create an OpenID Connect identity provider for single sign-on logins.
+	// Only admins can manage identity providers.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: success
+	//   400: badRequest
+	//   403: forbidden
+	auth.POST("/idp/create", CreateIdentityProvider)
+	// swagger:route POST /auth/idp/update IdentityProvider UpdateIdentityProvider
+	// Update Identity Provider.
+	// Update an identity provider. Group mappings, if specified, replace the existing group mappings.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: success
+	//   400: badRequest
+	//   403: forbidden
+	auth.POST("/idp/update", UpdateIdentityProvider)
+	// swagger:route POST /auth/idp/delete IdentityProvider DeleteIdentityProvider
+	// Delete Identity Provider.
+	// Delete an identity provider which has no users.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: success
+	//   400: badRequest
+	//   403: forbidden
+	auth.POST("/idp/delete", DeleteIdentityProvider)
+	// swagger:route POST /auth/idp/show IdentityProvider ShowIdentityProvider
+	// Show Identity Providers.
+	// Show identity providers and their group mappings.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: success
+	//   400: badRequest
+	//   403: forbidden
+	auth.POST("/idp/show", ShowIdentityProvider)
 	// swagger:route POST /auth/org/create Organization CreateOrg
 	// Create Organization.
 	// Create an Organization to access operator/cloudlet APIs.
@@ -558,6 +736,83 @@ func RunServer(config *ServerConfig) (retserver *Server, reterr error) {
 	//   403: forbidden
 	//   404: notFound
 	auth.POST("/org/delete", DeleteOrg)
+	// swagger:route POST /auth/org/quota/create OrgQuota CreateOrgQuota
+	// Create Organization Quota.
+	// Create a resource quota for a developer Organization, either for a
+	// region or for the total across all regions. Only admins can manage quotas.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: success
+	//   400: badRequest
+	//   403: forbidden
+	auth.POST("/org/quota/create", CreateOrgQuota)
+	// swagger:route POST /auth/org/quota/update OrgQuota UpdateOrgQuota
+	// Update Organization Quota.
+	// Update the limits of an Organization quota, 0 removes the limit.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: success
+	//   400: badRequest
+	//   403: forbidden
+	auth.POST("/org/quota/update", UpdateOrgQuota)
+	// swagger:route POST /auth/org/quota/delete OrgQuota DeleteOrgQuota
+	// Delete Organization Quota.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: success
+	//   400: badRequest
+	//   403: forbidden
+	auth.POST("/org/quota/delete", DeleteOrgQuota)
+	// swagger:route POST /auth/org/quota/show OrgQuota ShowOrgQuota
+	// Show Organization Quotas.
+	// Show Organization quotas and current resource usage, in total
+	// across all regions and per region.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: success
+	//   400: badRequest
+	//   403: forbidden
+	auth.POST("/org/quota/show", ShowOrgQuota)
+	// swagger:route POST /auth/data/diff Data DiffData
+	// Diff Data.
+	// Compare an AllData manifest against the current Flavors,
+	// ClusterInsts, Apps and AppInsts in each region, and show the
+	// creates, updates and deletes needed to converge to the manifest.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: listDataChanges
+	//   400: badRequest
+	//   403: forbidden
+	auth.POST("/data/diff", DiffData)
+	// swagger:route POST /auth/data/apply Data ApplyData
+	// Apply Data.
+	// Create and update the Flavors, ClusterInsts, Apps and AppInsts in
+	// an AllData manifest, in dependency order, and show the result of
+	// each change. Applying the same manifest again changes nothing.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: listDataChanges
+	//   400: badRequest
+	//   403: forbidden
+	auth.POST("/data/apply", ApplyData)
+	// swagger:route POST /auth/data/prune Data PruneData
+	// Prune Data.
+	// Delete the ClusterInsts, Apps and AppInsts of the Organizations in
+	// an AllData manifest that are not in the manifest, and the Flavors
+	// not in the manifest if the manifest specifies Flavors for the region.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: listDataChanges
+	//   400: badRequest
+	//   403: forbidden
+	auth.POST("/data/prune", PruneData)
 
 	auth.POST("/billingorg/create", CreateBillingOrg)
 	// swagger:route POST /auth/billingorg/update BillingOrganization UpdateBillingOrg
@@ -633,6 +888,8 @@ func RunServer(config *ServerConfig) (retserver *Server, reterr error) {
 	auth.POST("/config/version", ShowVersion)
 	auth.POST("/restricted/user/update", RestrictedUserUpdate)
 	auth.POST("/restricted/org/update", RestrictedUpdateOrg)
+	auth.POST("/restricted/org/suspend", SuspendOrg)
+	auth.POST("/restricted/org/resume", ResumeOrg)
 	auth.POST("/cloudletpoolaccessinvitation/create", CreateCloudletPoolAccessInvitation)
 	auth.POST("/cloudletpoolaccessinvitation/delete", DeleteCloudletPoolAccessInvitation)
 	auth.POST("/cloudletpoolaccessinvitation/show", ShowCloudletPoolAccessInvitation)
@@ -782,6 +1039,111 @@ func RunServer(config *ServerConfig) (retserver *Server, reterr error) {
 	//   403: forbidden
 	//   404: notFound
 	auth.POST("/events/terms", EventTerms)
+	// swagger:route POST /auth/eventwebhook/create EventWebhook CreateEventWebhook
+	// Create Event Webhook
+	// Subscribe a webhook to events of an organization. Matching
+	// events are posted to the webhook URL, signed with the webhook secret.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: success
+	//   400: badRequest
+	//   403: forbidden
+	//   404: notFound
+	auth.POST("/eventwebhook/create", CreateEventWebhook)
+	// swagger:route POST /auth/eventwebhook/update EventWebhook UpdateEventWebhook
+	// Update Event Webhook
+	// Update event webhook URL, secret, or event filters.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: success
+	//   400: badRequest
+	//   403: forbidden
+	//   404: notFound
+	auth.POST("/eventwebhook/update", UpdateEventWebhook)
+	// swagger:route POST /auth/eventwebhook/delete EventWebhook DeleteEventWebhook
+	// Delete Event Webhook
+	// Delete event webhook and its delivery history.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: success
+	//   400: badRequest
+	//   403: forbidden
+	//   404: notFound
+	auth.POST("/eventwebhook/delete", DeleteEventWebhook)
+	// swagger:route POST /auth/eventwebhook/show EventWebhook ShowEventWebhook
+	// Show Event Webhooks
+	// Show event webhooks.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: listEventWebhooks
+	//   400: badRequest
+	//   403: forbidden
+	//   404: notFound
+	auth.POST("/eventwebhook/show", ShowEventWebhook)
+	// swagger:route POST /auth/eventwebhook/delivery/show EventWebhook ShowEventWebhookDelivery
+	// Show Event Webhook Deliveries
+	// Show delivery history of event webhooks. Deliveries in the Failed
+	// state have exhausted all retries.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: listEventWebhookDeliveries
+	//   400: badRequest
+	//   403: forbidden
+	//   404: notFound
+	auth.POST("/eventwebhook/delivery/show", ShowEventWebhookDelivery)
+	// swagger:route POST /auth/eventwebhook/delivery/retry EventWebhook RetryEventWebhookDelivery
+	// Retry Event Webhook Deliveries
+	// Retry failed deliveries of event webhooks.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: success
+	//   400: badRequest
+	//   403: forbidden
+	//   404: notFound
+	auth.POST("/eventwebhook/delivery/retry", RetryEventWebhookDelivery)
+	// swagger:route POST /auth/graphql GraphQL GraphQL
+	// Run GraphQL Query
+	// Run a read-only GraphQL query over organizations and regional
+	// cloudlet pools, cloudlets, cluster instances, application
+	// instances and their metrics, across regions. Objects are
+	// filtered by the same permissions as the show APIs.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: graphQLResponse
+	//   400: badRequest
+	//   403: forbidden
+	//   404: notFound
+	auth.POST("/graphql", GraphQL)
+	// swagger:route POST /auth/audit/show Audit ShowAudit
+	// Show audit logs
+	// Display audit logs based on the query filter, limited
+	// to the audit log retention period.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: listAudits
+	//   400: badRequest
+	//   403: forbidden
+	//   404: notFound
+	auth.POST("/audit/show", ShowAudit)
+	// swagger:route POST /auth/audit/export Audit ExportAudit
+	// Export audit logs
+	// Export audit logs based on the query filter as a JSON or CSV file.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: success
+	//   400: badRequest
+	//   403: forbidden
+	//   404: notFound
+	auth.POST("/audit/export", ExportAudit)
 
 	auth.POST("/spans/terms", SpanTerms)
 	auth.POST("/spans/show", ShowSpans)
@@ -855,6 +1217,74 @@ func RunServer(config *ServerConfig) (retserver *Server, reterr error) {
 	//   403: forbidden
 	//   404: notFound
 	auth.POST("/alertreceiver/show", ShowAlertReceiver)
+	// swagger:route POST /auth/alertsilence/create AlertSilence CreateAlertSilence
+	// Create Alert Silence
+	// Create alert silence.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: success
+	//   400: badRequest
+	//   403: forbidden
+	//   404: notFound
+	auth.POST("/alertsilence/create", CreateAlertSilence)
+	// swagger:route POST /auth/alertsilence/delete AlertSilence DeleteAlertSilence
+	// Delete Alert Silence
+	// Delete alert silence.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: success
+	//   400: badRequest
+	//   403: forbidden
+	//   404: notFound
+	auth.POST("/alertsilence/delete", DeleteAlertSilence)
+	// swagger:route POST /auth/alertsilence/show AlertSilence ShowAlertSilence
+	// Show Alert Silence
+	// Show alert silence.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: success
+	//   400: badRequest
+	//   403: forbidden
+	//   404: notFound
+	auth.POST("/alertsilence/show", ShowAlertSilence)
+	// swagger:route POST /auth/maintenancewindow/create MaintenanceWindow CreateMaintenanceWindow
+	// Create Maintenance Window
+	// Schedule a maintenance window for a cloudlet or cloudlet pool.
+	// Alerts are silenced and auto-provisioning actions are suspended
+	// on the affected cloudlets while the window is open.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: success
+	//   400: badRequest
+	//   403: forbidden
+	//   404: notFound
+	auth.POST("/maintenancewindow/create", CreateMaintenanceWindow)
+	// swagger:route POST /auth/maintenancewindow/delete MaintenanceWindow DeleteMaintenanceWindow
+	// Delete Maintenance Window
+	// Delete maintenance window, closing it if it is open.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: success
+	//   400: badRequest
+	//   403: forbidden
+	//   404: notFound
+	auth.POST("/maintenancewindow/delete", DeleteMaintenanceWindow)
+	// swagger:route POST /auth/maintenancewindow/show MaintenanceWindow ShowMaintenanceWindow
+	// Show Maintenance Windows
+	// Show maintenance windows.
+	// Security:
+	//   Bearer:
+	// responses:
+	//   200: success
+	//   400: badRequest
+	//   403: forbidden
+	//   404: notFound
+	auth.POST("/maintenancewindow/show", ShowMaintenanceWindow)
 
 	auth.POST("/reporter/create", CreateReporter)
 	auth.POST("/reporter/update", UpdateReporter)
@@ -900,7 +1330,7 @@ func RunServer(config *ServerConfig) (retserver *Server, reterr error) {
 	// Use GET method for websockets as thats the method used
 	// in setting up TCP connection by most of the clients
 	// Also, authorization is handled as part of websocketUpgrade
-	ws := e.Group("ws/"+root+"/auth", server.websocketUpgrade)
+	ws := e.Group("ws/"+root+"/auth", server.websocketUpgrade, ShowStreamPaging)
 	addControllerApis("GET", ws)
 	// Metrics api route use ws to serve a query to influxDB
 	ws.GET("/metrics/app", GetMetricsCommon)
@@ -1003,6 +1433,21 @@ func RunServer(config *ServerConfig) (retserver *Server, reterr error) {
 		}()
 	}
 
+	if config.MetricsAddr != "" {
+		// Prometheus metrics about the MC itself
+		server.metricsServer = &http.Server{
+			Addr:    config.MetricsAddr,
+			Handler: getMetricsHandler(),
+		}
+		go func() {
+			err := server.metricsServer.ListenAndServe()
+			if err != nil && err != http.ErrServerClosed {
+				server.Stop()
+				log.FatalLog("Failed to serve metrics", "err", err)
+			}
+		}()
+	}
+
 	// gitlab/artifactory sync and alertmanager requires data to be initialized
 	err = <-server.initDataDone
 	if err != nil {
@@ -1070,6 +1515,9 @@ func (s *Server) Stop() {
 	if s.federationEcho != nil {
 		s.federationEcho.Close()
 	}
+	if s.metricsServer != nil {
+		s.metricsServer.Close()
+	}
 	if connCache != nil {
 		connCache.Finish()
 	}
@@ -1222,6 +1670,9 @@ func ReadConn(c echo.Context, in interface{}) ([]byte, error) {
 	if err == nil {
 		err = BindJson(dat, in)
 	}
+	if err == nil {
+		err = bindShowStreamPage(c, dat)
+	}
 
 	if err != nil {
 		if err == io.EOF || err == io.ErrUnexpectedEOF {
@@ -1330,10 +1781,17 @@ func writeWS(c echo.Context, ws *websocket.Conn, wsPayload *ormapi.WSStreamPaylo
 }
 
 func WriteStream(c echo.Context, payload *ormapi.StreamPayload) error {
+	if pager := ormutil.GetShowPager(c); pager != nil && payload.Data != nil {
+		// paged show, the page is written once the stream is done.
+		// The stream is not ordered, so it is read to the end for
+		// every page.
+		return pager.Add(payload.Data)
+	}
 	if ws := ormutil.GetWs(c); ws != nil {
 		wsPayload := ormapi.WSStreamPayload{
-			Code: http.StatusOK,
-			Data: (*payload).Data,
+			Code:              http.StatusOK,
+			Data:              (*payload).Data,
+			ContinuationToken: (*payload).ContinuationToken,
 		}
 		return writeWS(c, ws, &wsPayload)
 	} else {
@@ -42,6 +42,7 @@ func TestServer(t *testing.T) {
 	log.InitTracer(nil)
 	defer log.FinishTracer()
 	addr := "127.0.0.1:9999"
+	metricsAddr := "127.0.0.1:9996"
 	uri := "http://" + addr + "/api/v1"
 	ctx := log.StartTestSpan(context.Background())
 
@@ -57,6 +58,7 @@ func TestServer(t *testing.T) {
 
 	config := ServerConfig{
 		ServAddr:                 addr,
+		MetricsAddr:              metricsAddr,
 		SqlAddr:                  "127.0.0.1:5445",
 		RunLocal:                 true,
 		InitLocal:                true,
@@ -843,7 +845,14 @@ func testServerClientRun(t *testing.T, ctx context.Context, clientRun mctestclie
 	testLockedUsers(t, uri, mcClient)
 	testPasswordStrength(t, ctx, mcClient, uri, token)
 	testEdgeboxOnlyOrgs(t, uri, mcClient)
+	testNotificationPrefs(t, ctx, mcClient, uri)
+	testEventWebhooks(t, ctx, mcClient, uri)
+	testOrgInvitations(t, ctx, mcClient, uri)
+	testCustomRoles(t, ctx, mcClient, uri, token)
+	testRoleExpiry(t, ctx, mcClient, uri)
+	testSSO(t, ctx, mcClient, uri, token)
 	testConfigUpgrade(t, ctx)
+	testMcMetrics(t, metricsAddr)
 }
 
 func waitServerOnline(addr string) error {
@@ -15,11 +15,18 @@
 package orm
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
+	"path"
+	"reflect"
 	"strings"
 
 	"github.com/jinzhu/gorm"
 	"github.com/labstack/echo"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ctrlclient"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormutil"
 )
 
 var NoShowFilter map[string]interface{} = nil
@@ -76,6 +83,9 @@ func bindDbFilter(c echo.Context, refObj interface{}) (map[string]interface{}, e
 	if err != nil {
 		return nil, err
 	}
+	if err := bindShowPage(c, filter, dbObjKey); err != nil {
+		return nil, err
+	}
 	dbFilter, err := jsonToDbNames(filter, refObj)
 	if err != nil {
 		err = fmt.Errorf("Failed to parse input data: %s", err.Error())
@@ -84,6 +94,144 @@ func bindDbFilter(c echo.Context, refObj interface{}) (map[string]interface{}, e
 	return dbFilter, nil
 }
 
+// Remove the paging keys from the filter, and if present, set up
+// paging of the show results.
+func bindShowPage(c echo.Context, filter map[string]interface{}, keyFunc ormutil.ShowPageKeyFunc) error {
+	page := ormapi.ShowPage{}
+	found := false
+	for k, v := range filter {
+		switch strings.ToLower(k) {
+		case ormapi.ShowPageLimitKey:
+			num, ok := v.(float64)
+			if !ok || num != math.Trunc(num) {
+				return fmt.Errorf("Invalid %s %v, must be an integer", k, v)
+			}
+			page.Limit = int(num)
+		case ormapi.ShowPageTokenKey:
+			str, ok := v.(string)
+			if !ok {
+				return fmt.Errorf("Invalid %s %v, must be a string", k, v)
+			}
+			page.ContinuationToken = str
+		default:
+			continue
+		}
+		delete(filter, k)
+		found = true
+	}
+	if !found {
+		return nil
+	}
+	pager, err := ormutil.NewShowPager(&page, keyFunc)
+	if err != nil {
+		return err
+	}
+	ormutil.SetShowPager(c, pager)
+	return nil
+}
+
+// Key used to order database objects for paging, based on the
+// primary key. Objects without a primary key are ordered by
+// their data.
+func dbObjKey(obj interface{}) (string, error) {
+	scope := &gorm.Scope{}
+	scope = scope.New(obj)
+	vals := []interface{}{}
+	for _, field := range scope.PrimaryFields() {
+		vals = append(vals, field.Field.Interface())
+	}
+	var dat []byte
+	var err error
+	if len(vals) > 0 {
+		dat, err = json.Marshal(vals)
+	} else {
+		dat, err = json.Marshal(obj)
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(dat), nil
+}
+
+// Find the objects for a show command. For paged shows, the
+// continuation token and limit are applied in the query, ordered by
+// primary key, so only the page is read from the database. Objects
+// can still be dropped after the query, i.e. for authorization.
+// String keys are compared bytewise so the order does not depend on
+// the database locale (citext keys compare in lower case).
+func findShowPage(db *gorm.DB, pager *ormutil.ShowPager, out interface{}) *gorm.DB {
+	if pager == nil {
+		return db.Find(out)
+	}
+	scope := db.NewScope(out)
+	cols := []string{}
+	for _, field := range scope.PrimaryFields() {
+		col := scope.Quote(field.DBName)
+		if field.Struct.Type.Kind() == reflect.String {
+			col += ` COLLATE "C"`
+		}
+		cols = append(cols, col)
+	}
+	if len(cols) == 0 {
+		// no key to page by, page after reading all objects
+		return db.Find(out)
+	}
+	keyCols := strings.Join(cols, ", ")
+	if after := pager.After(); after != "" {
+		vals, err := getShowPageKeyVals(after, len(cols))
+		if err != nil {
+			db.AddError(err)
+			return db
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(cols)), ", ")
+		db = db.Where(fmt.Sprintf("(%s) > (%s)", keyCols, placeholders), vals...)
+	}
+	db = db.Order(keyCols, true)
+	limit := pager.Limit()
+	if limit > 0 {
+		// read one more to know if there is another page
+		db = db.Limit(limit + 1)
+	}
+	res := db.Find(out)
+	if res.Error != nil {
+		return res
+	}
+	list := reflect.ValueOf(out).Elem()
+	more := false
+	if limit > 0 && list.Len() > limit {
+		more = true
+		list.Set(list.Slice(0, limit))
+	}
+	lastKey := ""
+	if list.Len() > 0 {
+		key, err := dbObjKey(list.Index(list.Len() - 1).Interface())
+		if err != nil {
+			res.AddError(err)
+			return res
+		}
+		lastKey = key
+	}
+	pager.SetSourcePage(more, lastKey)
+	return res
+}
+
+// Get the primary key values from the key in a continuation token,
+// which is the json array of values from dbObjKey.
+func getShowPageKeyVals(key string, numCols int) ([]interface{}, error) {
+	vals := []interface{}{}
+	dec := json.NewDecoder(strings.NewReader(key))
+	dec.UseNumber()
+	if err := dec.Decode(&vals); err != nil || len(vals) != numCols {
+		return nil, fmt.Errorf("Invalid continuation token")
+	}
+	for ii, val := range vals {
+		if num, ok := val.(json.Number); ok {
+			vals[ii] = num.String()
+		}
+	}
+	return vals, nil
+}
+
 func bindMap(c echo.Context) (map[string]interface{}, error) {
 	m := make(map[string]interface{})
 	if c.Request().ContentLength > 0 {
@@ -105,3 +253,59 @@ func getFilterString(filter map[string]interface{}, key string) (string, bool) {
 	}
 	return str, true
 }
+
+// Set up paging of the results of a streamed show API, if the
+// request data has paging keys. Data is the raw request data.
+func bindShowStreamPage(c echo.Context, dat []byte) error {
+	name := path.Base(c.Path())
+	if !strings.HasPrefix(name, "Show") {
+		return nil
+	}
+	if _, found := ormapi.UnpagedShowStreams[name]; found {
+		return nil
+	}
+	page := ormapi.ShowPage{}
+	if err := BindJson(dat, &page); err != nil {
+		return err
+	}
+	if page.Limit == 0 && page.ContinuationToken == "" {
+		return nil
+	}
+	pager, err := ctrlclient.NewShowStreamPager(&page)
+	if err != nil {
+		return err
+	}
+	ormutil.SetShowPager(c, pager)
+	return nil
+}
+
+// Paged show streams gather the page in WriteStream, which
+// is written back once the stream is done.
+func ShowStreamPaging(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		err := next(c)
+		if err != nil || c.Get(StreamAPITag) == nil {
+			return err
+		}
+		pager := ormutil.GetShowPager(c)
+		if pager == nil {
+			return nil
+		}
+		ormutil.SetShowPager(c, nil)
+		for _, obj := range pager.Objs() {
+			payload := ormapi.StreamPayload{
+				Data: obj,
+			}
+			if err := WriteStream(c, &payload); err != nil {
+				return err
+			}
+		}
+		if token := pager.ContinuationToken(); token != "" {
+			payload := ormapi.StreamPayload{
+				ContinuationToken: token,
+			}
+			return WriteStream(c, &payload)
+		}
+		return nil
+	}
+}
@@ -15,10 +15,20 @@
 package orm
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/jinzhu/gorm"
+	"github.com/mobiledgex/edge-cloud-infra/mc/mcctl/mctestclient"
 	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormclient"
+	"github.com/mobiledgex/edge-cloud/edgeproto"
+	"github.com/mobiledgex/edge-cloud/testutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -89,3 +99,139 @@ func TestShowUserNoFilterKeys(t *testing.T) {
 		require.True(t, ok, "check that %s is valid", name)
 	}
 }
+
+func TestDbObjKey(t *testing.T) {
+	// primary key
+	key, err := dbObjKey(ormapi.Organization{Name: "org1", Type: "developer"})
+	require.Nil(t, err)
+	require.Equal(t, `["org1"]`, key)
+	key, err = dbObjKey(&ormapi.Organization{Name: "org1"})
+	require.Nil(t, err)
+	require.Equal(t, `["org1"]`, key)
+
+	// no primary key
+	key, err = dbObjKey(ormapi.OrgCloudletPool{Org: "org1", Region: "local"})
+	require.Nil(t, err)
+	require.Contains(t, key, "org1")
+}
+
+func TestShowPageKeyVals(t *testing.T) {
+	vals, err := getShowPageKeyVals(`["org1"]`, 1)
+	require.Nil(t, err)
+	require.Equal(t, []interface{}{"org1"}, vals)
+	// numbers are passed as strings so they keep their precision
+	vals, err = getShowPageKeyVals(`["local",12345678901]`, 2)
+	require.Nil(t, err)
+	require.Equal(t, []interface{}{"local", "12345678901"}, vals)
+
+	for _, key := range []string{`["org1"]`, `"org1"`, `{}`, `[`} {
+		_, err = getShowPageKeyVals(key, 2)
+		require.NotNil(t, err, key)
+		require.Contains(t, err.Error(), "Invalid continuation token")
+	}
+}
+
+// Paging is only done via the REST client, since the cli only
+// passes the page size as a global flag.
+func testShowPaging(t *testing.T, ctx context.Context, ds *testutil.DummyServer, region string, restClient *ormclient.Client, mcClient *mctestclient.Client, uri, token string) {
+	flavors := []edgeproto.Flavor{}
+	for ii := 4; ii >= 0; ii-- {
+		flavor := edgeproto.Flavor{
+			Key:   edgeproto.FlavorKey{Name: fmt.Sprintf("page-flavor%d", ii)},
+			Vcpus: 1,
+			Ram:   1024,
+			Disk:  10,
+		}
+		ds.FlavorCache.Update(ctx, &flavor, 0)
+		flavors = append(flavors, flavor)
+	}
+	allFlavors, status, err := mcClient.ShowFlavor(uri, token, &ormapi.RegionFlavor{Region: region})
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	sort.Slice(allFlavors, func(i, j int) bool {
+		return allFlavors[i].Key.GetKeyString() < allFlavors[j].Key.GetKeyString()
+	})
+
+	// single page of a stream, ends with the continuation token
+	in := map[string]interface{}{
+		"region":                region,
+		ormapi.ShowPageLimitKey: 2,
+	}
+	resp, err := restClient.PostJsonSend(uri+"/auth/ctrl/ShowFlavor", token, in)
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	pageFlavors := []edgeproto.Flavor{}
+	pageToken := ""
+	dec := json.NewDecoder(resp.Body)
+	for dec.More() {
+		flavor := edgeproto.Flavor{}
+		payload := ormapi.StreamPayload{
+			Data: &flavor,
+		}
+		require.Nil(t, dec.Decode(&payload))
+		require.Nil(t, payload.Result)
+		if payload.ContinuationToken != "" {
+			pageToken = payload.ContinuationToken
+			continue
+		}
+		require.Equal(t, "", pageToken, "token must be last")
+		pageFlavors = append(pageFlavors, flavor)
+	}
+	require.Equal(t, allFlavors[:2], pageFlavors)
+	require.NotEqual(t, "", pageToken)
+
+	// client follows the continuation tokens to get all pages
+	pagedFlavors := []edgeproto.Flavor{}
+	flavor := edgeproto.Flavor{}
+	status, err = restClient.PostJsonStreamOut(uri+"/auth/ctrl/ShowFlavor", token, in, &flavor, func() {
+		pagedFlavors = append(pagedFlavors, flavor)
+	})
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, allFlavors, pagedFlavors)
+
+	// single page of a database show, token is in the header
+	allOrgs, status, err := mcClient.ShowOrg(uri, token, ClientNoShowFilter)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.True(t, len(allOrgs) > 1)
+	// org names are case-insensitive, and paged in lower case order
+	sort.Slice(allOrgs, func(i, j int) bool {
+		return strings.ToLower(allOrgs[i].Name) < strings.ToLower(allOrgs[j].Name)
+	})
+	in = map[string]interface{}{
+		ormapi.ShowPageLimitKey: 1,
+	}
+	orgResp, err := restClient.PostJsonSend(uri+"/auth/org/show", token, in)
+	require.Nil(t, err)
+	defer orgResp.Body.Close()
+	require.Equal(t, http.StatusOK, orgResp.StatusCode)
+	pageOrgs := []ormapi.Organization{}
+	require.Nil(t, json.NewDecoder(orgResp.Body).Decode(&pageOrgs))
+	require.Equal(t, allOrgs[:1], pageOrgs)
+	require.NotEqual(t, "", orgResp.Header.Get(ormapi.ContinuationTokenHeader))
+
+	// client follows the continuation tokens to get all pages
+	pagedOrgs := []ormapi.Organization{}
+	status, err = restClient.PostJson(uri+"/auth/org/show", token, in, &pagedOrgs)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, allOrgs, pagedOrgs)
+
+	// invalid paging args
+	in[ormapi.ShowPageLimitKey] = -1
+	status, err = restClient.PostJson(uri+"/auth/org/show", token, in, &pagedOrgs)
+	require.NotNil(t, err)
+	require.Equal(t, http.StatusBadRequest, status)
+	in[ormapi.ShowPageLimitKey] = 1
+	in[ormapi.ShowPageTokenKey] = "invalid"
+	status, err = restClient.PostJson(uri+"/auth/org/show", token, in, &pagedOrgs)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Invalid continuation token")
+	require.Equal(t, http.StatusBadRequest, status)
+
+	for _, flavor := range flavors {
+		ds.FlavorCache.Delete(ctx, &flavor, 0)
+	}
+}
@@ -0,0 +1,943 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
+	"github.com/labstack/echo"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormutil"
+	"github.com/mobiledgex/edge-cloud-infra/mc/rbac"
+	"github.com/mobiledgex/edge-cloud/log"
+	"github.com/mobiledgex/edge-cloud/util"
+	"github.com/mobiledgex/edge-cloud/vault"
+)
+
+// Single sign-on logins use the OpenID Connect authorization code flow.
+// The user is redirected to the identity provider, which redirects back
+// to the SSO callback with an authorization code. The code is exchanged
+// for an ID token, which identifies the user and their groups.
+
+const (
+	DefaultIdpUsernameClaim = "preferred_username"
+	DefaultIdpGroupsClaim   = "groups"
+)
+
+var SSOStateValidDuration = 10 * time.Minute
+
+// Cookie binding the SSO login to the browser that started it, so
+// that a login started by someone else cannot be completed in the
+// user's browser.
+const SSONonceCookie = "ssononce"
+
+func newSSONonceCookie(nonce string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:   SSONonceCookie,
+		Value:  nonce,
+		Path:   "/api/v1/sso",
+		MaxAge: maxAge,
+		// only send this cookie over HTTPS
+		Secure:   true,
+		HttpOnly: true,
+		// the callback is a cross-site redirect from the identity
+		// provider, which does not carry strict cookies
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+var ssoHttpClient = &http.Client{
+	Timeout: 15 * time.Second,
+}
+
+// State passed through the identity provider to the SSO callback
+type ssoStateClaims struct {
+	jwt.StandardClaims
+	Idp   string `json:"idp"`
+	Nonce string `json:"nonce"`
+	Kid   int    `json:"kid"`
+}
+
+func (s *ssoStateClaims) GetKid() (int, error) {
+	return s.Kid, nil
+}
+
+func (s *ssoStateClaims) SetKid(kid int) {
+	s.Kid = kid
+}
+
+type oidcProviderConfig struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksUri               string `json:"jwks_uri"`
+}
+
+type oidcTokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	IdToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+type oidcJwks struct {
+	Keys []oidcJwk `json:"keys"`
+}
+
+type oidcJwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// Identity provider client secrets are stored in Vault
+type idpCreds struct {
+	ClientSecret string `json:"clientsecret"`
+}
+
+func getIdpVaultPath(name string) string {
+	return "/secret/data/accounts/idps/" + name
+}
+
+func putIdpClientSecretToVault(ctx context.Context, idp *ormapi.IdentityProvider) error {
+	creds := idpCreds{
+		ClientSecret: idp.ClientSecret,
+	}
+	log.SpanLog(ctx, log.DebugLevelApi, "Storing identity provider client secret in vault", "idp", idp.Name)
+	return vault.PutData(serverConfig.vaultConfig, getIdpVaultPath(idp.Name), &creds)
+}
+
+func getIdpClientSecretFromVault(idp *ormapi.IdentityProvider) (string, error) {
+	creds := idpCreds{}
+	err := vault.GetData(serverConfig.vaultConfig, getIdpVaultPath(idp.Name), 0, &creds)
+	if err != nil {
+		if strings.Contains(err.Error(), "no secrets") {
+			// public client without a secret
+			return "", nil
+		}
+		return "", err
+	}
+	return creds.ClientSecret, nil
+}
+
+func deleteIdpClientSecretFromVault(ctx context.Context, idp *ormapi.IdentityProvider) {
+	if err := vault.DeleteData(serverConfig.vaultConfig, getIdpVaultPath(idp.Name)); err != nil {
+		log.SpanLog(ctx, log.DebugLevelInfo, "Failed to delete identity provider client secret from vault", "idp", idp.Name, "err", err)
+	}
+}
+
+func getSSORedirectURI() string {
+	return strings.TrimSuffix(serverConfig.PublicAddr, "/") + "/api/v1/sso/callback"
+}
+
+func getIdentityProvider(ctx context.Context, name string) (*ormapi.IdentityProvider, error) {
+	idp := ormapi.IdentityProvider{}
+	db := loggedDB(ctx)
+	res := db.Where(&ormapi.IdentityProvider{Name: name}).First(&idp)
+	if res.RecordNotFound() {
+		return nil, fmt.Errorf("Identity provider %s not found", name)
+	}
+	if res.Error != nil {
+		return nil, ormutil.DbErr(res.Error)
+	}
+	return &idp, nil
+}
+
+func getIdpGroupMappings(ctx context.Context, idpName string) ([]ormapi.IdpGroupMapping, error) {
+	mappings := []ormapi.IdpGroupMapping{}
+	db := loggedDB(ctx)
+	err := db.Where(&ormapi.IdpGroupMapping{Idp: idpName}).Order("group_name").Find(&mappings).Error
+	if err != nil {
+		return nil, ormutil.DbErr(err)
+	}
+	return mappings, nil
+}
+
+func oidcGetJson(ctx context.Context, reqUrl string, out interface{}) error {
+	log.SpanLog(ctx, log.DebugLevelApi, "oidc get", "url", reqUrl)
+	resp, err := ssoHttpClient.Get(reqUrl)
+	if err != nil {
+		return fmt.Errorf("Request to identity provider failed, %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Request to identity provider %s failed, status %s", reqUrl, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("Failed to decode identity provider response from %s, %v", reqUrl, err)
+	}
+	return nil
+}
+
+// Discover the provider endpoints from the issuer
+func getOIDCProviderConfig(ctx context.Context, idp *ormapi.IdentityProvider) (*oidcProviderConfig, error) {
+	issuer := strings.TrimSuffix(idp.Issuer, "/")
+	provider := oidcProviderConfig{}
+	err := oidcGetJson(ctx, issuer+"/.well-known/openid-configuration", &provider)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(provider.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("Identity provider issuer %s does not match configured issuer %s", provider.Issuer, idp.Issuer)
+	}
+	if provider.AuthorizationEndpoint == "" || provider.TokenEndpoint == "" || provider.JwksUri == "" {
+		return nil, fmt.Errorf("Identity provider configuration is missing endpoints")
+	}
+	return &provider, nil
+}
+
+func getOIDCKeys(ctx context.Context, provider *oidcProviderConfig) (map[string]*rsa.PublicKey, error) {
+	jwks := oidcJwks{}
+	err := oidcGetJson(ctx, provider.JwksUri, &jwks)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]*rsa.PublicKey)
+	for _, jwk := range jwks.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(jwk.N, "="))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid modulus for identity provider key %s, %v", jwk.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(jwk.E, "="))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid exponent for identity provider key %s, %v", jwk.Kid, err)
+		}
+		keys[jwk.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return keys, nil
+}
+
+// Exchange the authorization code for the ID token
+func oidcExchangeCode(ctx context.Context, idp *ormapi.IdentityProvider, provider *oidcProviderConfig, code string) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", getSSORedirectURI())
+	form.Set("client_id", idp.ClientId)
+	clientSecret, err := getIdpClientSecretFromVault(idp)
+	if err != nil {
+		log.SpanLog(ctx, log.DebugLevelApi, "failed to get identity provider client secret", "idp", idp.Name, "err", err)
+		return "", fmt.Errorf("Failed to get identity provider client secret")
+	}
+	req, err := http.NewRequest(http.MethodPost, provider.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(idp.ClientId), url.QueryEscape(clientSecret))
+
+	log.SpanLog(ctx, log.DebugLevelApi, "oidc token request", "url", provider.TokenEndpoint)
+	resp, err := ssoHttpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("Token request to identity provider failed, %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("Failed to read identity provider token response, %v", err)
+	}
+	tokenResp := oidcTokenResponse{}
+	err = json.Unmarshal(body, &tokenResp)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Token request to identity provider failed, status %s %s %s", resp.Status, tokenResp.Error, tokenResp.ErrorDescription)
+	}
+	if err != nil {
+		return "", fmt.Errorf("Failed to decode identity provider token response, %v", err)
+	}
+	if tokenResp.IdToken == "" {
+		return "", fmt.Errorf("Identity provider token response is missing the ID token")
+	}
+	return tokenResp.IdToken, nil
+}
+
+func verifyOIDCIdToken(ctx context.Context, idp *ormapi.IdentityProvider, provider *oidcProviderConfig, rawToken, nonce string) (jwt.MapClaims, error) {
+	keys, err := getOIDCKeys(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(rawToken, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		if key, found := keys[kid]; found {
+			return key, nil
+		}
+		if kid == "" && len(keys) == 1 {
+			for _, key := range keys {
+				return key, nil
+			}
+		}
+		return nil, fmt.Errorf("signing key %q not found", kid)
+	})
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("Invalid ID token, %v", err)
+	}
+	if _, found := claims["exp"]; !found {
+		return nil, fmt.Errorf("Invalid ID token, missing expiration")
+	}
+	if !claims.VerifyIssuer(provider.Issuer, true) {
+		return nil, fmt.Errorf("Invalid ID token issuer")
+	}
+	audOk := false
+	for _, aud := range getClaimStrings(claims, "aud") {
+		if aud == idp.ClientId {
+			audOk = true
+			break
+		}
+	}
+	if !audOk {
+		return nil, fmt.Errorf("Invalid ID token audience")
+	}
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce != nonce {
+		return nil, fmt.Errorf("Invalid ID token nonce")
+	}
+	return claims, nil
+}
+
+// Claims may be a single string or a list of strings
+func getClaimStrings(claims jwt.MapClaims, name string) []string {
+	vals := []string{}
+	switch v := claims[name].(type) {
+	case string:
+		vals = append(vals, v)
+	case []interface{}:
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				vals = append(vals, str)
+			}
+		}
+	}
+	return vals
+}
+
+// Look up the user for the ID token, creating the user if allowed
+func getSSOUser(ctx context.Context, idp *ormapi.IdentityProvider, claims jwt.MapClaims) (*ormapi.User, error) {
+	usernameClaim := idp.UsernameClaim
+	if usernameClaim == "" {
+		usernameClaim = DefaultIdpUsernameClaim
+	}
+	username, _ := claims[usernameClaim].(string)
+	if username == "" {
+		return nil, fmt.Errorf("ID token is missing the %s claim", usernameClaim)
+	}
+	span := log.SpanFromContext(ctx)
+	span.SetTag("username", username)
+
+	user := ormapi.User{}
+	db := loggedDB(ctx)
+	res := db.Where(&ormapi.User{Name: username}).First(&user)
+	if res.Error == nil {
+		// Only users created by the identity provider may log in
+		// with it, otherwise the identity provider could take over
+		// local accounts.
+		if user.IdentityProvider != idp.Name {
+			return nil, ormutil.NewHTTPError(http.StatusUnauthorized, fmt.Sprintf("User %s cannot log in with identity provider %s", username, idp.Name))
+		}
+		return &user, nil
+	}
+	if !res.RecordNotFound() {
+		return nil, ormutil.DbErr(res.Error)
+	}
+	if !idp.CreateUsers {
+		return nil, ormutil.NewHTTPError(http.StatusUnauthorized, fmt.Sprintf("User %s not found", username))
+	}
+
+	if err := ValidName(username); err != nil {
+		return nil, err
+	}
+	email, _ := claims["email"].(string)
+	if !util.ValidEmail(email) {
+		return nil, fmt.Errorf("ID token is missing a valid email claim")
+	}
+	if verified, ok := claims["email_verified"].(bool); ok && !verified {
+		return nil, fmt.Errorf("Email %s not verified by identity provider", email)
+	}
+	orgT, err := GetAllOrgs(ctx)
+	if err == nil {
+		for orgName, _ := range orgT {
+			if strings.ToLower(username) == strings.ToLower(orgName) {
+				return nil, fmt.Errorf("user name cannot be same as org name")
+			}
+		}
+	}
+	config, err := getConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	user = ormapi.User{
+		Name:             username,
+		Email:            email,
+		EmailVerified:    true,
+		Locked:           config.LockNewAccounts,
+		IdentityProvider: idp.Name,
+	}
+	user.GivenName, _ = claims["given_name"].(string)
+	user.FamilyName, _ = claims["family_name"].(string)
+	// local password login is not allowed, set a random password
+	user.Passhash, user.Salt, user.Iter = ormutil.NewPasshash(uuid.New().String() + uuid.New().String())
+	if err := db.Create(&user).Error; err != nil {
+		if err.Error() == "pq: duplicate key value violates unique constraint \"users_pkey\"" {
+			return nil, fmt.Errorf("Username with name %s (case-insensitive) already exists", user.Name)
+		}
+		if err.Error() == "pq: duplicate key value violates unique constraint \"users_email_key\"" {
+			return nil, fmt.Errorf("Email already in use")
+		}
+		return nil, ormutil.DbErr(err)
+	}
+	err = gitlabCreateLDAPUser(ctx, &user)
+	if err != nil {
+		db.Delete(&user)
+		return nil, err
+	}
+	err = artifactoryCreateLDAPUser(ctx, &user)
+	if err != nil {
+		gitlabDeleteLDAPUser(ctx, user.Name)
+		db.Delete(&user)
+		return nil, err
+	}
+	if user.Locked {
+		msg := fmt.Sprintf("Locked account created for single sign-on user %s, email %s", user.Name, user.Email)
+		// just log in case of error
+		senderr := sendNotify(ctx, config.NotifyEmailAddress,
+			"Locked account created", msg)
+		if senderr != nil {
+			log.SpanLog(ctx, log.DebugLevelApi, "failed to send notify of new locked account", "err", senderr)
+		}
+	}
+	return &user, nil
+}
+
+type orgRole struct {
+	org  string
+	role string
+}
+
+// Add and remove the user's org roles to match the group mappings
+func syncIdpGroupRoles(ctx context.Context, idp *ormapi.IdentityProvider, user *ormapi.User, groups []string) error {
+	mappings, err := getIdpGroupMappings(ctx, idp.Name)
+	if err != nil {
+		return err
+	}
+	if len(mappings) == 0 {
+		return nil
+	}
+	groupSet := make(map[string]struct{})
+	for _, group := range groups {
+		groupSet[group] = struct{}{}
+	}
+	mapped := make(map[orgRole]struct{})
+	desired := make(map[string]string)
+	for _, m := range mappings {
+		mapped[orgRole{m.Org, m.Role}] = struct{}{}
+		if _, found := groupSet[m.GroupName]; !found {
+			continue
+		}
+		// users can only have one role per org
+		if _, found := desired[m.Org]; !found {
+			desired[m.Org] = m.Role
+		}
+	}
+
+	groupings, err := enforcer.GetGroupingPolicy()
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	existing := make(map[string]string)
+	for ii, _ := range groupings {
+		r := parseRole(groupings[ii])
+		if r == nil || r.Username != user.Name || r.Org == "" {
+			continue
+		}
+		existing[r.Org] = r.Role
+	}
+
+	orgs, err := GetAllOrgs(ctx)
+	if err != nil {
+		return err
+	}
+	getOrgType := func(org string) string {
+		if o, found := orgs[org]; found {
+			return o.Type
+		}
+		return ""
+	}
+	for org, role := range existing {
+		if _, found := mapped[orgRole{org, role}]; !found || desired[org] == role {
+			continue
+		}
+		r := ormapi.Role{
+			Org:      org,
+			Username: user.Name,
+			Role:     role,
+		}
+		log.SpanLog(ctx, log.DebugLevelApi, "remove sso mapped role", "role", r)
+		psub := rbac.GetCasbinGroup(org, user.Name)
+		if err := enforcer.RemoveGroupingPolicy(ctx, psub, role); err != nil {
+			return ormutil.DbErr(err)
+		}
+		delete(existing, org)
+		deleteRoleExpiration(ctx, &r)
+		gitlabRemoveGroupMember(ctx, &r, getOrgType(org))
+		artifactoryRemoveUserFromGroup(ctx, &r, getOrgType(org))
+	}
+	for org, role := range desired {
+		if cur, found := existing[org]; found {
+			if cur != role {
+				// keep roles that were not assigned by the mapping
+				log.SpanLog(ctx, log.DebugLevelApi, "skip sso mapped role, user already has a role for org", "user", user.Name, "org", org, "role", role, "existing", cur)
+			}
+			continue
+		}
+		if _, found := orgs[org]; !found {
+			log.SpanLog(ctx, log.DebugLevelApi, "skip sso mapped role, org not found", "org", org, "role", role)
+			continue
+		}
+		r := ormapi.Role{
+			Org:      org,
+			Username: user.Name,
+			Role:     role,
+		}
+		log.SpanLog(ctx, log.DebugLevelApi, "add sso mapped role", "role", r)
+		psub := rbac.GetCasbinGroup(org, user.Name)
+		if err := enforcer.AddGroupingPolicy(ctx, psub, role); err != nil {
+			return ormutil.DbErr(err)
+		}
+		gitlabAddGroupMember(ctx, &r, getOrgType(org))
+		artifactoryAddUserToGroup(ctx, &r, getOrgType(org))
+	}
+	return nil
+}
+
+// Redirect the user to the identity provider to log in
+func SSOLogin(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	name := c.QueryParam("idp")
+	if name == "" {
+		return fmt.Errorf("Identity provider not specified")
+	}
+	idp, err := getIdentityProvider(ctx, name)
+	if err != nil {
+		return err
+	}
+	provider, err := getOIDCProviderConfig(ctx, idp)
+	if err != nil {
+		return err
+	}
+	claims := ssoStateClaims{
+		StandardClaims: jwt.StandardClaims{
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: time.Now().Add(SSOStateValidDuration).Unix(),
+		},
+		Idp:   idp.Name,
+		Nonce: uuid.New().String(),
+	}
+	state, err := Jwks.GenerateCookie(&claims)
+	if err != nil {
+		log.SpanLog(ctx, log.DebugLevelApi, "failed to generate sso state", "err", err)
+		return fmt.Errorf("Failed to generate state")
+	}
+	// save the login so that the state can only be used once
+	db := loggedDB(ctx)
+	err = db.Where("expires_at < ?", time.Now()).Delete(&ormapi.SSOLoginState{}).Error
+	if err != nil {
+		log.SpanLog(ctx, log.DebugLevelApi, "failed to clean up expired sso states", "err", err)
+	}
+	loginState := ormapi.SSOLoginState{
+		Nonce:     claims.Nonce,
+		Idp:       idp.Name,
+		ExpiresAt: time.Unix(claims.ExpiresAt, 0),
+	}
+	if err := db.Create(&loginState).Error; err != nil {
+		return ormutil.DbErr(err)
+	}
+	authUrl, err := url.Parse(provider.AuthorizationEndpoint)
+	if err != nil {
+		return fmt.Errorf("Invalid identity provider authorization endpoint, %v", err)
+	}
+	scopes := []string{"openid", "email", "profile"}
+	scopes = append(scopes, idp.Scopes...)
+	q := authUrl.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", idp.ClientId)
+	q.Set("redirect_uri", getSSORedirectURI())
+	q.Set("scope", strings.Join(scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", claims.Nonce)
+	authUrl.RawQuery = q.Encode()
+	c.SetCookie(newSSONonceCookie(claims.Nonce, int(SSOStateValidDuration.Seconds())))
+	return c.Redirect(http.StatusFound, authUrl.String())
+}
+
+// Complete the single sign-on login after the identity provider redirects back
+func SSOCallback(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	if idpErr := c.QueryParam("error"); idpErr != "" {
+		return ormutil.NewHTTPError(http.StatusUnauthorized, fmt.Sprintf("Single sign-on failed, %s %s", idpErr, c.QueryParam("error_description")))
+	}
+	code := c.QueryParam("code")
+	state := c.QueryParam("state")
+	if code == "" || state == "" {
+		return fmt.Errorf("Missing code or state")
+	}
+	stateClaims := ssoStateClaims{}
+	token, err := Jwks.VerifyCookie(state, &stateClaims)
+	if err != nil || !token.Valid || stateClaims.Idp == "" || stateClaims.Nonce == "" {
+		return &echo.HTTPError{
+			Code:     http.StatusUnauthorized,
+			Message:  "invalid or expired state",
+			Internal: err,
+		}
+	}
+	// state must belong to this browser's login
+	nonceCookie, err := c.Cookie(SSONonceCookie)
+	if err != nil || subtle.ConstantTimeCompare([]byte(nonceCookie.Value), []byte(stateClaims.Nonce)) != 1 {
+		return ormutil.NewHTTPError(http.StatusUnauthorized, "state does not match login session")
+	}
+	c.SetCookie(newSSONonceCookie("", -1))
+	// state can only be used once
+	res := loggedDB(ctx).Where("nonce = ? AND expires_at >= ?", stateClaims.Nonce, time.Now()).Delete(&ormapi.SSOLoginState{})
+	if res.Error != nil {
+		return ormutil.DbErr(res.Error)
+	}
+	if res.RowsAffected != 1 {
+		return ormutil.NewHTTPError(http.StatusUnauthorized, "invalid or expired state")
+	}
+	idp, err := getIdentityProvider(ctx, stateClaims.Idp)
+	if err != nil {
+		return err
+	}
+	provider, err := getOIDCProviderConfig(ctx, idp)
+	if err != nil {
+		return err
+	}
+	rawIdToken, err := oidcExchangeCode(ctx, idp, provider, code)
+	if err != nil {
+		return err
+	}
+	idClaims, err := verifyOIDCIdToken(ctx, idp, provider, rawIdToken, stateClaims.Nonce)
+	if err != nil {
+		log.SpanLog(ctx, log.DebugLevelApi, "sso id token verification failed", "idp", idp.Name, "err", err)
+		return ormutil.NewHTTPError(http.StatusUnauthorized, err.Error())
+	}
+	user, err := getSSOUser(ctx, idp, idClaims)
+	if err != nil {
+		return err
+	}
+	if user.Locked {
+		return fmt.Errorf("Account is locked, please contact MobiledgeX support")
+	}
+	config, err := getConfig(ctx)
+	if err != nil {
+		return err
+	}
+	user.FailedLogins = 0
+	user.LastLogin = time.Now()
+	db := loggedDB(ctx)
+	saveUserLogin(ctx, db, user)
+
+	groupsClaim := idp.GroupsClaim
+	if groupsClaim == "" {
+		groupsClaim = DefaultIdpGroupsClaim
+	}
+	err = syncIdpGroupRoles(ctx, idp, user, getClaimStrings(idClaims, groupsClaim))
+	if err != nil {
+		return err
+	}
+	isAdmin, err := isUserAdmin(ctx, user.Name)
+	if err != nil {
+		return err
+	}
+
+	cookie, err := GenerateCookie(user, "", serverConfig.DomainName, config)
+	if err != nil {
+		log.SpanLog(ctx, log.DebugLevelApi, "failed to generate cookie", "err", err)
+		return fmt.Errorf("Failed to generate cookie")
+	}
+	ret := ormutil.M{"token": cookie.Value}
+	if isAdmin {
+		ret["admin"] = true
+	}
+	c.SetCookie(cookie)
+	return c.JSON(http.StatusOK, ret)
+}
+
+func validateIdentityProvider(ctx context.Context, idp *ormapi.IdentityProvider) error {
+	if err := ValidName(idp.Name); err != nil {
+		return err
+	}
+	issuer, err := url.Parse(idp.Issuer)
+	if err != nil || (issuer.Scheme != "https" && issuer.Scheme != "http") || issuer.Host == "" {
+		return fmt.Errorf("Invalid issuer URL %q", idp.Issuer)
+	}
+	if idp.ClientId == "" {
+		return fmt.Errorf("Client ID not specified")
+	}
+	orgs, err := GetAllOrgs(ctx)
+	if err != nil {
+		return err
+	}
+	mappedOrgs := make(map[string]struct{})
+	for ii, _ := range idp.GroupMappings {
+		m := &idp.GroupMappings[ii]
+		m.Idp = idp.Name
+		if m.GroupName == "" {
+			return fmt.Errorf("Group mapping group name not specified")
+		}
+		if m.Org == "" || m.Role == "" {
+			return fmt.Errorf("Group mapping for group %s must specify org and role", m.GroupName)
+		}
+		org, found := orgs[m.Org]
+		if !found {
+			return fmt.Errorf("Group mapping for group %s org %s not found", m.GroupName, m.Org)
+		}
+		if _, found := mappedOrgs[m.GroupName+"/"+m.Org]; found {
+			return fmt.Errorf("Group %s is mapped more than once for org %s", m.GroupName, m.Org)
+		}
+		mappedOrgs[m.GroupName+"/"+m.Org] = struct{}{}
+		customRole, err := getCustomRole(ctx, m.Role)
+		if err != nil {
+			return err
+		}
+		customRoleType := ""
+		if customRole != nil {
+			customRoleType = customRole.OrgType
+		}
+		if org.Type == OrgTypeDeveloper && !isDeveloperRole(m.Role) && customRoleType != OrgTypeDeveloper {
+			return fmt.Errorf("Group mapping for group %s can only map developer roles for developer organization %s", m.GroupName, m.Org)
+		}
+		if org.Type == OrgTypeOperator && !isOperatorRole(m.Role) && customRoleType != OrgTypeOperator {
+			return fmt.Errorf("Group mapping for group %s can only map operator roles for operator organization %s", m.GroupName, m.Org)
+		}
+	}
+	return nil
+}
+
+// Replace the group mappings of the identity provider
+func setIdpGroupMappings(ctx context.Context, idp *ormapi.IdentityProvider, mappings []ormapi.IdpGroupMapping) error {
+	db := loggedDB(ctx)
+	err := db.Where(&ormapi.IdpGroupMapping{Idp: idp.Name}).Delete(&ormapi.IdpGroupMapping{}).Error
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	for ii, _ := range mappings {
+		if err := db.Create(&mappings[ii]).Error; err != nil {
+			return ormutil.DbErr(err)
+		}
+	}
+	return nil
+}
+
+func CreateIdentityProvider(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	in := ormapi.IdentityProvider{}
+	if err := c.Bind(&in); err != nil {
+		return ormutil.BindErr(err)
+	}
+	if err := authorized(ctx, claims.Username, "", ResourceConfig, ActionManage); err != nil {
+		return err
+	}
+	if in.Name == "" {
+		return fmt.Errorf("Name not specified")
+	}
+	if err := validateIdentityProvider(ctx, &in); err != nil {
+		return err
+	}
+	mappings := in.GroupMappings
+	in.GroupMappings = nil
+
+	db := loggedDB(ctx)
+	if err := db.Create(&in).Error; err != nil {
+		if strings.Contains(err.Error(), "pq: duplicate key value violates unique constraint") {
+			return fmt.Errorf("Identity provider %s already exists", in.Name)
+		}
+		return ormutil.DbErr(err)
+	}
+	if in.ClientSecret != "" {
+		if err := putIdpClientSecretToVault(ctx, &in); err != nil {
+			log.SpanLog(ctx, log.DebugLevelApi, "failed to store identity provider client secret", "idp", in.Name, "err", err)
+			// clean up, ignore errors
+			db.Delete(&in)
+			return fmt.Errorf("Failed to store client secret")
+		}
+	}
+	if err := setIdpGroupMappings(ctx, &in, mappings); err != nil {
+		// clean up, ignore errors
+		db.Where(&ormapi.IdpGroupMapping{Idp: in.Name}).Delete(&ormapi.IdpGroupMapping{})
+		db.Delete(&in)
+		deleteIdpClientSecretFromVault(ctx, &in)
+		return err
+	}
+	return c.JSON(http.StatusOK, ormutil.Msg("Identity provider created"))
+}
+
+func UpdateIdentityProvider(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	// Pull json directly so we can unmarshal twice.
+	// First time is to do lookup, second time is to apply
+	// modified fields.
+	body, err := ioutil.ReadAll(c.Request().Body)
+	in := ormapi.IdentityProvider{}
+	err = BindJson(body, &in)
+	if err != nil {
+		return ormutil.BindErr(err)
+	}
+	if err := authorized(ctx, claims.Username, "", ResourceConfig, ActionManage); err != nil {
+		return err
+	}
+	if in.Name == "" {
+		return fmt.Errorf("Name not specified")
+	}
+	idp, err := getIdentityProvider(ctx, in.Name)
+	if err != nil {
+		return err
+	}
+	oldIdp := *idp
+	err = BindJson(body, idp)
+	if err != nil {
+		return ormutil.BindErr(err)
+	}
+	if !idp.CreatedAt.Equal(oldIdp.CreatedAt) {
+		return fmt.Errorf("Cannot change createdat")
+	}
+	// group mappings are only replaced if specified
+	mappings := idp.GroupMappings
+	if err := validateIdentityProvider(ctx, idp); err != nil {
+		return err
+	}
+	idp.GroupMappings = nil
+	db := loggedDB(ctx)
+	if err := db.Save(idp).Error; err != nil {
+		return ormutil.DbErr(err)
+	}
+	// client secret is not in the db, so it is only set
+	// if specified in the update
+	if idp.ClientSecret != "" {
+		if err := putIdpClientSecretToVault(ctx, idp); err != nil {
+			log.SpanLog(ctx, log.DebugLevelApi, "failed to store identity provider client secret", "idp", idp.Name, "err", err)
+			return fmt.Errorf("Failed to store client secret")
+		}
+	}
+	if len(mappings) > 0 {
+		if err := setIdpGroupMappings(ctx, idp, mappings); err != nil {
+			return err
+		}
+	}
+	return c.JSON(http.StatusOK, ormutil.Msg("Identity provider updated"))
+}
+
+func DeleteIdentityProvider(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	in := ormapi.IdentityProvider{}
+	if err := c.Bind(&in); err != nil {
+		return ormutil.BindErr(err)
+	}
+	if err := authorized(ctx, claims.Username, "", ResourceConfig, ActionManage); err != nil {
+		return err
+	}
+	if in.Name == "" {
+		return fmt.Errorf("Name not specified")
+	}
+	idp, err := getIdentityProvider(ctx, in.Name)
+	if err != nil {
+		return err
+	}
+	db := loggedDB(ctx)
+	userCount := 0
+	err = db.Model(&ormapi.User{}).Where(&ormapi.User{IdentityProvider: idp.Name}).Count(&userCount).Error
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	if userCount > 0 {
+		return fmt.Errorf("Identity provider %s has %d users, please delete the users first", idp.Name, userCount)
+	}
+	err = db.Where(&ormapi.IdpGroupMapping{Idp: idp.Name}).Delete(&ormapi.IdpGroupMapping{}).Error
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	if err := db.Delete(idp).Error; err != nil {
+		return ormutil.DbErr(err)
+	}
+	deleteIdpClientSecretFromVault(ctx, idp)
+	return c.JSON(http.StatusOK, ormutil.Msg("Identity provider deleted"))
+}
+
+func ShowIdentityProvider(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	filter := ormapi.IdentityProvider{}
+	if c.Request().ContentLength > 0 {
+		if err := c.Bind(&filter); err != nil {
+			return ormutil.BindErr(err)
+		}
+	}
+	if err := authorized(ctx, claims.Username, "", ResourceConfig, ActionView); err != nil {
+		return err
+	}
+	filter.GroupMappings = nil
+	idps := []ormapi.IdentityProvider{}
+	db := loggedDB(ctx)
+	err = db.Where(&filter).Find(&idps).Error
+	if err != nil {
+		return ormutil.DbErr(err)
+	}
+	for ii, _ := range idps {
+		mappings, err := getIdpGroupMappings(ctx, idps[ii].Name)
+		if err != nil {
+			return err
+		}
+		idps[ii].GroupMappings = mappings
+	}
+	return c.JSON(http.StatusOK, idps)
+}
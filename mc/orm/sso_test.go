@@ -0,0 +1,422 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/mobiledgex/edge-cloud-infra/mc/mcctl/mctestclient"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+	"github.com/mobiledgex/edge-cloud/cli"
+	"github.com/stretchr/testify/require"
+)
+
+// Stub OpenID Connect identity provider
+type stubIdp struct {
+	server       *httptest.Server
+	key          *rsa.PrivateKey
+	clientId     string
+	clientSecret string
+	mux          sync.Mutex
+	codes        map[string]jwt.MapClaims
+}
+
+func newStubIdp(t *testing.T) *stubIdp {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.Nil(t, err)
+	idp := &stubIdp{
+		key:          key,
+		clientId:     "mc-client",
+		clientSecret: "mc-secret",
+		codes:        make(map[string]jwt.MapClaims),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "key1",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(idp.key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(idp.key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != idp.clientId || secret != idp.clientSecret {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+			return
+		}
+		idp.mux.Lock()
+		claims, found := idp.codes[r.FormValue("code")]
+		delete(idp.codes, r.FormValue("code"))
+		idp.mux.Unlock()
+		if !found || r.FormValue("grant_type") != "authorization_code" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "key1"
+		idToken, err := token.SignedString(idp.key)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"id_token":     idToken,
+		})
+	})
+	idp.server = httptest.NewServer(mux)
+	return idp
+}
+
+// Register the ID token claims to return for the code
+func (s *stubIdp) addCode(code, nonce string, claims jwt.MapClaims) {
+	claims["iss"] = s.server.URL
+	claims["aud"] = s.clientId
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+	claims["iat"] = time.Now().Unix()
+	claims["nonce"] = nonce
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.codes[code] = claims
+}
+
+var ssoTestClient = &http.Client{
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// Start the SSO login, returns the identity provider redirect and
+// the nonce cookie set by MC.
+func ssoTestStart(t *testing.T, uri string, idp *stubIdp, idpName string) (*url.URL, *http.Cookie) {
+	resp, err := ssoTestClient.Get(uri + "/sso/login?idp=" + idpName)
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	require.Nil(t, err)
+	require.Equal(t, idp.server.URL+"/authorize", loc.Scheme+"://"+loc.Host+loc.Path)
+	require.Equal(t, idp.clientId, loc.Query().Get("client_id"))
+	require.Equal(t, "code", loc.Query().Get("response_type"))
+	require.Equal(t, "openid email profile", loc.Query().Get("scope"))
+	var nonceCookie *http.Cookie
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == SSONonceCookie {
+			nonceCookie = cookie
+		}
+	}
+	require.NotNil(t, nonceCookie)
+	require.True(t, nonceCookie.HttpOnly)
+	require.Equal(t, http.SameSiteLaxMode, nonceCookie.SameSite)
+	require.Equal(t, loc.Query().Get("nonce"), nonceCookie.Value)
+	return loc, nonceCookie
+}
+
+// Complete the SSO login, returns the MC auth token.
+func ssoTestCallback(t *testing.T, uri, code, state string, nonceCookie *http.Cookie) (string, int, string) {
+	q := url.Values{}
+	q.Set("code", code)
+	q.Set("state", state)
+	req, err := http.NewRequest(http.MethodGet, uri+"/sso/callback?"+q.Encode(), nil)
+	require.Nil(t, err)
+	if nonceCookie != nil {
+		req.AddCookie(&http.Cookie{Name: nonceCookie.Name, Value: nonceCookie.Value})
+	}
+	resp, err := ssoTestClient.Do(req)
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	ret := map[string]interface{}{}
+	err = json.NewDecoder(resp.Body).Decode(&ret)
+	require.Nil(t, err)
+	token, _ := ret["token"].(string)
+	message, _ := ret["message"].(string)
+	return token, resp.StatusCode, message
+}
+
+// Run the SSO login flow against the stub identity provider,
+// returns the MC auth token.
+func ssoTestLogin(t *testing.T, uri string, idp *stubIdp, idpName, code string, claims jwt.MapClaims) (string, int, string) {
+	loc, nonceCookie := ssoTestStart(t, uri, idp, idpName)
+	idp.addCode(code, loc.Query().Get("nonce"), claims)
+	return ssoTestCallback(t, uri, code, loc.Query().Get("state"), nonceCookie)
+}
+
+func TestOIDCIdTokenVerify(t *testing.T) {
+	stub := newStubIdp(t)
+	defer stub.server.Close()
+	ctx := context.Background()
+
+	idp := &ormapi.IdentityProvider{
+		Name:     "stub",
+		Issuer:   stub.server.URL,
+		ClientId: stub.clientId,
+	}
+	provider, err := getOIDCProviderConfig(ctx, idp)
+	require.Nil(t, err)
+	require.Equal(t, stub.server.URL+"/token", provider.TokenEndpoint)
+
+	sign := func(claims jwt.MapClaims, key *rsa.PrivateKey) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "key1"
+		raw, err := token.SignedString(key)
+		require.Nil(t, err)
+		return raw
+	}
+	getClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":                stub.server.URL,
+			"aud":                []interface{}{"other", stub.clientId},
+			"exp":                time.Now().Add(time.Hour).Unix(),
+			"nonce":              "nonce1",
+			"preferred_username": "ssouser",
+			"groups":             []interface{}{"devs", "ops"},
+		}
+	}
+	claims, err := verifyOIDCIdToken(ctx, idp, provider, sign(getClaims(), stub.key), "nonce1")
+	require.Nil(t, err)
+	require.Equal(t, []string{"devs", "ops"}, getClaimStrings(claims, "groups"))
+
+	_, err = verifyOIDCIdToken(ctx, idp, provider, sign(getClaims(), stub.key), "nonce2")
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "nonce")
+
+	badClaims := getClaims()
+	badClaims["aud"] = "other"
+	_, err = verifyOIDCIdToken(ctx, idp, provider, sign(badClaims, stub.key), "nonce1")
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "audience")
+
+	badClaims = getClaims()
+	badClaims["iss"] = "https://evil.example.com"
+	_, err = verifyOIDCIdToken(ctx, idp, provider, sign(badClaims, stub.key), "nonce1")
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "issuer")
+
+	badClaims = getClaims()
+	badClaims["exp"] = time.Now().Add(-time.Hour).Unix()
+	_, err = verifyOIDCIdToken(ctx, idp, provider, sign(badClaims, stub.key), "nonce1")
+	require.NotNil(t, err)
+
+	badClaims = getClaims()
+	delete(badClaims, "exp")
+	_, err = verifyOIDCIdToken(ctx, idp, provider, sign(badClaims, stub.key), "nonce1")
+	require.NotNil(t, err)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.Nil(t, err)
+	_, err = verifyOIDCIdToken(ctx, idp, provider, sign(getClaims(), otherKey), "nonce1")
+	require.NotNil(t, err)
+
+	// unsigned tokens are rejected
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, getClaims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.Nil(t, err)
+	_, err = verifyOIDCIdToken(ctx, idp, provider, unsigned, "nonce1")
+	require.NotNil(t, err)
+
+	// issuer must match discovery
+	idp.Issuer = stub.server.URL + "/other"
+	_, err = getOIDCProviderConfig(ctx, idp)
+	require.NotNil(t, err)
+}
+
+func testSSO(t *testing.T, ctx context.Context, mcClient *mctestclient.Client, uri, token string) {
+	stub := newStubIdp(t)
+	defer stub.server.Close()
+
+	user1, token1, _ := testCreateUser(t, mcClient, uri, "ssomanager")
+	org := testCreateOrg(t, mcClient, uri, token1, OrgTypeDeveloper, "SSOOrg")
+
+	idp := ormapi.IdentityProvider{
+		Name:         "stubidp",
+		Issuer:       stub.server.URL,
+		ClientId:     stub.clientId,
+		ClientSecret: stub.clientSecret,
+		CreateUsers:  true,
+		GroupMappings: []ormapi.IdpGroupMapping{{
+			GroupName: "devs",
+			Org:       org.Name,
+			Role:      RoleDeveloperContributor,
+		}},
+	}
+
+	// only admins can manage identity providers
+	status, err := mcClient.CreateIdentityProvider(uri, token1, &idp)
+	require.NotNil(t, err)
+	require.Equal(t, http.StatusForbidden, status)
+	// validation
+	badIdp := idp
+	badIdp.Issuer = "foo"
+	_, err = mcClient.CreateIdentityProvider(uri, token, &badIdp)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Invalid issuer URL")
+	badIdp = idp
+	badIdp.GroupMappings = []ormapi.IdpGroupMapping{{
+		GroupName: "devs",
+		Org:       org.Name,
+		Role:      RoleOperatorViewer,
+	}}
+	_, err = mcClient.CreateIdentityProvider(uri, token, &badIdp)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "can only map developer roles")
+
+	status, err = mcClient.CreateIdentityProvider(uri, token, &idp)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	idps, status, err := mcClient.ShowIdentityProvider(uri, token, &ormapi.IdentityProvider{})
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, 1, len(idps))
+	require.Equal(t, "", idps[0].ClientSecret)
+	require.Equal(t, 1, len(idps[0].GroupMappings))
+	// client secret is only stored in vault
+	clientSecret, err := getIdpClientSecretFromVault(&idp)
+	require.Nil(t, err)
+	require.Equal(t, stub.clientSecret, clientSecret)
+
+	// unknown identity provider
+	resp, err := http.Get(uri + "/sso/login?idp=foo")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// first login creates the user, with roles from the group mappings
+	claims := jwt.MapClaims{
+		"sub":                "1234",
+		"preferred_username": "ssouser1",
+		"email":              "ssouser1@example.com",
+		"email_verified":     true,
+		"groups":             []string{"devs"},
+	}
+	ssoToken, status, msg := ssoTestLogin(t, uri, stub, idp.Name, "code1", claims)
+	require.Equal(t, http.StatusOK, status, msg)
+	require.NotEmpty(t, ssoToken)
+	ssoUser, status, err := mcClient.CurrentUser(uri, ssoToken)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "ssouser1", ssoUser.Name)
+	require.Equal(t, idp.Name, ssoUser.IdentityProvider)
+	require.True(t, ssoUser.EmailVerified)
+	roles, _, err := mcClient.ShowRoleAssignment(uri, ssoToken, ClientNoShowFilter)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(roles))
+	require.Equal(t, org.Name, roles[0].Org)
+	require.Equal(t, RoleDeveloperContributor, roles[0].Role)
+
+	// state must match the browser's login session
+	loc, nonceCookie := ssoTestStart(t, uri, stub, idp.Name)
+	otherLoc, otherCookie := ssoTestStart(t, uri, stub, idp.Name)
+	stub.addCode("codeA", loc.Query().Get("nonce"), claims)
+	_, status, msg = ssoTestCallback(t, uri, "codeA", loc.Query().Get("state"), nil)
+	require.Equal(t, http.StatusUnauthorized, status)
+	require.Contains(t, msg, "does not match login session")
+	_, status, msg = ssoTestCallback(t, uri, "codeA", loc.Query().Get("state"), otherCookie)
+	require.Equal(t, http.StatusUnauthorized, status)
+	require.Contains(t, msg, "does not match login session")
+	_, status, msg = ssoTestCallback(t, uri, "codeA", loc.Query().Get("state"), nonceCookie)
+	require.Equal(t, http.StatusOK, status, msg)
+	// state can only be used once
+	stub.addCode("codeB", loc.Query().Get("nonce"), claims)
+	_, status, msg = ssoTestCallback(t, uri, "codeB", loc.Query().Get("state"), nonceCookie)
+	require.Equal(t, http.StatusUnauthorized, status)
+	require.Contains(t, msg, "invalid or expired state")
+	stub.addCode("codeC", otherLoc.Query().Get("nonce"), claims)
+	_, status, msg = ssoTestCallback(t, uri, "codeC", otherLoc.Query().Get("state"), otherCookie)
+	require.Equal(t, http.StatusOK, status, msg)
+
+	// password login is not allowed for single sign-on users
+	_, _, err = mcClient.DoLogin(uri, "ssouser1", "any-password", NoOTP, NoApiKeyId, NoApiKey)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "Invalid username or password")
+
+	// identity provider cannot log in as a local user
+	claims["preferred_username"] = user1.Name
+	_, status, msg = ssoTestLogin(t, uri, stub, idp.Name, "code2", claims)
+	require.Equal(t, http.StatusUnauthorized, status)
+	require.Contains(t, msg, "cannot log in with identity provider")
+
+	// roles are removed when the user leaves the group
+	claims["preferred_username"] = "ssouser1"
+	claims["groups"] = []string{"others"}
+	ssoToken, status, msg = ssoTestLogin(t, uri, stub, idp.Name, "code3", claims)
+	require.Equal(t, http.StatusOK, status, msg)
+	roles, _, err = mcClient.ShowRoleAssignment(uri, ssoToken, ClientNoShowFilter)
+	require.Nil(t, err)
+	require.Equal(t, 0, len(roles))
+
+	// users are not created if not allowed
+	update := &cli.MapData{
+		Namespace: cli.StructNamespace,
+		Data: map[string]interface{}{
+			"Name":        idp.Name,
+			"CreateUsers": false,
+		},
+	}
+	status, err = mcClient.UpdateIdentityProvider(uri, token, update)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	claims["preferred_username"] = "ssouser2"
+	claims["email"] = "ssouser2@example.com"
+	_, status, msg = ssoTestLogin(t, uri, stub, idp.Name, "code4", claims)
+	require.Equal(t, http.StatusUnauthorized, status)
+	require.Contains(t, msg, "not found")
+	// client secret is kept on update
+	claims["preferred_username"] = "ssouser1"
+	claims["email"] = "ssouser1@example.com"
+	_, status, msg = ssoTestLogin(t, uri, stub, idp.Name, "code5", claims)
+	require.Equal(t, http.StatusOK, status, msg)
+
+	// cannot delete identity provider with users
+	_, err = mcClient.DeleteIdentityProvider(uri, token, &ormapi.IdentityProvider{Name: idp.Name})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "please delete the users first")
+
+	// cleanup
+	testDeleteUser(t, mcClient, uri, token, "ssouser1")
+	status, err = mcClient.DeleteIdentityProvider(uri, token, &ormapi.IdentityProvider{Name: idp.Name})
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, status)
+	clientSecret, err = getIdpClientSecretFromVault(&idp)
+	require.Nil(t, err)
+	require.Equal(t, "", clientSecret)
+	testDeleteOrg(t, mcClient, uri, token1, org.Name)
+	testDeleteUser(t, mcClient, uri, token1, user1.Name)
+}
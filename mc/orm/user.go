@@ -34,6 +34,7 @@ import (
 	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
 	"github.com/mobiledgex/edge-cloud-infra/mc/ormutil"
 	"github.com/mobiledgex/edge-cloud-infra/mc/rbac"
+	"github.com/mobiledgex/edge-cloud/edgeproto"
 	"github.com/mobiledgex/edge-cloud/log"
 	"github.com/mobiledgex/edge-cloud/util"
 	"github.com/pquerna/otp"
@@ -142,14 +143,31 @@ func Login(c echo.Context) error {
 		span.SetTag("username", user.Name)
 		span.SetTag("email", user.Email)
 
-		matches, err := ormutil.PasswordMatches(login.ApiKey, apiKeyObj.ApiKeyHash, apiKeyObj.Salt, apiKeyObj.Iter)
-		if err != nil {
-			log.SpanLog(ctx, log.DebugLevelApi, "apiKeyId matches err", "err", err)
-		}
+		matches, err := apiKeyMatches(ctx, login.ApiKey, &apiKeyObj, time.Now())
 		if !matches || err != nil {
 			time.Sleep(BadAuthDelay)
 			return fmt.Errorf("Invalid ApiKey or ApiKeyId")
 		}
+		if !apiKeyObj.ExpiresAt.IsZero() {
+			if !time.Now().Before(apiKeyObj.ExpiresAt) {
+				return fmt.Errorf("ApiKey has expired")
+			}
+			// login token should not outlive the API key
+			if untilExpiry := time.Until(apiKeyObj.ExpiresAt); untilExpiry < config.ApiKeyLoginTokenValidDuration.TimeDuration() {
+				keyConfig := *config
+				keyConfig.ApiKeyLoginTokenValidDuration = edgeproto.Duration(untilExpiry)
+				config = &keyConfig
+			}
+		}
+		apiKeyObj.LastUsed = time.Now()
+		apiKeyObj.LastUsedIP = c.RealIP()
+		err = db.Model(&apiKeyObj).Updates(map[string]interface{}{
+			"last_used":    apiKeyObj.LastUsed,
+			"last_used_ip": apiKeyObj.LastUsedIP,
+		}).Error
+		if err != nil {
+			log.SpanLog(ctx, log.DebugLevelApi, "failed to save api key last used", "apiKeyId", apiKeyObj.Id, "err", err)
+		}
 	} else {
 		if login.Username == "" {
 			return fmt.Errorf("Username not specified")
@@ -176,6 +194,12 @@ func Login(c echo.Context) error {
 		span.SetTag("username", user.Name)
 		span.SetTag("email", user.Email)
 
+		if user.IdentityProvider != "" {
+			// do not reveal the account or its identity provider
+			log.SpanLog(ctx, log.DebugLevelApi, "password login for single sign-on user", "user", user.Name, "identityProvider", user.IdentityProvider)
+			time.Sleep(BadAuthDelay)
+			return fmt.Errorf("Invalid username or password")
+		}
 		// check if login is locked due to failed attempt(s)
 		err = checkLoginLocked(&user, config)
 		if err != nil {
@@ -264,6 +288,15 @@ func Login(c echo.Context) error {
 		}
 	}
 
+	if login.InviteToken != "" {
+		if login.ApiKeyId != "" {
+			return fmt.Errorf("Invitations can only be accepted when logging in with user account")
+		}
+		if _, err := acceptOrgInvitation(ctx, &user, login.InviteToken); err != nil {
+			return err
+		}
+	}
+
 	cookie, err := GenerateCookie(&user, login.ApiKeyId, serverConfig.DomainName, config)
 	if err != nil {
 		log.SpanLog(ctx, log.DebugLevelApi, "failed to generate cookie", "err", err)
@@ -321,6 +354,24 @@ func RefreshAuthCookie(c echo.Context) error {
 	}
 	claims.StandardClaims.IssuedAt = time.Now().Unix()
 	claims.StandardClaims.ExpiresAt = time.Now().AddDate(0, 0, 1).Unix()
+	if claims.AuthType == ApiKeyAuth {
+		// the api key may have been deleted or expired since login
+		apiKeyObj := ormapi.UserApiKey{Id: claims.Username}
+		err = loggedDB(ctx).Where(&apiKeyObj).First(&apiKeyObj).Error
+		if err != nil {
+			log.SpanLog(ctx, log.DebugLevelApi, "ApiKey lookup failed", "apiKeyId", claims.Username, "err", err)
+			return ormutil.NewHTTPError(http.StatusUnauthorized, "Invalid ApiKey")
+		}
+		if !apiKeyObj.ExpiresAt.IsZero() {
+			if !time.Now().Before(apiKeyObj.ExpiresAt) {
+				return ormutil.NewHTTPError(http.StatusUnauthorized, "ApiKey has expired")
+			}
+			// auth token should not outlive the API key
+			if claims.ExpiresAt > apiKeyObj.ExpiresAt.Unix() {
+				claims.ExpiresAt = apiKeyObj.ExpiresAt.Unix()
+			}
+		}
+	}
 	cookie, err := Jwks.GenerateCookie(claims)
 	if err != nil {
 		log.SpanLog(ctx, log.DebugLevelApi, "failed to generate cookie", "err", err)
@@ -409,11 +460,20 @@ func CreateUser(c echo.Context) error {
 	span.SetTag("username", user.Name)
 	span.SetTag("email", user.Email)
 
+	if createuser.InviteToken != "" {
+		// check the invitation before creating the user
+		if _, err := getOrgInvitation(ctx, createuser.InviteToken, user.Email); err != nil {
+			return err
+		}
+	}
+
 	user.Locked = false
 	if config.LockNewAccounts {
 		user.Locked = true
 	}
 	user.EmailVerified = false
+	// only single sign-on logins create identity provider users
+	user.IdentityProvider = ""
 
 	userResponse := ormapi.UserResponse{}
 	if user.EnableTOTP {
@@ -477,6 +537,17 @@ func CreateUser(c echo.Context) error {
 	} else {
 		userResponse.Message = "user created"
 	}
+	if createuser.InviteToken != "" {
+		// user is already created, so just report failure
+		invite, err := acceptOrgInvitation(ctx, &user, createuser.InviteToken)
+		if err != nil {
+			log.SpanLog(ctx, log.DebugLevelApi, "failed to accept invitation for new user", "err", err)
+			_, res := getErrorResult(err)
+			userResponse.Message += ", but failed to accept invitation: " + res.Message
+		} else {
+			userResponse.Message += fmt.Sprintf(", joined organization %s with role %s", invite.Org, invite.Role)
+		}
+	}
 	return c.JSON(http.StatusOK, &userResponse)
 }
 
@@ -499,7 +570,7 @@ func VerifyEmail(c echo.Context) error {
 	}
 	claims := EmailClaims{}
 	token, err := Jwks.VerifyCookie(tok.Token, &claims)
-	if err != nil || !token.Valid {
+	if err != nil || !token.Valid || claims.Email == "" {
 		return &echo.HTTPError{
 			Code:     http.StatusUnauthorized,
 			Message:  "invalid or expired token",
@@ -596,6 +667,11 @@ func DeleteUser(c echo.Context) error {
 			}
 		}
 	}
+	// delete notification preferences and held notifications
+	err = deleteUserNotifications(ctx, user.Name)
+	if err != nil {
+		return err
+	}
 	// delete user
 	db := loggedDB(ctx)
 	err = db.Delete(&user).Error
@@ -677,7 +753,7 @@ func ShowUser(c echo.Context) error {
 	// look for all users matching user filter
 	db := loggedDB(ctx)
 	users := []ormapi.User{}
-	err = db.Where(filter).Find(&users).Error
+	err = findShowPage(db.Where(filter), ormutil.GetShowPager(c), &users).Error
 	if err != nil {
 		return ormutil.DbErr(err)
 	}
@@ -738,7 +814,7 @@ func ShowUser(c echo.Context) error {
 	sort.Slice(users, func(i, j int) bool {
 		return users[i].Name < users[j].Name
 	})
-	return c.JSON(http.StatusOK, users)
+	return ormutil.SetReply(c, users)
 }
 
 func NewPassword(c echo.Context) error {
@@ -927,7 +1003,7 @@ func PasswordReset(c echo.Context) error {
 	}
 	claims := EmailClaims{}
 	token, err := Jwks.VerifyCookie(pw.Token, &claims)
-	if err != nil || !token.Valid {
+	if err != nil || !token.Valid || claims.Username == "" {
 		return &echo.HTTPError{
 			Code:     http.StatusUnauthorized,
 			Message:  "invalid or expired token",
@@ -1096,6 +1172,9 @@ func UpdateUser(c echo.Context) error {
 	if old.LastFailedLogin != user.LastFailedLogin {
 		return fmt.Errorf("Cannot change last failed login time")
 	}
+	if old.IdentityProvider != user.IdentityProvider {
+		return fmt.Errorf("Cannot change identity provider")
+	}
 
 	// if email changed, need to verify
 	sendVerify := false
@@ -1194,10 +1273,15 @@ func CreateUserApiKey(c echo.Context) error {
 		return fmt.Errorf("No permissions for specified org")
 	}
 
+	if !apiKeyReq.ExpiresAt.IsZero() && !time.Now().Before(apiKeyReq.ExpiresAt) {
+		return fmt.Errorf("Expiry time %s must be in the future", apiKeyReq.ExpiresAt.Format(time.RFC3339))
+	}
+
 	apiKeyObj := ormapi.UserApiKey{}
 	apiKeyObj.Username = claims.Username
 	apiKeyObj.Org = apiKeyReq.Org
 	apiKeyObj.Description = apiKeyReq.Description
+	apiKeyObj.ExpiresAt = apiKeyReq.ExpiresAt
 
 	// verify that specified org exists
 	org := ormapi.Organization{}
@@ -1379,6 +1463,10 @@ func ShowUserApiKey(c echo.Context) error {
 		out.Description = apiKeyObj.Description
 		out.Org = apiKeyObj.Org
 		out.CreatedAt = apiKeyObj.CreatedAt
+		out.ExpiresAt = apiKeyObj.ExpiresAt
+		out.LastUsed = apiKeyObj.LastUsed
+		out.LastUsedIP = apiKeyObj.LastUsedIP
+		out.PrevExpiresAt = apiKeyObj.PrevExpiresAt
 		if super {
 			out.Username = apiKeyObj.Username
 		}
@@ -1394,3 +1482,166 @@ func ShowUserApiKey(c echo.Context) error {
 	}
 	return c.JSON(http.StatusOK, &outApiKeys)
 }
+
+// Checks the API key against the current key, and the previous key
+// if it was rotated within the grace period.
+func apiKeyMatches(ctx context.Context, apiKey string, apiKeyObj *ormapi.UserApiKey, now time.Time) (bool, error) {
+	matches, err := ormutil.PasswordMatches(apiKey, apiKeyObj.ApiKeyHash, apiKeyObj.Salt, apiKeyObj.Iter)
+	if err != nil {
+		log.SpanLog(ctx, log.DebugLevelApi, "apiKeyId matches err", "err", err)
+		return false, err
+	}
+	if matches {
+		return true, nil
+	}
+	if apiKeyObj.PrevApiKeyHash == "" || !now.Before(apiKeyObj.PrevExpiresAt) {
+		return false, nil
+	}
+	matches, err = ormutil.PasswordMatches(apiKey, apiKeyObj.PrevApiKeyHash, apiKeyObj.PrevSalt, apiKeyObj.PrevIter)
+	if err != nil {
+		log.SpanLog(ctx, log.DebugLevelApi, "previous apiKeyId matches err", "err", err)
+		return false, err
+	}
+	return matches, nil
+}
+
+// Issue a new secret for an existing API key, keeping its ID and
+// permissions. The previous secret remains valid for the configured
+// grace period so that automation can be switched over.
+func RotateUserApiKey(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	db := loggedDB(ctx)
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	// Disallow apikey rotation if auth type is ApiKey auth
+	if claims.AuthType == ApiKeyAuth {
+		return ormutil.NewHTTPError(http.StatusForbidden, "ApiKey auth not allowed to rotate API keys, please log in with user account")
+	}
+	in := ormapi.CreateUserApiKey{}
+	if err := c.Bind(&in); err != nil {
+		return ormutil.BindErr(err)
+	}
+	if in.Id == "" {
+		return fmt.Errorf("Missing API key ID")
+	}
+	config, err := getConfig(ctx)
+	if err != nil {
+		return err
+	}
+	apiKeyObj := ormapi.UserApiKey{Id: in.Id}
+	res := db.Where(&apiKeyObj).First(&apiKeyObj)
+	if res.RecordNotFound() {
+		return fmt.Errorf("API key ID not found")
+	}
+	if res.Error != nil {
+		return ormutil.DbErr(res.Error)
+	}
+	if apiKeyObj.Username != claims.Username {
+		return ormutil.NewHTTPError(http.StatusForbidden, "Cannot rotate other user's API key")
+	}
+	now := time.Now()
+	if !apiKeyObj.ExpiresAt.IsZero() && !now.Before(apiKeyObj.ExpiresAt) {
+		return fmt.Errorf("API key has expired, please create a new one")
+	}
+	if !in.ExpiresAt.IsZero() {
+		if !now.Before(in.ExpiresAt) {
+			return fmt.Errorf("Expiry time %s must be in the future", in.ExpiresAt.Format(time.RFC3339))
+		}
+		apiKeyObj.ExpiresAt = in.ExpiresAt
+	}
+
+	apiKey := uuid.New().String()
+	apiKeyObj.PrevApiKeyHash = apiKeyObj.ApiKeyHash
+	apiKeyObj.PrevSalt = apiKeyObj.Salt
+	apiKeyObj.PrevIter = apiKeyObj.Iter
+	apiKeyObj.PrevExpiresAt = now.Add(config.ApiKeyRotateGracePeriod.TimeDuration())
+	apiKeyObj.ApiKeyHash, apiKeyObj.Salt, apiKeyObj.Iter = ormutil.NewPasshash(apiKey)
+	if err := db.Save(&apiKeyObj).Error; err != nil {
+		return ormutil.DbErr(err)
+	}
+	apiKeyOut := ormapi.CreateUserApiKey{}
+	apiKeyOut.Id = apiKeyObj.Id
+	apiKeyOut.ApiKey = apiKey
+	apiKeyOut.ExpiresAt = apiKeyObj.ExpiresAt
+	apiKeyOut.PrevExpiresAt = apiKeyObj.PrevExpiresAt
+	return c.JSON(http.StatusOK, &apiKeyOut)
+}
+
+// Get API keys which have expired, or have not been used to log in
+// since the cutoff time. Keys that were never used are measured from
+// when they were created.
+func getStaleApiKeys(ctx context.Context, cutoff, now time.Time) ([]ormapi.UserApiKey, error) {
+	apiKeys := []ormapi.UserApiKey{}
+	db := loggedDB(ctx)
+	err := db.Order("username, id").Find(&apiKeys).Error
+	if err != nil {
+		return nil, ormutil.DbErr(err)
+	}
+	stale := []ormapi.UserApiKey{}
+	for _, apiKeyObj := range apiKeys {
+		lastActive := apiKeyObj.LastUsed
+		if lastActive.IsZero() {
+			lastActive = apiKeyObj.CreatedAt
+		}
+		expired := !apiKeyObj.ExpiresAt.IsZero() && !now.Before(apiKeyObj.ExpiresAt)
+		if expired || lastActive.Before(cutoff) {
+			stale = append(stale, apiKeyObj)
+		}
+	}
+	return stale, nil
+}
+
+func ShowStaleUserApiKey(c echo.Context) error {
+	ctx := ormutil.GetContext(c)
+	claims, err := getClaims(c)
+	if err != nil {
+		return err
+	}
+	if claims.AuthType == ApiKeyAuth {
+		return ormutil.NewHTTPError(http.StatusForbidden, "ApiKey auth not allowed to show API keys, please log in with user account")
+	}
+	if err := authorized(ctx, claims.Username, "", ResourceUsers, ActionView); err != nil {
+		return err
+	}
+	filter := ormapi.StaleApiKeyFilter{}
+	if c.Request().ContentLength > 0 {
+		if err := c.Bind(&filter); err != nil {
+			return ormutil.BindErr(err)
+		}
+	}
+	if filter.UnusedDuration < 0 {
+		return fmt.Errorf("Unused duration cannot be negative")
+	}
+	unused := filter.UnusedDuration.TimeDuration()
+	if unused == 0 {
+		config, err := getConfig(ctx)
+		if err != nil {
+			return err
+		}
+		unused = config.ApiKeyStaleDuration.TimeDuration()
+	}
+	now := time.Now()
+	apiKeys, err := getStaleApiKeys(ctx, now.Add(-unused), now)
+	if err != nil {
+		return err
+	}
+	outApiKeys := []ormapi.CreateUserApiKey{}
+	for _, apiKeyObj := range apiKeys {
+		if filter.Username != "" && apiKeyObj.Username != filter.Username {
+			continue
+		}
+		out := ormapi.CreateUserApiKey{}
+		out.Id = apiKeyObj.Id
+		out.Description = apiKeyObj.Description
+		out.Org = apiKeyObj.Org
+		out.Username = apiKeyObj.Username
+		out.CreatedAt = apiKeyObj.CreatedAt
+		out.ExpiresAt = apiKeyObj.ExpiresAt
+		out.LastUsed = apiKeyObj.LastUsed
+		out.LastUsedIP = apiKeyObj.LastUsedIP
+		outApiKeys = append(outApiKeys, out)
+	}
+	return c.JSON(http.StatusOK, &outApiKeys)
+}
@@ -250,6 +250,9 @@ func authorized(ctx context.Context, sub, org, obj, act string, ops ...authOp) e
 	if !allow {
 		return echo.ErrForbidden
 	}
+	if err := checkOrgSuspended(ctx, org, obj, act, admin); err != nil {
+		return err
+	}
 	if !opts.showAudit {
 		if opts.requiresOrg != "" {
 			if err := checkRequiresOrg(ctx, opts.requiresOrg, obj, admin, opts.noEdgeboxOnly); err != nil {
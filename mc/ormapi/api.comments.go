@@ -17,34 +17,49 @@ package ormapi
 // This is an auto-generated file. DO NOT EDIT directly.
 
 var UserComments = map[string]string{
-	"name":            `User name. Can only contain letters, digits, underscore, period, hyphen. It cannot have leading or trailing spaces or period. It cannot start with hyphen`,
-	"email":           `User email`,
-	"emailverified":   `Email address has been verified`,
-	"familyname":      `Family Name`,
-	"givenname":       `Given Name`,
-	"nickname":        `Nick Name`,
-	"locked":          `Account is locked`,
-	"enabletotp":      `Enable or disable temporary one-time passwords for the account`,
-	"metadata":        `Metadata`,
-	"lastlogin":       `Last successful login time`,
-	"lastfailedlogin": `Last failed login time`,
-	"failedlogins":    `Number of failed login attempts since last successful login`,
+	"name":             `User name. Can only contain letters, digits, underscore, period, hyphen. It cannot have leading or trailing spaces or period. It cannot start with hyphen`,
+	"email":            `User email`,
+	"emailverified":    `Email address has been verified`,
+	"familyname":       `Family Name`,
+	"givenname":        `Given Name`,
+	"nickname":         `Nick Name`,
+	"locked":           `Account is locked`,
+	"enabletotp":       `Enable or disable temporary one-time passwords for the account`,
+	"metadata":         `Metadata`,
+	"lastlogin":        `Last successful login time`,
+	"lastfailedlogin":  `Last failed login time`,
+	"failedlogins":     `Number of failed login attempts since last successful login`,
+	"identityprovider": `Identity provider the user logs in with via single sign-on, blank for local accounts`,
 }
 
 var CreateUserApiKeyComments = map[string]string{
-	"userapikey.id":          `API key ID used as an identifier for API keys`,
-	"userapikey.description": `Description of the purpose of this API key`,
-	"userapikey.org":         `Org to which API key has permissions to access its objects`,
-	"apikey":                 `API key`,
-	"permissions:#.role":     `Role defines a collection of permissions, which are resource-action pairs`,
-	"permissions:#.resource": `Resource defines a resource to act upon`,
-	"permissions:#.action":   `Action defines what type of action can be performed on a resource`,
+	"userapikey.id":             `API key ID used as an identifier for API keys`,
+	"userapikey.description":    `Description of the purpose of this API key`,
+	"userapikey.org":            `Org to which API key has permissions to access its objects`,
+	"userapikey.expiresat":      `Time after which the API key can no longer be used to log in, blank for no expiry`,
+	"userapikey.lastused":       `Time the API key was last used to log in`,
+	"userapikey.lastusedip":     `IP address the API key was last used to log in from`,
+	"userapikey.prevapikeyhash": `Hash of the previous API key after rotation`,
+	"userapikey.prevexpiresat":  `Time until which the previous API key can still be used after rotation`,
+	"apikey":                    `API key`,
+	"permissions:#.role":        `Role defines a collection of permissions, which are resource-action pairs`,
+	"permissions:#.resource":    `Resource defines a resource to act upon`,
+	"permissions:#.action":      `Action defines what type of action can be performed on a resource`,
+}
+
+var StaleApiKeyFilterComments = map[string]string{
+	"username": `Only report API keys of this user`,
 }
 
 var UserApiKeyComments = map[string]string{
-	"id":          `API key ID used as an identifier for API keys`,
-	"description": `Description of the purpose of this API key`,
-	"org":         `Org to which API key has permissions to access its objects`,
+	"id":             `API key ID used as an identifier for API keys`,
+	"description":    `Description of the purpose of this API key`,
+	"org":            `Org to which API key has permissions to access its objects`,
+	"expiresat":      `Time after which the API key can no longer be used to log in, blank for no expiry`,
+	"lastused":       `Time the API key was last used to log in`,
+	"lastusedip":     `IP address the API key was last used to log in from`,
+	"prevapikeyhash": `Hash of the previous API key after rotation`,
+	"prevexpiresat":  `Time until which the previous API key can still be used after rotation`,
 }
 
 var OrganizationComments = map[string]string{
@@ -55,6 +70,43 @@ var OrganizationComments = map[string]string{
 	"publicimages":     `Images are made available to other organization`,
 	"deleteinprogress": `Delete of this organization is in progress`,
 	"edgeboxonly":      `Edgebox only operator organization`,
+	"suspended":        `Organization is suspended, mutating controller APIs are blocked`,
+	"suspendreason":    `Reason the organization was suspended`,
+}
+
+var OrgSuspendComments = map[string]string{
+	"name":             `Organization name`,
+	"reason":           `Reason for suspending the organization`,
+	"poweroffappinsts": `Power off the organization's AppInsts when suspending`,
+	"poweronappinsts":  `Power on the organization's AppInsts when resuming`,
+}
+
+var OrgQuotaComments = map[string]string{
+	"org":             `Organization name`,
+	"region":          `Region the quota applies to, blank for the total across all regions`,
+	"maxvcpus":        `Maximum number of vCPUs, 0 for no limit`,
+	"maxram":          `Maximum RAM in MB, 0 for no limit`,
+	"maxgpus":         `Maximum number of GPUs, 0 for no limit`,
+	"maxappinsts":     `Maximum number of AppInsts, 0 for no limit`,
+	"maxclusterinsts": `Maximum number of ClusterInsts, 0 for no limit`,
+}
+
+var OrgResourceUsageComments = map[string]string{
+	"vcpus":        `Number of vCPUs in use`,
+	"ram":          `RAM in use in MB`,
+	"gpus":         `Number of GPUs in use`,
+	"appinsts":     `Number of AppInsts`,
+	"clusterinsts": `Number of ClusterInsts`,
+}
+
+var OrgQuotaUsageComments = map[string]string{
+	"org":                `Organization name`,
+	"region":             `Region of the usage, blank for the total across all regions`,
+	"usage.vcpus":        `Number of vCPUs in use`,
+	"usage.ram":          `RAM in use in MB`,
+	"usage.gpus":         `Number of GPUs in use`,
+	"usage.appinsts":     `Number of AppInsts`,
+	"usage.clusterinsts": `Number of ClusterInsts`,
 }
 
 var InvoiceRequestComments = map[string]string{
@@ -151,9 +203,43 @@ var RolePermComments = map[string]string{
 }
 
 var RoleComments = map[string]string{
-	"org":      `Organization name`,
-	"username": `User name`,
-	"role":     `Role which defines the set of permissions`,
+	"org":       `Organization name`,
+	"username":  `User name`,
+	"role":      `Role which defines the set of permissions`,
+	"expiresat": `Time when the role assignment expires and is removed automatically, optional`,
+}
+
+var RoleExpirationComments = map[string]string{
+	"org":         `Organization name`,
+	"username":    `User name`,
+	"role":        `Role name`,
+	"expiresat":   `Time when the role assignment expires`,
+	"warningsent": `Whether the expiry warning has been sent`,
+}
+
+var CustomRoleComments = map[string]string{
+	"name":                   `Custom role name. Can only contain letters, digits, period, hyphen. It cannot have leading or trailing spaces or period. It cannot start with hyphen`,
+	"orgtype":                `Type of organization the role can be assigned for, one of developer, operator`,
+	"description":            `Description of the role`,
+	"createdby":              `User who created the role`,
+	"permissions:#.role":     `Role defines a collection of permissions, which are resource-action pairs`,
+	"permissions:#.resource": `Resource defines a resource to act upon`,
+	"permissions:#.action":   `Action defines what type of action can be performed on a resource`,
+}
+
+var OrgInvitationComments = map[string]string{
+	"id":        `Invitation ID`,
+	"org":       `Organization name`,
+	"email":     `Email address of the user being invited`,
+	"role":      `Role given to the user when the invitation is accepted`,
+	"invitedby": `User who sent the invitation`,
+	"expiresat": `Time after which the invitation can no longer be accepted, defaults to 7 days`,
+}
+
+var OrgInvitationResponseComments = map[string]string{
+	"message": `Result message`,
+	"id":      `Invitation ID`,
+	"token":   `Invitation token, only returned if emails are not sent because email verification is skipped`,
 }
 
 var OrgCloudletComments = map[string]string{
@@ -162,28 +248,58 @@ var OrgCloudletComments = map[string]string{
 }
 
 var ShowUserComments = map[string]string{
-	"user.name":            `User name. Can only contain letters, digits, underscore, period, hyphen. It cannot have leading or trailing spaces or period. It cannot start with hyphen`,
-	"user.email":           `User email`,
-	"user.emailverified":   `Email address has been verified`,
-	"user.familyname":      `Family Name`,
-	"user.givenname":       `Given Name`,
-	"user.nickname":        `Nick Name`,
-	"user.locked":          `Account is locked`,
-	"user.enabletotp":      `Enable or disable temporary one-time passwords for the account`,
-	"user.metadata":        `Metadata`,
-	"user.lastlogin":       `Last successful login time`,
-	"user.lastfailedlogin": `Last failed login time`,
-	"user.failedlogins":    `Number of failed login attempts since last successful login`,
-	"org":                  `Organization name`,
-	"role":                 `Role name`,
+	"user.name":             `User name. Can only contain letters, digits, underscore, period, hyphen. It cannot have leading or trailing spaces or period. It cannot start with hyphen`,
+	"user.email":            `User email`,
+	"user.emailverified":    `Email address has been verified`,
+	"user.familyname":       `Family Name`,
+	"user.givenname":        `Given Name`,
+	"user.nickname":         `Nick Name`,
+	"user.locked":           `Account is locked`,
+	"user.enabletotp":       `Enable or disable temporary one-time passwords for the account`,
+	"user.metadata":         `Metadata`,
+	"user.lastlogin":        `Last successful login time`,
+	"user.lastfailedlogin":  `Last failed login time`,
+	"user.failedlogins":     `Number of failed login attempts since last successful login`,
+	"user.identityprovider": `Identity provider the user logs in with via single sign-on, blank for local accounts`,
+	"org":                   `Organization name`,
+	"role":                  `Role name`,
 }
 
 var UserLoginComments = map[string]string{
-	"username": `User's name or email address`,
-	"password": `User's password`,
-	"totp":     `Temporary one-time password if 2-factor authentication is enabled`,
-	"apikeyid": `API key ID if logging in using API key`,
-	"apikey":   `API key if logging in using API key`,
+	"username":    `User's name or email address`,
+	"password":    `User's password`,
+	"totp":        `Temporary one-time password if 2-factor authentication is enabled`,
+	"apikeyid":    `API key ID if logging in using API key`,
+	"apikey":      `API key if logging in using API key`,
+	"invitetoken": `Organization invitation token to accept on login`,
+}
+
+var IdentityProviderComments = map[string]string{
+	"name":                      `Identity provider name. Can only contain letters, digits, underscore, period, hyphen. It cannot have leading or trailing spaces or period. It cannot start with hyphen`,
+	"issuer":                    `Issuer URL, the provider endpoints are discovered from its /.well-known/openid-configuration`,
+	"clientid":                  `OAuth2 client ID registered with the identity provider`,
+	"clientsecret":              `OAuth2 client secret registered with the identity provider, stored in Vault`,
+	"scopes":                    `Additional scopes to request, openid, email and profile are always requested`,
+	"usernameclaim":             `ID token claim used as the user name (Default: preferred_username)`,
+	"groupsclaim":               `ID token claim listing the user's groups (Default: groups)`,
+	"createusers":               `Create users on their first single sign-on login`,
+	"groupmappings:#.idp":       `Identity provider name`,
+	"groupmappings:#.groupname": `Group name in the groups claim`,
+	"groupmappings:#.org":       `Organization name`,
+	"groupmappings:#.role":      `Role to assign for the organization`,
+}
+
+var IdpGroupMappingComments = map[string]string{
+	"idp":       `Identity provider name`,
+	"groupname": `Group name in the groups claim`,
+	"org":       `Organization name`,
+	"role":      `Role to assign for the organization`,
+}
+
+var SSOLoginStateComments = map[string]string{
+	"nonce":     `Nonce of the login, also set in the browser's SSO cookie`,
+	"idp":       `Identity provider name`,
+	"expiresat": `Time after which the login can no longer be completed`,
 }
 
 var NewPasswordComments = map[string]string{
@@ -192,19 +308,33 @@ var NewPasswordComments = map[string]string{
 }
 
 var CreateUserComments = map[string]string{
-	"user.name":            `User name. Can only contain letters, digits, underscore, period, hyphen. It cannot have leading or trailing spaces or period. It cannot start with hyphen`,
-	"user.email":           `User email`,
-	"user.emailverified":   `Email address has been verified`,
-	"user.familyname":      `Family Name`,
-	"user.givenname":       `Given Name`,
-	"user.nickname":        `Nick Name`,
-	"user.locked":          `Account is locked`,
-	"user.enabletotp":      `Enable or disable temporary one-time passwords for the account`,
-	"user.metadata":        `Metadata`,
-	"user.lastlogin":       `Last successful login time`,
-	"user.lastfailedlogin": `Last failed login time`,
-	"user.failedlogins":    `Number of failed login attempts since last successful login`,
-	"verify.email":         `User's email address`,
+	"user.name":             `User name. Can only contain letters, digits, underscore, period, hyphen. It cannot have leading or trailing spaces or period. It cannot start with hyphen`,
+	"user.email":            `User email`,
+	"user.emailverified":    `Email address has been verified`,
+	"user.familyname":       `Family Name`,
+	"user.givenname":        `Given Name`,
+	"user.nickname":         `Nick Name`,
+	"user.locked":           `Account is locked`,
+	"user.enabletotp":       `Enable or disable temporary one-time passwords for the account`,
+	"user.metadata":         `Metadata`,
+	"user.lastlogin":        `Last successful login time`,
+	"user.lastfailedlogin":  `Last failed login time`,
+	"user.failedlogins":     `Number of failed login attempts since last successful login`,
+	"user.identityprovider": `Identity provider the user logs in with via single sign-on, blank for local accounts`,
+	"verify.email":          `User's email address`,
+	"invitetoken":           `Organization invitation token to accept once the user is created`,
+}
+
+var AuditQueryComments = map[string]string{
+	"username":  `User that ran the operation`,
+	"org":       `Organization the operation was run against`,
+	"limit":     `Number of results to return, either to limit or for paging results`,
+	"from":      `Start offset if paging through results`,
+	"operation": `Operation (API path) that was run, i.e. /api/v1/auth/ctrl/CreateApp`,
+	"status":    `HTTP status code of the operation`,
+	"clientip":  `IP address of the client that ran the operation`,
+	"tags":      `Additional audit tags to match, value is key=value format`,
+	"format":    `Export format, either json or csv (default json), export only`,
 }
 
 var EmailRequestComments = map[string]string{
@@ -220,10 +350,25 @@ var TokenComments = map[string]string{
 	"token": `Authentication token`,
 }
 
+var ShowPageComments = map[string]string{
+	"limit":             `Max number of objects to return, 0 for all`,
+	"continuationtoken": `Token from the previous page to get the next page`,
+}
+
 var RegionDataComments = map[string]string{
 	"region": `Region name`,
 }
 
+var DataChangeComments = map[string]string{
+	"region": `Region name`,
+	"type":   `Object type, one of Flavor, ClusterInst, App or AppInst`,
+	"key":    `Object key`,
+	"action": `Action, one of create, update or delete`,
+	"fields": `Fields to update`,
+	"done":   `Action was run successfully`,
+	"error":  `Error if the action failed`,
+}
+
 var MetricsCommonComments = map[string]string{
 	"numsamples": `Display X samples spaced out evenly over start and end times`,
 	"limit":      `Display the last X metrics`,
@@ -333,7 +478,7 @@ var RegionCloudletPoolUsageRegisterComments = map[string]string{
 
 var AlertReceiverComments = map[string]string{
 	"name":                    `Receiver Name`,
-	"type":                    `Receiver type. Eg. email, slack, pagerduty`,
+	"type":                    `Receiver type. Eg. email, slack, pagerduty, webhook, teams`,
 	"severity":                `Alert severity filter`,
 	"region":                  `Region for the alert receiver`,
 	"user":                    `User that created this receiver`,
@@ -342,6 +487,103 @@ var AlertReceiverComments = map[string]string{
 	"slackwebhook":            `Custom slack webhook`,
 	"pagerdutyintegrationkey": `PagerDuty integration key`,
 	"pagerdutyapiversion":     `PagerDuty API version`,
+	"webhookurl":              `Generic webhook URL`,
+	"webhookuser":             `Generic webhook basic auth username`,
+	"webhookpassword":         `Generic webhook basic auth password, stored in Vault`,
+	"webhookbearertoken":      `Generic webhook bearer token, stored in Vault`,
+	"webhookheaders":          `Custom HTTP headers sent with each webhook request, value is key=value format`,
+	"teamswebhook":            `Microsoft Teams incoming webhook URL`,
+}
+
+var AlertSilenceComments = map[string]string{
+	"id":        `Silence ID assigned by AlertManager`,
+	"region":    `Region for the silenced alerts`,
+	"alertname": `Name of the alert to silence, all alerts if not specified`,
+	"severity":  `Alert severity to silence, all severities if not specified`,
+	"startsat":  `Absolute time (in RFC3339 format with intended timezone) when the silence starts (Default: now)`,
+	"endsat":    `Absolute time (in RFC3339 format with intended timezone) when the silence ends`,
+	"comment":   `Reason for silencing the alerts`,
+	"createdby": `User that created this silence`,
+	"state":     `Silence state: active, pending or expired`,
+}
+
+var MaintenanceWindowComments = map[string]string{
+	"name":         `Maintenance window name. Can only contain letters, digits, period, hyphen. It cannot have leading or trailing spaces or period. It cannot start with hyphen`,
+	"org":          `Operator organization of the cloudlet or cloudlet pool`,
+	"region":       `Region name`,
+	"cloudlet":     `Cloudlet under maintenance, one of cloudlet or cloudlet pool must be specified`,
+	"cloudletpool": `Cloudlet pool whose cloudlets are under maintenance`,
+	"starttime":    `Absolute time (in RFC3339 format with intended timezone) when the window opens`,
+	"endtime":      `Absolute time (in RFC3339 format with intended timezone) when the window closes`,
+	"recurrence":   `How often the window repeats, one of None, Daily, Weekly, Monthly (Default: None)`,
+	"comment":      `Description of the maintenance work`,
+	"username":     `User name (for internal use only)`,
+	"state":        `Window state: Scheduled, Open or Closed`,
+	"silenceids":   `Alert silences created for the open window`,
+}
+
+var NotificationPreferencesComments = map[string]string{
+	"username":        `User name (for internal use only)`,
+	"categories":      `Event categories to notify about, any of roles, cloudletpools, appinstfailures (Default: roles, cloudletpools). Failed AppInst creates are only reported in the daily digest`,
+	"delivery":        `How notifications are delivered, one of immediate, digest, none (Default: immediate)`,
+	"quiethoursstart": `Hour of the day (0-23) when quiet hours start. Immediate notifications during quiet hours are held until quiet hours end. Quiet hours are disabled if start and end are the same`,
+	"quiethoursend":   `Hour of the day (0-23) when quiet hours end`,
+	"digesthour":      `Hour of the day (0-23) when the daily digest email is sent (Default: 8)`,
+	"timezone":        `Timezone for quiet hours and digest hour, defaults to UTC`,
+	"lastdigest":      `Time the last digest email was sent`,
+}
+
+var PendingNotificationComments = map[string]string{
+	"id":        `Notification ID`,
+	"username":  `User to notify`,
+	"category":  `Event category`,
+	"org":       `Organization the event applies to`,
+	"message":   `Notification message`,
+	"createdat": `Time the event occurred`,
+}
+
+var EventWebhookComments = map[string]string{
+	"name":          `Webhook name. Can only contain letters, digits, period, hyphen. It cannot have leading or trailing spaces or period. It cannot start with hyphen`,
+	"org":           `Organization whose events are delivered`,
+	"url":           `URL that events are posted to`,
+	"secret":        `Shared secret used to sign deliveries. The hex encoded HMAC-SHA256 of the request body is sent in the X-MC-Signature header`,
+	"names":         `Only deliver events with one of these names`,
+	"types":         `Only deliver events of these types, event or audit`,
+	"regions":       `Only deliver events from these regions`,
+	"tags":          `Only deliver events with all of these tags, specified as key=value`,
+	"failed":        `Only deliver failed events`,
+	"disabled":      `Stop delivering events without deleting the webhook`,
+	"username":      `User name (for internal use only)`,
+	"lasteventtime": `Time up to which events have been queued for delivery`,
+}
+
+var EventWebhookDeliveryComments = map[string]string{
+	"id":          `Delivery ID`,
+	"webhook":     `Webhook name`,
+	"org":         `Webhook organization`,
+	"eventname":   `Event name`,
+	"eventtime":   `Time the event occurred`,
+	"state":       `Delivery state, one of Pending, Delivered, Failed. Failed deliveries have exhausted all retries and can be retried manually`,
+	"attempts":    `Number of delivery attempts`,
+	"laststatus":  `HTTP status code of the last attempt`,
+	"lasterror":   `Error of the last attempt`,
+	"nextattempt": `Time of the next delivery attempt`,
+	"createdat":   `Time the delivery was queued`,
+	"deliveredat": `Time the event was delivered`,
+	"payload":     `Delivered JSON payload`,
+}
+
+var GraphQLQueryComments = map[string]string{
+	"query":         `GraphQL query document, only query operations are allowed`,
+	"operationname": `Name of the operation to run if the query document has multiple operations`,
+}
+
+var GraphQLResponseComments = map[string]string{
+	"errors:#.message": `Error message`,
+}
+
+var GraphQLErrorComments = map[string]string{
+	"message": `Error message`,
 }
 
 var ReporterComments = map[string]string{
@@ -353,6 +595,7 @@ var ReporterComments = map[string]string{
 	"nextscheduledate":  `Date when the next report is scheduled to be generated (for internal use only)`,
 	"username":          `User name (for internal use only)`,
 	"timezone":          `Timezone in which to show the reports, defaults to UTC`,
+	"format":            `Output format of the generated reports, one of pdf, csv, json (Default: pdf). CSV reports are a zip bundle of CSV files`,
 	"status":            `Last report status`,
 }
 
@@ -368,6 +611,7 @@ var GenerateReportComments = map[string]string{
 	"endtime":   `Absolute time (in RFC3339 format with intended timezone) to end report capture`,
 	"region":    `Region name (for internal use only)`,
 	"timezone":  `Timezone in which to show the reports, defaults to UTC`,
+	"format":    `Output format of the report, one of pdf, csv, json (Default: pdf). CSV reports are a zip bundle of CSV files`,
 }
 
 var FederatorComments = map[string]string{
@@ -19,6 +19,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/mobiledgex/edge-cloud/edgeproto"
 )
 
@@ -34,7 +35,7 @@ type User struct {
 	// read only: true
 	EmailVerified bool
 	// read only: true
-	Passhash string `gorm:"not null"`
+	Passhash string `gorm:"not null" audit:"redact"`
 	// read only: true
 	Salt string `gorm:"not null"`
 	// read only: true
@@ -59,7 +60,7 @@ type User struct {
 	// Enable or disable temporary one-time passwords for the account
 	EnableTOTP bool
 	// read only: true
-	TOTPSharedKey string
+	TOTPSharedKey string `audit:"redact"`
 	// Metadata
 	Metadata string
 	// Last successful login time
@@ -70,16 +71,26 @@ type User struct {
 	LastFailedLogin time.Time `json:",omitempty"`
 	// Number of failed login attempts since last successful login
 	FailedLogins int
+	// Identity provider the user logs in with via single sign-on, blank for local accounts
+	// read only: true
+	IdentityProvider string `json:",omitempty"`
 }
 
 type CreateUserApiKey struct {
 	UserApiKey `json:",inline"`
 	// API key
-	ApiKey string
+	ApiKey string `audit:"redact"`
 	// List of API key permissions
 	Permissions []RolePerm `json:"permissions"`
 }
 
+type StaleApiKeyFilter struct {
+	// Report API keys not used to log in for this long (in format 2h30m10s), defaults to the ApiKeyStaleDuration config
+	UnusedDuration edgeproto.Duration `json:"unusedduration,omitempty"`
+	// Only report API keys of this user
+	Username string `json:"username,omitempty"`
+}
+
 type UserApiKey struct {
 	// API key ID used as an identifier for API keys
 	// read only: true
@@ -93,11 +104,29 @@ type UserApiKey struct {
 	// read only: true
 	Username string
 	// read only: true
-	ApiKeyHash string `gorm:"not null"`
+	ApiKeyHash string `gorm:"not null" audit:"redact"`
 	// read only: true
 	Salt string `gorm:"not null"`
 	// read only: true
 	Iter int `gorm:"not null"`
+	// Time after which the API key can no longer be used to log in, blank for no expiry
+	ExpiresAt time.Time `json:",omitempty"`
+	// Time the API key was last used to log in
+	// read only: true
+	LastUsed time.Time `json:",omitempty"`
+	// IP address the API key was last used to log in from
+	// read only: true
+	LastUsedIP string `json:",omitempty"`
+	// Hash of the previous API key after rotation
+	// read only: true
+	PrevApiKeyHash string `json:",omitempty" audit:"redact"`
+	// read only: true
+	PrevSalt string `json:",omitempty"`
+	// read only: true
+	PrevIter int `json:",omitempty"`
+	// Time until which the previous API key can still be used after rotation
+	// read only: true
+	PrevExpiresAt time.Time `json:",omitempty"`
 	// read only: true
 	CreatedAt time.Time `json:",omitempty"`
 	// read only: true
@@ -106,8 +135,8 @@ type UserApiKey struct {
 
 type UserResponse struct {
 	Message       string
-	TOTPSharedKey string
-	TOTPQRImage   []byte
+	TOTPSharedKey string `audit:"redact"`
+	TOTPQRImage   []byte `audit:"redact"`
 }
 
 type Organization struct {
@@ -135,6 +164,70 @@ type Organization struct {
 	// Edgebox only operator organization
 	// read only: true
 	EdgeboxOnly bool `json:",omitempty"`
+	// Organization is suspended, mutating controller APIs are blocked
+	// read only: true
+	Suspended bool `json:",omitempty"`
+	// Reason the organization was suspended
+	// read only: true
+	SuspendReason string `json:",omitempty"`
+}
+
+type OrgSuspend struct {
+	// Organization name
+	// required: true
+	Name string `json:"name"`
+	// Reason for suspending the organization
+	Reason string `json:"reason,omitempty"`
+	// Power off the organization's AppInsts when suspending
+	PowerOffAppInsts bool `json:"poweroffappinsts,omitempty"`
+	// Power on the organization's AppInsts when resuming
+	PowerOnAppInsts bool `json:"poweronappinsts,omitempty"`
+}
+
+type OrgQuota struct {
+	// Organization name
+	// required: true
+	Org string `gorm:"primary_key;type:citext"`
+	// Region the quota applies to, blank for the total across all regions
+	Region string `gorm:"primary_key" json:",omitempty"`
+	// Maximum number of vCPUs, 0 for no limit
+	MaxVcpus uint64 `json:",omitempty"`
+	// Maximum RAM in MB, 0 for no limit
+	MaxRam uint64 `json:",omitempty"`
+	// Maximum number of GPUs, 0 for no limit
+	MaxGpus uint64 `json:",omitempty"`
+	// Maximum number of AppInsts, 0 for no limit
+	MaxAppInsts uint64 `json:",omitempty"`
+	// Maximum number of ClusterInsts, 0 for no limit
+	MaxClusterInsts uint64 `json:",omitempty"`
+	// read only: true
+	CreatedAt time.Time `json:",omitempty"`
+	// read only: true
+	UpdatedAt time.Time `json:",omitempty"`
+}
+
+type OrgResourceUsage struct {
+	// Number of vCPUs in use
+	Vcpus uint64
+	// RAM in use in MB
+	Ram uint64
+	// Number of GPUs in use
+	Gpus uint64
+	// Number of AppInsts
+	AppInsts uint64
+	// Number of ClusterInsts
+	ClusterInsts uint64
+}
+
+type OrgQuotaUsage struct {
+	// Organization name
+	Org string
+	// Region of the usage, blank for the total across all regions
+	Region string `json:",omitempty"`
+	// Quota for the organization and region, if any
+	Quota *OrgQuota `json:",omitempty"`
+	// Current resource usage
+	Usage OrgResourceUsage
 }
 
 type InvoiceRequest struct {
@@ -260,6 +353,14 @@ type Config struct {
 	ApiKeyLoginTokenValidDuration edgeproto.Duration
 	// Websocket auth token valid duration (in format 2h30m10s, default 2m)
 	WebsocketTokenValidDuration edgeproto.Duration
+	// How long before a role assignment expires to warn the user and org managers (in format 2h30m10s, default 72h)
+	RoleExpiryWarningDuration edgeproto.Duration
+	// How long the previous API key remains valid after rotating an API key (in format 2h30m10s, default 24h)
+	ApiKeyRotateGracePeriod edgeproto.Duration
+	// API keys not used for this long are reported as stale (in format 2h30m10s, default 2160h)
+	ApiKeyStaleDuration edgeproto.Duration
+	// How long audit logs are kept, older audit logs are deleted (in format 2h30m10s, default 2160h)
+	AuditLogRetention edgeproto.Duration
 }
 
 type McRateLimitFlowSettings struct {
@@ -348,6 +449,74 @@ type Role struct {
 	Username string `form:"username" json:"username"`
 	// Role which defines the set of permissions
 	Role string `form:"role" json:"role"`
+	// Time when the role assignment expires and is removed automatically, optional
+	ExpiresAt time.Time `form:"expiresat" json:"expiresat,omitempty"`
+}
+
+// Expiration of a role assignment (for internal use only)
+type RoleExpiration struct {
+	// Organization name
+	Org string `gorm:"primary_key;type:citext"`
+	// User name
+	Username string `gorm:"primary_key;type:citext"`
+	// Role name
+	Role string `gorm:"primary_key"`
+	// Time when the role assignment expires
+	ExpiresAt time.Time
+	// Whether the expiry warning has been sent
+	WarningSent bool
+}
+
+type CustomRole struct {
+	// Custom role name. Can only contain letters, digits, period, hyphen. It cannot have leading or trailing spaces or period. It cannot start with hyphen
+	// required: true
+	Name string `gorm:"primary_key;type:citext"`
+	// Type of organization the role can be assigned for, one of developer, operator
+	// required: true
+	OrgType string `gorm:"not null"`
+	// Description of the role
+	Description string
+	// User who created the role
+	// read only: true
+	CreatedBy string
+	// read only: true
+	CreatedAt time.Time `json:",omitempty"`
+	// read only: true
+	UpdatedAt time.Time `json:",omitempty"`
+	// List of resource and action permissions of the role
+	Permissions []RolePerm `gorm:"-" json:"permissions"`
+}
+
+// Invitation for a user to join an organization with a role
+type OrgInvitation struct {
+	// Invitation ID
+	// read only: true
+	Id string `gorm:"primary_key" json:"id,omitempty"`
+	// Organization name
+	// required: true
+	Org string `gorm:"type:citext REFERENCES organizations(name);not null" json:"org"`
+	// Email address of the user being invited
+	// required: true
+	Email string `gorm:"type:citext;not null" json:"email"`
+	// Role given to the user when the invitation is accepted
+	// required: true
+	Role string `gorm:"not null" json:"role"`
+	// User who sent the invitation
+	// read only: true
+	InvitedBy string `json:"invitedby,omitempty"`
+	// read only: true
+	CreatedAt time.Time `json:"createdat,omitempty"`
+	// Time after which the invitation can no longer be accepted, defaults to 7 days
+	ExpiresAt time.Time `json:"expiresat,omitempty"`
+}
+
+type OrgInvitationResponse struct {
+	// Result message
+	Message string `json:"message"`
+	// Invitation ID
+	Id string `json:"id"`
+	// Invitation token, only returned if emails are not sent because email verification is skipped
+	Token string `json:"token,omitempty" audit:"redact"`
 }
 
 type OrgCloudlet struct {
@@ -371,37 +540,107 @@ type UserLogin struct {
 	Username string `form:"username" json:"username"`
 	// User's password
 	// required: true
-	Password string `form:"password" json:"password"`
+	Password string `form:"password" json:"password" audit:"redact"`
 	// Temporary one-time password if 2-factor authentication is enabled
-	TOTP string `form:"totp" json:"totp"`
+	TOTP string `form:"totp" json:"totp" audit:"redact"`
 	// API key ID if logging in using API key
 	ApiKeyId string `form:"apikeyid" json:"apikeyid"`
 	// API key if logging in using API key
-	ApiKey string `form:"apikey" json:"apikey"`
+	ApiKey string `form:"apikey" json:"apikey" audit:"redact"`
+	// Organization invitation token to accept on login
+	InviteToken string `form:"invitetoken" json:"invitetoken,omitempty" audit:"redact"`
+}
+
+// OpenID Connect identity provider for single sign-on logins
+type IdentityProvider struct {
+	// Identity provider name. Can only contain letters, digits, underscore, period, hyphen. It cannot have leading or trailing spaces or period. It cannot start with hyphen
+	// required: true
+	Name string `gorm:"primary_key;type:citext"`
+	// Issuer URL, the provider endpoints are discovered from its /.well-known/openid-configuration
+	// required: true
+	Issuer string `gorm:"not null"`
+	// OAuth2 client ID registered with the identity provider
+	// required: true
+	ClientId string `gorm:"not null"`
+	// OAuth2 client secret registered with the identity provider, stored in Vault
+	ClientSecret string `gorm:"-" json:",omitempty" audit:"redact"`
+	// Additional scopes to request, openid, email and profile are always requested
+	Scopes pq.StringArray `gorm:"type:text[]"`
+	// ID token claim used as the user name (Default: preferred_username)
+	UsernameClaim string
+	// ID token claim listing the user's groups (Default: groups)
+	GroupsClaim string
+	// Create users on their first single sign-on login
+	CreateUsers bool
+	// Mappings of identity provider groups onto organization roles. Roles are added and removed on each login to match the user's groups
+	GroupMappings []IdpGroupMapping `gorm:"-"`
+	// read only: true
+	CreatedAt time.Time `json:",omitempty"`
+	// read only: true
+	UpdatedAt time.Time `json:",omitempty"`
+}
+
+// Mapping of an identity provider group onto an organization role
+type IdpGroupMapping struct {
+	// Identity provider name
+	// read only: true
+	Idp string `gorm:"primary_key;type:citext"`
+	// Group name in the groups claim
+	GroupName string `gorm:"primary_key"`
+	// Organization name
+	Org string `gorm:"primary_key;type:citext"`
+	// Role to assign for the organization
+	Role string `gorm:"not null"`
+}
+
+// Pending single sign-on login, used once by the SSO callback (for internal use only)
+type SSOLoginState struct {
+	// Nonce of the login, also set in the browser's SSO cookie
+	Nonce string `gorm:"primary_key"`
+	// Identity provider name
+	Idp string `gorm:"type:citext"`
+	// Time after which the login can no longer be completed
+	ExpiresAt time.Time
 }
 
 type NewPassword struct {
 	// User's current password
 	// required: true
-	CurrentPassword string `form:"password" json:"currentpassword"`
+	CurrentPassword string `form:"password" json:"currentpassword" audit:"redact"`
 	// User's new password
 	// required: true
-	Password string `form:"password" json:"password"`
+	Password string `form:"password" json:"password" audit:"redact"`
 }
 
 type CreateUser struct {
 	User `json:",inline"`
 	// Client information to include in verification email request, used mainly by Web UI client
 	Verify EmailRequest `json:"verify"` // for verifying email
+	// Organization invitation token to accept once the user is created
+	InviteToken string `json:"invitetoken,omitempty" audit:"redact"`
 }
 
 type AuditQuery struct {
-	Username            string `json:"username"`
-	Org                 string `form:"org" json:"org"`
-	Limit               int    `json:"limit"`
+	// User that ran the operation
+	Username string `json:"username"`
+	// Organization the operation was run against
+	Org string `form:"org" json:"org"`
+	// Number of results to return, either to limit or for paging results
+	Limit int `json:"limit"`
+	// Start offset if paging through results
+	From int `json:"from,omitempty"`
+	// Time range of the query, limited by the audit log retention
 	edgeproto.TimeRange `json:",inline"`
-	Operation           string            `json:"operation"`
-	Tags                map[string]string `json:"tags"`
+	// Operation (API path) that was run, i.e. /api/v1/auth/ctrl/CreateApp
+	Operation string `json:"operation"`
+	// HTTP status code of the operation
+	Status int `json:"status,omitempty"`
+	// IP address of the client that ran the operation
+	ClientIP string `json:"clientip,omitempty"`
+	// Additional audit tags to match
+	Tags map[string]string `json:"tags"`
+	// Export format, either json or csv (default json), export only
+	Format string `json:"format,omitempty"`
 }
 
 type AuditResponse struct {
@@ -430,15 +669,15 @@ type EmailRequest struct {
 type PasswordReset struct {
 	// Authentication token
 	// required: true
-	Token string `form:"token" json:"token"`
+	Token string `form:"token" json:"token" audit:"redact"`
 	// User's new password
 	// required: true
-	Password string `form:"password" json:"password"`
+	Password string `form:"password" json:"password" audit:"redact"`
 }
 
 type Token struct {
 	// Authentication token
-	Token string `form:"token" json:"token"`
+	Token string `form:"token" json:"token" audit:"redact"`
 }
 
 // Structs used in replies
@@ -458,16 +697,42 @@ type Version struct {
 // Data struct sent back for streaming (chunked) commands.
 // Contains a data payload for incremental data, and a result
 // payload for an error result. Only one of the two will be used
-// in each chunk.
+// in each chunk. For paged show commands, the last chunk may
+// instead contain the continuation token for the next page.
 
 type StreamPayload struct {
-	Data   interface{} `json:"data,omitempty"`
-	Result *Result     `json:"result,omitempty"`
+	Data              interface{} `json:"data,omitempty"`
+	Result            *Result     `json:"result,omitempty"`
+	ContinuationToken string      `json:"continuationtoken,omitempty"`
 }
 
 type WSStreamPayload struct {
-	Code int         `json:"code"`
-	Data interface{} `json:"data"`
+	Code              int         `json:"code"`
+	Data              interface{} `json:"data"`
+	ContinuationToken string      `json:"continuationtoken,omitempty"`
+}
+
+// Paging keys that may be added to the filter of show commands.
+// Results are ordered by key. If the limit is reached, the reply
+// includes a continuation token, which is passed back in the next
+// request to get the next page. Streamed replies send the token in
+// the last chunk, other replies send it in the ContinuationTokenHeader.
+const (
+	ShowPageLimitKey        = "limit"
+	ShowPageTokenKey        = "continuationtoken"
+	ContinuationTokenHeader = "X-Continuation-Token"
+)
+
+// Streamed controller show APIs that never finish, so cannot be paged.
+var UnpagedShowStreams = map[string]struct{}{
+	"ShowAppInstClient": struct{}{},
+}
+
+type ShowPage struct {
+	// Max number of objects to return, 0 for all
+	Limit int `json:"limit,omitempty"`
+	// Token from the previous page to get the next page
+	ContinuationToken string `json:"continuationtoken,omitempty"`
 }
 
 // RegionObj interface is for all protobuf-defined objects that
@@ -504,6 +769,25 @@ type RegionData struct {
 	AppData edgeproto.AllData `json:"appdata,omitempty"`
 }
 
+// Change to a regional object computed by diffing a declarative
+// AllData manifest against the current state.
+type DataChange struct {
+	// Region name
+	Region string `json:"region"`
+	// Object type, one of Flavor, ClusterInst, App or AppInst
+	Type string `json:"type"`
+	// Object key
+	Key string `json:"key"`
+	// Action, one of create, update or delete
+	Action string `json:"action"`
+	// Fields to update
+	Fields []string `json:"fields,omitempty"`
+	// Action was run successfully
+	Done bool `json:"done,omitempty"`
+	// Error if the action failed
+	Error string `json:"error,omitempty"`
+}
+
 type MetricsCommon struct {
 	edgeproto.TimeRange `json:",inline"`
 	// Display X samples spaced out evenly over start and end times
@@ -714,7 +998,7 @@ type RegionCloudletPoolUsageRegister struct {
 type AlertReceiver struct {
 	// Receiver Name
 	Name string
-	// Receiver type. Eg. email, slack, pagerduty
+	// Receiver type. Eg. email, slack, pagerduty, webhook, teams
 	Type string
 	// Alert severity filter
 	Severity string
@@ -727,17 +1011,231 @@ type AlertReceiver struct {
 	// Custom slack channel
 	SlackChannel string `json:",omitempty"`
 	// Custom slack webhook
-	SlackWebhook string `json:",omitempty"`
+	SlackWebhook string `json:",omitempty" audit:"redact"`
 	// PagerDuty integration key
-	PagerDutyIntegrationKey string `json:",omitempty"`
+	PagerDutyIntegrationKey string `json:",omitempty" audit:"redact"`
 	// PagerDuty API version
 	PagerDutyApiVersion string `json:",omitempty"`
+	// Generic webhook URL
+	WebhookUrl string `json:",omitempty"`
+	// Generic webhook basic auth username
+	WebhookUser string `json:",omitempty"`
+	// Generic webhook basic auth password, stored in Vault
+	WebhookPassword string `json:",omitempty" audit:"redact"`
+	// Generic webhook bearer token, stored in Vault
+	WebhookBearerToken string `json:",omitempty" audit:"redact"`
+	// Custom HTTP headers sent with each webhook request
+	WebhookHeaders map[string]string `json:",omitempty" audit:"redact"`
+	// Microsoft Teams incoming webhook URL
+	TeamsWebhook string `json:",omitempty" audit:"redact"`
 	// Cloudlet spec for alerts
 	Cloudlet edgeproto.CloudletKey `json:",omitempty"`
 	// AppInst spec for alerts
 	AppInst edgeproto.AppInstKey `json:",omitempty"`
 }
 
+// AlertManager Silence for a set of alerts
+type AlertSilence struct {
+	// Silence ID assigned by AlertManager
+	Id string `json:",omitempty"`
+	// Region for the silenced alerts
+	Region string `json:",omitempty"`
+	// Name of the alert to silence, all alerts if not specified
+	AlertName string `json:",omitempty"`
+	// Alert severity to silence, all severities if not specified
+	Severity string `json:",omitempty"`
+	// Cloudlet spec for silenced alerts
+	Cloudlet edgeproto.CloudletKey `json:",omitempty"`
+	// AppInst spec for silenced alerts
+	AppInst edgeproto.AppInstKey `json:",omitempty"`
+	// Absolute time (in RFC3339 format with intended timezone) when the silence starts (Default: now)
+	StartsAt time.Time `json:",omitempty"`
+	// Absolute time (in RFC3339 format with intended timezone) when the silence ends
+	EndsAt time.Time `json:",omitempty"`
+	// Reason for silencing the alerts
+	Comment string `json:",omitempty"`
+	// User that created this silence
+	// read only: true
+	CreatedBy string `json:",omitempty"`
+	// Silence state: active, pending or expired
+	// read only: true
+	State string `json:",omitempty"`
+}
+
+// Maintenance window for a cloudlet or cloudlet pool. While the window
+// is open, alerts for the affected cloudlets are silenced and auto-provisioning
+// and auto-scaling actions on them are suspended.
+type MaintenanceWindow struct {
+	// Maintenance window name. Can only contain letters, digits, period, hyphen. It cannot have leading or trailing spaces or period. It cannot start with hyphen
+	// required: true
+	Name string `gorm:"primary_key;type:citext"`
+	// Operator organization of the cloudlet or cloudlet pool
+	// required: true
+	Org string `gorm:"primary_key;type:citext REFERENCES organizations(name)"`
+	// Region name
+	// required: true
+	Region string `gorm:"type:text REFERENCES controllers(region)"`
+	// Cloudlet under maintenance, one of cloudlet or cloudlet pool must be specified
+	Cloudlet string `json:",omitempty"`
+	// Cloudlet pool whose cloudlets are under maintenance
+	CloudletPool string `json:",omitempty"`
+	// Absolute time (in RFC3339 format with intended timezone) when the window opens
+	// required: true
+	StartTime time.Time `json:",omitempty"`
+	// Absolute time (in RFC3339 format with intended timezone) when the window closes
+	// required: true
+	EndTime time.Time `json:",omitempty"`
+	// How often the window repeats, one of None, Daily, Weekly, Monthly (Default: None)
+	Recurrence string `json:",omitempty"`
+	// Description of the maintenance work
+	Comment string `json:",omitempty"`
+	// User name (for internal use only)
+	// read only: true
+	Username string `json:",omitempty"`
+	// Window state: Scheduled, Open or Closed
+	// read only: true
+	State string `json:",omitempty"`
+	// Alert silences created for the open window
+	// read only: true
+	SilenceIds pq.StringArray `gorm:"type:text[]" json:",omitempty"`
+}
+
+// Notification preferences for the current user
+type NotificationPreferences struct {
+	// User name (for internal use only)
+	// read only: true
+	Username string `gorm:"primary_key;type:citext"`
+	// Event categories to notify about, any of roles, cloudletpools, appinstfailures (Default: roles, cloudletpools). Failed AppInst creates are only reported in the daily digest
+	Categories pq.StringArray `gorm:"type:text[]"`
+	// How notifications are delivered, one of immediate, digest, none (Default: immediate)
+	Delivery string
+	// Hour of the day (0-23) when quiet hours start. Immediate notifications during quiet hours are held until quiet hours end. Quiet hours are disabled if start and end are the same
+	QuietHoursStart int
+	// Hour of the day (0-23) when quiet hours end
+	QuietHoursEnd int
+	// Hour of the day (0-23) when the daily digest email is sent (Default: 8)
+	DigestHour int
+	// Timezone for quiet hours and digest hour, defaults to UTC
+	Timezone string
+	// Time the last digest email was sent
+	// read only: true
+	LastDigest time.Time `json:",omitempty"`
+}
+
+// Notification held for a later digest email (for internal use only)
+type PendingNotification struct {
+	// Notification ID
+	Id string `gorm:"primary_key"`
+	// User to notify
+	Username string `gorm:"type:citext;not null"`
+	// Event category
+	Category string
+	// Organization the event applies to
+	Org string
+	// Notification message
+	Message string
+	// Time the event occurred
+	CreatedAt time.Time
+}
+
+// Webhook subscription to events of an organization. Matching events
+// are posted to the URL as they occur.
+type EventWebhook struct {
+	// Webhook name. Can only contain letters, digits, period, hyphen. It cannot have leading or trailing spaces or period. It cannot start with hyphen
+	// required: true
+	Name string `gorm:"primary_key;type:citext"`
+	// Organization whose events are delivered
+	// required: true
+	Org string `gorm:"primary_key;type:citext REFERENCES organizations(name)"`
+	// URL that events are posted to
+	// required: true
+	Url string `json:",omitempty"`
+	// Shared secret used to sign deliveries. The hex encoded HMAC-SHA256 of the request body is sent in the X-MC-Signature header
+	Secret string `gorm:"-" json:",omitempty" audit:"redact"`
+	// Only deliver events with one of these names
+	Names pq.StringArray `gorm:"type:text[]" json:",omitempty"`
+	// Only deliver events of these types, event or audit
+	Types pq.StringArray `gorm:"type:text[]" json:",omitempty"`
+	// Only deliver events from these regions
+	Regions pq.StringArray `gorm:"type:text[]" json:",omitempty"`
+	// Only deliver events with all of these tags, specified as key=value
+	Tags pq.StringArray `gorm:"type:text[]" json:",omitempty"`
+	// Only deliver failed events
+	Failed bool `json:",omitempty"`
+	// Stop delivering events without deleting the webhook
+	Disabled bool `json:",omitempty"`
+	// User name (for internal use only)
+	// read only: true
+	Username string `json:",omitempty"`
+	// Time up to which events have been queued for delivery
+	// read only: true
+	LastEventTime time.Time `json:",omitempty"`
+}
+
+// Delivery of an event to a webhook
+type EventWebhookDelivery struct {
+	// Delivery ID
+	Id string `gorm:"primary_key" json:",omitempty"`
+	// Webhook name
+	Webhook string `gorm:"type:citext;not null" json:",omitempty"`
+	// Webhook organization
+	Org string `gorm:"type:citext;not null" json:",omitempty"`
+	// Event name
+	// read only: true
+	EventName string `json:",omitempty"`
+	// Time the event occurred
+	// read only: true
+	EventTime time.Time `json:",omitempty"`
+	// Delivery state, one of Pending, Delivered, Failed. Failed deliveries have exhausted all retries and can be retried manually
+	State string `json:",omitempty"`
+	// Number of delivery attempts
+	// read only: true
+	Attempts int `json:",omitempty"`
+	// HTTP status code of the last attempt
+	// read only: true
+	LastStatus int `json:",omitempty"`
+	// Error of the last attempt
+	// read only: true
+	LastError string `json:",omitempty"`
+	// Time of the next delivery attempt
+	// read only: true
+	NextAttempt time.Time `json:",omitempty"`
+	// Time the delivery was queued
+	// read only: true
+	CreatedAt time.Time `json:",omitempty"`
+	// Time the event was delivered
+	// read only: true
+	DeliveredAt time.Time `json:",omitempty"`
+	// Delivered JSON payload
+	// read only: true
+	Payload string `json:",omitempty"`
+}
+
+// GraphQL query over MC and regional data
+type GraphQLQuery struct {
+	// GraphQL query document, only query operations are allowed
+	// required: true
+	Query string `json:"query"`
+	// Name of the operation to run if the query document has multiple operations
+	OperationName string `json:"operationName,omitempty"`
+	// Values of the query variables
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+type GraphQLResponse struct {
+	// Query result
+	Data interface{} `json:"data"`
+	// Errors from the query, data for fields that failed is null
+	Errors []GraphQLError `json:"errors,omitempty"`
+}
+
+type GraphQLError struct {
+	// Error message
+	Message string `json:"message"`
+	// Path of the field that failed in the response data
+	Path []interface{} `json:"path,omitempty"`
+}
+
 // Reporter to generate period reports
 type Reporter struct {
 	// Reporter name. Can only contain letters, digits, period, hyphen. It cannot have leading or trailing spaces or period. It cannot start with hyphen
@@ -760,6 +1258,8 @@ type Reporter struct {
 	Username string
 	// Timezone in which to show the reports, defaults to UTC
 	Timezone string
+	// Output format of the generated reports, one of pdf, csv, json (Default: pdf). CSV reports are a zip bundle of CSV files
+	Format string `json:",omitempty"`
 	// Last report status
 	// read only: true
 	Status string
@@ -790,18 +1290,36 @@ type GenerateReport struct {
 	Region string
 	// Timezone in which to show the reports, defaults to UTC
 	Timezone string
+	// Output format of the report, one of pdf, csv, json (Default: pdf). CSV reports are a zip bundle of CSV files
+	Format string `json:",omitempty"`
+}
+
+const (
+	ReportFormatPDF  = "pdf"
+	ReportFormatCSV  = "csv"
+	ReportFormatJSON = "json"
+)
+
+func GetReportFileExt(format string) string {
+	switch format {
+	case ReportFormatCSV:
+		return ".zip"
+	case ReportFormatJSON:
+		return ".json"
+	}
+	return ".pdf"
 }
 
 func GetReporterFileName(reporterName string, report *GenerateReport) string {
 	startDate := report.StartTime.Format(TimeFormatDateName) // YYYYMMDD
 	endDate := report.EndTime.Format(TimeFormatDateName)
-	return report.Org + "/" + reporterName + "/" + startDate + "_" + endDate + ".pdf"
+	return report.Org + "/" + reporterName + "/" + startDate + "_" + endDate + GetReportFileExt(report.Format)
 }
 
 func GetReportFileName(report *GenerateReport) string {
 	startDate := report.StartTime.Format(TimeFormatDateName) // YYYYMMDD
 	endDate := report.EndTime.Format(TimeFormatDateName)
-	return report.Org + "_" + startDate + "_" + endDate + ".pdf"
+	return report.Org + "_" + startDate + "_" + endDate + GetReportFileExt(report.Format)
 }
 
 func GetInfoFromReportFileName(fileName string) (string, string) {
@@ -16,6 +16,7 @@ package ormapi
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -33,3 +34,17 @@ func TestReportFileName(t *testing.T) {
 		require.Equal(t, reporterName, out[1])
 	}
 }
+
+func TestReportFileFormat(t *testing.T) {
+	report := GenerateReport{
+		Org:       "GDDT",
+		StartTime: time.Date(2021, time.April, 20, 0, 0, 0, 0, time.UTC),
+		EndTime:   time.Date(2021, time.May, 3, 0, 0, 0, 0, time.UTC),
+	}
+	require.Equal(t, "GDDT/GDDTReporter/20210420_20210503.pdf", GetReporterFileName("GDDTReporter", &report))
+	report.Format = ReportFormatCSV
+	require.Equal(t, "GDDT/GDDTReporter/20210420_20210503.zip", GetReporterFileName("GDDTReporter", &report))
+	require.Equal(t, "GDDT_20210420_20210503.zip", GetReportFileName(&report))
+	report.Format = ReportFormatJSON
+	require.Equal(t, "GDDT_20210420_20210503.json", GetReportFileName(&report))
+}
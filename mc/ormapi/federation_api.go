@@ -40,9 +40,9 @@ type Federator struct {
 	// read only: true
 	Revision string `json:"revision"`
 	// API Key used for authentication (stored in secure storage)
-	ApiKey string `json:"apikey"`
+	ApiKey string `json:"apikey" audit:"redact"`
 	// read only: true
-	ApiKeyHash string `gorm:"not null"`
+	ApiKeyHash string `gorm:"not null" audit:"redact"`
 	// read only: true
 	Salt string `gorm:"not null"`
 	// read only: true
@@ -0,0 +1,57 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ormapi
+
+import (
+	"reflect"
+
+	"github.com/mobiledgex/edge-cloud/edgeproto"
+)
+
+// Fields that hold secrets are tagged with `audit:"redact"`.
+// Their values are removed from request and response data before
+// it is written to the audit log.
+const (
+	AuditTag       = "audit"
+	AuditTagRedact = "redact"
+)
+
+// Types that have fields tagged for redaction. Any new type with
+// redacted fields must be added here.
+var AuditRedactTypes = []interface{}{
+	User{},
+	CreateUser{},
+	CreateUserApiKey{},
+	UserApiKey{},
+	UserResponse{},
+	UserLogin{},
+	IdentityProvider{},
+	NewPassword{},
+	PasswordReset{},
+	Token{},
+	OrgInvitationResponse{},
+	AlertReceiver{},
+	EventWebhook{},
+	Federator{},
+	Federation{},
+}
+
+// Fields of types from other packages that hold secrets. These
+// cannot be tagged, so they are listed here by field name instead.
+var AuditRedactExternalFields = map[reflect.Type][]string{
+	reflect.TypeOf(edgeproto.Cloudlet{}):       []string{"AccessVars"},
+	reflect.TypeOf(edgeproto.GPUDriver{}):      []string{"LicenseConfig"},
+	reflect.TypeOf(edgeproto.GPUDriverBuild{}): []string{"DriverPathCreds"},
+}
@@ -182,16 +182,45 @@ func (s *Client) HttpJsonSendReq(method, uri, token string, reqData interface{})
 	return client.Do(req)
 }
 
+// HttpJsonSend sends the request and decodes the reply. If the reply
+// is a page of a paged show command, the remaining pages are fetched
+// and appended to the reply.
 func (s *Client) HttpJsonSend(method, uri, token string, reqData interface{}, replyData interface{}) (int, error) {
+	pageData := replyData
+	for {
+		status, nextToken, err := s.httpJsonSendPage(method, uri, token, reqData, pageData)
+		if err != nil {
+			return status, err
+		}
+		listV := reflect.ValueOf(replyData)
+		if pageData != replyData {
+			// append page to previous pages
+			listV.Elem().Set(reflect.AppendSlice(listV.Elem(), reflect.ValueOf(pageData).Elem()))
+		}
+		if nextToken == "" {
+			return status, nil
+		}
+		if listV.Kind() != reflect.Ptr || listV.Elem().Kind() != reflect.Slice {
+			return status, fmt.Errorf("%s %s paged reply must be a pointer to a slice, not %T", method, uri, replyData)
+		}
+		pageData = reflect.New(listV.Elem().Type()).Interface()
+		reqData, err = setContinuationToken(reqData, nextToken)
+		if err != nil {
+			return status, fmt.Errorf("%s %s %v", method, uri, err)
+		}
+	}
+}
+
+func (s *Client) httpJsonSendPage(method, uri, token string, reqData interface{}, replyData interface{}) (int, string, error) {
 	resp, err := s.HttpJsonSendReq(method, uri, token, reqData)
 	if err != nil {
-		return 0, fmt.Errorf("%s %s client do failed, %s", method, uri, err.Error())
+		return 0, "", fmt.Errorf("%s %s client do failed, %s", method, uri, err.Error())
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode == http.StatusOK && replyData != nil {
 		err = json.NewDecoder(resp.Body).Decode(replyData)
 		if err != nil && err != io.EOF {
-			return resp.StatusCode, fmt.Errorf("%s %s decode resp failed, %v", method, uri, err)
+			return resp.StatusCode, "", fmt.Errorf("%s %s decode resp failed, %v", method, uri, err)
 		}
 	}
 	if resp.StatusCode != http.StatusOK {
@@ -203,11 +232,37 @@ func (s *Client) HttpJsonSend(method, uri, token string, reqData interface{}, re
 		err = json.Unmarshal(body, &res)
 		if err != nil {
 			// string error
-			return resp.StatusCode, fmt.Errorf("%s", body)
+			return resp.StatusCode, "", fmt.Errorf("%s", body)
+		}
+		return resp.StatusCode, "", errors.New(res.Message)
+	}
+	return resp.StatusCode, resp.Header.Get(ormapi.ContinuationTokenHeader), nil
+}
+
+// Set the continuation token in the request data to get the
+// next page of a paged show command.
+func setContinuationToken(reqData interface{}, token string) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+	if reqData != nil {
+		var dat []byte
+		if str, ok := reqData.(string); ok {
+			dat = []byte(str)
+		} else {
+			out, err := json.Marshal(reqData)
+			if err != nil {
+				return nil, fmt.Errorf("marshal req failed, %s", err.Error())
+			}
+			dat = out
+		}
+		// keep numbers as is to avoid float conversions
+		dec := json.NewDecoder(bytes.NewReader(dat))
+		dec.UseNumber()
+		if err := dec.Decode(&data); err != nil {
+			return nil, fmt.Errorf("paged request data must be a JSON object, %s", err.Error())
 		}
-		return resp.StatusCode, errors.New(res.Message)
 	}
-	return resp.StatusCode, nil
+	data[ormapi.ShowPageTokenKey] = token
+	return data, nil
 }
 
 func (s *Client) PostJsonStreamOut(uri, token string, reqData, replyData interface{}, replyReady func()) (int, error) {
@@ -218,25 +273,39 @@ func (s *Client) PostJsonStreamOut(uri, token string, reqData, replyData interfa
 	}
 }
 
+// Paged show streams are followed until the last page.
 func (s *Client) handleHttpStreamOut(uri, token string, reqData, replyData interface{}, replyReady func()) (int, error) {
+	for {
+		status, nextToken, err := s.handleHttpStreamOutPage(uri, token, reqData, replyData, replyReady)
+		if err != nil || nextToken == "" {
+			return status, err
+		}
+		reqData, err = setContinuationToken(reqData, nextToken)
+		if err != nil {
+			return status, fmt.Errorf("post %s %v", uri, err)
+		}
+	}
+}
+
+func (s *Client) handleHttpStreamOutPage(uri, token string, reqData, replyData interface{}, replyReady func()) (int, string, error) {
 	resp, err := s.PostJsonSend(uri, token, reqData)
 	if err != nil {
-		return 0, fmt.Errorf("post %s client do failed, %s", uri, err.Error())
+		return 0, "", fmt.Errorf("post %s client do failed, %s", uri, err.Error())
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
-			return resp.StatusCode, err
+			return resp.StatusCode, "", err
 		}
 		res := ormapi.Result{}
 		err = json.Unmarshal(body, &res)
 		if err != nil {
 			// string error
-			return resp.StatusCode, fmt.Errorf("%s", body)
+			return resp.StatusCode, "", fmt.Errorf("%s", body)
 		}
-		return resp.StatusCode, errors.New(res.Message)
+		return resp.StatusCode, "", errors.New(res.Message)
 	}
 	if ch, ok := s.MidstreamFailChs[uri]; ok {
 		ch <- true
@@ -246,6 +315,7 @@ func (s *Client) handleHttpStreamOut(uri, token string, reqData, replyData inter
 		payload.Data = replyData
 	}
 
+	nextToken := ""
 	dec := json.NewDecoder(resp.Body)
 	for {
 		if replyData != nil {
@@ -255,21 +325,27 @@ func (s *Client) handleHttpStreamOut(uri, token string, reqData, replyData inter
 		}
 
 		payload.Result = nil
+		payload.ContinuationToken = ""
 		err := dec.Decode(&payload)
 		if err != nil {
 			if err == io.EOF {
 				break
 			}
-			return resp.StatusCode, fmt.Errorf("post %s decode resp failed, %s", uri, err.Error())
+			return resp.StatusCode, "", fmt.Errorf("post %s decode resp failed, %s", uri, err.Error())
 		}
 		if payload.Result != nil {
-			return resp.StatusCode, errors.New(payload.Result.Message)
+			return resp.StatusCode, "", errors.New(payload.Result.Message)
+		}
+		if payload.ContinuationToken != "" {
+			// last chunk of a paged show
+			nextToken = payload.ContinuationToken
+			continue
 		}
 		if replyReady != nil {
 			replyReady()
 		}
 	}
-	return resp.StatusCode, nil
+	return resp.StatusCode, nextToken, nil
 }
 
 func (s *Client) WebsocketConn(uri, token string, reqData interface{}) (*websocket.Conn, error) {
@@ -322,14 +398,28 @@ func (s *Client) WebsocketConn(uri, token string, reqData interface{}) (*websock
 	return ws, nil
 }
 
+// Paged show streams are followed until the last page.
 func (s *Client) HandleWebsocketStreamOut(uri, token string, reader *bufio.Reader, reqData, replyData interface{}, replyReady func()) (int, error) {
+	for {
+		status, nextToken, err := s.handleWebsocketStreamOutPage(uri, token, reader, reqData, replyData, replyReady)
+		if err != nil || nextToken == "" {
+			return status, err
+		}
+		reqData, err = setContinuationToken(reqData, nextToken)
+		if err != nil {
+			return status, fmt.Errorf("post %s %v", uri, err)
+		}
+	}
+}
+
+func (s *Client) handleWebsocketStreamOutPage(uri, token string, reader *bufio.Reader, reqData, replyData interface{}, replyReady func()) (int, string, error) {
 	wsPayload, ok := replyData.(*ormapi.WSStreamPayload)
 	if !ok {
-		return 0, fmt.Errorf("response can only be of type WSStreamPayload")
+		return 0, "", fmt.Errorf("response can only be of type WSStreamPayload")
 	}
 	ws, err := s.WebsocketConn(uri, token, reqData)
 	if err != nil {
-		return 0, fmt.Errorf("post %s client do failed, %s", uri, err.Error())
+		return 0, "", fmt.Errorf("post %s client do failed, %s", uri, err.Error())
 	}
 	if reader != nil {
 		go func() {
@@ -345,6 +435,7 @@ func (s *Client) HandleWebsocketStreamOut(uri, token string, reader *bufio.Reade
 		}()
 	}
 	payload := wsPayload
+	nextToken := ""
 	for {
 		if payload != nil {
 			// clear passed in buffer for next iteration.
@@ -357,24 +448,29 @@ func (s *Client) HandleWebsocketStreamOut(uri, token string, reader *bufio.Reade
 			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
 				break
 			}
-			return http.StatusBadRequest, fmt.Errorf("post %s decode resp failed, %s", uri, err.Error())
+			return http.StatusBadRequest, "", fmt.Errorf("post %s decode resp failed, %s", uri, err.Error())
 		}
 		if payload.Code != http.StatusOK {
 			if payload.Data == nil {
-				return payload.Code, nil
+				return payload.Code, "", nil
 			}
 			errRes := edgeproto.Result{}
 			err = mapstructure.Decode(payload.Data, &errRes)
 			if err == nil {
-				return payload.Code, errors.New(errRes.Message)
+				return payload.Code, "", errors.New(errRes.Message)
 			}
-			return payload.Code, nil
+			return payload.Code, "", nil
+		}
+		if payload.ContinuationToken != "" {
+			// last message of a paged show
+			nextToken = payload.ContinuationToken
+			continue
 		}
 		if replyReady != nil {
 			replyReady()
 		}
 	}
-	return http.StatusOK, nil
+	return http.StatusOK, nextToken, nil
 }
 
 func ClearObject(obj interface{}) {
@@ -0,0 +1,195 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ormutil
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/labstack/echo"
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+)
+
+const ShowPagerTag = "ShowPager"
+
+// ShowPageKeyFunc gets the key used to order objects for paging.
+type ShowPageKeyFunc func(obj interface{}) (string, error)
+
+// ShowPager pages the results of a show command. Results are
+// ordered by key, and the continuation token marks the last key
+// of the previous page. Sources like controller show streams are
+// not ordered, so the pager only keeps the lowest keyed objects
+// after the continuation token, which bounds memory by the page
+// limit instead of the total number of objects. Note that such
+// sources cannot stop early, each page still reads the whole
+// stream, so paging through N objects reads O(N) objects per page.
+// Sources like database queries that already read only the page,
+// in order, mark the pager as presorted via SetSourcePage.
+type ShowPager struct {
+	limit      int
+	after      string
+	keyFunc    ShowPageKeyFunc
+	keys       []string
+	objs       []interface{}
+	more       bool
+	presorted  bool
+	sourceLast string
+}
+
+type showPageToken struct {
+	After string `json:"after"`
+}
+
+func NewShowPager(page *ormapi.ShowPage, keyFunc ShowPageKeyFunc) (*ShowPager, error) {
+	if page.Limit < 0 {
+		return nil, fmt.Errorf("Invalid limit %d, cannot be negative", page.Limit)
+	}
+	pager := ShowPager{
+		limit:   page.Limit,
+		keyFunc: keyFunc,
+	}
+	if page.ContinuationToken != "" {
+		dat, err := base64.RawURLEncoding.DecodeString(page.ContinuationToken)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid continuation token")
+		}
+		token := showPageToken{}
+		if err := json.Unmarshal(dat, &token); err != nil || token.After == "" {
+			return nil, fmt.Errorf("Invalid continuation token")
+		}
+		pager.after = token.After
+	}
+	return &pager, nil
+}
+
+// Limit gets the page size, 0 means no limit.
+func (s *ShowPager) Limit() int {
+	return s.limit
+}
+
+// After gets the key of the last object of the previous page, or an
+// empty string for the first page.
+func (s *ShowPager) After() string {
+	return s.after
+}
+
+// SetSourcePage marks the objects added from now on as the page
+// itself, in order. The source already applied the continuation
+// token and limit, and may have dropped some objects after reading
+// them, i.e. for authorization. More is whether the source has more
+// objects after the page, and lastKey is the key of the last object
+// the source read for the page.
+func (s *ShowPager) SetSourcePage(more bool, lastKey string) {
+	s.presorted = true
+	s.more = more
+	s.sourceLast = lastKey
+}
+
+// Add an object, which is kept only if it belongs on the page.
+func (s *ShowPager) Add(obj interface{}) error {
+	if s.presorted {
+		s.objs = append(s.objs, obj)
+		return nil
+	}
+	key, err := s.keyFunc(obj)
+	if err != nil {
+		return err
+	}
+	if s.after != "" && key <= s.after {
+		// on a previous page
+		return nil
+	}
+	ii := sort.SearchStrings(s.keys, key)
+	if s.limit > 0 && ii >= s.limit {
+		s.more = true
+		return nil
+	}
+	s.keys = append(s.keys, "")
+	copy(s.keys[ii+1:], s.keys[ii:])
+	s.keys[ii] = key
+	s.objs = append(s.objs, nil)
+	copy(s.objs[ii+1:], s.objs[ii:])
+	s.objs[ii] = obj
+	if s.limit > 0 && len(s.keys) > s.limit {
+		s.keys = s.keys[:s.limit]
+		s.objs = s.objs[:s.limit]
+		s.more = true
+	}
+	return nil
+}
+
+// Objs gets the objects on the page, in key order.
+func (s *ShowPager) Objs() []interface{} {
+	return s.objs
+}
+
+// ContinuationToken gets the token for the next page, or an empty
+// string if there are no more pages.
+func (s *ShowPager) ContinuationToken() string {
+	token := showPageToken{}
+	if s.presorted {
+		if !s.more || s.sourceLast == "" {
+			return ""
+		}
+		token.After = s.sourceLast
+	} else {
+		if !s.more || len(s.keys) == 0 {
+			return ""
+		}
+		token.After = s.keys[len(s.keys)-1]
+	}
+	dat, err := json.Marshal(&token)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(dat)
+}
+
+// PageList gets the page from a list of objects. The list must be
+// a slice or a pointer to a slice, and the page is returned as a
+// slice of the same type.
+func (s *ShowPager) PageList(list interface{}) (interface{}, error) {
+	v := reflect.ValueOf(list)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("Cannot page results of type %T", list)
+	}
+	for ii := 0; ii < v.Len(); ii++ {
+		if err := s.Add(v.Index(ii).Interface()); err != nil {
+			return nil, err
+		}
+	}
+	page := reflect.MakeSlice(v.Type(), 0, len(s.objs))
+	for _, obj := range s.objs {
+		page = reflect.Append(page, reflect.ValueOf(obj))
+	}
+	return page.Interface(), nil
+}
+
+func SetShowPager(c echo.Context, pager *ShowPager) {
+	c.Set(ShowPagerTag, pager)
+}
+
+func GetShowPager(c echo.Context) *ShowPager {
+	if pager, ok := c.Get(ShowPagerTag).(*ShowPager); ok {
+		return pager
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ormutil
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mobiledgex/edge-cloud-infra/mc/ormapi"
+	"github.com/stretchr/testify/require"
+)
+
+type pageTestObj struct {
+	Name string
+}
+
+func pageTestObjKey(obj interface{}) (string, error) {
+	return obj.(pageTestObj).Name, nil
+}
+
+func TestShowPager(t *testing.T) {
+	// unordered objects, as from a controller show stream
+	names := []string{"e", "b", "g", "a", "f", "c", "d"}
+	objs := []pageTestObj{}
+	for _, name := range names {
+		objs = append(objs, pageTestObj{Name: name})
+	}
+
+	// get all pages
+	page := ormapi.ShowPage{Limit: 3}
+	got := []string{}
+	numPages := 0
+	for {
+		pager, err := NewShowPager(&page, pageTestObjKey)
+		require.Nil(t, err)
+		for _, obj := range objs {
+			require.Nil(t, pager.Add(obj))
+		}
+		require.True(t, len(pager.Objs()) <= page.Limit)
+		for _, obj := range pager.Objs() {
+			got = append(got, obj.(pageTestObj).Name)
+		}
+		numPages++
+		page.ContinuationToken = pager.ContinuationToken()
+		if page.ContinuationToken == "" {
+			break
+		}
+	}
+	require.Equal(t, []string{"a", "b", "c", "d", "e", "f", "g"}, got)
+	require.Equal(t, 3, numPages)
+
+	// page of a list
+	page = ormapi.ShowPage{Limit: 2}
+	pager, err := NewShowPager(&page, pageTestObjKey)
+	require.Nil(t, err)
+	list, err := pager.PageList(&objs)
+	require.Nil(t, err)
+	require.Equal(t, []pageTestObj{{"a"}, {"b"}}, list)
+	require.NotEqual(t, "", pager.ContinuationToken())
+
+	// no limit gets everything in order, without a token
+	page = ormapi.ShowPage{}
+	pager, err = NewShowPager(&page, pageTestObjKey)
+	require.Nil(t, err)
+	list, err = pager.PageList(objs)
+	require.Nil(t, err)
+	require.Equal(t, len(objs), len(list.([]pageTestObj)))
+	require.Equal(t, "", pager.ContinuationToken())
+
+	// exactly the limit does not need another page
+	page = ormapi.ShowPage{Limit: len(objs)}
+	pager, err = NewShowPager(&page, pageTestObjKey)
+	require.Nil(t, err)
+	_, err = pager.PageList(objs)
+	require.Nil(t, err)
+	require.Equal(t, "", pager.ContinuationToken())
+
+	// invalid args
+	_, err = NewShowPager(&ormapi.ShowPage{Limit: -1}, pageTestObjKey)
+	require.NotNil(t, err)
+	for _, token := range []string{"foo", "e30"} {
+		_, err = NewShowPager(&ormapi.ShowPage{ContinuationToken: token}, pageTestObjKey)
+		require.NotNil(t, err, fmt.Sprintf("token %s", token))
+	}
+	_, err = pager.PageList(objs[0])
+	require.NotNil(t, err)
+}
+
+func TestShowPagerSourcePage(t *testing.T) {
+	page := ormapi.ShowPage{Limit: 3}
+	pager, err := NewShowPager(&page, pageTestObjKey)
+	require.Nil(t, err)
+	// source read d, e, f in order and has more, e was dropped
+	pager.SetSourcePage(true, "f")
+	list, err := pager.PageList([]pageTestObj{{"d"}, {"f"}})
+	require.Nil(t, err)
+	require.Equal(t, []pageTestObj{{"d"}, {"f"}}, list)
+	page.ContinuationToken = pager.ContinuationToken()
+	require.NotEqual(t, "", page.ContinuationToken)
+
+	// next page starts after the last object the source read
+	pager, err = NewShowPager(&page, pageTestObjKey)
+	require.Nil(t, err)
+	require.Equal(t, "f", pager.After())
+	require.Equal(t, 3, pager.Limit())
+
+	// last page, even if all objects were dropped
+	pager.SetSourcePage(false, "g")
+	list, err = pager.PageList([]pageTestObj{})
+	require.Nil(t, err)
+	require.Equal(t, []pageTestObj{}, list)
+	require.Equal(t, "", pager.ContinuationToken())
+}
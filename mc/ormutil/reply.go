@@ -64,8 +64,21 @@ func BindErr(err error) error {
 	return err
 }
 
-// SetReply sets the reply data on a successful API call
+// SetReply sets the reply data on a successful API call.
+// For paged show commands, only the requested page of the
+// data is sent back.
 func SetReply(c echo.Context, data interface{}) error {
+	if pager := GetShowPager(c); pager != nil {
+		SetShowPager(c, nil)
+		page, err := pager.PageList(data)
+		if err != nil {
+			return err
+		}
+		if token := pager.ContinuationToken(); token != "" {
+			c.Response().Header().Set(ormapi.ContinuationTokenHeader, token)
+		}
+		data = page
+	}
 	return c.JSON(http.StatusOK, data)
 }
 
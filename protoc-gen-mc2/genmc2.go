@@ -381,6 +381,7 @@ func (g *GenMC2) getMethodArgs(service string, method *descriptor.MethodDescript
 		HasFields:            gensupport.HasGrpcFields(in.DescriptorProto),
 		NotifyRoot:           GetMc2ApiNotifyroot(method),
 		CustomValidateInput:  GetMc2CustomValidateInput(method),
+		OrgQuotaCheck:        orgQuotaMethods[*method.Name],
 	}
 	if gensupport.GetMessageKey(in.DescriptorProto) != nil || gensupport.GetObjAndKey(in.DescriptorProto) {
 		args.HasKey = true
@@ -543,6 +544,15 @@ type tmplArgs struct {
 	CliShort             string
 	CliGroup             string
 	CustomValidateInput  bool
+	OrgQuotaCheck        bool
+}
+
+// Methods which may increase an organization's resource usage,
+// which are checked against the organization's quotas by the MC.
+var orgQuotaMethods = map[string]bool{
+	"CreateClusterInst": true,
+	"UpdateClusterInst": true,
+	"CreateAppInst":     true,
 }
 
 var tmplApi = `
@@ -667,6 +677,11 @@ func {{.MethodName}}(c echo.Context) error {
 	}
 {{- end}}
 {{- end}}
+{{- if .OrgQuotaCheck}}
+	if err := checkOrgQuota{{.MethodName}}(ctx, rc, obj); err != nil {
+		return err
+	}
+{{- end}}
 {{- if .TargetCloudlet}}
        // Need access to database for federation handling
        rc.Database = database
@@ -0,0 +1,340 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/mobiledgex/edge-cloud-infra/autoprov/autorules"
+	"github.com/mobiledgex/edge-cloud-infra/infracommon"
+	"github.com/mobiledgex/edge-cloud-infra/shepherd/shepherd_common"
+	"github.com/mobiledgex/edge-cloud/cloud-resource-manager/k8smgmt"
+	"github.com/mobiledgex/edge-cloud/edgeproto"
+	"github.com/mobiledgex/edge-cloud/log"
+	"github.com/mobiledgex/edge-cloud/util"
+	ssh "github.com/mobiledgex/golang-ssh"
+)
+
+// Kubernetes AppInsts can have their deployment replica count scaled
+// by an AutoScalePolicy. The App opts in via its annotations, i.e.
+// autoscalepolicy=mypolicy,minreplicas=1,maxreplicas=5.
+// The policy's TargetCpu and TargetMem are used as the target per
+// replica utilization, in the same units as the appinst-cpu metric
+// and the AlertPolicy cpu/mem limits (percent of cluster resources).
+const (
+	AppAnnotationAutoScalePolicy           = autorules.AppAnnotationAutoScalePolicy
+	AppAnnotationMinReplicas               = "minreplicas"
+	AppAnnotationMaxReplicas               = "maxreplicas"
+	AppAnnotationScaleUpStabilizationSec   = "scaleupstabilizationsec"
+	AppAnnotationScaleDownStabilizationSec = "scaledownstabilizationsec"
+)
+
+var defaultReplicaScaleDownStabilization = 5 * time.Minute
+
+type AppReplicaScaleConfig struct {
+	Policy                 edgeproto.AutoScalePolicy
+	MinReplicas            int
+	MaxReplicas            int
+	ScaleUpStabilization   time.Duration
+	ScaleDownStabilization time.Duration
+}
+
+type replicaRecommendation struct {
+	ts       time.Time
+	replicas int
+}
+
+// Tracks recent replica recommendations for an AppInst, to apply
+// the stabilization windows. The AppInst's deployments are cached,
+// and only refreshed when the recommendation differs from the cached
+// replica count.
+type AppInstAutoScaler struct {
+	recommendations []replicaRecommendation // oldest first
+	deps            []infracommon.AppDeployment
+	depsUpdated     time.Time
+}
+
+// Get the replica scaling config for the App. Returns nil if the
+// App does not have replica scaling enabled.
+func getAppReplicaScaleConfig(app *edgeproto.App) (*AppReplicaScaleConfig, error) {
+	annotations := autorules.ParseAppAnnotations(app.Annotations)
+	policyName, ok := annotations[AppAnnotationAutoScalePolicy]
+	if !ok || policyName == "" {
+		return nil, nil
+	}
+	cfg := AppReplicaScaleConfig{
+		MinReplicas: 1,
+	}
+	cfg.Policy.Key.Name = policyName
+	cfg.Policy.Key.Organization = app.Key.Organization
+	if !AutoScalePoliciesCache.Get(&cfg.Policy.Key, &cfg.Policy) {
+		return nil, fmt.Errorf("AutoScalePolicy %s not found", policyName)
+	}
+	getInt := func(name string, val *int) error {
+		str, ok := annotations[name]
+		if !ok {
+			return nil
+		}
+		v, err := strconv.Atoi(str)
+		if err != nil || v < 0 {
+			return fmt.Errorf("invalid %s annotation %q, must be a non-negative integer", name, str)
+		}
+		*val = v
+		return nil
+	}
+	var upSec, downSec int
+	downSec = int(defaultReplicaScaleDownStabilization.Seconds())
+	if cfg.Policy.StabilizationWindowSec > 0 {
+		downSec = int(cfg.Policy.StabilizationWindowSec)
+	}
+	for name, val := range map[string]*int{
+		AppAnnotationMinReplicas:               &cfg.MinReplicas,
+		AppAnnotationMaxReplicas:               &cfg.MaxReplicas,
+		AppAnnotationScaleUpStabilizationSec:   &upSec,
+		AppAnnotationScaleDownStabilizationSec: &downSec,
+	} {
+		if err := getInt(name, val); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.MinReplicas < 1 {
+		return nil, fmt.Errorf("%s must be at least 1", AppAnnotationMinReplicas)
+	}
+	if cfg.MaxReplicas == 0 {
+		return nil, fmt.Errorf("%s annotation must be specified", AppAnnotationMaxReplicas)
+	}
+	if cfg.MaxReplicas < cfg.MinReplicas {
+		return nil, fmt.Errorf("%s %d cannot be less than %s %d", AppAnnotationMaxReplicas, cfg.MaxReplicas, AppAnnotationMinReplicas, cfg.MinReplicas)
+	}
+	cfg.ScaleUpStabilization = time.Duration(upSec) * time.Second
+	cfg.ScaleDownStabilization = time.Duration(downSec) * time.Second
+	return &cfg, nil
+}
+
+// Get the raw number of replicas needed for the total load of the
+// AppInst's pods. Returns 0 if the policy has no per-replica targets.
+func getDesiredReplicasRaw(pods []*shepherd_common.AppMetrics, policy *edgeproto.AutoScalePolicy) (float64, string) {
+	var totalCpu, totalMem float64
+	for _, pod := range pods {
+		totalCpu += pod.Cpu
+		totalMem += pod.MemPercent
+	}
+	var desiredRaw float64
+	reason := ""
+	if policy.TargetCpu > 0 {
+		desiredRaw = totalCpu / float64(policy.TargetCpu)
+		reason = fmt.Sprintf("total cpu %f%%, target %d%% per replica", totalCpu, policy.TargetCpu)
+	}
+	if policy.TargetMem > 0 {
+		numReplicas := totalMem / float64(policy.TargetMem)
+		if numReplicas > desiredRaw {
+			desiredRaw = numReplicas
+			reason = fmt.Sprintf("total mem %f%%, target %d%% per replica", totalMem, policy.TargetMem)
+		}
+	}
+	return desiredRaw, reason
+}
+
+// Get the number of replicas to scale to. Scale up only happens if all
+// recommendations within the scale up stabilization window are above
+// the current count, and scale down only happens if all recommendations
+// within the scale down window are below the current count.
+func (s *AppInstAutoScaler) getDesiredReplicas(now time.Time, desiredRaw float64, current int, cfg *AppReplicaScaleConfig) int {
+	// 10% hysteresis at the step point, same as cluster auto scaling
+	rec := int(math.Ceil(desiredRaw))
+	if desiredRaw >= float64(current) && desiredRaw < float64(current)*1.1 {
+		rec = current
+	}
+	if rec < cfg.MinReplicas {
+		rec = cfg.MinReplicas
+	}
+	if rec > cfg.MaxReplicas {
+		rec = cfg.MaxReplicas
+	}
+	s.recommendations = append(s.recommendations, replicaRecommendation{
+		ts:       now,
+		replicas: rec,
+	})
+	maxWindow := cfg.ScaleUpStabilization
+	if cfg.ScaleDownStabilization > maxWindow {
+		maxWindow = cfg.ScaleDownStabilization
+	}
+	for len(s.recommendations) > 1 && now.Sub(s.recommendations[0].ts) > maxWindow {
+		s.recommendations = s.recommendations[1:]
+	}
+	upRec := rec
+	downRec := rec
+	for _, r := range s.recommendations {
+		age := now.Sub(r.ts)
+		if age <= cfg.ScaleUpStabilization && r.replicas < upRec {
+			upRec = r.replicas
+		}
+		if age <= cfg.ScaleDownStabilization && r.replicas > downRec {
+			downRec = r.replicas
+		}
+	}
+	if upRec > current {
+		return upRec
+	}
+	if downRec < current {
+		return downRec
+	}
+	return current
+}
+
+// Check all the AppInsts on the cluster that have replica scaling
+// enabled, and scale their deployments if needed.
+func (p *ClusterWorker) checkAppInstAutoScale(ctx context.Context, appStatsMap map[shepherd_common.MetricAppInstKey]*shepherd_common.AppMetrics) {
+	k8sStats, ok := p.clusterStat.(*K8sClusterStats)
+	if !ok || k8sStats.kubeNames == nil {
+		return
+	}
+	type scaleAppInst struct {
+		appInst edgeproto.AppInst
+		cfg     *AppReplicaScaleConfig
+	}
+	appInsts := []scaleAppInst{}
+	AppInstCache.GetForRealClusterInstKey(&p.clusterInstKey, func(appInst *edgeproto.AppInst) {
+		app := edgeproto.App{}
+		if !AppCache.Get(&appInst.Key.AppKey, &app) {
+			return
+		}
+		cfg, err := getAppReplicaScaleConfig(&app)
+		if err != nil {
+			log.SpanLog(ctx, log.DebugLevelMetrics, "invalid App replica auto scale config", "app", app.Key, "err", err)
+			return
+		}
+		if cfg == nil {
+			return
+		}
+		appInsts = append(appInsts, scaleAppInst{
+			appInst: *appInst,
+			cfg:     cfg,
+		})
+	})
+
+	autoScalers := make(map[edgeproto.AppInstKey]*AppInstAutoScaler)
+	for _, ai := range appInsts {
+		autoScaler, found := p.appAutoScalers[ai.appInst.Key]
+		if !found {
+			autoScaler = &AppInstAutoScaler{}
+		}
+		autoScalers[ai.appInst.Key] = autoScaler
+		autoScaler.check(ctx, p.client, k8sStats.kubeNames, &ai.appInst, ai.cfg, appStatsMap)
+	}
+	// drop state for AppInsts that are gone or no longer scaled
+	p.appAutoScalers = autoScalers
+}
+
+func (s *AppInstAutoScaler) check(ctx context.Context, client ssh.Client, kubeNames *k8smgmt.KubeNames, appInst *edgeproto.AppInst, cfg *AppReplicaScaleConfig, appStatsMap map[shepherd_common.MetricAppInstKey]*shepherd_common.AppMetrics) {
+	appName := util.DNSSanitize(appInst.Key.AppKey.Name)
+	appVers := util.DNSSanitize(appInst.Key.AppKey.Version)
+	pods := []*shepherd_common.AppMetrics{}
+	for key, stat := range appStatsMap {
+		if key.App == appName && key.Version == appVers {
+			pods = append(pods, stat)
+		}
+	}
+	if len(pods) == 0 {
+		return
+	}
+	desiredRaw, reason := getDesiredReplicasRaw(pods, &cfg.Policy)
+	if desiredRaw == 0 && reason == "" {
+		log.SpanLog(ctx, log.DebugLevelMetrics, "AppInst replica auto scale policy has no cpu or mem target", "appInst", appInst.Key, "policy", cfg.Policy.Key)
+		return
+	}
+	suspended, resumedAt := getAutoProvSuspendState(&appInst.Key)
+	if suspended {
+		// suspend owns the replica count until the AppInst is resumed
+		s.deps = nil
+		return
+	}
+	if resumedAt.After(s.depsUpdated) {
+		s.deps = nil
+	}
+	if s.deps == nil {
+		if !s.updateDeployments(ctx, client, kubeNames, appInst, appName, appVers) {
+			return
+		}
+	}
+	current := s.getReplicas()
+	desired := s.getDesiredReplicas(time.Now(), desiredRaw, current, cfg)
+	log.SpanLog(ctx, log.DebugLevelMetrics, "AppInst replica auto scale calculations", "appInst", appInst.Key, "desiredRaw", desiredRaw, "current", current, "desired", desired, "reason", reason)
+	if desired == current {
+		return
+	}
+	// make sure the cached count is still correct before scaling
+	if !s.updateDeployments(ctx, client, kubeNames, appInst, appName, appVers) {
+		return
+	}
+	if s.getReplicas() != current {
+		log.SpanLog(ctx, log.DebugLevelMetrics, "AppInst replicas changed, recheck next interval", "appInst", appInst.Key, "cached", current, "current", s.getReplicas())
+		return
+	}
+	var err error
+	for ii := range s.deps {
+		dep := &s.deps[ii]
+		// record the count first, so that a CRM manifest apply
+		// restores it rather than resetting it
+		cmd := fmt.Sprintf("%s kubectl annotate deployment %s -n %s --overwrite %s=%d", kubeNames.KconfEnv, dep.Name, dep.Namespace, infracommon.AutoScaledReplicasAnnotation, desired)
+		if out, cerr := client.Output(cmd); cerr != nil {
+			err = fmt.Errorf("failed to annotate deployment %s: %s, %v", dep.Name, out, cerr)
+			break
+		}
+		if dep.Replicas != desired {
+			err = infracommon.ScaleAppDeployment(ctx, client, kubeNames, dep, desired)
+			if err != nil {
+				break
+			}
+		}
+		dep.Replicas = desired
+	}
+	if err != nil {
+		s.deps = nil
+	}
+	nodeMgr.Event(ctx, "AppInst replicas auto scaled", appInst.Key.AppKey.Organization, appInst.Key.GetTags(), err, "replicas", strconv.Itoa(current), "new replicas", strconv.Itoa(desired), "reason", reason, "autoscalepolicy", cfg.Policy.Key.Name)
+}
+
+func (s *AppInstAutoScaler) updateDeployments(ctx context.Context, client ssh.Client, kubeNames *k8smgmt.KubeNames, appInst *edgeproto.AppInst, appName, appVers string) bool {
+	s.deps = nil
+	deps, err := infracommon.GetAppDeployments(ctx, client, kubeNames, appName, appVers)
+	if err != nil {
+		log.SpanLog(ctx, log.DebugLevelMetrics, "failed to get AppInst deployments for auto scaling", "appInst", appInst.Key, "err", err)
+		return false
+	}
+	if len(deps) == 0 {
+		log.SpanLog(ctx, log.DebugLevelMetrics, "no deployments found for AppInst replica auto scaling", "appInst", appInst.Key)
+		return false
+	}
+	s.deps = deps
+	s.depsUpdated = time.Now()
+	return true
+}
+
+// All deployments of the AppInst are scaled together, so the
+// current count is the highest of them.
+func (s *AppInstAutoScaler) getReplicas() int {
+	current := 0
+	for _, dep := range s.deps {
+		if dep.Replicas > current {
+			current = dep.Replicas
+		}
+	}
+	return current
+}
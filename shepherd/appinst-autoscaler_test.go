@@ -0,0 +1,314 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mobiledgex/edge-cloud-infra/infracommon"
+	"github.com/mobiledgex/edge-cloud-infra/shepherd/shepherd_common"
+	"github.com/mobiledgex/edge-cloud/cloud-resource-manager/k8smgmt"
+	"github.com/mobiledgex/edge-cloud/cloud-resource-manager/platform/pc"
+	"github.com/mobiledgex/edge-cloud/cloudcommon"
+	"github.com/mobiledgex/edge-cloud/edgeproto"
+	"github.com/mobiledgex/edge-cloud/log"
+	"github.com/stretchr/testify/require"
+)
+
+// Fakes kubectl get, scale and annotate of deployments. Only
+// myapp-deployment is scaled and annotated.
+type testDeploymentClient struct {
+	pc.LocalClient
+	replicas   int
+	annotated  string // suspended replicas annotation
+	autoScaled string // auto scaled replicas annotation
+	scaleCmd   string
+	cmds       []string
+}
+
+func (s *testDeploymentClient) Output(command string) (string, error) {
+	s.cmds = append(s.cmds, command)
+	if strings.Contains(command, "kubectl get deployments") {
+		annotations := map[string]string{}
+		if s.annotated != "" {
+			annotations[infracommon.SuspendedReplicasAnnotation] = s.annotated
+		}
+		if s.autoScaled != "" {
+			annotations[infracommon.AutoScaledReplicasAnnotation] = s.autoScaled
+		}
+		annotationsJson, err := json.Marshal(annotations)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf(`{"items":[{
+  "metadata":{"name":"myapp-deployment","namespace":"default","annotations":%s},
+  "spec":{"replicas":%d,"template":{"metadata":{"labels":{"mexAppName":"myapp","mexAppVersion":"10"}}}}
+},{
+  "metadata":{"name":"other-deployment","namespace":"default"},
+  "spec":{"replicas":1,"template":{"metadata":{"labels":{"mexAppName":"other","mexAppVersion":"10"}}}}
+}]}`, string(annotationsJson), s.replicas), nil
+	}
+	if strings.Contains(command, "kubectl scale deployment myapp-deployment") {
+		s.scaleCmd = command
+		_, err := fmt.Sscanf(command[strings.Index(command, "--replicas="):], "--replicas=%d", &s.replicas)
+		return "", err
+	}
+	if strings.Contains(command, "kubectl annotate deployment myapp-deployment") {
+		val := &s.annotated
+		if strings.Contains(command, infracommon.AutoScaledReplicasAnnotation) {
+			val = &s.autoScaled
+		}
+		if strings.HasSuffix(command, "-") {
+			*val = ""
+		} else {
+			*val = command[strings.LastIndex(command, "=")+1:]
+		}
+		return "", nil
+	}
+	return "", fmt.Errorf("unexpected command %s", command)
+}
+
+func TestAppReplicaScaleConfig(t *testing.T) {
+	ctx := setupLog()
+	defer log.FinishTracer()
+
+	policy := edgeproto.AutoScalePolicy{}
+	policy.Key.Name = "test-policy"
+	policy.Key.Organization = "devorg"
+	policy.StabilizationWindowSec = 60
+	policy.TargetCpu = 50
+	edgeproto.InitAutoScalePolicyCache(&AutoScalePoliciesCache)
+	AutoScalePoliciesCache.Update(ctx, &policy, 0)
+	defer AutoScalePoliciesCache.Delete(ctx, &policy, 0)
+
+	app := edgeproto.App{}
+	app.Key.Organization = "devorg"
+
+	// not enabled
+	cfg, err := getAppReplicaScaleConfig(&app)
+	require.Nil(t, err)
+	require.Nil(t, cfg)
+	app.Annotations = "foo=bar"
+	cfg, err = getAppReplicaScaleConfig(&app)
+	require.Nil(t, err)
+	require.Nil(t, cfg)
+
+	// defaults
+	app.Annotations = "foo=bar, autoscalepolicy=test-policy,maxreplicas=4"
+	cfg, err = getAppReplicaScaleConfig(&app)
+	require.Nil(t, err)
+	require.NotNil(t, cfg)
+	require.Equal(t, policy.Key, cfg.Policy.Key)
+	require.Equal(t, uint32(50), cfg.Policy.TargetCpu)
+	require.Equal(t, 1, cfg.MinReplicas)
+	require.Equal(t, 4, cfg.MaxReplicas)
+	require.Equal(t, time.Duration(0), cfg.ScaleUpStabilization)
+	require.Equal(t, 60*time.Second, cfg.ScaleDownStabilization)
+
+	// all specified
+	app.Annotations = "autoscalepolicy=test-policy,minreplicas=2,maxreplicas=4,scaleupstabilizationsec=30,scaledownstabilizationsec=600"
+	cfg, err = getAppReplicaScaleConfig(&app)
+	require.Nil(t, err)
+	require.Equal(t, 2, cfg.MinReplicas)
+	require.Equal(t, 30*time.Second, cfg.ScaleUpStabilization)
+	require.Equal(t, 600*time.Second, cfg.ScaleDownStabilization)
+
+	// errors
+	app.Annotations = "autoscalepolicy=missing,maxreplicas=4"
+	_, err = getAppReplicaScaleConfig(&app)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "AutoScalePolicy missing not found")
+	app.Annotations = "autoscalepolicy=test-policy"
+	_, err = getAppReplicaScaleConfig(&app)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "maxreplicas annotation must be specified")
+	app.Annotations = "autoscalepolicy=test-policy,minreplicas=3,maxreplicas=2"
+	_, err = getAppReplicaScaleConfig(&app)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "cannot be less than")
+	app.Annotations = "autoscalepolicy=test-policy,minreplicas=0,maxreplicas=2"
+	_, err = getAppReplicaScaleConfig(&app)
+	require.NotNil(t, err)
+	app.Annotations = "autoscalepolicy=test-policy,maxreplicas=abc"
+	_, err = getAppReplicaScaleConfig(&app)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "invalid maxreplicas annotation")
+}
+
+func TestAppInstDesiredReplicas(t *testing.T) {
+	policy := edgeproto.AutoScalePolicy{}
+	policy.TargetCpu = 50
+	policy.TargetMem = 40
+
+	pods := []*shepherd_common.AppMetrics{
+		{Cpu: 40, MemPercent: 20},
+		{Cpu: 30, MemPercent: 20},
+	}
+	raw, reason := getDesiredReplicasRaw(pods, &policy)
+	require.Equal(t, 1.4, raw)
+	require.Contains(t, reason, "total cpu")
+	pods[1].MemPercent = 50
+	raw, reason = getDesiredReplicasRaw(pods, &policy)
+	require.Equal(t, 1.75, raw)
+	require.Contains(t, reason, "total mem")
+
+	cfg := AppReplicaScaleConfig{
+		MinReplicas:            1,
+		MaxReplicas:            5,
+		ScaleUpStabilization:   20 * time.Second,
+		ScaleDownStabilization: 60 * time.Second,
+	}
+	start := time.Now()
+	at := func(sec int) time.Time {
+		return start.Add(time.Duration(sec) * time.Second)
+	}
+	scaler := AppInstAutoScaler{}
+
+	// within 10% above the current count there is no change
+	require.Equal(t, 2, scaler.getDesiredReplicas(at(0), 2.1, 2, &cfg))
+	// scale up waits for the up stabilization window
+	require.Equal(t, 2, scaler.getDesiredReplicas(at(10), 3.5, 2, &cfg))
+	require.Equal(t, 2, scaler.getDesiredReplicas(at(20), 3.5, 2, &cfg))
+	// uses the lowest recommendation in the window
+	require.Equal(t, 3, scaler.getDesiredReplicas(at(30), 2.5, 2, &cfg))
+	require.Equal(t, 4, scaler.getDesiredReplicas(at(51), 3.5, 3, &cfg))
+	// clamped to max
+	require.Equal(t, 5, scaler.getDesiredReplicas(at(100), 20, 5, &cfg))
+	// scale down waits for the down stabilization window
+	require.Equal(t, 5, scaler.getDesiredReplicas(at(110), 3.5, 5, &cfg))
+	require.Equal(t, 5, scaler.getDesiredReplicas(at(120), 0.5, 5, &cfg))
+	require.Equal(t, 5, scaler.getDesiredReplicas(at(150), 0.5, 5, &cfg))
+	// uses the highest recommendation in the window
+	require.Equal(t, 4, scaler.getDesiredReplicas(at(161), 0.5, 5, &cfg))
+	require.Equal(t, 1, scaler.getDesiredReplicas(at(200), 0.5, 4, &cfg))
+	// old recommendations are pruned
+	require.Equal(t, 1, scaler.getDesiredReplicas(at(230), 0.5, 1, &cfg))
+	require.Equal(t, 2, len(scaler.recommendations))
+}
+
+func TestAppInstAutoScale(t *testing.T) {
+	ctx := setupLog()
+	defer log.FinishTracer()
+	log.SetDebugLevel(log.DebugLevelMetrics)
+
+	policy := edgeproto.AutoScalePolicy{}
+	policy.Key.Name = "test-policy"
+	policy.Key.Organization = "devorg"
+	policy.TargetCpu = 50
+
+	app := edgeproto.App{}
+	app.Key.Name = "MyApp"
+	app.Key.Organization = "devorg"
+	app.Key.Version = "1.0"
+	app.Deployment = cloudcommon.DeploymentTypeKubernetes
+	app.Annotations = "autoscalepolicy=test-policy,maxreplicas=3"
+
+	clusterInstKey := edgeproto.ClusterInstKey{}
+	clusterInstKey.ClusterKey.Name = "testcluster"
+	clusterInstKey.CloudletKey.Name = "testcloudlet"
+	clusterInstKey.CloudletKey.Organization = "testoper"
+	clusterInstKey.Organization = "devorg"
+	appInst := edgeproto.AppInst{}
+	appInst.Key.AppKey = app.Key
+	appInst.Key.ClusterInstKey = *clusterInstKey.Virtual("")
+
+	edgeproto.InitAutoScalePolicyCache(&AutoScalePoliciesCache)
+	edgeproto.InitAppCache(&AppCache)
+	edgeproto.InitAppInstCache(&AppInstCache)
+	AutoScalePoliciesCache.Update(ctx, &policy, 0)
+	AppCache.Update(ctx, &app, 0)
+	AppInstCache.Update(ctx, &appInst, 0)
+	defer func() {
+		AppInstCache.Delete(ctx, &appInst, 0)
+		AppCache.Delete(ctx, &app, 0)
+		AutoScalePoliciesCache.Delete(ctx, &policy, 0)
+	}()
+
+	client := &testDeploymentClient{replicas: 1}
+	worker := &ClusterWorker{
+		clusterInstKey: clusterInstKey,
+		deployment:     cloudcommon.DeploymentTypeKubernetes,
+		client:         client,
+		clusterStat: &K8sClusterStats{
+			key: clusterInstKey,
+			kubeNames: &k8smgmt.KubeNames{
+				KconfEnv: "KUBECONFIG=testcluster.kubeconfig",
+			},
+		},
+	}
+	podKey := func(app, pod string) shepherd_common.MetricAppInstKey {
+		return shepherd_common.MetricAppInstKey{
+			ClusterInstKey: clusterInstKey,
+			Pod:            pod,
+			App:            app,
+			Version:        "10",
+		}
+	}
+	appStatsMap := map[shepherd_common.MetricAppInstKey]*shepherd_common.AppMetrics{
+		podKey("myapp", "myapp-pod-1"): {Cpu: 90},
+		podKey("other", "other-pod-1"): {Cpu: 200},
+		podKey("myapp", "myapp-pod-2"): {Cpu: 30},
+	}
+
+	// 120% total cpu at 50% per replica, the count is recorded
+	// before scaling
+	worker.checkAppInstAutoScale(ctx, appStatsMap)
+	require.Equal(t, "KUBECONFIG=testcluster.kubeconfig kubectl scale deployment myapp-deployment -n default --replicas=3", client.scaleCmd)
+	require.Equal(t, "3", client.autoScaled)
+	require.Equal(t, 3, client.replicas)
+	require.Equal(t, 1, len(worker.appAutoScalers))
+
+	// already at the desired count, the cached deployments are used
+	client.scaleCmd = ""
+	client.cmds = nil
+	worker.checkAppInstAutoScale(ctx, appStatsMap)
+	require.Equal(t, "", client.scaleCmd)
+	require.Equal(t, 0, len(client.cmds))
+
+	// count changed outside of shepherd, deployments are refreshed
+	// before scaling and the scale waits for the next check
+	client.replicas = 2
+	appStatsMap[podKey("myapp", "myapp-pod-1")].Cpu = 20
+	appStatsMap[podKey("myapp", "myapp-pod-2")].Cpu = 20
+	scaler := worker.appAutoScalers[appInst.Key]
+	scaler.recommendations = nil
+	worker.checkAppInstAutoScale(ctx, appStatsMap)
+	require.Equal(t, "", client.scaleCmd)
+	require.Equal(t, 1, len(client.cmds))
+	require.Equal(t, 2, scaler.getReplicas())
+
+	// suspended AppInsts are not scaled
+	autoProvSuspendMux.Lock()
+	autoProvSuspendStates[appInst.Key] = &autoProvSuspendState{suspended: true}
+	autoProvSuspendMux.Unlock()
+	client.cmds = nil
+	worker.checkAppInstAutoScale(ctx, appStatsMap)
+	require.Equal(t, 0, len(client.cmds))
+	require.Nil(t, scaler.deps)
+	autoProvSuspendMux.Lock()
+	delete(autoProvSuspendStates, appInst.Key)
+	autoProvSuspendMux.Unlock()
+
+	// no longer enabled, state is removed
+	app.Annotations = ""
+	AppCache.Update(ctx, &app, 0)
+	worker.checkAppInstAutoScale(ctx, appStatsMap)
+	require.Equal(t, "", client.scaleCmd)
+	require.Equal(t, 0, len(worker.appAutoScalers))
+}
@@ -0,0 +1,354 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mobiledgex/edge-cloud-infra/autoprov/autorules"
+	"github.com/mobiledgex/edge-cloud-infra/infracommon"
+	"github.com/mobiledgex/edge-cloud-infra/shepherd/shepherd_common"
+	"github.com/mobiledgex/edge-cloud/cloud-resource-manager/k8smgmt"
+	"github.com/mobiledgex/edge-cloud/cloudcommon"
+	dme "github.com/mobiledgex/edge-cloud/d-match-engine/dme-proto"
+	"github.com/mobiledgex/edge-cloud/edgeproto"
+	"github.com/mobiledgex/edge-cloud/log"
+	"github.com/mobiledgex/edge-cloud/util"
+	ssh "github.com/mobiledgex/golang-ssh"
+)
+
+// Idle auto-provisioned AppInsts of Apps with the autoprovsuspend
+// annotation are suspended instead of undeployed when the auto
+// undeploy alert fires. Kubernetes AppInsts are suspended here by
+// scaling their deployments to zero, with the previous replica count
+// saved as a deployment annotation. VM AppInsts are powered off by
+// autoprov. The AppInst keeps its DNS and rootLB proxy ports, and a new
+// connection seen in the proxy stats resumes it: kubernetes deployments
+// are scaled back up here, and for VM AppInsts a resume alert tells
+// autoprov to power the VM back on. While a kubernetes AppInst is
+// suspended a suspended alert is raised, which autoprov waits for to
+// confirm the suspend before skipping the undeploy.
+
+type autoProvSuspendState struct {
+	suspended bool
+	busy      bool // suspend or resume in progress
+	// proxy connection count when last checked
+	lastConns     uint64
+	haveLastConns bool
+	// undeploy alerts that became active before this are stale
+	resumedAt time.Time
+}
+
+var autoProvSuspendMux sync.Mutex
+var autoProvSuspendStates = make(map[edgeproto.AppInstKey]*autoProvSuspendState)
+
+// Get whether the kubernetes AppInst is suspended or being suspended
+// or resumed, and when it was last resumed.
+func getAutoProvSuspendState(key *edgeproto.AppInstKey) (bool, time.Time) {
+	autoProvSuspendMux.Lock()
+	defer autoProvSuspendMux.Unlock()
+	state, found := autoProvSuspendStates[*key]
+	if !found {
+		return false, time.Time{}
+	}
+	return state.suspended || state.busy, state.resumedAt
+}
+
+func getAutoProvAlert(key *edgeproto.AppInstKey, alertname string) *edgeproto.Alert {
+	alert := &edgeproto.Alert{}
+	alert.Labels = key.GetTags()
+	alert.Labels["alertname"] = alertname
+	alert.Labels["region"] = *region
+	alert.State = "firing"
+	alert.ActiveAt = dme.TimeToTimestamp(time.Now())
+	return alert
+}
+
+// Raise the alert that tells autoprov a kubernetes AppInst was
+// suspended, or clear it once the AppInst is resumed.
+func setAutoProvSuspendedAlert(ctx context.Context, key *edgeproto.AppInstKey, suspended bool) {
+	alert := getAutoProvAlert(key, autorules.AlertAutoProvSuspended)
+	if !suspended {
+		AlertCache.Delete(ctx, alert, 0)
+		return
+	}
+	AlertCache.UpdateModFunc(ctx, alert.GetKey(), 0, func(old *edgeproto.Alert) (*edgeproto.Alert, bool) {
+		if old != nil {
+			return nil, false
+		}
+		return alert, true
+	})
+}
+
+func isKubernetesDeployment(deployment string) bool {
+	return deployment == cloudcommon.DeploymentTypeKubernetes || deployment == cloudcommon.DeploymentTypeHelm
+}
+
+// Get the rootLB client and kube names for the AppInst's cluster.
+func getAppInstKubeClient(appInst *edgeproto.AppInst) (ssh.Client, *k8smgmt.KubeNames, error) {
+	workerMapMutex.Lock()
+	defer workerMapMutex.Unlock()
+	worker, found := workerMap[getClusterWorkerMapKey(appInst.ClusterInstKey())]
+	if !found {
+		return nil, nil, fmt.Errorf("no cluster worker for %s", appInst.ClusterInstKey().GetKeyString())
+	}
+	k8sStats, ok := worker.clusterStat.(*K8sClusterStats)
+	if !ok || k8sStats.kubeNames == nil || worker.client == nil {
+		return nil, nil, fmt.Errorf("cluster %s is not a kubernetes cluster", appInst.ClusterInstKey().GetKeyString())
+	}
+	return worker.client, k8sStats.kubeNames, nil
+}
+
+// Called with the cloudlet prometheus alerts. Suspends kubernetes
+// AppInsts for firing auto undeploy alerts, and removes resume alerts
+// that are no longer needed.
+func updateAutoProvSuspend(ctx context.Context, alerts []edgeproto.Alert) {
+	for ii := range alerts {
+		alert := &alerts[ii]
+		if alert.Labels["alertname"] != cloudcommon.AlertAutoUndeploy || alert.State != "firing" {
+			continue
+		}
+		key := autorules.GetAlertAppInstKey(alert)
+		appInst := edgeproto.AppInst{}
+		if !AppInstCache.Get(&key, &appInst) {
+			continue
+		}
+		app := edgeproto.App{}
+		if !AppCache.Get(&key.AppKey, &app) || !autorules.IsAutoProvSuspendEnabled(&app) || !isKubernetesDeployment(app.Deployment) {
+			continue
+		}
+		autoProvSuspendMux.Lock()
+		state, found := autoProvSuspendStates[key]
+		if !found {
+			state = &autoProvSuspendState{}
+			autoProvSuspendStates[key] = state
+		}
+		activeAt := dme.TimestampToTime(alert.ActiveAt)
+		if state.suspended || state.busy || activeAt.Before(state.resumedAt) {
+			autoProvSuspendMux.Unlock()
+			continue
+		}
+		state.busy = true
+		autoProvSuspendMux.Unlock()
+
+		err := suspendK8sAppInst(ctx, &appInst)
+		autoProvSuspendMux.Lock()
+		state.busy = false
+		if err == nil {
+			state.suspended = true
+			state.haveLastConns = false
+		}
+		autoProvSuspendMux.Unlock()
+		if err == nil {
+			setAutoProvSuspendedAlert(ctx, &key, true)
+		}
+	}
+
+	// drop state for deleted AppInsts
+	autoProvSuspendMux.Lock()
+	for key, state := range autoProvSuspendStates {
+		if !state.busy && !AppInstCache.HasKey(&key) {
+			delete(autoProvSuspendStates, key)
+		}
+	}
+	autoProvSuspendMux.Unlock()
+
+	// clean up resume alerts once the VM is powered on, and
+	// suspended alerts once the AppInst is gone
+	toDelete := []edgeproto.AlertKey{}
+	AlertCache.Mux.Lock()
+	for k, data := range AlertCache.Objs {
+		name := data.Obj.Labels["alertname"]
+		if name == autorules.AlertAutoProvResume || name == autorules.AlertAutoProvSuspended {
+			toDelete = append(toDelete, k)
+		}
+	}
+	AlertCache.Mux.Unlock()
+	for _, k := range toDelete {
+		alert := edgeproto.Alert{}
+		alert.SetKey(&k)
+		key := autorules.GetAlertAppInstKey(&alert)
+		appInst := edgeproto.AppInst{}
+		found := AppInstCache.Get(&key, &appInst)
+		if found && alert.Labels["alertname"] == autorules.AlertAutoProvSuspended {
+			continue
+		}
+		if found && appInst.PowerState != edgeproto.PowerState_POWER_ON {
+			continue
+		}
+		log.SpanLog(ctx, log.DebugLevelMetrics, "Delete auto-prov alert", "alert", alert)
+		AlertCache.Delete(ctx, &alert, 0)
+	}
+}
+
+// Called with the proxy stats of the AppInst. If the AppInst is
+// suspended and there is a new connection, resume it.
+func checkAutoProvResume(ctx context.Context, key *edgeproto.AppInstKey, metrics *shepherd_common.ProxyMetrics) {
+	appInst := edgeproto.AppInst{}
+	if !AppInstCache.Get(key, &appInst) {
+		return
+	}
+	app := edgeproto.App{}
+	if !AppCache.Get(&key.AppKey, &app) || !autorules.IsAutoProvSuspendEnabled(&app) {
+		return
+	}
+	isVM := app.Deployment == cloudcommon.DeploymentTypeVM
+
+	autoProvSuspendMux.Lock()
+	defer autoProvSuspendMux.Unlock()
+	state, found := autoProvSuspendStates[*key]
+	if !found {
+		state = &autoProvSuspendState{}
+		autoProvSuspendStates[*key] = state
+	}
+	if isVM {
+		// VM AppInsts are suspended by autoprov powering them off
+		wasSuspended := state.suspended
+		state.suspended = appInst.PowerState == edgeproto.PowerState_POWER_OFF
+		if state.suspended && !wasSuspended {
+			state.haveLastConns = false
+		}
+	}
+	conns := getProxyConnCount(metrics)
+	if !state.suspended || state.busy {
+		state.lastConns = conns
+		state.haveLastConns = true
+		return
+	}
+	if !state.haveLastConns || conns <= state.lastConns {
+		// first check since suspend, or proxy restarted
+		state.lastConns = conns
+		state.haveLastConns = true
+		return
+	}
+	log.SpanLog(ctx, log.DebugLevelMetrics, "New connection for suspended AppInst, resuming", "AppInst", key, "conns", conns, "lastConns", state.lastConns)
+	state.lastConns = conns
+	if isVM {
+		alert := getAutoProvAlert(key, autorules.AlertAutoProvResume)
+		AlertCache.UpdateModFunc(ctx, alert.GetKey(), 0, func(old *edgeproto.Alert) (*edgeproto.Alert, bool) {
+			if old != nil {
+				return nil, false
+			}
+			return alert, true
+		})
+		state.resumedAt = time.Now()
+		return
+	}
+	state.busy = true
+	go func() {
+		span := log.StartSpan(log.DebugLevelMetrics, "auto-prov resume AppInst")
+		log.SetTags(span, key.GetTags())
+		defer span.Finish()
+		rctx := log.ContextWithSpan(context.Background(), span)
+
+		err := resumeK8sAppInst(rctx, &appInst)
+		if err == nil {
+			setAutoProvSuspendedAlert(rctx, &appInst.Key, false)
+		}
+		autoProvSuspendMux.Lock()
+		defer autoProvSuspendMux.Unlock()
+		state.busy = false
+		if err == nil {
+			state.suspended = false
+			state.resumedAt = time.Now()
+		}
+	}()
+}
+
+// Total number of connections and datagrams the proxy has seen for
+// the AppInst. Any increase is a new client.
+func getProxyConnCount(metrics *shepherd_common.ProxyMetrics) uint64 {
+	if metrics.Nginx {
+		return metrics.Accepts
+	}
+	var count uint64
+	for _, stat := range metrics.EnvoyTcpStats {
+		count += stat.Accepts
+	}
+	for _, stat := range metrics.EnvoyUdpStats {
+		count += stat.RecvDatagrams
+	}
+	return count
+}
+
+func suspendK8sAppInst(ctx context.Context, appInst *edgeproto.AppInst) error {
+	client, kubeNames, err := getAppInstKubeClient(appInst)
+	if err == nil {
+		err = setK8sAppInstSuspended(ctx, client, kubeNames, appInst, true)
+	}
+	log.SpanLog(ctx, log.DebugLevelMetrics, "Suspend AppInst", "AppInst", appInst.Key, "err", err)
+	nodeMgr.Event(ctx, "AppInst suspended", appInst.Key.AppKey.Organization, appInst.Key.GetTags(), err)
+	return err
+}
+
+func resumeK8sAppInst(ctx context.Context, appInst *edgeproto.AppInst) error {
+	client, kubeNames, err := getAppInstKubeClient(appInst)
+	if err == nil {
+		err = setK8sAppInstSuspended(ctx, client, kubeNames, appInst, false)
+	}
+	log.SpanLog(ctx, log.DebugLevelMetrics, "Resume AppInst", "AppInst", appInst.Key, "err", err)
+	nodeMgr.Event(ctx, "AppInst resumed", appInst.Key.AppKey.Organization, appInst.Key.GetTags(), err)
+	return err
+}
+
+// Scale the AppInst's deployments to zero, saving the replica count in
+// an annotation, or scale them back to the saved replica count.
+func setK8sAppInstSuspended(ctx context.Context, client ssh.Client, kubeNames *k8smgmt.KubeNames, appInst *edgeproto.AppInst, suspend bool) error {
+	appName := util.DNSSanitize(appInst.Key.AppKey.Name)
+	appVers := util.DNSSanitize(appInst.Key.AppKey.Version)
+	deps, err := infracommon.GetAppDeployments(ctx, client, kubeNames, appName, appVers)
+	if err != nil {
+		return err
+	}
+	if len(deps) == 0 {
+		return fmt.Errorf("no deployments found for AppInst")
+	}
+	for ii := range deps {
+		dep := &deps[ii]
+		saved, isSuspended := dep.Annotations[infracommon.SuspendedReplicasAnnotation]
+		if suspend {
+			if isSuspended {
+				continue
+			}
+			cmd := fmt.Sprintf("%s kubectl annotate deployment %s -n %s --overwrite %s=%d", kubeNames.KconfEnv, dep.Name, dep.Namespace, infracommon.SuspendedReplicasAnnotation, dep.Replicas)
+			if out, err := client.Output(cmd); err != nil {
+				return fmt.Errorf("failed to annotate deployment %s: %s, %v", dep.Name, out, err)
+			}
+			if err := infracommon.ScaleAppDeployment(ctx, client, kubeNames, dep, 0); err != nil {
+				return err
+			}
+			continue
+		}
+		if !isSuspended {
+			continue
+		}
+		replicas, err := strconv.Atoi(saved)
+		if err != nil || replicas < 1 {
+			replicas = 1
+		}
+		if err := infracommon.ScaleAppDeployment(ctx, client, kubeNames, dep, replicas); err != nil {
+			return err
+		}
+		cmd := fmt.Sprintf("%s kubectl annotate deployment %s -n %s %s-", kubeNames.KconfEnv, dep.Name, dep.Namespace, infracommon.SuspendedReplicasAnnotation)
+		if out, err := client.Output(cmd); err != nil {
+			return fmt.Errorf("failed to remove annotation from deployment %s: %s, %v", dep.Name, out, err)
+		}
+	}
+	return nil
+}
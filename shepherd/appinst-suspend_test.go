@@ -0,0 +1,213 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/mobiledgex/edge-cloud-infra/autoprov/autorules"
+	"github.com/mobiledgex/edge-cloud-infra/infracommon"
+	"github.com/mobiledgex/edge-cloud-infra/shepherd/shepherd_common"
+	"github.com/mobiledgex/edge-cloud/cloud-resource-manager/k8smgmt"
+	"github.com/mobiledgex/edge-cloud/cloudcommon"
+	"github.com/mobiledgex/edge-cloud/edgeproto"
+	"github.com/mobiledgex/edge-cloud/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoProvSuspendK8s(t *testing.T) {
+	ctx := setupLog()
+	defer log.FinishTracer()
+	log.SetDebugLevel(log.DebugLevelMetrics)
+
+	appInst := edgeproto.AppInst{}
+	appInst.Key.AppKey.Name = "MyApp"
+	appInst.Key.AppKey.Organization = "devorg"
+	appInst.Key.AppKey.Version = "1.0"
+	client := &testDeploymentClient{replicas: 3}
+	kubeNames := &k8smgmt.KubeNames{
+		KconfEnv: "KUBECONFIG=testcluster.kubeconfig",
+	}
+
+	// suspend saves the replica count and scales to zero
+	err := setK8sAppInstSuspended(ctx, client, kubeNames, &appInst, true)
+	require.Nil(t, err)
+	require.Equal(t, 0, client.replicas)
+	require.Equal(t, "3", client.annotated)
+	require.Contains(t, client.cmds, "KUBECONFIG=testcluster.kubeconfig kubectl annotate deployment myapp-deployment -n default --overwrite "+infracommon.SuspendedReplicasAnnotation+"=3")
+
+	// suspending again does not overwrite the saved count
+	err = setK8sAppInstSuspended(ctx, client, kubeNames, &appInst, true)
+	require.Nil(t, err)
+	require.Equal(t, "3", client.annotated)
+
+	// resume restores the replica count
+	err = setK8sAppInstSuspended(ctx, client, kubeNames, &appInst, false)
+	require.Nil(t, err)
+	require.Equal(t, 3, client.replicas)
+	require.Equal(t, "", client.annotated)
+
+	// resume of a running AppInst does nothing
+	client.cmds = nil
+	err = setK8sAppInstSuspended(ctx, client, kubeNames, &appInst, false)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(client.cmds))
+
+	// no deployments
+	appInst.Key.AppKey.Name = "OtherApp"
+	err = setK8sAppInstSuspended(ctx, client, kubeNames, &appInst, true)
+	require.NotNil(t, err)
+}
+
+func TestAutoProvResumeVM(t *testing.T) {
+	ctx := setupLog()
+	defer log.FinishTracer()
+	log.SetDebugLevel(log.DebugLevelMetrics)
+
+	app := edgeproto.App{}
+	app.Key.Name = "MyVMApp"
+	app.Key.Organization = "devorg"
+	app.Key.Version = "1.0"
+	app.Deployment = cloudcommon.DeploymentTypeVM
+	app.Annotations = autorules.AppAnnotationAutoProvSuspend + "=true"
+	appInst := edgeproto.AppInst{}
+	appInst.Key.AppKey = app.Key
+	appInst.Key.ClusterInstKey.CloudletKey.Name = "testcloudlet"
+	appInst.Key.ClusterInstKey.CloudletKey.Organization = "testoper"
+	appInst.PowerState = edgeproto.PowerState_POWER_ON
+
+	edgeproto.InitAppCache(&AppCache)
+	edgeproto.InitAppInstCache(&AppInstCache)
+	edgeproto.InitAlertCache(&AlertCache)
+	AppCache.Update(ctx, &app, 0)
+	AppInstCache.Update(ctx, &appInst, 0)
+	defer func() {
+		AppCache.Delete(ctx, &app, 0)
+		autoProvSuspendMux.Lock()
+		delete(autoProvSuspendStates, appInst.Key)
+		autoProvSuspendMux.Unlock()
+	}()
+
+	metrics := func(accepts uint64) *shepherd_common.ProxyMetrics {
+		return &shepherd_common.ProxyMetrics{
+			EnvoyTcpStats: map[int32]shepherd_common.TcpConnectionsMetric{
+				80: {Accepts: accepts},
+			},
+		}
+	}
+	resumeAlert := getAutoProvAlert(&appInst.Key, autorules.AlertAutoProvResume)
+
+	// powered on, connections do nothing
+	checkAutoProvResume(ctx, &appInst.Key, metrics(5))
+	checkAutoProvResume(ctx, &appInst.Key, metrics(6))
+	require.False(t, AlertCache.HasKey(resumeAlert.GetKey()))
+
+	// powered off by autoprov, first check sets the baseline
+	appInst.PowerState = edgeproto.PowerState_POWER_OFF
+	AppInstCache.Update(ctx, &appInst, 0)
+	checkAutoProvResume(ctx, &appInst.Key, metrics(7))
+	checkAutoProvResume(ctx, &appInst.Key, metrics(7))
+	require.False(t, AlertCache.HasKey(resumeAlert.GetKey()))
+
+	// new connection raises the resume alert
+	checkAutoProvResume(ctx, &appInst.Key, metrics(8))
+	require.True(t, AlertCache.HasKey(resumeAlert.GetKey()))
+
+	// alert stays until the VM is powered on
+	updateAutoProvSuspend(ctx, []edgeproto.Alert{})
+	require.True(t, AlertCache.HasKey(resumeAlert.GetKey()))
+	appInst.PowerState = edgeproto.PowerState_POWER_ON
+	AppInstCache.Update(ctx, &appInst, 0)
+	updateAutoProvSuspend(ctx, []edgeproto.Alert{})
+	require.False(t, AlertCache.HasKey(resumeAlert.GetKey()))
+
+	// state is removed with the AppInst
+	AppInstCache.Delete(ctx, &appInst, 0)
+	updateAutoProvSuspend(ctx, []edgeproto.Alert{})
+	autoProvSuspendMux.Lock()
+	require.Equal(t, 0, len(autoProvSuspendStates))
+	autoProvSuspendMux.Unlock()
+}
+
+func TestAutoProvSuspendedAlert(t *testing.T) {
+	ctx := setupLog()
+	defer log.FinishTracer()
+	log.SetDebugLevel(log.DebugLevelMetrics)
+
+	clusterInstKey := edgeproto.ClusterInstKey{}
+	clusterInstKey.ClusterKey.Name = "testcluster"
+	clusterInstKey.CloudletKey.Name = "testcloudlet"
+	clusterInstKey.CloudletKey.Organization = "testoper"
+	clusterInstKey.Organization = "devorg"
+	app := edgeproto.App{}
+	app.Key.Name = "MyApp"
+	app.Key.Organization = "devorg"
+	app.Key.Version = "1.0"
+	app.Deployment = cloudcommon.DeploymentTypeKubernetes
+	app.Annotations = autorules.AppAnnotationAutoProvSuspend + "=true"
+	appInst := edgeproto.AppInst{}
+	appInst.Key.AppKey = app.Key
+	appInst.Key.ClusterInstKey = *clusterInstKey.Virtual("")
+
+	edgeproto.InitAppCache(&AppCache)
+	edgeproto.InitAppInstCache(&AppInstCache)
+	edgeproto.InitAlertCache(&AlertCache)
+	AppCache.Update(ctx, &app, 0)
+	AppInstCache.Update(ctx, &appInst, 0)
+
+	client := &testDeploymentClient{replicas: 2}
+	saveWorkerMap := workerMap
+	workerMap = map[string]*ClusterWorker{
+		getClusterWorkerMapKey(&clusterInstKey): {
+			clusterInstKey: clusterInstKey,
+			deployment:     cloudcommon.DeploymentTypeKubernetes,
+			client:         client,
+			clusterStat: &K8sClusterStats{
+				key: clusterInstKey,
+				kubeNames: &k8smgmt.KubeNames{
+					KconfEnv: "KUBECONFIG=testcluster.kubeconfig",
+				},
+			},
+		},
+	}
+	defer func() {
+		workerMap = saveWorkerMap
+		AppCache.Delete(ctx, &app, 0)
+		autoProvSuspendMux.Lock()
+		delete(autoProvSuspendStates, appInst.Key)
+		autoProvSuspendMux.Unlock()
+	}()
+
+	undeployAlert := edgeproto.Alert{}
+	undeployAlert.Labels = appInst.Key.GetTags()
+	undeployAlert.Labels["alertname"] = cloudcommon.AlertAutoUndeploy
+	undeployAlert.State = "firing"
+	suspendedAlert := getAutoProvAlert(&appInst.Key, autorules.AlertAutoProvSuspended)
+
+	// suspend raises the suspended alert for autoprov
+	updateAutoProvSuspend(ctx, []edgeproto.Alert{undeployAlert})
+	require.Equal(t, 0, client.replicas)
+	require.Equal(t, "2", client.annotated)
+	require.True(t, AlertCache.HasKey(suspendedAlert.GetKey()))
+
+	// alert stays while the AppInst exists
+	updateAutoProvSuspend(ctx, []edgeproto.Alert{})
+	require.True(t, AlertCache.HasKey(suspendedAlert.GetKey()))
+
+	// alert is removed with the AppInst
+	AppInstCache.Delete(ctx, &appInst, 0)
+	updateAutoProvSuspend(ctx, []edgeproto.Alert{})
+	require.False(t, AlertCache.HasKey(suspendedAlert.GetKey()))
+}
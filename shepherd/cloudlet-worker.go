@@ -77,7 +77,7 @@ func CloudletScraper(done chan bool) {
 				log.SpanLog(ctx, log.DebugLevelMetrics, "Using cached metrics due to no changes", "elapsed", elapsed)
 			}
 			for _, metric := range metrics {
-				MetricSender.Update(context.Background(), metric)
+				sendMetric(context.Background(), metric)
 			}
 
 			span.Finish()
@@ -108,6 +108,7 @@ func CloudletPrometheusScraper(done chan bool) {
 				}
 				// key is nil, since we just check against the predefined set of rules
 				UpdateAlerts(actx, alerts, nil, pruneCloudletForeignAlerts)
+				updateAutoProvSuspend(actx, alerts)
 				// query stats
 				getCloudletPrometheusStats(actx, CloudletPrometheusAddr, client)
 			} else {
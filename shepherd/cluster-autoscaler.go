@@ -16,9 +16,15 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"math"
+	"net/url"
+	"os"
+	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/mobiledgex/edge-cloud-infra/shepherd/shepherd_common"
 	"github.com/mobiledgex/edge-cloud/cloudcommon"
@@ -29,6 +35,13 @@ import (
 
 var clusterAutoScalerWorkers tasks.KeyWorkers
 
+// for unit tests to control time
+var forecastNow = time.Now
+
+// Directory the seasonal forecast profiles of clusters are saved in,
+// empty if they are only kept in memory
+var forecastDir string
+
 func init() {
 	clusterAutoScalerWorkers.Init("cluster-autoscale", checkClusterAutoScale)
 }
@@ -40,6 +53,152 @@ type ClusterAutoScaler struct {
 	lastStabilizedTotalMem    float32
 	lastStabilizedActiveConns float64
 	scaleInProgress           bool // makes sure Alert gets deleted when done
+	// forecasts for predictive mode
+	cpuForecast   *Forecaster
+	memForecast   *Forecaster
+	connsForecast *Forecaster
+	// stabilization of forecast scale ups
+	forecastUpSince    time.Time
+	forecastHoldUntil  time.Time
+	forecastHoldNodes  float64
+	forecastHoldReason string
+}
+
+// Seasonal forecast profiles saved for a cluster
+type savedForecasts struct {
+	Cpu               *ForecastSeasonal
+	Mem               *ForecastSeasonal
+	ActiveConnections *ForecastSeasonal
+}
+
+// Per-cluster forecast state shown by the debug command
+type AutoScaleForecastState struct {
+	Policy            string
+	Cpu               *ForecastState `json:",omitempty"`
+	Mem               *ForecastState `json:",omitempty"`
+	ActiveConnections *ForecastState `json:",omitempty"`
+}
+
+func getForecastFile(key *edgeproto.ClusterInstKey) string {
+	return filepath.Join(forecastDir, url.PathEscape(key.Organization+"_"+key.ClusterKey.Name)+".json")
+}
+
+func (s *ClusterAutoScaler) initForecasts(ctx context.Context, key *edgeproto.ClusterInstKey) {
+	if s.cpuForecast != nil {
+		return
+	}
+	s.cpuForecast = NewForecaster(*autoScaleForecastSamples)
+	s.memForecast = NewForecaster(*autoScaleForecastSamples)
+	s.connsForecast = NewForecaster(*autoScaleForecastSamples)
+	if forecastDir == "" {
+		return
+	}
+	// seed the seasonal profiles saved before a restart
+	fileName := getForecastFile(key)
+	dat, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.SpanLog(ctx, log.DebugLevelMetrics, "Failed to read saved forecasts", "file", fileName, "err", err)
+		}
+		return
+	}
+	saved := savedForecasts{}
+	if err := json.Unmarshal(dat, &saved); err != nil {
+		log.SpanLog(ctx, log.DebugLevelMetrics, "Failed to parse saved forecasts", "file", fileName, "err", err)
+		return
+	}
+	seed := func(forecast *Forecaster, seasonal *ForecastSeasonal) {
+		if seasonal == nil {
+			return
+		}
+		if err := forecast.SetSeasonal(seasonal); err != nil {
+			log.SpanLog(ctx, log.DebugLevelMetrics, "Failed to seed saved forecast", "file", fileName, "err", err)
+		}
+	}
+	seed(s.cpuForecast, saved.Cpu)
+	seed(s.memForecast, saved.Mem)
+	seed(s.connsForecast, saved.ActiveConnections)
+}
+
+// Save the seasonal profiles, which take a week to build up, so that
+// they survive restarts. Called once an hour when they are updated.
+func (s *ClusterAutoScaler) saveForecasts(ctx context.Context, key *edgeproto.ClusterInstKey) {
+	if forecastDir == "" {
+		return
+	}
+	saved := savedForecasts{
+		Cpu:               s.cpuForecast.GetSeasonal(),
+		Mem:               s.memForecast.GetSeasonal(),
+		ActiveConnections: s.connsForecast.GetSeasonal(),
+	}
+	dat, err := json.Marshal(&saved)
+	if err != nil {
+		log.SpanLog(ctx, log.DebugLevelMetrics, "Failed to marshal forecasts", "key", key, "err", err)
+		return
+	}
+	if err := os.MkdirAll(forecastDir, 0755); err != nil {
+		log.SpanLog(ctx, log.DebugLevelMetrics, "Failed to create forecast dir", "dir", forecastDir, "err", err)
+		return
+	}
+	// write and rename so that a crash does not leave a partial file
+	fileName := getForecastFile(key)
+	if err := ioutil.WriteFile(fileName+".tmp", dat, 0644); err != nil {
+		log.SpanLog(ctx, log.DebugLevelMetrics, "Failed to save forecasts", "file", fileName, "err", err)
+		return
+	}
+	if err := os.Rename(fileName+".tmp", fileName); err != nil {
+		log.SpanLog(ctx, log.DebugLevelMetrics, "Failed to save forecasts", "file", fileName, "err", err)
+	}
+}
+
+func deleteSavedForecasts(ctx context.Context, key *edgeproto.ClusterInstKey) {
+	if forecastDir == "" {
+		return
+	}
+	fileName := getForecastFile(key)
+	if err := os.Remove(fileName); err != nil && !os.IsNotExist(err) {
+		log.SpanLog(ctx, log.DebugLevelMetrics, "Failed to delete saved forecasts", "file", fileName, "err", err)
+	}
+}
+
+// Forecast scale ups only apply once the forecast has called for more
+// nodes than the cluster has for the stabilization window, so that a
+// short spike in the trend does not scale the cluster. Once applied,
+// the highest forecast is held for the forecast horizon, so that the
+// cluster is not scaled back down before the forecast load arrives.
+func (s *ClusterAutoScaler) stabilizeForecast(now time.Time, numNodes uint32, forecastNodes float64, reason string) (float64, string) {
+	if forecastNodes > float64(numNodes) {
+		if s.forecastUpSince.IsZero() {
+			s.forecastUpSince = now
+		}
+		stabilized := now.Sub(s.forecastUpSince) >= *autoScaleForecastStabilization
+		held := now.Before(s.forecastHoldUntil)
+		if stabilized && (!held || forecastNodes >= s.forecastHoldNodes) {
+			s.forecastHoldUntil = now.Add(*autoScaleForecastHorizon)
+			s.forecastHoldNodes = forecastNodes
+			s.forecastHoldReason = reason
+		}
+	} else {
+		s.forecastUpSince = time.Time{}
+	}
+	if now.Before(s.forecastHoldUntil) {
+		return s.forecastHoldNodes, s.forecastHoldReason
+	}
+	return 0, ""
+}
+
+func (s *ClusterAutoScaler) getForecastState() *AutoScaleForecastState {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	state := AutoScaleForecastState{
+		Policy: s.policyName,
+	}
+	if s.cpuForecast != nil {
+		state.Cpu = s.cpuForecast.GetState(*autoScaleForecastHorizon)
+		state.Mem = s.memForecast.GetState(*autoScaleForecastHorizon)
+		state.ActiveConnections = s.connsForecast.GetState(*autoScaleForecastHorizon)
+	}
+	return &state
 }
 
 func (s *ClusterAutoScaler) updateClusterStats(ctx context.Context, key edgeproto.ClusterInstKey, stats *shepherd_common.ClusterMetrics) {
@@ -56,6 +215,17 @@ func (s *ClusterAutoScaler) updateClusterStats(ctx context.Context, key edgeprot
 		s.lastStabilizedTotalMem = float32(stats.AutoScaleMem)
 		needsWork = true
 	}
+	if *autoScalePredictive {
+		// forecast changes with every sample
+		now := forecastNow()
+		s.initForecasts(ctx, &key)
+		cpuUpdated := s.cpuForecast.Add(now, stats.AutoScaleCpu)
+		memUpdated := s.memForecast.Add(now, stats.AutoScaleMem)
+		if cpuUpdated || memUpdated {
+			s.saveForecasts(ctx, &key)
+		}
+		needsWork = true
+	}
 	s.mux.Unlock()
 	// Note scaleInProgress is needed to ensure alert is removed
 	// after scaling is done, in case stats remain constant.
@@ -71,6 +241,13 @@ func (s *ClusterAutoScaler) updateConnStats(ctx context.Context, key edgeproto.C
 		s.lastStabilizedActiveConns = activeConns
 		needsWork = true
 	}
+	if *autoScalePredictive {
+		s.initForecasts(ctx, &key)
+		if s.connsForecast.Add(forecastNow(), activeConns) {
+			s.saveForecasts(ctx, &key)
+		}
+		needsWork = true
+	}
 	s.mux.Unlock()
 	if needsWork || s.scaleInProgress {
 		clusterAutoScalerWorkers.NeedsWork(ctx, key)
@@ -137,6 +314,32 @@ func checkClusterAutoScale(ctx context.Context, k interface{}) {
 			reason = fmt.Sprintf("stabilized total active connections %f, target %d per node", autoScaler.lastStabilizedActiveConns, policy.TargetActiveConnections)
 		}
 	}
+	// In predictive mode, scale for the forecast load if it is higher,
+	// so that the cluster is scaled up before the load arrives.
+	if *autoScalePredictive && autoScaler.cpuForecast != nil {
+		horizon := *autoScaleForecastHorizon
+		var forecastNodes float64
+		forecastReason := ""
+		checkForecast := func(forecast *Forecaster, target float64, name string) {
+			val, _, ok := forecast.Forecast(horizon)
+			if !ok || target <= 0 {
+				return
+			}
+			numNodes := val / target
+			if numNodes > forecastNodes {
+				forecastNodes = numNodes
+				forecastReason = fmt.Sprintf("forecast total %s %f in %s, target %f per node", name, val, horizon.String(), target)
+			}
+		}
+		checkForecast(autoScaler.cpuForecast, float64(policy.TargetCpu)/100.0, "cpu")
+		checkForecast(autoScaler.memForecast, float64(policy.TargetMem)/100.0, "mem")
+		checkForecast(autoScaler.connsForecast, float64(policy.TargetActiveConnections), "active connections")
+		heldNodes, heldReason := autoScaler.stabilizeForecast(forecastNow(), cinst.NumNodes, forecastNodes, forecastReason)
+		if heldNodes > desiredNodesRaw {
+			desiredNodesRaw = heldNodes
+			reason = heldReason
+		}
+	}
 	log.SpanLog(ctx, log.DebugLevelApi, "checkClusterAutoScale calculations", "key", key, "autoScaler", fmt.Sprintf("%+v", autoScaler), "policy", policy, "desiredNodesRaw", desiredNodesRaw, "curNumNodes", cinst.NumNodes, "reason", reason)
 	autoScaler.mux.Unlock()
 	if desiredNodesRaw == 0 {
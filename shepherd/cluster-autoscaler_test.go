@@ -15,7 +15,12 @@
 package main
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/mobiledgex/edge-cloud-infra/shepherd/shepherd_common"
 	"github.com/mobiledgex/edge-cloud/cloudcommon"
@@ -163,3 +168,168 @@ func TestClusterAutoScaler(t *testing.T) {
 	updateStats(0.7*.99, 0)
 	checkAlert(true, 1)
 }
+
+func TestClusterAutoScalerPredictive(t *testing.T) {
+	ctx := setupLog()
+	defer log.FinishTracer()
+	log.SetDebugLevel(log.DebugLevelMetrics | log.DebugLevelApi)
+
+	*autoScalePredictive = true
+	defer func() {
+		*autoScalePredictive = false
+	}()
+	now := time.Date(2022, 3, 7, 12, 0, 0, 0, time.UTC)
+	forecastNow = func() time.Time {
+		return now
+	}
+	defer func() {
+		forecastNow = time.Now
+	}()
+
+	cluster := testutil.ClusterInstData[2]
+	policy := edgeproto.AutoScalePolicy{}
+	policy.Key.Name = "test-policy"
+	policy.Key.Organization = cluster.Key.Organization
+	policy.MinNodes = 1
+	policy.MaxNodes = 4
+	policy.TargetCpu = 70
+
+	cluster.AutoScalePolicy = policy.Key.Name
+	cluster.Deployment = cloudcommon.DeploymentTypeKubernetes
+	cluster.NumNodes = 2
+
+	edgeproto.InitAutoScalePolicyCache(&AutoScalePoliciesCache)
+	edgeproto.InitClusterInstCache(&ClusterInstCache)
+	edgeproto.InitAlertCache(&AlertCache)
+	AutoScalePoliciesCache.Update(ctx, &policy, 0)
+	ClusterInstCache.Update(ctx, &cluster, 0)
+	defer func() {
+		ClusterInstCache.Delete(ctx, &cluster, 0)
+		AutoScalePoliciesCache.Delete(ctx, &policy, 0)
+	}()
+	worker := &ClusterWorker{}
+	worker.autoScaler.policyName = cluster.AutoScalePolicy
+	worker.clusterInstKey = cluster.Key
+	workerMapMutex.Lock()
+	workerMap = make(map[string]*ClusterWorker)
+	workerMap[getClusterWorkerMapKey(&cluster.Key)] = worker
+	workerMapMutex.Unlock()
+	defer func() {
+		workerMapMutex.Lock()
+		delete(workerMap, getClusterWorkerMapKey(&cluster.Key))
+		workerMapMutex.Unlock()
+	}()
+
+	updateStats := func(cpu float64) {
+		now = now.Add(30 * time.Second)
+		stats := shepherd_common.ClusterMetrics{}
+		stats.AutoScaleCpu = cpu
+		worker.autoScaler.updateClusterStats(ctx, worker.clusterInstKey, &stats)
+		clusterAutoScalerWorkers.WaitIdle()
+	}
+	alert := getAutoScaleAlert(&cluster.Key, 0)
+	checkAlert := func(exists bool, desiredNodes float64) {
+		buf := edgeproto.Alert{}
+		found := AlertCache.Get(alert.GetKey(), &buf)
+		require.Equal(t, exists, found)
+		if exists {
+			require.Equal(t, desiredNodes, buf.Value)
+		}
+	}
+
+	// num nodes is 2, and target is 0.7. Cpu is ramping up but
+	// not enough samples yet to forecast.
+	for _, cpu := range []float64{0.5, 0.6, 0.7, 0.8} {
+		updateStats(cpu)
+		checkAlert(false, 0)
+	}
+	// Current cpu of 0.9 does not need scaling, but forecast
+	// in 5 minutes is 1.9 which needs 3 nodes. The forecast must
+	// need 3 nodes for the 1 minute stabilization window.
+	updateStats(0.9)
+	checkAlert(false, 0)
+	updateStats(0.9)
+	checkAlert(false, 0)
+	updateStats(0.9)
+	checkAlert(true, 3)
+	buf := edgeproto.Alert{}
+	require.True(t, AlertCache.Get(alert.GetKey(), &buf))
+	require.Contains(t, buf.Annotations["reason"], "forecast total cpu")
+
+	out := showAutoScaleForecast(ctx, &edgeproto.DebugRequest{})
+	require.Contains(t, out, `"Policy": "test-policy"`)
+	require.Contains(t, out, `"Samples": 7`)
+	out = showAutoScaleForecast(ctx, &edgeproto.DebugRequest{Args: "other-cluster"})
+	require.Equal(t, "{}", out)
+
+	// load levels off, the forecast scale up is held for the
+	// forecast horizon after the forecast last needed it
+	updateStats(0.9)
+	checkAlert(true, 3)
+	updateStats(0.9)
+	checkAlert(true, 3)
+	for ii := 0; ii < *autoScaleForecastSamples; ii++ {
+		updateStats(0.9)
+	}
+	checkAlert(false, 0)
+}
+
+func TestClusterAutoScalerStabilizeForecast(t *testing.T) {
+	now := time.Date(2022, 3, 7, 12, 0, 0, 0, time.UTC)
+	s := ClusterAutoScaler{}
+	check := func(elapsed time.Duration, forecastNodes, expNodes float64) {
+		nodes, reason := s.stabilizeForecast(now.Add(elapsed), 2, forecastNodes, fmt.Sprintf("forecast %f", forecastNodes))
+		require.Equal(t, expNodes, nodes, "elapsed %s", elapsed)
+		if expNodes > 0 {
+			require.Equal(t, fmt.Sprintf("forecast %f", expNodes), reason)
+		}
+	}
+	// forecast must need more nodes for the stabilization window
+	check(0, 3.5, 0)
+	check(30*time.Second, 3.5, 0)
+	check(45*time.Second, 1.5, 0)
+	check(time.Minute, 3.5, 0)
+	check(2*time.Minute, 3.2, 3.2)
+	// highest forecast is held for the horizon
+	check(150*time.Second, 2.5, 3.2)
+	check(3*time.Minute, 1.5, 3.2)
+	check(6*time.Minute, 1.5, 3.2)
+	check(7*time.Minute, 1.5, 0)
+}
+
+func TestClusterAutoScalerSaveForecasts(t *testing.T) {
+	ctx := setupLog()
+	defer log.FinishTracer()
+
+	dir, err := ioutil.TempDir("", "shepherd-forecast")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	forecastDir = filepath.Join(dir, "cloudlet")
+	defer func() {
+		forecastDir = ""
+	}()
+
+	key := testutil.ClusterInstData[2].Key
+	start := time.Date(2022, 3, 7, 12, 0, 0, 0, time.UTC)
+	s := ClusterAutoScaler{}
+	s.initForecasts(ctx, &key)
+	for ii := 0; ii < 3; ii++ {
+		ts := start.Add(time.Duration(ii) * 40 * time.Minute)
+		if s.cpuForecast.Add(ts, 1.0) {
+			s.saveForecasts(ctx, &key)
+		}
+	}
+	_, err = os.Stat(getForecastFile(&key))
+	require.Nil(t, err)
+
+	// seasonal profile is seeded from the saved profile
+	restarted := ClusterAutoScaler{}
+	restarted.initForecasts(ctx, &key)
+	require.Equal(t, 1, restarted.cpuForecast.GetState(time.Minute).SeasonalBuckets)
+	require.Equal(t, 0, restarted.cpuForecast.GetState(time.Minute).Samples)
+	require.Equal(t, 0, restarted.memForecast.GetState(time.Minute).SeasonalBuckets)
+
+	deleteSavedForecasts(ctx, &key)
+	_, err = os.Stat(getForecastFile(&key))
+	require.True(t, os.IsNotExist(err))
+}
@@ -48,6 +48,7 @@ type ClusterWorker struct {
 	stop           chan struct{}
 	client         ssh.Client
 	autoScaler     ClusterAutoScaler
+	appAutoScalers map[edgeproto.AppInstKey]*AppInstAutoScaler
 }
 
 func NewClusterWorker(ctx context.Context, promAddr string, promPort int32, scrapeInterval time.Duration, pushInterval time.Duration, send func(ctx context.Context, metric *edgeproto.Metric) bool, clusterInst *edgeproto.ClusterInst, kubeNames *k8smgmt.KubeNames, pf platform.Platform) (*ClusterWorker, error) {
@@ -195,6 +196,9 @@ func (p *ClusterWorker) RunNotify() {
 			if p.autoScaler.policyName != "" {
 				p.autoScaler.updateClusterStats(ctx, p.clusterInstKey, clusterStats)
 			}
+			if p.deployment == cloudcommon.DeploymentTypeKubernetes {
+				p.checkAppInstAutoScale(ctx, appStatsMap)
+			}
 
 			// Marshaling and sending only every push interval
 			if p.checkAndSetLastPushMetrics(time.Now()) {
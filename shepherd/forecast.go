@@ -0,0 +1,246 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Short-horizon forecast of a metric, used for predictive auto scaling.
+// This is a Holt-Winters style model: level and trend are fit by double
+// exponential smoothing over the last N samples, and a seasonal profile
+// keeps the average value for each hour of each day of the week.
+// The forecast is the extrapolated level and trend, plus the expected
+// seasonal change between now and the forecast time. The seasonal
+// profile needs a week of history, so it can be saved and restored
+// with GetSeasonal and SetSeasonal to survive restarts.
+
+var (
+	forecastAlpha      = 0.5 // level smoothing
+	forecastBeta       = 0.3 // trend smoothing
+	forecastGamma      = 0.3 // seasonal smoothing, applied once per hour
+	forecastMinSamples = 5
+)
+
+const numSeasonBuckets = 7 * 24
+
+type forecastSample struct {
+	ts    time.Time
+	value float64
+}
+
+type Forecaster struct {
+	maxSamples int
+	samples    []forecastSample // oldest first
+	level      float64
+	trend      float64
+	// seasonal average per hour of the week
+	seasonal      [numSeasonBuckets]float64
+	seasonalCount [numSeasonBuckets]int
+	// running sum for the hour currently being observed
+	curBucket      int
+	curBucketStart time.Time
+	curBucketSum   float64
+	curBucketNum   int
+}
+
+// Model state shown by the debug command
+type ForecastState struct {
+	Samples         int
+	Interval        string `json:",omitempty"`
+	Level           float64
+	Trend           float64
+	SeasonalBuckets int
+	LastValue       float64
+	Forecast        float64 `json:",omitempty"`
+	ForecastAt      string  `json:",omitempty"`
+}
+
+// Seasonal profile, average value and number of samples folded into
+// it for each hour of the week.
+type ForecastSeasonal struct {
+	Values []float64
+	Counts []int
+}
+
+func NewForecaster(maxSamples int) *Forecaster {
+	if maxSamples < forecastMinSamples {
+		maxSamples = forecastMinSamples
+	}
+	return &Forecaster{
+		maxSamples: maxSamples,
+		curBucket:  -1,
+	}
+}
+
+func seasonBucket(ts time.Time) int {
+	return int(ts.Weekday())*24 + ts.Hour()
+}
+
+// Add a sample. Samples are expected at a regular interval.
+// Returns true if the seasonal profile was updated.
+func (s *Forecaster) Add(ts time.Time, value float64) bool {
+	if len(s.samples) > 0 && !ts.After(s.samples[len(s.samples)-1].ts) {
+		// out of order or duplicate
+		return false
+	}
+	updated := s.updateSeasonal(ts, value)
+	s.samples = append(s.samples, forecastSample{ts: ts, value: value})
+	if len(s.samples) > s.maxSamples {
+		s.samples = s.samples[len(s.samples)-s.maxSamples:]
+	}
+	s.fit()
+	return updated
+}
+
+// Fold the average of each completed hour into the seasonal profile.
+func (s *Forecaster) updateSeasonal(ts time.Time, value float64) bool {
+	updated := false
+	hourStart := ts.Truncate(time.Hour)
+	if s.curBucket >= 0 && !hourStart.Equal(s.curBucketStart) {
+		avg := s.curBucketSum / float64(s.curBucketNum)
+		if s.seasonalCount[s.curBucket] == 0 {
+			s.seasonal[s.curBucket] = avg
+		} else {
+			s.seasonal[s.curBucket] = forecastGamma*avg + (1-forecastGamma)*s.seasonal[s.curBucket]
+		}
+		s.seasonalCount[s.curBucket]++
+		s.curBucket = -1
+		updated = true
+	}
+	if s.curBucket < 0 {
+		s.curBucket = seasonBucket(ts)
+		s.curBucketStart = hourStart
+		s.curBucketSum = 0
+		s.curBucketNum = 0
+	}
+	s.curBucketSum += value
+	s.curBucketNum++
+	return updated
+}
+
+func (s *Forecaster) GetSeasonal() *ForecastSeasonal {
+	seasonal := ForecastSeasonal{
+		Values: make([]float64, numSeasonBuckets),
+		Counts: make([]int, numSeasonBuckets),
+	}
+	copy(seasonal.Values, s.seasonal[:])
+	copy(seasonal.Counts, s.seasonalCount[:])
+	return &seasonal
+}
+
+// Seed the seasonal profile, i.e. from one saved before a restart.
+func (s *Forecaster) SetSeasonal(seasonal *ForecastSeasonal) error {
+	if len(seasonal.Values) != numSeasonBuckets || len(seasonal.Counts) != numSeasonBuckets {
+		return fmt.Errorf("invalid seasonal profile with %d values and %d counts, expected %d", len(seasonal.Values), len(seasonal.Counts), numSeasonBuckets)
+	}
+	copy(s.seasonal[:], seasonal.Values)
+	copy(s.seasonalCount[:], seasonal.Counts)
+	return nil
+}
+
+// Fit level and trend over the current samples.
+func (s *Forecaster) fit() {
+	s.level = s.samples[0].value
+	s.trend = 0
+	if len(s.samples) > 1 {
+		s.trend = s.samples[1].value - s.samples[0].value
+	}
+	for _, sample := range s.samples[1:] {
+		lastLevel := s.level
+		s.level = forecastAlpha*sample.value + (1-forecastAlpha)*(s.level+s.trend)
+		s.trend = forecastBeta*(s.level-lastLevel) + (1-forecastBeta)*s.trend
+	}
+}
+
+// Average interval between samples
+func (s *Forecaster) interval() time.Duration {
+	if len(s.samples) < 2 {
+		return 0
+	}
+	total := s.samples[len(s.samples)-1].ts.Sub(s.samples[0].ts)
+	return total / time.Duration(len(s.samples)-1)
+}
+
+// Seasonal value at the given time, interpolated between the
+// centers of the hourly buckets. Returns false if there is no
+// seasonal data for the time.
+func (s *Forecaster) seasonalAt(ts time.Time) (float64, bool) {
+	center := ts.Truncate(time.Hour).Add(30 * time.Minute)
+	other := center.Add(time.Hour)
+	if ts.Before(center) {
+		other = center.Add(-time.Hour)
+	}
+	b1 := seasonBucket(center)
+	b2 := seasonBucket(other)
+	if s.seasonalCount[b1] == 0 || s.seasonalCount[b2] == 0 {
+		return 0, false
+	}
+	frac := ts.Sub(center).Hours()
+	if frac < 0 {
+		frac = -frac
+	}
+	return s.seasonal[b1] + frac*(s.seasonal[b2]-s.seasonal[b1]), true
+}
+
+// Forecast the value at the horizon after the last sample. Returns
+// false if there are not enough samples yet.
+func (s *Forecaster) Forecast(horizon time.Duration) (float64, time.Time, bool) {
+	if len(s.samples) < forecastMinSamples {
+		return 0, time.Time{}, false
+	}
+	interval := s.interval()
+	if interval <= 0 {
+		return 0, time.Time{}, false
+	}
+	last := s.samples[len(s.samples)-1].ts
+	at := last.Add(horizon)
+	steps := float64(horizon) / float64(interval)
+	val := s.level + steps*s.trend
+	seasonalNow, ok1 := s.seasonalAt(last)
+	seasonalAt, ok2 := s.seasonalAt(at)
+	if ok1 && ok2 {
+		val += seasonalAt - seasonalNow
+	}
+	if val < 0 {
+		val = 0
+	}
+	return val, at, true
+}
+
+func (s *Forecaster) GetState(horizon time.Duration) *ForecastState {
+	state := ForecastState{
+		Samples: len(s.samples),
+		Level:   s.level,
+		Trend:   s.trend,
+	}
+	if interval := s.interval(); interval > 0 {
+		state.Interval = interval.String()
+	}
+	for _, count := range s.seasonalCount {
+		if count > 0 {
+			state.SeasonalBuckets++
+		}
+	}
+	if len(s.samples) > 0 {
+		state.LastValue = s.samples[len(s.samples)-1].value
+	}
+	if val, at, ok := s.Forecast(horizon); ok {
+		state.Forecast = val
+		state.ForecastAt = at.Format(time.RFC3339)
+	}
+	return &state
+}
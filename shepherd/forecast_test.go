@@ -0,0 +1,118 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestForecaster(t *testing.T) {
+	// Monday
+	start := time.Date(2022, 3, 7, 0, 0, 0, 0, time.UTC)
+	interval := 30 * time.Second
+	horizon := 5 * time.Minute
+
+	// not enough samples
+	fc := NewForecaster(20)
+	for ii := 0; ii < forecastMinSamples-1; ii++ {
+		fc.Add(start.Add(time.Duration(ii)*interval), 1.0)
+	}
+	_, _, ok := fc.Forecast(horizon)
+	require.False(t, ok)
+
+	// constant series
+	fc.Add(start.Add(time.Duration(forecastMinSamples-1)*interval), 1.0)
+	val, at, ok := fc.Forecast(horizon)
+	require.True(t, ok)
+	require.Equal(t, 1.0, val)
+	require.Equal(t, start.Add(time.Duration(forecastMinSamples-1)*interval+horizon), at)
+
+	// linear ramp is extrapolated
+	fc = NewForecaster(20)
+	for ii := 0; ii < 30; ii++ {
+		fc.Add(start.Add(time.Duration(ii)*interval), 0.1*float64(ii))
+	}
+	val, _, ok = fc.Forecast(horizon)
+	require.True(t, ok)
+	// last value 2.9 plus 10 intervals
+	require.InDelta(t, 3.9, val, 0.0001)
+	state := fc.GetState(horizon)
+	require.Equal(t, 20, state.Samples)
+	require.Equal(t, interval.String(), state.Interval)
+	require.InDelta(t, 0.1, state.Trend, 0.0001)
+
+	// forecast is never negative
+	fc = NewForecaster(20)
+	for ii := 0; ii < 10; ii++ {
+		fc.Add(start.Add(time.Duration(ii)*interval), 1.0-0.1*float64(ii))
+	}
+	val, _, ok = fc.Forecast(horizon)
+	require.True(t, ok)
+	require.Equal(t, 0.0, val)
+
+	// out of order samples are ignored
+	fc.Add(start, 5.0)
+	require.Equal(t, 10, fc.GetState(horizon).Samples)
+
+	// daily pattern, load goes up from 9:00 to 17:00
+	dailyLoad := func(ts time.Time) float64 {
+		if ts.Hour() >= 9 && ts.Hour() < 17 {
+			return 3.0
+		}
+		return 1.0
+	}
+	seasonInterval := 10 * time.Minute
+	horizon = 30 * time.Minute
+	// Monday 8:50 a week later
+	end := start.Add(7*24*time.Hour + 8*time.Hour + 50*time.Minute)
+	fc = NewForecaster(20)
+	for ts := start; !ts.After(end); ts = ts.Add(seasonInterval) {
+		fc.Add(ts, dailyLoad(ts))
+	}
+	state = fc.GetState(horizon)
+	require.Equal(t, numSeasonBuckets, state.SeasonalBuckets)
+	// seasonal 8:50 is 1/3 of the way to the 9:00 step, 9:20 is 5/6
+	// of the way, so the expected change is half the step.
+	val, _, ok = fc.Forecast(horizon)
+	require.True(t, ok)
+	require.InDelta(t, 2.0, val, 0.0001)
+
+	seasonal := fc.GetSeasonal()
+
+	// same without a week of history has no seasonal component
+	fc = NewForecaster(20)
+	for ts := end.Add(-24 * time.Hour); !ts.After(end); ts = ts.Add(seasonInterval) {
+		fc.Add(ts, dailyLoad(ts))
+	}
+	val, _, ok = fc.Forecast(horizon)
+	require.True(t, ok)
+	require.InDelta(t, 1.0, val, 0.0001)
+
+	// seeded with the saved seasonal profile, i.e. after a restart
+	fc = NewForecaster(20)
+	require.Nil(t, fc.SetSeasonal(seasonal))
+	for ts := end.Add(-24 * time.Hour); !ts.After(end); ts = ts.Add(seasonInterval) {
+		fc.Add(ts, dailyLoad(ts))
+	}
+	val, _, ok = fc.Forecast(horizon)
+	require.True(t, ok)
+	require.InDelta(t, 2.0, val, 0.0001)
+	err := fc.SetSeasonal(&ForecastSeasonal{Values: []float64{1}})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "invalid seasonal profile")
+}
@@ -0,0 +1,386 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mobiledgex/edge-cloud/edgeproto"
+	"github.com/mobiledgex/edge-cloud/log"
+)
+
+// The metrics spool keeps metrics on disk while they cannot be sent
+// over notify, i.e. while the connection to the CRM is down, and replays
+// them in order once the connection is back. Metrics are appended to
+// segment files in the spool dir. The oldest segments are removed
+// once the spool exceeds its size or age limits.
+
+var (
+	spoolSegmentMaxSize int64 = 1024 * 1024
+	spoolReplayDelay          = 100 * time.Millisecond
+	spoolSegmentSuffix        = ".spool"
+)
+
+var metricsSpool *MetricsSpool
+
+type spoolSegment struct {
+	seq        uint64
+	path       string
+	size       int64
+	numMetrics int
+	modTime    time.Time
+}
+
+type MetricsSpool struct {
+	dir       string
+	maxSize   int64
+	maxAge    time.Duration
+	send      func(ctx context.Context, metric *edgeproto.Metric) bool
+	mux       sync.Mutex
+	segments  []*spoolSegment // oldest first
+	file      *os.File        // open for append, always the last segment
+	nextSeq   uint64
+	size      int64
+	replaying bool
+	replayed  uint64
+	dropped   uint64
+}
+
+// Spool stats shown by the debug command
+type MetricsSpoolStats struct {
+	Dir             string
+	Segments        int
+	Metrics         int
+	Bytes           int64
+	MaxBytes        int64
+	MaxAge          string
+	OldestAge       string `json:",omitempty"`
+	Replaying       bool
+	ReplayedMetrics uint64
+	DroppedMetrics  uint64
+}
+
+func NewMetricsSpool(ctx context.Context, dir string, maxSize int64, maxAge time.Duration, send func(ctx context.Context, metric *edgeproto.Metric) bool) (*MetricsSpool, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create metrics spool dir %s, %v", dir, err)
+	}
+	s := &MetricsSpool{
+		dir:     dir,
+		maxSize: maxSize,
+		maxAge:  maxAge,
+		send:    send,
+		nextSeq: 1,
+	}
+	// pick up metrics spooled before a restart
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metrics spool dir %s, %v", dir, err)
+	}
+	for _, fi := range files {
+		if fi.IsDir() || !strings.HasSuffix(fi.Name(), spoolSegmentSuffix) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(fi.Name(), spoolSegmentSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		seg := &spoolSegment{
+			seq:     seq,
+			path:    filepath.Join(dir, fi.Name()),
+			size:    fi.Size(),
+			modTime: fi.ModTime(),
+		}
+		metrics, err := readSpoolSegment(seg.path)
+		if err != nil {
+			log.SpanLog(ctx, log.DebugLevelMetrics, "metrics spool segment is truncated", "path", seg.path, "err", err)
+		}
+		seg.numMetrics = len(metrics)
+		s.segments = append(s.segments, seg)
+		s.size += seg.size
+		if seq >= s.nextSeq {
+			s.nextSeq = seq + 1
+		}
+	}
+	sort.Slice(s.segments, func(i, j int) bool {
+		return s.segments[i].seq < s.segments[j].seq
+	})
+	s.enforceLimits(ctx)
+	return s, nil
+}
+
+// Send the metric, or spool it if it cannot be sent. While there are
+// spooled metrics, new metrics are spooled as well so that they are
+// replayed in order.
+func (s *MetricsSpool) Send(ctx context.Context, metric *edgeproto.Metric) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if len(s.segments) == 0 && s.send(ctx, metric) {
+		return true
+	}
+	if err := s.add(ctx, metric); err != nil {
+		log.SpanLog(ctx, log.DebugLevelMetrics, "failed to spool metric", "metric", metric.Name, "err", err)
+	}
+	return false
+}
+
+func (s *MetricsSpool) add(ctx context.Context, metric *edgeproto.Metric) error {
+	data, err := metric.Marshal()
+	if err != nil {
+		return err
+	}
+	var seg *spoolSegment
+	if s.file != nil {
+		seg = s.segments[len(s.segments)-1]
+		if seg.size >= s.segmentMaxSize() {
+			s.closeFile()
+			seg = nil
+		}
+	}
+	if seg == nil {
+		seg = &spoolSegment{
+			seq:  s.nextSeq,
+			path: filepath.Join(s.dir, fmt.Sprintf("%016d%s", s.nextSeq, spoolSegmentSuffix)),
+		}
+		s.file, err = os.OpenFile(seg.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			s.file = nil
+			return err
+		}
+		s.nextSeq++
+		s.segments = append(s.segments, seg)
+		log.SpanLog(ctx, log.DebugLevelMetrics, "metrics spool started new segment", "path", seg.path)
+	}
+	n, err := s.file.Write(encodeSpoolRecord(data))
+	seg.size += int64(n)
+	s.size += int64(n)
+	if err != nil {
+		// partial records are ignored when the segment is read
+		s.closeFile()
+		return err
+	}
+	seg.numMetrics++
+	seg.modTime = time.Now()
+	s.enforceLimits(ctx)
+	return nil
+}
+
+func (s *MetricsSpool) segmentMaxSize() int64 {
+	size := s.maxSize / 4
+	if size > spoolSegmentMaxSize {
+		size = spoolSegmentMaxSize
+	}
+	return size
+}
+
+func (s *MetricsSpool) closeFile() {
+	if s.file != nil {
+		s.file.Close()
+		s.file = nil
+	}
+}
+
+// Remove the oldest segments until the spool is within its limits.
+func (s *MetricsSpool) enforceLimits(ctx context.Context) {
+	for len(s.segments) > 0 {
+		oldest := s.segments[0]
+		reason := ""
+		if s.size > s.maxSize {
+			reason = "size"
+		} else if s.maxAge > 0 && time.Since(oldest.modTime) > s.maxAge {
+			reason = "age"
+		} else {
+			return
+		}
+		log.SpanLog(ctx, log.DebugLevelMetrics, "metrics spool over limit, dropping oldest segment", "limit", reason, "path", oldest.path, "numMetrics", oldest.numMetrics)
+		s.dropped += uint64(oldest.numMetrics)
+		s.removeOldest()
+	}
+}
+
+func (s *MetricsSpool) removeOldest() {
+	seg := s.segments[0]
+	if len(s.segments) == 1 {
+		s.closeFile()
+	}
+	os.Remove(seg.path)
+	s.size -= seg.size
+	s.segments = s.segments[1:]
+}
+
+// Replay spooled metrics in the background. Called when the notify
+// connection is (re-)established.
+func (s *MetricsSpool) Replay(ctx context.Context) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.replaying || len(s.segments) == 0 {
+		return
+	}
+	s.replaying = true
+	go s.replay()
+}
+
+func (s *MetricsSpool) replay() {
+	span := log.StartSpan(log.DebugLevelMetrics, "metrics spool replay")
+	defer span.Finish()
+	ctx := log.ContextWithSpan(context.Background(), span)
+
+	for {
+		done, err := s.replaySegment(ctx)
+		if err != nil {
+			log.SpanLog(ctx, log.DebugLevelMetrics, "metrics spool replay stopped", "err", err)
+		}
+		if done {
+			return
+		}
+		// don't flood the notify send queue
+		time.Sleep(spoolReplayDelay)
+	}
+}
+
+// Send the metrics in the oldest segment. Returns true if replay is
+// done, either because the spool is empty or metrics cannot be sent.
+func (s *MetricsSpool) replaySegment(ctx context.Context) (bool, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.enforceLimits(ctx)
+	if len(s.segments) == 0 {
+		s.replaying = false
+		log.SpanLog(ctx, log.DebugLevelMetrics, "metrics spool replay done", "replayed", s.replayed)
+		return true, nil
+	}
+	seg := s.segments[0]
+	if len(s.segments) == 1 {
+		// new metrics go to a new segment
+		s.closeFile()
+	}
+	metrics, err := readSpoolSegment(seg.path)
+	if err != nil {
+		log.SpanLog(ctx, log.DebugLevelMetrics, "metrics spool segment is truncated", "path", seg.path, "err", err)
+	}
+	for ii, metric := range metrics {
+		if s.send(ctx, metric) {
+			s.replayed++
+			continue
+		}
+		// disconnected again, keep the unsent metrics
+		s.replaying = false
+		return true, s.rewriteSegment(seg, metrics[ii:])
+	}
+	s.removeOldest()
+	return false, nil
+}
+
+func (s *MetricsSpool) rewriteSegment(seg *spoolSegment, metrics []*edgeproto.Metric) error {
+	tmpPath := seg.path + ".tmp"
+	buf := []byte{}
+	for _, metric := range metrics {
+		data, err := metric.Marshal()
+		if err != nil {
+			return err
+		}
+		buf = append(buf, encodeSpoolRecord(data)...)
+	}
+	if err := ioutil.WriteFile(tmpPath, buf, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, seg.path); err != nil {
+		return err
+	}
+	s.size += int64(len(buf)) - seg.size
+	seg.size = int64(len(buf))
+	seg.numMetrics = len(metrics)
+	return nil
+}
+
+// Records are the length of the marshaled metric as a uvarint,
+// followed by the marshaled metric.
+func encodeSpoolRecord(data []byte) []byte {
+	buf := make([]byte, binary.MaxVarintLen64, binary.MaxVarintLen64+len(data))
+	n := binary.PutUvarint(buf, uint64(len(data)))
+	return append(buf[:n], data...)
+}
+
+// Read the metrics in the segment. If the segment is truncated, the
+// metrics before the bad record are returned along with the error.
+func readSpoolSegment(path string) ([]*edgeproto.Metric, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	rd := bufio.NewReader(f)
+	metrics := []*edgeproto.Metric{}
+	for {
+		l, err := binary.ReadUvarint(rd)
+		if err == io.EOF {
+			return metrics, nil
+		}
+		if err != nil {
+			return metrics, err
+		}
+		data := make([]byte, l)
+		if _, err := io.ReadFull(rd, data); err != nil {
+			return metrics, err
+		}
+		metric := &edgeproto.Metric{}
+		if err := metric.Unmarshal(data); err != nil {
+			return metrics, err
+		}
+		metrics = append(metrics, metric)
+	}
+}
+
+func (s *MetricsSpool) GetStats() *MetricsSpoolStats {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	stats := MetricsSpoolStats{
+		Dir:             s.dir,
+		Segments:        len(s.segments),
+		Bytes:           s.size,
+		MaxBytes:        s.maxSize,
+		MaxAge:          s.maxAge.String(),
+		Replaying:       s.replaying,
+		ReplayedMetrics: s.replayed,
+		DroppedMetrics:  s.dropped,
+	}
+	for _, seg := range s.segments {
+		stats.Metrics += seg.numMetrics
+	}
+	if len(s.segments) > 0 {
+		stats.OldestAge = time.Since(s.segments[0].modTime).Round(time.Second).String()
+	}
+	return &stats
+}
+
+func (s *MetricsSpool) ShowStats() string {
+	out, err := json.MarshalIndent(s.GetStats(), "", "  ")
+	if err != nil {
+		return err.Error()
+	}
+	return string(out)
+}
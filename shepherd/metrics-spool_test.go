@@ -0,0 +1,176 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mobiledgex/edge-cloud/edgeproto"
+	"github.com/mobiledgex/edge-cloud/log"
+	"github.com/stretchr/testify/require"
+)
+
+type testSpoolSender struct {
+	mux       sync.Mutex
+	connected bool
+	sent      []int64
+}
+
+func (s *testSpoolSender) send(ctx context.Context, metric *edgeproto.Metric) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if !s.connected {
+		return false
+	}
+	s.sent = append(s.sent, metric.Timestamp.Seconds)
+	return true
+}
+
+func (s *testSpoolSender) setConnected(connected bool) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.connected = connected
+}
+
+func (s *testSpoolSender) getSent() []int64 {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return append([]int64{}, s.sent...)
+}
+
+func waitSpoolReplay(t *testing.T, spool *MetricsSpool) {
+	for ii := 0; ii < 100; ii++ {
+		if !spool.GetStats().Replaying {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Fail(t, "timed out waiting for replay")
+}
+
+func TestMetricsSpool(t *testing.T) {
+	log.SetDebugLevel(log.DebugLevelMetrics)
+	log.InitTracer(nil)
+	defer log.FinishTracer()
+	ctx := log.StartTestSpan(context.Background())
+
+	spoolReplayDelay = time.Millisecond
+	dir, err := ioutil.TempDir("", "metrics-spool")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	data, err := testRemoteWriteMetric(1).Marshal()
+	require.Nil(t, err)
+	recordSize := int64(len(encodeSpoolRecord(data)))
+
+	sender := testSpoolSender{connected: true}
+	// segments hold 2 metrics, spool holds 4 segments
+	maxSize := 8 * recordSize
+	spool, err := NewMetricsSpool(ctx, dir, maxSize, time.Hour, sender.send)
+	require.Nil(t, err)
+
+	// connected, metrics are sent directly
+	require.True(t, spool.Send(ctx, testRemoteWriteMetric(1)))
+	require.Equal(t, []int64{1}, sender.getSent())
+	require.Equal(t, 0, spool.GetStats().Metrics)
+
+	// disconnected, metrics are spooled
+	sender.setConnected(false)
+	for ii := int64(2); ii <= 6; ii++ {
+		require.False(t, spool.Send(ctx, testRemoteWriteMetric(ii)))
+	}
+	stats := spool.GetStats()
+	require.Equal(t, 3, stats.Segments)
+	require.Equal(t, 5, stats.Metrics)
+	require.Equal(t, 5*recordSize, stats.Bytes)
+
+	// while metrics are spooled, new metrics are spooled too so
+	// that order is preserved
+	sender.setConnected(true)
+	require.False(t, spool.Send(ctx, testRemoteWriteMetric(7)))
+	require.Equal(t, []int64{1}, sender.getSent())
+
+	// size limit drops the oldest segment
+	for ii := int64(8); ii <= 10; ii++ {
+		require.False(t, spool.Send(ctx, testRemoteWriteMetric(ii)))
+	}
+	stats = spool.GetStats()
+	require.Equal(t, 4, stats.Segments)
+	require.Equal(t, 7, stats.Metrics)
+	require.Equal(t, uint64(2), stats.DroppedMetrics)
+
+	// spooled metrics survive a restart
+	spool.mux.Lock()
+	spool.closeFile()
+	spool.mux.Unlock()
+	spool, err = NewMetricsSpool(ctx, dir, maxSize, time.Hour, sender.send)
+	require.Nil(t, err)
+	require.Equal(t, 7, spool.GetStats().Metrics)
+
+	// replay in order
+	spool.Replay(ctx)
+	waitSpoolReplay(t, spool)
+	require.Equal(t, []int64{1, 4, 5, 6, 7, 8, 9, 10}, sender.getSent())
+	stats = spool.GetStats()
+	require.Equal(t, 0, stats.Segments)
+	require.Equal(t, int64(0), stats.Bytes)
+	require.Equal(t, uint64(7), stats.ReplayedMetrics)
+	files, err := ioutil.ReadDir(dir)
+	require.Nil(t, err)
+	require.Equal(t, 0, len(files))
+
+	// spool is empty, send directly again
+	require.True(t, spool.Send(ctx, testRemoteWriteMetric(11)))
+
+	// metrics not sent by a replay stay spooled
+	sender.setConnected(false)
+	for ii := int64(12); ii <= 14; ii++ {
+		require.False(t, spool.Send(ctx, testRemoteWriteMetric(ii)))
+	}
+	// simulate metric 12 being sent before the disconnect
+	spool.mux.Lock()
+	metrics, err := readSpoolSegment(spool.segments[0].path)
+	require.Nil(t, err)
+	require.Equal(t, 2, len(metrics))
+	err = spool.rewriteSegment(spool.segments[0], metrics[1:])
+	require.Nil(t, err)
+	spool.mux.Unlock()
+	require.Equal(t, 2, spool.GetStats().Metrics)
+	spool.Replay(ctx)
+	waitSpoolReplay(t, spool)
+	require.Equal(t, 2, spool.GetStats().Metrics)
+	sender.setConnected(true)
+	spool.Replay(ctx)
+	waitSpoolReplay(t, spool)
+	require.Equal(t, []int64{1, 4, 5, 6, 7, 8, 9, 10, 11, 13, 14}, sender.getSent())
+
+	// age limit drops old segments
+	sender.setConnected(false)
+	require.False(t, spool.Send(ctx, testRemoteWriteMetric(15)))
+	require.False(t, spool.Send(ctx, testRemoteWriteMetric(16)))
+	spool.mux.Lock()
+	spool.segments[0].modTime = time.Now().Add(-2 * time.Hour)
+	spool.mux.Unlock()
+	require.False(t, spool.Send(ctx, testRemoteWriteMetric(17)))
+	stats = spool.GetStats()
+	require.Equal(t, 1, stats.Metrics)
+	require.Equal(t, uint64(2), stats.DroppedMetrics)
+	require.Contains(t, spool.ShowStats(), `"Metrics": 1`)
+}
@@ -117,6 +117,21 @@ func collectAppPrometheusMetrics(ctx context.Context, p *K8sClusterStats) map[sh
 			}
 		}
 	}
+	// Get Pod Mem usage percentage
+	resp, err = promutils.GetPromMetrics(ctx, p.promAddr, promutils.PromQMemPercentPodUrlEncoded, p.client)
+	if err == nil && resp.Status == "success" {
+		for _, metric := range resp.Data.Result {
+			// skip system pods
+			if metric.Labels.App == "" {
+				continue
+			}
+			stat := getAppMetricFromPrometheusData(p, appStatsMap, &metric)
+			//copy only if we can parse the value
+			if val, err := strconv.ParseFloat(metric.Values[1].(string), 64); err == nil {
+				stat.MemPercent = val
+			}
+		}
+	}
 	// Get Pod Disk usage
 	resp, err = promutils.GetPromMetrics(ctx, p.promAddr, promutils.PromQDiskPodUrlEncoded, p.client)
 	if err == nil && resp.Status == "success" {
@@ -452,6 +452,7 @@ func ProxyScraper(done chan bool) {
 						for _, datapoint := range influxData {
 							metricsSendFunc(context.Background(), datapoint)
 						}
+						checkAutoProvResume(ctx, &v.Key, metrics)
 						// update cluster stats
 						if stat, found := clusterStats[v.ClusterInstKey]; found {
 							stat.NetSent += totalSentTcp + totalSentUdp
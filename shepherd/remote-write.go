@@ -0,0 +1,331 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/mobiledgex/edge-cloud/edgeproto"
+	"github.com/mobiledgex/edge-cloud/log"
+)
+
+// Prometheus remote write of the metrics that are sent over notify,
+// so that they can be stored in Prometheus or Thanos alongside the
+// custom app metrics. Samples are buffered in memory and retried
+// while the remote write endpoint is unreachable.
+
+var (
+	remoteWriteMaxBatch      = 2000
+	remoteWriteMaxRetryDelay = 5 * time.Minute
+	remoteWriteTimeout       = 30 * time.Second
+)
+
+var remoteWriter *RemoteWriter
+
+type promLabel struct {
+	name  string
+	value string
+}
+
+type promSample struct {
+	labels    []promLabel
+	value     float64
+	timestamp int64 // milliseconds
+}
+
+type RemoteWriter struct {
+	url           string
+	region        string
+	interval      time.Duration
+	maxSamples    int
+	client        *http.Client
+	mux           sync.Mutex
+	pending       []promSample
+	dropped       int
+	droppedLogged int
+	stop          chan struct{}
+	wg            sync.WaitGroup
+}
+
+func NewRemoteWriter(url, region string, interval time.Duration, maxSamples int) *RemoteWriter {
+	return &RemoteWriter{
+		url:        url,
+		region:     region,
+		interval:   interval,
+		maxSamples: maxSamples,
+		client: &http.Client{
+			Timeout: remoteWriteTimeout,
+		},
+		stop: make(chan struct{}),
+	}
+}
+
+// Replace characters that are not valid in Prometheus metric
+// and label names.
+func promName(name string) string {
+	var sb strings.Builder
+	for ii, c := range name {
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_' || (c >= '0' && c <= '9' && ii > 0) {
+			sb.WriteRune(c)
+		} else {
+			sb.WriteRune('_')
+		}
+	}
+	return sb.String()
+}
+
+// Convert the metric to samples. Each value becomes a separate
+// time series named <metric>_<value>, e.g. cloudlet_utilization_vCpuUsed.
+// Metric tags and string values become labels, and the region label
+// is added to all samples.
+func (s *RemoteWriter) getSamples(metric *edgeproto.Metric) []promSample {
+	labels := []promLabel{}
+	if s.region != "" {
+		labels = append(labels, promLabel{name: "region", value: s.region})
+	}
+	for _, tag := range metric.Tags {
+		if tag.Val == "" {
+			continue
+		}
+		labels = append(labels, promLabel{name: promName(tag.Name), value: tag.Val})
+	}
+	for _, val := range metric.Vals {
+		if sval, ok := val.Value.(*edgeproto.MetricVal_Sval); ok && sval.Sval != "" {
+			labels = append(labels, promLabel{name: promName(val.Name), value: sval.Sval})
+		}
+	}
+	ts := metric.Timestamp.Seconds*1000 + int64(metric.Timestamp.Nanos)/int64(time.Millisecond)
+	if ts == 0 {
+		ts = time.Now().UnixNano() / int64(time.Millisecond)
+	}
+	samples := []promSample{}
+	for _, val := range metric.Vals {
+		var value float64
+		switch v := val.Value.(type) {
+		case *edgeproto.MetricVal_Dval:
+			value = v.Dval
+		case *edgeproto.MetricVal_Ival:
+			value = float64(v.Ival)
+		case *edgeproto.MetricVal_Bval:
+			if v.Bval {
+				value = 1
+			}
+		default:
+			continue
+		}
+		sampleLabels := make([]promLabel, 0, len(labels)+1)
+		sampleLabels = append(sampleLabels, promLabel{
+			name:  "__name__",
+			value: promName(metric.Name + "_" + val.Name),
+		})
+		sampleLabels = append(sampleLabels, labels...)
+		// remote write requires labels sorted by name
+		sort.Slice(sampleLabels, func(i, j int) bool {
+			return sampleLabels[i].name < sampleLabels[j].name
+		})
+		samples = append(samples, promSample{
+			labels:    sampleLabels,
+			value:     value,
+			timestamp: ts,
+		})
+	}
+	return samples
+}
+
+// Queue the metric for remote write. If the buffer is full the
+// oldest samples are dropped.
+func (s *RemoteWriter) AddMetric(metric *edgeproto.Metric) {
+	samples := s.getSamples(metric)
+	if len(samples) == 0 {
+		return
+	}
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.pending = append(s.pending, samples...)
+	if over := len(s.pending) - s.maxSamples; over > 0 {
+		s.pending = s.pending[over:]
+		s.dropped += over
+	}
+}
+
+func (s *RemoteWriter) NumPending() int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return len(s.pending)
+}
+
+func (s *RemoteWriter) Start() {
+	s.wg.Add(1)
+	go s.run()
+}
+
+func (s *RemoteWriter) Stop() {
+	close(s.stop)
+	s.wg.Wait()
+}
+
+func (s *RemoteWriter) run() {
+	defer s.wg.Done()
+	delay := s.interval
+	for {
+		select {
+		case <-time.After(delay):
+		case <-s.stop:
+			return
+		}
+		span := log.StartSpan(log.DebugLevelMetrics, "remote-write")
+		ctx := log.ContextWithSpan(context.Background(), span)
+		err := s.flush(ctx)
+		if err != nil {
+			// back off while the endpoint is unreachable
+			delay *= 2
+			if delay > remoteWriteMaxRetryDelay {
+				delay = remoteWriteMaxRetryDelay
+			}
+			log.SpanLog(ctx, log.DebugLevelMetrics, "remote write failed, will retry", "url", s.url, "pending", s.NumPending(), "retryIn", delay.String(), "err", err)
+		} else {
+			delay = s.interval
+		}
+		span.Finish()
+	}
+}
+
+// Send all pending samples in batches. Samples stay queued if they
+// could not be sent due to a retryable error.
+func (s *RemoteWriter) flush(ctx context.Context) error {
+	s.mux.Lock()
+	if s.dropped > s.droppedLogged {
+		log.SpanLog(ctx, log.DebugLevelMetrics, "remote write buffer full, dropped oldest samples", "dropped", s.dropped-s.droppedLogged)
+		s.droppedLogged = s.dropped
+	}
+	s.mux.Unlock()
+	for {
+		s.mux.Lock()
+		num := len(s.pending)
+		if num > remoteWriteMaxBatch {
+			num = remoteWriteMaxBatch
+		}
+		batch := s.pending[:num]
+		dropped := s.dropped
+		s.mux.Unlock()
+		if num == 0 {
+			return nil
+		}
+		retry, err := s.send(ctx, batch)
+		if err != nil && retry {
+			return err
+		}
+		if err != nil {
+			// endpoint rejected the data, retrying won't help
+			log.SpanLog(ctx, log.DebugLevelMetrics, "remote write rejected samples, dropping them", "num", num, "err", err)
+		}
+		s.mux.Lock()
+		// samples may have been dropped from the front of the
+		// buffer while the batch was being sent
+		num -= s.dropped - dropped
+		if num > 0 {
+			s.pending = s.pending[num:]
+		}
+		s.mux.Unlock()
+	}
+}
+
+// Send the samples. Returns whether the send should be retried
+// if it failed.
+func (s *RemoteWriter) send(ctx context.Context, samples []promSample) (bool, error) {
+	data := snappy.Encode(nil, encodeWriteRequest(samples))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(data))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		return false, nil
+	}
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("remote write returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	// server errors and throttling are retryable, other client
+	// errors mean the data is bad.
+	retry := resp.StatusCode/100 == 5 || resp.StatusCode == http.StatusTooManyRequests
+	return retry, err
+}
+
+// Protobuf encoding of the remote write WriteRequest message:
+//
+//	message WriteRequest { repeated TimeSeries timeseries = 1; }
+//	message TimeSeries { repeated Label labels = 1; repeated Sample samples = 2; }
+//	message Label { string name = 1; string value = 2; }
+//	message Sample { double value = 1; int64 timestamp = 2; }
+const (
+	protoWireVarint  = 0
+	protoWireFixed64 = 1
+	protoWireBytes   = 2
+)
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	return append(buf, tmp[:n]...)
+}
+
+func appendProtoKey(buf []byte, field, wireType int) []byte {
+	return appendUvarint(buf, uint64(field<<3|wireType))
+}
+
+func appendProtoBytes(buf []byte, field int, data []byte) []byte {
+	buf = appendProtoKey(buf, field, protoWireBytes)
+	buf = appendUvarint(buf, uint64(len(data)))
+	return append(buf, data...)
+}
+
+func encodeWriteRequest(samples []promSample) []byte {
+	req := []byte{}
+	for _, sample := range samples {
+		ts := []byte{}
+		for _, label := range sample.labels {
+			lb := appendProtoBytes(nil, 1, []byte(label.name))
+			lb = appendProtoBytes(lb, 2, []byte(label.value))
+			ts = appendProtoBytes(ts, 1, lb)
+		}
+		var val [8]byte
+		binary.LittleEndian.PutUint64(val[:], math.Float64bits(sample.value))
+		sb := appendProtoKey(nil, 1, protoWireFixed64)
+		sb = append(sb, val[:]...)
+		sb = appendProtoKey(sb, 2, protoWireVarint)
+		sb = appendUvarint(sb, uint64(sample.timestamp))
+		ts = appendProtoBytes(ts, 2, sb)
+		req = appendProtoBytes(req, 1, ts)
+	}
+	return req
+}
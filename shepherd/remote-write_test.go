@@ -0,0 +1,246 @@
+// Copyright 2022 MobiledgeX, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/golang/snappy"
+	"github.com/mobiledgex/edge-cloud/edgeproto"
+	"github.com/mobiledgex/edge-cloud/log"
+	"github.com/stretchr/testify/require"
+)
+
+type testSeries struct {
+	labels    map[string]string
+	value     float64
+	timestamp int64
+}
+
+// Iterate over the fields of an encoded protobuf message
+func testProtoFields(t *testing.T, buf []byte, cb func(field int, data []byte, num uint64)) {
+	for len(buf) > 0 {
+		key, n := binary.Uvarint(buf)
+		require.True(t, n > 0)
+		buf = buf[n:]
+		field := int(key >> 3)
+		switch key & 0x7 {
+		case protoWireVarint:
+			val, n := binary.Uvarint(buf)
+			require.True(t, n > 0)
+			buf = buf[n:]
+			cb(field, nil, val)
+		case protoWireFixed64:
+			require.True(t, len(buf) >= 8)
+			cb(field, nil, binary.LittleEndian.Uint64(buf[:8]))
+			buf = buf[8:]
+		case protoWireBytes:
+			l, n := binary.Uvarint(buf)
+			require.True(t, n > 0)
+			buf = buf[n:]
+			require.True(t, uint64(len(buf)) >= l)
+			cb(field, buf[:l], 0)
+			buf = buf[l:]
+		default:
+			require.Fail(t, "unexpected wire type", "key", key)
+		}
+	}
+}
+
+func testDecodeWriteRequest(t *testing.T, data []byte) []testSeries {
+	series := []testSeries{}
+	testProtoFields(t, data, func(field int, tsData []byte, _ uint64) {
+		require.Equal(t, 1, field)
+		ts := testSeries{
+			labels: map[string]string{},
+		}
+		lastName := ""
+		testProtoFields(t, tsData, func(field int, data []byte, _ uint64) {
+			switch field {
+			case 1:
+				var name, value string
+				testProtoFields(t, data, func(field int, data []byte, _ uint64) {
+					if field == 1 {
+						name = string(data)
+					} else {
+						value = string(data)
+					}
+				})
+				require.True(t, lastName < name, "labels must be sorted")
+				lastName = name
+				ts.labels[name] = value
+			case 2:
+				testProtoFields(t, data, func(field int, _ []byte, num uint64) {
+					if field == 1 {
+						ts.value = math.Float64frombits(num)
+					} else {
+						ts.timestamp = int64(num)
+					}
+				})
+			}
+		})
+		series = append(series, ts)
+	})
+	return series
+}
+
+type testRemoteWriteServer struct {
+	mux      sync.Mutex
+	requests [][]byte
+	failures int
+	status   int
+}
+
+func (s *testRemoteWriteServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.failures > 0 {
+		s.failures--
+		w.WriteHeader(s.status)
+		return
+	}
+	if r.Header.Get("Content-Encoding") != "snappy" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	data, err := snappy.Decode(nil, body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	s.requests = append(s.requests, data)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *testRemoteWriteServer) getSeries(t *testing.T) []testSeries {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	series := []testSeries{}
+	for _, data := range s.requests {
+		series = append(series, testDecodeWriteRequest(t, data)...)
+	}
+	return series
+}
+
+func testRemoteWriteMetric(ts int64) *edgeproto.Metric {
+	metric := edgeproto.Metric{
+		Name:      "appinst-cpu",
+		Timestamp: types.Timestamp{Seconds: ts},
+	}
+	metric.AddTag("app", "testapp")
+	metric.AddTag("apporg", "testorg")
+	metric.AddTag("ver", "1.0")
+	metric.AddTag("cluster", "testcluster")
+	metric.AddTag("cloudlet", "")
+	metric.AddStringVal("pod", "testpod")
+	metric.AddDoubleVal("cpu", 10.5)
+	return &metric
+}
+
+func TestRemoteWrite(t *testing.T) {
+	log.SetDebugLevel(log.DebugLevelMetrics)
+	log.InitTracer(nil)
+	defer log.FinishTracer()
+	ctx := log.StartTestSpan(context.Background())
+
+	// names and labels
+	writer := NewRemoteWriter("", "local", time.Second, 3)
+	samples := writer.getSamples(testRemoteWriteMetric(100))
+	require.Equal(t, 1, len(samples))
+	require.Equal(t, []promLabel{
+		{"__name__", "appinst_cpu_cpu"},
+		{"app", "testapp"},
+		{"apporg", "testorg"},
+		{"cluster", "testcluster"},
+		{"pod", "testpod"},
+		{"region", "local"},
+		{"ver", "1.0"},
+	}, samples[0].labels)
+	require.Equal(t, 10.5, samples[0].value)
+	require.Equal(t, int64(100000), samples[0].timestamp)
+
+	// buffer drops oldest samples when full
+	for ii := int64(1); ii <= 5; ii++ {
+		writer.AddMetric(testRemoteWriteMetric(ii))
+	}
+	require.Equal(t, 3, writer.NumPending())
+	require.Equal(t, int64(3000), writer.pending[0].timestamp)
+
+	server := testRemoteWriteServer{}
+	httpServer := httptest.NewServer(&server)
+	defer httpServer.Close()
+	writer.url = httpServer.URL
+
+	// retryable errors keep the samples buffered
+	server.failures = 1
+	server.status = http.StatusServiceUnavailable
+	err := writer.flush(ctx)
+	require.NotNil(t, err)
+	require.Equal(t, 3, writer.NumPending())
+	require.Equal(t, 0, len(server.getSeries(t)))
+
+	err = writer.flush(ctx)
+	require.Nil(t, err)
+	require.Equal(t, 0, writer.NumPending())
+	series := server.getSeries(t)
+	require.Equal(t, 3, len(series))
+	for ii, ts := range series {
+		require.Equal(t, "appinst_cpu_cpu", ts.labels["__name__"])
+		require.Equal(t, "testpod", ts.labels["pod"])
+		require.Equal(t, "local", ts.labels["region"])
+		require.Equal(t, 10.5, ts.value)
+		require.Equal(t, int64(ii+3)*1000, ts.timestamp)
+	}
+
+	// bad requests are dropped
+	writer.AddMetric(testRemoteWriteMetric(6))
+	server.failures = 1
+	server.status = http.StatusBadRequest
+	err = writer.flush(ctx)
+	require.Nil(t, err)
+	require.Equal(t, 0, writer.NumPending())
+
+	// unreachable endpoint is retried by the run loop
+	writer.url = "http://127.0.0.1:1"
+	writer.AddMetric(testRemoteWriteMetric(7))
+	err = writer.flush(ctx)
+	require.NotNil(t, err)
+	require.Equal(t, 1, writer.NumPending())
+	writer.url = httpServer.URL
+	writer.interval = 10 * time.Millisecond
+	writer.Start()
+	for ii := 0; ii < 100 && writer.NumPending() > 0; ii++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	writer.Stop()
+	require.Equal(t, 0, writer.NumPending())
+	series = server.getSeries(t)
+	require.Equal(t, 4, len(series))
+	require.Equal(t, int64(7000), series[3].timestamp)
+}
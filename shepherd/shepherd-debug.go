@@ -16,6 +16,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -31,6 +32,8 @@ func InitDebug(nodeMgr *node.NodeMgr) {
 	nodeMgr.Debug.AddDebugFunc("reset-scrape-interval", resetScrapeInterval)
 	nodeMgr.Debug.AddDebugFunc("show-scrape-interval", showScrapeInterval)
 	nodeMgr.Debug.AddDebugFunc("show-platform-active", showPlatformActive)
+	nodeMgr.Debug.AddDebugFunc("show-metrics-spool", showMetricsSpool)
+	nodeMgr.Debug.AddDebugFunc("show-autoscale-forecast", showAutoScaleForecast)
 
 }
 
@@ -42,6 +45,37 @@ func showPlatformActive(ctx context.Context, req *edgeproto.DebugRequest) string
 	return fmt.Sprintf("PlatformActive: %t", shepherd_common.ShepherdPlatformActive)
 }
 
+func showMetricsSpool(ctx context.Context, req *edgeproto.DebugRequest) string {
+	if metricsSpool == nil {
+		return "metrics spool is disabled"
+	}
+	return metricsSpool.ShowStats()
+}
+
+// Optional args is a cluster name to filter on
+func showAutoScaleForecast(ctx context.Context, req *edgeproto.DebugRequest) string {
+	if !*autoScalePredictive {
+		return "predictive auto scaling is disabled"
+	}
+	states := make(map[string]*AutoScaleForecastState)
+	workerMapMutex.Lock()
+	for _, worker := range workerMap {
+		if worker.autoScaler.policyName == "" {
+			continue
+		}
+		if req.Args != "" && req.Args != worker.clusterInstKey.ClusterKey.Name {
+			continue
+		}
+		states[worker.clusterInstKey.GetKeyString()] = worker.autoScaler.getForecastState()
+	}
+	workerMapMutex.Unlock()
+	out, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return err.Error()
+	}
+	return string(out)
+}
+
 func setIntervalFromDbg(ctx context.Context, scrapeInterval *time.Duration) error {
 	if settings.ShepherdAlertEvaluationInterval.TimeDuration() < *scrapeInterval {
 		return fmt.Errorf("evaluation interval %s cannot be less than scrape interval %s", settings.ShepherdAlertEvaluationInterval.TimeDuration().String(), scrapeInterval.String())
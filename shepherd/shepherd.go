@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -70,6 +71,17 @@ var chefServerPath = flag.String("chefServerPath", "", "Chef server path")
 var promScrapeInterval = flag.Duration("promScrapeInterval", defaultScrapeInterval, "Prometheus Scraping Interval")
 var haRole = flag.String("HARole", string(process.HARolePrimary), "HARole") // for info purposes and to distinguish nodes when running debug commands
 var thanosRecvAddr = flag.String("thanosRecvAddr", "", "Address of thanos receive API endpoint including port")
+var remoteWriteAddr = flag.String("remoteWriteAddr", "", "Prometheus remote write URL to also send metrics to, e.g. http://thanos-receive:19291/api/v1/receive; disabled if empty")
+var remoteWriteInterval = flag.Duration("remoteWriteInterval", 15*time.Second, "Interval at which metrics are sent to the remote write URL")
+var remoteWriteMaxBuffer = flag.Int("remoteWriteMaxBuffer", 100000, "Max number of samples buffered while the remote write URL is unreachable")
+var autoScalePredictive = flag.Bool("autoScalePredictive", false, "Scale clusters with an auto scale policy ahead of forecast load")
+var autoScaleForecastSamples = flag.Int("autoScaleForecastSamples", 20, "Number of recent stats samples used to forecast load for predictive auto scaling")
+var autoScaleForecastHorizon = flag.Duration("autoScaleForecastHorizon", 5*time.Minute, "How far ahead to forecast load for predictive auto scaling")
+var autoScaleForecastStabilization = flag.Duration("autoScaleForecastStabilization", time.Minute, "How long the forecast load must call for more nodes before predictive auto scaling scales up")
+var autoScaleForecastDir = flag.String("autoScaleForecastDir", "", "Directory to save the seasonal load profiles of predictive auto scaling in so that they survive restarts, i.e. /var/tmp/shepherd_forecast; kept in memory only if empty")
+var metricsSpoolDir = flag.String("metricsSpoolDir", "", "Directory to spool metrics in while the notify connection is down, i.e. /var/tmp/shepherd_metrics_spool; disabled if empty")
+var metricsSpoolMaxSize = flag.Int64("metricsSpoolMaxSize", 100*1024*1024, "Max size in bytes of spooled metrics, oldest metrics are dropped beyond this")
+var metricsSpoolMaxAge = flag.Duration("metricsSpoolMaxAge", 24*time.Hour, "Max age of spooled metrics, older metrics are dropped")
 
 var metricsScrapingInterval time.Duration
 
@@ -141,7 +153,7 @@ func appInstCb(ctx context.Context, old *edgeproto.AppInst, new *edgeproto.AppIn
 		myPlatform.VmAppChangedCallback(ctx, new, new.State)
 		if new.State == edgeproto.TrackedState_READY && !exists {
 			// Add/Create
-			stats := NewAppInstWorker(ctx, collectInterval, MetricSender.Update, new, myPlatform)
+			stats := NewAppInstWorker(ctx, collectInterval, sendMetric, new, myPlatform)
 			if stats != nil {
 				vmAppWorkerMap[mapKey] = stats
 				stats.Start(ctx)
@@ -196,7 +208,7 @@ func appInstCb(ctx context.Context, old *edgeproto.AppInst, new *edgeproto.AppIn
 		// not have an IP address yet. Although we don't have an IP, we do need the port
 		log.SpanLog(ctx, log.DebugLevelMetrics, "prometheus found", "prom port", port)
 		if !exists {
-			stats, err = NewClusterWorker(ctx, promAddress, port, metricsScrapingInterval, collectInterval, MetricSender.Update, &clusterInst, kubeNames, myPlatform)
+			stats, err = NewClusterWorker(ctx, promAddress, port, metricsScrapingInterval, collectInterval, sendMetric, &clusterInst, kubeNames, myPlatform)
 			if err == nil {
 				workerMap[mapKey] = stats
 				stats.Start(ctx)
@@ -223,6 +235,7 @@ func appInstDeletedCb(ctx context.Context, old *edgeproto.AppInst) {
 
 func clusterInstDeletedCb(ctx context.Context, old *edgeproto.ClusterInst) {
 	ChangeSinceLastPlatformStats = true
+	deleteSavedForecasts(ctx, &old.Key)
 }
 
 func clusterInstCb(ctx context.Context, old *edgeproto.ClusterInst, new *edgeproto.ClusterInst) {
@@ -248,7 +261,7 @@ func clusterInstCb(ctx context.Context, old *edgeproto.ClusterInst, new *edgepro
 	collectInterval := settings.ShepherdMetricsCollectionInterval.TimeDuration()
 	if new.State == edgeproto.TrackedState_READY {
 		log.SpanLog(ctx, log.DebugLevelMetrics, "New Docker cluster detected", "clustername", mapKey, "clusterInst", new)
-		stats, err := NewClusterWorker(ctx, "", 0, metricsScrapingInterval, collectInterval, MetricSender.Update, new, nil, myPlatform)
+		stats, err := NewClusterWorker(ctx, "", 0, metricsScrapingInterval, collectInterval, sendMetric, new, nil, myPlatform)
 		if err == nil {
 			workerMap[mapKey] = stats
 			stats.Start(ctx)
@@ -551,6 +564,20 @@ func start() {
 	// register to send metrics
 	MetricSender = notify.NewMetricSend()
 	notifyClient.RegisterSend(MetricSender)
+	if *metricsSpoolDir != "" {
+		// separate spool per cloudlet and HA role in case several
+		// shepherds run on the same host
+		spoolDir := filepath.Join(*metricsSpoolDir, cloudletKey.Organization+"_"+cloudletKey.Name+"_"+*haRole)
+		metricsSpool, err = NewMetricsSpool(ctx, spoolDir, *metricsSpoolMaxSize, *metricsSpoolMaxAge, MetricSender.Update)
+		if err != nil {
+			// metrics are still sent, just not spooled
+			log.SpanLog(ctx, log.DebugLevelInfo, "Failed to init metrics spool, continuing without it", "dir", spoolDir, "err", err)
+			metricsSpool = nil
+		}
+	}
+	if *autoScaleForecastDir != "" {
+		forecastDir = filepath.Join(*autoScaleForecastDir, cloudletKey.Organization+"_"+cloudletKey.Name+"_"+*haRole)
+	}
 	edgeproto.InitAlertCache(&AlertCache)
 	notifyClient.RegisterSendAlertCache(&AlertCache)
 	// register to send cloudletInfo, to receive appinst/clusterinst/cloudlet notifications from crm
@@ -627,8 +654,13 @@ func start() {
 	if err != nil {
 		log.FatalLog("Failed to initialize platform", "platformName", platformName, "err", err)
 	}
+	if *remoteWriteAddr != "" {
+		remoteWriter = NewRemoteWriter(*remoteWriteAddr, *region, *remoteWriteInterval, *remoteWriteMaxBuffer)
+		remoteWriter.Start()
+		log.SpanLog(ctx, log.DebugLevelMetrics, "Started metrics remote write", "url", *remoteWriteAddr)
+	}
 	// LB metrics are not supported in fake mode
-	InitProxyScraper(metricsScrapingInterval, settings.ShepherdMetricsCollectionInterval.TimeDuration(), MetricSender.Update)
+	InitProxyScraper(metricsScrapingInterval, settings.ShepherdMetricsCollectionInterval.TimeDuration(), sendMetric)
 	if pf.GetType(*platformName) != "fake" {
 		StartProxyScraper(stopCh)
 	}
@@ -661,6 +693,9 @@ func stop() {
 	}
 	// stop cloudlet workers
 	close(stopCh)
+	if remoteWriter != nil {
+		remoteWriter.Stop()
+	}
 	if ctrlConn != nil {
 		ctrlConn.Close()
 	}
@@ -673,6 +708,23 @@ func (s *sendAllRecv) RecvAllStart() {}
 
 func (s *sendAllRecv) RecvAllEnd(ctx context.Context) {
 	targetFileWorkers.NeedsWork(ctx, targetsFileWorkerKey)
+	// notify connection is up, send any metrics spooled while it was down
+	if metricsSpool != nil {
+		metricsSpool.Replay(ctx)
+	}
+}
+
+// Metric send func that sends the metric over notify, spooling it
+// if the notify connection is down, and also queues it for remote
+// write if enabled.
+func sendMetric(ctx context.Context, metric *edgeproto.Metric) bool {
+	if remoteWriter != nil {
+		remoteWriter.AddMetric(metric)
+	}
+	if metricsSpool != nil {
+		return metricsSpool.Send(ctx, metric)
+	}
+	return MetricSender.Update(ctx, metric)
 }
 
 // update active connection alerts for cloudlet prometheus
@@ -52,6 +52,8 @@ type AppMetrics struct {
 	// Disk is bytes used
 	Disk   uint64
 	DiskTS *types.Timestamp
+	// MemPercent is a percentage of cluster memory, used for auto scaling
+	MemPercent float64
 }
 
 type ClusterMetrics struct {
@@ -26,7 +26,7 @@ fi
 echo "Setting up infra Vault region $REGION"
 
 # autoprov approle
-# Just need access to influx db credentials
+# Needs access to influx db credentials and cloudlets under maintenance
 cat > /tmp/autoprov-pol.hcl <<EOF
 path "auth/approle/login" {
   capabilities = [ "create", "read" ]
@@ -36,6 +36,10 @@ path "secret/data/+/accounts/influxdb" {
   capabilities = [ "read" ]
 }
 
+path "secret/data/$REGION/maintenancewindows" {
+  capabilities = [ "read" ]
+}
+
 path "pki-regional/issue/$REGION" {
   capabilities = [ "read", "update" ]
 }
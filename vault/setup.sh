@@ -83,6 +83,22 @@ path "secret/data/kafka/*" {
 path "secret/data/federation/*" {
   capabilities = [ "create", "update", "delete", "read" ]
 }
+
+path "secret/data/accounts/alertreceivers/*" {
+  capabilities = [ "create", "update", "delete", "read" ]
+}
+
+path "secret/data/accounts/eventwebhooks/*" {
+  capabilities = [ "create", "update", "delete", "read" ]
+}
+
+path "secret/data/accounts/idps/*" {
+  capabilities = [ "create", "update", "delete", "read" ]
+}
+
+path "secret/data/+/maintenancewindows" {
+  capabilities = [ "create", "update", "read" ]
+}
 EOF
 
 vault policy write mcorm /tmp/mcorm-pol.hcl
@@ -811,7 +811,11 @@ func (v *VMPlatform) UpdateAppInst(ctx context.Context, clusterInst *edgeproto.C
 
 	switch deployment := app.Deployment; deployment {
 	case cloudcommon.DeploymentTypeKubernetes:
-		return k8smgmt.UpdateAppInst(ctx, v.VMProperties.CommonPf.PlatformConfig.AccessApi, client, names, app, appInst, flavor)
+		err = k8smgmt.UpdateAppInst(ctx, v.VMProperties.CommonPf.PlatformConfig.AccessApi, client, names, app, appInst, flavor)
+		if err != nil {
+			return err
+		}
+		return infracommon.RestoreAppDeploymentReplicas(ctx, client, names, app)
 	case cloudcommon.DeploymentTypeDocker:
 		err = seedDockerSecrets(ctx, client, clusterInst, names, v.VMProperties.CommonPf.PlatformConfig.AccessApi)
 		if err != nil {
@@ -819,7 +823,11 @@ func (v *VMPlatform) UpdateAppInst(ctx context.Context, clusterInst *edgeproto.C
 		}
 		return dockermgmt.UpdateAppInst(ctx, v.VMProperties.CommonPf.PlatformConfig.AccessApi, client, app, appInst)
 	case cloudcommon.DeploymentTypeHelm:
-		return k8smgmt.UpdateHelmAppInst(ctx, client, names, app, appInst)
+		err = k8smgmt.UpdateHelmAppInst(ctx, client, names, app, appInst)
+		if err != nil {
+			return err
+		}
+		return infracommon.RestoreAppDeploymentReplicas(ctx, client, names, app)
 
 	default:
 		return fmt.Errorf("UpdateAppInst not supported for deployment: %s", app.Deployment)